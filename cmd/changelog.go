@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/changelog"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// runChangelog writes the contract changes between two versions of a mock
+// suite, for release notes.
+func runChangelog(args []string) {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, i18n.T("Write the changelog as JSON instead of Markdown"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy changelog [options] <old_file_or_directory> <new_file_or_directory>")
+		fmt.Println("\n" + i18n.T("Lists the endpoints added, removed and changed between two versions of a mock suite."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	old, err := loadEndpoints(fs.Arg(0))
+	if err != nil {
+		fmt.Println(i18n.T("Error loading endpoints: %v", err))
+		os.Exit(1)
+	}
+	new, err := loadEndpoints(fs.Arg(1))
+	if err != nil {
+		fmt.Println(i18n.T("Error loading endpoints: %v", err))
+		os.Exit(1)
+	}
+
+	log := changelog.Diff(old, new)
+	if *asJSON {
+		err = log.WriteJSON(os.Stdout)
+	} else {
+		err = log.WriteMarkdown(os.Stdout)
+	}
+	if err != nil {
+		fmt.Println(i18n.T("Error writing changelog: %v", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/pact"
+	"github.com/pretodev/anansi-proxy/internal/wiremock"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// runExport converts .apimock files into the stubs and contracts of other
+// tools.
+func runExport(args []string) {
+	if len(args) == 0 || args[0] != "wiremock" && args[0] != "pact" {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy export wiremock [options] <file_or_directory>...")
+		fmt.Println("  anansi-proxy export pact --consumer <name> --provider <name> [options] <file_or_directory>...")
+		os.Exit(1)
+	}
+	format := args[0]
+
+	fs := flag.NewFlagSet("export "+format, flag.ExitOnError)
+	out := fs.String("out", "", i18n.T("File the output is written to (default: standard output)"))
+	var consumer, provider *string
+	if format == "pact" {
+		consumer = fs.String("consumer", "", i18n.T("Name of the consumer the contract is for"))
+		provider = fs.String("provider", "", i18n.T("Name of the provider the contract is verified against"))
+	}
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		if format == "pact" {
+			fmt.Println("  anansi-proxy export pact --consumer <name> --provider <name> [options] <file_or_directory>...")
+			fmt.Println("\n" + i18n.T("Writes a Pact consumer contract with an interaction per response of the .apimock files."))
+		} else {
+			fmt.Println("  anansi-proxy export wiremock [options] <file_or_directory>...")
+			fmt.Println("\n" + i18n.T("Writes a WireMock mappings document with a stub per response of the .apimock files."))
+		}
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if fs.NArg() == 0 || format == "pact" && (*consumer == "" || *provider == "") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	_, files := parseASTs(fs.Args())
+
+	var write func(io.Writer) error
+	var warnings []string
+	if format == "pact" {
+		var contract *pact.Pact
+		contract, warnings = pact.Generate(*consumer, *provider, files)
+		write = contract.WriteJSON
+	} else {
+		var mappings *wiremock.Mappings
+		mappings, warnings = wiremock.Export(files)
+		write = mappings.WriteJSON
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, i18n.T("Warning: %s", warning))
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Println(i18n.T("Error writing %s: %v", *out, err))
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w); err != nil {
+		fmt.Println(i18n.T("Error writing %s: %v", *out, err))
+		os.Exit(1)
+	}
+}
+
+// parseASTs parses the .apimock files found in paths, exiting on the first
+// broken one. It returns the paths of the files and their syntax trees.
+func parseASTs(paths []string) ([]string, []*apimock.APIMockFile) {
+	found, err := discovery.FindAPIMockFiles(paths...)
+	if err != nil {
+		fmt.Println(i18n.T("Error finding .apimock files: %v", err))
+		os.Exit(1)
+	}
+	var files []*apimock.APIMockFile
+	for _, path := range found {
+		parser, err := apimock.NewParser(path)
+		if err != nil {
+			fmt.Println(i18n.T("Error reading %s: %v", path, err))
+			os.Exit(1)
+		}
+		file, err := parser.Parse()
+		if err != nil {
+			fmt.Println(apimock.Explain(err))
+			os.Exit(1)
+		}
+		files = append(files, file)
+	}
+	return found, files
+}
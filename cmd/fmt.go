@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// runFmt rewrites .apimock files in the format Marshal produces: properties
+// and query parameters sorted, path continuations indented by two spaces and
+// one blank line between sections. Like gofmt, it prints the formatted files
+// unless told to write, list or check them.
+func runFmt(args []string) {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, i18n.T("Write the formatted source back to the files instead of printing it"))
+	list := fs.Bool("l", false, i18n.T("List the files whose formatting differs"))
+	check := fs.Bool("check", false, i18n.T("List the files whose formatting differs and exit with an error if there are any, without changing them"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy fmt [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Rewrite .apimock files in the canonical format"))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println(i18n.T("Error: at least one file or directory path is required."))
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	paths, fromStdin := splitStdin(fs.Args())
+	failed := false
+	if fromStdin {
+		content, err := io.ReadAll(os.Stdin)
+		if err == nil {
+			content, err = formatSource(stdinName, content)
+		}
+		if err != nil {
+			fmt.Println(i18n.T("Error formatting %s: %v", stdinName, err))
+			failed = true
+		} else {
+			os.Stdout.Write(content)
+		}
+	}
+
+	var filePaths []string
+	if len(paths) > 0 {
+		var err error
+		if filePaths, err = discovery.FindAPIMockFiles(paths...); err != nil {
+			fmt.Println(i18n.T("Error finding .apimock files: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	if *check {
+		*write, *list = false, true
+	}
+	unformatted := 0
+	for _, path := range filePaths {
+		changed, err := formatFile(path, *write, *list)
+		if err != nil {
+			fmt.Println(i18n.T("Error formatting %s: %v", path, err))
+			failed = true
+		}
+		if changed {
+			unformatted++
+		}
+	}
+	if *check && unformatted > 0 {
+		fmt.Println(i18n.T("%d file(s) need formatting; run anansi-proxy fmt -w", unformatted))
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// formatFile formats the file at path and reports whether its content
+// differs from the formatted source.
+func formatFile(path string, write, list bool) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	formatted, err := formatSource(path, content)
+	if err != nil {
+		return false, err
+	}
+
+	changed := !bytes.Equal(content, formatted)
+	if list && changed {
+		fmt.Println(path)
+	}
+	if write && changed {
+		return true, os.WriteFile(path, formatted, info.Mode().Perm())
+	}
+	if !write && !list {
+		_, err = os.Stdout.Write(formatted)
+	}
+	return changed, err
+}
+
+// formatSource parses .apimock source and renders it back canonically. Files
+// with @include directives or ${NAME} references are refused, since the
+// syntax tree holds the included sections and the values of the variables,
+// and rendering it would inline them.
+func formatSource(name string, content []byte) ([]byte, error) {
+	ast, err := apimock.NewParserFromBytes(name, content).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if len(ast.Includes) > 0 {
+		return nil, errors.New(i18n.T("files with %s directives cannot be formatted", apimock.IncludeDirective))
+	}
+	if len(ast.Env) > 0 {
+		return nil, errors.New(i18n.T("files with ${NAME} environment variables cannot be formatted"))
+	}
+	return ast.Marshal()
+}
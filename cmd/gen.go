@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pretodev/anansi-proxy/internal/corpus"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// runGen generates artifacts for tool authors. Only the corpus generator is
+// available for now.
+func runGen(args []string) {
+	if len(args) == 0 || args[0] != "corpus" {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy gen corpus [options]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("gen corpus", flag.ExitOnError)
+	count := fs.Int("n", 100, i18n.T("Number of files to generate"))
+	seed := fs.Int64("seed", 1, i18n.T("Seed of the random generator; the same seed yields the same corpus"))
+	out := fs.String("out", "corpus", i18n.T("Directory the files are written to"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy gen corpus [options]")
+		fmt.Println("\n" + i18n.T("Writes random, valid .apimock files for testing tools that read the format."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Println(i18n.T("Error creating output directory: %v", err))
+		os.Exit(1)
+	}
+
+	gen := corpus.New(*seed)
+	for i := 1; i <= *count; i++ {
+		sources, err := gen.Split(gen.File(), fmt.Sprintf("%04d.apimock", i))
+		if err != nil {
+			fmt.Println(i18n.T("Error generating file %d: %v", i, err))
+			os.Exit(1)
+		}
+		for name, source := range sources {
+			path := filepath.Join(*out, name)
+			if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+				fmt.Println(i18n.T("Error writing %s: %v", path, err))
+				os.Exit(1)
+			}
+		}
+	}
+
+	fmt.Println(i18n.T("Generated %d .apimock file(s) in %s", *count, *out))
+}
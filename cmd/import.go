@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pretodev/anansi-proxy/internal/har"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/importer"
+	"github.com/pretodev/anansi-proxy/internal/wiremock"
+)
+
+// runImport converts recordings and stubs of other tools into .apimock files.
+func runImport(args []string) {
+	if len(args) == 0 || args[0] != "har" && args[0] != "wiremock" {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy import har [options] <session.har>")
+		fmt.Println("  anansi-proxy import wiremock [options] <mappings.json_or_directory>")
+		os.Exit(1)
+	}
+	format := args[0]
+
+	fs := flag.NewFlagSet("import "+format, flag.ExitOnError)
+	out := fs.String("out", "mocks", i18n.T("Directory the files are written to"))
+	force := fs.Bool("force", false, i18n.T("Overwrite existing files"))
+	var host *string
+	if format == "har" {
+		host = fs.String("host", "", i18n.T("Only import the requests sent to this host"))
+	}
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		if format == "har" {
+			fmt.Println("  anansi-proxy import har [options] <session.har>")
+			fmt.Println("\n" + i18n.T("Writes one .apimock file per method and path recorded in a HAR file, with a response per status code."))
+		} else {
+			fmt.Println("  anansi-proxy import wiremock [options] <mappings.json_or_directory>")
+			fmt.Println("\n" + i18n.T("Writes one .apimock file per request pattern of WireMock stub mappings, with a response per mapping."))
+		}
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var mocks []importer.Mock
+	var from int
+	if format == "har" {
+		mocks, from = importHAR(fs.Arg(0), *host)
+	} else {
+		mocks, from = importWireMock(fs.Arg(0))
+	}
+	if len(mocks) == 0 {
+		fmt.Println(i18n.T("No requests to import in %s", fs.Arg(0)))
+		os.Exit(1)
+	}
+
+	if err := importer.Write(mocks, *out, *force); err != nil {
+		if errors.Is(err, importer.ErrExists) {
+			fmt.Println(i18n.T("%v; use --force to overwrite it", err))
+		} else {
+			fmt.Println(i18n.T("Error writing files: %v", err))
+		}
+		os.Exit(1)
+	}
+	if format == "har" {
+		fmt.Println(i18n.T("Imported %d endpoint(s) from %d request(s) into %s", len(mocks), from, *out))
+	} else {
+		fmt.Println(i18n.T("Imported %d endpoint(s) from %d mapping(s) into %s", len(mocks), from, *out))
+	}
+}
+
+// importHAR converts the recording at path, returning the mocks and the number
+// of recorded requests.
+func importHAR(path, host string) ([]importer.Mock, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println(i18n.T("Error reading %s: %v", path, err))
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	recording, err := har.Read(f)
+	if err != nil {
+		fmt.Println(i18n.T("Error reading %s: %v", path, err))
+		os.Exit(1)
+	}
+	return har.Convert(recording, host), len(recording.Log.Entries)
+}
+
+// importWireMock converts the mappings document at path, or the JSON files of
+// a WireMock mappings directory, returning the mocks and the number of
+// mappings. Stub features .apimock files cannot express are listed on
+// standard error.
+func importWireMock(path string) ([]importer.Mock, int) {
+	files := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		files, _ = filepath.Glob(filepath.Join(path, "*.json"))
+	}
+
+	all := &wiremock.Mappings{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Println(i18n.T("Error reading %s: %v", file, err))
+			os.Exit(1)
+		}
+		m, err := wiremock.Read(f)
+		f.Close()
+		if err != nil {
+			fmt.Println(i18n.T("Error reading %s: %v", file, err))
+			os.Exit(1)
+		}
+		all.Mappings = append(all.Mappings, m.Mappings...)
+	}
+
+	mocks, warnings := wiremock.Import(all)
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, i18n.T("Warning: %s", warning))
+	}
+	return mocks, len(all.Mappings)
+}
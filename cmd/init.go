@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/scaffold"
+)
+
+// runInit writes a starter mock project. On a terminal it asks for the values
+// not given as flags.
+func runInit(args []string) {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := flags.String("dir", "mocks", i18n.T("Directory the example mocks are written to"))
+	port := flags.Int("port", 8977, i18n.T("Port the Makefile target serves the mocks on"))
+	force := flags.Bool("force", false, i18n.T("Overwrite existing example mocks"))
+	yes := flags.Bool("y", false, i18n.T("Use the defaults instead of asking"))
+	addLangFlag(flags)
+	flags.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy init [options]")
+		fmt.Println("\n" + i18n.T("Create example mocks and a Makefile target to serve them"))
+		fmt.Println("\n" + i18n.T("Options:"))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	if !*yes && term.IsTerminal(os.Stdin.Fd()) {
+		in := bufio.NewReader(os.Stdin)
+		if !given["dir"] {
+			*dir = ask(in, i18n.T("Directory for the mocks"), *dir)
+		}
+		for !given["port"] {
+			answer := ask(in, i18n.T("Port"), strconv.Itoa(*port))
+			if p, err := strconv.Atoi(answer); err == nil && p > 0 && p <= 65535 {
+				*port = p
+				break
+			}
+			fmt.Println(i18n.T("Invalid port %q.", answer))
+		}
+	}
+
+	written, err := scaffold.Write(scaffold.Options{Dir: *dir, Port: *port, Makefile: "Makefile", Force: *force})
+	for _, path := range written {
+		fmt.Println(i18n.T("Created %s", path))
+	}
+	if err != nil {
+		fmt.Println(i18n.T("Error creating the project: %v", err))
+		if errors.Is(err, fs.ErrExist) {
+			fmt.Println(i18n.T("Run with --force to overwrite the example mocks."))
+		}
+		os.Exit(1)
+	}
+
+	fmt.Println("\n" + i18n.T("Start the mocks with:"))
+	fmt.Printf("  make %s\n", scaffold.MakeTarget)
+	fmt.Printf("  anansi-proxy --port %d %s\n", *port, *dir)
+}
+
+// ask prompts for a value, returning def when the answer is empty.
+func ask(in *bufio.Reader, prompt, def string) string {
+	fmt.Printf("%s [%s]: ", prompt, def)
+	answer, _ := in.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
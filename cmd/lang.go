@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// setupLanguage selects the language of the CLI messages from the --lang
+// argument or the environment. It runs before any flag is defined so that
+// flag descriptions are translated too.
+func setupLanguage(args []string) {
+	tag := ""
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "lang" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		tag = value
+	}
+	i18n.SetLanguage(i18n.Resolve(tag))
+}
+
+// addLangFlag registers --lang on fs. Its value is applied by setupLanguage.
+func addLangFlag(fs *flag.FlagSet) {
+	fs.String("lang", "", i18n.T("Language of the messages (%s); defaults to LANG", strings.Join(i18n.Languages(), ", ")))
+}
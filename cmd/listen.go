@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/server"
+)
+
+// project is a set of mocks served on a listener of its own, with its own
+// sessions, call counts and caches.
+type project struct {
+	addr      string // as given to --listen; empty for the positional paths
+	endpoints []*endpoint.EndpointWithFile
+	broken    []*endpoint.FileError // files left out because they failed to load
+	ln        net.Listener
+}
+
+// listenProject loads the mocks of a --listen spec, PORT=PATH or
+// HOST:PORT=PATH, and opens its listener. host is used when the spec has
+// none.
+func listenProject(spec, host string) (*project, error) {
+	addr, path, ok := strings.Cut(spec, "=")
+	if !ok || addr == "" || path == "" {
+		return nil, fmt.Errorf("expected PORT=PATH or HOST:PORT=PATH")
+	}
+
+	portText := addr
+	if strings.Contains(addr, ":") {
+		var err error
+		if host, portText, err = net.SplitHostPort(addr); err != nil {
+			return nil, err
+		}
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portText)
+	}
+
+	filePaths, err := discovery.FindAPIMockFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, broken := parseFiles(filePaths)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no valid endpoints found in %s", path)
+	}
+	ln, err := server.Listen(host, port, "")
+	if err != nil {
+		return nil, err
+	}
+	return &project{addr: addr, endpoints: endpoints, broken: broken, ln: ln}, nil
+}
+
+// print lists the endpoints the project serves.
+func (p *project) print() {
+	if p.addr == "" {
+		fmt.Println("\n" + i18n.T("Server ready! Serving %d endpoint(s):", len(p.endpoints)))
+	} else {
+		fmt.Println("\n" + i18n.T("Serving %d endpoint(s) on %s:", len(p.endpoints), p.ln.Addr()))
+	}
+
+	for i, ep := range p.endpoints {
+		responses := ep.Schema.SliceResponses()
+		if ep.Schema.Upstream != nil {
+			fmt.Printf("  [%d] %s -> proxy %s\n", i, ep.Schema.Route, ep.Schema.Upstream.URL)
+		} else if len(responses) > 0 {
+			firstResponse := responses[0]
+			fmt.Printf("  [%d] %s -> [%d] %s\n", i, ep.Schema.Route, firstResponse.StatusCode, firstResponse.Title)
+		} else {
+			fmt.Printf("  [%d] %s -> %s\n", i, ep.Schema.Route, i18n.T("(no responses)"))
+		}
+	}
+}
@@ -1,105 +1,660 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
+	"net"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/charmbracelet/x/term"
+	"github.com/pretodev/anansi-proxy/internal/authmock"
 	"github.com/pretodev/anansi-proxy/internal/discovery"
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
 	"github.com/pretodev/anansi-proxy/internal/server"
 	"github.com/pretodev/anansi-proxy/internal/state"
+	"github.com/pretodev/anansi-proxy/internal/stats"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
 	"github.com/pretodev/anansi-proxy/internal/ui"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
 )
 
 func main() {
+	setupLanguage(os.Args[1:])
+
+	if len(os.Args) > 1 {
+		for _, cmd := range commands() {
+			if os.Args[1] == cmd.name {
+				cmd.run(os.Args[2:])
+				return
+			}
+		}
+		switch os.Args[1] {
+		case "help", "-h", "-help", "--help":
+			printUsage()
+			return
+		}
+	}
+
+	// Without a command the arguments are served, as with serve
+	runServe(os.Args[1:])
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+func commands() []command {
+	return []command{
+		{"serve", i18n.T("Serve mocks over HTTP (the default command)"), runServe},
+		{"init", i18n.T("Create example mocks and a Makefile target to serve them"), runInit},
+		{"validate", i18n.T("Check that .apimock files load, listing the errors of those that do not"), runValidate},
+		{"parse", i18n.T("Print the syntax tree of an .apimock file as JSON"), runParse},
+		{"fmt", i18n.T("Rewrite .apimock files in the canonical format"), runFmt},
+		{"owners", i18n.T("Report which team owns each mocked route"), runOwners},
+		{"changelog", i18n.T("List the contract changes between two versions of a mock suite"), runChangelog},
+		{"gen", i18n.T("Generate random .apimock files for testing tools"), runGen},
+		{"import", i18n.T("Convert HAR recordings and WireMock stubs into .apimock files"), runImport},
+		{"export", i18n.T("Convert .apimock files into WireMock stubs or Pact contracts"), runExport},
+		{"verify", i18n.T("Replay the mocked requests against a live API and report drift"), runVerify},
+		{"replay", i18n.T("Send the requests of a recorded history again and report the responses that differ"), runReplay},
+		{"schema", i18n.T("Infer the JSON Schema of request bodies from the examples of a mock"), runSchema},
+	}
+}
+
+// printUsage lists the commands of the CLI.
+func printUsage() {
+	fmt.Println(i18n.T("Usage:"))
+	fmt.Println("  anansi-proxy <command> [arguments]")
+	fmt.Println("  anansi-proxy [options] <file_or_directory>...")
+	fmt.Println("\n" + i18n.T("Commands:"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	w.Flush()
+	fmt.Println("\n" + i18n.T("Run 'anansi-proxy <command> -h' for the options of a command."))
+}
+
+// runServe serves the mocks found in args.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+
 	var port int
+	var host string
+	var socket string
 	var interactive bool
+	var noAltScreen bool
+	var compare string
+	var report string
+	var compress bool
+	var freeze bool
+	var strictXSD bool
+	var strict bool
+	var fallbackToMock bool
+	var upstreamTimeout time.Duration
+	var probeUpstreams string
+	var authMock bool
+	var chaosRate float64
+	var chaosSeed int64
+	var seed int64
+	var timeouts server.Timeouts
+	var inline stringList
+	var failOnDraft bool
+	var listens stringList
+	var failOnBroken bool
+	var profile string
+	var validateResponses string
+	var otlpEndpoint string
+	var historySize int
+	var responseHeader string
 
-	flag.IntVar(&port, "port", 8977, "Port number for the HTTP server")
-	flag.IntVar(&port, "p", 8977, "Port number for the HTTP server (shorthand)")
-	flag.BoolVar(&interactive, "it", false, "Interactive mode - display response selection UI")
-	flag.Parse()
-
-	// Get paths from positional arguments
-	paths := flag.Args()
-	if len(paths) == 0 {
-		fmt.Println("Error: at least one file or directory path is required.")
-		fmt.Println("\nUsage:")
-		fmt.Println("  anansi-proxy [options] <file_or_directory>...")
-		fmt.Println("\nExamples:")
+	fs.IntVar(&port, "port", 8977, i18n.T("Port number for the HTTP server"))
+	fs.IntVar(&port, "p", 8977, i18n.T("Port number for the HTTP server (shorthand)"))
+	fs.StringVar(&host, "host", "", i18n.T("Address to bind, such as 127.0.0.1 or 0.0.0.0 (default: all interfaces)"))
+	fs.StringVar(&socket, "unix-socket", "", i18n.T("Listen on this unix domain socket instead of a TCP port"))
+	fs.BoolVar(&interactive, "it", false, i18n.T("Interactive mode - display response selection UI"))
+	fs.BoolVar(&noAltScreen, "no-altscreen", false, i18n.T("Print numbered choices and read the selection from stdin instead of drawing the interactive UI"))
+	fs.StringVar(&compare, "compare", "", i18n.T("Baseline file or directory evaluated in the background to report behavioral diffs"))
+	fs.StringVar(&report, "report", "", i18n.T("Write a request summary to this file on exit (.md for Markdown, JSON otherwise)"))
+	fs.BoolVar(&compress, "compress", false, i18n.T("Compress responses with gzip, deflate or brotli when the client accepts it"))
+	fs.BoolVar(&freeze, "freeze-random", false, i18n.T("Fill time placeholders and session IDs with fixed values, and seed the random functions, so responses are identical from run to run"))
+	fs.BoolVar(&strict, "strict", false, i18n.T("Answer 500 when the conditions or placeholders of a response fail to evaluate, instead of skipping the conditions and sending the placeholders as written"))
+	fs.BoolVar(&fallbackToMock, "fallback-to-mock", false, i18n.T("Answer proxied requests with the mocked response when the upstream cannot be reached, answers 5xx or times out"))
+	fs.DurationVar(&upstreamTimeout, "upstream-timeout", server.DefaultUpstreamTimeout, i18n.T("Time given to an upstream to reply before --fallback-to-mock answers with the mock (0 = no limit)"))
+	fs.StringVar(&probeUpstreams, "probe-upstreams", "", i18n.T("Check at startup that the upstreams of proxy sections answer: warn prints the unreachable ones, fail exits, mock answers their routes with the mocks"))
+	fs.BoolVar(&strictXSD, "strict-xsd", false, i18n.T("Fail to load endpoints with XML schemas this build cannot validate"))
+	fs.BoolVar(&authMock, "auth-mock", false, i18n.T("Serve a mock OAuth2/OpenID Connect provider at /token, /authorize and /.well-known"))
+	fs.Float64Var(&chaosRate, "chaos", 0, i18n.T("Fraction of responses to break with connection resets, truncated or garbled bodies, extreme latency or 5xx statuses"))
+	fs.Int64Var(&chaosSeed, "chaos-seed", 0, i18n.T("Seed for --chaos faults, to reproduce a run (default: random)"))
+	fs.Int64Var(&seed, "seed", 0, i18n.T("Seed for the random functions of placeholders, such as .random_int, .uuid and .random_sticky, to reproduce a run (default: random)"))
+	fs.DurationVar(&timeouts.Read, "read-timeout", 0, i18n.T("Maximum duration for reading a request, including its body (0 = no limit)"))
+	fs.DurationVar(&timeouts.Write, "write-timeout", 0, i18n.T("Maximum duration for writing a response (0 = no limit)"))
+	fs.DurationVar(&timeouts.Idle, "idle-timeout", 0, i18n.T("Maximum time an idle keep-alive connection is kept open (0 = no limit)"))
+	fs.DurationVar(&timeouts.Shutdown, "shutdown-timeout", server.DefaultShutdownTimeout, i18n.T("Time given to in-flight requests to finish when the server stops"))
+	fs.BoolVar(&failOnDraft, "fail-on-draft", false, i18n.T("Exit with an error when a response is a draft (its description starts with TODO)"))
+	fs.BoolVar(&failOnBroken, "fail-on-broken", false, i18n.T("Exit with an error when an .apimock file fails to load, instead of serving the others"))
+	fs.StringVar(&profile, "profile", "", i18n.T("Response profile to serve, such as outage; switch it at runtime with PUT /_admin/profile"))
+	fs.StringVar(&validateResponses, "validate-responses", "", i18n.T("Check response bodies against the schema of their Schema property: log prints mismatches, error answers 500 instead"))
+	fs.StringVar(&otlpEndpoint, "otlp-endpoint", otlpEndpointFromEnv(), i18n.T("Export request spans to this OpenTelemetry collector over OTLP/HTTP, such as http://localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)"))
+	fs.IntVar(&historySize, "history", server.DefaultHistorySize, i18n.T("Number of requests kept per route for GET /_admin/history and the interactive UI (0 = none)"))
+	fs.StringVar(&responseHeader, "response-header", server.DefaultResponseHeader, i18n.T("Request header naming the response to serve by status code or title, such as 404 (empty = off)"))
+	fs.Var(&listens, "listen", i18n.T("Serve the mocks of a file or directory on a port of their own, as PORT=PATH or HOST:PORT=PATH; can be repeated"))
+	fs.Var(&inline, "inline", i18n.T("Serve a one-line mock such as 'GET /ping -> 200 {\"ok\":true}'; can be repeated"))
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy [serve] [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Examples:"))
 		fmt.Println("  anansi-proxy ./docs/example/simple.apimock")
 		fmt.Println("  anansi-proxy ./docs/example/simple.apimock ./docs/example/xml.apimock")
 		fmt.Println("  anansi-proxy ./docs/example")
-		fmt.Println("\nOptions:")
-		flag.PrintDefaults()
-		os.Exit(1)
+		fmt.Println(`  anansi-proxy --inline 'GET /ping -> 200 {"ok":true}'`)
+		fmt.Println("  anansi-proxy --listen 8977=./payments --listen 8978=./users")
+		fmt.Println("  generate-mock | anansi-proxy -")
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+		fmt.Println()
+		printUsage()
 	}
+	addLangFlag(fs)
+	fs.Parse(args)
 
-	filePaths, err := discovery.FindAPIMockFiles(paths...)
+	endpoint.SetStrictXSD(strictXSD)
+	if seed != 0 {
+		apimock.SeedRandom(seed)
+	}
+	responseValidation, err := server.ParseResponseValidation(validateResponses)
 	if err != nil {
-		fmt.Printf("Error finding .apimock files: %v\n", err)
+		fmt.Println(i18n.T("Error: unknown --validate-responses mode %q (expected log or error)", validateResponses))
 		os.Exit(1)
 	}
+	upstreamProbe, err := server.ParseUpstreamProbe(probeUpstreams)
+	if err != nil {
+		fmt.Println(i18n.T("Error: unknown --probe-upstreams mode %q (expected warn, fail or mock)", probeUpstreams))
+		os.Exit(1)
+	}
+
+	// The linear selector is an interactive mode of its own
+	interactive = interactive || noAltScreen
+
+	// Get paths from positional arguments
+	paths, fromStdin := splitStdin(fs.Args())
+	mainProject := len(paths) > 0 || fromStdin || len(inline) > 0
+	if !mainProject && len(listens) == 0 {
+		fmt.Println(i18n.T("Error: at least one file or directory path is required.") + "\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var filePaths []string
+	if len(paths) > 0 {
+		var err error
+		filePaths, err = discovery.FindAPIMockFiles(paths...)
+		if err != nil {
+			fmt.Println(i18n.T("Error finding .apimock files: %v", err))
+			os.Exit(1)
+		}
+	}
 
-	if len(filePaths) > 0 && interactive {
-		fmt.Println("Warning: Interactive mode is not supported when multiple files are provided. Defaulting to non-interactive mode.")
+	if fromStdin && interactive {
+		fmt.Println(i18n.T("Warning: Interactive mode is not supported when reading mocks from standard input. Defaulting to non-interactive mode."))
 		interactive = false
 	}
 
-	fmt.Printf("Found %d .apimock file(s)\n", len(filePaths))
+	if len(listens) > 0 && interactive {
+		fmt.Println(i18n.T("Warning: Interactive mode is not supported with --listen. Defaulting to non-interactive mode."))
+		interactive = false
+	}
+	if len(filePaths)+len(inline) > 1 && noAltScreen {
+		fmt.Println(i18n.T("Warning: --no-altscreen is not supported when multiple files are provided. Defaulting to non-interactive mode."))
+		interactive = false
+	}
 
-	endpoints, err := endpoint.ParseAPIMockFiles(filePaths...)
-	if err != nil {
-		fmt.Printf("Error parsing files: %v\n", err)
-		os.Exit(1)
+	var endpoints []*endpoint.EndpointWithFile
+	var broken []*endpoint.FileError
+	if len(filePaths) > 0 {
+		fmt.Println(i18n.T("Found %d .apimock file(s)", len(filePaths)))
+		endpoints, broken = parseFiles(filePaths)
 	}
 
-	if len(endpoints) == 0 {
-		fmt.Println("Error: no valid endpoints found")
+	if fromStdin {
+		ep, err := readStdin()
+		if err != nil {
+			fmt.Println(i18n.T("Error parsing standard input: %v", err))
+			os.Exit(1)
+		}
+		endpoints = append(endpoints, ep)
+	}
+
+	for _, spec := range inline {
+		schema, err := endpoint.ParseInline(spec)
+		if err != nil {
+			fmt.Println(i18n.T("Error parsing --inline: %v", err))
+			os.Exit(1)
+		}
+		endpoints = append(endpoints, &endpoint.EndpointWithFile{Schema: schema, FilePath: endpoint.InlineFilePath})
+	}
+
+	var projects []*project
+	if mainProject {
+		if len(endpoints) == 0 {
+			fmt.Println(i18n.T("Error: no valid endpoints found"))
+			os.Exit(1)
+		}
+		ln, err := server.Listen(host, port, socket)
+		if err != nil {
+			fmt.Println(i18n.T("HTTP server error: %v", err))
+			os.Exit(1)
+		}
+		projects = append(projects, &project{endpoints: endpoints, broken: broken, ln: ln})
+	}
+	for _, spec := range listens {
+		p, err := listenProject(spec, host)
+		if err != nil {
+			fmt.Println(i18n.T("Error in --listen %s: %v", spec, err))
+			os.Exit(1)
+		}
+		projects = append(projects, p)
+	}
+
+	var all []*endpoint.EndpointWithFile
+	brokenFiles := 0
+	for _, p := range projects {
+		all = append(all, p.endpoints...)
+		brokenFiles += len(p.broken)
+	}
+	if brokenFiles > 0 && failOnBroken {
+		fmt.Println(i18n.T("Error: %d file(s) failed to load and --fail-on-broken is set", brokenFiles))
 		os.Exit(1)
 	}
+	if drafts := printDrafts(all); drafts > 0 && failOnDraft {
+		fmt.Println(i18n.T("Error: %d draft response(s) found and --fail-on-draft is set", drafts))
+		os.Exit(1)
+	}
+
+	if interactive && slices.ContainsFunc(endpoints, func(ep *endpoint.EndpointWithFile) bool { return ep.Schema.Upstream != nil }) {
+		fmt.Println(i18n.T("Warning: Interactive mode is not supported for proxy endpoints. Defaulting to non-interactive mode."))
+		interactive = false
+	}
 
-	if len(endpoints) == 1 && interactive {
-		runInteractiveMode(endpoints[0].Schema, port)
+	if interactive {
+		mode := tuiMode
+		switch {
+		case noAltScreen:
+			mode = linearMode
+		case !term.IsTerminal(os.Stdout.Fd()):
+			// CI and container logs cannot show the UI
+			fmt.Println(i18n.T("Standard output is not a terminal; reading commands from standard input instead of drawing the interactive UI."))
+			mode = replMode
+		}
+		runInteractiveMode(endpoints, projects[0].ln, mode, freeze, seed, timeouts, historySize, chaosRate, chaosSeed, responseHeader)
 		return
 	}
 
-	httpSrv := server.New(endpoints)
-	go func() {
-		if err := httpSrv.Serve(port); err != nil {
-			fmt.Printf("HTTP server error: %v\n", err)
+	if chaosRate > 0 || chaosSeed != 0 {
+		if chaosSeed == 0 {
+			chaosSeed = time.Now().UnixNano()
+		}
+		fmt.Println(i18n.T("Chaos mode: breaking %g%% of responses (seed %d)", chaosRate*100, chaosSeed))
+	}
+	if profile != "" {
+		if declared := server.New(all).Profiles(); !slices.Contains(declared, profile) {
+			fmt.Println(i18n.T("Unknown profile %q; declared profiles: %s", profile, strings.Join(declared, ", ")))
 			os.Exit(1)
 		}
-	}()
+		fmt.Println(i18n.T("Serving profile %s", profile))
+	}
+	var unreachable []string
+	if upstreamProbe != server.UpstreamProbeOff {
+		errs := server.ProbeUpstreams(context.Background(), all, upstreamTimeout)
+		for _, url := range slices.Sorted(maps.Keys(errs)) {
+			fmt.Println(i18n.T("Warning: upstream %s is unreachable: %v", url, errs[url]))
+			unreachable = append(unreachable, url)
+		}
+		switch {
+		case len(unreachable) > 0 && upstreamProbe == server.UpstreamProbeFail:
+			fmt.Println(i18n.T("Error: %d upstream(s) unreachable and --probe-upstreams is fail", len(unreachable)))
+			os.Exit(1)
+		case len(unreachable) > 0 && upstreamProbe == server.UpstreamProbeMock:
+			fmt.Println(i18n.T("Answering the requests to unreachable upstreams with the mocks"))
+		}
+	}
 
-	fmt.Printf("\nServer ready! Serving %d endpoint(s):\n", len(endpoints))
+	var baseline []*endpoint.EndpointWithFile
+	if compare != "" && mainProject {
+		var err error
+		baseline, err = loadEndpoints(compare)
+		if err != nil {
+			fmt.Println(i18n.T("Error loading comparison baseline: %v", err))
+			os.Exit(1)
+		}
+		fmt.Println(i18n.T("Comparing responses against %d baseline endpoint(s) from %s", len(baseline), compare))
+	}
 
-	for i, ep := range endpoints {
-		responses := ep.Schema.SliceResponses()
-		if len(responses) > 0 {
-			firstResponse := responses[0]
-			fmt.Printf("  [%d] %s -> [%d] %s\n", i, ep.Schema.Route, firstResponse.StatusCode, firstResponse.Title)
-		} else {
-			fmt.Printf("  [%d] %s -> (no responses)\n", i, ep.Schema.Route)
+	// One collector covers every port, for a single report of the run
+	collector := stats.NewCollector(all)
+
+	var tracer *tracing.Tracer
+	if otlpEndpoint != "" {
+		tracer = tracing.New(otlpEndpoint, serviceName())
+		fmt.Println(i18n.T("Exporting traces to %s", otlpEndpoint))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, len(projects))
+
+	for _, p := range projects {
+		httpSrv := server.New(p.endpoints)
+		httpSrv.SetTimeouts(timeouts)
+		httpSrv.SetResponseHeader(responseHeader)
+		httpSrv.PublishEvents(events.NewBus())
+		httpSrv.CollectStats(collector)
+		httpSrv.ReportBrokenFiles(p.broken)
+		httpSrv.ValidateResponses(responseValidation)
+		if compress {
+			httpSrv.EnableCompression()
+		}
+		if freeze {
+			httpSrv.FreezeRandom()
+			if seed != 0 {
+				// --seed takes precedence over the seed of --freeze-random
+				apimock.SeedRandom(seed)
+			}
+		}
+		if strict {
+			httpSrv.EnableStrict()
 		}
+		if fallbackToMock {
+			httpSrv.EnableFallbackToMock(upstreamTimeout)
+		}
+		if upstreamProbe == server.UpstreamProbeMock {
+			httpSrv.MockUpstreams(unreachable...)
+		}
+		if tracer != nil {
+			httpSrv.Trace(tracer)
+		}
+		if historySize > 0 {
+			httpSrv.KeepHistory(historySize)
+		}
+		if chaosRate > 0 || chaosSeed != 0 {
+			httpSrv.EnableChaos(chaosRate, chaosSeed)
+		}
+		if slices.Contains(httpSrv.Profiles(), profile) {
+			httpSrv.SetProfile(profile)
+		}
+		if authMock {
+			provider, err := authmock.New()
+			if err != nil {
+				fmt.Println(i18n.T("Error starting the OAuth2 mock: %v", err))
+				os.Exit(1)
+			}
+			httpSrv.MockAuth(provider)
+		}
+		if baseline != nil && p.addr == "" {
+			httpSrv.CompareWith(server.New(baseline), os.Stdout)
+		}
+		fmt.Println("\n" + i18n.T("Starting server on %s...", server.ListenAddress(p.ln)))
+		go func() { served <- httpSrv.ServeListener(ctx, p.ln) }()
+	}
+
+	for _, p := range projects {
+		p.print()
+	}
+
+	// A server failing stops the others
+	var failed error
+	for range projects {
+		if err := <-served; err != nil && failed == nil {
+			failed = err
+			stop()
+		}
+	}
+	// The traces and the report cover the requests served before a failure
+	// too, such as a shutdown cutting off slow requests
+	if failed != nil {
+		fmt.Println(i18n.T("HTTP server error: %v", failed))
+	} else {
+		fmt.Println("\n" + i18n.T("Server stopped"))
+	}
+
+	if tracer != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := tracer.Close(flushCtx); err != nil {
+			fmt.Println(i18n.T("Warning: exporting traces failed: %v", err))
+		}
+		cancel()
+	}
+
+	if report != "" {
+		writeReport(collector, report)
+	}
+	if failed != nil {
+		os.Exit(1)
 	}
-	select {}
 }
 
-func runInteractiveMode(endpoint *endpoint.EndpointSchema, port int) {
-	sm := state.New(endpoint.CountResponses())
+// otlpEndpointFromEnv returns the collector the OpenTelemetry environment
+// variables point traces to, or "".
+func otlpEndpointFromEnv() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// serviceName returns the service name of exported spans: $OTEL_SERVICE_NAME,
+// or anansi-proxy.
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return "anansi-proxy"
+}
+
+// writeReport writes the collected statistics to path.
+func writeReport(collector *stats.Collector, path string) {
+	if err := collector.Report().WriteFile(path); err != nil {
+		fmt.Println(i18n.T("Error writing report: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(i18n.T("Report written to %s", path))
+}
+
+// printDrafts lists the draft responses of endpoints and returns how many
+// there are.
+func printDrafts(endpoints []*endpoint.EndpointWithFile) int {
+	count := 0
+	for _, ep := range endpoints {
+		for _, resp := range ep.Schema.Drafts() {
+			if count == 0 {
+				fmt.Println(i18n.T("Draft responses:"))
+			}
+			count++
+			fmt.Printf("  %s -> [%d] %s (%s:%d)\n", ep.Schema.Route, resp.StatusCode, resp.Title, ep.FilePath, resp.Lines.Start)
+		}
+	}
+	return count
+}
+
+// parseFiles parses the mocks of a project, warning about the files that fail
+// to load so the others can still be served.
+func parseFiles(filePaths []string) ([]*endpoint.EndpointWithFile, []*endpoint.FileError) {
+	endpoints, broken := endpoint.LoadAPIMockFiles(filePaths...)
+	if len(broken) > 0 {
+		fmt.Println(i18n.T("Warning: some files failed to parse:") + "\n" + endpoint.FormatFileErrors(broken))
+	}
+	return endpoints, broken
+}
+
+// stdinArg is the path argument that reads an .apimock document from standard
+// input, and stdinName stands in for its file path in listings.
+const (
+	stdinArg  = "-"
+	stdinName = "(stdin)"
+)
 
-	httpSrv := server.NewInteractive(sm, endpoint)
+// splitStdin removes stdinArg from paths and reports whether it was there.
+func splitStdin(paths []string) ([]string, bool) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path != stdinArg {
+			files = append(files, path)
+		}
+	}
+	return files, len(files) < len(paths)
+}
+
+// readStdin parses the .apimock document piped to the process.
+func readStdin() (*endpoint.EndpointWithFile, error) {
+	schema, err := endpoint.ParseAPIMockReader(stdinName, os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint.EndpointWithFile{Schema: schema, FilePath: stdinName}, nil
+}
+
+func loadEndpoints(paths ...string) ([]*endpoint.EndpointWithFile, error) {
+	paths, fromStdin := splitStdin(paths)
+
+	var endpoints []*endpoint.EndpointWithFile
+	if len(paths) > 0 {
+		filePaths, err := discovery.FindAPIMockFiles(paths...)
+		if err != nil {
+			return nil, err
+		}
+		if endpoints, err = endpoint.ParseAPIMockFiles(filePaths...); err != nil {
+			return nil, err
+		}
+	}
+	if fromStdin {
+		ep, err := readStdin()
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// Front ends of the interactive mode.
+const (
+	tuiMode    = iota // the terminal UI
+	linearMode        // numbered choices read from stdin, for --no-altscreen
+	replMode          // commands read from stdin, when stdout is not a terminal
+)
+
+// runInteractiveMode serves endpoints on ln, each with the response selected
+// in the UI mode. The linear UI selects for a single endpoint.
+func runInteractiveMode(endpoints []*endpoint.EndpointWithFile, ln net.Listener, mode int, freeze bool, seed int64, timeouts server.Timeouts, historySize int, chaosRate float64, chaosSeed int64, responseHeader string) {
+	counts := make([]int, len(endpoints))
+	for i, ep := range endpoints {
+		counts[i] = ep.Schema.CountResponses()
+	}
+	sel := state.NewSelection(counts...)
+
+	httpSrv := server.NewInteractiveSet(sel, endpoints)
+	httpSrv.PublishEvents(events.NewBus())
+	httpSrv.SetTimeouts(timeouts)
+	httpSrv.SetResponseHeader(responseHeader)
+	if freeze {
+		httpSrv.FreezeRandom()
+		if seed != 0 {
+			// --seed takes precedence over the seed of --freeze-random
+			apimock.SeedRandom(seed)
+		}
+	}
+	if historySize > 0 {
+		httpSrv.KeepHistory(historySize)
+	}
+	// --chaos starts with the chaos switch on, at its rate
+	if chaosRate > 0 || chaosSeed != 0 {
+		if chaosSeed == 0 {
+			chaosSeed = time.Now().UnixNano()
+		}
+		httpSrv.EnableChaos(cmp.Or(chaosRate, server.InteractiveChaosRate), chaosSeed)
+		sel.Controls().SetChaos(chaosRate > 0)
+	}
+
+	// The UI handles Ctrl+C itself; the server stops when the UI returns
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	fmt.Println("\n" + i18n.T("Starting server on %s...", server.ListenAddress(ln)))
 	go func() {
-		if err := httpSrv.Serve(port); err != nil {
-			fmt.Printf("HTTP server error: %v\n", err)
+		err := httpSrv.ServeListener(ctx, ln)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println(i18n.T("HTTP server error: %v", err))
 			os.Exit(1)
 		}
+		served <- err
+	}()
+	defer func() {
+		stop()
+		<-served
 	}()
 
-	if err := ui.Render(sm, endpoint); err != nil {
-		fmt.Printf("UI error: %v\n", err)
+	var err error
+	switch mode {
+	case linearMode:
+		err = ui.RenderLinear(sel.Endpoint(0), endpoints[0].Schema, os.Stdin, os.Stdout)
+	case replMode:
+		if err = ui.RunREPL(sel, endpoints, os.Stdin, os.Stdout); errors.Is(err, io.EOF) {
+			// Without input, as under docker run without -i, keep serving
+			fmt.Println(i18n.T("Standard input closed; serving until interrupted."))
+			wait, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			<-wait.Done()
+			stop()
+			err = nil
+		}
+	default:
+		baseURL, socket := curlTarget(ln.Addr())
+		err = ui.Render(sel, endpoints, ui.Options{History: httpSrv.History(), Apply: httpSrv.SetSchema, BaseURL: baseURL, UnixSocket: socket})
+	}
+	if err != nil {
+		fmt.Println(i18n.T("UI error: %v", err))
 		os.Exit(1)
 	}
 }
+
+// curlTarget returns the base URL curl commands reach the server listening
+// on addr at, and the unix socket they connect through, if any.
+func curlTarget(addr net.Addr) (baseURL, socket string) {
+	if addr.Network() == "unix" {
+		return "http://localhost", addr.String()
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String(), ""
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port), ""
+}
+
+// stringList collects the values of a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/owners"
+)
+
+// runOwners reports which team owns each mocked route and flags unowned ones.
+func runOwners(args []string) {
+	fs := flag.NewFlagSet("owners", flag.ExitOnError)
+	codeowners := fs.String("codeowners", "", i18n.T("CODEOWNERS-like file mapping path patterns to owners"))
+	failUnowned := fs.Bool("fail-unowned", false, i18n.T("Exit with an error when an endpoint has no owner"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy owners [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Owners come from the X-Meta-Owner request property or from the --codeowners file."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println(i18n.T("Error: at least one file or directory path is required."))
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	endpoints, err := loadEndpoints(fs.Args()...)
+	if err != nil {
+		fmt.Println(i18n.T("Error loading endpoints: %v", err))
+		os.Exit(1)
+	}
+
+	var rules *owners.Rules
+	if *codeowners != "" {
+		rules, err = owners.LoadRules(*codeowners)
+		if err != nil {
+			fmt.Println(i18n.T("Error reading owners file: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	entries := owners.Report(endpoints, rules)
+	unowned := 0
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("ROUTE\tOWNERS\tFILE"))
+	for _, entry := range entries {
+		owner := strings.Join(entry.Owners, ", ")
+		if entry.Unowned() {
+			owner = i18n.T("(unowned)")
+			unowned++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Route, owner, displayPath(entry.FilePath))
+	}
+	w.Flush()
+
+	fmt.Println("\n" + i18n.T("%d endpoint(s), %d unowned", len(entries), unowned))
+	if unowned > 0 && *failUnowned {
+		os.Exit(1)
+	}
+}
+
+// displayPath shortens a path relative to the working directory when possible.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
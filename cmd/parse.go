@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// runParse prints the syntax tree of an .apimock file, for debugging the
+// parser and for tools that would rather not link it.
+func runParse(args []string) {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy parse <file>")
+		fmt.Println("\n" + i18n.T("Print the syntax tree of an .apimock file as JSON"))
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	name := fs.Arg(0)
+	var parser *apimock.Parser
+	if name == stdinArg {
+		name = stdinName
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println(i18n.T("Error parsing %s: %v", name, err))
+			os.Exit(1)
+		}
+		parser = apimock.NewParserFromBytes(name, content)
+	} else {
+		var err error
+		if parser, err = apimock.NewParser(name); err != nil {
+			fmt.Println(i18n.T("Error parsing %s: %v", name, err))
+			os.Exit(1)
+		}
+	}
+
+	ast, err := parser.Parse()
+	if err == nil {
+		err = ast.Validate()
+	}
+	if err != nil {
+		fmt.Println(i18n.T("Error parsing %s: %v", name, apimock.Explain(err)))
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(ast)
+}
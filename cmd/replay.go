@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/replay"
+)
+
+// runReplay sends the requests of recorded histories again and reports the
+// responses that differ from the recorded ones.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	baseURL := fs.String("base-url", "", i18n.T("URL of the server the requests are replayed against, such as http://localhost:8977"))
+	shape := fs.Bool("shape", false, i18n.T("Compare JSON bodies by their fields and types only, ignoring values"))
+	timeout := fs.Duration("timeout", 0, i18n.T("Maximum duration of each request (default: 10s)"))
+	format := fs.String("format", "text", i18n.T("Output format: text or json"))
+	var headers stringList
+	fs.Var(&headers, "header", i18n.T("Header sent with every request, as 'Name: value'; can be repeated"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy replay --base-url <url> [options] <history.json>...")
+		fmt.Println("\n" + i18n.T("Sends the requests of a history saved from GET /_admin/history again, in the order they were recorded, and reports where the status, content type and body differ from the recorded responses."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *baseURL == "" || fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Println(i18n.T("Error: unknown format %q (expected text or json)", *format))
+		os.Exit(1)
+	}
+
+	opts := replay.Options{BaseURL: *baseURL, Header: http.Header{}, Shape: *shape, Timeout: *timeout}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			fmt.Println(i18n.T("Error: invalid header %q (expected 'Name: value')", header))
+			os.Exit(1)
+		}
+		opts.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	var exchanges []history.Exchange
+	for _, path := range fs.Args() {
+		loaded, err := loadHistory(path)
+		if err != nil {
+			fmt.Println(i18n.T("Error reading %s: %v", path, err))
+			os.Exit(1)
+		}
+		exchanges = append(exchanges, loaded...)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := replay.Replay(ctx, exchanges, opts)
+
+	failed := 0
+	for _, result := range results {
+		if !result.OK() {
+			failed++
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		printReplay(results, failed)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// loadHistory reads a history saved from GET /_admin/history.
+func loadHistory(path string) ([]history.Exchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return replay.Load(f)
+}
+
+// printReplay lists the outcome of every request, then a summary.
+func printReplay(results []replay.Result, failed int) {
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			fmt.Println(i18n.T("FAIL  %s: %s", result.Request, result.Error))
+		case len(result.Diff) > 0:
+			fmt.Println(i18n.T("DIFF  %s -> %d", result.Request, result.Status))
+			for _, diff := range result.Diff {
+				fmt.Printf("      %s\n", diff)
+			}
+		default:
+			fmt.Println(i18n.T("OK    %s -> %d", result.Request, result.Status))
+		}
+	}
+	fmt.Println(i18n.T("%d request(s) replayed as recorded, %d differ", len(results)-failed, failed))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// runSchema works with the JSON Schemas of request sections.
+func runSchema(args []string) {
+	if len(args) == 0 || args[0] != "infer" {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy schema infer [options] <file_or_directory>...")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("schema infer", flag.ExitOnError)
+	write := fs.Bool("w", false, i18n.T("Write the schema into the request section of the files instead of printing it"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy schema infer [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Derives the JSON Schema of the request body from the examples of a mock: the request body, when it is an example rather than a schema, or else the JSON bodies of the 2xx responses."))
+		fmt.Println(i18n.T("With -w the schema replaces the example in the request section, so requests are validated, and the file is rewritten in the format of anansi-proxy fmt."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if fs.NArg() == 0 {
+		fmt.Println(i18n.T("Error: at least one file or directory path is required."))
+		fs.Usage()
+		os.Exit(1)
+	}
+	paths, err := discovery.FindAPIMockFiles(fs.Args()...)
+	if err != nil {
+		fmt.Println(i18n.T("Error finding .apimock files: %v", err))
+		os.Exit(1)
+	}
+	if !*write && len(paths) != 1 {
+		fmt.Println(i18n.T("Error: printing a schema takes exactly one file; use -w to update several."))
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range paths {
+		if !*write {
+			if err := printSchema(path); err != nil {
+				fmt.Println(i18n.T("Error inferring the schema of %s: %v", path, err))
+				failed = true
+			}
+			continue
+		}
+
+		switch err := injectSchema(path); {
+		case errors.Is(err, apimock.ErrHasSchema), errors.Is(err, apimock.ErrNoExamples):
+			fmt.Println(i18n.T("Skipped %s: %v", path, err))
+		case err != nil:
+			fmt.Println(i18n.T("Error inferring the schema of %s: %v", path, err))
+			failed = true
+		default:
+			fmt.Println(i18n.T("Updated %s", path))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printSchema prints the schema inferred for the request body of the file
+// at path.
+func printSchema(path string) error {
+	parser, err := apimock.NewParser(path)
+	if err != nil {
+		return err
+	}
+	ast, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+	schema, err := ast.InferRequestSchema()
+	if err != nil {
+		return err
+	}
+	fmt.Println(schema.JSON())
+	return nil
+}
+
+// injectSchema writes the schema inferred for the request body of the file
+// at path into its request section. Like fmt, it refuses files with @include
+// directives or ${NAME} references, which rendering the syntax tree would
+// inline.
+func injectSchema(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ast, err := apimock.NewParserFromBytes(path, content).Parse()
+	if err != nil {
+		return err
+	}
+	if len(ast.Includes) > 0 {
+		return errors.New(i18n.T("files with %s directives cannot be rewritten", apimock.IncludeDirective))
+	}
+	if len(ast.Env) > 0 {
+		return errors.New(i18n.T("files with ${NAME} environment variables cannot be rewritten"))
+	}
+	if ast.Request == nil {
+		return errors.New(i18n.T("the file has no request section to write the schema into"))
+	}
+
+	schema, err := ast.InferRequestSchema()
+	if err != nil {
+		return err
+	}
+	ast.Request.BodySchema = schema.JSON()
+	rendered, err := ast.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, rendered, info.Mode().Perm())
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pretodev/anansi-proxy/internal/diagnostic"
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// runValidate checks that .apimock files load without serving them. With
+// --format json or sarif it prints the problems as diagnostics for editors
+// and CI annotations instead of text.
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	failOnDraft := fs.Bool("fail-on-draft", false, i18n.T("Exit with an error when a response is a draft (its description starts with TODO)"))
+	format := fs.String("format", "text", i18n.T("Output format: text, json or sarif"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy validate [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Check that .apimock files load, listing the errors of those that do not"))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var writeDiagnostics func(io.Writer, []diagnostic.Diagnostic) error
+	switch *format {
+	case "text":
+	case "json":
+		writeDiagnostics = diagnostic.WriteJSON
+	case "sarif":
+		writeDiagnostics = diagnostic.WriteSARIF
+	default:
+		fmt.Println(i18n.T("Error: unknown format %q (expected text, json or sarif)", *format))
+		os.Exit(1)
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Println(i18n.T("Error: at least one file or directory path is required."))
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	paths, fromStdin := splitStdin(fs.Args())
+	var endpoints []*endpoint.EndpointWithFile
+	var broken []*endpoint.FileError
+	if len(paths) > 0 {
+		filePaths, err := discovery.FindAPIMockFiles(paths...)
+		if err != nil {
+			fmt.Println(i18n.T("Error finding .apimock files: %v", err))
+			os.Exit(1)
+		}
+		endpoints, broken = endpoint.LoadAPIMockFiles(filePaths...)
+	}
+	if fromStdin {
+		if ep, err := readStdin(); err != nil {
+			broken = append(broken, &endpoint.FileError{FilePath: stdinName, Err: err})
+		} else {
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	failed := len(broken) > 0 || *failOnDraft && hasDrafts(endpoints)
+	if writeDiagnostics != nil {
+		diags := make([]diagnostic.Diagnostic, 0, len(broken))
+		for _, err := range broken {
+			diags = append(diags, diagnostic.FromFileError(err)...)
+		}
+		diags = append(diags, diagnostic.Drafts(endpoints)...)
+		if err := writeDiagnostics(os.Stdout, diags); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("Error writing diagnostics: %v", err))
+			os.Exit(1)
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The errors already name their file
+	for _, err := range broken {
+		fmt.Println(err.Detail())
+	}
+	printDrafts(endpoints)
+
+	fmt.Println(i18n.T("%d file(s) valid, %d with errors", len(endpoints), len(broken)))
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func hasDrafts(endpoints []*endpoint.EndpointWithFile) bool {
+	for _, ep := range endpoints {
+		if len(ep.Schema.Drafts()) > 0 {
+			return true
+		}
+	}
+	return false
+}
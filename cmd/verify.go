@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/verify"
+)
+
+// runVerify replays the requests of .apimock files against a live API and
+// reports the responses that drifted from the mocks.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	baseURL := fs.String("base-url", "", i18n.T("URL of the API the requests are replayed against, such as https://staging.example.com"))
+	unsafe := fs.Bool("unsafe", false, i18n.T("Also replay requests with methods other than GET, HEAD and OPTIONS, which may change the API"))
+	timeout := fs.Duration("timeout", 0, i18n.T("Maximum duration of each request (default: 10s)"))
+	format := fs.String("format", "text", i18n.T("Output format: text or json"))
+	var headers stringList
+	fs.Var(&headers, "header", i18n.T("Header sent with every request, as 'Name: value'; can be repeated"))
+	addLangFlag(fs)
+	fs.Usage = func() {
+		fmt.Println(i18n.T("Usage:"))
+		fmt.Println("  anansi-proxy verify --base-url <url> [options] <file_or_directory>...")
+		fmt.Println("\n" + i18n.T("Replays the request of each .apimock file against a live API and reports where the status, headers and body shape drifted from the mock."))
+		fmt.Println("\n" + i18n.T("Options:"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *baseURL == "" || fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Println(i18n.T("Error: unknown format %q (expected text or json)", *format))
+		os.Exit(1)
+	}
+
+	opts := verify.Options{BaseURL: *baseURL, Header: http.Header{}, Unsafe: *unsafe, Timeout: *timeout}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			fmt.Println(i18n.T("Error: invalid header %q (expected 'Name: value')", header))
+			os.Exit(1)
+		}
+		opts.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	paths, files := parseASTs(fs.Args())
+	mocks := make([]verify.Mock, len(files))
+	for i, file := range files {
+		mocks[i] = verify.Mock{File: paths[i], AST: file}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := verify.Verify(ctx, mocks, opts)
+
+	drifted := 0
+	for _, result := range results {
+		if result.Skipped == "" && !result.OK() {
+			drifted++
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		printVerification(results, drifted)
+	}
+	if drifted > 0 {
+		os.Exit(1)
+	}
+}
+
+// printVerification lists the outcome of every mock, then a summary.
+func printVerification(results []verify.Result, drifted int) {
+	verified := 0
+	for _, result := range results {
+		switch {
+		case result.Skipped != "":
+			fmt.Println(i18n.T("SKIP  %s (%s): %s", result.Route, result.File, result.Skipped))
+		case result.Error != "":
+			fmt.Println(i18n.T("FAIL  %s (%s): %s", result.Route, result.File, result.Error))
+		case len(result.Drift) > 0:
+			fmt.Println(i18n.T("DRIFT %s -> %d (%s)", result.Request, result.Status, result.File))
+			for _, drift := range result.Drift {
+				fmt.Printf("      %s\n", drift)
+			}
+		default:
+			verified++
+			fmt.Println(i18n.T("OK    %s -> %d", result.Request, result.Status))
+		}
+	}
+	fmt.Println(i18n.T("%d mock(s) match the API, %d drifted, %d skipped", verified, drifted, len(results)-verified-drifted))
+}
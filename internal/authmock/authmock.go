@@ -0,0 +1,262 @@
+// Package authmock serves a mock OAuth2/OpenID Connect provider issuing RS256
+// signed JWTs, so clients can run their real login flows against the mock.
+package authmock
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Routes of the provider.
+const (
+	DiscoveryRoute = "GET /.well-known/openid-configuration"
+	JWKSRoute      = "GET /.well-known/jwks.json"
+	AuthorizeRoute = "GET /authorize"
+	TokenRoute     = "POST /token"
+)
+
+// TokenLifetime is how long the issued tokens are valid.
+const TokenLifetime = time.Hour
+
+// DefaultSubject is the subject of tokens whose request names no user.
+const DefaultSubject = "user"
+
+// grant is what an authorization code or refresh token stands for.
+type grant struct {
+	clientID string
+	subject  string
+	scope    string
+	nonce    string
+}
+
+// Provider issues tokens signed with a key generated at startup.
+type Provider struct {
+	key   *rsa.PrivateKey
+	keyID string
+	now   func() time.Time
+
+	mu            sync.Mutex
+	codes         map[string]grant
+	refreshTokens map[string]grant
+}
+
+func New() (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	sum := sha256.Sum256(key.N.Bytes())
+	return &Provider{
+		key:           key,
+		keyID:         hex.EncodeToString(sum[:8]),
+		now:           time.Now,
+		codes:         make(map[string]grant),
+		refreshTokens: make(map[string]grant),
+	}, nil
+}
+
+// Routes returns the handlers of the provider by net/http pattern.
+func (p *Provider) Routes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		DiscoveryRoute: p.discovery,
+		JWKSRoute:      p.jwks,
+		AuthorizeRoute: p.authorize,
+		TokenRoute:     p.token,
+	}
+}
+
+// issuer derives the issuer URL from the address the request was sent to, so
+// tokens match the discovery document whatever host and port clients use.
+func issuer(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	iss := issuer(r)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                iss,
+		"authorization_endpoint":                iss + "/authorize",
+		"token_endpoint":                        iss + "/token",
+		"jwks_uri":                              iss + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "client_credentials", "password", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "offline_access"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []jwk{publicJWK(&p.key.PublicKey, p.keyID)},
+	})
+}
+
+// authorize logs the user in right away and redirects back to the client with
+// an authorization code. The user is named by the login_hint parameter.
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		writeError(w, http.StatusBadRequest, "invalid_request", "redirect_uri must be an absolute URL")
+		return
+	}
+
+	params := redirect.Query()
+	if state := query.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	if responseType := query.Get("response_type"); responseType != "code" {
+		params.Set("error", "unsupported_response_type")
+	} else {
+		code := randomToken()
+		p.mu.Lock()
+		p.codes[code] = grant{
+			clientID: query.Get("client_id"),
+			subject:  cmp.Or(query.Get("login_hint"), DefaultSubject),
+			scope:    query.Get("scope"),
+			nonce:    query.Get("nonce"),
+		}
+		p.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok {
+		clientID = user
+	}
+
+	var g grant
+	var ok bool
+	refreshable := true
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		p.mu.Lock()
+		g, ok = p.codes[code]
+		delete(p.codes, code)
+		p.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "unknown or already used authorization code")
+			return
+		}
+	case "refresh_token":
+		p.mu.Lock()
+		g, ok = p.refreshTokens[r.PostForm.Get("refresh_token")]
+		p.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "unknown refresh token")
+			return
+		}
+	case "client_credentials":
+		// There is no user whose session could be refreshed
+		refreshable = false
+		g = grant{clientID: clientID, subject: cmp.Or(clientID, DefaultSubject), scope: r.PostForm.Get("scope")}
+	case "password":
+		g = grant{clientID: clientID, subject: cmp.Or(r.PostForm.Get("username"), DefaultSubject), scope: r.PostForm.Get("scope")}
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("grant_type %q is not supported", grantType))
+		return
+	}
+	p.issue(w, r, g, refreshable)
+}
+
+// issue writes a token response for g, with an ID token when the openid scope
+// was granted.
+func (p *Provider) issue(w http.ResponseWriter, r *http.Request, g grant, refreshable bool) {
+	now := p.now()
+	claims := map[string]any{
+		"iss":   issuer(r),
+		"sub":   g.subject,
+		"iat":   now.Unix(),
+		"exp":   now.Add(TokenLifetime).Unix(),
+		"jti":   randomToken(),
+		"scope": g.scope,
+	}
+	if g.clientID != "" {
+		claims["aud"] = g.clientID
+		claims["client_id"] = g.clientID
+	}
+
+	accessToken, err := sign(p.key, p.keyID, claims)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	resp := map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(TokenLifetime.Seconds()),
+		"scope":        g.scope,
+	}
+
+	if slices.Contains(strings.Fields(g.scope), "openid") {
+		idClaims := map[string]any{
+			"iss": claims["iss"],
+			"sub": g.subject,
+			"aud": g.clientID,
+			"iat": claims["iat"],
+			"exp": claims["exp"],
+		}
+		if g.nonce != "" {
+			idClaims["nonce"] = g.nonce
+		}
+		if resp["id_token"], err = sign(p.key, p.keyID, idClaims); err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+	}
+
+	if refreshable {
+		refreshToken := randomToken()
+		p.mu.Lock()
+		p.refreshTokens[refreshToken] = g
+		p.mu.Unlock()
+		resp["refresh_token"] = refreshToken
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an OAuth2 error response.
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,184 @@
+package authmock
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestProvider(t *testing.T) (*Provider, http.Handler) {
+	t.Helper()
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	for pattern, handler := range p.Routes() {
+		mux.HandleFunc(pattern, handler)
+	}
+	return p, mux
+}
+
+func postToken(t *testing.T, handler http.Handler, form url.Values) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "http://auth.test/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid token response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+// verify checks the signature of token against the published JWKS and
+// returns its claims.
+func verify(t *testing.T, handler http.Handler, token string) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://auth.test/.well-known/jwks.json", nil))
+	var set struct{ Keys []jwk }
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil || len(set.Keys) != 1 {
+		t.Fatalf("invalid JWKS %q: %v", rec.Body.String(), err)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(set.Keys[0].Modulus)
+	e, _ := base64.RawURLEncoding.DecodeString(set.Keys[0].Exponent)
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("token signature does not match the JWKS: %v", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("invalid claims: %v", err)
+	}
+	return claims
+}
+
+func TestProvider_Discovery(t *testing.T) {
+	_, handler := newTestProvider(t)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8977/.well-known/openid-configuration", nil))
+
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid discovery document: %v", err)
+	}
+	want := map[string]string{
+		"issuer":                 "http://localhost:8977",
+		"token_endpoint":         "http://localhost:8977/token",
+		"authorization_endpoint": "http://localhost:8977/authorize",
+		"jwks_uri":               "http://localhost:8977/.well-known/jwks.json",
+	}
+	for key, value := range want {
+		if doc[key] != value {
+			t.Errorf("expected %s %q, got %v", key, value, doc[key])
+		}
+	}
+}
+
+func TestProvider_ClientCredentials(t *testing.T) {
+	_, handler := newTestProvider(t)
+	status, body := postToken(t, handler, url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "scope": {"invoices:read"}})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", status, body)
+	}
+	if _, ok := body["refresh_token"]; ok {
+		t.Error("expected no refresh token for client credentials")
+	}
+	if _, ok := body["id_token"]; ok {
+		t.Error("expected no ID token without the openid scope")
+	}
+
+	claims := verify(t, handler, body["access_token"].(string))
+	if claims["sub"] != "billing" || claims["aud"] != "billing" || claims["scope"] != "invoices:read" || claims["iss"] != "http://auth.test" {
+		t.Errorf("unexpected claims %v", claims)
+	}
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	_, handler := newTestProvider(t)
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {"web"},
+		"redirect_uri":  {"http://app.test/callback"},
+		"scope":         {"openid profile"},
+		"state":         {"xyz"},
+		"nonce":         {"n-1"},
+		"login_hint":    {"ana"},
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://auth.test/authorize?"+query.Encode(), nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if location.Host != "app.test" || location.Query().Get("state") != "xyz" {
+		t.Fatalf("unexpected redirect %s", location)
+	}
+	code := location.Query().Get("code")
+
+	exchange := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "client_id": {"web"}}
+	status, body := postToken(t, handler, exchange)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", status, body)
+	}
+	idClaims := verify(t, handler, body["id_token"].(string))
+	if idClaims["sub"] != "ana" || idClaims["nonce"] != "n-1" || idClaims["aud"] != "web" {
+		t.Errorf("unexpected ID token claims %v", idClaims)
+	}
+
+	if status, _ := postToken(t, handler, exchange); status != http.StatusBadRequest {
+		t.Errorf("expected a used code to be rejected, got %d", status)
+	}
+
+	status, refreshed := postToken(t, handler, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {body["refresh_token"].(string)}})
+	if status != http.StatusOK {
+		t.Fatalf("expected refresh to succeed, got %d: %v", status, refreshed)
+	}
+	if claims := verify(t, handler, refreshed["access_token"].(string)); claims["sub"] != "ana" {
+		t.Errorf("expected refreshed token for ana, got %v", claims)
+	}
+}
+
+func TestProvider_Errors(t *testing.T) {
+	_, handler := newTestProvider(t)
+
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{name: "unsupported grant", form: url.Values{"grant_type": {"device_code"}}, want: "unsupported_grant_type"},
+		{name: "unknown code", form: url.Values{"grant_type": {"authorization_code"}, "code": {"nope"}}, want: "invalid_grant"},
+		{name: "unknown refresh token", form: url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"nope"}}, want: "invalid_grant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := postToken(t, handler, tt.form)
+			if status != http.StatusBadRequest || body["error"] != tt.want {
+				t.Errorf("expected 400 %s, got %d %v", tt.want, status, body)
+			}
+		})
+	}
+}
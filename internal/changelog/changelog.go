@@ -0,0 +1,198 @@
+// Package changelog describes the contract changes between two versions of a
+// mock suite, in a form suitable for release notes.
+package changelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// Changelog lists the endpoints added, removed and changed between two
+// versions. Endpoints are identified by their route shape, so renaming a path
+// parameter is not a change.
+type Changelog struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// Change describes how the contract of an endpoint present in both versions
+// changed. Responses are identified by status code and content type and
+// listed as `404 Not found` (application/json).
+type Change struct {
+	Route            string   `json:"route"`
+	AddedResponses   []string `json:"addedResponses,omitempty"`
+	RemovedResponses []string `json:"removedResponses,omitempty"`
+	ChangedResponses []string `json:"changedResponses,omitempty"`
+	// RequestChanged tells whether the accepted content type or the request
+	// body schema changed
+	RequestChanged bool `json:"requestChanged,omitempty"`
+}
+
+// contract is what a version declares for one route shape, merged over the
+// endpoints sharing it.
+type contract struct {
+	route     string
+	request   []string
+	responses map[string]endpoint.Response
+}
+
+// Diff compares the endpoints of two versions of a mock suite.
+func Diff(old, new []*endpoint.EndpointWithFile) *Changelog {
+	before, after := contracts(old), contracts(new)
+	c := &Changelog{Added: []string{}, Removed: []string{}, Changed: []Change{}}
+
+	for _, shape := range sortedKeys(after) {
+		prev, ok := before[shape]
+		if !ok {
+			c.Added = append(c.Added, after[shape].route)
+			continue
+		}
+		if change, changed := compare(prev, after[shape]); changed {
+			c.Changed = append(c.Changed, change)
+		}
+	}
+	for _, shape := range sortedKeys(before) {
+		if _, ok := after[shape]; !ok {
+			c.Removed = append(c.Removed, before[shape].route)
+		}
+	}
+	return c
+}
+
+// Empty reports whether the versions declare the same contract.
+func (c *Changelog) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// WriteMarkdown writes the changelog as a Markdown section.
+func (c *Changelog) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## Contract Changes\n")
+	if c.Empty() {
+		b.WriteString("\nNo contract changes.\n")
+	}
+
+	writeRoutes(&b, "Added Endpoints", c.Added)
+	writeRoutes(&b, "Removed Endpoints", c.Removed)
+	if len(c.Changed) > 0 {
+		b.WriteString("\n### Changed Endpoints\n\n")
+		for _, change := range c.Changed {
+			fmt.Fprintf(&b, "- `%s`\n", change.Route)
+			if change.RequestChanged {
+				b.WriteString("  - changed request\n")
+			}
+			for _, resp := range change.AddedResponses {
+				fmt.Fprintf(&b, "  - added response %s\n", resp)
+			}
+			for _, resp := range change.RemovedResponses {
+				fmt.Fprintf(&b, "  - removed response %s\n", resp)
+			}
+			for _, resp := range change.ChangedResponses {
+				fmt.Fprintf(&b, "  - changed response %s\n", resp)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the changelog as indented JSON.
+func (c *Changelog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func writeRoutes(b *strings.Builder, title string, routes []string) {
+	if len(routes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, route := range routes {
+		fmt.Fprintf(b, "- `%s`\n", route)
+	}
+}
+
+func contracts(endpoints []*endpoint.EndpointWithFile) map[string]*contract {
+	byShape := make(map[string]*contract, len(endpoints))
+	for _, ep := range endpoints {
+		shape := endpoint.RouteShape(ep.Schema.Route)
+		c, ok := byShape[shape]
+		if !ok {
+			c = &contract{route: ep.Schema.Route, responses: make(map[string]endpoint.Response)}
+			byShape[shape] = c
+		}
+		c.request = append(c.request, ep.Schema.Accept+"\n"+normalize(ep.Schema.Body))
+		for _, resp := range ep.Schema.SliceResponses() {
+			key := responseKey(resp)
+			if _, seen := c.responses[key]; !seen {
+				c.responses[key] = resp
+			}
+		}
+	}
+	for _, c := range byShape {
+		sort.Strings(c.request)
+	}
+	return byShape
+}
+
+func compare(old, new *contract) (Change, bool) {
+	change := Change{
+		Route:          new.route,
+		RequestChanged: strings.Join(old.request, "\x00") != strings.Join(new.request, "\x00"),
+	}
+	for _, key := range sortedKeys(new.responses) {
+		resp := new.responses[key]
+		prev, ok := old.responses[key]
+		switch {
+		case !ok:
+			change.AddedResponses = append(change.AddedResponses, describe(resp))
+		case normalize(prev.Body) != normalize(resp.Body):
+			change.ChangedResponses = append(change.ChangedResponses, describe(resp))
+		}
+	}
+	for _, key := range sortedKeys(old.responses) {
+		if _, ok := new.responses[key]; !ok {
+			change.RemovedResponses = append(change.RemovedResponses, describe(old.responses[key]))
+		}
+	}
+
+	changed := change.RequestChanged || len(change.AddedResponses)+len(change.RemovedResponses)+len(change.ChangedResponses) > 0
+	return change, changed
+}
+
+// responseKey identifies a response by status code and content type, and
+// sorts by status code.
+func responseKey(resp endpoint.Response) string {
+	return fmt.Sprintf("%03d %s", resp.StatusCode, resp.ContentType)
+}
+
+func describe(resp endpoint.Response) string {
+	return fmt.Sprintf("`%d %s` (%s)", resp.StatusCode, resp.Title, resp.ContentType)
+}
+
+// normalize compacts JSON documents so formatting changes are not reported.
+func normalize(body string) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(body)); err == nil {
+		return b.String()
+	}
+	return strings.TrimSpace(body)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
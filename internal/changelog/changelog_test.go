@@ -0,0 +1,75 @@
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func newEndpoint(route, body string, responses ...endpoint.Response) *endpoint.EndpointWithFile {
+	schema := &endpoint.EndpointSchema{
+		Route:     route,
+		Accept:    "application/json",
+		Body:      body,
+		Responses: make(map[int][]endpoint.Response),
+	}
+	for _, resp := range responses {
+		schema.Responses[resp.StatusCode] = append(schema.Responses[resp.StatusCode], resp)
+	}
+	return &endpoint.EndpointWithFile{Schema: schema, FilePath: route + ".apimock"}
+}
+
+func jsonResponse(status int, title, body string) endpoint.Response {
+	return endpoint.Response{StatusCode: status, Title: title, ContentType: "application/json", Body: body}
+}
+
+func TestDiff(t *testing.T) {
+	old := []*endpoint.EndpointWithFile{
+		newEndpoint("GET /users/{id}", "", jsonResponse(200, "Found", `{"id": 1}`), jsonResponse(404, "Missing", `{}`)),
+		newEndpoint("POST /users", `{"type": "object"}`, jsonResponse(201, "Created", `{"id": 1}`)),
+		newEndpoint("GET /legacy", "", jsonResponse(200, "OK", `[]`)),
+	}
+	new := []*endpoint.EndpointWithFile{
+		// Renamed parameter and reformatted body: unchanged
+		newEndpoint("GET /users/{userId}", "", jsonResponse(200, "Found", "{\n  \"id\": 1\n}"), jsonResponse(404, "Missing", `{}`)),
+		newEndpoint("POST /users", `{"type": "object", "required": ["name"]}`,
+			jsonResponse(201, "Created", `{"id": 2}`), jsonResponse(422, "Invalid", `{}`)),
+		newEndpoint("GET /orders", "", jsonResponse(200, "OK", `[]`)),
+	}
+
+	got := Diff(old, new)
+	want := &Changelog{
+		Added:   []string{"GET /orders"},
+		Removed: []string{"GET /legacy"},
+		Changed: []Change{{
+			Route:            "POST /users",
+			AddedResponses:   []string{"`422 Invalid` (application/json)"},
+			ChangedResponses: []string{"`201 Created` (application/json)"},
+			RequestChanged:   true,
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+
+	var b strings.Builder
+	if err := got.WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"### Added Endpoints", "- `GET /legacy`", "  - added response `422 Invalid` (application/json)", "  - changed request"} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Markdown is missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	endpoints := []*endpoint.EndpointWithFile{newEndpoint("GET /ping", "", jsonResponse(200, "OK", `{}`))}
+
+	got := Diff(endpoints, endpoints)
+	if !got.Empty() {
+		t.Errorf("Diff() of the same endpoints = %+v, want no changes", got)
+	}
+}
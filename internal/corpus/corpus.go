@@ -0,0 +1,422 @@
+// Package corpus generates random, valid .apimock files for property-based
+// tests of the parser and for tools built on top of the format.
+package corpus
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+var (
+	methods      = []string{"", "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	words        = []string{"users", "orders", "items", "v1", "v2", "api", "files", "reports", "search", "health", "accounts", "carts"}
+	paramNames   = []string{"id", "userId", "orderId", "slug", "name"}
+	paramPattern = []string{"[0-9]+", "[a-z-]+", "[A-Z]{2,3}", "v[0-9]"}
+	statusCodes  = []int{200, 201, 202, 204, 301, 302, 400, 401, 403, 404, 409, 422, 429, 500, 502, 503}
+	descriptions = []string{"", "OK", "Created", "Resource not found", "Validation error", "Internal failure", "Rate limited"}
+	contentTypes = []string{"application/json", "application/xml", "text/plain", "text/csv", "application/x-yaml"}
+	metadataKeys = []string{"X-Meta-Owner", "X-Meta-Ticket", "X-Meta-Deprecated"}
+	queryValues  = []string{"1", "10", "true", "desc", "created_at", "{value}", "a,b,c"}
+	jsonValues   = []string{`1`, `42.5`, `true`, `false`, `null`, `"text"`, `"2024-01-15T10:30:00Z"`}
+	profiles     = []string{"outage", "degraded", "slow", "outage, degraded"}
+	upstreams    = []string{"http://localhost:8080", "https://api.example.com", "https://api.example.com/v2", "http://127.0.0.1:9000/api"}
+	variables    = []string{"method", "path", "call_count", "query.page", "query.status", "params.id", "body.name", "body.user?.name", "body.items[1].price", `headers["X-Plan"]`, `cookies["session"]`}
+	assigned     = []string{"total", "user", "first", "last", "plan"}
+	binaryOps    = []string{"+", "-", "*", "/", "//", "%", "==", "!=", "<", "<=", ">", ">=", "in", "not in", "and", "or", ".."}
+)
+
+// Generator produces pseudo-random .apimock files. The same seed always yields
+// the same sequence of files.
+type Generator struct {
+	rand *rand.Rand
+}
+
+func New(seed int64) *Generator {
+	return &Generator{rand: rand.New(rand.NewSource(seed))}
+}
+
+// File returns a random APIMockFile that Marshal can render and the parser
+// reads back unchanged.
+func (g *Generator) File() *apimock.APIMockFile {
+	f := apimock.NewAPIMockFile()
+	if g.rand.Intn(5) > 0 {
+		f.Request = g.request()
+	}
+
+	for i := 0; i < 1+g.rand.Intn(4); i++ {
+		f.Responses = append(f.Responses, g.response())
+	}
+	if g.rand.Intn(5) == 0 {
+		f.Responses = append(f.Responses, g.proxy())
+	}
+	return f
+}
+
+// Source returns a random file rendered as .apimock source.
+func (g *Generator) Source() (string, error) {
+	data, err := g.File().Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Split renders f as the source of the file named name and of fragments it
+// pulls in with @include, keyed by their paths relative to the directory of
+// name. Some property values and proxy URLs are written as ${NAME:-value}
+// references to ANANSI_CORPUS_* environment variables, which are expected to
+// be unset, so the sources still parse into f.
+func (g *Generator) Split(f *apimock.APIMockFile, name string) (map[string]string, error) {
+	data, err := f.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every line starting with -- starts a response section, since Marshal
+	// refuses body lines that would start one
+	var sections [][]string
+	current := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if strings.HasPrefix(line, "--") && (len(sections) > 0 || len(current) > 0) {
+			sections = append(sections, current)
+			current = []string{}
+		}
+		current = append(current, line)
+	}
+	sections = append(sections, current)
+
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	sources := make(map[string]string)
+	var main []string
+	for i, lines := range sections {
+		g.referenceEnv(lines)
+		if (i == 0 && f.Request != nil) || g.rand.Intn(3) > 0 {
+			main = append(main, lines...)
+			continue
+		}
+		// The blank line separating sections stays in the including file
+		blank := lines[len(lines)-1] == ""
+		if blank {
+			lines = lines[:len(lines)-1]
+		}
+		fragment := fmt.Sprintf("%s-%d.include", base, len(sources)+1)
+		sources[fragment] = strings.Join(lines, "\n") + "\n"
+		main = append(main, apimock.IncludeDirective+" "+fragment)
+		if blank {
+			main = append(main, "")
+		}
+	}
+	sources[name] = strings.Join(main, "\n") + "\n"
+	return sources, nil
+}
+
+// referenceEnv writes some property values and proxy URLs of the section
+// lines as environment variable references defaulting to them.
+func (g *Generator) referenceEnv(lines []string) {
+	for i, line := range lines {
+		if i > 0 && (line == "" || strings.HasPrefix(line, apimock.ConditionPrefix)) {
+			return
+		}
+		if i == 0 && !strings.HasPrefix(line, "-- proxy:") {
+			continue
+		}
+		prefix, value, ok := strings.Cut(line, ": ")
+		if !ok || strings.HasPrefix(line, " ") || strings.ContainsAny(value, "${}") || g.rand.Intn(4) > 0 {
+			continue
+		}
+		key := strings.ToUpper(strings.Trim(prefix, "- "))
+		key = strings.Map(func(r rune) rune {
+			if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, key)
+		lines[i] = fmt.Sprintf("%s: ${ANANSI_CORPUS_%s:-%s}", prefix, key, value)
+	}
+}
+
+func (g *Generator) request() *apimock.RequestSection {
+	r := apimock.NewRequestSection()
+	r.Method = pick(g, methods)
+
+	for i := 0; i < 1+g.rand.Intn(4); i++ {
+		seg := g.pathSegment()
+		r.PathSegments = append(r.PathSegments, seg)
+		r.Path += "/" + seg.Value
+	}
+	if g.rand.Intn(4) == 0 {
+		seg := apimock.PathSegment{Value: "*", IsWildcard: true}
+		r.PathSegments = append(r.PathSegments, seg)
+		r.Path += "/*"
+	}
+
+	for i := 0; i < g.rand.Intn(3); i++ {
+		r.QueryParams[pick(g, words)] = pick(g, queryValues)
+	}
+
+	if g.rand.Intn(2) == 0 {
+		r.Properties["Accept"] = pick(g, contentTypes)
+	}
+	if g.rand.Intn(3) == 0 {
+		r.Properties[pick(g, metadataKeys)] = "@" + pick(g, words)
+	}
+	if g.rand.Intn(2) == 0 {
+		r.BodySchema = g.jsonBody(false)
+	}
+	return r
+}
+
+func (g *Generator) pathSegment() apimock.PathSegment {
+	switch g.rand.Intn(6) {
+	case 0:
+		name := pick(g, paramNames)
+		return apimock.PathSegment{Value: "{" + name + "}", IsParameter: true, Name: name}
+	case 1:
+		name, pattern := pick(g, paramNames), pick(g, paramPattern)
+		return apimock.PathSegment{Value: "{" + name + ":" + pattern + "}", IsParameter: true, Name: name, Pattern: pattern}
+	default:
+		return apimock.PathSegment{Value: pick(g, words)}
+	}
+}
+
+func (g *Generator) response() apimock.ResponseSection {
+	r := apimock.NewResponseSection()
+	r.StatusCode = statusCodes[g.rand.Intn(len(statusCodes))]
+	r.Description = pick(g, descriptions)
+
+	if g.rand.Intn(4) > 0 {
+		r.Properties["ContentType"] = pick(g, contentTypes)
+	}
+	if g.rand.Intn(4) == 0 {
+		r.Properties[pick(g, metadataKeys)] = pick(g, words)
+	}
+	if g.rand.Intn(5) == 0 {
+		r.Properties["Profile"] = pick(g, profiles)
+	}
+
+	switch g.rand.Intn(4) {
+	case 0:
+		r.Body = g.jsonBody(true)
+	case 1:
+		r.Body = g.xmlBody()
+	case 2:
+		r.Body = g.textBody()
+	}
+	if g.rand.Intn(3) == 0 {
+		r.Body = strings.TrimSuffix(g.conditions()+"\n\n"+r.Body, "\n\n")
+	}
+	return r
+}
+
+// proxy returns a proxy section, which forwards requests instead of
+// answering them.
+func (g *Generator) proxy() apimock.ResponseSection {
+	r := apimock.NewResponseSection()
+	r.Upstream = pick(g, upstreams)
+	if g.rand.Intn(3) == 0 {
+		r.Properties["Profile"] = pick(g, profiles)
+	}
+	return r
+}
+
+// conditions returns the condition lines leading a response body, some
+// joined with or and some followed by a comment.
+func (g *Generator) conditions() string {
+	lines := make([]string, 0, 3)
+	for i := 0; i < 1+g.rand.Intn(3); i++ {
+		line := apimock.ConditionPrefix + " "
+		if i > 0 && g.rand.Intn(3) == 0 {
+			line += "or "
+		}
+		line += g.Expression()
+		if g.rand.Intn(5) == 0 {
+			line += " # " + pick(g, words)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Expression returns a random expression of the conditions language that
+// ParseExpression accepts. Operands that are operations themselves are
+// parenthesized, and functions are called with as many arguments as their
+// signatures list.
+func (g *Generator) Expression() string {
+	x, _ := g.expression(3)
+	return x
+}
+
+// expression returns an expression nested up to depth levels, and whether
+// it is a single value that needs no parentheses as an operand.
+func (g *Generator) expression(depth int) (string, bool) {
+	if depth == 0 {
+		return g.atom(0), true
+	}
+	switch g.rand.Intn(10) {
+	case 0:
+		return g.operand(depth-1) + " " + pick(g, binaryOps) + " " + g.operand(depth-1), false
+	case 1:
+		return "not " + g.operand(depth-1), false
+	case 2:
+		return "-" + g.operand(depth-1), false
+	case 3:
+		x, _ := g.expression(depth - 1)
+		return "if " + g.operand(depth-1) + " then " + x + " else " + g.operand(depth-1), false
+	case 4:
+		return g.operand(depth-1) + " ? " + g.operand(depth-1) + " : " + g.operand(depth-1), false
+	case 5:
+		return g.call(depth - 1), false
+	case 6:
+		names := []string{pick(g, assigned)}
+		if g.rand.Intn(3) == 0 {
+			names = append(names, pick(g, assigned))
+		}
+		return g.operand(depth-1) + " >> " + strings.Join(names, ", "), false
+	default:
+		return g.atom(depth - 1), true
+	}
+}
+
+// operand returns an expression that can be written next to an operator or
+// as an argument.
+func (g *Generator) operand(depth int) string {
+	x, single := g.expression(depth)
+	if single {
+		return x
+	}
+	return "(" + x + ")"
+}
+
+// atom returns a literal, a variable, a table or an exists check.
+func (g *Generator) atom(depth int) string {
+	switch g.rand.Intn(8) {
+	case 0:
+		return strconv.Itoa(g.rand.Intn(100))
+	case 1:
+		return strconv.FormatFloat(float64(g.rand.Intn(1000))/10, 'f', -1, 64)
+	case 2:
+		return strconv.Quote(pick(g, words))
+	case 3:
+		return pick(g, []string{"true", "false", "nil"})
+	case 4:
+		return g.table(depth)
+	case 5:
+		return "exists(" + pick(g, variables) + ")"
+	default:
+		return pick(g, variables)
+	}
+}
+
+// table returns an array or a dictionary of up to three elements. Elements
+// are parenthesized too, since a comma would otherwise continue an
+// assignment such as `x >> first, last`.
+func (g *Generator) table(depth int) string {
+	dictionary := g.rand.Intn(2) == 0
+	elements := make([]string, 0, 3)
+	for i := 0; i < g.rand.Intn(4); i++ {
+		x := g.operand(depth)
+		if dictionary {
+			x = pick(g, words) + " = " + x
+		}
+		elements = append(elements, x)
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// call returns a call to a built-in function, its first argument sometimes
+// piped into it with >>.
+func (g *Generator) call(depth int) string {
+	fn := pick(g, apimock.Functions())
+	args := make([]string, 0, len(fn.Params()))
+	for _, param := range fn.Params() {
+		args = append(args, g.argument(param, depth))
+	}
+	if len(args) > 0 && g.rand.Intn(2) == 0 {
+		return strings.Join(append([]string{args[0], ">> ." + fn.Name}, args[1:]...), " ")
+	}
+	return strings.Join(append([]string{"." + fn.Name}, args...), " ")
+}
+
+// argument returns an argument of the type of a function parameter.
+func (g *Generator) argument(param string, depth int) string {
+	switch strings.Split(param, "|")[0] {
+	case "string":
+		if g.rand.Intn(2) == 0 {
+			return pick(g, variables)
+		}
+		return strconv.Quote(pick(g, words))
+	case "number":
+		return strconv.Itoa(g.rand.Intn(100))
+	case "table":
+		return g.table(0)
+	case "expression":
+		return strconv.Quote(g.Expression())
+	case "boolean":
+		return pick(g, []string{"true", "false"})
+	}
+	return g.operand(depth)
+}
+
+// jsonBody returns an indented JSON object. Request bodies end at the first
+// blank line, so blank lines are only used in response bodies.
+func (g *Generator) jsonBody(allowBlankLines bool) string {
+	unique := make(map[string]bool)
+	for i := 0; i < 1+g.rand.Intn(4); i++ {
+		unique[pick(g, words)] = true
+	}
+	keys := make([]string, 0, len(unique))
+	for key := range unique {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{"{"}
+	for i, key := range keys {
+		line := fmt.Sprintf("  %q: %s", key, pick(g, jsonValues))
+		if i < len(keys)-1 {
+			line += ","
+		}
+		lines = append(lines, line)
+		if allowBlankLines && g.rand.Intn(6) == 0 {
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+func (g *Generator) xmlBody() string {
+	root := pick(g, words)
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%s>", root)
+	for i := 0; i < 1+g.rand.Intn(3); i++ {
+		child := pick(g, words)
+		fmt.Fprintf(&b, "\n  <%s>%s</%s>", child, pick(g, words), child)
+	}
+	fmt.Fprintf(&b, "\n</%s>", root)
+	return b.String()
+}
+
+// textBody returns lines of words. Some start with a word followed by a colon,
+// which the lexer sees as properties and the parser must keep as body text.
+func (g *Generator) textBody() string {
+	lines := make([]string, 0, 3)
+	for i := 0; i < 1+g.rand.Intn(3); i++ {
+		line := pick(g, words) + " " + pick(g, words)
+		if g.rand.Intn(3) == 0 {
+			line = pick(g, words) + ": " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func pick[T any](g *Generator, values []T) T {
+	return values[g.rand.Intn(len(values))]
+}
@@ -0,0 +1,152 @@
+package corpus
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func TestGenerator_RoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		want := New(seed).File()
+
+		source, err := want.Marshal()
+		if err != nil {
+			t.Fatalf("seed %d: Marshal() error = %v", seed, err)
+		}
+
+		got, err := apimock.NewParserFromBytes("corpus.apimock", source).Parse()
+		if err != nil {
+			t.Fatalf("seed %d: Parse() error = %v\n%s", seed, err, source)
+		}
+		clearLines(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("seed %d: parsed file differs from generated one\nsource:\n%s\ngot:  %+v\nwant: %+v", seed, source, got, want)
+		}
+
+		again, err := got.Marshal()
+		if err != nil {
+			t.Fatalf("seed %d: second Marshal() error = %v", seed, err)
+		}
+		if !bytes.Equal(again, source) {
+			t.Fatalf("seed %d: Marshal is not stable\nfirst:\n%s\nsecond:\n%s", seed, source, again)
+		}
+	}
+}
+
+// clearLines drops the source lines recorded by the parser, which generated
+// files do not have.
+func clearLines(f *apimock.APIMockFile) {
+	if f.Request != nil {
+		f.Request.Lines = apimock.LineRange{}
+	}
+	for i := range f.Responses {
+		f.Responses[i].Lines = apimock.LineRange{}
+	}
+}
+
+func TestGenerator_Split(t *testing.T) {
+	var includes, env int
+	for seed := int64(0); seed < 500; seed++ {
+		gen := New(seed)
+		want := gen.File()
+
+		sources, err := gen.Split(want, "corpus.apimock")
+		if err != nil {
+			t.Fatalf("seed %d: Split() error = %v", seed, err)
+		}
+		fsys := fstest.MapFS{}
+		for name, source := range sources {
+			fsys[name] = &fstest.MapFile{Data: []byte(source)}
+		}
+
+		parser, err := apimock.NewParserFS(fsys, "corpus.apimock")
+		if err != nil {
+			t.Fatalf("seed %d: NewParserFS() error = %v", seed, err)
+		}
+		got, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("seed %d: Parse() error = %v\n%s", seed, err, sources["corpus.apimock"])
+		}
+
+		wantIncludes, wantEnv := references(sources, "corpus.apimock")
+		if !reflect.DeepEqual(got.Includes, wantIncludes) {
+			t.Errorf("seed %d: Includes = %v, want %v", seed, got.Includes, wantIncludes)
+		}
+		if !reflect.DeepEqual(got.Env, wantEnv) {
+			t.Errorf("seed %d: Env = %v, want %v", seed, got.Env, wantEnv)
+		}
+		includes += len(got.Includes)
+		env += len(got.Env)
+
+		got.Includes, got.Env = nil, nil
+		clearLines(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("seed %d: parsed file differs from generated one\nsources:\n%v\ngot:  %+v\nwant: %+v", seed, sources, got, want)
+		}
+	}
+	if includes == 0 || env == 0 {
+		t.Errorf("expected the corpus to use @include and ${NAME}, got %d includes and %d references", includes, env)
+	}
+}
+
+var envReference = regexp.MustCompile(`\$\{(\w+):-`)
+
+// references returns the files the source of name includes and the
+// environment variables it references, in the order the parser meets them.
+func references(sources map[string]string, name string) (includes, env []string) {
+	for _, line := range strings.Split(sources[name], "\n") {
+		if fragment, ok := strings.CutPrefix(line, apimock.IncludeDirective+" "); ok {
+			includes = append(includes, fragment)
+			line = sources[fragment]
+		}
+		for _, m := range envReference.FindAllStringSubmatch(line, -1) {
+			if !slices.Contains(env, m[1]) {
+				env = append(env, m[1])
+			}
+		}
+	}
+	return includes, env
+}
+
+func TestGenerator_Expression(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		text := New(seed).Expression()
+
+		x, err := apimock.ParseExpression(text)
+		if err != nil {
+			t.Fatalf("seed %d: ParseExpression(%s) error = %v", seed, text, err)
+		}
+		data, err := apimock.MarshalExpr(x)
+		if err != nil {
+			t.Fatalf("seed %d: MarshalExpr(%s) error = %v", seed, text, err)
+		}
+		decoded, err := apimock.UnmarshalExpr(data)
+		if err != nil {
+			t.Fatalf("seed %d: UnmarshalExpr(%s) error = %v", seed, data, err)
+		}
+		if !reflect.DeepEqual(decoded, x) {
+			t.Fatalf("seed %d: decoded syntax tree of %s differs\ngot:  %s", seed, text, data)
+		}
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	a, err := New(42).Source()
+	if err != nil {
+		t.Fatalf("Source() error = %v", err)
+	}
+	b, err := New(42).Source()
+	if err != nil {
+		t.Fatalf("Source() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("expected the same seed to generate the same file\n%s\n---\n%s", a, b)
+	}
+}
@@ -0,0 +1,131 @@
+// Package diagnostic reports the problems found in .apimock files in forms
+// that editors and CI systems read: JSON and SARIF.
+package diagnostic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Severity levels, named as in SARIF.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Codes identify the kind of problem a diagnostic reports. Errors of the
+// parser carry the apimock.ErrorCode of the problem instead, and fall back to
+// CodeParse and CodeValidation when they have none.
+const (
+	CodeParse      = "parse-error"
+	CodeValidation = "validation-error"
+	CodeLoad       = "load-error"
+	CodeDraft      = "draft-response"
+)
+
+// descriptions explains the codes in the SARIF tool driver.
+var descriptions = map[string]string{
+	CodeParse:      "The file does not follow the .apimock syntax",
+	CodeValidation: "The file parses but declares invalid values",
+	CodeLoad:       "The file could not be read or turned into an endpoint",
+	CodeDraft:      "The response is a draft (its description starts with TODO)",
+
+	string(apimock.CodeMissingResponse):     "The file declares no response section",
+	string(apimock.CodeMissingRequestLine):  "The request section does not start with a method and/or path",
+	string(apimock.CodeInvalidResponseLine): "A response section does not start with -- CODE: Description",
+	string(apimock.CodeInvalidStatusCode):   "A response declares a status code outside 100-599",
+	string(apimock.CodeInvalidProxyURL):     "A proxy section does not point to an http or https URL",
+	string(apimock.CodeMissingPath):         "The request section declares no path",
+	string(apimock.CodeInvalidMethod):       "The request section declares an unknown HTTP method",
+	string(apimock.CodeIncludeNotFound):     "An @include directive names a file that cannot be read",
+	string(apimock.CodeIncludeCycle):        "A file includes itself, directly or through other files",
+	string(apimock.CodeUndefinedEnv):        "A ${NAME} reference names an unset environment variable and gives no default",
+	string(apimock.CodeInvalidExpression):   "A condition line is not a valid expression",
+	string(apimock.CodeUnknownFunction):     "A condition line calls a function that is neither built in nor registered",
+}
+
+// Diagnostic is one problem found in a file. Line and Column start at 1 and
+// are 0 when unknown.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Suggestion is a hint on how to fix the problem, when there is one
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// FromFileError describes why a file failed to load, with one diagnostic per
+// parse error found in it.
+func FromFileError(err *endpoint.FileError) []Diagnostic {
+	if parseErrs := apimock.ParseErrorsOf(err.Err); len(parseErrs) > 0 {
+		diags := make([]Diagnostic, len(parseErrs))
+		for i, parseErr := range parseErrs {
+			diags[i] = Diagnostic{
+				File:       err.FilePath,
+				Line:       parseErr.Line,
+				Column:     parseErr.Column,
+				Code:       codeOr(parseErr.Code, CodeParse),
+				Severity:   SeverityError,
+				Message:    parseErr.Message,
+				Suggestion: parseErr.Suggestion,
+			}
+		}
+		return diags
+	}
+
+	d := Diagnostic{
+		File:     err.FilePath,
+		Code:     CodeLoad,
+		Severity: SeverityError,
+		Message:  err.Err.Error(),
+	}
+	var validationErr *apimock.ValidationError
+	if errors.As(err.Err, &validationErr) {
+		d.Code = codeOr(validationErr.Code, CodeValidation)
+		d.Line, d.Message = validationErr.Line, validationErr.Error()
+	}
+	return []Diagnostic{d}
+}
+
+func codeOr(code apimock.ErrorCode, fallback string) string {
+	if code == "" {
+		return fallback
+	}
+	return string(code)
+}
+
+// Drafts returns a warning for each draft response of the endpoints.
+func Drafts(endpoints []*endpoint.EndpointWithFile) []Diagnostic {
+	var diags []Diagnostic
+	for _, ep := range endpoints {
+		for _, resp := range ep.Schema.Drafts() {
+			diags = append(diags, Diagnostic{
+				File:     ep.FilePath,
+				Line:     resp.Lines.Start,
+				Code:     CodeDraft,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s -> [%d] %s is a draft", ep.Schema.Route, resp.StatusCode, resp.Title),
+			})
+		}
+	}
+	return diags
+}
+
+// WriteJSON writes the diagnostics as an indented JSON array.
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(diags)
+}
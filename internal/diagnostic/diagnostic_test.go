@@ -0,0 +1,126 @@
+package diagnostic
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func loadBroken(t *testing.T, content string) *endpoint.FileError {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "broken.apimock")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, broken := endpoint.LoadAPIMockFiles(path)
+	if len(broken) != 1 {
+		t.Fatalf("expected the file to fail to load, got %d errors", len(broken))
+	}
+	return broken[0]
+}
+
+func TestFromFileError(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantCode   string
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:       "parse error keeps its position",
+			content:    "GET /users\n\n-- 999: Weird\n",
+			wantCode:   string(apimock.CodeInvalidStatusCode),
+			wantLine:   3,
+			wantColumn: 4,
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			wantCode: string(apimock.CodeMissingResponse),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := FromFileError(loadBroken(t, tt.content))
+			if len(diags) != 1 {
+				t.Fatalf("expected one diagnostic, got %+v", diags)
+			}
+			d := diags[0]
+			if d.Code != tt.wantCode || d.Line != tt.wantLine || d.Column != tt.wantColumn || d.Severity != SeverityError {
+				t.Errorf("got %+v, want code %s at %d:%d", d, tt.wantCode, tt.wantLine, tt.wantColumn)
+			}
+			if d.Message == "" {
+				t.Error("expected a message")
+			}
+		})
+	}
+}
+
+func TestFromFileError_OtherErrors(t *testing.T) {
+	d := FromFileError(&endpoint.FileError{FilePath: "gone.apimock", Err: os.ErrNotExist})[0]
+	if d.Code != CodeLoad || d.File != "gone.apimock" || d.Line != 0 {
+		t.Errorf("got %+v", d)
+	}
+}
+
+func TestFromFileError_EveryParseError(t *testing.T) {
+	diags := FromFileError(loadBroken(t, "-- 999: Weird\n\n-- 200: OK\n\n-- 700: Weirder\n"))
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got %+v", diags)
+	}
+	if diags[0].Line != 1 || diags[1].Line != 5 {
+		t.Errorf("expected errors at lines 1 and 5, got %d and %d", diags[0].Line, diags[1].Line)
+	}
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("got %q, want an empty array", got)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	diags := []Diagnostic{
+		{File: "a.apimock", Line: 3, Code: CodeParse, Severity: SeverityError, Message: "bad status"},
+		{File: "b.apimock", Code: CodeLoad, Severity: SeverityError, Message: "unreadable"},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, diags); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if region := results[0].Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 3 {
+		t.Errorf("expected the first result at line 3, got %+v", region)
+	}
+	if region := results[1].Locations[0].PhysicalLocation.Region; region != nil {
+		t.Errorf("expected no region without a line, got %+v", region)
+	}
+	if results[0].RuleID != CodeParse || results[0].Level != "error" {
+		t.Errorf("got %+v", results[0])
+	}
+	if rules := log.Runs[0].Tool.Driver.Rules; len(rules) != 2 || rules[0].ShortDescription == nil {
+		t.Errorf("expected a described rule per code, got %+v", rules)
+	}
+}
@@ -2,6 +2,7 @@ package discovery
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -107,3 +108,40 @@ func findInDirectory(dirPath string) ([]string, error) {
 
 	return files, nil
 }
+
+// FindAPIMockFilesFS returns the .apimock files of fsys under root, which is
+// either a directory, searched recursively, or an .apimock file. Paths are
+// slash-separated, as fs.FS paths are.
+func FindAPIMockFilesFS(fsys fs.FS, root string) ([]string, error) {
+	var files []string
+
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if ignoredDirs[d.Name()] && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(path, ".apimock") {
+			files = append(files, path)
+		} else if path == root {
+			return fmt.Errorf("file '%s' is not an .apimock file", path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search '%s': %w", root, err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .apimock files found in '%s'", root)
+	}
+
+	return files, nil
+}
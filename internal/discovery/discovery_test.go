@@ -3,7 +3,9 @@ package discovery
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"testing/fstest"
 )
 
 func TestFindAPIMockFiles_SingleFile(t *testing.T) {
@@ -168,3 +170,33 @@ func TestFindAPIMockFiles_NoDuplicates(t *testing.T) {
 		t.Errorf("Expected 1 file (no duplicates), got %d", len(files))
 	}
 }
+
+func TestFindAPIMockFilesFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mocks/users.apimock":                 {Data: []byte("users")},
+		"mocks/orders/list.apimock":           {Data: []byte("orders")},
+		"mocks/orders/README.md":              {Data: []byte("docs")},
+		"mocks/node_modules/dep/x.apimock":    {Data: []byte("ignored")},
+		"other/elsewhere.apimock":             {Data: []byte("other")},
+		"mocks/.git/hooks/pre-commit.apimock": {Data: []byte("ignored")},
+	}
+
+	files, err := FindAPIMockFilesFS(fsys, "mocks")
+	if err != nil {
+		t.Fatalf("FindAPIMockFilesFS() error = %v", err)
+	}
+	want := []string{"mocks/orders/list.apimock", "mocks/users.apimock"}
+	if !slices.Equal(files, want) {
+		t.Errorf("FindAPIMockFilesFS() = %v, want %v", files, want)
+	}
+
+	if files, err := FindAPIMockFilesFS(fsys, "mocks/users.apimock"); err != nil || len(files) != 1 {
+		t.Errorf("FindAPIMockFilesFS() on a file = %v, %v", files, err)
+	}
+	if _, err := FindAPIMockFilesFS(fsys, "mocks/orders/README.md"); err == nil {
+		t.Error("expected an error for a file that is not an .apimock file")
+	}
+	if _, err := FindAPIMockFilesFS(fsys, "missing"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -2,11 +2,64 @@ package endpoint
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pretodev/anansi-proxy/internal/i18n"
 	"github.com/pretodev/anansi-proxy/pkg/apimock"
 )
 
+// responseControlProperties are the response properties that configure the
+// mock itself. Every other response property is sent as an HTTP header.
+var responseControlProperties = map[string]bool{
+	ResponseContentTypePropertyName:         true,
+	ResponseSOAPFaultPropertyName:           true,
+	ResponseCallbackPropertyName:            true,
+	ResponseCallbackDelayPropertyName:       true,
+	ResponseCallbackBodyPropertyName:        true,
+	ResponseCallbackContentTypePropertyName: true,
+	ResponseProfilePropertyName:             true,
+	ResponseSchemaPropertyName:              true,
+	ResponseScriptPropertyName:              true,
+}
+
+// IsResponseControlProperty reports whether a response property configures
+// the mock rather than being sent as a header.
+func IsResponseControlProperty(key string) bool {
+	return responseControlProperties[key]
+}
+
+// requestControlProperties are the request properties that configure the
+// mock itself. Every other request property names a header requests send.
+var requestControlProperties = map[string]bool{
+	RequestAcceptPropertyName:         true,
+	RequestBudgetPropertyName:         true,
+	RequestCompressPropertyName:       true,
+	RequestETagPropertyName:           true,
+	RequestSessionPropertyName:        true,
+	RequestSessionCookiePropertyName:  true,
+	RequestMaxCallsPropertyName:       true,
+	RequestMaxBodySizePropertyName:    true,
+	RequestJWTPropertyName:            true,
+	RequestJWTSecretPropertyName:      true,
+	RequestRateLimitPropertyName:      true,
+	RequestChaosPropertyName:          true,
+	RequestRequiredStatusPropertyName: true,
+	RequestMatchBodyPropertyName:      true,
+	RequestSOAPBodyPropertyName:       true,
+}
+
+// IsRequestControlProperty reports whether a request property configures
+// the mock rather than naming a header of the request.
+func IsRequestControlProperty(key string) bool {
+	return requestControlProperties[key]
+}
+
 // EndpointWithFile represents an endpoint schema along with its source file
 type EndpointWithFile struct {
 	Schema   *EndpointSchema
@@ -16,31 +69,177 @@ type EndpointWithFile struct {
 // FromAPIMockFile converts an APIMockFile to an EndpointSchema.
 // This adapter allows the internal server and UI to work with the apimock package.
 func FromAPIMockFile(ast *apimock.APIMockFile) (*EndpointSchema, error) {
+	return fromAPIMockFile(ast, "")
+}
+
+// fromAPIMockFile converts the AST of the file at filePath, resolving the
+// $refs of its request schema from the directory of the file.
+func fromAPIMockFile(ast *apimock.APIMockFile, filePath string) (*EndpointSchema, error) {
 	if len(ast.Responses) == 0 {
 		return nil, fmt.Errorf("no responses found in APIMock file")
 	}
 
 	endpoint := &EndpointSchema{
-		Route:     "/",
-		Accept:    DefaultContentType,
-		Responses: make(map[int][]Response),
+		Route:         "/",
+		Accept:        DefaultContentType,
+		Responses:     make(map[int][]Response),
+		SessionCookie: DefaultSessionCookie,
+		Includes:      ast.Includes,
 	}
 
+	soap := false
+	version := SOAP11
 	if ast.Request != nil {
 		method := ""
 		if ast.Request.Method != "" {
 			method = strings.ToUpper(ast.Request.Method) + " "
 		}
 
-		endpoint.Route = method + ast.Request.Path
+		path := ast.Request.Path
+		if hasPathPatterns(ast.Request.PathSegments) {
+			routePath, matcher, err := RoutePath(ast.Request.PathSegments)
+			if err != nil {
+				return nil, err
+			}
+			path = routePath
+			if matcher != nil {
+				endpoint.Matchers = append(endpoint.Matchers, matcher)
+			}
+		}
+
+		endpoint.Route = method + path
+		endpoint.ParamTypes = ParamTypes(ast.Request.PathSegments)
+		endpoint.RequestLines = ast.Request.Lines
+		endpoint.Metadata = ast.Request.Metadata()
 
 		if contentType, ok := ast.Request.Properties[RequestAcceptPropertyName]; ok {
 			endpoint.Accept = contentType
 		}
 
-		if ast.Request.BodySchema != "" {
+		if budget, ok := ast.Request.Properties[RequestBudgetPropertyName]; ok {
+			d, err := time.ParseDuration(strings.TrimSpace(budget))
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s %q: expected a positive duration such as 200ms", RequestBudgetPropertyName, budget)
+			}
+			endpoint.Budget = d
+		}
+
+		if compress, ok, err := boolProperty(ast.Request.Properties, RequestCompressPropertyName); err != nil {
+			return nil, err
+		} else if ok {
+			endpoint.Compress = compress
+		}
+
+		if etag, ok, err := boolProperty(ast.Request.Properties, RequestETagPropertyName); err != nil {
+			return nil, err
+		} else if ok {
+			endpoint.DisableETag = !etag
+		}
+
+		if action, ok := ast.Request.Properties[RequestSessionPropertyName]; ok {
+			action = strings.ToLower(strings.TrimSpace(action))
+			switch action {
+			case SessionCreate, SessionRequire, SessionDestroy:
+				endpoint.Session = action
+			default:
+				return nil, fmt.Errorf("invalid %s %q: expected %s, %s or %s", RequestSessionPropertyName, action, SessionCreate, SessionRequire, SessionDestroy)
+			}
+		}
+
+		if cookie, ok := ast.Request.Properties[RequestSessionCookiePropertyName]; ok {
+			if cookie = strings.TrimSpace(cookie); cookie != "" {
+				endpoint.SessionCookie = cookie
+			}
+		}
+
+		if maxCalls, ok := ast.Request.Properties[RequestMaxCallsPropertyName]; ok {
+			n, err := strconv.Atoi(strings.TrimSpace(maxCalls))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid %s %q: expected a positive number", RequestMaxCallsPropertyName, maxCalls)
+			}
+			endpoint.MaxCalls = n
+		}
+
+		if maxSize, ok := ast.Request.Properties[RequestMaxBodySizePropertyName]; ok {
+			size, err := parseByteSize(maxSize)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", RequestMaxBodySizePropertyName, maxSize, err)
+			}
+			endpoint.MaxBodySize = size
+		}
+
+		if limit, ok := ast.Request.Properties[RequestRateLimitPropertyName]; ok {
+			rateLimit, err := parseRateLimit(limit)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", RequestRateLimitPropertyName, limit, err)
+			}
+			endpoint.RateLimit = rateLimit
+		}
+
+		if chaos, ok := ast.Request.Properties[RequestChaosPropertyName]; ok {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(chaos), 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf("invalid %s %q: expected a fraction of responses such as 0.1", RequestChaosPropertyName, chaos)
+			}
+			endpoint.Chaos = rate
+		}
+
+		endpoint.RequiredHeaders = ast.Request.RequiredProperties
+		endpoint.RequiredQuery = ast.Request.RequiredQuery
+		endpoint.RequiredStatus = http.StatusBadRequest
+		if status, ok := ast.Request.Properties[RequestRequiredStatusPropertyName]; ok {
+			code, err := strconv.Atoi(strings.TrimSpace(status))
+			if err != nil || code < 400 || code > 599 {
+				return nil, fmt.Errorf("invalid %s %q: expected a 4xx or 5xx status code", RequestRequiredStatusPropertyName, status)
+			}
+			endpoint.RequiredStatus = code
+		}
+
+		if jwt, ok := ast.Request.Properties[RequestJWTPropertyName]; ok {
+			if strings.ToLower(strings.TrimSpace(jwt)) != "required" {
+				return nil, fmt.Errorf("invalid %s %q: expected required", RequestJWTPropertyName, jwt)
+			}
+			endpoint.RequireJWT = true
+		}
+
+		if secret, ok := ast.Request.Properties[RequestJWTSecretPropertyName]; ok {
+			endpoint.RequireJWT = true
+			endpoint.JWTSecret = secret
+		}
+
+		if predicate, ok := ast.Request.Properties[RequestMatchBodyPropertyName]; ok {
+			matcher, err := NewBodyMatcher(predicate)
+			if err != nil {
+				return nil, err
+			}
+			endpoint.Matchers = append(endpoint.Matchers, matcher)
+		}
+
+		if IsSOAPEndpoint(endpoint.Accept, ast.Request.Properties) {
+			soap = true
+			version = SOAPVersionOf(endpoint.Accept)
+			endpoint.Matchers = append(endpoint.Matchers, &SOAPMatcher{
+				Action:      strings.Trim(ast.Request.Properties[RequestSOAPActionPropertyName], `"`),
+				BodyElement: ast.Request.Properties[RequestSOAPBodyPropertyName],
+			})
+			validator, err := NewSOAPValidator(ast.Request.BodySchema)
+			if skipsXSD(err) {
+				warnXSDSkipped(endpoint.Route, err)
+				// Still check the envelope
+				validator, err = NewSOAPValidator("")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to create SOAP validator: %w", err)
+			}
+			endpoint.Body = ast.Request.BodySchema
+			endpoint.Validator = validator
+		} else if ast.Request.BodySchema != "" {
 			endpoint.Body = ast.Request.BodySchema
-			validator, err := NewValidator(endpoint.Accept, endpoint.Body)
+			validator, err := NewValidatorAt(endpoint.Accept, endpoint.Body, filePath)
+			if skipsXSD(err) {
+				warnXSDSkipped(endpoint.Route, err)
+				validator, err = nil, nil
+			}
 			if err != nil {
 				return nil, fmt.Errorf("failed to create schema validator: %w", err)
 			}
@@ -50,11 +249,27 @@ func FromAPIMockFile(ast *apimock.APIMockFile) (*EndpointSchema, error) {
 
 	// Convert each response section
 	for _, resp := range ast.Responses {
+		if resp.Upstream != "" {
+			if endpoint.Upstream != nil {
+				return nil, fmt.Errorf("more than one proxy section")
+			}
+			upstream, err := upstreamFromSection(resp)
+			if err != nil {
+				return nil, err
+			}
+			endpoint.Upstream = upstream
+			continue
+		}
+
 		response := Response{
 			Title:       resp.Description,
-			Body:        resp.Body,
+			Draft:       strings.HasPrefix(resp.Description, DraftMarker),
+			Body:        resp.Content(),
 			ContentType: DefaultContentType,
 			StatusCode:  resp.StatusCode,
+			Lines:       resp.Lines,
+			Metadata:    resp.Metadata(),
+			Conditions:  resp.Conditions(),
 		}
 
 		// If no description, create a default one
@@ -62,9 +277,42 @@ func FromAPIMockFile(ast *apimock.APIMockFile) (*EndpointSchema, error) {
 			response.Title = fmt.Sprintf("Response %d", resp.StatusCode)
 		}
 
+		if soap {
+			response.ContentType = SOAP11ContentType
+			if version == SOAP12 {
+				response.ContentType = SOAP12ContentType
+			}
+		}
+
 		if contentType, ok := resp.Properties[ResponseContentTypePropertyName]; ok {
 			response.ContentType = contentType
 		}
+		response.Profiles = PropertyProfiles(resp.Properties)
+		response.SchemaFile = strings.TrimSpace(resp.Properties[ResponseSchemaPropertyName])
+		response.ScriptFile = strings.TrimSpace(resp.Properties[ResponseScriptPropertyName])
+
+		if code, ok := resp.Properties[ResponseSOAPFaultPropertyName]; ok {
+			response.Body = SOAPFault(version, code, resp.Description, response.Body)
+		}
+
+		callback, err := callbackFromProperties(resp.Properties)
+		if err != nil {
+			return nil, err
+		}
+		response.Callback = callback
+
+		for key, value := range resp.Properties {
+			if responseControlProperties[key] || apimock.IsMetadataKey(key) {
+				continue
+			}
+			if suggestion, ok := suggestResponseProperty(key); ok {
+				warnResponseProperty(endpoint.Route, resp.StatusCode, key, suggestion)
+			}
+			if response.Headers == nil {
+				response.Headers = make(map[string]string)
+			}
+			response.Headers[key] = value
+		}
 
 		if _, exists := endpoint.Responses[response.StatusCode]; !exists {
 			endpoint.Responses[response.StatusCode] = make([]Response, 0)
@@ -87,7 +335,33 @@ func ParseAPIMock(filePath string) (*EndpointSchema, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create parser for '%s': %w", filePath, err)
 	}
+	return parseAPIMock(nil, filePath, parser)
+}
 
+// ParseAPIMockFS parses the .apimock file name of fsys, such as an embed.FS.
+// Its includes and response schema files are read from fsys; relative $refs
+// of its JSON Schemas are left unresolved.
+func ParseAPIMockFS(fsys fs.FS, name string) (*EndpointSchema, error) {
+	parser, err := apimock.NewParserFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser for '%s': %w", name, err)
+	}
+	return parseAPIMock(fsys, name, parser)
+}
+
+// ParseAPIMockReader parses an .apimock document read from r, such as
+// standard input. The name stands in for the file path in error messages.
+func ParseAPIMockReader(name string, r io.Reader) (*EndpointSchema, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", name, err)
+	}
+	return parseAPIMock(nil, name, apimock.NewParserFromBytes(name, content))
+}
+
+// parseAPIMock parses the file at filePath of fsys, nil for the operating
+// system's files.
+func parseAPIMock(fsys fs.FS, filePath string, parser *apimock.Parser) (*EndpointSchema, error) {
 	// Parse the file
 	ast, err := parser.Parse()
 	if err != nil {
@@ -100,28 +374,60 @@ func ParseAPIMock(filePath string) (*EndpointSchema, error) {
 	}
 
 	// Convert to EndpointSchema
-	endpoint, err := FromAPIMockFile(ast)
+	location := filePath
+	if fsys != nil {
+		location = ""
+	}
+	endpoint, err := fromAPIMockFile(ast, location)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert APIMock file '%s': %w", filePath, err)
 	}
+	if err := endpoint.loadResponseSchemas(fsys, filepath.Dir(filePath)); err != nil {
+		return nil, fmt.Errorf("failed to load response schemas of '%s': %w", filePath, err)
+	}
+	if err := endpoint.loadResponseScripts(fsys, filepath.Dir(filePath)); err != nil {
+		return nil, fmt.Errorf("failed to load response scripts of '%s': %w", filePath, err)
+	}
 
 	return endpoint, nil
 }
 
-// ParseAPIMockFiles parses multiple .apimock files and returns a slice of EndpointWithFile.
-// This function processes each file and collects all successfully parsed endpoints.
-func ParseAPIMockFiles(filePaths ...string) ([]*EndpointWithFile, error) {
-	if len(filePaths) == 0 {
-		return nil, fmt.Errorf("no file paths provided")
+// FileError is the reason an .apimock file could not be loaded.
+type FileError struct {
+	FilePath string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.FilePath, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Detail describes e for people: each parse error of the file followed by
+// the source line it points to and a fix suggestion, or the error itself
+// when the file did not fail to parse. Parse errors already name the file.
+func (e *FileError) Detail() string {
+	if len(apimock.ParseErrorsOf(e.Err)) == 0 {
+		return e.Error()
 	}
+	return apimock.Explain(e.Err)
+}
 
+// LoadAPIMockFiles parses every file it is given, returning the endpoints of
+// the files that parsed and the errors of those that did not, so one broken
+// mock does not keep the others from being served. Files that another file
+// pulls in with @include are fragments and are not loaded on their own.
+func LoadAPIMockFiles(filePaths ...string) ([]*EndpointWithFile, []*FileError) {
 	endpoints := make([]*EndpointWithFile, 0, len(filePaths))
-	var errors []string
+	var errs []*FileError
 
 	for _, filePath := range filePaths {
 		endpoint, err := ParseAPIMock(filePath)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("- %s: %v", filePath, err))
+			errs = append(errs, &FileError{FilePath: filePath, Err: err})
 			continue
 		}
 
@@ -130,14 +436,181 @@ func ParseAPIMockFiles(filePaths ...string) ([]*EndpointWithFile, error) {
 			FilePath: filePath,
 		})
 	}
+	return withoutFragments(endpoints, errs)
+}
+
+// LoadAPIMockFS parses the files names of fsys as LoadAPIMockFiles does.
+func LoadAPIMockFS(fsys fs.FS, names ...string) ([]*EndpointWithFile, []*FileError) {
+	endpoints := make([]*EndpointWithFile, 0, len(names))
+	var errs []*FileError
+
+	for _, name := range names {
+		endpoint, err := ParseAPIMockFS(fsys, name)
+		if err != nil {
+			errs = append(errs, &FileError{FilePath: name, Err: err})
+			continue
+		}
+
+		endpoints = append(endpoints, &EndpointWithFile{
+			Schema:   endpoint,
+			FilePath: name,
+		})
+	}
+	return withoutFragments(endpoints, errs)
+}
+
+// withoutFragments drops the endpoints and errors of the files included by
+// the endpoints.
+func withoutFragments(endpoints []*EndpointWithFile, errs []*FileError) ([]*EndpointWithFile, []*FileError) {
+	included := make(map[string]bool)
+	for _, ep := range endpoints {
+		for _, path := range ep.Schema.Includes {
+			if abs, err := filepath.Abs(path); err == nil {
+				included[abs] = true
+			}
+		}
+	}
+	if len(included) == 0 {
+		return endpoints, errs
+	}
+
+	isFragment := func(path string) bool {
+		abs, err := filepath.Abs(path)
+		return err == nil && included[abs]
+	}
+	kept := endpoints[:0]
+	for _, ep := range endpoints {
+		if !isFragment(ep.FilePath) {
+			kept = append(kept, ep)
+		}
+	}
+	var keptErrs []*FileError
+	for _, err := range errs {
+		if !isFragment(err.FilePath) {
+			keptErrs = append(keptErrs, err)
+		}
+	}
+	return kept, keptErrs
+}
+
+// ParseAPIMockFiles parses multiple .apimock files and returns a slice of EndpointWithFile.
+// This function processes each file and collects all successfully parsed endpoints.
+func ParseAPIMockFiles(filePaths ...string) ([]*EndpointWithFile, error) {
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("no file paths provided")
+	}
 
-	if len(errors) > 0 {
+	endpoints, errs := LoadAPIMockFiles(filePaths...)
+	if len(errs) > 0 {
 		if len(endpoints) == 0 {
-			return nil, fmt.Errorf("failed to parse all files:\n%s", strings.Join(errors, "\n"))
+			return nil, fmt.Errorf("failed to parse all files:\n%s", FormatFileErrors(errs))
 		}
 		// Log warnings but continue if we have at least some valid endpoints
-		fmt.Printf("Warning: some files failed to parse:\n%s\n", strings.Join(errors, "\n"))
+		fmt.Println(i18n.T("Warning: some files failed to parse:") + "\n" + FormatFileErrors(errs))
 	}
 
 	return endpoints, nil
 }
+
+// FormatFileErrors lists errs one per line, as "- path: error", with the
+// details of parse errors below them.
+func FormatFileErrors(errs []*FileError) string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = "- " + strings.ReplaceAll(err.Detail(), "\n", "\n  ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func warnXSDSkipped(route string, err error) {
+	fmt.Println(i18n.T("Warning: %s: %v; requests are not validated. Use --strict-xsd to fail instead.", route, err))
+}
+
+// ratePeriods are the period names accepted by parseRateLimit.
+var ratePeriods = map[string]time.Duration{
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// parseRateLimit parses a rate such as 10/min, 5/s or 100/15m: a number of
+// requests over a period name or a duration.
+func parseRateLimit(s string) (*RateLimit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if !ok || err != nil || n <= 0 {
+		return nil, fmt.Errorf("expected a rate such as 10/min or 100/1h")
+	}
+
+	period = strings.ToLower(strings.TrimSpace(period))
+	d, known := ratePeriods[period]
+	if !known {
+		d, err = time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("expected a period such as s, min, hour, day or 15m")
+		}
+	}
+	return &RateLimit{Requests: n, Period: d}, nil
+}
+
+// byteUnits are the size suffixes accepted by parseByteSize, longest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a positive size such as 512, 512B, 10KB or 1MB. Units
+// are powers of 1024.
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(number), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive size such as 512, 10KB or 1MB")
+	}
+	return n * multiplier, nil
+}
+
+// boolProperty parses the property name as a boolean. ok is false when the
+// property is not declared.
+func boolProperty(properties map[string]string, name string) (value, ok bool, err error) {
+	raw, ok := properties[name]
+	if !ok {
+		return false, false, nil
+	}
+	value, err = strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return false, false, fmt.Errorf("invalid %s %q: expected true or false", name, raw)
+	}
+	return value, true, nil
+}
+
+// hasPathPatterns reports whether a path uses wildcards or constrained
+// parameters, which net/http patterns cannot express directly.
+func hasPathPatterns(segments []apimock.PathSegment) bool {
+	for _, seg := range segments {
+		if seg.IsWildcard || seg.Pattern != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,430 @@
+package endpoint
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func newTestAPIMockFile(properties map[string]string) *apimock.APIMockFile {
+	req := apimock.NewRequestSection()
+	req.Method = "GET"
+	req.Path = "/api/users"
+	for k, v := range properties {
+		req.Properties[k] = v
+	}
+
+	resp := apimock.NewResponseSection()
+	resp.StatusCode = 200
+	resp.Description = "OK"
+
+	ast := apimock.NewAPIMockFile()
+	ast.Request = req
+	ast.Responses = append(ast.Responses, resp)
+	return ast
+}
+
+func TestFromAPIMockFile_Budget(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "milliseconds", budget: "200ms", want: 200 * time.Millisecond},
+		{name: "seconds", budget: "2s", want: 2 * time.Second},
+		{name: "surrounding spaces", budget: " 150ms ", want: 150 * time.Millisecond},
+		{name: "missing unit", budget: "200", wantErr: true},
+		{name: "negative", budget: "-1s", wantErr: true},
+		{name: "zero", budget: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := newTestAPIMockFile(map[string]string{RequestBudgetPropertyName: tt.budget})
+
+			schema, err := FromAPIMockFile(ast)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for budget %q", tt.budget)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.Budget != tt.want {
+				t.Errorf("expected budget %s, got %s", tt.want, schema.Budget)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_NoBudget(t *testing.T) {
+	schema, err := FromAPIMockFile(newTestAPIMockFile(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.Budget != 0 {
+		t.Errorf("expected no budget, got %s", schema.Budget)
+	}
+}
+
+func TestEndpointSchema_SliceResponses_DeclarationOrder(t *testing.T) {
+	schema := &EndpointSchema{
+		Responses: map[int][]Response{
+			500: {{StatusCode: 500, Title: "Error", Lines: apimock.LineRange{Start: 5, End: 8}}},
+			200: {
+				{StatusCode: 200, Title: "First", Lines: apimock.LineRange{Start: 10, End: 14}},
+				{StatusCode: 200, Title: "Second", Lines: apimock.LineRange{Start: 20, End: 24}},
+			},
+			404: {{StatusCode: 404, Title: "Missing", Lines: apimock.LineRange{Start: 15, End: 18}}},
+		},
+	}
+
+	want := []string{"Error", "First", "Missing", "Second"}
+	for range 10 {
+		var got []string
+		for _, resp := range schema.SliceResponses() {
+			got = append(got, resp.Title)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("SliceResponses() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFromAPIMockFile_ResponseHeaders(t *testing.T) {
+	ast := newTestAPIMockFile(nil)
+	ast.Responses[0].Properties[ResponseContentTypePropertyName] = "application/json"
+	ast.Responses[0].Properties["Location"] = "/api/users/{{body.id}}"
+	ast.Responses[0].Properties["X-Request-ID"] = "abc-123"
+	ast.Responses[0].Properties["X-Meta-Reviewed-By"] = "alice"
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, _ := schema.GetResponseByStatusCode(200)
+	if len(resp.Headers) != 2 {
+		t.Fatalf("expected 2 headers, got %v", resp.Headers)
+	}
+	if resp.Headers["Location"] != "/api/users/{{body.id}}" {
+		t.Errorf("expected Location header to be kept verbatim, got %q", resp.Headers["Location"])
+	}
+	if _, ok := resp.Headers[ResponseContentTypePropertyName]; ok {
+		t.Error("expected ContentType to configure the response instead of being sent as a header")
+	}
+	if _, ok := resp.Headers["X-Meta-Reviewed-By"]; ok {
+		t.Error("expected metadata not to be sent as a header")
+	}
+}
+
+func TestFromAPIMockFile_Compress(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "enabled", compress: "true", want: true},
+		{name: "disabled", compress: "false", want: false},
+		{name: "surrounding spaces", compress: " true ", want: true},
+		{name: "invalid", compress: "gzip", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := newTestAPIMockFile(map[string]string{RequestCompressPropertyName: tt.compress})
+
+			schema, err := FromAPIMockFile(ast)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for compress %q", tt.compress)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.Compress != tt.want {
+				t.Errorf("expected compress %v, got %v", tt.want, schema.Compress)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_ETag(t *testing.T) {
+	tests := []struct {
+		name        string
+		properties  map[string]string
+		wantDisable bool
+		wantErr     bool
+	}{
+		{name: "default", properties: nil, wantDisable: false},
+		{name: "enabled", properties: map[string]string{RequestETagPropertyName: "true"}, wantDisable: false},
+		{name: "disabled", properties: map[string]string{RequestETagPropertyName: "false"}, wantDisable: true},
+		{name: "invalid", properties: map[string]string{RequestETagPropertyName: "off"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := FromAPIMockFile(newTestAPIMockFile(tt.properties))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.DisableETag != tt.wantDisable {
+				t.Errorf("expected DisableETag %v, got %v", tt.wantDisable, schema.DisableETag)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_Session(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		wantAction string
+		wantCookie string
+		wantErr    bool
+	}{
+		{name: "no session", properties: nil, wantCookie: DefaultSessionCookie},
+		{name: "create", properties: map[string]string{RequestSessionPropertyName: "create"}, wantAction: SessionCreate, wantCookie: DefaultSessionCookie},
+		{name: "case insensitive", properties: map[string]string{RequestSessionPropertyName: "Require"}, wantAction: SessionRequire, wantCookie: DefaultSessionCookie},
+		{name: "custom cookie", properties: map[string]string{RequestSessionPropertyName: "destroy", RequestSessionCookiePropertyName: "sid"}, wantAction: SessionDestroy, wantCookie: "sid"},
+		{name: "invalid", properties: map[string]string{RequestSessionPropertyName: "login"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := FromAPIMockFile(newTestAPIMockFile(tt.properties))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.Session != tt.wantAction || schema.SessionCookie != tt.wantCookie {
+				t.Errorf("expected session %q with cookie %q, got %q with %q", tt.wantAction, tt.wantCookie, schema.Session, schema.SessionCookie)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_Quotas(t *testing.T) {
+	tests := []struct {
+		name         string
+		properties   map[string]string
+		wantMaxCalls int
+		wantMaxBody  int64
+		wantErr      bool
+	}{
+		{name: "no quotas", properties: nil},
+		{name: "max calls", properties: map[string]string{RequestMaxCallsPropertyName: " 3 "}, wantMaxCalls: 3},
+		{name: "bytes", properties: map[string]string{RequestMaxBodySizePropertyName: "512"}, wantMaxBody: 512},
+		{name: "bytes with unit", properties: map[string]string{RequestMaxBodySizePropertyName: "512B"}, wantMaxBody: 512},
+		{name: "kilobytes", properties: map[string]string{RequestMaxBodySizePropertyName: "10KB"}, wantMaxBody: 10 << 10},
+		{name: "megabytes lowercase", properties: map[string]string{RequestMaxBodySizePropertyName: "1 mb"}, wantMaxBody: 1 << 20},
+		{name: "zero calls", properties: map[string]string{RequestMaxCallsPropertyName: "0"}, wantErr: true},
+		{name: "invalid calls", properties: map[string]string{RequestMaxCallsPropertyName: "ten"}, wantErr: true},
+		{name: "invalid size", properties: map[string]string{RequestMaxBodySizePropertyName: "1TB"}, wantErr: true},
+		{name: "negative size", properties: map[string]string{RequestMaxBodySizePropertyName: "-1KB"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := FromAPIMockFile(newTestAPIMockFile(tt.properties))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.MaxCalls != tt.wantMaxCalls || schema.MaxBodySize != tt.wantMaxBody {
+				t.Errorf("expected quotas %d calls / %d bytes, got %d / %d", tt.wantMaxCalls, tt.wantMaxBody, schema.MaxCalls, schema.MaxBodySize)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_RateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    RateLimit
+		wantErr bool
+	}{
+		{name: "per minute", value: "10/min", want: RateLimit{Requests: 10, Period: time.Minute}},
+		{name: "per second", value: "5/s", want: RateLimit{Requests: 5, Period: time.Second}},
+		{name: "spaces and case", value: " 100 / Hour ", want: RateLimit{Requests: 100, Period: time.Hour}},
+		{name: "duration period", value: "3/15m", want: RateLimit{Requests: 3, Period: 15 * time.Minute}},
+		{name: "missing period", value: "10", wantErr: true},
+		{name: "zero requests", value: "0/min", wantErr: true},
+		{name: "unknown period", value: "10/fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := FromAPIMockFile(newTestAPIMockFile(map[string]string{RequestRateLimitPropertyName: tt.value}))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.RateLimit == nil || *schema.RateLimit != tt.want {
+				t.Errorf("expected rate limit %+v, got %+v", tt.want, schema.RateLimit)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_Chaos(t *testing.T) {
+	schema, err := FromAPIMockFile(newTestAPIMockFile(map[string]string{RequestChaosPropertyName: "0.25"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.Chaos != 0.25 {
+		t.Errorf("expected chaos rate 0.25, got %v", schema.Chaos)
+	}
+
+	for _, invalid := range []string{"0", "1.5", "-0.1", "often"} {
+		if _, err := FromAPIMockFile(newTestAPIMockFile(map[string]string{RequestChaosPropertyName: invalid})); err == nil {
+			t.Errorf("expected error for Chaos %q", invalid)
+		}
+	}
+}
+
+func TestFromAPIMockFile_RequiredStatus(t *testing.T) {
+	schema, err := FromAPIMockFile(newTestAPIMockFile(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.RequiredStatus != http.StatusBadRequest {
+		t.Errorf("expected required status 400 by default, got %d", schema.RequiredStatus)
+	}
+
+	schema, err = FromAPIMockFile(newTestAPIMockFile(map[string]string{RequestRequiredStatusPropertyName: "401"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.RequiredStatus != http.StatusUnauthorized {
+		t.Errorf("expected required status 401, got %d", schema.RequiredStatus)
+	}
+
+	for _, invalid := range []string{"200", "600", "missing"} {
+		if _, err := FromAPIMockFile(newTestAPIMockFile(map[string]string{RequestRequiredStatusPropertyName: invalid})); err == nil {
+			t.Errorf("expected error for Required-Status %q", invalid)
+		}
+	}
+}
+
+func TestParseAPIMockReader(t *testing.T) {
+	source := "GET /api/users\n\n-- 200: OK\nContentType: application/json\n\n[]\n"
+	schema, err := ParseAPIMockReader("(stdin)", strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("ParseAPIMockReader() error = %v", err)
+	}
+	if schema.Route != "GET /api/users" {
+		t.Errorf("Route = %q, want %q", schema.Route, "GET /api/users")
+	}
+
+	_, err = ParseAPIMockReader("(stdin)", strings.NewReader("GET /api/users\n"))
+	if err == nil || !strings.Contains(err.Error(), "(stdin)") {
+		t.Errorf("error = %v, want one naming (stdin)", err)
+	}
+}
+
+func TestFromAPIMockFile_Draft(t *testing.T) {
+	ast := newTestAPIMockFile(nil)
+	for status, description := range map[int]string{404: "Not found", 501: "TODO implement", 503: "TODO: decide on retry"} {
+		resp := apimock.NewResponseSection()
+		resp.StatusCode = status
+		resp.Description = description
+		ast.Responses = append(ast.Responses, resp)
+	}
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("FromAPIMockFile() error = %v", err)
+	}
+
+	drafts := schema.Drafts()
+	if len(drafts) != 2 || drafts[0].StatusCode != 501 || drafts[1].StatusCode != 503 {
+		t.Errorf("Drafts() = %+v, want the 501 and 503 responses", drafts)
+	}
+}
+
+func TestLoadAPIMockFiles_KeepsGoodFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "users.apimock")
+	bad := filepath.Join(dir, "orders.apimock")
+	if err := os.WriteFile(good, []byte("GET /users\n\n-- 200: OK\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("GET /orders\n\n-- 999: Nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, errs := LoadAPIMockFiles(good, bad)
+	if len(endpoints) != 1 || endpoints[0].FilePath != good {
+		t.Errorf("endpoints = %v, want the one in %s", endpoints, good)
+	}
+	if len(errs) != 1 || errs[0].FilePath != bad {
+		t.Fatalf("errs = %v, want one for %s", errs, bad)
+	}
+	if !strings.HasPrefix(errs[0].Error(), bad+": ") {
+		t.Errorf("Error() = %q, want it prefixed with the path", errs[0].Error())
+	}
+}
+
+func TestLoadAPIMockFiles_SkipsIncludedFragments(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.apimock")
+	errorsFile := filepath.Join(dir, "errors.apimock")
+	headers := filepath.Join(dir, "headers.apimock")
+	files := map[string]string{
+		users:      "GET /users\n@include headers.apimock\n\n-- 200: OK\n\n@include errors.apimock\n",
+		errorsFile: "-- 500: Internal Server Error\n",
+		// Not a valid file on its own
+		headers: "Accept: application/json\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	endpoints, errs := LoadAPIMockFiles(errorsFile, headers, users)
+	if len(errs) != 0 {
+		t.Fatalf("expected fragments not to be reported, got %v", errs)
+	}
+	if len(endpoints) != 1 || endpoints[0].FilePath != users {
+		t.Fatalf("expected only %s to be loaded, got %v", users, endpoints)
+	}
+	if _, ok := endpoints[0].Schema.Responses[500]; !ok {
+		t.Error("expected the included 500 response")
+	}
+}
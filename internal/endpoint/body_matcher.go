@@ -0,0 +1,209 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const RequestMatchBodyPropertyName = "Match-Body"
+
+// bodyOperators lists the comparison operators of a body predicate. Two
+// character operators come first so they are found before their prefixes.
+var bodyOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// BodyMatcher selects an endpoint by a JSONPath predicate on the request
+// body, such as `$.type == "premium"` or `$.items[0].qty > 1`. A predicate
+// without an operator matches when the path exists and is not null.
+type BodyMatcher struct {
+	path     []any // object keys (string) and array indexes (int)
+	operator string
+	value    any
+}
+
+func NewBodyMatcher(expr string) (*BodyMatcher, error) {
+	expr = strings.TrimSpace(expr)
+
+	pathExpr, operator, literal := expr, "", ""
+	for _, op := range bodyOperators {
+		if i := indexOutsideQuotes(expr, op); i >= 0 {
+			pathExpr = strings.TrimSpace(expr[:i])
+			operator = op
+			literal = strings.TrimSpace(expr[i+len(op):])
+			break
+		}
+	}
+
+	path, err := parseJSONPath(pathExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", RequestMatchBodyPropertyName, expr, err)
+	}
+
+	m := &BodyMatcher{path: path, operator: operator}
+	if operator == "" {
+		return m, nil
+	}
+
+	if err := json.Unmarshal([]byte(literal), &m.value); err != nil {
+		return nil, fmt.Errorf("invalid %s %q: value %s is not a JSON literal", RequestMatchBodyPropertyName, expr, literal)
+	}
+	if isOrdering(operator) {
+		switch m.value.(type) {
+		case float64, string:
+		default:
+			return nil, fmt.Errorf("invalid %s %q: %s needs a number or string", RequestMatchBodyPropertyName, expr, operator)
+		}
+	}
+	return m, nil
+}
+
+// Match implements RequestMatcher.
+func (m *BodyMatcher) Match(_ *http.Request, body []byte) bool {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false
+	}
+
+	actual, ok := lookupJSONPath(doc, m.path)
+	switch m.operator {
+	case "":
+		return ok && actual != nil
+	case "==":
+		return ok && reflect.DeepEqual(actual, m.value)
+	case "!=":
+		return !ok || !reflect.DeepEqual(actual, m.value)
+	}
+
+	if !ok {
+		return false
+	}
+	cmp, comparable := compareJSON(actual, m.value)
+	if !comparable {
+		return false
+	}
+	switch m.operator {
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp <= 0
+	}
+}
+
+func isOrdering(operator string) bool {
+	return operator != "==" && operator != "!="
+}
+
+// compareJSON orders two numbers or two strings.
+func compareJSON(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+// parseJSONPath parses the subset of JSONPath used by body predicates:
+// `$` followed by `.key`, `["key"]` and `[index]` steps.
+func parseJSONPath(expr string) ([]any, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+
+	var path []any
+	rest := expr[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := 1
+			for end < len(rest) && rest[end] != '.' && rest[end] != '[' {
+				end++
+			}
+			key := rest[1:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in path %s", expr)
+			}
+			path = append(path, key)
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in path %s", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			if key, err := strconv.Unquote(inner); err == nil {
+				path = append(path, key)
+			} else if index, err := strconv.Atoi(inner); err == nil && index >= 0 {
+				path = append(path, index)
+			} else {
+				return nil, fmt.Errorf("invalid step [%s] in path %s", inner, expr)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %s", rest[0], expr)
+		}
+	}
+	return path, nil
+}
+
+func lookupJSONPath(doc any, path []any) (any, bool) {
+	current := doc
+	for _, step := range path {
+		switch s := step.(type) {
+		case string:
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[s]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := current.([]any)
+			if !ok || s >= len(arr) {
+				return nil, false
+			}
+			current = arr[s]
+		}
+	}
+	return current, true
+}
+
+// indexOutsideQuotes returns the index of the first occurrence of sub that is
+// not inside a double-quoted string, or -1.
+func indexOutsideQuotes(s, sub string) int {
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuotes:
+			i++
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(s[i:], sub):
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBodyMatcher_Match(t *testing.T) {
+	body := `{"type": "premium", "total": 150.5, "items": [{"sku": "A1", "qty": 2}], "coupon": null}`
+
+	tests := []struct {
+		name string
+		expr string
+		body string
+		want bool
+	}{
+		{name: "String equality", expr: `$.type == "premium"`, want: true},
+		{name: "String inequality", expr: `$.type != "basic"`, want: true},
+		{name: "Wrong value", expr: `$.type == "basic"`, want: false},
+		{name: "Number greater", expr: `$.total > 100`, want: true},
+		{name: "Number less or equal", expr: `$.total <= 100`, want: false},
+		{name: "Array index and key", expr: `$.items[0].qty >= 2`, want: true},
+		{name: "Bracket key", expr: `$["items"][0]["sku"] == "A1"`, want: true},
+		{name: "Existence", expr: `$.items`, want: true},
+		{name: "Missing path", expr: `$.missing`, want: false},
+		{name: "Null is not present", expr: `$.coupon`, want: false},
+		{name: "Null equality", expr: `$.coupon == null`, want: true},
+		{name: "Missing path is not equal", expr: `$.missing != "x"`, want: true},
+		{name: "Out of range index", expr: `$.items[3].qty > 0`, want: false},
+		{name: "Operator inside quotes", expr: `$.type == "a==b"`, want: false},
+		{name: "Invalid JSON body", expr: `$.type == "premium"`, body: `type=premium`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewBodyMatcher(tt.expr)
+			if err != nil {
+				t.Fatalf("NewBodyMatcher(%q) error = %v", tt.expr, err)
+			}
+
+			reqBody := body
+			if tt.body != "" {
+				reqBody = tt.body
+			}
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if got := m.Match(req, []byte(reqBody)); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBodyMatcher_Invalid(t *testing.T) {
+	tests := []string{
+		`type == "premium"`,
+		`$.type == premium`,
+		`$.items[x] == 1`,
+		`$.items[0 == 1`,
+		`$. == 1`,
+		`$.total > true`,
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := NewBodyMatcher(expr); err == nil {
+				t.Errorf("expected error for %q", expr)
+			}
+		})
+	}
+}
+
+func TestFromAPIMockFile_MatchBody(t *testing.T) {
+	ast := newTestAPIMockFile(map[string]string{
+		RequestMatchBodyPropertyName: `$.type == "premium"`,
+	})
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+	if !schema.Match(req, []byte(`{"type": "premium"}`)) {
+		t.Error("expected premium body to match")
+	}
+	if schema.Match(req, []byte(`{"type": "basic"}`)) {
+		t.Error("expected basic body not to match")
+	}
+}
@@ -0,0 +1,65 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Response properties declaring a callback fired after the response is sent.
+const (
+	ResponseCallbackPropertyName            = "Callback"
+	ResponseCallbackDelayPropertyName       = "Callback-Delay"
+	ResponseCallbackBodyPropertyName        = "Callback-Body"
+	ResponseCallbackContentTypePropertyName = "Callback-ContentType"
+)
+
+// Callback is a request the server sends after answering, as asynchronous
+// APIs do with webhooks. URL and Body may contain {{...}} placeholders filled
+// from the request that triggered it.
+type Callback struct {
+	Method      string
+	URL         string
+	Delay       time.Duration
+	Body        string
+	ContentType string
+}
+
+// callbackFromProperties reads the callback declared by the properties of a
+// response, which is nil when there is none. The Callback property holds the
+// target URL, optionally preceded by a method (POST by default).
+func callbackFromProperties(properties map[string]string) (*Callback, error) {
+	target, ok := properties[ResponseCallbackPropertyName]
+	if !ok {
+		return nil, nil
+	}
+
+	cb := &Callback{
+		Method:      http.MethodPost,
+		URL:         strings.TrimSpace(target),
+		Body:        properties[ResponseCallbackBodyPropertyName],
+		ContentType: "application/json",
+	}
+	if method, rest, ok := strings.Cut(cb.URL, " "); ok {
+		cb.Method, cb.URL = strings.ToUpper(method), strings.TrimSpace(rest)
+	}
+	if !HasTemplate(cb.URL) {
+		if u, err := url.Parse(cb.URL); err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid %s %q: expected [METHOD] URL, with an absolute http(s) URL or a placeholder", ResponseCallbackPropertyName, target)
+		}
+	}
+
+	if delay, ok := properties[ResponseCallbackDelayPropertyName]; ok {
+		d, err := time.ParseDuration(strings.TrimSpace(delay))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s %q: expected a duration such as 3s", ResponseCallbackDelayPropertyName, delay)
+		}
+		cb.Delay = d
+	}
+	if contentType, ok := properties[ResponseCallbackContentTypePropertyName]; ok {
+		cb.ContentType = contentType
+	}
+	return cb, nil
+}
@@ -0,0 +1,52 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallbackFromProperties(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		want       *Callback
+		wantErr    bool
+	}{
+		{name: "none", properties: map[string]string{"Location": "/payments/1"}},
+		{
+			name:       "url only",
+			properties: map[string]string{"Callback": "{{body.callback_url}}"},
+			want:       &Callback{Method: "POST", URL: "{{body.callback_url}}", ContentType: "application/json"},
+		},
+		{
+			name: "method, delay and body",
+			properties: map[string]string{
+				"Callback":             "put https://client.example.com/hooks",
+				"Callback-Delay":       "3s",
+				"Callback-Body":        `{"status": "paid"}`,
+				"Callback-ContentType": "application/vnd.api+json",
+			},
+			want: &Callback{Method: "PUT", URL: "https://client.example.com/hooks", Delay: 3 * time.Second, Body: `{"status": "paid"}`, ContentType: "application/vnd.api+json"},
+		},
+		{name: "empty url", properties: map[string]string{"Callback": "POST "}, wantErr: true},
+		{name: "invalid delay", properties: map[string]string{"Callback": "http://x", "Callback-Delay": "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callbackFromProperties(tt.properties)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("callbackFromProperties() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
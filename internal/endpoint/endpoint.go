@@ -1,15 +1,41 @@
 package endpoint
 
 import (
+	"cmp"
 	"fmt"
 	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+const (
+	ContentTypeHeader                = "Content-Type"
+	DefaultContentType               = "text/plain; charset=utf-8"
+	RequestAcceptPropertyName        = "Accept"
+	RequestBudgetPropertyName        = "Budget"
+	RequestCompressPropertyName      = "Compress"
+	RequestETagPropertyName          = "ETag"
+	RequestSessionPropertyName       = "Session"
+	RequestSessionCookiePropertyName = "Session-Cookie"
+	RequestMaxCallsPropertyName      = "Max-Calls"
+	RequestMaxBodySizePropertyName   = "Max-Body-Size"
+	RequestJWTPropertyName           = "JWT"
+	RequestJWTSecretPropertyName     = "JWT-Secret"
+	RequestRateLimitPropertyName     = "RateLimit"
+	RequestChaosPropertyName         = "Chaos"
+	ResponseContentTypePropertyName  = "ContentType"
+	DefaultSessionCookie             = "session"
+	// DraftMarker starts the description of unfinished responses
+	DraftMarker = "TODO"
 )
 
+// Session actions of an endpoint, set with the Session request property.
 const (
-	ContentTypeHeader               = "Content-Type"
-	DefaultContentType              = "text/plain; charset=utf-8"
-	RequestAcceptPropertyName       = "Accept"
-	ResponseContentTypePropertyName = "ContentType"
+	SessionCreate  = "create"  // start a session and set its cookie
+	SessionRequire = "require" // answer 401 unless the request has a live session
+	SessionDestroy = "destroy" // end the session and expire its cookie
 )
 
 type Response struct {
@@ -17,6 +43,31 @@ type Response struct {
 	Body        string
 	ContentType string
 	StatusCode  int
+	// Headers holds the declared response headers; values may contain {{...}} placeholders
+	Headers map[string]string
+	// Metadata holds the X-Meta- prefixed response properties (reviewers, ticket links, ...)
+	Metadata map[string]string
+	// Lines locates the response section in its .apimock file
+	Lines apimock.LineRange
+	// Callback is sent after the response, if declared
+	Callback *Callback
+	// Draft marks a response whose description starts with DraftMarker, as in
+	// "-- 501: TODO implement"
+	Draft bool
+	// Profiles lists the profiles the response is served under; responses
+	// without one are served unless the active profile replaces them
+	Profiles []string
+	// SchemaFile is the schema the body must satisfy, as declared by the
+	// Schema property, and Validator checks bodies against it
+	SchemaFile string
+	Validator  SchemaValidator
+	// ScriptFile is the script computing the response, as declared by the
+	// Script property, and Script runs it
+	ScriptFile string
+	Script     Script
+	// Conditions holds the condition lines leading the body, if any: the
+	// response is served to the requests they hold for
+	Conditions []apimock.ConditionLine
 }
 
 func EmptyResponse() Response {
@@ -25,22 +76,130 @@ func EmptyResponse() Response {
 	}
 }
 
+// RequestMatcher decides whether an endpoint answers a request. It lets
+// several endpoints share a route and be told apart by the request content.
+type RequestMatcher interface {
+	Match(r *http.Request, body []byte) bool
+}
+
 type EndpointSchema struct {
 	Route     string
 	Accept    string
 	Body      string
 	Validator SchemaValidator
+	Matchers  []RequestMatcher
 	Responses map[int][]Response
+	// Metadata holds the X-Meta- prefixed request properties (owners, ticket links, ...)
+	Metadata map[string]string
+	// Budget is the expected latency for serving this endpoint (0 = none)
+	Budget time.Duration
+	// Compress enables compressing responses for clients that accept it
+	Compress bool
+	// DisableETag turns off the ETags generated for response bodies
+	DisableETag bool
+	// RequestLines locates the request section in its .apimock file
+	RequestLines apimock.LineRange
+	// Session is the session action of the endpoint, if any
+	Session string
+	// SessionCookie names the cookie holding the session ID
+	SessionCookie string
+	// MaxCalls is the number of calls served before answering 429 (0 = no limit)
+	MaxCalls int
+	// MaxBodySize is the largest request body accepted, in bytes (0 = no limit)
+	MaxBodySize int64
+	// RequireJWT answers 401 to requests without a valid bearer JWT
+	RequireJWT bool
+	// JWTSecret, if set, is the HS256 key bearer tokens must be signed with
+	JWTSecret string
+	// RateLimit is the request rate served before answering 429, if any
+	RateLimit *RateLimit
+	// Chaos is the fraction of responses broken on purpose (0 = server default)
+	Chaos float64
+	// RequiredHeaders and RequiredQuery name the headers and query parameters
+	// requests must send, marked with ! in the request section; requests
+	// missing one get RequiredStatus
+	RequiredHeaders []string
+	RequiredQuery   []string
+	RequiredStatus  int
+	// ParamTypes holds the types of the typed path parameters, by name
+	ParamTypes map[string]string
+	// Upstream, if set, answers the requests the mock does not divert to a
+	// declared error response
+	Upstream *Upstream
+	// Includes lists the files the .apimock file pulls in with @include
+	Includes []string
+}
+
+// RateLimit allows Requests requests per Period, refilled continuously as a
+// token bucket, so bursts of up to Requests requests are served at once.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Match reports whether all matchers of the endpoint accept the request.
+// Endpoints without matchers accept every request on their route.
+func (e *EndpointSchema) Match(r *http.Request, body []byte) bool {
+	for _, m := range e.Matchers {
+		if !m.Match(r, body) {
+			return false
+		}
+	}
+	return true
 }
 
+// SliceResponses returns all responses in the order their sections are
+// declared. Responses is a map, so without sorting the positions used by
+// ResponseIndex, the history and the UI would change from call to call.
 func (e *EndpointSchema) SliceResponses() []Response {
 	var responses []Response
 	for _, respList := range e.Responses {
 		responses = append(responses, respList...)
 	}
+	slices.SortStableFunc(responses, func(a, b Response) int {
+		return cmp.Or(cmp.Compare(a.Lines.Start, b.Lines.Start), cmp.Compare(a.StatusCode, b.StatusCode))
+	})
 	return responses
 }
 
+// ResponseIndex returns the position of resp in SliceResponses, or -1 if it is
+// not a response of the endpoint.
+func (e *EndpointSchema) ResponseIndex(resp Response) int {
+	for i, r := range e.SliceResponses() {
+		if r.StatusCode == resp.StatusCode && r.Title == resp.Title && r.ContentType == resp.ContentType && r.Body == resp.Body && r.Lines == resp.Lines {
+			return i
+		}
+	}
+	return -1
+}
+
+// ConditionalResponses returns the responses with condition lines served
+// under profile, in declaration order: those of the profile and those
+// without one.
+func (e *EndpointSchema) ConditionalResponses(profile string) []Response {
+	var responses []Response
+	for _, resp := range e.SliceResponses() {
+		if len(resp.Conditions) > 0 && (len(resp.Profiles) == 0 || resp.InProfile(profile)) {
+			responses = append(responses, resp)
+		}
+	}
+	slices.SortStableFunc(responses, func(a, b Response) int {
+		return cmp.Compare(a.Lines.Start, b.Lines.Start)
+	})
+	return responses
+}
+
+// Drafts returns the draft responses of the endpoint, ordered by status code.
+func (e *EndpointSchema) Drafts() []Response {
+	var drafts []Response
+	for _, resp := range e.SliceResponses() {
+		if resp.Draft {
+			drafts = append(drafts, resp)
+		}
+	}
+	return drafts
+}
+
 func (e *EndpointSchema) CountResponses() int {
 	return len(e.SliceResponses())
 }
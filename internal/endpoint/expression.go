@@ -0,0 +1,548 @@
+package endpoint
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// maxRange is the largest number of elements a range such as 1..10 may have.
+const maxRange = 10000
+
+// Evaluate computes an arithmetic expression such as `10 - call_count` or
+// `(query.page - 1) * 20`, failing when it is not a number. See
+// apimock.ParseExpression for what expressions may hold.
+func (c *TemplateContext) Evaluate(expr string) (float64, bool) {
+	x, err := apimock.ParseExpression(expr)
+	if err != nil {
+		return 0, false
+	}
+	value, err := c.eval(x)
+	number, isNumber := value.(float64)
+	return number, err == nil && isNumber
+}
+
+// Holds reports whether conditions hold for the request of c: all the lines
+// of one of their alternatives, each alternative starting at a line led by
+// or. Lines are evaluated in order, so the variables a line assigns are seen
+// by the lines that follow, and kept in c.Variables for the placeholders of
+// the response. Lines failing to evaluate do not hold; err joins their
+// *apimock.EvalErrors, whose Expression is the raw line.
+func (c *TemplateContext) Holds(conditions []apimock.ConditionLine) (bool, error) {
+	if c.Variables == nil {
+		c.Variables = make(map[string]any)
+	}
+	holds := true // of the current alternative
+	var errs []error
+	for i, line := range conditions {
+		if line.Or && i > 0 {
+			if holds {
+				break
+			}
+			holds = true
+		}
+		if !holds {
+			continue
+		}
+		if line.Expr == nil {
+			holds = false
+			continue
+		}
+		value, err := c.eval(line.Expr)
+		if err != nil {
+			errs = append(errs, withExpression(err, line.Raw, len(apimock.ConditionPrefix)))
+		}
+		holds = err == nil && apimock.Truthy(value)
+	}
+	return holds, errors.Join(errs...)
+}
+
+// eval computes the value of x. It fails on values of the wrong type, such
+// as adding to a string, and on missing variables, with an
+// *apimock.EvalError locating the part of x that failed.
+func (c *TemplateContext) eval(x apimock.Expr) (any, error) {
+	value, err := c.evalNode(x)
+	if err == nil {
+		return value, nil
+	}
+	var evalErr *apimock.EvalError
+	if !errors.As(err, &evalErr) {
+		return nil, &apimock.EvalError{Code: apimock.CodeInvalidOperand, Column: x.Pos() + 1, Message: err.Error()}
+	}
+	if evalErr.Column == 0 {
+		evalErr.Column = x.Pos() + 1
+	}
+	return nil, err
+}
+
+// withExpression sets the expression of the *apimock.EvalError err holds,
+// if not set yet, to source, in which the expression evaluated starts at
+// offset.
+func withExpression(err error, source string, offset int) error {
+	var evalErr *apimock.EvalError
+	if errors.As(err, &evalErr) && evalErr.Expression == "" {
+		evalErr.Expression = source
+		evalErr.Column += offset
+	}
+	return err
+}
+
+// evalNode computes the value of x, for eval.
+func (c *TemplateContext) evalNode(x apimock.Expr) (any, error) {
+	switch x := x.(type) {
+	case *apimock.Literal:
+		return x.Value, nil
+	case *apimock.Variable:
+		value, err := c.evalVariable(x.Name)
+		if err != nil {
+			return nil, &apimock.EvalError{Code: apimock.CodeUndefinedVariable, Column: x.Pos() + 1, Message: err.Error()}
+		}
+		return value, nil
+	case *apimock.Table:
+		return c.evalTable(x)
+	case *apimock.Index:
+		return c.evalIndex(x)
+	case *apimock.Unary:
+		value, err := c.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		if x.Op == "not" {
+			return !apimock.Truthy(value), nil
+		}
+		number, isNumber := value.(float64)
+		if !isNumber {
+			return nil, fmt.Errorf("cannot negate %s", describe(value))
+		}
+		return -number, nil
+	case *apimock.Binary:
+		return c.evalBinary(x)
+	case *apimock.Conditional:
+		// Only the branch chosen is evaluated, so the other may refer to
+		// missing variables
+		cond, err := c.eval(x.Cond)
+		if err != nil {
+			return nil, err
+		}
+		if apimock.Truthy(cond) {
+			return c.eval(x.Then)
+		}
+		return c.eval(x.Else)
+	case *apimock.Exists:
+		// True when x has a value other than nil, false when it is nil,
+		// missing or fails
+		value, err := c.eval(x.X)
+		return err == nil && value != nil, nil
+	case *apimock.Call:
+		return c.evalCall(x)
+	case *apimock.Assign:
+		return c.evalAssign(x)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", x)
+}
+
+// evalVariable returns the value of a variable reference. With a ?. step,
+// as in `body.user?.name`, it is nil instead of failing when what follows
+// the ?. is missing.
+func (c *TemplateContext) evalVariable(ref string) (any, error) {
+	safe, _, optional := strings.Cut(ref, "?.")
+	if !optional {
+		return c.variable(ref)
+	}
+	value, err := c.variable(strings.ReplaceAll(ref, "?.", "."))
+	if err == nil {
+		return value, nil
+	}
+	// Safe access: what comes before the first ?. must be there
+	if root, rest := splitReference(safe); rest != "" || !isRoot(root) {
+		if _, err := c.variable(safe); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// isRoot reports whether name is a context variable holding others, such as
+// headers, which is not a value by itself.
+func isRoot(name string) bool {
+	switch name {
+	case "env", "headers", "cookies", "query", "params", "session", "body", "validation", "jwt":
+		return true
+	}
+	return false
+}
+
+// variable returns the value of the variable ref in expressions: a field of
+// the element a function such as .filter evaluates an expression on, a
+// variable assigned by a condition, or a context variable. Values of JSON
+// documents (body, session, jwt and validation) keep their types and
+// raw_body is a string; the other context variables are text, read as
+// numbers when they are numbers, except for typed path parameters that are
+// not ints.
+func (c *TemplateContext) variable(ref string) (any, error) {
+	if c.inElement {
+		if value, ok := c.elementVariable(ref); ok {
+			return value, nil
+		}
+	}
+	root, rest := splitReference(ref)
+	if assigned, ok := c.Variables[root]; ok {
+		if rest == "" {
+			return assigned, nil
+		}
+		path, err := parseJSONPath("$" + rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference %s: %w", ref, err)
+		}
+		if value, ok := lookupJSONPath(assigned, path); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("%s is not set", ref)
+	}
+
+	value, ok := c.lookup(ref)
+	if !ok {
+		return nil, fmt.Errorf("%s is not set", ref)
+	}
+	switch root {
+	case "body", "session", "jwt", "validation", "raw_body":
+		if ref != "session.id" {
+			return value, nil
+		}
+	}
+	if text, isString := value.(string); isString && c.numeric(ref) {
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number, nil
+		}
+	}
+	return value, nil
+}
+
+// elementVariable resolves ref on the element a function such as .filter
+// evaluates an expression on: item is the element, and the fields of an
+// object element are variables.
+func (c *TemplateContext) elementVariable(ref string) (any, bool) {
+	path := "$"
+	if root, rest := splitReference(ref); root == "item" {
+		path += rest
+	} else if _, isObject := c.element.(map[string]any); isObject {
+		path += "." + ref
+	} else {
+		return nil, false
+	}
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+	return lookupJSONPath(c.element, steps)
+}
+
+// evalTable returns the array or the dictionary written in an expression.
+func (c *TemplateContext) evalTable(t *apimock.Table) (any, error) {
+	values := make([]any, len(t.Elements))
+	for i, x := range t.Elements {
+		value, err := c.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	if t.Keys == nil {
+		return values, nil
+	}
+	dictionary := make(map[string]any, len(values))
+	for i, key := range t.Keys {
+		dictionary[key] = values[i]
+	}
+	return dictionary, nil
+}
+
+// evalIndex returns an element of an array, from 0, or a value of a
+// dictionary.
+func (c *TemplateContext) evalIndex(x *apimock.Index) (any, error) {
+	container, err := c.eval(x.X)
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.eval(x.Key)
+	if err != nil {
+		return nil, err
+	}
+	switch container := container.(type) {
+	case []any:
+		i, isNumber := key.(float64)
+		if !isNumber || i != math.Trunc(i) {
+			return nil, fmt.Errorf("cannot index a table with %s", describe(key))
+		}
+		// Compared as floats, since huge indexes overflow int
+		if i < 0 || i >= float64(len(container)) {
+			return nil, fmt.Errorf("index %g out of range of a table of %d elements", i, len(container))
+		}
+		return container[int(i)], nil
+	case map[string]any:
+		if value, found := container[renderValue(key)]; found {
+			return value, nil
+		}
+		return nil, fmt.Errorf("no key %q", renderValue(key))
+	}
+	return nil, fmt.Errorf("cannot index %s", describe(container))
+}
+
+// evalBinary computes an operation on two values. The right operand of and
+// and or is evaluated only when the left one does not decide the result.
+func (c *TemplateContext) evalBinary(b *apimock.Binary) (any, error) {
+	x, err := c.eval(b.X)
+	if err != nil {
+		return nil, err
+	}
+	if b.Op == "and" || b.Op == "or" {
+		if apimock.Truthy(x) != (b.Op == "and") {
+			return apimock.Truthy(x), nil
+		}
+		y, err := c.eval(b.Y)
+		return apimock.Truthy(y), err
+	}
+	y, err := c.eval(b.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.Op {
+	case "==":
+		return apimock.Equal(x, y), nil
+	case "!=":
+		return !apimock.Equal(x, y), nil
+	case "in", "not in":
+		found, ok := contains(y, x)
+		if !ok {
+			return nil, fmt.Errorf("cannot look for a value in %s", describe(y))
+		}
+		return found == (b.Op == "in"), nil
+	case "<":
+		order, comparable := compare(x, y)
+		return comparable && order < 0, nil
+	case "<=":
+		order, comparable := compare(x, y)
+		return comparable && order <= 0, nil
+	case ">":
+		order, comparable := compare(x, y)
+		return comparable && order > 0, nil
+	case ">=":
+		order, comparable := compare(x, y)
+		return comparable && order >= 0, nil
+	case "..":
+		return concat(x, y)
+	}
+
+	left, isNumber := x.(float64)
+	right, bothNumbers := y.(float64)
+	if !isNumber || !bothNumbers {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", b.Op, describe(x), describe(y))
+	}
+	switch b.Op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/", "//", "%":
+		if right == 0 || b.Op == "%" && int64(right) == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+	}
+	switch b.Op {
+	case "/":
+		return left / right, nil
+	case "//":
+		return math.Floor(left / right), nil
+	case "%":
+		return float64(int64(left) % int64(right)), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", b.Op)
+}
+
+// concat computes x..y: the integers from x to y when both are numbers, as
+// in 1..10, or the texts of strings and numbers joined otherwise.
+func concat(x, y any) (any, error) {
+	from, isNumber := x.(float64)
+	to, bothNumbers := y.(float64)
+	if isNumber && bothNumbers {
+		if from != math.Trunc(from) || to != math.Trunc(to) {
+			return nil, fmt.Errorf("range bounds must be integers")
+		}
+		if to-from >= maxRange {
+			return nil, fmt.Errorf("range %v..%v has more than %d elements", from, to, maxRange)
+		}
+		numbers := make([]any, 0, max(int(to-from)+1, 0))
+		for n := from; n <= to; n++ {
+			numbers = append(numbers, n)
+		}
+		return numbers, nil
+	}
+	for _, value := range []any{x, y} {
+		switch value.(type) {
+		case string, float64:
+		default:
+			return nil, fmt.Errorf("cannot concatenate %s", describe(value))
+		}
+	}
+	return renderValue(x) + renderValue(y), nil
+}
+
+// evalCall calls a function of the conditions language, such as
+// `.random_int 1 10`, at c.Now. The keys of .random_sticky are scoped to the
+// session of the request, and the string arguments of expression parameters, as
+// in `.filter "price > 10"`, are compiled into apimock.Expressions.
+func (c *TemplateContext) evalCall(f *apimock.Call) (any, error) {
+	args := make([]any, len(f.Args))
+	for i, x := range f.Args {
+		value, err := c.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	if f.Name == "random_sticky" && len(args) > 0 {
+		args[0] = c.Scope + "\x00" + renderValue(args[0])
+	}
+	if fn, ok := apimock.LookupFunction(f.Name); ok {
+		for i, param := range fn.Params() {
+			source, isString := "", false
+			if i < len(args) {
+				source, isString = args[i].(string)
+			}
+			if param == "expression" && isString {
+				x, err := apimock.ParseExpression(source)
+				if err != nil {
+					return nil, &apimock.EvalError{Code: apimock.CodeInvalidArgument, Function: f.Name, Message: fmt.Sprintf("invalid expression %q", source), Err: err}
+				}
+				args[i] = c.elementExpression(source, x)
+			}
+		}
+	}
+	return apimock.CallFunctionAt(c.Now, f.Name, args...)
+}
+
+// elementExpression returns x, written as source, as the expression of a
+// function evaluated on each element of a table, such as .filter: item is
+// the element, the fields of object elements are variables, and the other
+// variables are those of c.
+func (c *TemplateContext) elementExpression(source string, x apimock.Expr) apimock.Expression {
+	return func(element any) (any, error) {
+		ec := *c
+		ec.element, ec.inElement = element, true
+		value, err := ec.eval(x)
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate on %s: %w", renderValue(element), withExpression(err, source, 0))
+		}
+		return value, nil
+	}
+}
+
+// evalAssign assigns the value of an attribution to its variable, or the
+// elements of a table to the variables it is destructured into, missing
+// elements being nil. Attributions are true.
+func (c *TemplateContext) evalAssign(a *apimock.Assign) (any, error) {
+	value, err := c.eval(a.X)
+	if err != nil {
+		return nil, err
+	}
+	if c.Variables == nil {
+		c.Variables = make(map[string]any)
+	}
+	if len(a.Names) == 1 {
+		c.Variables[a.Names[0]] = value
+		return true, nil
+	}
+	elements, isArray := value.([]any)
+	if !isArray {
+		return nil, fmt.Errorf("cannot destructure %s into %s", describe(value), strings.Join(a.Names, ", "))
+	}
+	for i, name := range a.Names {
+		c.Variables[name] = nil
+		if i < len(elements) {
+			c.Variables[name] = elements[i]
+		}
+	}
+	return true, nil
+}
+
+// describe names a value in errors, by its type and its text.
+func describe(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("the string %q", value)
+	case float64:
+		return "the number " + renderValue(value)
+	case bool:
+		return "the boolean " + renderValue(value)
+	}
+	return "the table " + renderValue(value)
+}
+
+// compare orders two values: numbers, and strings reading as numbers, by
+// value, and other strings and numbers by their text, byte by byte, so
+// "apple" < "banana" and "B" < "a". Other values are not comparable.
+func compare(x, y any) (int, bool) {
+	if left, right, ok := numbers(x, y); ok {
+		return cmp.Compare(left, right), true
+	}
+	for _, value := range []any{x, y} {
+		switch value.(type) {
+		case string, float64:
+		default:
+			return 0, false
+		}
+	}
+	return strings.Compare(renderValue(x), renderValue(y)), true
+}
+
+// numbers returns x and y as numbers when both are numbers or one is and
+// the other is a string reading as a number.
+func numbers(x, y any) (float64, float64, bool) {
+	left, xNumber := number(x)
+	right, yNumber := number(y)
+	_, xIsNumber := x.(float64)
+	_, yIsNumber := y.(float64)
+	return left, right, xNumber && yNumber && (xIsNumber || yIsNumber)
+}
+
+// number returns value as a number if it is one or a string reading as one.
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// contains reports whether value is an element of an array, a key of an
+// object or a substring of a string. It fails on other containers.
+func contains(container, value any) (bool, bool) {
+	switch c := container.(type) {
+	case []any:
+		for _, element := range c {
+			if apimock.Equal(element, value) {
+				return true, true
+			}
+		}
+		return false, true
+	case map[string]any:
+		_, found := c[renderValue(value)]
+		return found, true
+	case string:
+		return strings.Contains(c, renderValue(value)), true
+	}
+	return false, false
+}
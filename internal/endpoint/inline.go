@@ -0,0 +1,53 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// InlineArrow separates the request line of an inline mock from its response.
+const InlineArrow = "->"
+
+// InlineFilePath stands in for the file path of endpoints declared inline, in
+// listings and error messages.
+const InlineFilePath = "(inline)"
+
+// ParseInline builds an endpoint from a one-line mock such as
+// `GET /ping -> 200 {"ok":true}`: a request line, an arrow, a status code and
+// an optional body. The body is served as application/json when it is valid
+// JSON and as text/plain otherwise.
+func ParseInline(spec string) (*EndpointSchema, error) {
+	request, response, ok := strings.Cut(spec, InlineArrow)
+	request = strings.TrimSpace(request)
+	if !ok || request == "" {
+		return nil, fmt.Errorf("invalid inline mock %q: expected \"METHOD /path %s STATUS [body]\"", spec, InlineArrow)
+	}
+
+	code, body, _ := strings.Cut(strings.TrimSpace(response), " ")
+	if _, err := strconv.Atoi(code); err != nil {
+		return nil, fmt.Errorf("invalid inline mock %q: status code %q is not a number", spec, code)
+	}
+	body = strings.TrimSpace(body)
+
+	var source strings.Builder
+	fmt.Fprintf(&source, "%s\n\n-- %s:\n", request, code)
+	if body != "" && json.Valid([]byte(body)) {
+		fmt.Fprintf(&source, "%s: application/json\n", ResponseContentTypePropertyName)
+	}
+	if body != "" {
+		fmt.Fprintf(&source, "\n%s\n", body)
+	}
+
+	ast, err := apimock.NewParserFromBytes(InlineFilePath, []byte(source.String())).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid inline mock %q: %w", spec, err)
+	}
+	if err := ast.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid inline mock %q: %w", spec, err)
+	}
+	return FromAPIMockFile(ast)
+}
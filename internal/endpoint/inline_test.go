@@ -0,0 +1,76 @@
+package endpoint
+
+import (
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		name            string
+		spec            string
+		wantRoute       string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json body",
+			spec:            `GET /ping -> 200 {"ok":true}`,
+			wantRoute:       "GET /ping",
+			wantStatus:      200,
+			wantContentType: "application/json",
+			wantBody:        `{"ok":true}`,
+		},
+		{
+			name:            "text body",
+			spec:            "POST /orders/{id} -> 201 created: yes",
+			wantRoute:       "POST /orders/{id}",
+			wantStatus:      201,
+			wantContentType: DefaultContentType,
+			wantBody:        "created: yes",
+		},
+		{
+			name:            "no body",
+			spec:            "DELETE /items/1->204",
+			wantRoute:       "DELETE /items/1",
+			wantStatus:      204,
+			wantContentType: DefaultContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseInline(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseInline() error = %v", err)
+			}
+			if schema.Route != tt.wantRoute {
+				t.Errorf("Route = %q, want %q", schema.Route, tt.wantRoute)
+			}
+			resp, ok := schema.GetResponseByStatusCode(tt.wantStatus)
+			if !ok {
+				t.Fatalf("no %d response in %v", tt.wantStatus, schema.Responses)
+			}
+			if resp.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", resp.ContentType, tt.wantContentType)
+			}
+			if resp.Body != tt.wantBody {
+				t.Errorf("Body = %q, want %q", resp.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseInline_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"GET /ping",
+		"-> 200",
+		"GET /ping -> ok",
+		"GET /ping -> 999",
+		"FETCH /ping -> 200",
+	} {
+		if _, err := ParseInline(spec); err == nil {
+			t.Errorf("ParseInline(%q) should fail", spec)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package endpoint
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Errors reported for the bearer token of endpoints requiring a JWT.
+var (
+	ErrJWTMissing   = errors.New("missing bearer token")
+	ErrJWTMalformed = apimock.ErrJWTMalformed
+	ErrJWTExpired   = errors.New("expired JWT")
+	ErrJWTSignature = apimock.ErrJWTSignature
+)
+
+// BearerClaims decodes the claims of the JWT in the Authorization header. The
+// signature is not verified: mocks only read what the client sent.
+func BearerClaims(headers http.Header) (map[string]any, bool) {
+	token, ok := bearerToken(headers)
+	if !ok {
+		return nil, false
+	}
+	return apimock.DecodeJWT(token)
+}
+
+func bearerToken(headers http.Header) (string, bool) {
+	scheme, token, ok := strings.Cut(headers.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// CheckBearer validates the bearer token of a request to an endpoint that
+// requires a JWT: the token must decode, must not be expired at now and, when
+// the endpoint declares a secret, must carry a valid HS256 signature. It
+// returns nil for endpoints that do not require a JWT.
+func (e *EndpointSchema) CheckBearer(r *http.Request, now time.Time) error {
+	if !e.RequireJWT {
+		return nil
+	}
+	token, ok := bearerToken(r.Header)
+	if !ok {
+		return ErrJWTMissing
+	}
+	claims, ok := apimock.DecodeJWT(token)
+	if !ok {
+		return ErrJWTMalformed
+	}
+	if apimock.JWTExpired(claims, now) {
+		return ErrJWTExpired
+	}
+	if e.JWTSecret != "" {
+		return apimock.VerifyJWT(token, e.JWTSecret)
+	}
+	return nil
+}
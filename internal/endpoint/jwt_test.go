@@ -0,0 +1,56 @@
+package endpoint
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func signHS256(claims, secret string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestEndpointSchema_CheckBearer(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid := signHS256(`{"sub": "ana", "exp": 1700003600}`, "s3cr3t")
+	expired := signHS256(`{"sub": "ana", "exp": 1699999999}`, "s3cr3t")
+	unsigned := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub": "ana"}`)) + "."
+
+	tests := []struct {
+		name          string
+		schema        EndpointSchema
+		authorization string
+		want          error
+	}{
+		{"not required", EndpointSchema{}, "", nil},
+		{"missing", EndpointSchema{RequireJWT: true}, "", ErrJWTMissing},
+		{"basic scheme", EndpointSchema{RequireJWT: true}, "Basic YWxhZGRpbjpvcGVuc2VzYW1l", ErrJWTMissing},
+		{"malformed", EndpointSchema{RequireJWT: true}, "Bearer not-a-jwt", ErrJWTMalformed},
+		{"structure only", EndpointSchema{RequireJWT: true}, "Bearer " + unsigned, nil},
+		{"expired", EndpointSchema{RequireJWT: true}, "Bearer " + expired, ErrJWTExpired},
+		{"valid signature", EndpointSchema{RequireJWT: true, JWTSecret: "s3cr3t"}, "Bearer " + valid, nil},
+		{"wrong secret", EndpointSchema{RequireJWT: true, JWTSecret: "other"}, "Bearer " + valid, ErrJWTSignature},
+		{"unsigned with secret", EndpointSchema{RequireJWT: true, JWTSecret: "s3cr3t"}, "Bearer " + unsigned, ErrJWTSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if err := tt.schema.CheckBearer(req, now); !errors.Is(err, tt.want) {
+				t.Errorf("CheckBearer() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package endpoint
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is one media range of an Accept header, such as text/* or
+// application/json;q=0.5.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// NegotiateResponse returns the response for statusCode whose content type is
+// preferred by the Accept header of a request. When several responses share
+// the status code and none is acceptable, or accept is empty, the first
+// declared response is returned. Responses assigned to a profile are left out.
+func (e *EndpointSchema) NegotiateResponse(statusCode int, accept string) (Response, bool) {
+	return e.NegotiateProfileResponse("", statusCode, accept)
+}
+
+// NegotiateProfileResponse is NegotiateResponse while profile is active: the
+// responses of the profile take the place of those without a profile when
+// the profile declares statusCode.
+func (e *EndpointSchema) NegotiateProfileResponse(profile string, statusCode int, accept string) (Response, bool) {
+	responses := e.profileResponses(profile, statusCode)
+	if len(responses) == 0 {
+		return Response{}, false
+	}
+	if len(responses) == 1 || strings.TrimSpace(accept) == "" {
+		return responses[0], true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQuality := 0, 0.0
+	for i, resp := range responses {
+		if q := acceptQuality(ranges, resp.ContentType); q > bestQuality {
+			best, bestQuality = i, q
+		}
+	}
+	return responses[best], true
+}
+
+// HasVariants reports whether statusCode is declared with responses of
+// different content types, which are then chosen by content negotiation.
+func (e *EndpointSchema) HasVariants(statusCode int) bool {
+	responses := e.Responses[statusCode]
+	for _, resp := range responses[min(1, len(responses)):] {
+		if resp.ContentType != responses[0].ContentType {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality the most specific matching media range
+// assigns to contentType, or 0 if no range accepts it. A response without a
+// content type is only accepted by */*.
+func acceptQuality(ranges []acceptRange, contentType string) float64 {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, 0
+	for _, r := range ranges {
+		s := 0
+		switch {
+		case r.mediaType == "*/*":
+			s = 1
+		case mediaType == "":
+			continue
+		case strings.HasSuffix(r.mediaType, "/*") && strings.TrimSuffix(r.mediaType, "/*") == mainType:
+			s = 2
+		case r.mediaType == mediaType:
+			s = 3
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
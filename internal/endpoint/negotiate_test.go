@@ -0,0 +1,67 @@
+package endpoint
+
+import "testing"
+
+func newNegotiationSchema() *EndpointSchema {
+	return &EndpointSchema{
+		Responses: map[int][]Response{
+			200: {
+				{Title: "JSON", ContentType: "application/json", StatusCode: 200},
+				{Title: "XML", ContentType: "application/xml; charset=utf-8", StatusCode: 200},
+				{Title: "Text", ContentType: "text/plain", StatusCode: 200},
+			},
+			404: {
+				{Title: "Missing", ContentType: "application/json", StatusCode: 404},
+			},
+		},
+	}
+}
+
+func TestEndpointSchema_NegotiateResponse(t *testing.T) {
+	schema := newNegotiationSchema()
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "No Accept header", accept: "", want: "JSON"},
+		{name: "Exact match", accept: "application/xml", want: "XML"},
+		{name: "Type wildcard", accept: "text/*", want: "Text"},
+		{name: "Any type", accept: "*/*", want: "JSON"},
+		{name: "Quality values", accept: "application/json;q=0.5, application/xml;q=0.9", want: "XML"},
+		{name: "Specific range overrides wildcard", accept: "*/*;q=0.8, application/json;q=0.1", want: "XML"},
+		{name: "Nothing acceptable falls back to first", accept: "image/png", want: "JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, ok := schema.NegotiateResponse(200, tt.accept)
+			if !ok {
+				t.Fatal("expected a response")
+			}
+			if resp.Title != tt.want {
+				t.Errorf("NegotiateResponse(%q) = %s, want %s", tt.accept, resp.Title, tt.want)
+			}
+		})
+	}
+
+	if _, ok := schema.NegotiateResponse(500, "application/json"); ok {
+		t.Error("expected no response for undeclared status code")
+	}
+}
+
+func TestEndpointSchema_HasVariants(t *testing.T) {
+	schema := newNegotiationSchema()
+	schema.Responses[201] = []Response{
+		{Title: "A", ContentType: "application/json", StatusCode: 201},
+		{Title: "B", ContentType: "application/json", StatusCode: 201},
+	}
+
+	if !schema.HasVariants(200) {
+		t.Error("expected 200 to have content type variants")
+	}
+	if schema.HasVariants(404) || schema.HasVariants(201) || schema.HasVariants(500) {
+		t.Error("expected no variants when all responses share a content type")
+	}
+}
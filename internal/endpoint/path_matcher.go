@@ -0,0 +1,185 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// WildcardParameterName names the path value captured by a * segment. Further
+// wildcards in the same route are numbered: wildcard2, wildcard3, ...
+const WildcardParameterName = "wildcard"
+
+// routeWildcardRegex matches the {name} and {name...} wildcards of a route.
+var routeWildcardRegex = regexp.MustCompile(`\{[^{}]*?(\.\.\.)?\}`)
+
+// PathMatcher selects an endpoint by the regular expressions constraining its
+// path parameters, as in `GET /orders/{id:[0-9]+}`, or by their types, as in
+// `GET /orders/{id:int}`. Constraints are checked by segment position, so
+// endpoints sharing a route shape can name their parameters differently.
+type PathMatcher struct {
+	constraints map[int]*regexp.Regexp
+	// typed holds the typed parameters, by segment position
+	typed map[int]apimock.PathSegment
+}
+
+// Match implements RequestMatcher.
+func (m *PathMatcher) Match(r *http.Request, _ []byte) bool {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	for index := range m.constraints {
+		if index >= len(segments) || !m.accepts(index, segments[index]) {
+			return false
+		}
+	}
+	return true
+}
+
+// accepts reports whether value satisfies the constraint of the parameter at
+// index. Int parameters must also fit in 64 bits.
+func (m *PathMatcher) accepts(index int, value string) bool {
+	if !m.constraints[index].MatchString(value) {
+		return false
+	}
+	if m.typed[index].Type == apimock.ParamTypeInt {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	}
+	return true
+}
+
+// checkTypes returns a *ParamTypeError for the first typed parameter of the
+// request path whose value is not of its type. Parameters constrained by a
+// regular expression are not checked.
+func (m *PathMatcher) checkTypes(r *http.Request) error {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	for index, value := range segments {
+		if seg, ok := m.typed[index]; ok && !m.accepts(index, value) {
+			return &ParamTypeError{Name: seg.Name, Type: seg.Type, Value: value}
+		}
+	}
+	return nil
+}
+
+// ParamTypeError is the error of a request whose path holds a value of the
+// wrong type for a typed parameter, as abc for {id:int}.
+type ParamTypeError struct {
+	Name, Type, Value string
+}
+
+func (e *ParamTypeError) Error() string {
+	return fmt.Sprintf("path parameter %s must be of type %s, got %q", e.Name, e.Type, e.Value)
+}
+
+// issues describes the error for validation placeholders, at a path such as
+// /params/id.
+func (e *ParamTypeError) issues() []ValidationIssue {
+	return []ValidationIssue{{InstancePath: "/params/" + escapePointer([]string{e.Name})[0], Keyword: "type", Message: e.Error()}}
+}
+
+// CheckParamTypes returns a *ParamTypeError if a typed parameter of the
+// request path holds a value of another type. Such requests are on the route
+// of the endpoint but ask for something it cannot serve, unlike requests
+// failing a regular expression constraint, which are for another route.
+func (e *EndpointSchema) CheckParamTypes(r *http.Request) error {
+	for _, m := range e.Matchers {
+		if matcher, ok := m.(*PathMatcher); ok {
+			return matcher.checkTypes(r)
+		}
+	}
+	return nil
+}
+
+// ParamTypes returns the types of the typed path parameters of segments, by
+// name, or nil when there are none.
+func ParamTypes(segments []apimock.PathSegment) map[string]string {
+	var types map[string]string
+	for _, seg := range segments {
+		if seg.Type != "" {
+			if types == nil {
+				types = make(map[string]string)
+			}
+			types[seg.Name] = seg.Type
+		}
+	}
+	return types
+}
+
+// RoutePath translates the path segments of a request section into a
+// net/http pattern. Constrained parameters become plain wildcards checked by
+// the returned matcher, which is nil when there are no constraints. A trailing
+// * matches the rest of the path and any other * matches a single segment.
+func RoutePath(segments []apimock.PathSegment) (string, *PathMatcher, error) {
+	var b strings.Builder
+	matcher := &PathMatcher{constraints: make(map[int]*regexp.Regexp), typed: make(map[int]apimock.PathSegment)}
+	wildcards := 0
+
+	for i, seg := range segments {
+		b.WriteString("/")
+		switch {
+		case seg.IsWildcard:
+			wildcards++
+			name := WildcardParameterName
+			if wildcards > 1 {
+				name += strconv.Itoa(wildcards)
+			}
+			if i == len(segments)-1 {
+				name += "..."
+			}
+			b.WriteString("{" + name + "}")
+		case seg.IsParameter && seg.Pattern != "":
+			pattern, err := regexp.Compile("^(?:" + seg.Pattern + ")$")
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid pattern for path parameter %s: %w", seg.Name, err)
+			}
+			matcher.constraints[i] = pattern
+			if seg.Type != "" {
+				matcher.typed[i] = seg
+			}
+			b.WriteString("{" + seg.Name + "}")
+		default:
+			b.WriteString(seg.Value)
+		}
+	}
+
+	if len(matcher.constraints) == 0 {
+		return b.String(), nil, nil
+	}
+	return b.String(), matcher, nil
+}
+
+// PathParam returns the value of the path parameter name of the endpoint in
+// path, read at the segment position where the route of the endpoint
+// declares it, or "" if the route has no such parameter. Endpoints sharing a
+// route shape are registered under the route of one of them, so the path
+// values of the request are named after that route rather than theirs. A
+// {name...} wildcard gets the rest of the path.
+func (e *EndpointSchema) PathParam(path, name string) string {
+	_, pattern, found := strings.Cut(e.Route, " ")
+	if !found {
+		pattern = e.Route
+	}
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range patternSegments {
+		if i >= len(pathSegments) {
+			break
+		}
+		switch seg {
+		case "{" + name + "}":
+			return pathSegments[i]
+		case "{" + name + "...}":
+			return strings.Join(pathSegments[i:], "/")
+		}
+	}
+	return ""
+}
+
+// RouteShape returns route with its wildcard names erased, so routes that
+// differ only in how they name their parameters share a shape.
+func RouteShape(route string) string {
+	return routeWildcardRegex.ReplaceAllString(route, "{$1}")
+}
@@ -0,0 +1,161 @@
+package endpoint
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func TestRoutePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []apimock.PathSegment
+		want     string
+	}{
+		{
+			name: "Constrained parameter",
+			segments: []apimock.PathSegment{
+				{Value: "orders"},
+				{Value: "{id:[0-9]+}", IsParameter: true, Name: "id", Pattern: "[0-9]+"},
+			},
+			want: "/orders/{id}",
+		},
+		{
+			name:     "Trailing wildcard",
+			segments: []apimock.PathSegment{{Value: "files"}, {Value: "*", IsWildcard: true}},
+			want:     "/files/{wildcard...}",
+		},
+		{
+			name: "Inner wildcards",
+			segments: []apimock.PathSegment{
+				{Value: "*", IsWildcard: true},
+				{Value: "*", IsWildcard: true},
+				{Value: "status"},
+			},
+			want: "/{wildcard}/{wildcard2}/status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := RoutePath(tt.segments)
+			if err != nil {
+				t.Fatalf("RoutePath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RoutePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutePath_InvalidPattern(t *testing.T) {
+	_, _, err := RoutePath([]apimock.PathSegment{{Value: "{id:[0-9}", IsParameter: true, Name: "id", Pattern: "[0-9"}})
+	if err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
+
+func TestPathMatcher_Match(t *testing.T) {
+	_, matcher, err := RoutePath([]apimock.PathSegment{
+		{Value: "orders"},
+		{Value: "{id:[0-9]+}", IsParameter: true, Name: "id", Pattern: "[0-9]+"},
+	})
+	if err != nil {
+		t.Fatalf("RoutePath() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/orders/42", true},
+		{"/orders/abc", false},
+		{"/orders/42a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if got := matcher.Match(req, nil); got != tt.want {
+				t.Errorf("Match(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathMatcher_Types(t *testing.T) {
+	ast, err := apimock.NewParserFromBytes("users.apimock", []byte("GET /users/{id:int}/tags/{tag:string}\n\n-- 200: OK\n")).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	route, matcher, err := RoutePath(ast.Request.PathSegments)
+	if err != nil {
+		t.Fatalf("RoutePath() error = %v", err)
+	}
+	if route != "/users/{id}/tags/{tag}" {
+		t.Errorf("RoutePath() = %q", route)
+	}
+	schema := &EndpointSchema{Matchers: []RequestMatcher{matcher}}
+
+	tests := []struct {
+		path    string
+		match   bool
+		invalid string // parameter named by the type error, if any
+	}{
+		{path: "/users/42/tags/go", match: true},
+		{path: "/users/-7/tags/42", match: true},
+		{path: "/users/abc/tags/go", invalid: "id"},
+		{path: "/users/99999999999999999999/tags/go", invalid: "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if got := matcher.Match(req, nil); got != tt.match {
+				t.Errorf("Match() = %v, want %v", got, tt.match)
+			}
+			err := schema.CheckParamTypes(req)
+			var typeErr *ParamTypeError
+			switch {
+			case tt.invalid == "" && err != nil:
+				t.Errorf("CheckParamTypes() error = %v", err)
+			case tt.invalid != "" && (!errors.As(err, &typeErr) || typeErr.Name != tt.invalid || typeErr.Type != apimock.ParamTypeInt):
+				t.Errorf("CheckParamTypes() error = %v, want a type error for %s", err, tt.invalid)
+			}
+		})
+	}
+
+	if issues := ValidationIssues(&ParamTypeError{Name: "id", Type: "int", Value: "abc"}); issues[0].InstancePath != "/params/id" || issues[0].Keyword != "type" {
+		t.Errorf("ValidationIssues() = %+v", issues)
+	}
+}
+
+func TestRouteShape(t *testing.T) {
+	if RouteShape("GET /orders/{id}/files/{wildcard...}") != RouteShape("GET /orders/{slug}/files/{rest...}") {
+		t.Error("expected routes differing only in wildcard names to share a shape")
+	}
+	if RouteShape("GET /orders/{id}") == RouteShape("GET /orders/{id}/items") {
+		t.Error("expected different routes to have different shapes")
+	}
+}
+
+func TestEndpointSchema_PathParam(t *testing.T) {
+	schema := &EndpointSchema{Route: "GET /orders/{id}/files/{rest...}"}
+
+	tests := []struct {
+		path, name, want string
+	}{
+		{"/orders/7/files/a/b.txt", "id", "7"},
+		{"/orders/7/files/a/b.txt", "rest", "a/b.txt"},
+		{"/orders/7/files/a/b.txt", "slug", ""},
+		{"/orders", "id", ""},
+	}
+	for _, tt := range tests {
+		if got := schema.PathParam(tt.path, tt.name); got != tt.want {
+			t.Errorf("PathParam(%q, %q) = %q, want %q", tt.path, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package endpoint
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ResponseProfilePropertyName assigns a response to named profiles, as in
+// `Profile: degraded, outage`. A profiled response is only served while one
+// of its profiles is active, in place of the responses without a profile.
+const ResponseProfilePropertyName = "Profile"
+
+// PropertyProfiles returns the profiles named by the Profile property of a
+// response section.
+func PropertyProfiles(properties map[string]string) []string {
+	var profiles []string
+	for _, name := range strings.Split(properties[ResponseProfilePropertyName], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
+// InProfile reports whether the response belongs to profile. Responses
+// without a profile belong to "".
+func (r Response) InProfile(profile string) bool {
+	if profile == "" {
+		return len(r.Profiles) == 0
+	}
+	return slices.Contains(r.Profiles, profile)
+}
+
+// Profiles returns the profiles declared by the responses of the endpoint,
+// sorted.
+func (e *EndpointSchema) Profiles() []string {
+	var profiles []string
+	for _, responses := range e.Responses {
+		for _, resp := range responses {
+			for _, profile := range resp.Profiles {
+				if !slices.Contains(profiles, profile) {
+					profiles = append(profiles, profile)
+				}
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// ProfileStatusCodes returns the sorted status codes the endpoint declares
+// responses for under profile.
+func (e *EndpointSchema) ProfileStatusCodes(profile string) []int {
+	var codes []int
+	for code, responses := range e.Responses {
+		if slices.ContainsFunc(responses, func(r Response) bool { return r.InProfile(profile) }) {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// profileResponses returns the responses for statusCode served under
+// profile: those of the profile when it declares the status code, otherwise
+// those without a profile.
+func (e *EndpointSchema) profileResponses(profile string, statusCode int) []Response {
+	var base, profiled []Response
+	for _, resp := range e.Responses[statusCode] {
+		switch {
+		case profile != "" && resp.InProfile(profile):
+			profiled = append(profiled, resp)
+		case len(resp.Profiles) == 0:
+			base = append(base, resp)
+		}
+	}
+	if len(profiled) > 0 {
+		return profiled
+	}
+	return base
+}
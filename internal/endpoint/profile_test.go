@@ -0,0 +1,68 @@
+package endpoint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newProfileSchema() *EndpointSchema {
+	return &EndpointSchema{
+		Responses: map[int][]Response{
+			200: {
+				{Title: "OK", StatusCode: 200},
+				{Title: "Slow", StatusCode: 200, Profiles: []string{"slow-backend"}},
+			},
+			404: {
+				{Title: "Missing", StatusCode: 404},
+			},
+			503: {
+				{Title: "Down", StatusCode: 503, Profiles: []string{"outage", "degraded"}},
+			},
+		},
+	}
+}
+
+func TestEndpointSchema_NegotiateProfileResponse(t *testing.T) {
+	schema := newProfileSchema()
+
+	tests := []struct {
+		name    string
+		profile string
+		status  int
+		want    string
+		ok      bool
+	}{
+		{name: "base", profile: "", status: 200, want: "OK", ok: true},
+		{name: "base leaves profiled out", profile: "", status: 503, ok: false},
+		{name: "profile replaces base", profile: "slow-backend", status: 200, want: "Slow", ok: true},
+		{name: "profile falls back to base", profile: "slow-backend", status: 404, want: "Missing", ok: true},
+		{name: "second profile", profile: "degraded", status: 503, want: "Down", ok: true},
+		{name: "other profile", profile: "outage", status: 200, want: "OK", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, ok := schema.NegotiateProfileResponse(tt.profile, tt.status, "")
+			if ok != tt.ok {
+				t.Fatalf("NegotiateProfileResponse(%q, %d) ok = %v, want %v", tt.profile, tt.status, ok, tt.ok)
+			}
+			if ok && resp.Title != tt.want {
+				t.Errorf("NegotiateProfileResponse(%q, %d) = %s, want %s", tt.profile, tt.status, resp.Title, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointSchema_Profiles(t *testing.T) {
+	schema := newProfileSchema()
+
+	if got, want := schema.Profiles(), []string{"degraded", "outage", "slow-backend"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Profiles() = %v, want %v", got, want)
+	}
+	if got, want := schema.ProfileStatusCodes("outage"), []int{503}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileStatusCodes(outage) = %v, want %v", got, want)
+	}
+	if got, want := schema.ProfileStatusCodes(""), []int{200, 404}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileStatusCodes() = %v, want %v", got, want)
+	}
+}
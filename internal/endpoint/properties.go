@@ -0,0 +1,100 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// commonResponseHeaders are the headers mocks usually declare. Response
+// properties close to one of them, or to a control property, are likely
+// typos.
+var commonResponseHeaders = []string{
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Origin",
+	"Access-Control-Expose-Headers",
+	"Age",
+	"Allow",
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Location",
+	"Content-Security-Policy",
+	"Content-Type",
+	"Date",
+	"ETag",
+	"Expires",
+	"Last-Modified",
+	"Link",
+	"Location",
+	"Pragma",
+	"Retry-After",
+	"Server",
+	"Set-Cookie",
+	"Strict-Transport-Security",
+	"Vary",
+	"WWW-Authenticate",
+}
+
+// suggestResponseProperty returns the control property or common header a
+// response property was probably meant to be. It returns false for known
+// names, X- headers and names too far from any known one.
+func suggestResponseProperty(key string) (string, bool) {
+	if responseControlProperties[key] || strings.HasPrefix(strings.ToUpper(key), "X-") {
+		return "", false
+	}
+	for _, header := range commonResponseHeaders {
+		if http.CanonicalHeaderKey(key) == http.CanonicalHeaderKey(header) {
+			return "", false
+		}
+	}
+
+	candidates := make([]string, 0, len(responseControlProperties)+len(commonResponseHeaders))
+	for name := range responseControlProperties {
+		candidates = append(candidates, name)
+	}
+	candidates = append(candidates, commonResponseHeaders...)
+
+	best, bestDistance := "", -1
+	for _, name := range candidates {
+		d := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if bestDistance < 0 || d < bestDistance || d == bestDistance && name < best {
+			best, bestDistance = name, d
+		}
+	}
+	if bestDistance > 2 || bestDistance*4 > len(key) {
+		return "", false
+	}
+	return best, true
+}
+
+// levenshtein returns the number of single-character edits turning a into b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func warnResponseProperty(route string, status int, key, suggestion string) {
+	fmt.Println(i18n.T("Warning: %s: property %q of response %d is sent as a header; did you mean %q?", route, key, status, suggestion))
+}
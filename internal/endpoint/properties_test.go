@@ -0,0 +1,51 @@
+package endpoint
+
+import "testing"
+
+func TestSuggestResponseProperty(t *testing.T) {
+	tests := []struct {
+		key      string
+		want     string
+		wantWarn bool
+	}{
+		{key: "Content-Typ", want: "Content-Type", wantWarn: true},
+		{key: "Contenttype", want: "ContentType", wantWarn: true},
+		{key: "SOAPFalut", want: "SOAPFault", wantWarn: true},
+		{key: "Locaton", want: "Location", wantWarn: true},
+		{key: "Set-Cokie", want: "Set-Cookie", wantWarn: true},
+		{key: "ContentType"},
+		{key: "content-type"},
+		{key: "Retry-After"},
+		{key: "X-Request-Id"},
+		{key: "X-Contnt-Type"},
+		{key: "Api-Version"},
+		{key: "Tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, warn := suggestResponseProperty(tt.key)
+			if warn != tt.wantWarn || got != tt.want {
+				t.Errorf("suggestResponseProperty(%q) = %q, %v; want %q, %v", tt.key, got, warn, tt.want, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"content-typ", "content-type", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
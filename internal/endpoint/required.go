@@ -0,0 +1,69 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestRequiredStatusPropertyName sets the status answered to requests
+// missing a required header or query parameter, as in
+// `Required-Status: 401`. It defaults to 400.
+const RequestRequiredStatusPropertyName = "Required-Status"
+
+// MissingParametersError lists the required headers and query parameters a
+// request did not send.
+type MissingParametersError struct {
+	Headers []string
+	Query   []string
+}
+
+func (e *MissingParametersError) Error() string {
+	var missing []string
+	for _, name := range e.Headers {
+		missing = append(missing, "header "+name)
+	}
+	for _, name := range e.Query {
+		missing = append(missing, "query parameter "+name)
+	}
+	return "missing required " + strings.Join(missing, ", ")
+}
+
+// issues describes the missing parameters for validation placeholders, at
+// paths such as /headers/Authorization and /query/limit.
+func (e *MissingParametersError) issues() []ValidationIssue {
+	var issues []ValidationIssue
+	for _, name := range e.Headers {
+		issues = append(issues, ValidationIssue{InstancePath: "/headers/" + escapePointer([]string{name})[0], Keyword: "required", Message: fmt.Sprintf("header %s is required", name)})
+	}
+	for _, name := range e.Query {
+		issues = append(issues, ValidationIssue{InstancePath: "/query/" + escapePointer([]string{name})[0], Keyword: "required", Message: fmt.Sprintf("query parameter %s is required", name)})
+	}
+	return issues
+}
+
+// CheckRequired returns a *MissingParametersError if the request lacks a
+// header or query parameter the endpoint requires. Empty values count as
+// sent.
+func (e *EndpointSchema) CheckRequired(r *http.Request) error {
+	if len(e.RequiredHeaders) == 0 && len(e.RequiredQuery) == 0 {
+		return nil
+	}
+
+	missing := &MissingParametersError{}
+	for _, name := range e.RequiredHeaders {
+		if len(r.Header.Values(name)) == 0 {
+			missing.Headers = append(missing.Headers, name)
+		}
+	}
+	query := r.URL.Query()
+	for _, name := range e.RequiredQuery {
+		if !query.Has(name) {
+			missing.Query = append(missing.Query, name)
+		}
+	}
+	if len(missing.Headers) == 0 && len(missing.Query) == 0 {
+		return nil
+	}
+	return missing
+}
@@ -0,0 +1,94 @@
+package endpoint
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	slashpath "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// ResponseSchemaPropertyName names the JSON Schema or XSD file the body of a
+// response must satisfy, as in `Schema: schemas/user.json`. Relative paths
+// are resolved from the directory of the .apimock file. Bodies are only
+// checked when the server validates responses.
+const ResponseSchemaPropertyName = "Schema"
+
+// loadResponseSchemas compiles the schemas named by the responses of the
+// endpoint, reading relative paths from dir of fsys, nil for the operating
+// system's files, with the validator registered for the content type of
+// each response.
+func (e *EndpointSchema) loadResponseSchemas(fsys fs.FS, dir string) error {
+	validators := make(map[string]SchemaValidator)
+	for code, responses := range e.Responses {
+		for i, resp := range responses {
+			if resp.SchemaFile == "" {
+				continue
+			}
+			path := resp.SchemaFile
+			if fsys != nil {
+				path = slashpath.Join(filepath.ToSlash(dir), path)
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+
+			key := validatorMediaType(resp.ContentType) + " " + path
+			validator, ok := validators[key]
+			if !ok {
+				schema, location, err := readMockFile(fsys, path)
+				if err != nil {
+					return fmt.Errorf("invalid %s of response %d: %w", ResponseSchemaPropertyName, code, err)
+				}
+				validator, err = NewValidatorAt(resp.ContentType, string(schema), location)
+				if skipsXSD(err) {
+					fmt.Println(i18n.T("Warning: %s: %v; responses with status %d are not validated.", e.Route, err, code))
+					validator, err = nil, nil
+				}
+				if err != nil {
+					return fmt.Errorf("invalid %s of response %d: %w", ResponseSchemaPropertyName, code, err)
+				}
+				validators[key] = validator
+			}
+			responses[i].Validator = validator
+		}
+	}
+	return nil
+}
+
+// readMockFile reads a file a mock refers to at path of fsys, returning the
+// location its relative references are resolved from: none for files of an
+// fs.FS.
+func readMockFile(fsys fs.FS, path string) ([]byte, string, error) {
+	if fsys != nil {
+		schema, err := fs.ReadFile(fsys, path)
+		return schema, "", err
+	}
+	schema, err := os.ReadFile(path)
+	return schema, path, err
+}
+
+// isXMLContentType reports whether contentType is an XML media type, such as
+// application/xml, text/xml or application/atom+xml.
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(contentType)
+	}
+	return mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// ValidateBody checks the body of the response against its declared schema.
+// Responses without one are always valid.
+func (r Response) ValidateBody() error {
+	if r.Validator == nil {
+		return nil
+	}
+	if err := r.Validator.Validate(r.Body); err != nil {
+		return fmt.Errorf("response %d (%s) does not match %s: %w", r.StatusCode, r.Title, r.SchemaFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package endpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIMock_ResponseSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "schemas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "schemas", "user.json"), []byte(`{"type": "object", "required": ["id"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	schema, err := ParseAPIMock(write("users.apimock", `GET /users/{id}
+
+-- 200: OK
+ContentType: application/json
+Schema: schemas/user.json
+
+{"id": 1}
+
+-- 200: Broken
+ContentType: application/json
+Schema: schemas/user.json
+
+{"name": "Ana"}
+
+-- 404: Not Found
+
+missing
+`))
+	if err != nil {
+		t.Fatalf("ParseAPIMock() error = %v", err)
+	}
+
+	ok, broken, notFound := schema.Responses[200][0], schema.Responses[200][1], schema.Responses[404][0]
+	if ok.Validator == nil || ok.Validator != broken.Validator {
+		t.Errorf("Expected both responses to share the compiled schema")
+	}
+	if _, isHeader := ok.Headers[ResponseSchemaPropertyName]; isHeader {
+		t.Errorf("Expected %s not to be sent as a header", ResponseSchemaPropertyName)
+	}
+	if err := ok.ValidateBody(); err != nil {
+		t.Errorf("ValidateBody() error = %v for a matching body", err)
+	}
+	if err := broken.ValidateBody(); err == nil || !strings.Contains(err.Error(), "schemas/user.json") {
+		t.Errorf("ValidateBody() error = %v, want a mismatch naming the schema", err)
+	}
+	if err := notFound.ValidateBody(); err != nil {
+		t.Errorf("ValidateBody() error = %v for a response without a schema", err)
+	}
+
+	if _, err := ParseAPIMock(write("missing.apimock", "GET /x\n\n-- 200: OK\nSchema: nope.json\n\n{}\n")); err == nil {
+		t.Error("Expected a missing schema file to fail the mock")
+	}
+}
+
+func TestIsXMLContentType(t *testing.T) {
+	tests := map[string]bool{
+		"application/xml":            true,
+		"text/xml; charset=utf-8":    true,
+		"application/soap+xml":       true,
+		"application/json":           false,
+		"application/xml-patch+json": false,
+		"text/plain":                 false,
+	}
+	for contentType, want := range tests {
+		if got := isXMLContentType(contentType); got != want {
+			t.Errorf("isXMLContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
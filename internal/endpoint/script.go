@@ -0,0 +1,119 @@
+package endpoint
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	slashpath "path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ResponseScriptPropertyName names a script computing the status, headers
+// and body of a response from the request, as in
+// `Script: ./hooks/orders.js`, for logic the conditions language cannot
+// express. Relative paths are resolved from the directory of the .apimock
+// file, and the script runs with the engine registered for its extension.
+const ResponseScriptPropertyName = "Script"
+
+// Script computes a response from the request it answers.
+type Script interface {
+	// Run returns the response to the request described by ctx, the values
+	// {{...}} placeholders see.
+	Run(ctx *TemplateContext) (ScriptResult, error)
+}
+
+// ScriptResult is what a script changes in the declared response: a zero
+// Status and a nil Body keep the declared ones, and Headers are added to the
+// declared headers.
+type ScriptResult struct {
+	Status  int
+	Headers map[string]string
+	Body    []byte
+}
+
+// Apply returns resp changed by the result.
+func (r ScriptResult) Apply(resp Response) Response {
+	if r.Status != 0 {
+		resp.StatusCode = r.Status
+	}
+	if r.Body != nil {
+		resp.Body = string(r.Body)
+	}
+	if len(r.Headers) > 0 {
+		headers := maps.Clone(resp.Headers)
+		if headers == nil {
+			headers = make(map[string]string, len(r.Headers))
+		}
+		maps.Copy(headers, r.Headers)
+		resp.Headers = headers
+	}
+	return resp
+}
+
+// ScriptEngine compiles the source of a script read from location, "" for
+// scripts read from an fs.FS.
+type ScriptEngine func(source []byte, location string) (Script, error)
+
+var (
+	scriptEnginesMu sync.RWMutex
+	// scriptEngines maps a file extension, such as .js, to its engine
+	scriptEngines = make(map[string]ScriptEngine)
+)
+
+// RegisterScriptEngine makes engine run the scripts whose file name ends
+// with ext, such as .js or .lua, replacing the engine registered before, if
+// any. No engine is built in. It panics if engine is nil.
+func RegisterScriptEngine(ext string, engine ScriptEngine) {
+	if engine == nil {
+		panic("endpoint: RegisterScriptEngine engine is nil")
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	scriptEnginesMu.Lock()
+	defer scriptEnginesMu.Unlock()
+	scriptEngines[strings.ToLower(ext)] = engine
+}
+
+func scriptEngine(path string) (ScriptEngine, bool) {
+	scriptEnginesMu.RLock()
+	defer scriptEnginesMu.RUnlock()
+	engine, ok := scriptEngines[strings.ToLower(slashpath.Ext(filepath.ToSlash(path)))]
+	return engine, ok
+}
+
+// loadResponseScripts compiles the scripts named by the responses of the
+// endpoint, reading relative paths from dir of fsys, nil for the operating
+// system's files.
+func (e *EndpointSchema) loadResponseScripts(fsys fs.FS, dir string) error {
+	for code, responses := range e.Responses {
+		for i, resp := range responses {
+			if resp.ScriptFile == "" {
+				continue
+			}
+			path := resp.ScriptFile
+			if fsys != nil {
+				path = slashpath.Join(filepath.ToSlash(dir), path)
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+
+			engine, ok := scriptEngine(path)
+			if !ok {
+				return fmt.Errorf("invalid %s of response %d: no script engine is registered for %s files", ResponseScriptPropertyName, code, slashpath.Ext(filepath.ToSlash(path)))
+			}
+			source, location, err := readMockFile(fsys, path)
+			if err != nil {
+				return fmt.Errorf("invalid %s of response %d: %w", ResponseScriptPropertyName, code, err)
+			}
+			script, err := engine(source, location)
+			if err != nil {
+				return fmt.Errorf("invalid %s of response %d: %w", ResponseScriptPropertyName, code, err)
+			}
+			responses[i].Script = script
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package endpoint
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// echoScript answers with its source as the body and the status given by the
+// status query parameter, standing in for a script engine.
+type echoScript struct {
+	source, location string
+}
+
+func (s *echoScript) Run(ctx *TemplateContext) (ScriptResult, error) {
+	if ctx.Query.Get("fail") != "" {
+		return ScriptResult{}, errors.New("boom")
+	}
+	return ScriptResult{Status: 201, Headers: map[string]string{"X-Script": "yes"}, Body: []byte(s.source)}, nil
+}
+
+func registerEchoEngine(t *testing.T) {
+	t.Helper()
+	saved := maps.Clone(scriptEngines)
+	t.Cleanup(func() { scriptEngines = saved })
+	RegisterScriptEngine("JS", func(source []byte, location string) (Script, error) {
+		if strings.Contains(string(source), "syntax error") {
+			return nil, errors.New("unexpected token")
+		}
+		return &echoScript{source: string(source), location: location}, nil
+	})
+}
+
+func TestParseAPIMock_ResponseScript(t *testing.T) {
+	registerEchoEngine(t)
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("hooks/orders.js", "order()")
+	write("hooks/broken.js", "syntax error")
+	write("hooks/orders.lua", "order()")
+
+	schema, err := ParseAPIMock(write("orders.apimock", "POST /orders\n\n-- 200: OK\nScript: hooks/orders.js\n\n{}\n"))
+	if err != nil {
+		t.Fatalf("ParseAPIMock() error = %v", err)
+	}
+	resp := schema.Responses[200][0]
+	if _, isHeader := resp.Headers[ResponseScriptPropertyName]; isHeader {
+		t.Errorf("Expected %s not to be sent as a header", ResponseScriptPropertyName)
+	}
+	script, ok := resp.Script.(*echoScript)
+	if !ok || script.source != "order()" || script.location != filepath.Join(dir, "hooks/orders.js") {
+		t.Fatalf("Script = %+v, want the compiled hooks/orders.js", resp.Script)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{name: "unregistered extension", script: "hooks/orders.lua", want: "no script engine is registered for .lua files"},
+		{name: "missing file", script: "hooks/missing.js", want: "missing.js"},
+		{name: "compile error", script: "hooks/broken.js", want: "unexpected token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAPIMock(write("broken.apimock", "POST /orders\n\n-- 200: OK\nScript: "+tt.script+"\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseAPIMock() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAPIMockFS_ResponseScript(t *testing.T) {
+	registerEchoEngine(t)
+
+	fsys := fstest.MapFS{
+		"mocks/orders.apimock": {Data: []byte("POST /orders\n\n-- 200: OK\nScript: ../hooks/orders.js\n")},
+		"hooks/orders.js":      {Data: []byte("order()")},
+	}
+	schema, err := ParseAPIMockFS(fsys, "mocks/orders.apimock")
+	if err != nil {
+		t.Fatalf("ParseAPIMockFS() error = %v", err)
+	}
+	if script, ok := schema.Responses[200][0].Script.(*echoScript); !ok || script.source != "order()" || script.location != "" {
+		t.Errorf("Script = %+v, want hooks/orders.js read from the fs.FS", schema.Responses[200][0].Script)
+	}
+}
+
+func TestScriptResult_Apply(t *testing.T) {
+	declared := Response{StatusCode: 200, Body: "declared", Headers: map[string]string{"X-Declared": "1"}}
+
+	kept := ScriptResult{}.Apply(declared)
+	if kept.StatusCode != 200 || kept.Body != "declared" {
+		t.Errorf("Apply() of an empty result = %+v, want the declared response", kept)
+	}
+
+	changed := ScriptResult{Status: 409, Body: []byte{}, Headers: map[string]string{"X-Script": "2"}}.Apply(declared)
+	if changed.StatusCode != 409 || changed.Body != "" || changed.Headers["X-Declared"] != "1" || changed.Headers["X-Script"] != "2" {
+		t.Errorf("Apply() = %+v", changed)
+	}
+	if _, leaked := declared.Headers["X-Script"]; leaked {
+		t.Error("Apply() changed the headers of the declared response")
+	}
+}
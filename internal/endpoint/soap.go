@@ -0,0 +1,331 @@
+package endpoint
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	SOAP11EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	SOAP12EnvelopeNamespace = "http://www.w3.org/2003/05/soap-envelope"
+	SOAP11ContentType       = "text/xml; charset=utf-8"
+	SOAP12ContentType       = "application/soap+xml; charset=utf-8"
+
+	RequestSOAPActionPropertyName = "SOAPAction"
+	RequestSOAPBodyPropertyName   = "SOAPBody"
+	ResponseSOAPFaultPropertyName = "SOAPFault"
+
+	wsdlNamespace = "http://schemas.xmlsoap.org/wsdl/"
+	xsdNamespace  = "http://www.w3.org/2001/XMLSchema"
+)
+
+// SOAPVersion identifies the envelope flavour used by a SOAP endpoint.
+type SOAPVersion int
+
+const (
+	SOAP11 SOAPVersion = iota
+	SOAP12
+)
+
+// IsSOAPEndpoint reports whether an endpoint accepting contentType with the
+// given request properties should be treated as a SOAP endpoint.
+func IsSOAPEndpoint(contentType string, properties map[string]string) bool {
+	if _, ok := properties[RequestSOAPActionPropertyName]; ok {
+		return true
+	}
+	if _, ok := properties[RequestSOAPBodyPropertyName]; ok {
+		return true
+	}
+	return SOAPVersionOf(contentType) == SOAP12
+}
+
+// SOAPVersionOf returns the SOAP version implied by a content type.
+func SOAPVersionOf(contentType string) SOAPVersion {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/soap+xml" {
+		return SOAP12
+	}
+	return SOAP11
+}
+
+// SOAPValidator checks that a request body is a SOAP envelope and validates
+// the payload inside soap:Body against an XSD, which may be embedded in a WSDL.
+type SOAPValidator struct {
+	payload SchemaValidator
+}
+
+func NewSOAPValidator(schema string) (SchemaValidator, error) {
+	v := &SOAPValidator{}
+	if strings.TrimSpace(schema) == "" {
+		return v, nil
+	}
+
+	xsd, err := schemaFromWSDL([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := NewXmlSchemaValidator(string(xsd))
+	if err != nil {
+		return nil, err
+	}
+	v.payload = payload
+	return v, nil
+}
+
+// Validate implements SchemaValidator.
+func (s *SOAPValidator) Validate(body string) error {
+	payload, err := soapPayload([]byte(body))
+	if err != nil {
+		return fmt.Errorf("invalid SOAP envelope: %w", err)
+	}
+	if s.payload == nil {
+		return nil
+	}
+	return s.payload.Validate(string(payload.raw))
+}
+
+// Free releases the resources held by the payload validator.
+func (s *SOAPValidator) Free() {
+	if x, ok := s.payload.(*XMLSchemaValidator); ok {
+		x.Free()
+	}
+}
+
+// SOAPMatcher selects a SOAP endpoint by the SOAPAction of the request and
+// the name of the first element inside soap:Body.
+type SOAPMatcher struct {
+	Action      string
+	BodyElement string
+}
+
+// Match implements RequestMatcher.
+func (m *SOAPMatcher) Match(r *http.Request, body []byte) bool {
+	if m.Action != "" && soapAction(r) != m.Action {
+		return false
+	}
+	if m.BodyElement != "" {
+		payload, err := soapPayload(body)
+		if err != nil || payload.name.Local != m.BodyElement {
+			return false
+		}
+	}
+	return true
+}
+
+// soapAction returns the action of a SOAP request, taken from the SOAPAction
+// header (SOAP 1.1) or the action parameter of the Content-Type (SOAP 1.2).
+func soapAction(r *http.Request) string {
+	if action := r.Header.Get("SOAPAction"); action != "" {
+		return strings.Trim(action, `"`)
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get(ContentTypeHeader))
+	if err != nil {
+		return ""
+	}
+	return params["action"]
+}
+
+// SOAPFault builds a SOAP fault envelope. The code is given in SOAP 1.1 terms
+// (Client, Server, ...) and mapped to Sender/Receiver for SOAP 1.2.
+func SOAPFault(version SOAPVersion, code, reason, detail string) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+
+	if version == SOAP12 {
+		switch code {
+		case "Client":
+			code = "Sender"
+		case "Server":
+			code = "Receiver"
+		}
+		fmt.Fprintf(&b, "<env:Envelope xmlns:env=%q>\n", SOAP12EnvelopeNamespace)
+		b.WriteString("  <env:Body>\n    <env:Fault>\n")
+		fmt.Fprintf(&b, "      <env:Code><env:Value>env:%s</env:Value></env:Code>\n", escapeXML(code))
+		fmt.Fprintf(&b, "      <env:Reason><env:Text xml:lang=\"en\">%s</env:Text></env:Reason>\n", escapeXML(reason))
+		if detail != "" {
+			fmt.Fprintf(&b, "      <env:Detail>%s</env:Detail>\n", detail)
+		}
+		b.WriteString("    </env:Fault>\n  </env:Body>\n</env:Envelope>")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "<soap:Envelope xmlns:soap=%q>\n", SOAP11EnvelopeNamespace)
+	b.WriteString("  <soap:Body>\n    <soap:Fault>\n")
+	fmt.Fprintf(&b, "      <faultcode>soap:%s</faultcode>\n", escapeXML(code))
+	fmt.Fprintf(&b, "      <faultstring>%s</faultstring>\n", escapeXML(reason))
+	if detail != "" {
+		fmt.Fprintf(&b, "      <detail>%s</detail>\n", detail)
+	}
+	b.WriteString("    </soap:Fault>\n  </soap:Body>\n</soap:Envelope>")
+	return b.String()
+}
+
+// xmlElement is a standalone copy of an element extracted from a document,
+// carrying the namespace declarations it inherited from its ancestors.
+type xmlElement struct {
+	name xml.Name
+	raw  []byte
+}
+
+// soapPayload extracts the first element inside the soap:Body of an envelope.
+func soapPayload(doc []byte) (*xmlElement, error) {
+	root, err := rootElement(doc)
+	if err != nil {
+		return nil, err
+	}
+	if root.Local != "Envelope" || (root.Space != SOAP11EnvelopeNamespace && root.Space != SOAP12EnvelopeNamespace) {
+		return nil, fmt.Errorf("root element is %s, expected a SOAP Envelope", root.Local)
+	}
+
+	el, err := extractElement(doc, func(stack []xml.Name) bool {
+		return len(stack) == 3 && stack[1].Local == "Body" && stack[1].Space == root.Space
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no element found inside soap:Body")
+	}
+	return el, nil
+}
+
+// schemaFromWSDL returns the first XML schema embedded in the types section
+// of a WSDL document. Documents that are not WSDL are returned unchanged.
+func schemaFromWSDL(doc []byte) ([]byte, error) {
+	root, err := rootElement(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if root.Local != "definitions" || root.Space != wsdlNamespace {
+		return doc, nil
+	}
+
+	el, err := extractElement(doc, func(stack []xml.Name) bool {
+		return len(stack) == 3 && stack[1].Local == "types" && stack[2].Local == "schema" && stack[2].Space == xsdNamespace
+	})
+	if err != nil {
+		return nil, fmt.Errorf("WSDL has no embedded XML schema in its types section")
+	}
+	return el.raw, nil
+}
+
+func rootElement(doc []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// extractElement returns the first element for which want returns true. The
+// stack passed to want holds the names of the element and its ancestors,
+// starting at the root.
+func extractElement(doc []byte, want func(stack []xml.Name) bool) (*xmlElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+
+	var names []xml.Name
+	var scopes []map[string]string
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("element not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			names = append(names, t.Name)
+			if !want(names) {
+				scopes = append(scopes, namespaceDecls(t.Attr))
+				continue
+			}
+
+			own := namespaceDecls(t.Attr)
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+			raw := doc[offset:d.InputOffset()]
+
+			inherited := make(map[string]string)
+			for _, scope := range scopes {
+				for prefix, uri := range scope {
+					inherited[prefix] = uri
+				}
+			}
+			for prefix := range own {
+				delete(inherited, prefix)
+			}
+			return &xmlElement{name: t.Name, raw: withNamespaces(raw, inherited)}, nil
+		case xml.EndElement:
+			names = names[:len(names)-1]
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+}
+
+// namespaceDecls maps prefixes to namespace URIs for the xmlns attributes of
+// an element. The default namespace uses the empty prefix.
+func namespaceDecls(attrs []xml.Attr) map[string]string {
+	decls := make(map[string]string)
+	for _, attr := range attrs {
+		switch {
+		case attr.Name.Space == "xmlns":
+			decls[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			decls[""] = attr.Value
+		}
+	}
+	return decls
+}
+
+// withNamespaces adds namespace declarations to the opening tag of raw.
+func withNamespaces(raw []byte, decls map[string]string) []byte {
+	if len(decls) == 0 {
+		return raw
+	}
+
+	end := 1
+	for end < len(raw) && !strings.ContainsRune(" \t\r\n/>", rune(raw[end])) {
+		end++
+	}
+
+	prefixes := make([]string, 0, len(decls))
+	for prefix := range decls {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var attrs strings.Builder
+	for _, prefix := range prefixes {
+		uri := decls[prefix]
+		if prefix == "" {
+			fmt.Fprintf(&attrs, ` xmlns="%s"`, escapeXML(uri))
+			continue
+		}
+		fmt.Fprintf(&attrs, ` xmlns:%s="%s"`, prefix, escapeXML(uri))
+	}
+
+	out := make([]byte, 0, len(raw)+attrs.Len())
+	out = append(out, raw[:end]...)
+	out = append(out, attrs.String()...)
+	return append(out, raw[end:]...)
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
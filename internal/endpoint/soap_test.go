@@ -0,0 +1,216 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWSDL = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+             xmlns:xs="http://www.w3.org/2001/XMLSchema"
+             xmlns:tns="urn:users"
+             targetNamespace="urn:users">
+  <types>
+    <xs:schema targetNamespace="urn:users" elementFormDefault="qualified">
+      <xs:element name="GetUser">
+        <xs:complexType>
+          <xs:sequence>
+            <xs:element name="id" type="xs:integer"/>
+          </xs:sequence>
+        </xs:complexType>
+      </xs:element>
+    </xs:schema>
+  </types>
+</definitions>`
+
+func soap11Envelope(payload string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:u="urn:users">
+  <soap:Body>
+    ` + payload + `
+  </soap:Body>
+</soap:Envelope>`
+}
+
+func TestSOAPValidator_ValidatesPayloadAgainstWSDLSchema(t *testing.T) {
+	validator, err := NewSOAPValidator(testWSDL)
+	if err != nil {
+		t.Fatalf("Failed to create SOAP validator: %v", err)
+	}
+	defer validator.(*SOAPValidator).Free()
+
+	tests := []struct {
+		name      string
+		body      string
+		shouldErr bool
+	}{
+		{
+			name:      "Valid payload using envelope namespace prefix",
+			body:      soap11Envelope(`<u:GetUser><u:id>42</u:id></u:GetUser>`),
+			shouldErr: false,
+		},
+		{
+			name:      "Invalid payload type",
+			body:      soap11Envelope(`<u:GetUser><u:id>abc</u:id></u:GetUser>`),
+			shouldErr: true,
+		},
+		{
+			name:      "Not an envelope",
+			body:      `<u:GetUser xmlns:u="urn:users"><u:id>42</u:id></u:GetUser>`,
+			shouldErr: true,
+		},
+		{
+			name:      "Empty body",
+			body:      soap11Envelope(``),
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.body)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+func TestSOAPValidator_WithoutSchemaOnlyChecksEnvelope(t *testing.T) {
+	validator, err := NewSOAPValidator("")
+	if err != nil {
+		t.Fatalf("Failed to create SOAP validator: %v", err)
+	}
+
+	if err := validator.Validate(soap11Envelope(`<anything/>`)); err != nil {
+		t.Errorf("expected envelope to be accepted, got %v", err)
+	}
+	if err := validator.Validate(`{"json": true}`); err == nil {
+		t.Error("expected non-envelope body to be rejected")
+	}
+}
+
+func TestSchemaFromWSDL_PlainSchemaUnchanged(t *testing.T) {
+	xsd := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a" type="xs:string"/></xs:schema>`
+
+	got, err := schemaFromWSDL([]byte(xsd))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != xsd {
+		t.Errorf("expected schema to be returned unchanged, got %s", got)
+	}
+}
+
+func TestSchemaFromWSDL_InheritsNamespaces(t *testing.T) {
+	got, err := schemaFromWSDL([]byte(testWSDL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	schema := string(got)
+	if !strings.HasPrefix(schema, "<xs:schema ") {
+		t.Errorf("expected extracted schema element, got %s", schema)
+	}
+	if !strings.Contains(schema, `xmlns:xs="http://www.w3.org/2001/XMLSchema"`) {
+		t.Errorf("expected xs namespace to be declared on extracted schema, got %s", schema)
+	}
+	if !strings.Contains(schema, `xmlns:tns="urn:users"`) {
+		t.Errorf("expected tns namespace to be declared on extracted schema, got %s", schema)
+	}
+}
+
+func TestSOAPMatcher_Match(t *testing.T) {
+	body := []byte(soap11Envelope(`<u:GetUser><u:id>1</u:id></u:GetUser>`))
+
+	tests := []struct {
+		name        string
+		matcher     SOAPMatcher
+		action      string
+		contentType string
+		want        bool
+	}{
+		{name: "Action header", matcher: SOAPMatcher{Action: "urn:GetUser"}, action: `"urn:GetUser"`, want: true},
+		{name: "Wrong action", matcher: SOAPMatcher{Action: "urn:GetUser"}, action: `"urn:DeleteUser"`, want: false},
+		{name: "SOAP 1.2 action parameter", matcher: SOAPMatcher{Action: "urn:GetUser"}, contentType: `application/soap+xml; charset=utf-8; action="urn:GetUser"`, want: true},
+		{name: "Body element", matcher: SOAPMatcher{BodyElement: "GetUser"}, want: true},
+		{name: "Wrong body element", matcher: SOAPMatcher{BodyElement: "DeleteUser"}, want: false},
+		{name: "Action and body element", matcher: SOAPMatcher{Action: "urn:GetUser", BodyElement: "GetUser"}, action: "urn:GetUser", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/soap", nil)
+			if tt.action != "" {
+				req.Header.Set("SOAPAction", tt.action)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			if got := tt.matcher.Match(req, body); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSOAPFault(t *testing.T) {
+	fault11 := SOAPFault(SOAP11, "Client", "Invalid <id>", "<code>E1</code>")
+	for _, want := range []string{
+		`xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"`,
+		"<faultcode>soap:Client</faultcode>",
+		"<faultstring>Invalid &lt;id&gt;</faultstring>",
+		"<detail><code>E1</code></detail>",
+	} {
+		if !strings.Contains(fault11, want) {
+			t.Errorf("SOAP 1.1 fault missing %q:\n%s", want, fault11)
+		}
+	}
+
+	fault12 := SOAPFault(SOAP12, "Server", "Down", "")
+	for _, want := range []string{
+		`xmlns:env="http://www.w3.org/2003/05/soap-envelope"`,
+		"<env:Value>env:Receiver</env:Value>",
+		`<env:Text xml:lang="en">Down</env:Text>`,
+	} {
+		if !strings.Contains(fault12, want) {
+			t.Errorf("SOAP 1.2 fault missing %q:\n%s", want, fault12)
+		}
+	}
+	if strings.Contains(fault12, "Detail") {
+		t.Errorf("expected no detail element without detail, got:\n%s", fault12)
+	}
+}
+
+func TestFromAPIMockFile_SOAPEndpoint(t *testing.T) {
+	ast := newTestAPIMockFile(map[string]string{
+		RequestSOAPActionPropertyName: `"urn:GetUser"`,
+	})
+	ast.Request.Method = "POST"
+	ast.Responses[0].StatusCode = 500
+	ast.Responses[0].Description = "User lookup failed"
+	ast.Responses[0].Properties[ResponseSOAPFaultPropertyName] = "Server"
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(schema.Matchers) != 1 {
+		t.Fatalf("expected a SOAP matcher, got %d matchers", len(schema.Matchers))
+	}
+	if m := schema.Matchers[0].(*SOAPMatcher); m.Action != "urn:GetUser" {
+		t.Errorf("expected action urn:GetUser, got %q", m.Action)
+	}
+
+	resp, _ := schema.GetResponseByStatusCode(500)
+	if resp.ContentType != SOAP11ContentType {
+		t.Errorf("expected SOAP content type, got %q", resp.ContentType)
+	}
+	if !strings.Contains(resp.Body, "<faultstring>User lookup failed</faultstring>") {
+		t.Errorf("expected fault body built from the description, got %s", resp.Body)
+	}
+}
@@ -0,0 +1,290 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// templateRegex matches {{...}} placeholders, which may hold {...} tables.
+var templateRegex = regexp.MustCompile(`\{\{\s*((?:[^{}]|\{[^{}]*\})*?)\s*\}\}`)
+
+// TemplateContext holds the request values that {{...}} placeholders in
+// response headers and bodies can refer to, using the context variable names of the
+// conditions language: method, path, headers, cookies, query, body,
+// raw_body, params, timestamp, date, call_count, response_index and
+// previous_status, plus the session of the request, the claims of its
+// bearer token, the environment variables of the server and the reasons
+// its body failed validation.
+type TemplateContext struct {
+	Method  string
+	Path    string
+	Headers http.Header
+	Query   url.Values
+	Params  func(name string) string
+	Body    any    // decoded JSON body, nil when the body is not JSON
+	RawBody string // body as sent, for signatures and non-JSON bodies
+	Now     time.Time
+	// CallCount is the number of times the endpoint has been called, including
+	// the current request
+	CallCount int
+	// ResponseIndex and PreviousStatus describe the response served on the
+	// previous call to the endpoint: its position among the responses of the
+	// endpoint, ordered by status code, and the status code sent. They are -1
+	// and 0 before the first response.
+	ResponseIndex  int
+	PreviousStatus int
+	// SessionID and SessionData describe the session of the request, if any.
+	// SessionData is the decoded JSON body of the request that created it.
+	SessionID   string
+	SessionData any
+	// Validation is the error of a request body failing its schema, for the
+	// error response served in its place
+	Validation error
+	// ParamTypes holds the types of the typed path parameters, by name; int
+	// parameters are numbers in expressions and the others strings
+	ParamTypes map[string]string
+	// Scope names the session the request belongs to: .random_sticky keeps
+	// its values per scope
+	Scope string
+	// Variables holds the variables assigned by the conditions of the
+	// response, as in `body.items >> items`, by name
+	Variables map[string]any
+
+	// element is the element of a table an expression of .filter, .map or
+	// .sort is evaluated on, when inElement is set
+	element   any
+	inElement bool
+}
+
+func NewTemplateContext(r *http.Request, body []byte) *TemplateContext {
+	ctx := &TemplateContext{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: r.Header,
+		Query:   r.URL.Query(),
+		Params:  r.PathValue,
+		RawBody: string(body),
+		Now:     time.Now(),
+
+		ResponseIndex: -1,
+	}
+	if len(body) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err == nil {
+			ctx.Body = doc
+		}
+	}
+	return ctx
+}
+
+// HasTemplate reports whether s contains a {{...}} placeholder.
+func HasTemplate(s string) bool {
+	return templateRegex.MatchString(s)
+}
+
+// Interpolate replaces the placeholders of s with values from the context.
+// Placeholders that cannot be resolved are left untouched so mistakes show up
+// in the response, and err joins why: an *apimock.ParseError or an
+// *apimock.EvalError per placeholder, locating the column at fault in its
+// expression.
+func (c *TemplateContext) Interpolate(s string) (string, error) {
+	var errs []error
+	result := templateRegex.ReplaceAllStringFunc(s, func(placeholder string) string {
+		expr := templateRegex.FindStringSubmatch(placeholder)[1]
+		if value, ok := c.Lookup(expr); ok {
+			return value
+		}
+		x, err := apimock.ParseExpression(expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("placeholder %s: %w", placeholder, err))
+			return placeholder
+		}
+		value, err := c.eval(x)
+		if err != nil {
+			errs = append(errs, withExpression(err, expr, 0))
+			return placeholder
+		}
+		return renderValue(value)
+	})
+	return result, errors.Join(errs...)
+}
+
+// Lookup resolves a context variable reference such as `method`,
+// `headers["Authorization"]`, `cookies.session`, `query.page`,
+// `body.user.name`, `session.id`, `jwt.sub` or `env.API_KEY`. Values of
+// JSON documents other than strings are rendered as JSON.
+func (c *TemplateContext) Lookup(expr string) (string, bool) {
+	value, ok := c.lookup(expr)
+	if !ok {
+		return "", false
+	}
+	return renderValue(value), true
+}
+
+// lookup resolves a context variable reference as Lookup does, leaving the
+// values of JSON documents decoded.
+func (c *TemplateContext) lookup(expr string) (any, bool) {
+	root, rest := splitReference(expr)
+
+	switch root {
+	case "method":
+		return c.Method, rest == ""
+	case "path":
+		return c.Path, rest == ""
+	case "raw_body":
+		return c.RawBody, rest == ""
+	case "timestamp":
+		return c.Now.Format(time.RFC3339), rest == ""
+	case "date":
+		return c.Now.Format(time.DateOnly), rest == ""
+	case "call_count":
+		return strconv.Itoa(c.CallCount), rest == ""
+	case "response_index":
+		return strconv.Itoa(c.ResponseIndex), rest == ""
+	case "previous_status":
+		return strconv.Itoa(c.PreviousStatus), rest == ""
+	}
+
+	path, err := parseJSONPath("$" + rest)
+	if err != nil || len(path) != 1 && root != "body" && root != "session" && root != "jwt" && root != "validation" {
+		return nil, false
+	}
+
+	switch root {
+	case "env":
+		name, ok := path[0].(string)
+		if !ok {
+			return nil, false
+		}
+		return os.LookupEnv(name)
+	case "headers", "cookies", "query", "params":
+		name, ok := path[0].(string)
+		if !ok {
+			return nil, false
+		}
+		switch root {
+		case "headers":
+			values, ok := c.Headers[http.CanonicalHeaderKey(name)]
+			return strings.Join(values, ", "), ok
+		case "cookies":
+			cookie, err := (&http.Request{Header: c.Headers}).Cookie(name)
+			if err != nil {
+				return nil, false
+			}
+			return cookie.Value, true
+		case "query":
+			values, ok := c.Query[name]
+			return strings.Join(values, ","), ok
+		default:
+			if c.Params == nil {
+				return nil, false
+			}
+			value := c.Params(name)
+			return value, value != ""
+		}
+	case "session":
+		if c.SessionID == "" {
+			return nil, false
+		}
+		if len(path) == 1 && path[0] == "id" {
+			return c.SessionID, true
+		}
+		return documentValue(c.SessionData, path)
+	case "body":
+		return documentValue(c.Body, path)
+	case "validation":
+		if c.Validation == nil {
+			return nil, false
+		}
+		return documentValue(validationDocument(c.Validation), path)
+	case "jwt":
+		claims, ok := BearerClaims(c.Headers)
+		if !ok {
+			return nil, false
+		}
+		return documentValue(claims, path)
+	}
+	return nil, false
+}
+
+// splitReference splits a context variable reference into the variable and
+// its .key and ["key"] steps.
+func splitReference(expr string) (root, rest string) {
+	end := 0
+	for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+		end++
+	}
+	return expr[:end], expr[end:]
+}
+
+// documentValue returns the value at path in a decoded JSON document.
+func documentValue(doc any, path []any) (any, bool) {
+	value, ok := lookupJSONPath(doc, path)
+	if !ok || doc == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// renderValue renders a value for a placeholder: strings as they are,
+// numbers in decimal and anything else as JSON.
+func renderValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// numeric reports whether the context variable ref may hold a number. Typed
+// path parameters are numbers only when they are ints, so a {slug:string}
+// parameter such as 42 is not added to.
+func (c *TemplateContext) numeric(ref string) bool {
+	rest, ok := strings.CutPrefix(ref, "params")
+	if !ok {
+		return true
+	}
+	path, err := parseJSONPath("$" + rest)
+	if err != nil || len(path) != 1 {
+		return true
+	}
+	name, _ := path[0].(string)
+	typ := c.ParamTypes[name]
+	return typ == "" || typ == apimock.ParamTypeInt
+}
+
+// validationDocument describes a validation error for placeholders:
+// {"message": "...", "count": n, "errors": [{"instancePath", "keyword",
+// "message"}], "first": {"path", "keyword", "message"}}.
+func validationDocument(err error) any {
+	issues := ValidationIssues(err)
+	data, _ := json.Marshal(map[string]any{
+		"message": err.Error(),
+		"count":   len(issues),
+		"errors":  issues,
+		"first": map[string]string{
+			"path":    issues[0].InstancePath,
+			"keyword": issues[0].Keyword,
+			"message": issues[0].Message,
+		},
+	})
+	var doc any
+	json.Unmarshal(data, &doc)
+	return doc
+}
@@ -0,0 +1,60 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// RewriteSeparator separates the text to replace from its replacement in the
+// rewrite rules of a proxy section.
+const RewriteSeparator = " => "
+
+// Upstream is the server a proxy section (-- proxy: URL) forwards the
+// requests of an endpoint to, and how its replies are rewritten.
+type Upstream struct {
+	URL *url.URL
+	// Headers are set on the reply; values may contain {{...}} placeholders
+	Headers map[string]string
+	// Rewrites are applied to the reply body, in order
+	Rewrites []Rewrite
+}
+
+// Rewrite replaces every occurrence of Old with New in a proxied body.
+type Rewrite struct {
+	Old string
+	New string
+}
+
+// upstreamFromSection converts a proxy section. Its properties are reply
+// headers and each line of its body is a rewrite rule: `old => new`.
+func upstreamFromSection(section apimock.ResponseSection) (*Upstream, error) {
+	u, err := url.Parse(section.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", section.Upstream, err)
+	}
+
+	upstream := &Upstream{URL: u, Headers: section.Properties}
+	for _, line := range strings.Split(section.Body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		old, replacement, ok := strings.Cut(line, RewriteSeparator)
+		if !ok || old == "" {
+			return nil, fmt.Errorf("invalid rewrite rule %q (expected: old%snew)", line, RewriteSeparator)
+		}
+		upstream.Rewrites = append(upstream.Rewrites, Rewrite{Old: old, New: replacement})
+	}
+	return upstream, nil
+}
+
+// Rewrite applies the rewrite rules to a proxied body.
+func (u *Upstream) Rewrite(body []byte) []byte {
+	s := string(body)
+	for _, r := range u.Rewrites {
+		s = strings.ReplaceAll(s, r.Old, r.New)
+	}
+	return []byte(s)
+}
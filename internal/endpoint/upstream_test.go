@@ -0,0 +1,61 @@
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func newProxySection(upstream, rules string) apimock.ResponseSection {
+	section := apimock.NewResponseSection()
+	section.Upstream = upstream
+	section.Body = rules
+	return section
+}
+
+func TestFromAPIMockFile_Upstream(t *testing.T) {
+	ast := newTestAPIMockFile(nil)
+	proxy := newProxySection("https://api.example.com/v1", "sandbox => mock\n\n\"live\": false => \"live\": true")
+	proxy.Properties["X-Mocked"] = "false"
+	ast.Responses = append(ast.Responses, proxy)
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.Upstream == nil || schema.Upstream.URL.String() != "https://api.example.com/v1" {
+		t.Fatalf("expected upstream https://api.example.com/v1, got %+v", schema.Upstream)
+	}
+	if schema.Upstream.Headers["X-Mocked"] != "false" {
+		t.Errorf("expected proxy properties as reply headers, got %v", schema.Upstream.Headers)
+	}
+	if schema.CountResponses() != 1 {
+		t.Errorf("expected the proxy section not to be a response, got %d responses", schema.CountResponses())
+	}
+
+	got := string(schema.Upstream.Rewrite([]byte(`{"env": "sandbox", "live": false}`)))
+	if want := `{"env": "mock", "live": true}`; got != want {
+		t.Errorf("Rewrite() = %s, want %s", got, want)
+	}
+}
+
+func TestFromAPIMockFile_UpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []apimock.ResponseSection
+	}{
+		{name: "rule without separator", sections: []apimock.ResponseSection{newProxySection("https://api.example.com", "sandbox mock")}},
+		{name: "rule without text", sections: []apimock.ResponseSection{newProxySection("https://api.example.com", " => mock")}},
+		{name: "two proxy sections", sections: []apimock.ResponseSection{newProxySection("https://a.example.com", ""), newProxySection("https://b.example.com", "")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := newTestAPIMockFile(nil)
+			ast.Responses = append(ast.Responses, tt.sections...)
+			if _, err := FromAPIMockFile(ast); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -2,27 +2,57 @@ package endpoint
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
-	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
+// ErrXSDUnavailable is returned for XML schemas the built-in XSD validator of
+// builds without cgo cannot handle. Builds with cgo validate them with
+// libxml2.
+var ErrXSDUnavailable = errors.New("full XML Schema support needs a build with CGO_ENABLED=1 and libxml2 installed")
+
+// strictXSD makes XML schemas fail to load when they cannot be validated,
+// instead of being skipped with a warning.
+var strictXSD atomic.Bool
+
+// SetStrictXSD sets whether endpoints with XML schemas fail to load when the
+// schemas cannot be validated. By default their requests are not validated.
+func SetStrictXSD(strict bool) {
+	strictXSD.Store(strict)
+}
+
+// skipsXSD reports whether err tells that an XML schema cannot be validated and
+// the schema should be skipped rather than rejected.
+func skipsXSD(err error) bool {
+	return errors.Is(err, ErrXSDUnavailable) && !strictXSD.Load()
+}
+
 type SchemaValidator interface {
 	Validate(body string) error
 }
 
 func NewValidator(contentType string, schema string) (SchemaValidator, error) {
+	return NewValidatorAt(contentType, schema, "")
+}
+
+// NewValidatorAt is NewValidator for a schema read from the file at
+// location, whose relative JSON Schema $refs name files next to it. The
+// validator comes from the factory registered for contentType (see
+// RegisterValidator).
+func NewValidatorAt(contentType, schema, location string) (SchemaValidator, error) {
 	if strings.TrimSpace(schema) == "" {
 		return nil, nil
 	}
 
-	if contentType == "application/xml" {
-		return NewXmlSchemaValidator(schema)
-	}
-
-	return NewJsonSchemaValidator(schema)
+	return validatorFactory(contentType)(schema, location)
 }
 
 type JsonSchemaValidator struct {
@@ -30,13 +60,30 @@ type JsonSchemaValidator struct {
 }
 
 func NewJsonSchemaValidator(schema string) (SchemaValidator, error) {
+	return NewJsonSchemaValidatorAt(schema, "")
+}
+
+// NewJsonSchemaValidatorAt compiles a JSON Schema read from the file at
+// location, resolving its relative $refs from the directory of that file.
+// Schemas without $schema follow draft 2020-12; draft 2019-09, draft-07,
+// draft-06 and draft-04 are used when $schema names them. An empty
+// location leaves relative $refs unresolvable.
+func NewJsonSchemaValidatorAt(schema, location string) (SchemaValidator, error) {
 	var schemaDoc any
 	if err := json.Unmarshal([]byte(schema), &schemaDoc); err != nil {
 		return nil, fmt.Errorf("failed to parse schema JSON: %w", err)
 	}
 
 	compiler := jsonschema.NewCompiler()
-	const schemaURL = "inmemory://schema.json"
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	schemaURL := "inmemory://schema.json"
+	if location != "" {
+		abs, err := filepath.Abs(location)
+		if err != nil {
+			return nil, err
+		}
+		schemaURL = (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
 	if err := compiler.AddResource(schemaURL, schemaDoc); err != nil {
 		return nil, err
 	}
@@ -49,7 +96,8 @@ func NewJsonSchemaValidator(schema string) (SchemaValidator, error) {
 	}, nil
 }
 
-// Validate implements SchemaValidator.
+// Validate implements SchemaValidator. Bodies failing the schema return a
+// *BodyValidationError.
 func (j *JsonSchemaValidator) Validate(body string) error {
 	var data any
 	if err := json.Unmarshal([]byte(body), &data); err != nil {
@@ -57,44 +105,94 @@ func (j *JsonSchemaValidator) Validate(body string) error {
 	}
 
 	if err := j.validator.Validate(data); err != nil {
+		var verr *jsonschema.ValidationError
+		if errors.As(err, &verr) {
+			return &BodyValidationError{Format: "JSON", Issues: validationIssues(verr)}
+		}
 		return fmt.Errorf("JSON validation failed: %w", err)
 	}
 
 	return nil
 }
 
-type XMLSchemaValidator struct {
-	xsdHandler *xsdvalidate.XsdHandler
+// ValidationIssue is one reason a body fails its schema.
+type ValidationIssue struct {
+	// InstancePath is the JSON pointer of the failing value, "" for the
+	// whole body
+	InstancePath string `json:"instancePath"`
+	// Keyword is the schema keyword the value fails, such as required or type
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
 }
 
-func NewXmlSchemaValidator(schema string) (SchemaValidator, error) {
-	xsdvalidate.Init()
-	xsdHandler, err := xsdvalidate.NewXsdHandlerMem([]byte(schema), xsdvalidate.ParsErrDefault)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse XSD schema: %w", err)
-	}
-
-	return &XMLSchemaValidator{
-		xsdHandler: xsdHandler,
-	}, nil
+// BodyValidationError lists the issues of a body failing its schema.
+type BodyValidationError struct {
+	Format string // JSON or XML
+	Issues []ValidationIssue
 }
 
-func (x *XMLSchemaValidator) Validate(body string) error {
-	if x.xsdHandler == nil {
-		return fmt.Errorf("XSD handler not initialized")
+func (e *BodyValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		path := issue.InstancePath
+		if path == "" {
+			path = "/"
+		}
+		messages[i] = fmt.Sprintf("%s: %s", path, issue.Message)
 	}
+	return e.Format + " validation failed: " + strings.Join(messages, "; ")
+}
 
-	if err := x.xsdHandler.ValidateMem([]byte(body), xsdvalidate.ValidErrDefault); err != nil {
-		return fmt.Errorf("XML validation failed: %w", err)
+// ValidationIssues returns the issues of a validation error: those of a
+// *BodyValidationError, *MissingParametersError or *ParamTypeError, or a
+// single issue holding the message of any other error, such as a body that is
+// not JSON.
+func ValidationIssues(err error) []ValidationIssue {
+	var verr *BodyValidationError
+	if errors.As(err, &verr) && len(verr.Issues) > 0 {
+		return verr.Issues
 	}
+	var described interface{ issues() []ValidationIssue }
+	if errors.As(err, &described) {
+		return described.issues()
+	}
+	return []ValidationIssue{{Message: err.Error()}}
+}
 
-	return nil
+// validationIssues flattens the leaves of a validation error tree, which are
+// the keywords the body fails; the inner nodes only tell which subschema,
+// such as a $ref or an allOf branch, led to them.
+func validationIssues(verr *jsonschema.ValidationError) []ValidationIssue {
+	var issues []ValidationIssue
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+		issue := ValidationIssue{Message: e.ErrorKind.LocalizedString(englishPrinter)}
+		if len(e.InstanceLocation) > 0 {
+			issue.InstancePath = "/" + strings.Join(escapePointer(e.InstanceLocation), "/")
+		}
+		if keywords := e.ErrorKind.KeywordPath(); len(keywords) > 0 {
+			issue.Keyword = keywords[0]
+		}
+		issues = append(issues, issue)
+	}
+	walk(verr)
+	return issues
 }
 
-// Free releases the resources held by the XMLSchemaValidator.
-// This should be called when the validator is no longer needed.
-func (x *XMLSchemaValidator) Free() {
-	if x.xsdHandler != nil {
-		x.xsdHandler.Free()
+// englishPrinter renders the messages of validation errors.
+var englishPrinter = message.NewPrinter(language.English)
+
+// escapePointer escapes the tokens of a JSON pointer.
+func escapePointer(tokens []string) []string {
+	escaped := make([]string, len(tokens))
+	for i, token := range tokens {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
 	}
+	return escaped
 }
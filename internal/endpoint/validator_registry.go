@@ -0,0 +1,63 @@
+package endpoint
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// ValidatorFactory compiles the schema declared for bodies of a content
+// type. location is the file the schema was read from, "" for schemas
+// written inline in a mock file, so factories can resolve references to
+// files next to it.
+type ValidatorFactory func(schema, location string) (SchemaValidator, error)
+
+var (
+	validatorsMu sync.RWMutex
+	// validatorFactories maps a media type to the factory of its validators
+	validatorFactories = map[string]ValidatorFactory{
+		"application/json": NewJsonSchemaValidatorAt,
+		"application/xml": func(schema, _ string) (SchemaValidator, error) {
+			return NewXmlSchemaValidator(schema)
+		},
+	}
+)
+
+// RegisterValidator makes factory compile the schemas of request and
+// response bodies of contentType, such as application/x-protobuf, replacing
+// the factory registered before, if any. Parameters of contentType are
+// ignored. It panics if factory is nil.
+//
+// Content types without a factory of their own use the application/xml one
+// when they are XML, as text/xml or application/atom+xml, and the
+// application/json one otherwise.
+func RegisterValidator(contentType string, factory ValidatorFactory) {
+	if factory == nil {
+		panic("endpoint: RegisterValidator factory is nil")
+	}
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validatorFactories[validatorMediaType(contentType)] = factory
+}
+
+// validatorFactory returns the factory compiling the schemas of contentType.
+func validatorFactory(contentType string) ValidatorFactory {
+	mediaType := validatorMediaType(contentType)
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	if factory, ok := validatorFactories[mediaType]; ok {
+		return factory
+	}
+	if isXMLContentType(mediaType) {
+		return validatorFactories["application/xml"]
+	}
+	return validatorFactories["application/json"]
+}
+
+func validatorMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
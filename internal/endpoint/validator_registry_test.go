@@ -0,0 +1,85 @@
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"testing"
+)
+
+// lengthValidator accepts bodies of a fixed length, standing in for a
+// custom validator.
+type lengthValidator struct {
+	schema, location string
+}
+
+func (v *lengthValidator) Validate(body string) error {
+	if len(body) != len(v.schema) {
+		return errors.New("wrong length")
+	}
+	return nil
+}
+
+func TestRegisterValidator(t *testing.T) {
+	saved := maps.Clone(validatorFactories)
+	t.Cleanup(func() { validatorFactories = saved })
+
+	RegisterValidator("Application/X-Protobuf; proto=shop.Order", func(schema, location string) (SchemaValidator, error) {
+		return &lengthValidator{schema: schema, location: location}, nil
+	})
+
+	validator, err := NewValidatorAt("application/x-protobuf", "abc", "mocks/orders.apimock")
+	if err != nil {
+		t.Fatalf("NewValidatorAt() error = %v", err)
+	}
+	custom, ok := validator.(*lengthValidator)
+	if !ok {
+		t.Fatalf("NewValidatorAt() = %T, want the registered validator", validator)
+	}
+	if custom.location != "mocks/orders.apimock" {
+		t.Errorf("location = %q, want mocks/orders.apimock", custom.location)
+	}
+	if err := validator.Validate("xyz"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Empty schemas still mean no validation
+	if validator, err := NewValidatorAt("application/x-protobuf", " ", ""); validator != nil || err != nil {
+		t.Errorf("NewValidatorAt() with an empty schema = %v, %v", validator, err)
+	}
+}
+
+func TestValidatorFactory_Fallbacks(t *testing.T) {
+	tests := []struct {
+		contentType string
+		schema      string
+		want        string
+	}{
+		{contentType: "application/json", schema: `{"type": "object"}`, want: "*endpoint.JsonSchemaValidator"},
+		{contentType: "application/vnd.api+json; charset=utf-8", schema: `{"type": "object"}`, want: "*endpoint.JsonSchemaValidator"},
+		{contentType: "", schema: `{"type": "object"}`, want: "*endpoint.JsonSchemaValidator"},
+		{contentType: "text/xml; charset=utf-8", schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"/></xs:schema>`, want: "*endpoint.XMLSchemaValidator"},
+		{contentType: "application/atom+xml", schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"/></xs:schema>`, want: "*endpoint.XMLSchemaValidator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			validator, err := NewValidator(tt.contentType, tt.schema)
+			if err != nil {
+				t.Fatalf("NewValidator() error = %v", err)
+			}
+			if got := fmt.Sprintf("%T", validator); got != tt.want {
+				t.Errorf("NewValidator() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterValidator_NilFactory(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected RegisterValidator to panic on a nil factory")
+		}
+	}()
+	RegisterValidator("application/x-protobuf", nil)
+}
@@ -1,7 +1,11 @@
 package endpoint
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -63,6 +67,104 @@ func TestJsonSchemaValidator_Validate(t *testing.T) {
 	}
 }
 
+func TestJsonSchemaValidator_Issues(t *testing.T) {
+	validator, err := NewJsonSchemaValidator(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name"]
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to create JSON schema validator: %v", err)
+	}
+
+	err = validator.Validate(`{"tags": ["a", 1]}`)
+	var verr *BodyValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want a *BodyValidationError", err)
+	}
+	got := make(map[string]string)
+	for _, issue := range verr.Issues {
+		got[issue.InstancePath] = issue.Keyword
+		if issue.Message == "" {
+			t.Errorf("Issue %+v has no message", issue)
+		}
+	}
+	want := map[string]string{"": "required", "/tags/1": "type"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Issues = %+v, want paths and keywords %v", verr.Issues, want)
+	}
+
+	if issues := ValidationIssues(errors.New("not JSON")); len(issues) != 1 || issues[0].Message != "not JSON" {
+		t.Errorf("ValidationIssues() = %+v for a plain error", issues)
+	}
+}
+
+func TestJsonSchemaValidator_Drafts(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		body   string
+		valid  bool
+	}{
+		{
+			name:   "2020-12 by default",
+			schema: `{"prefixItems": [{"type": "integer"}], "items": false}`,
+			body:   `[1, 2]`,
+			valid:  false,
+		},
+		{
+			name:   "2019-09",
+			schema: `{"$schema": "https://json-schema.org/draft/2019-09/schema", "dependentRequired": {"a": ["b"]}}`,
+			body:   `{"a": 1}`,
+			valid:  false,
+		},
+		{
+			name:   "draft-04 boolean exclusiveMaximum",
+			schema: `{"$schema": "http://json-schema.org/draft-04/schema#", "maximum": 10, "exclusiveMaximum": true}`,
+			body:   `10`,
+			valid:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewJsonSchemaValidator(tt.schema)
+			if err != nil {
+				t.Fatalf("Failed to create JSON schema validator: %v", err)
+			}
+			if err := validator.Validate(tt.body); (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestNewJsonSchemaValidatorAt_LocalRefs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "defs.json"), []byte(`{"$defs": {"id": {"type": "integer", "minimum": 1}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"type": "object", "properties": {"id": {"$ref": "defs.json#/$defs/id"}}}`
+
+	validator, err := NewJsonSchemaValidatorAt(schema, filepath.Join(dir, "users.apimock"))
+	if err != nil {
+		t.Fatalf("NewJsonSchemaValidatorAt() error = %v", err)
+	}
+	if err := validator.Validate(`{"id": 3}`); err != nil {
+		t.Errorf("Validate() error = %v for a valid body", err)
+	}
+	if err := validator.Validate(`{"id": 0}`); err == nil {
+		t.Error("Validate() accepted a value failing the referenced schema")
+	}
+
+	if _, err := NewJsonSchemaValidatorAt(`{"$ref": "missing.json"}`, filepath.Join(dir, "users.apimock")); err == nil {
+		t.Error("Expected a $ref to a missing file to fail")
+	}
+}
+
 func TestXMLSchemaValidator_Validate(t *testing.T) {
 	schema := `<?xml version="1.0" encoding="UTF-8"?>
 <xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
@@ -209,3 +311,29 @@ func TestNewValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestSkipsXSD(t *testing.T) {
+	t.Cleanup(func() { SetStrictXSD(false) })
+
+	tests := []struct {
+		name   string
+		err    error
+		strict bool
+		want   bool
+	}{
+		{name: "unavailable", err: ErrXSDUnavailable, want: true},
+		{name: "wrapped", err: fmt.Errorf("failed to create SOAP validator: %w", ErrXSDUnavailable), want: true},
+		{name: "strict", err: ErrXSDUnavailable, strict: true, want: false},
+		{name: "other error", err: fmt.Errorf("failed to parse XSD schema"), want: false},
+		{name: "no error", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetStrictXSD(tt.strict)
+			if got := skipsXSD(tt.err); got != tt.want {
+				t.Errorf("skipsXSD(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
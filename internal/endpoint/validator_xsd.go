@@ -0,0 +1,45 @@
+//go:build cgo
+
+package endpoint
+
+import (
+	"fmt"
+
+	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
+)
+
+type XMLSchemaValidator struct {
+	xsdHandler *xsdvalidate.XsdHandler
+}
+
+func NewXmlSchemaValidator(schema string) (SchemaValidator, error) {
+	xsdvalidate.Init()
+	xsdHandler, err := xsdvalidate.NewXsdHandlerMem([]byte(schema), xsdvalidate.ParsErrDefault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XSD schema: %w", err)
+	}
+
+	return &XMLSchemaValidator{
+		xsdHandler: xsdHandler,
+	}, nil
+}
+
+func (x *XMLSchemaValidator) Validate(body string) error {
+	if x.xsdHandler == nil {
+		return fmt.Errorf("XSD handler not initialized")
+	}
+
+	if err := x.xsdHandler.ValidateMem([]byte(body), xsdvalidate.ValidErrDefault); err != nil {
+		return fmt.Errorf("XML validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// Free releases the resources held by the XMLSchemaValidator.
+// This should be called when the validator is no longer needed.
+func (x *XMLSchemaValidator) Free() {
+	if x.xsdHandler != nil {
+		x.xsdHandler.Free()
+	}
+}
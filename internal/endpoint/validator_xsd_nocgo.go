@@ -0,0 +1,25 @@
+//go:build !cgo
+
+package endpoint
+
+// XMLSchemaValidator validates XML bodies with the built-in XSD validator,
+// which covers the subset of XML Schema described in xsd.go.
+type XMLSchemaValidator struct {
+	schema *xsdSchema
+}
+
+func NewXmlSchemaValidator(schema string) (SchemaValidator, error) {
+	compiled, err := compileXSD([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+	return &XMLSchemaValidator{schema: compiled}, nil
+}
+
+func (x *XMLSchemaValidator) Validate(body string) error {
+	return x.schema.validate([]byte(body))
+}
+
+// Free releases the resources held by the XMLSchemaValidator, of which the
+// built-in validator holds none.
+func (x *XMLSchemaValidator) Free() {}
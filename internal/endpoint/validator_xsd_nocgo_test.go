@@ -0,0 +1,28 @@
+//go:build !cgo
+
+package endpoint
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromAPIMockFile_XSDUnavailable(t *testing.T) {
+	t.Cleanup(func() { SetStrictXSD(false) })
+
+	ast := newTestAPIMockFile(map[string]string{RequestAcceptPropertyName: "application/xml"})
+	ast.Request.BodySchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:import namespace="urn:other" schemaLocation="other.xsd"/></xs:schema>`
+
+	schema, err := FromAPIMockFile(ast)
+	if err != nil {
+		t.Fatalf("expected the schema to be skipped, got error: %v", err)
+	}
+	if schema.Validator != nil {
+		t.Errorf("expected no validator, got %T", schema.Validator)
+	}
+
+	SetStrictXSD(true)
+	if _, err := FromAPIMockFile(ast); !errors.Is(err, ErrXSDUnavailable) {
+		t.Errorf("expected ErrXSDUnavailable in strict mode, got %v", err)
+	}
+}
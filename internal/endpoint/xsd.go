@@ -0,0 +1,1435 @@
+package endpoint
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file holds the XSD validator of builds without cgo. It covers the
+// parts of XML Schema mocks use: global and local elements, named and
+// anonymous types, sequence, choice and all groups with minOccurs and
+// maxOccurs, named groups and attribute groups, attributes, simple and
+// complex content derived by extension or restriction, the built-in types
+// and the facets of simple types. Schemas using anything else, such as
+// xs:import or substitution groups, fail to compile with an error wrapping
+// ErrXSDUnavailable. Identity constraints (xs:unique, xs:key) are not
+// checked.
+
+const (
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// xsdUnsupported is the error of a schema construct the validator does not
+// implement.
+func xsdUnsupported(construct string) error {
+	return fmt.Errorf("the built-in XSD validator does not support %s: %w", construct, ErrXSDUnavailable)
+}
+
+// xmlNode is an element of a parsed XML document.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr // without namespace declarations
+	children []*xmlNode
+	text     string            // character data directly inside the element
+	scope    map[string]string // namespace prefixes in scope, "" for the default namespace
+}
+
+// parseXMLTree parses an XML document into its root element.
+func parseXMLTree(doc []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, scope: make(map[string]string)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				maps.Copy(n.scope, parent.scope)
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+			maps.Copy(n.scope, namespaceDecls(t.Attr))
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" && (attr.Name.Space != "" || attr.Name.Local != "xmlns") {
+					n.attrs = append(n.attrs, attr)
+				}
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("document has no root element")
+	}
+	return root, nil
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// qname resolves a prefixed name written in an attribute of n, such as
+// tns:Order, using the namespaces in scope.
+func (n *xmlNode) qname(value string) xml.Name {
+	prefix, local, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return xml.Name{Space: n.scope[""], Local: prefix}
+	}
+	return xml.Name{Space: n.scope[prefix], Local: local}
+}
+
+// xsdSchema is a compiled XML schema.
+type xsdSchema struct {
+	targetNS   string
+	elements   map[xml.Name]*xsdElement
+	types      map[xml.Name]*xsdType
+	attributes map[xml.Name]*xsdAttribute
+}
+
+type xsdElement struct {
+	name     xml.Name
+	typeName xml.Name // named type, when typ is nil
+	typ      *xsdType
+	nillable bool
+	fixed    *string
+}
+
+// xsdType is a simple type, a complex type or xs:anyType.
+type xsdType struct {
+	simple  *xsdSimpleType
+	complex *xsdComplexType
+	any     bool
+}
+
+type xsdSimpleType struct {
+	builtin string          // local name of a built-in type
+	base    *xsdSimpleRef   // type restricted by facets
+	item    *xsdSimpleRef   // item type of a list
+	members []*xsdSimpleRef // member types of a union
+	facets  xsdFacets
+}
+
+// xsdSimpleRef refers to a simple type by name or holds it.
+type xsdSimpleRef struct {
+	name xml.Name
+	typ  *xsdSimpleType
+}
+
+type xsdFacets struct {
+	enumeration                  []string
+	patterns                     []*regexp.Regexp
+	length, minLength, maxLength int // -1 when unset
+	minInclusive, maxInclusive   string
+	minExclusive, maxExclusive   string
+	totalDigits, fractionDigits  int // -1 when unset
+}
+
+type xsdComplexType struct {
+	base          xml.Name // type derived from, if any
+	extension     bool     // derived by extension rather than restriction
+	simpleContent bool
+	facets        *xsdFacets // facets of a simple content restriction
+	content       *xsdParticle
+	attributes    []*xsdAttribute
+	anyAttribute  bool
+	mixed         bool
+
+	resolved  *xsdContent
+	resolving bool
+}
+
+// xsdContent is what a complex type allows once its derivation is applied.
+type xsdContent struct {
+	simple       *xsdSimpleType // text of simple content
+	particle     *xsdParticle
+	attributes   []*xsdAttribute
+	anyAttribute bool
+	mixed        bool
+}
+
+type xsdAttribute struct {
+	name       xml.Name
+	typ        *xsdSimpleRef // nil for xs:anySimpleType
+	required   bool
+	prohibited bool
+	fixed      *string
+}
+
+type particleKind int
+
+const (
+	particleElement particleKind = iota
+	particleSequence
+	particleChoice
+	particleAll
+	particleAny
+)
+
+type xsdParticle struct {
+	kind     particleKind
+	min, max int // max < 0 is unbounded
+	element  *xsdElement
+	children []*xsdParticle
+	// namespaces and processContents of xs:any
+	namespaces      []string
+	processContents string
+}
+
+// xsdCompiler turns the elements of a schema document into an xsdSchema.
+type xsdCompiler struct {
+	s          *xsdSchema
+	qualified  bool // elementFormDefault="qualified"
+	attrQual   bool // attributeFormDefault="qualified"
+	groups     map[xml.Name]*xmlNode
+	attrGroups map[xml.Name]*xmlNode
+	expanding  map[*xmlNode]bool
+	// fixups resolve references once every global declaration is known
+	fixups []func() error
+}
+
+// compileXSD compiles an XSD document.
+func compileXSD(doc []byte) (*xsdSchema, error) {
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XSD schema: %w", err)
+	}
+	if root.name.Space != xsdNamespace || root.name.Local != "schema" {
+		return nil, fmt.Errorf("failed to parse XSD schema: root element is %s, not xs:schema", root.name.Local)
+	}
+
+	targetNS, _ := root.attr("targetNamespace")
+	elementForm, _ := root.attr("elementFormDefault")
+	attributeForm, _ := root.attr("attributeFormDefault")
+	c := &xsdCompiler{
+		s: &xsdSchema{
+			targetNS:   targetNS,
+			elements:   make(map[xml.Name]*xsdElement),
+			types:      make(map[xml.Name]*xsdType),
+			attributes: make(map[xml.Name]*xsdAttribute),
+		},
+		qualified:  elementForm == "qualified",
+		attrQual:   attributeForm == "qualified",
+		groups:     make(map[xml.Name]*xmlNode),
+		attrGroups: make(map[xml.Name]*xmlNode),
+		expanding:  make(map[*xmlNode]bool),
+	}
+
+	// Named groups are expanded where they are referenced
+	for _, n := range xsdChildren(root) {
+		name, _ := n.attr("name")
+		switch n.name.Local {
+		case "group":
+			c.groups[c.global(name)] = n
+		case "attributeGroup":
+			c.attrGroups[c.global(name)] = n
+		}
+	}
+
+	for _, n := range xsdChildren(root) {
+		name, _ := n.attr("name")
+		switch n.name.Local {
+		case "element":
+			el, err := c.element(n, true)
+			if err != nil {
+				return nil, err
+			}
+			c.s.elements[el.name] = el
+		case "complexType":
+			ct, err := c.complexType(n)
+			if err != nil {
+				return nil, err
+			}
+			c.s.types[c.global(name)] = &xsdType{complex: ct}
+		case "simpleType":
+			st, err := c.simpleType(n)
+			if err != nil {
+				return nil, err
+			}
+			c.s.types[c.global(name)] = &xsdType{simple: st}
+		case "attribute":
+			attr, err := c.attribute(n, true)
+			if err != nil {
+				return nil, err
+			}
+			c.s.attributes[attr.name] = attr
+		case "group", "attributeGroup", "annotation", "notation":
+		default:
+			return nil, xsdUnsupported("xs:" + n.name.Local)
+		}
+	}
+
+	for _, fixup := range c.fixups {
+		if err := fixup(); err != nil {
+			return nil, fmt.Errorf("failed to parse XSD schema: %w", err)
+		}
+	}
+	return c.s, nil
+}
+
+// xsdChildren returns the XML Schema elements inside n, leaving out
+// annotations.
+func xsdChildren(n *xmlNode) []*xmlNode {
+	var children []*xmlNode
+	for _, child := range n.children {
+		if child.name.Space == xsdNamespace && child.name.Local != "annotation" {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func (c *xsdCompiler) global(name string) xml.Name {
+	return xml.Name{Space: c.s.targetNS, Local: name}
+}
+
+// requireType checks, once the schema is compiled, that name is a built-in
+// or declared type.
+func (c *xsdCompiler) requireType(name xml.Name) {
+	c.fixups = append(c.fixups, func() error {
+		if _, ok := c.s.typeOf(name); !ok {
+			return fmt.Errorf("unknown type %s", name.Local)
+		}
+		return nil
+	})
+}
+
+func (c *xsdCompiler) element(n *xmlNode, global bool) (*xsdElement, error) {
+	if _, ok := n.attr("substitutionGroup"); ok {
+		return nil, xsdUnsupported("substitution groups")
+	}
+	if abstract, _ := n.attr("abstract"); abstract == "true" {
+		return nil, xsdUnsupported("abstract elements")
+	}
+
+	name, _ := n.attr("name")
+	form, hasForm := n.attr("form")
+	el := &xsdElement{name: xml.Name{Local: name}}
+	if global || form == "qualified" || !hasForm && c.qualified {
+		el.name.Space = c.s.targetNS
+	}
+	nillable, _ := n.attr("nillable")
+	el.nillable = nillable == "true"
+	if fixed, ok := n.attr("fixed"); ok {
+		el.fixed = &fixed
+	}
+
+	if typeName, ok := n.attr("type"); ok {
+		el.typeName = n.qname(typeName)
+		c.requireType(el.typeName)
+		return el, nil
+	}
+	for _, child := range xsdChildren(n) {
+		switch child.name.Local {
+		case "complexType":
+			ct, err := c.complexType(child)
+			if err != nil {
+				return nil, err
+			}
+			el.typ = &xsdType{complex: ct}
+		case "simpleType":
+			st, err := c.simpleType(child)
+			if err != nil {
+				return nil, err
+			}
+			el.typ = &xsdType{simple: st}
+		case "unique", "key", "keyref":
+		default:
+			return nil, xsdUnsupported("xs:" + child.name.Local + " in an element")
+		}
+	}
+	if el.typ == nil {
+		el.typ = &xsdType{any: true}
+	}
+	return el, nil
+}
+
+func (c *xsdCompiler) complexType(n *xmlNode) (*xsdComplexType, error) {
+	ct := &xsdComplexType{}
+	mixed, _ := n.attr("mixed")
+	ct.mixed = mixed == "true"
+	if err := c.typeBody(ct, n); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+// typeBody compiles the content model and attributes of a complex type, or
+// of the derivation inside its simpleContent or complexContent.
+func (c *xsdCompiler) typeBody(ct *xsdComplexType, n *xmlNode) error {
+	for _, child := range xsdChildren(n) {
+		switch child.name.Local {
+		case "sequence", "choice", "all", "group":
+			p, err := c.particle(child)
+			if err != nil {
+				return err
+			}
+			ct.content = p
+		case "attribute", "attributeGroup", "anyAttribute":
+			if err := c.attributeUse(ct, child); err != nil {
+				return err
+			}
+		case "simpleContent", "complexContent":
+			if mixed, _ := child.attr("mixed"); mixed == "true" {
+				ct.mixed = true
+			}
+			ct.simpleContent = child.name.Local == "simpleContent"
+			derivations := xsdChildren(child)
+			if len(derivations) != 1 || derivations[0].name.Local != "extension" && derivations[0].name.Local != "restriction" {
+				return fmt.Errorf("failed to parse XSD schema: xs:%s needs an xs:extension or xs:restriction", child.name.Local)
+			}
+			derivation := derivations[0]
+			base, ok := derivation.attr("base")
+			if !ok {
+				return fmt.Errorf("failed to parse XSD schema: xs:%s without a base", derivation.name.Local)
+			}
+			ct.base = derivation.qname(base)
+			ct.extension = derivation.name.Local == "extension"
+			c.requireType(ct.base)
+			if ct.simpleContent && !ct.extension {
+				facets, err := c.facets(derivation)
+				if err != nil {
+					return err
+				}
+				ct.facets = &facets
+			}
+			if err := c.typeBody(ct, derivation); err != nil {
+				return err
+			}
+		case "simpleType":
+			// The inline base of a simple content restriction
+			if n.name.Local != "restriction" {
+				return xsdUnsupported("xs:simpleType in " + n.name.Local)
+			}
+		case "enumeration", "pattern", "length", "minLength", "maxLength", "minInclusive", "maxInclusive",
+			"minExclusive", "maxExclusive", "totalDigits", "fractionDigits", "whiteSpace":
+			if n.name.Local != "restriction" {
+				return xsdUnsupported("xs:" + child.name.Local + " in " + n.name.Local)
+			}
+		case "openContent", "assert":
+			return xsdUnsupported("xs:" + child.name.Local)
+		default:
+			return xsdUnsupported("xs:" + child.name.Local + " in a complex type")
+		}
+	}
+	return nil
+}
+
+// attributeUse adds an attribute, attribute group or attribute wildcard to
+// the attributes of ct.
+func (c *xsdCompiler) attributeUse(ct *xsdComplexType, n *xmlNode) error {
+	switch n.name.Local {
+	case "anyAttribute":
+		ct.anyAttribute = true
+	case "attribute":
+		attr, err := c.attribute(n, false)
+		if err != nil {
+			return err
+		}
+		ct.attributes = append(ct.attributes, attr)
+	case "attributeGroup":
+		ref, ok := n.attr("ref")
+		if !ok {
+			return fmt.Errorf("failed to parse XSD schema: local xs:attributeGroup without ref")
+		}
+		group, ok := c.attrGroups[n.qname(ref)]
+		if !ok {
+			return fmt.Errorf("failed to parse XSD schema: unknown attribute group %s", ref)
+		}
+		if c.expanding[group] {
+			return fmt.Errorf("failed to parse XSD schema: attribute group %s refers to itself", ref)
+		}
+		c.expanding[group] = true
+		defer delete(c.expanding, group)
+		for _, child := range xsdChildren(group) {
+			if err := c.attributeUse(ct, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *xsdCompiler) attribute(n *xmlNode, global bool) (*xsdAttribute, error) {
+	attr := &xsdAttribute{}
+	use, _ := n.attr("use")
+	attr.required = use == "required"
+	attr.prohibited = use == "prohibited"
+	if fixed, ok := n.attr("fixed"); ok {
+		attr.fixed = &fixed
+	}
+
+	if ref, ok := n.attr("ref"); ok {
+		name := n.qname(ref)
+		c.fixups = append(c.fixups, func() error {
+			decl, ok := c.s.attributes[name]
+			if !ok {
+				return fmt.Errorf("unknown attribute %s", ref)
+			}
+			attr.name, attr.typ = decl.name, decl.typ
+			if attr.fixed == nil {
+				attr.fixed = decl.fixed
+			}
+			return nil
+		})
+		return attr, nil
+	}
+
+	name, _ := n.attr("name")
+	form, hasForm := n.attr("form")
+	attr.name = xml.Name{Local: name}
+	if global || form == "qualified" || !hasForm && c.attrQual {
+		attr.name.Space = c.s.targetNS
+	}
+	if typeName, ok := n.attr("type"); ok {
+		attr.typ = &xsdSimpleRef{name: n.qname(typeName)}
+		c.requireType(attr.typ.name)
+	}
+	for _, child := range xsdChildren(n) {
+		if child.name.Local != "simpleType" {
+			return nil, xsdUnsupported("xs:" + child.name.Local + " in an attribute")
+		}
+		st, err := c.simpleType(child)
+		if err != nil {
+			return nil, err
+		}
+		attr.typ = &xsdSimpleRef{typ: st}
+	}
+	return attr, nil
+}
+
+// occurs reads the minOccurs and maxOccurs of a particle.
+func occurs(n *xmlNode) (int, int, error) {
+	lo, hi := 1, 1
+	if v, ok := n.attr("minOccurs"); ok {
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("failed to parse XSD schema: invalid minOccurs %q", v)
+		}
+		lo = parsed
+	}
+	if v, ok := n.attr("maxOccurs"); ok {
+		if strings.TrimSpace(v) == "unbounded" {
+			hi = -1
+		} else {
+			parsed, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || parsed < 0 {
+				return 0, 0, fmt.Errorf("failed to parse XSD schema: invalid maxOccurs %q", v)
+			}
+			hi = parsed
+		}
+	}
+	return lo, hi, nil
+}
+
+func (c *xsdCompiler) particle(n *xmlNode) (*xsdParticle, error) {
+	lo, hi, err := occurs(n)
+	if err != nil {
+		return nil, err
+	}
+	p := &xsdParticle{min: lo, max: hi}
+
+	switch n.name.Local {
+	case "element":
+		p.kind = particleElement
+		if ref, ok := n.attr("ref"); ok {
+			name := n.qname(ref)
+			c.fixups = append(c.fixups, func() error {
+				el, ok := c.s.elements[name]
+				if !ok {
+					return fmt.Errorf("unknown element %s", ref)
+				}
+				p.element = el
+				return nil
+			})
+			return p, nil
+		}
+		el, err := c.element(n, false)
+		if err != nil {
+			return nil, err
+		}
+		p.element = el
+	case "any":
+		p.kind = particleAny
+		namespaces, ok := n.attr("namespace")
+		if !ok {
+			namespaces = "##any"
+		}
+		p.namespaces = strings.Fields(namespaces)
+		p.processContents, _ = n.attr("processContents")
+		if p.processContents == "" {
+			p.processContents = "strict"
+		}
+	case "group":
+		ref, ok := n.attr("ref")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse XSD schema: local xs:group without ref")
+		}
+		group, ok := c.groups[n.qname(ref)]
+		if !ok {
+			return nil, fmt.Errorf("failed to parse XSD schema: unknown group %s", ref)
+		}
+		if c.expanding[group] {
+			return nil, fmt.Errorf("failed to parse XSD schema: group %s refers to itself", ref)
+		}
+		c.expanding[group] = true
+		defer delete(c.expanding, group)
+		children := xsdChildren(group)
+		if len(children) != 1 {
+			return nil, fmt.Errorf("failed to parse XSD schema: group %s needs one model group", ref)
+		}
+		inner, err := c.particle(children[0])
+		if err != nil {
+			return nil, err
+		}
+		// The occurrence of the reference applies to the group as a whole
+		return &xsdParticle{kind: particleSequence, min: lo, max: hi, children: []*xsdParticle{inner}}, nil
+	case "sequence", "choice", "all":
+		p.kind = map[string]particleKind{"sequence": particleSequence, "choice": particleChoice, "all": particleAll}[n.name.Local]
+		for _, child := range xsdChildren(n) {
+			inner, err := c.particle(child)
+			if err != nil {
+				return nil, err
+			}
+			if p.kind == particleAll && inner.kind != particleElement {
+				return nil, fmt.Errorf("failed to parse XSD schema: xs:all may only hold elements")
+			}
+			p.children = append(p.children, inner)
+		}
+	default:
+		return nil, xsdUnsupported("xs:" + n.name.Local + " in a model group")
+	}
+	return p, nil
+}
+
+func (c *xsdCompiler) simpleType(n *xmlNode) (*xsdSimpleType, error) {
+	children := xsdChildren(n)
+	if len(children) != 1 {
+		return nil, fmt.Errorf("failed to parse XSD schema: xs:simpleType needs a restriction, list or union")
+	}
+	def := children[0]
+	st := &xsdSimpleType{}
+
+	// refs returns the simple types named by attr, or the inline xs:simpleType
+	// children of def
+	refs := func(attr string) ([]*xsdSimpleRef, error) {
+		var refs []*xsdSimpleRef
+		if names, ok := def.attr(attr); ok {
+			for _, name := range strings.Fields(names) {
+				ref := &xsdSimpleRef{name: def.qname(name)}
+				c.requireType(ref.name)
+				refs = append(refs, ref)
+			}
+		}
+		for _, child := range xsdChildren(def) {
+			if child.name.Local != "simpleType" {
+				continue
+			}
+			inline, err := c.simpleType(child)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, &xsdSimpleRef{typ: inline})
+		}
+		return refs, nil
+	}
+
+	switch def.name.Local {
+	case "restriction":
+		base, err := refs("base")
+		if err != nil {
+			return nil, err
+		}
+		if len(base) != 1 {
+			return nil, fmt.Errorf("failed to parse XSD schema: xs:restriction needs one base type")
+		}
+		st.base = base[0]
+		if st.facets, err = c.facets(def); err != nil {
+			return nil, err
+		}
+	case "list":
+		item, err := refs("itemType")
+		if err != nil {
+			return nil, err
+		}
+		if len(item) != 1 {
+			return nil, fmt.Errorf("failed to parse XSD schema: xs:list needs one item type")
+		}
+		st.item = item[0]
+		st.facets = unsetFacets()
+	case "union":
+		members, err := refs("memberTypes")
+		if err != nil {
+			return nil, err
+		}
+		st.members = members
+		st.facets = unsetFacets()
+	default:
+		return nil, xsdUnsupported("xs:" + def.name.Local + " in a simple type")
+	}
+	return st, nil
+}
+
+func unsetFacets() xsdFacets {
+	return xsdFacets{length: -1, minLength: -1, maxLength: -1, totalDigits: -1, fractionDigits: -1}
+}
+
+// facets reads the facets of a restriction.
+func (c *xsdCompiler) facets(n *xmlNode) (xsdFacets, error) {
+	f := unsetFacets()
+	var patterns []string
+	for _, child := range xsdChildren(n) {
+		value, _ := child.attr("value")
+		number := func() (int, error) {
+			v, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("failed to parse XSD schema: invalid %s %q", child.name.Local, value)
+			}
+			return v, nil
+		}
+		var err error
+		switch child.name.Local {
+		case "enumeration":
+			f.enumeration = append(f.enumeration, value)
+		case "pattern":
+			patterns = append(patterns, value)
+		case "length":
+			f.length, err = number()
+		case "minLength":
+			f.minLength, err = number()
+		case "maxLength":
+			f.maxLength, err = number()
+		case "totalDigits":
+			f.totalDigits, err = number()
+		case "fractionDigits":
+			f.fractionDigits, err = number()
+		case "minInclusive":
+			f.minInclusive = strings.TrimSpace(value)
+		case "maxInclusive":
+			f.maxInclusive = strings.TrimSpace(value)
+		case "minExclusive":
+			f.minExclusive = strings.TrimSpace(value)
+		case "maxExclusive":
+			f.maxExclusive = strings.TrimSpace(value)
+		case "whiteSpace", "simpleType", "attribute", "attributeGroup", "anyAttribute", "sequence", "choice", "all", "group":
+		default:
+			return f, xsdUnsupported("xs:" + child.name.Local)
+		}
+		if err != nil {
+			return f, err
+		}
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return f, xsdUnsupported(fmt.Sprintf("the pattern %q", pattern))
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// typeOf returns the built-in or declared type called name.
+func (s *xsdSchema) typeOf(name xml.Name) (*xsdType, bool) {
+	if name.Space == xsdNamespace {
+		if name.Local == "anyType" {
+			return &xsdType{any: true}, true
+		}
+		return &xsdType{simple: &xsdSimpleType{builtin: name.Local}}, true
+	}
+	t, ok := s.types[name]
+	return t, ok
+}
+
+// simpleOf returns the simple type ref stands for; nil refs are
+// xs:anySimpleType.
+func (s *xsdSchema) simpleOf(ref *xsdSimpleRef) (*xsdSimpleType, error) {
+	if ref == nil {
+		return &xsdSimpleType{builtin: "anySimpleType"}, nil
+	}
+	if ref.typ != nil {
+		return ref.typ, nil
+	}
+	t, _ := s.typeOf(ref.name)
+	switch {
+	case t.simple != nil:
+		return t.simple, nil
+	case t.any:
+		return &xsdSimpleType{builtin: "anySimpleType"}, nil
+	}
+	content, err := s.content(t.complex)
+	if err != nil || content.simple == nil {
+		return nil, fmt.Errorf("type %s is not a simple type", ref.name.Local)
+	}
+	return content.simple, nil
+}
+
+// content applies the derivation of a complex type.
+func (s *xsdSchema) content(ct *xsdComplexType) (*xsdContent, error) {
+	if ct.resolved != nil {
+		return ct.resolved, nil
+	}
+	if ct.resolving {
+		return nil, errors.New("circular type derivation")
+	}
+	ct.resolving = true
+	defer func() { ct.resolving = false }()
+
+	content := &xsdContent{
+		particle:     ct.content,
+		attributes:   ct.attributes,
+		anyAttribute: ct.anyAttribute,
+		mixed:        ct.mixed,
+	}
+	if ct.base != (xml.Name{}) {
+		base, _ := s.typeOf(ct.base)
+		switch {
+		case base.any:
+			if ct.simpleContent {
+				content.simple = &xsdSimpleType{builtin: "anySimpleType"}
+			}
+		case base.simple != nil:
+			content.simple = base.simple
+		default:
+			inherited, err := s.content(base.complex)
+			if err != nil {
+				return nil, err
+			}
+			content.attributes = mergeAttributes(inherited.attributes, ct.attributes)
+			content.anyAttribute = content.anyAttribute || inherited.anyAttribute
+			switch {
+			case ct.simpleContent:
+				content.simple = inherited.simple
+			case ct.extension:
+				content.mixed = content.mixed || inherited.mixed
+				switch {
+				case inherited.particle == nil:
+				case ct.content == nil:
+					content.particle = inherited.particle
+				default:
+					content.particle = &xsdParticle{kind: particleSequence, min: 1, max: 1, children: []*xsdParticle{inherited.particle, ct.content}}
+				}
+			}
+		}
+		if ct.simpleContent && content.simple == nil {
+			return nil, fmt.Errorf("type %s has no simple content to derive from", ct.base.Local)
+		}
+		if ct.facets != nil {
+			content.simple = &xsdSimpleType{base: &xsdSimpleRef{typ: content.simple}, facets: *ct.facets}
+		}
+	}
+	ct.resolved = content
+	return content, nil
+}
+
+// mergeAttributes returns the inherited attributes overridden by the
+// declared ones.
+func mergeAttributes(inherited, declared []*xsdAttribute) []*xsdAttribute {
+	merged := slices.Clone(declared)
+	for _, attr := range inherited {
+		if !slices.ContainsFunc(declared, func(d *xsdAttribute) bool { return d.name == attr.name }) {
+			merged = append(merged, attr)
+		}
+	}
+	return merged
+}
+
+// validate checks an XML document against the schema.
+func (s *xsdSchema) validate(doc []byte) error {
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+	v := &xsdValidation{schema: s}
+	path := "/" + root.name.Local
+	if el, ok := s.elements[root.name]; ok {
+		v.element(root, el, path)
+	} else {
+		v.report(path, "element", "no declaration for root element %s", describeName(root.name))
+	}
+	if len(v.issues) > 0 {
+		return &BodyValidationError{Format: "XML", Issues: v.issues}
+	}
+	return nil
+}
+
+func describeName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return fmt.Sprintf("{%s}%s", name.Space, name.Local)
+}
+
+// xsdValidation collects the issues of a document.
+type xsdValidation struct {
+	schema *xsdSchema
+	issues []ValidationIssue
+}
+
+func (v *xsdValidation) report(path, keyword, format string, args ...any) {
+	v.issues = append(v.issues, ValidationIssue{InstancePath: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *xsdValidation) element(n *xmlNode, el *xsdElement, path string) {
+	typ := el.typ
+	if typ == nil {
+		typ, _ = v.schema.typeOf(el.typeName)
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Space == xsiNamespace && attr.Name.Local == "nil" && strings.TrimSpace(attr.Value) == "true" {
+			if !el.nillable {
+				v.report(path, "nillable", "element %s is not nillable", n.name.Local)
+			} else if len(n.children) > 0 || strings.TrimSpace(n.text) != "" {
+				v.report(path, "nillable", "nil element %s must be empty", n.name.Local)
+			}
+			return
+		}
+	}
+	if typ.any {
+		return
+	}
+
+	if typ.simple != nil {
+		v.attributes(n, path, nil)
+		if len(n.children) > 0 {
+			v.report(path, "type", "element %s must not have child elements", n.name.Local)
+			return
+		}
+		v.text(n, el, typ.simple, path)
+		return
+	}
+
+	content, err := v.schema.content(typ.complex)
+	if err != nil {
+		v.report(path, "type", "%v", err)
+		return
+	}
+	v.attributes(n, path, content)
+	if content.simple != nil {
+		if len(n.children) > 0 {
+			v.report(path, "type", "element %s must not have child elements", n.name.Local)
+			return
+		}
+		v.text(n, el, content.simple, path)
+		return
+	}
+	if !content.mixed && strings.TrimSpace(n.text) != "" {
+		v.report(path, "mixed", "element %s must not contain text", n.name.Local)
+	}
+	v.children(n, content.particle, path)
+}
+
+// text checks the text of an element of simple type.
+func (v *xsdValidation) text(n *xmlNode, el *xsdElement, st *xsdSimpleType, path string) {
+	if keyword, message := v.schema.checkSimple(st, n.text); keyword != "" {
+		v.report(path, keyword, "%s", message)
+		return
+	}
+	if el.fixed != nil && v.schema.normalize(st, n.text) != *el.fixed {
+		v.report(path, "fixed", "element %s must be %q", n.name.Local, *el.fixed)
+	}
+}
+
+// attributes checks the attributes of n against those content declares;
+// nil content allows none.
+func (v *xsdValidation) attributes(n *xmlNode, path string, content *xsdContent) {
+	var declared []*xsdAttribute
+	anyAttribute := false
+	if content != nil {
+		declared, anyAttribute = content.attributes, content.anyAttribute
+	}
+	seen := make(map[xml.Name]bool)
+	for _, attr := range n.attrs {
+		if attr.Name.Space == xsiNamespace || attr.Name.Space == xmlNamespace {
+			continue
+		}
+		attrPath := path + "/@" + attr.Name.Local
+		i := slices.IndexFunc(declared, func(d *xsdAttribute) bool { return d.name == attr.Name })
+		if i < 0 || declared[i].prohibited {
+			if !anyAttribute {
+				v.report(attrPath, "attribute", "attribute %s is not allowed on element %s", attr.Name.Local, n.name.Local)
+			}
+			continue
+		}
+		decl := declared[i]
+		seen[decl.name] = true
+		st, err := v.schema.simpleOf(decl.typ)
+		if err != nil {
+			v.report(attrPath, "type", "%v", err)
+			continue
+		}
+		if keyword, message := v.schema.checkSimple(st, attr.Value); keyword != "" {
+			v.report(attrPath, keyword, "%s", message)
+		} else if decl.fixed != nil && v.schema.normalize(st, attr.Value) != *decl.fixed {
+			v.report(attrPath, "fixed", "attribute %s must be %q", attr.Name.Local, *decl.fixed)
+		}
+	}
+	for _, decl := range declared {
+		if decl.required && !seen[decl.name] {
+			v.report(path, "required", "element %s is missing required attribute %s", n.name.Local, decl.name.Local)
+		}
+	}
+}
+
+// children checks the child elements of n against its content model, then
+// each child against the declaration it matched.
+func (v *xsdValidation) children(n *xmlNode, particle *xsdParticle, path string) {
+	paths := childPaths(n, path)
+	if particle == nil {
+		for i, child := range n.children {
+			v.report(paths[i], "element", "element %s is not allowed in %s, which must be empty", child.name.Local, n.name.Local)
+		}
+		return
+	}
+
+	m := &contentMatch{schema: v.schema, nodes: n.children, assigned: make(map[*xmlNode]*xsdParticle)}
+	if !slices.Contains(m.match(particle, 0), len(n.children)) {
+		expected := ""
+		if len(m.expected) > 0 {
+			expected = "; expected " + strings.Join(m.expected, ", ")
+		}
+		if m.furthest < len(n.children) {
+			v.report(paths[m.furthest], "element", "element %s is not expected here%s", n.children[m.furthest].name.Local, expected)
+		} else {
+			v.report(path, "element", "element %s is incomplete%s", n.name.Local, expected)
+		}
+		return
+	}
+
+	for i, child := range n.children {
+		p := m.assigned[child]
+		if p.kind == particleElement {
+			v.element(child, p.element, paths[i])
+			continue
+		}
+		if p.processContents == "skip" {
+			continue
+		}
+		if el, ok := v.schema.elements[child.name]; ok {
+			v.element(child, el, paths[i])
+		} else if p.processContents == "strict" {
+			v.report(paths[i], "any", "no declaration for element %s", describeName(child.name))
+		}
+	}
+}
+
+// childPaths returns the paths of the children of n, numbering the names
+// that repeat, as in /order/item[2].
+func childPaths(n *xmlNode, path string) []string {
+	counts := make(map[xml.Name]int)
+	for _, child := range n.children {
+		counts[child.name]++
+	}
+	seen := make(map[xml.Name]int)
+	paths := make([]string, len(n.children))
+	for i, child := range n.children {
+		seen[child.name]++
+		paths[i] = path + "/" + child.name.Local
+		if counts[child.name] > 1 {
+			paths[i] += fmt.Sprintf("[%d]", seen[child.name])
+		}
+	}
+	return paths
+}
+
+// contentMatch matches a list of elements against a content model,
+// tracking which particle each element matched and, for error messages,
+// how far the match got and what was expected there.
+type contentMatch struct {
+	schema   *xsdSchema
+	nodes    []*xmlNode
+	assigned map[*xmlNode]*xsdParticle
+	furthest int
+	expected []string
+}
+
+// match returns the positions p can end at when it starts at pos.
+func (m *contentMatch) match(p *xsdParticle, pos int) []int {
+	var ends []int
+	if p.min == 0 {
+		ends = append(ends, pos)
+	}
+	positions := []int{pos}
+	for count := 1; len(positions) > 0 && (p.max < 0 || count <= p.max); count++ {
+		var next []int
+		for _, at := range positions {
+			for _, end := range m.matchOnce(p, at) {
+				// Repeating an empty match past the minimum gets nowhere
+				if end == at && count > p.min {
+					continue
+				}
+				next = appendUnique(next, end)
+			}
+		}
+		if count >= p.min {
+			for _, end := range next {
+				ends = appendUnique(ends, end)
+			}
+		}
+		positions = next
+	}
+	return ends
+}
+
+func (m *contentMatch) matchOnce(p *xsdParticle, pos int) []int {
+	switch p.kind {
+	case particleElement:
+		if pos < len(m.nodes) && m.nodes[pos].name == p.element.name {
+			m.consume(pos, p)
+			return []int{pos + 1}
+		}
+		m.expect(pos, p.element.name.Local)
+	case particleAny:
+		if pos < len(m.nodes) && m.allows(p, m.nodes[pos].name.Space) {
+			m.consume(pos, p)
+			return []int{pos + 1}
+		}
+		m.expect(pos, "any element")
+	case particleSequence:
+		positions := []int{pos}
+		for _, child := range p.children {
+			var next []int
+			for _, at := range positions {
+				for _, end := range m.match(child, at) {
+					next = appendUnique(next, end)
+				}
+			}
+			if positions = next; len(positions) == 0 {
+				return nil
+			}
+		}
+		return positions
+	case particleChoice:
+		var ends []int
+		for _, child := range p.children {
+			for _, end := range m.match(child, pos) {
+				ends = appendUnique(ends, end)
+			}
+		}
+		return ends
+	case particleAll:
+		used := make([]bool, len(p.children))
+		at := pos
+		for at < len(m.nodes) {
+			i := slices.IndexFunc(p.children, func(child *xsdParticle) bool { return child.element.name == m.nodes[at].name })
+			if i < 0 || used[i] {
+				break
+			}
+			used[i] = true
+			m.consume(at, p.children[i])
+			at++
+		}
+		for i, child := range p.children {
+			if !used[i] && child.min > 0 {
+				m.expect(at, child.element.name.Local)
+				return nil
+			}
+		}
+		return []int{at}
+	}
+	return nil
+}
+
+func (m *contentMatch) consume(pos int, p *xsdParticle) {
+	m.assigned[m.nodes[pos]] = p
+	if pos+1 > m.furthest {
+		m.furthest, m.expected = pos+1, nil
+	}
+}
+
+func (m *contentMatch) expect(pos int, name string) {
+	if pos > m.furthest {
+		m.furthest, m.expected = pos, nil
+	}
+	if pos == m.furthest && !slices.Contains(m.expected, name) {
+		m.expected = append(m.expected, name)
+	}
+}
+
+// allows reports whether the wildcard p accepts an element of namespace ns.
+func (m *contentMatch) allows(p *xsdParticle, ns string) bool {
+	for _, allowed := range p.namespaces {
+		switch allowed {
+		case "##any":
+			return true
+		case "##other":
+			if ns != m.schema.targetNS && ns != "" {
+				return true
+			}
+		case "##targetNamespace":
+			if ns == m.schema.targetNS {
+				return true
+			}
+		case "##local":
+			if ns == "" {
+				return true
+			}
+		default:
+			if ns == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func appendUnique(list []int, n int) []int {
+	if slices.Contains(list, n) {
+		return list
+	}
+	return append(list, n)
+}
+
+// preservesSpace reports whether values of st keep their whitespace, which
+// only string types do; the others collapse it.
+func (s *xsdSchema) preservesSpace(st *xsdSimpleType) bool {
+	for depth := 0; st != nil && depth < 64; depth++ {
+		switch {
+		case st.builtin != "":
+			return st.builtin == "string" || st.builtin == "normalizedString" || st.builtin == "anySimpleType"
+		case st.base != nil:
+			base, err := s.simpleOf(st.base)
+			if err != nil {
+				return true
+			}
+			st = base
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalize applies the whitespace handling of st to value.
+func (s *xsdSchema) normalize(st *xsdSimpleType, value string) string {
+	if s.preservesSpace(st) {
+		return value
+	}
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// checkSimple validates value against a simple type, returning the keyword
+// it fails and why, or "" when it is valid.
+func (s *xsdSchema) checkSimple(st *xsdSimpleType, value string) (string, string) {
+	return s.checkSimpleDepth(st, value, 0)
+}
+
+func (s *xsdSchema) checkSimpleDepth(st *xsdSimpleType, value string, depth int) (string, string) {
+	if depth > 64 {
+		return "type", "type derivation is too deep"
+	}
+	value = s.normalize(st, value)
+
+	switch {
+	case st.builtin != "":
+		return checkBuiltin(st.builtin, value)
+	case st.item != nil:
+		item, err := s.simpleOf(st.item)
+		if err != nil {
+			return "type", err.Error()
+		}
+		items := strings.Fields(value)
+		for _, v := range items {
+			if keyword, message := s.checkSimpleDepth(item, v, depth+1); keyword != "" {
+				return keyword, message
+			}
+		}
+		return st.facets.check(value, len(items))
+	case len(st.members) > 0:
+		for _, ref := range st.members {
+			member, err := s.simpleOf(ref)
+			if err != nil {
+				return "type", err.Error()
+			}
+			if keyword, _ := s.checkSimpleDepth(member, value, depth+1); keyword == "" {
+				return "", ""
+			}
+		}
+		return "union", fmt.Sprintf("value %q matches none of the member types", value)
+	case st.base != nil:
+		base, err := s.simpleOf(st.base)
+		if err != nil {
+			return "type", err.Error()
+		}
+		if keyword, message := s.checkSimpleDepth(base, value, depth+1); keyword != "" {
+			return keyword, message
+		}
+		return st.facets.check(value, utf8.RuneCountInString(value))
+	}
+	return "", ""
+}
+
+// check applies the facets to a value of the given length, in characters or
+// list items.
+func (f *xsdFacets) check(value string, length int) (string, string) {
+	if len(f.enumeration) > 0 && !slices.Contains(f.enumeration, value) {
+		return "enumeration", fmt.Sprintf("value %q is not one of %s", value, strings.Join(quoteAll(f.enumeration), ", "))
+	}
+	// Patterns of the same restriction are alternatives
+	if len(f.patterns) > 0 && !slices.ContainsFunc(f.patterns, func(re *regexp.Regexp) bool { return re.MatchString(value) }) {
+		return "pattern", fmt.Sprintf("value %q does not match the pattern %s", value, f.patterns[0].String())
+	}
+	switch {
+	case f.length >= 0 && length != f.length:
+		return "length", fmt.Sprintf("value %q must have length %d", value, f.length)
+	case f.minLength >= 0 && length < f.minLength:
+		return "minLength", fmt.Sprintf("value %q is shorter than %d", value, f.minLength)
+	case f.maxLength >= 0 && length > f.maxLength:
+		return "maxLength", fmt.Sprintf("value %q is longer than %d", value, f.maxLength)
+	case f.minInclusive != "" && compareXSD(value, f.minInclusive) < 0:
+		return "minInclusive", fmt.Sprintf("value %s is less than %s", value, f.minInclusive)
+	case f.maxInclusive != "" && compareXSD(value, f.maxInclusive) > 0:
+		return "maxInclusive", fmt.Sprintf("value %s is greater than %s", value, f.maxInclusive)
+	case f.minExclusive != "" && compareXSD(value, f.minExclusive) <= 0:
+		return "minExclusive", fmt.Sprintf("value %s must be greater than %s", value, f.minExclusive)
+	case f.maxExclusive != "" && compareXSD(value, f.maxExclusive) >= 0:
+		return "maxExclusive", fmt.Sprintf("value %s must be less than %s", value, f.maxExclusive)
+	}
+	if f.totalDigits >= 0 || f.fractionDigits >= 0 {
+		whole, fraction, _ := strings.Cut(strings.TrimLeft(value, "+-"), ".")
+		whole, fraction = strings.TrimLeft(whole, "0"), strings.TrimRight(fraction, "0")
+		if f.totalDigits >= 0 && len(whole)+len(fraction) > f.totalDigits {
+			return "totalDigits", fmt.Sprintf("value %s has more than %d digits", value, f.totalDigits)
+		}
+		if f.fractionDigits >= 0 && len(fraction) > f.fractionDigits {
+			return "fractionDigits", fmt.Sprintf("value %s has more than %d fraction digits", value, f.fractionDigits)
+		}
+	}
+	return "", ""
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return quoted
+}
+
+// compareXSD orders two values numerically when both are numbers, and as
+// text otherwise, which orders dates and times written in the same zone.
+func compareXSD(a, b string) int {
+	x, okA := new(big.Float).SetString(a)
+	y, okB := new(big.Float).SetString(b)
+	if okA && okB {
+		return x.Cmp(y)
+	}
+	return strings.Compare(a, b)
+}
+
+var builtinPatterns = map[string]*regexp.Regexp{
+	"boolean":    regexp.MustCompile(`^(true|false|1|0)$`),
+	"decimal":    regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`),
+	"integer":    regexp.MustCompile(`^[+-]?\d+$`),
+	"date":       regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+	"dateTime":   regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+	"time":       regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+	"duration":   regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`),
+	"gYear":      regexp.MustCompile(`^-?\d{4,}(Z|[+-]\d{2}:\d{2})?$`),
+	"gYearMonth": regexp.MustCompile(`^-?\d{4,}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+	"hexBinary":  regexp.MustCompile(`^([0-9a-fA-F]{2})*$`),
+}
+
+// integerRanges bounds the built-in integer types; "" is unbounded.
+var integerRanges = map[string][2]string{
+	"integer":            {"", ""},
+	"nonNegativeInteger": {"0", ""},
+	"positiveInteger":    {"1", ""},
+	"nonPositiveInteger": {"", "0"},
+	"negativeInteger":    {"", "-1"},
+	"long":               {"-9223372036854775808", "9223372036854775807"},
+	"int":                {"-2147483648", "2147483647"},
+	"short":              {"-32768", "32767"},
+	"byte":               {"-128", "127"},
+	"unsignedLong":       {"0", "18446744073709551615"},
+	"unsignedInt":        {"0", "4294967295"},
+	"unsignedShort":      {"0", "65535"},
+	"unsignedByte":       {"0", "255"},
+}
+
+// checkBuiltin validates value against a built-in type. Built-in types
+// without a lexical check here, such as string or anyURI, accept any value.
+func checkBuiltin(name, value string) (string, string) {
+	invalid := func() (string, string) {
+		return "type", fmt.Sprintf("value %q is not a valid %s", value, name)
+	}
+
+	if bounds, ok := integerRanges[name]; ok {
+		n, ok := new(big.Int).SetString(strings.TrimPrefix(value, "+"), 10)
+		if !ok || !builtinPatterns["integer"].MatchString(value) {
+			return invalid()
+		}
+		if lo, ok := new(big.Int).SetString(bounds[0], 10); ok && n.Cmp(lo) < 0 {
+			return invalid()
+		}
+		if hi, ok := new(big.Int).SetString(bounds[1], 10); ok && n.Cmp(hi) > 0 {
+			return invalid()
+		}
+		return "", ""
+	}
+
+	switch name {
+	case "float", "double":
+		if value == "INF" || value == "-INF" || value == "NaN" {
+			return "", ""
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil || strings.ContainsAny(value, "xXpP_") {
+			return invalid()
+		}
+	case "base64Binary":
+		if _, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), "")); err != nil {
+			return invalid()
+		}
+	case "date", "dateTime":
+		if !builtinPatterns[name].MatchString(value) {
+			return invalid()
+		}
+		// The pattern does not check the ranges of months and days
+		if day := strings.TrimPrefix(value, "-"); len(day) >= 10 && day[4] == '-' {
+			if _, err := time.Parse(time.DateOnly, day[:10]); err != nil {
+				return invalid()
+			}
+		}
+	case "duration":
+		if !builtinPatterns[name].MatchString(value) || value == "P" || value == "-P" || strings.HasSuffix(value, "T") {
+			return invalid()
+		}
+	default:
+		if re, ok := builtinPatterns[name]; ok && !re.MatchString(value) {
+			return invalid()
+		}
+	}
+	return "", ""
+}
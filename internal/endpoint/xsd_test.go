@@ -0,0 +1,236 @@
+package endpoint
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const orderXSD = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+           xmlns:tns="urn:shop" targetNamespace="urn:shop" elementFormDefault="qualified">
+  <xs:simpleType name="Sku">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[A-Z]{3}-[0-9]+"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="Status">
+    <xs:restriction base="xs:token">
+      <xs:enumeration value="open"/>
+      <xs:enumeration value="paid"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="Item">
+    <xs:sequence>
+      <xs:element name="sku" type="tns:Sku"/>
+      <xs:element name="qty">
+        <xs:simpleType>
+          <xs:restriction base="xs:positiveInteger">
+            <xs:maxInclusive value="99"/>
+          </xs:restriction>
+        </xs:simpleType>
+      </xs:element>
+      <xs:element name="note" type="xs:string" minOccurs="0"/>
+    </xs:sequence>
+    <xs:attribute name="gift" type="xs:boolean"/>
+  </xs:complexType>
+  <xs:complexType name="Price">
+    <xs:simpleContent>
+      <xs:extension base="xs:decimal">
+        <xs:attribute name="currency" type="xs:string" use="required"/>
+      </xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="item" type="tns:Item" maxOccurs="unbounded"/>
+        <xs:choice>
+          <xs:element name="pickup" type="xs:string"/>
+          <xs:element name="address" type="xs:string"/>
+        </xs:choice>
+        <xs:element name="total" type="tns:Price"/>
+      </xs:sequence>
+      <xs:attribute name="status" type="tns:Status" use="required"/>
+      <xs:attribute name="placed" type="xs:date"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+func TestXSDSchema_Validate(t *testing.T) {
+	schema, err := compileXSD([]byte(orderXSD))
+	if err != nil {
+		t.Fatalf("compileXSD() error = %v", err)
+	}
+
+	order := func(attrs, content string) string {
+		return `<order xmlns="urn:shop" ` + attrs + `>` + content + `</order>`
+	}
+	const item = `<item><sku>ABC-1</sku><qty>2</qty></item>`
+	const rest = `<pickup>store</pickup><total currency="EUR">10.50</total>`
+
+	tests := []struct {
+		name    string
+		body    string
+		path    string // of the first issue, "" when valid
+		keyword string
+	}{
+		{name: "valid", body: order(`status="open" placed="2024-02-29"`, item+`<item gift="true"><sku>XYZ-20</sku><qty> 99 </qty><note>wrap it</note></item>`+rest)},
+		{name: "choice alternative", body: order(`status="paid"`, item+`<address>Main St</address><total currency="EUR">1</total>`)},
+		{name: "unknown root", body: `<invoice xmlns="urn:shop"/>`, path: "/invoice", keyword: "element"},
+		{name: "wrong namespace", body: `<order status="open">` + item + rest + `</order>`, path: "/order", keyword: "element"},
+		{name: "missing required attribute", body: order(``, item+rest), path: "/order", keyword: "required"},
+		{name: "undeclared attribute", body: order(`status="open" id="1"`, item+rest), path: "/order/@id", keyword: "attribute"},
+		{name: "enumeration", body: order(`status="closed"`, item+rest), path: "/order/@status", keyword: "enumeration"},
+		{name: "invalid date", body: order(`status="open" placed="2023-02-29"`, item+rest), path: "/order/@placed", keyword: "type"},
+		{name: "missing item", body: order(`status="open"`, rest), path: "/order/pickup", keyword: "element"},
+		{name: "both choices", body: order(`status="open"`, item+`<pickup>a</pickup><address>b</address><total currency="EUR">1</total>`), path: "/order/address", keyword: "element"},
+		{name: "incomplete", body: order(`status="open"`, item+`<pickup>a</pickup>`), path: "/order", keyword: "element"},
+		{name: "pattern", body: order(`status="open"`, `<item><sku>abc</sku><qty>1</qty></item>`+rest), path: "/order/item/sku", keyword: "pattern"},
+		{name: "integer type", body: order(`status="open"`, `<item><sku>ABC-1</sku><qty>two</qty></item>`+rest), path: "/order/item/qty", keyword: "type"},
+		{name: "max inclusive", body: order(`status="open"`, item+`<item><sku>ABC-1</sku><qty>100</qty></item>`+rest), path: "/order/item[2]/qty", keyword: "maxInclusive"},
+		{name: "simple content", body: order(`status="open"`, item+`<pickup>a</pickup><total currency="EUR">ten</total>`), path: "/order/total", keyword: "type"},
+		{name: "text in element only content", body: order(`status="open"`, `<item>oops<sku>ABC-1</sku><qty>1</qty></item>`+rest), path: "/order/item", keyword: "mixed"},
+		{name: "malformed", body: `<order>`, keyword: "parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.validate([]byte(tt.body))
+			if tt.keyword == "" {
+				if err != nil {
+					t.Fatalf("validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validate() accepted an invalid document")
+			}
+			if tt.keyword == "parse" {
+				return
+			}
+			var verr *BodyValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("validate() error = %T, want *BodyValidationError", err)
+			}
+			if verr.Format != "XML" || !strings.HasPrefix(err.Error(), "XML validation failed: ") {
+				t.Errorf("Error() = %q", err.Error())
+			}
+			if got := verr.Issues[0]; got.InstancePath != tt.path || got.Keyword != tt.keyword {
+				t.Errorf("first issue = %+v, want %s at %s", got, tt.keyword, tt.path)
+			}
+		})
+	}
+}
+
+func TestXSDSchema_Derivation(t *testing.T) {
+	schema, err := compileXSD([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:attributeGroup name="Audit">
+    <xs:attribute name="by" type="xs:string" use="required"/>
+  </xs:attributeGroup>
+  <xs:group name="Names">
+    <xs:sequence>
+      <xs:element name="first" type="xs:string"/>
+      <xs:element name="last" type="xs:string"/>
+    </xs:sequence>
+  </xs:group>
+  <xs:complexType name="Person">
+    <xs:group ref="Names"/>
+    <xs:attributeGroup ref="Audit"/>
+  </xs:complexType>
+  <xs:complexType name="Employee">
+    <xs:complexContent>
+      <xs:extension base="Person">
+        <xs:all>
+          <xs:element name="id" type="xs:unsignedShort"/>
+          <xs:element name="tags" minOccurs="0">
+            <xs:simpleType>
+              <xs:list itemType="xs:int"/>
+            </xs:simpleType>
+          </xs:element>
+        </xs:all>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="employee" type="Employee"/>
+  <xs:element name="box">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:any namespace="##other" processContents="lax" minOccurs="0" maxOccurs="2"/>
+        <xs:element name="size" nillable="true">
+          <xs:simpleType>
+            <xs:union memberTypes="xs:int">
+              <xs:simpleType>
+                <xs:restriction base="xs:string">
+                  <xs:enumeration value="small"/>
+                </xs:restriction>
+              </xs:simpleType>
+            </xs:union>
+          </xs:simpleType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`))
+	if err != nil {
+		t.Fatalf("compileXSD() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "extension", body: `<employee by="hr"><first>Ada</first><last>L</last><tags>1 2</tags><id>7</id></employee>`, valid: true},
+		{name: "base content first", body: `<employee by="hr"><id>7</id><first>Ada</first><last>L</last></employee>`},
+		{name: "inherited attribute", body: `<employee><first>Ada</first><last>L</last><id>7</id></employee>`},
+		{name: "all missing element", body: `<employee by="hr"><first>Ada</first><last>L</last></employee>`},
+		{name: "unsigned short range", body: `<employee by="hr"><first>Ada</first><last>L</last><id>70000</id></employee>`},
+		{name: "list items", body: `<employee by="hr"><first>Ada</first><last>L</last><id>7</id><tags>1 x</tags></employee>`},
+		{name: "wildcard and union", body: `<box><x:meta xmlns:x="urn:x">anything</x:meta><size>small</size></box>`, valid: true},
+		{name: "union member", body: `<box><size>12</size></box>`, valid: true},
+		{name: "union mismatch", body: `<box><size>large</size></box>`},
+		{name: "nil", body: `<box xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><size xsi:nil="true"/></box>`, valid: true},
+		{name: "wildcard namespace", body: `<box><meta/><size>1</size></box>`},
+		{name: "wildcard occurrence", body: `<box xmlns:x="urn:x"><x:a/><x:b/><x:c/><size>1</size></box>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.validate([]byte(tt.body))
+			if tt.valid && err != nil {
+				t.Errorf("validate() error = %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("validate() accepted an invalid document")
+			}
+		})
+	}
+}
+
+func TestCompileXSD_Unsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "import", schema: `<xs:import namespace="urn:other" schemaLocation="other.xsd"/>`},
+		{name: "include", schema: `<xs:include schemaLocation="other.xsd"/>`},
+		{name: "substitution group", schema: `<xs:element name="a" type="xs:string"/><xs:element name="b" substitutionGroup="a"/>`},
+		{name: "assertion", schema: `<xs:complexType name="T"><xs:assert test="@a"/></xs:complexType>`},
+		{name: "pattern", schema: `<xs:simpleType name="T"><xs:restriction base="xs:string"><xs:pattern value="\p{IsBasicLatin}+"/></xs:restriction></xs:simpleType>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileXSD([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + tt.schema + `</xs:schema>`))
+			if !errors.Is(err, ErrXSDUnavailable) {
+				t.Errorf("compileXSD() error = %v, want ErrXSDUnavailable", err)
+			}
+		})
+	}
+
+	_, err := compileXSD([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a" type="Missing"/></xs:schema>`))
+	if err == nil || errors.Is(err, ErrXSDUnavailable) {
+		t.Errorf("expected an unknown type to be a schema error, got %v", err)
+	}
+}
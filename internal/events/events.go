@@ -0,0 +1,164 @@
+// Package events publishes what the mock server is doing as a stream of
+// structured events, served to external tools as Server-Sent Events.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	TypeRequest = "request" // a request was answered
+	TypeState   = "state"   // the response served by an endpoint was changed
+	TypeError   = "error"   // a request could not be served as declared
+	TypeBudget  = "budget"  // a request took longer than the latency budget of its endpoint
+	TypeReload  = "reload"  // an endpoint was parsed again after its file was edited
+)
+
+// subscriberBuffer is the number of events kept for a subscriber that is not
+// keeping up. Further events are dropped for that subscriber.
+const subscriberBuffer = 64
+
+// Event is one entry of the stream.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Bus fans events out to every subscriber. Publishing never blocks.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	closing     chan struct{} // closed by CloseStreams
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{}), closing: make(chan struct{})}
+}
+
+// CloseStreams ends the streams ServeHTTP is serving, which otherwise last
+// until their clients go away, so a server shutting down does not wait for
+// them. Streams requested later are served as usual.
+func (b *Bus) CloseStreams() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(b.closing)
+	b.closing = make(chan struct{})
+}
+
+// Publish sends an event of the given type to the current subscribers.
+func (b *Bus) Publish(eventType string, data any) {
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events published from now on and
+// a function that ends the subscription.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// ServeHTTP streams the events as Server-Sent Events until the client goes
+// away or CloseStreams is called. Each event is named after its type and carries the event as JSON.
+func (b *Bus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+	b.mu.Lock()
+	closing := b.closing
+	b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closing:
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Request is the data of a request event. Route and File are empty when no
+// endpoint matched the request.
+type Request struct {
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Route      string  `json:"route,omitempty"`
+	File       string  `json:"file,omitempty"`
+	Status     int     `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// State is the data of a state event.
+type State struct {
+	Route  string `json:"route"`
+	Index  int    `json:"index"`
+	Status int    `json:"status"`
+	Title  string `json:"title,omitempty"`
+}
+
+// Budget is the data of a budget event.
+type Budget struct {
+	Route      string  `json:"route"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	DurationMs float64 `json:"durationMs"`
+	BudgetMs   float64 `json:"budgetMs"`
+}
+
+// Reload is the data of a reload event.
+type Reload struct {
+	Route string `json:"route"`
+	File  string `json:"file"`
+}
+
+// Error is the data of an error event.
+type Error struct {
+	Route   string `json:"route,omitempty"`
+	Method  string `json:"method,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
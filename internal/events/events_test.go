@@ -0,0 +1,93 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBus_PublishReachesSubscribers(t *testing.T) {
+	bus := NewBus()
+	first, unsubscribeFirst := bus.Subscribe()
+	second, unsubscribeSecond := bus.Subscribe()
+	defer unsubscribeSecond()
+
+	bus.Publish(TypeRequest, Request{Method: "GET", Path: "/users", Status: 200})
+	unsubscribeFirst()
+	bus.Publish(TypeState, State{Route: "/users", Index: 1})
+
+	if event := <-first; event.Type != TypeRequest {
+		t.Errorf("expected %q event, got %q", TypeRequest, event.Type)
+	}
+	select {
+	case event := <-first:
+		t.Errorf("unsubscribed channel received %q event", event.Type)
+	default:
+	}
+
+	for _, want := range []string{TypeRequest, TypeState} {
+		if event := <-second; event.Type != want {
+			t.Errorf("expected %q event, got %q", want, event.Type)
+		}
+	}
+}
+
+func TestBus_PublishDoesNotBlockOnSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	_, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer*2; i++ {
+			bus.Publish(TypeRequest, nil)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a subscriber that does not read")
+	}
+}
+
+func TestBus_ServeHTTPStreamsEvents(t *testing.T) {
+	bus := NewBus()
+	srv := httptest.NewServer(bus)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("expected Content-Type text/event-stream, got %q", got)
+	}
+
+	bus.Publish(TypeError, Error{Route: "POST /users", Message: "invalid body"})
+
+	reader := bufio.NewReader(resp.Body)
+	eventLine, _ := reader.ReadString('\n')
+	dataLine, _ := reader.ReadString('\n')
+
+	if eventLine != "event: error\n" {
+		t.Errorf("unexpected event line %q", eventLine)
+	}
+	var event struct {
+		Type string
+		Data Error
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &event); err != nil {
+		t.Fatalf("invalid data line %q: %v", dataLine, err)
+	}
+	if event.Type != TypeError || event.Data.Message != "invalid body" {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
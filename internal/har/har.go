@@ -0,0 +1,172 @@
+// Package har converts HTTP Archive (HAR) recordings, as exported by browser
+// developer tools and HTTP proxies, into .apimock files.
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/importer"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// HAR is the part of an HTTP Archive needed to build mocks.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the recorded entries, in the order they were recorded.
+type Log struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is one recorded request and its response.
+type Entry struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type Response struct {
+	Status     int      `json:"status"`
+	StatusText string   `json:"statusText"`
+	Headers    []Header `json:"headers"`
+	Content    Content  `json:"content"`
+}
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Content is a response body. Text is base64 encoded when Encoding is
+// "base64".
+type Content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+// keptHeaders are the response headers carried over to the mocks. Other
+// headers describe the recorded connection rather than the API.
+var keptHeaders = []string{"Location", "Retry-After", "WWW-Authenticate"}
+
+// Read decodes a HAR document.
+func Read(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %w", err)
+	}
+	return &h, nil
+}
+
+// Convert builds one mock per method and path among the entries, in the order
+// they were first recorded; query strings are ignored. Each distinct status
+// code becomes a response, from the first entry answering with it. When host
+// is not empty, only the entries sent to it are converted. Entries without a
+// response, such as aborted requests, are skipped.
+func Convert(h *HAR, host string) []importer.Mock {
+	var mocks []importer.Mock
+	index := make(map[string]int)
+	names := make(importer.Names)
+
+	for _, entry := range h.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || host != "" && u.Host != host && u.Hostname() != host {
+			continue
+		}
+		method := strings.ToUpper(entry.Request.Method)
+		status := entry.Response.Status
+		if !apimock.IsValidHTTPMethod(method) || !apimock.IsValidHTTPStatusCode(status) {
+			continue
+		}
+
+		path := u.EscapedPath()
+		key := method + " " + path
+		i, seen := index[key]
+		if !seen {
+			i = len(mocks)
+			index[key] = i
+			file := apimock.NewAPIMockFile()
+			file.Request = importer.NewRequest(method, path)
+			mocks = append(mocks, importer.Mock{Name: names.ForRequest(file.Request), File: file})
+		}
+		file := mocks[i].File
+		if hasStatus(file, status) {
+			continue
+		}
+		file.Responses = append(file.Responses, response(entry.Response))
+	}
+
+	for _, mock := range mocks {
+		sort.SliceStable(mock.File.Responses, func(a, b int) bool {
+			return mock.File.Responses[a].StatusCode < mock.File.Responses[b].StatusCode
+		})
+	}
+	return mocks
+}
+
+func hasStatus(file *apimock.APIMockFile, status int) bool {
+	for _, resp := range file.Responses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+func response(r Response) apimock.ResponseSection {
+	resp := apimock.NewResponseSection()
+	resp.StatusCode = r.Status
+	resp.Description = strings.TrimSpace(r.StatusText)
+	if resp.Description == "" {
+		resp.Description = http.StatusText(r.Status)
+	}
+	for _, h := range r.Headers {
+		for _, name := range keptHeaders {
+			if strings.EqualFold(h.Name, name) {
+				resp.Properties[name] = h.Value
+			}
+		}
+	}
+
+	body, ok := text(r.Content)
+	if !ok {
+		return resp
+	}
+	if r.Content.MimeType != "" {
+		resp.Properties[endpoint.ResponseContentTypePropertyName] = r.Content.MimeType
+	}
+	resp.Body = body
+	return resp
+}
+
+// text returns the body of c as mock source, or false for binary bodies.
+func text(c Content) (string, bool) {
+	body := c.Text
+	if c.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return "", false
+		}
+		body = string(data)
+	}
+	if !utf8.ValidString(body) || strings.ContainsRune(body, 0) {
+		return "", false
+	}
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.TrimRight(body, " \t\n")
+	return body, true
+}
@@ -0,0 +1,97 @@
+package har
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+const session = `{
+  "log": {
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/api/users?page=1"},
+        "response": {"status": 200, "statusText": "OK", "headers": [{"name": "Date", "value": "Mon"}],
+          "content": {"mimeType": "application/json", "text": "{\"users\": []}\r\n"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/api/users?page=2"},
+        "response": {"status": 200, "statusText": "OK", "content": {"mimeType": "application/json", "text": "{\"users\": [1]}"}}
+      },
+      {
+        "request": {"method": "POST", "url": "https://api.example.com/api/users"},
+        "response": {"status": 201, "statusText": "", "headers": [{"name": "location", "value": "/api/users/1"}],
+          "content": {"mimeType": "application/json", "text": "eyJpZCI6IDF9", "encoding": "base64"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/api/users?page=9"},
+        "response": {"status": 500, "statusText": "Internal Server Error", "content": {"mimeType": "text/plain", "text": "template ${name}"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://cdn.example.com/logo.png"},
+        "response": {"status": 200, "statusText": "OK", "content": {"mimeType": "image/png", "text": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk", "encoding": "base64"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/api/slow"},
+        "response": {"status": 0, "statusText": "", "content": {}}
+      }
+    ]
+  }
+}`
+
+func TestConvert(t *testing.T) {
+	h, err := Read(strings.NewReader(session))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	mocks := Convert(h, "api.example.com")
+	var names []string
+	sources := make(map[string]string)
+	for _, mock := range mocks {
+		names = append(names, mock.Name)
+		data, err := mock.File.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%s) error = %v", mock.Name, err)
+		}
+		sources[mock.Name] = string(data)
+	}
+
+	if want := []string{"get-api-users.apimock", "post-api-users.apimock"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Convert() names = %v, want %v", names, want)
+	}
+
+	wantGet := "GET /api/users\n\n-- 200: OK\nContentType: application/json\n\n{\"users\": []}\n\n" +
+		"-- 500: Internal Server Error\nContentType: text/plain\n\ntemplate $${name}\n"
+	if got := sources["get-api-users.apimock"]; got != wantGet {
+		t.Errorf("GET mock =\n%s\nwant\n%s", got, wantGet)
+	}
+	wantPost := "POST /api/users\n\n-- 201: Created\nContentType: application/json\nLocation: /api/users/1\n\n{\"id\": 1}\n"
+	if got := sources["post-api-users.apimock"]; got != wantPost {
+		t.Errorf("POST mock =\n%s\nwant\n%s", got, wantPost)
+	}
+
+	for name, src := range sources {
+		if _, err := apimock.NewParserFromBytes(name, []byte(src)).Parse(); err != nil {
+			t.Errorf("%s does not parse: %v", name, err)
+		}
+	}
+}
+
+func TestConvert_AllHosts(t *testing.T) {
+	h, err := Read(strings.NewReader(session))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	mocks := Convert(h, "")
+	if len(mocks) != 3 {
+		t.Fatalf("Convert() = %d mocks, want 3", len(mocks))
+	}
+	logo := mocks[2].File.Responses[0]
+	if logo.Body != "" || logo.Properties["ContentType"] != "" {
+		t.Errorf("Expected the binary body to be dropped, got %+v", logo)
+	}
+}
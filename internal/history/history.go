@@ -0,0 +1,169 @@
+// Package history keeps the last requests each endpoint of the mock server
+// answered, with the response it chose and what went wrong on the way, so
+// a test wondering why it got the wrong response can look back.
+package history
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxBody is the number of bytes of request and response bodies kept;
+// longer bodies are cut and flagged as truncated.
+const MaxBody = 64 << 10
+
+// Exchange is a request an endpoint answered and the response sent.
+type Exchange struct {
+	ID              int64       `json:"id"`
+	Time            time.Time   `json:"time"`
+	Method          string      `json:"method"`
+	Path            string      `json:"path"`
+	Query           string      `json:"query,omitempty"`
+	RequestHeaders  http.Header `json:"requestHeaders,omitempty"`
+	RequestBody     string      `json:"requestBody,omitempty"`
+	Route           string      `json:"route,omitempty"` // empty when no endpoint matched
+	File            string      `json:"file,omitempty"`
+	Status          int         `json:"status"`
+	ResponseIndex   int         `json:"responseIndex"` // position of the declared response served, -1 for none
+	Response        string      `json:"response,omitempty"`
+	ResponseHeaders http.Header `json:"responseHeaders,omitempty"`
+	ResponseBody    string      `json:"responseBody,omitempty"`
+	Truncated       bool        `json:"truncated,omitempty"` // a body was longer than MaxBody
+	// Errors lists why the request was not served as declared: failed
+	// validation, an exceeded quota, a missing token, a failing upstream
+	Errors     []string `json:"errors,omitempty"`
+	DurationMs float64  `json:"durationMs"`
+}
+
+// Log keeps the last exchanges of each route in a ring buffer.
+type Log struct {
+	mu     sync.Mutex
+	size   int
+	rings  map[string]*ring
+	lastID int64
+}
+
+// New returns a log keeping the last size exchanges of each route. It
+// panics if size is not positive.
+func New(size int) *Log {
+	if size <= 0 {
+		panic("history: New size must be positive")
+	}
+	return &Log{size: size, rings: make(map[string]*ring)}
+}
+
+// Size returns the number of exchanges kept per route.
+func (l *Log) Size() int {
+	return l.size
+}
+
+// Add records e, numbering it, and drops the oldest exchange of its route
+// when the route already holds Size exchanges.
+func (l *Log) Add(e Exchange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastID++
+	e.ID = l.lastID
+	r, ok := l.rings[e.Route]
+	if !ok {
+		r = &ring{entries: make([]Exchange, 0, l.size)}
+		l.rings[e.Route] = r
+	}
+	r.add(e)
+}
+
+// Clear forgets every exchange.
+func (l *Log) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.rings)
+}
+
+// Query selects exchanges. Zero fields match everything.
+type Query struct {
+	Route  string // declared route, such as "GET /users/{id}"
+	Method string // request method
+	Path   string // prefix of the request path
+	Status int    // status code sent
+	Failed bool   // only exchanges with errors
+	Limit  int    // maximum number of exchanges returned
+}
+
+func (q Query) matches(e Exchange) bool {
+	return (q.Method == "" || strings.EqualFold(q.Method, e.Method)) &&
+		strings.HasPrefix(e.Path, q.Path) &&
+		(q.Status == 0 || q.Status == e.Status) &&
+		(!q.Failed || len(e.Errors) > 0)
+}
+
+// Find returns the exchanges selected by q, newest first.
+func (l *Log) Find(q Query) []Exchange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var found []Exchange
+	for route, r := range l.rings {
+		if q.Route != "" && q.Route != route {
+			continue
+		}
+		for _, e := range r.entries {
+			if q.matches(e) {
+				found = append(found, e)
+			}
+		}
+	}
+	slices.SortFunc(found, func(a, b Exchange) int { return cmp.Compare(b.ID, a.ID) })
+	if q.Limit > 0 && len(found) > q.Limit {
+		found = found[:q.Limit]
+	}
+	return found
+}
+
+// ring is a fixed-size buffer overwriting its oldest entry.
+type ring struct {
+	entries []Exchange
+	next    int // index overwritten next, once entries is full
+}
+
+func (r *ring) add(e Exchange) {
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// ParseQuery reads a query from the parameters route, method, path, status,
+// failed and limit of a URL.
+func ParseQuery(values url.Values) (Query, error) {
+	q := Query{
+		Route:  values.Get("route"),
+		Method: values.Get("method"),
+		Path:   values.Get("path"),
+	}
+	var err error
+	if s := values.Get("status"); s != "" {
+		if q.Status, err = strconv.Atoi(s); err != nil {
+			return q, fmt.Errorf("invalid status %q", s)
+		}
+	}
+	if s := values.Get("failed"); s != "" {
+		if q.Failed, err = strconv.ParseBool(s); err != nil {
+			return q, fmt.Errorf("invalid failed %q", s)
+		}
+	}
+	if s := values.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 0 {
+			return q, fmt.Errorf("invalid limit %q", s)
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func ids(exchanges []Exchange) []int64 {
+	var ids []int64
+	for _, e := range exchanges {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestLog_KeepsLastOfEachRoute(t *testing.T) {
+	log := New(2)
+	for range 3 {
+		log.Add(Exchange{Route: "GET /users", Method: "GET", Path: "/users", Status: 200})
+	}
+	log.Add(Exchange{Route: "GET /orders", Method: "GET", Path: "/orders", Status: 200})
+
+	if got, want := ids(log.Find(Query{})), []int64{4, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("Find() = %v, want %v", got, want)
+	}
+
+	log.Clear()
+	if got := log.Find(Query{}); len(got) != 0 {
+		t.Errorf("Find() after Clear() = %v, want none", ids(got))
+	}
+}
+
+func TestLog_Find(t *testing.T) {
+	log := New(10)
+	log.Add(Exchange{Route: "GET /users/{id}", Method: "GET", Path: "/users/1", Status: 200})
+	log.Add(Exchange{Route: "GET /users/{id}", Method: "GET", Path: "/users/2", Status: 404, Errors: []string{"no such user"}})
+	log.Add(Exchange{Route: "POST /users", Method: "POST", Path: "/users", Status: 422, Errors: []string{"body: name is required"}})
+	log.Add(Exchange{Method: "GET", Path: "/missing", Status: 404})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int64
+	}{
+		{"everything", Query{}, []int64{4, 3, 2, 1}},
+		{"route", Query{Route: "GET /users/{id}"}, []int64{2, 1}},
+		{"method", Query{Method: "post"}, []int64{3}},
+		{"path prefix", Query{Path: "/users"}, []int64{3, 2, 1}},
+		{"status", Query{Status: 404}, []int64{4, 2}},
+		{"failed", Query{Failed: true}, []int64{3, 2}},
+		{"limit", Query{Limit: 2}, []int64{4, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(log.Find(tt.query)); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%+v) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(url.Values{"route": {"GET /users"}, "status": {"200"}, "failed": {"true"}, "limit": {"5"}})
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	if want := (Query{Route: "GET /users", Status: 200, Failed: true, Limit: 5}); q != want {
+		t.Errorf("ParseQuery() = %+v, want %+v", q, want)
+	}
+
+	for _, bad := range []url.Values{{"status": {"ok"}}, {"failed": {"maybe"}}, {"limit": {"-1"}}} {
+		if _, err := ParseQuery(bad); err == nil {
+			t.Errorf("ParseQuery(%v) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package i18n translates the messages shown by the CLI and the TUI.
+//
+// Messages are looked up by their English text, which doubles as the
+// fallback when a language has no translation for them.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	English    = "en"
+	Portuguese = "pt-BR"
+)
+
+var (
+	mu       sync.RWMutex
+	language = English
+)
+
+// catalogs maps a language to the translations of the English messages.
+var catalogs = map[string]map[string]string{
+	Portuguese: portuguese,
+}
+
+// Languages returns the supported language tags.
+func Languages() []string {
+	return []string{English, Portuguese}
+}
+
+// SetLanguage selects the language used by T. Unsupported tags select English.
+func SetLanguage(tag string) {
+	mu.Lock()
+	defer mu.Unlock()
+	language = Normalize(tag)
+}
+
+// Language returns the selected language tag.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return language
+}
+
+// T translates an English message into the selected language and formats it
+// with args as fmt.Sprintf does.
+func T(message string, args ...any) string {
+	mu.RLock()
+	if translated, ok := catalogs[language][message]; ok {
+		message = translated
+	}
+	mu.RUnlock()
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Normalize maps a language tag or POSIX locale (pt_BR.UTF-8, pt-br, pt) to
+// a supported language tag, defaulting to English.
+func Normalize(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+
+	if tag == "pt" || strings.HasPrefix(tag, "pt-") {
+		return Portuguese
+	}
+	return English
+}
+
+// Resolve picks the language from an explicit tag, such as the value of a
+// --lang flag, or else from the LC_ALL, LC_MESSAGES and LANG environment
+// variables.
+func Resolve(tag string) string {
+	if tag != "" {
+		return Normalize(tag)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" && value != "C" && value != "POSIX" {
+			return Normalize(value)
+		}
+	}
+	return English
+}
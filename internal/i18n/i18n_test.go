@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"pt-BR", Portuguese},
+		{"pt_BR.UTF-8", Portuguese},
+		{"pt", Portuguese},
+		{"PT-br", Portuguese},
+		{"en_US.UTF-8", English},
+		{"fr", English},
+		{"", English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := Normalize(tt.tag); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "pt_BR.UTF-8")
+
+	if got := Resolve(""); got != Portuguese {
+		t.Errorf("expected language from LANG, got %q", got)
+	}
+	if got := Resolve("en"); got != English {
+		t.Errorf("expected explicit tag to win over LANG, got %q", got)
+	}
+
+	t.Setenv("LC_ALL", "C")
+	if got := Resolve(""); got != Portuguese {
+		t.Errorf("expected C locale to be skipped, got %q", got)
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage(English)
+
+	SetLanguage(Portuguese)
+	if got := T("Found %d .apimock file(s)", 3); got != "3 arquivo(s) .apimock encontrado(s)" {
+		t.Errorf("unexpected translation: %q", got)
+	}
+	if got := T("Untranslated %s", "message"); got != "Untranslated message" {
+		t.Errorf("expected English fallback, got %q", got)
+	}
+
+	SetLanguage(English)
+	if got := T("Found %d .apimock file(s)", 3); got != "Found 3 .apimock file(s)" {
+		t.Errorf("unexpected English message: %q", got)
+	}
+}
+
+// TestCatalogsCoverMessages checks that every message passed to T in the
+// repository has a translation in each catalog.
+func TestCatalogsCoverMessages(t *testing.T) {
+	root := filepath.Join("..", "..")
+	messages := make(map[string]string)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "T" {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "i18n" {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if message, err := strconv.Unquote(lit.Value); err == nil {
+					messages[message] = path
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan sources: %v", err)
+	}
+
+	if len(messages) == 0 {
+		t.Fatal("expected to find translated messages")
+	}
+	for language, catalog := range catalogs {
+		for message, path := range messages {
+			if _, ok := catalog[message]; !ok {
+				t.Errorf("%s: missing %s translation for %q", path, language, message)
+			}
+		}
+	}
+}
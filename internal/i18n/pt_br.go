@@ -0,0 +1,246 @@
+package i18n
+
+var portuguese = map[string]string{
+	// CLI
+	"Usage:":    "Uso:",
+	"Examples:": "Exemplos:",
+	"Options:":  "Opções:",
+	"Error: at least one file or directory path is required.":     "Erro: informe ao menos um caminho de arquivo ou diretório.",
+	"Error: no valid endpoints found":                             "Erro: nenhum endpoint válido encontrado",
+	"Error finding .apimock files: %v":                            "Erro ao procurar arquivos .apimock: %v",
+	"Error loading comparison baseline: %v":                       "Erro ao carregar a base de comparação: %v",
+	"Error loading endpoints: %v":                                 "Erro ao carregar endpoints: %v",
+	"Error writing report: %v":                                    "Erro ao gravar o relatório: %v",
+	"HTTP server error: %v":                                       "Erro no servidor HTTP: %v",
+	"UI error: %v":                                                "Erro na interface: %v",
+	"Found %d .apimock file(s)":                                   "%d arquivo(s) .apimock encontrado(s)",
+	"Server ready! Serving %d endpoint(s):":                       "Servidor pronto! Servindo %d endpoint(s):",
+	"(no responses)":                                              "(sem respostas)",
+	"Comparing responses against %d baseline endpoint(s) from %s": "Comparando respostas com %d endpoint(s) de base em %s",
+	"Report written to %s":                                        "Relatório gravado em %s",
+	"Warning: some files failed to parse:":                        "Aviso: alguns arquivos não puderam ser interpretados:",
+
+	// Flags
+	"Port number for the HTTP server":                                                                                                                           "Porta do servidor HTTP",
+	"Port number for the HTTP server (shorthand)":                                                                                                               "Porta do servidor HTTP (forma curta)",
+	"Interactive mode - display response selection UI":                                                                                                          "Modo interativo - exibe a interface de seleção de respostas",
+	"Baseline file or directory evaluated in the background to report behavioral diffs":                                                                         "Arquivo ou diretório de base avaliado em segundo plano para apontar diferenças de comportamento",
+	"Write a request summary to this file on exit (.md for Markdown, JSON otherwise)":                                                                           "Grava um resumo das requisições neste arquivo ao encerrar (.md para Markdown, JSON nos demais casos)",
+	"Compress responses with gzip, deflate or brotli when the client accepts it":                                                                                "Comprime as respostas com gzip, deflate ou brotli quando o cliente aceita",
+	"Answer proxied requests with the mocked response when the upstream cannot be reached, answers 5xx or times out":                                            "Responde às requisições encaminhadas com a resposta simulada quando o upstream está inacessível, responde 5xx ou excede o tempo limite",
+	"Time given to an upstream to reply before --fallback-to-mock answers with the mock (0 = no limit)":                                                         "Tempo dado a um upstream para responder antes que --fallback-to-mock responda com o mock (0 = sem limite)",
+	"Answer 500 when the conditions or placeholders of a response fail to evaluate, instead of skipping the conditions and sending the placeholders as written": "Responde 500 quando as condições ou placeholders de uma resposta falham ao ser avaliados, em vez de ignorar as condições e enviar os placeholders como escritos",
+	"Fill time placeholders and session IDs with fixed values, and seed the random functions, so responses are identical from run to run":                       "Preenche placeholders de tempo e IDs de sessão com valores fixos, e fixa a semente das funções aleatórias, para que as respostas sejam idênticas entre execuções",
+	"Serve a mock OAuth2/OpenID Connect provider at /token, /authorize and /.well-known":                                                                        "Serve um provedor OAuth2/OpenID Connect simulado em /token, /authorize e /.well-known",
+	"Fraction of responses to break with connection resets, truncated or garbled bodies, extreme latency or 5xx statuses":                                       "Fração das respostas a quebrar com conexões reiniciadas, corpos truncados ou corrompidos, latência extrema ou status 5xx",
+	"Seed for --chaos faults, to reproduce a run (default: random)":                                                                                             "Semente das falhas do --chaos, para reproduzir uma execução (padrão: aleatória)",
+	"Chaos mode: breaking %g%% of responses (seed %d)":                                                                                                          "Modo caos: quebrando %g%% das respostas (semente %d)",
+	"Warning: %s: property %q of response %d is sent as a header; did you mean %q?":                                                                             "Aviso: %s: a propriedade %q da resposta %d é enviada como cabeçalho; você quis dizer %q?",
+	"Warning: Interactive mode is not supported for proxy endpoints. Defaulting to non-interactive mode.":                                                       "Aviso: o modo interativo não é suportado para endpoints de proxy. Usando o modo não interativo.",
+	"Warning: %s: callback failed: %v":                                                                                                                          "Aviso: %s: o callback falhou: %v",
+	"Error starting the OAuth2 mock: %v":                                                                                                                        "Erro ao iniciar o OAuth2 simulado: %v",
+	"Language of the messages (%s); defaults to LANG":                                                                                                           "Idioma das mensagens (%s); por padrão usa LANG",
+
+	// owners
+	"CODEOWNERS-like file mapping path patterns to owners":                              "Arquivo no estilo CODEOWNERS que associa padrões de caminho a responsáveis",
+	"Exit with an error when an endpoint has no owner":                                  "Encerra com erro quando um endpoint não tem responsável",
+	"Owners come from the X-Meta-Owner request property or from the --codeowners file.": "Os responsáveis vêm da propriedade X-Meta-Owner da requisição ou do arquivo --codeowners.",
+	"Error reading owners file: %v":                                                     "Erro ao ler o arquivo de responsáveis: %v",
+	"ROUTE\tOWNERS\tFILE":                                                               "ROTA\tRESPONSÁVEIS\tARQUIVO",
+	"(unowned)":                                                                         "(sem responsável)",
+	"%d endpoint(s), %d unowned":                                                        "%d endpoint(s), %d sem responsável",
+
+	// gen corpus
+	"Number of files to generate":                                                 "Quantidade de arquivos a gerar",
+	"Seed of the random generator; the same seed yields the same corpus":          "Semente do gerador aleatório; a mesma semente gera o mesmo corpus",
+	"Directory the files are written to":                                          "Diretório onde os arquivos são gravados",
+	"Writes random, valid .apimock files for testing tools that read the format.": "Gera arquivos .apimock válidos e aleatórios para testar ferramentas que leem o formato.",
+	"Error creating output directory: %v":                                         "Erro ao criar o diretório de saída: %v",
+	"Error generating file %d: %v":                                                "Erro ao gerar o arquivo %d: %v",
+	"Error writing %s: %v":                                                        "Erro ao gravar %s: %v",
+	"Generated %d .apimock file(s) in %s":                                         "%d arquivo(s) .apimock gerado(s) em %s",
+
+	// Server
+	"Starting server on %s...":                     "Iniciando o servidor em %s...",
+	"Warning: %s took %s, exceeding its %s budget": "Aviso: %s levou %s, acima do orçamento de %s",
+	"%s took %s, exceeding its %s budget":          "%s levou %s, acima do orçamento de %s",
+
+	// TUI
+	"Select a response for the server:": "Selecione uma resposta para o servidor:",
+	"move up":                           "subir",
+	"move down":                         "descer",
+	"quit":                              "sair",
+	"Select a response (1-%d), l to list, q to quit: ": "Selecione uma resposta (1-%d), l para listar, q para sair: ",
+	"Invalid choice %q.":      "Opção inválida %q.",
+	"Now serving %d: [%d] %s": "Servindo agora %d: [%d] %s",
+	"(selected)":              "(selecionada)",
+	"Print numbered choices and read the selection from stdin instead of drawing the interactive UI": "Imprime opções numeradas e lê a escolha da entrada padrão em vez de desenhar a interface interativa",
+	"Maximum duration for reading a request, including its body (0 = no limit)":                      "Tempo máximo para ler uma requisição, incluindo o corpo (0 = sem limite)",
+	"Maximum duration for writing a response (0 = no limit)":                                         "Tempo máximo para escrever uma resposta (0 = sem limite)",
+	"Maximum time an idle keep-alive connection is kept open (0 = no limit)":                         "Tempo máximo que uma conexão keep-alive ociosa fica aberta (0 = sem limite)",
+	"Time given to in-flight requests to finish when the server stops":                               "Tempo dado às requisições em andamento para terminarem quando o servidor para",
+	"Server stopped":        "Servidor parado",
+	"Compare: %s %s: %s":    "Comparação: %s %s: %s",
+	"status %d -> %d":       "status %d -> %d",
+	"content type %q -> %q": "tipo de conteúdo %q -> %q",
+	"body differs":          "corpo difere",
+	"Serve a one-line mock such as 'GET /ping -> 200 {\"ok\":true}'; can be repeated": "Serve um mock de uma linha como 'GET /ping -> 200 {\"ok\":true}'; pode ser repetido",
+	"Error parsing --inline: %v": "Erro ao interpretar --inline: %v",
+	"Address to bind, such as 127.0.0.1 or 0.0.0.0 (default: all interfaces)":                                                "Endereço de escuta, como 127.0.0.1 ou 0.0.0.0 (padrão: todas as interfaces)",
+	"Listen on this unix domain socket instead of a TCP port":                                                                "Escuta neste socket unix em vez de uma porta TCP",
+	"Warning: Interactive mode is not supported when reading mocks from standard input. Defaulting to non-interactive mode.": "Aviso: o modo interativo não é suportado ao ler mocks da entrada padrão. Usando o modo não interativo.",
+	"Error parsing standard input: %v":                                                                                       "Erro ao interpretar a entrada padrão: %v",
+	"Exit with an error when a response is a draft (its description starts with TODO)":                                       "Encerra com erro quando uma resposta é um rascunho (sua descrição começa com TODO)",
+	"Error: %d draft response(s) found and --fail-on-draft is set":                                                           "Erro: %d resposta(s) em rascunho encontrada(s) e --fail-on-draft está ativo",
+	"Draft responses:": "Respostas em rascunho:",
+	"Write the changelog as JSON instead of Markdown":                                                                "Escreve o changelog em JSON em vez de Markdown",
+	"Lists the endpoints added, removed and changed between two versions of a mock suite.":                           "Lista os endpoints adicionados, removidos e alterados entre duas versões de um conjunto de mocks.",
+	"Error writing changelog: %v":                                                                                    "Erro ao escrever o changelog: %v",
+	"Serve the mocks of a file or directory on a port of their own, as PORT=PATH or HOST:PORT=PATH; can be repeated": "Serve os mocks de um arquivo ou diretório em uma porta própria, como PORTA=CAMINHO ou HOST:PORTA=CAMINHO; pode ser repetido",
+	"Error in --listen %s: %v":                                                                                       "Erro em --listen %s: %v",
+	"Serving %d endpoint(s) on %s:":                                                                                  "Servindo %d endpoint(s) em %s:",
+	"Exit with an error when an .apimock file fails to load, instead of serving the others":                          "Encerra com erro quando um arquivo .apimock falha ao carregar, em vez de servir os demais",
+	"Error: %d file(s) failed to load and --fail-on-broken is set":                                                   "Erro: %d arquivo(s) falharam ao carregar e --fail-on-broken está ativo",
+	"Serve mocks over HTTP (the default command)":                                                                    "Serve mocks via HTTP (o comando padrão)",
+	"Check that .apimock files load, listing the errors of those that do not":                                        "Verifica se os arquivos .apimock carregam, listando os erros dos que não carregam",
+	"Print the syntax tree of an .apimock file as JSON":                                                              "Imprime a árvore sintática de um arquivo .apimock em JSON",
+	"Rewrite .apimock files in the canonical format":                                                                 "Reescreve arquivos .apimock no formato canônico",
+	"Report which team owns each mocked route":                                                                       "Informa qual equipe é dona de cada rota simulada",
+	"List the contract changes between two versions of a mock suite":                                                 "Lista as mudanças de contrato entre duas versões de um conjunto de mocks",
+	"Generate random .apimock files for testing tools":                                                               "Gera arquivos .apimock aleatórios para testar ferramentas",
+	"Commands:": "Comandos:",
+	"Run 'anansi-proxy <command> -h' for the options of a command.":       "Execute 'anansi-proxy <comando> -h' para ver as opções de um comando.",
+	"%d file(s) valid, %d with errors":                                    "%d arquivo(s) válido(s), %d com erros",
+	"Error parsing %s: %v":                                                "Erro ao interpretar %s: %v",
+	"Write the formatted source back to the files instead of printing it": "Grava o código formatado nos arquivos em vez de imprimi-lo",
+	"List the files whose formatting differs":                             "Lista os arquivos cuja formatação difere",
+	"Error formatting %s: %v":                                             "Erro ao formatar %s: %v",
+	"Directory the example mocks are written to":                          "Diretório onde os mocks de exemplo são gravados",
+	"Port the Makefile target serves the mocks on":                        "Porta em que o alvo do Makefile serve os mocks",
+	"Overwrite existing example mocks":                                    "Sobrescreve mocks de exemplo existentes",
+	"Use the defaults instead of asking":                                  "Usa os valores padrão em vez de perguntar",
+	"Create example mocks and a Makefile target to serve them":            "Cria mocks de exemplo e um alvo do Makefile para servi-los",
+	"Directory for the mocks":                                             "Diretório dos mocks",
+	"Port":                                                                "Porta",
+	"Invalid port %q.":                                                    "Porta inválida %q.",
+	"Created %s":                                                          "Criado %s",
+	"Error creating the project: %v":                                      "Erro ao criar o projeto: %v",
+	"Start the mocks with:":                                               "Inicie os mocks com:",
+	"Run with --force to overwrite the example mocks.":                    "Execute com --force para sobrescrever os mocks de exemplo.",
+	"List the files whose formatting differs and exit with an error if there are any, without changing them": "Lista os arquivos cuja formatação difere e encerra com erro se houver algum, sem alterá-los",
+	"%d file(s) need formatting; run anansi-proxy fmt -w":                                                    "%d arquivo(s) precisam de formatação; execute anansi-proxy fmt -w",
+	"Output format: text, json or sarif":                                                                     "Formato de saída: text, json ou sarif",
+	"Error: unknown format %q (expected text, json or sarif)":                                                "Erro: formato desconhecido %q (esperado text, json ou sarif)",
+	"Error writing diagnostics: %v":                                                                          "Erro ao escrever diagnósticos: %v",
+	"files with %s directives cannot be formatted":                                                           "arquivos com diretivas %s não podem ser formatados",
+	"files with ${NAME} environment variables cannot be formatted":                                           "arquivos com variáveis de ambiente ${NAME} não podem ser formatados",
+	"Response profile to serve, such as outage; switch it at runtime with PUT /_admin/profile":               "Perfil de respostas a servir, como outage; troque-o em execução com PUT /_admin/profile",
+	"Unknown profile %q; declared profiles: %s":                                                              "Perfil desconhecido %q; perfis declarados: %s",
+	"Serving profile %s": "Servindo o perfil %s",
+	"Convert HAR recordings and WireMock stubs into .apimock files":                                         "Converte gravações HAR e stubs do WireMock em arquivos .apimock",
+	"Only import the requests sent to this host":                                                            "Importa apenas as requisições enviadas a este host",
+	"Overwrite existing files":                                                                              "Sobrescreve arquivos existentes",
+	"Writes one .apimock file per method and path recorded in a HAR file, with a response per status code.": "Escreve um arquivo .apimock por método e caminho gravados em um arquivo HAR, com uma resposta por código de status.",
+	"Error reading %s: %v":                                                                                  "Erro ao ler %s: %v",
+	"No requests to import in %s":                                                                           "Nenhuma requisição para importar em %s",
+	"Imported %d endpoint(s) from %d request(s) into %s":                                                    "%d endpoint(s) importado(s) de %d requisição(ões) em %s",
+	"Writes one .apimock file per request pattern of WireMock stub mappings, with a response per mapping.":  "Escreve um arquivo .apimock por padrão de requisição dos mapeamentos do WireMock, com uma resposta por mapeamento.",
+	"Error writing files: %v":                                                                               "Erro ao escrever os arquivos: %v",
+	"Warning: %s":                                                                                           "Aviso: %s",
+	"Writes a WireMock mappings document with a stub per response of the .apimock files.":                   "Escreve um documento de mapeamentos do WireMock com um stub por resposta dos arquivos .apimock.",
+	"%v; use --force to overwrite it":                                                                       "%v; use --force para sobrescrevê-lo",
+	"Imported %d endpoint(s) from %d mapping(s) into %s":                                                    "%d endpoint(s) importado(s) de %d mapeamento(s) em %s",
+	"Convert .apimock files into WireMock stubs or Pact contracts":                                          "Converte arquivos .apimock em stubs do WireMock ou contratos Pact",
+	"File the output is written to (default: standard output)":                                              "Arquivo em que a saída é escrita (padrão: saída padrão)",
+	"Name of the consumer the contract is for":                                                              "Nome do consumidor a que o contrato se destina",
+	"Name of the provider the contract is verified against":                                                 "Nome do provedor contra o qual o contrato é verificado",
+	"Writes a Pact consumer contract with an interaction per response of the .apimock files.":               "Escreve um contrato de consumidor Pact com uma interação por resposta dos arquivos .apimock.",
+	"Replay the mocked requests against a live API and report drift":                                        "Repete as requisições dos mocks contra uma API real e relata as divergências",
+	"URL of the API the requests are replayed against, such as https://staging.example.com":                 "URL da API contra a qual as requisições são repetidas, como https://staging.example.com",
+	"Also replay requests with methods other than GET, HEAD and OPTIONS, which may change the API":          "Repete também requisições com métodos além de GET, HEAD e OPTIONS, que podem alterar a API",
+	"Maximum duration of each request (default: 10s)":                                                       "Duração máxima de cada requisição (padrão: 10s)",
+	"Output format: text or json":                                                                           "Formato de saída: text ou json",
+	"Header sent with every request, as 'Name: value'; can be repeated":                                     "Cabeçalho enviado em toda requisição, como 'Nome: valor'; pode ser repetido",
+	"Replays the request of each .apimock file against a live API and reports where the status, headers and body shape drifted from the mock.": "Repete a requisição de cada arquivo .apimock contra uma API real e relata onde o status, os cabeçalhos e a forma do corpo divergem do mock.",
+	"Error: unknown format %q (expected text or json)":  "Erro: formato desconhecido %q (esperado text ou json)",
+	"Error: invalid header %q (expected 'Name: value')": "Erro: cabeçalho inválido %q (esperado 'Nome: valor')",
+	"SKIP  %s (%s): %s": "PULADO   %s (%s): %s",
+	"FAIL  %s (%s): %s": "FALHA    %s (%s): %s",
+	"%d mock(s) match the API, %d drifted, %d skipped": "%d mock(s) conferem com a API, %d divergem, %d pulado(s)",
+	"DRIFT %s -> %d (%s)":                              "DIVERGE  %s -> %d (%s)",
+	"OK    %s -> %d":                                   "OK       %s -> %d",
+	"Infer the JSON Schema of request bodies from the examples of a mock":                                                                                                                  "Infere o JSON Schema dos corpos de requisição a partir dos exemplos de um mock",
+	"Write the schema into the request section of the files instead of printing it":                                                                                                        "Escreve o schema na seção de requisição dos arquivos em vez de imprimi-lo",
+	"Derives the JSON Schema of the request body from the examples of a mock: the request body, when it is an example rather than a schema, or else the JSON bodies of the 2xx responses.": "Deriva o JSON Schema do corpo da requisição a partir dos exemplos de um mock: o corpo da requisição, quando é um exemplo e não um schema, ou então os corpos JSON das respostas 2xx.",
+	"With -w the schema replaces the example in the request section, so requests are validated, and the file is rewritten in the format of anansi-proxy fmt.":                              "Com -w o schema substitui o exemplo na seção de requisição, para que as requisições sejam validadas, e o arquivo é reescrito no formato do anansi-proxy fmt.",
+	"Error: printing a schema takes exactly one file; use -w to update several.":                                                                                                           "Erro: imprimir um schema requer exatamente um arquivo; use -w para atualizar vários.",
+	"Error inferring the schema of %s: %v":         "Erro ao inferir o schema de %s: %v",
+	"Skipped %s: %v":                               "%s ignorado: %v",
+	"Updated %s":                                   "%s atualizado",
+	"files with %s directives cannot be rewritten": "arquivos com diretivas %s não podem ser reescritos",
+	"files with ${NAME} environment variables cannot be rewritten":                                                                                         "arquivos com variáveis de ambiente ${NAME} não podem ser reescritos",
+	"the file has no request section to write the schema into":                                                                                             "o arquivo não tem seção de requisição para receber o schema",
+	"Check response bodies against the schema of their Schema property: log prints mismatches, error answers 500 instead":                                  "Confere os corpos das respostas com o schema da propriedade Schema: log imprime as divergências, error responde 500 em seu lugar",
+	"Error: unknown --probe-upstreams mode %q (expected warn, fail or mock)":                                                                               "Erro: modo de --probe-upstreams desconhecido %q (esperado warn, fail ou mock)",
+	"Check at startup that the upstreams of proxy sections answer: warn prints the unreachable ones, fail exits, mock answers their routes with the mocks": "Verifica na inicialização se os upstreams das seções de proxy respondem: warn mostra os inacessíveis, fail encerra, mock responde suas rotas com os mocks",
+	"Warning: upstream %s is unreachable: %v":                                                                                                              "Aviso: upstream %s está inacessível: %v",
+	"Error: %d upstream(s) unreachable and --probe-upstreams is fail":                                                                                      "Erro: %d upstream(s) inacessível(is) e --probe-upstreams é fail",
+	"Answering the requests to unreachable upstreams with the mocks":                                                                                       "Respondendo às requisições para upstreams inacessíveis com os mocks",
+	"Error: unknown --validate-responses mode %q (expected log or error)":                                                                                  "Erro: modo de --validate-responses desconhecido %q (esperado log ou error)",
+	"Warning: %s: %v": "Aviso: %s: %v",
+	"Warning: %s: %v; requests are not validated. Use --strict-xsd to fail instead.":                                                             "Aviso: %s: %v; as requisições não serão validadas. Use --strict-xsd para falhar em vez disso.",
+	"Warning: %s: %v; responses with status %d are not validated.":                                                                               "Aviso: %s: %v; as respostas com status %d não são validadas.",
+	"Fail to load endpoints with XML schemas this build cannot validate":                                                                         "Falha ao carregar endpoints com XML Schemas que esta compilação não consegue validar",
+	"Export request spans to this OpenTelemetry collector over OTLP/HTTP, such as http://localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)": "Exporta os spans das requisições para este coletor OpenTelemetry via OTLP/HTTP, como http://localhost:4318 (padrão: $OTEL_EXPORTER_OTLP_ENDPOINT)",
+	"Exporting traces to %s":               "Exportando traces para %s",
+	"Warning: exporting traces failed: %v": "Aviso: falha ao exportar traces: %v",
+	"Number of requests kept per route for GET /_admin/history and the interactive UI (0 = none)": "Número de requisições mantidas por rota para GET /_admin/history e a interface interativa (0 = nenhuma)",
+	"Last requests:": "Últimas requisições:",
+	"none yet":       "nenhuma ainda",
+	"Send the requests of a recorded history again and report the responses that differ":                                                                                                             "Envia de novo as requisições de um histórico gravado e aponta as respostas que diferem",
+	"URL of the server the requests are replayed against, such as http://localhost:8977":                                                                                                             "URL do servidor contra o qual as requisições são reenviadas, como http://localhost:8977",
+	"Compare JSON bodies by their fields and types only, ignoring values":                                                                                                                            "Compara corpos JSON apenas pelos campos e tipos, ignorando os valores",
+	"Sends the requests of a history saved from GET /_admin/history again, in the order they were recorded, and reports where the status, content type and body differ from the recorded responses.": "Envia de novo as requisições de um histórico salvo de GET /_admin/history, na ordem em que foram gravadas, e aponta onde o status, o tipo de conteúdo e o corpo diferem das respostas gravadas.",
+	"FAIL  %s: %s":   "FALHA    %s: %s",
+	"DIFF  %s -> %d": "DIFERE   %s -> %d",
+	"%d request(s) replayed as recorded, %d differ":                                                                  "%d requisição(ões) reproduzida(s) como gravada(s), %d diferem",
+	"Warning: Interactive mode is not supported with --listen. Defaulting to non-interactive mode.":                  "Aviso: o modo interativo não é suportado com --listen. Usando o modo não interativo.",
+	"Warning: --no-altscreen is not supported when multiple files are provided. Defaulting to non-interactive mode.": "Aviso: --no-altscreen não é suportado com vários arquivos. Usando o modo não interativo.",
+	"previous endpoint":                     "endpoint anterior",
+	"next endpoint":                         "próximo endpoint",
+	"Endpoints:":                            "Endpoints:",
+	"edit":                                  "editar",
+	"%s is not a file and cannot be edited": "%s não é um arquivo e não pode ser editado",
+	"Editor failed: %v":                     "O editor falhou: %v",
+	"Changes to %s not applied: %v":         "Alterações em %s não aplicadas: %v",
+	"Applied changes to %s":                 "Alterações em %s aplicadas",
+	"toggle latency":                        "alternar latência",
+	"toggle chaos":                          "alternar caos",
+	"toggle forced response":                "alternar resposta forçada",
+	"Latency: %s  Chaos: %s  Forced response: %s": "Latência: %s  Caos: %s  Resposta forçada: %s",
+	"on":                         "ligado",
+	"off":                        "desligado",
+	"reset calls":                "zerar chamadas",
+	"reset all calls":            "zerar todas as chamadas",
+	"Calls to %s: %d":            "Chamadas a %s: %d",
+	"%d call(s)":                 "%d chamada(s)",
+	"filter":                     "filtrar",
+	"Filter: %s (esc to clear)":  "Filtro: %s (esc para limpar)",
+	"copy as curl":               "copiar como curl",
+	"No curl command for %s: %v": "Sem comando curl para %s: %v",
+	"Copied to the clipboard, if the terminal allows it:":                                                            "Copiado para a área de transferência, se o terminal permitir:",
+	"Standard output is not a terminal; reading commands from standard input instead of drawing the interactive UI.": "A saída padrão não é um terminal; lendo comandos da entrada padrão em vez de desenhar a interface interativa.",
+	"Standard input closed; serving until interrupted.":                                                              "Entrada padrão fechada; servindo até ser interrompido.",
+	"Enter help for the commands.":                                                                                   "Digite help para ver os comandos.",
+	"Unknown command %q. Enter help for the commands.":                                                               "Comando desconhecido %q. Digite help para ver os comandos.",
+	"Current endpoint: %s":                                                                                           "Endpoint atual: %s",
+	"list the endpoints and the responses of the current one":                                                        "lista os endpoints e as respostas do atual",
+	"make the N-th endpoint, or the one of ROUTE, current":                                                           "torna atual o N-ésimo endpoint, ou o de ROTA",
+	"serve the N-th response of the current endpoint (or just N)":                                                    "serve a N-ésima resposta do endpoint atual (ou apenas N)",
+	"reset the call count of the current endpoint, or of all":                                                        "zera a contagem de chamadas do endpoint atual, ou de todos",
+	"toggle or set a switch, as d and c do in the UI":                                                                "alterna ou define uma chave, como d e c fazem na interface",
+	"toggle or set whether the current endpoint forces its response":                                                 "alterna ou define se o endpoint atual força sua resposta",
+	"stop the server": "para o servidor",
+	"Request header naming the response to serve by status code or title, such as 404 (empty = off)":                                     "Cabeçalho da requisição que nomeia a resposta a servir pelo código de status ou título, como 404 (vazio = desligado)",
+	"Seed for the random functions of placeholders, such as .random_int, .uuid and .random_sticky, to reproduce a run (default: random)": "Semente das funções aleatórias dos placeholders, como .random_int, .uuid e .random_sticky, para reproduzir uma execução (padrão: aleatória)",
+}
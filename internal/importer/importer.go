@@ -0,0 +1,171 @@
+// Package importer holds what the converters from other mock and recording
+// formats share: naming the files they produce, building request sections
+// from foreign paths and writing the result.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Mock is an .apimock file converted from another format.
+type Mock struct {
+	// Name is a file name derived from the method and path, such as
+	// get-api-users.apimock, unique among the mocks of a conversion
+	Name string
+	File *apimock.APIMockFile
+}
+
+// ErrExists is returned by Write when a file would be overwritten.
+var ErrExists = errors.New("file already exists")
+
+var (
+	// literalSegment matches the static path segments of the .apimock grammar
+	literalSegment = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+	// parameterSegment matches {name} and {name:pattern} segments
+	parameterSegment = regexp.MustCompile(`^\{([a-zA-Z0-9_.\-]+)(?::(.+))?\}$`)
+	// segmentPattern matches the patterns a {name:pattern} segment may hold
+	segmentPattern = regexp.MustCompile(`^(?:[^{}/\s]|\{[0-9,]+\})+$`)
+)
+
+// Names hands out unique file names for the mocks of a conversion.
+type Names map[string]bool
+
+// For derives a file name from method and path, numbering names already
+// handed out. An empty method is written as any.
+func (n Names) For(method, path string) string {
+	if method == "" {
+		method = "any"
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	dash := true
+	for _, r := range strings.ToLower(path) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	base := b.String()
+	if base == strings.ToLower(method) {
+		base += "-root"
+	}
+
+	name := base + ".apimock"
+	for i := 2; n[name]; i++ {
+		name = fmt.Sprintf("%s-%d.apimock", base, i)
+	}
+	n[name] = true
+	return name
+}
+
+// ForRequest derives a file name from a request section, leaving out the
+// patterns of its parameters. A nil request names a catch-all mock.
+func (n Names) ForRequest(req *apimock.RequestSection) string {
+	if req == nil {
+		return n.For("", "/")
+	}
+	var path strings.Builder
+	for _, seg := range req.PathSegments {
+		path.WriteString("/")
+		if seg.IsParameter {
+			path.WriteString(seg.Name)
+		} else {
+			path.WriteString(seg.Value)
+		}
+	}
+	return n.For(req.Method, path.String())
+}
+
+// NewRequest returns the request section for method and path. Segments of
+// path may be literals, {name} or {name:pattern} parameters and * wildcards;
+// literals the .apimock grammar cannot hold, such as percent-encoded text,
+// become parameters. The root path has no request section: .apimock files
+// without one answer every request.
+func NewRequest(method, path string) *apimock.RequestSection {
+	if strings.Trim(path, "/") == "" {
+		return nil
+	}
+	req := apimock.NewRequestSection()
+	req.Method = method
+	for i, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		req.PathSegments = append(req.PathSegments, Segment(seg, fmt.Sprintf("p%d", i+1)))
+	}
+	for _, seg := range req.PathSegments {
+		req.Path += "/" + seg.Value
+	}
+	return req
+}
+
+// Segment returns the path segment for seg, named name when it has to become
+// a parameter.
+func Segment(seg, name string) apimock.PathSegment {
+	switch {
+	case seg == "*":
+		return apimock.PathSegment{Value: seg, IsWildcard: true}
+	case literalSegment.MatchString(seg):
+		return apimock.PathSegment{Value: seg}
+	}
+	if m := parameterSegment.FindStringSubmatch(seg); m != nil && (m[2] == "" || segmentPattern.MatchString(m[2])) {
+		return apimock.PathSegment{Value: seg, IsParameter: true, Name: m[1], Pattern: m[2]}
+	}
+	return apimock.PathSegment{Value: "{" + name + "}", IsParameter: true, Name: name}
+}
+
+// PatternSegment returns the path segment matching the regular expression
+// pattern, named name, or a plain parameter when the .apimock grammar cannot
+// hold the pattern.
+func PatternSegment(pattern, name string) apimock.PathSegment {
+	if pattern == ".*" || pattern == ".+" {
+		return apimock.PathSegment{Value: "*", IsWildcard: true}
+	}
+	if regexp.QuoteMeta(pattern) == pattern {
+		return Segment(pattern, name)
+	}
+	if segmentPattern.MatchString(pattern) {
+		value := "{" + name + ":" + pattern + "}"
+		return apimock.PathSegment{Value: value, IsParameter: true, Name: name, Pattern: pattern}
+	}
+	return apimock.PathSegment{Value: "{" + name + "}", IsParameter: true, Name: name}
+}
+
+// Write writes mocks to dir, creating it if needed. Unless force is set,
+// nothing is written when a file would be overwritten.
+func Write(mocks []Mock, dir string, force bool) error {
+	if !force {
+		for _, mock := range mocks {
+			path := filepath.Join(dir, mock.Name)
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s: %w", path, ErrExists)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, mock := range mocks {
+		data, err := mock.File.Marshal()
+		if err != nil {
+			return fmt.Errorf("%s: %w", mock.Name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, mock.Name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func TestNames_For(t *testing.T) {
+	names := make(Names)
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/", "get-root.apimock"},
+		{"GET", "/api/users/42", "get-api-users-42.apimock"},
+		{"GET", "/api/users-42", "get-api-users-42-2.apimock"},
+		{"DELETE", "/Files/%20x", "delete-files-20x.apimock"},
+		{"", "/users/{id}", "any-users-id.apimock"},
+	}
+	for _, tt := range tests {
+		if got := names.For(tt.method, tt.path); got != tt.want {
+			t.Errorf("For(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNames_ForRequest(t *testing.T) {
+	names := make(Names)
+	if got := names.ForRequest(NewRequest("POST", "/users/{id:[0-9]+}/*")); got != "post-users-id.apimock" {
+		t.Errorf("ForRequest() = %s, want post-users-id.apimock", got)
+	}
+	if got := names.ForRequest(nil); got != "any-root.apimock" {
+		t.Errorf("ForRequest(nil) = %s, want any-root.apimock", got)
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/users/", want: "/api/users"},
+		{path: "/users/{id}/files/*", want: "/users/{id}/files/*"},
+		{path: "/users/{id:[0-9]{3}}", want: "/users/{id:[0-9]{3}}"},
+		{path: "/files/a%20b/raw", want: "/files/{p2}/raw"},
+		{path: "/users/{id:a b}", want: "/users/{p2}"},
+	}
+	if req := NewRequest("GET", "/"); req != nil {
+		t.Errorf("NewRequest(/) = %+v, want no request section", req)
+	}
+	for _, tt := range tests {
+		req := NewRequest("GET", tt.path)
+		if req.Path != tt.want {
+			t.Errorf("NewRequest(%q).Path = %q, want %q", tt.path, req.Path, tt.want)
+			continue
+		}
+		src := "GET " + req.Path + "\n\n-- 200: OK\n"
+		ast, err := apimock.NewParserFromBytes("test.apimock", []byte(src)).Parse()
+		if err != nil {
+			t.Fatalf("%q does not parse: %v", src, err)
+		}
+		if got := ast.Request.Path; got != req.Path {
+			t.Errorf("parsed path = %q, want %q", got, req.Path)
+		}
+	}
+}
+
+func TestPatternSegment(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "users", want: "users"},
+		{pattern: "[0-9]+", want: "{id:[0-9]+}"},
+		{pattern: ".*", want: "*"},
+		{pattern: `\d+`, want: `{id:\d+}`},
+		{pattern: "[^/]+", want: "{id}"},
+	}
+	for _, tt := range tests {
+		if got := PatternSegment(tt.pattern, "id").Value; got != tt.want {
+			t.Errorf("PatternSegment(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	file := apimock.NewAPIMockFile()
+	file.Request = NewRequest("GET", "/users")
+	resp := apimock.NewResponseSection()
+	resp.StatusCode = 200
+	file.Responses = append(file.Responses, resp)
+	mocks := []Mock{{Name: "get-users.apimock", File: file}}
+
+	if err := Write(mocks, dir, false); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "get-users.apimock")); err != nil || string(data) != "GET /users\n\n-- 200:\n" {
+		t.Errorf("Write() wrote %q, %v", data, err)
+	}
+	if err := Write(mocks, dir, false); !errors.Is(err, ErrExists) {
+		t.Errorf("Write() over an existing file error = %v, want ErrExists", err)
+	}
+	if err := Write(mocks, dir, true); err != nil {
+		t.Errorf("Write() with force error = %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package owners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// MetadataKey is the request property that names the owners of an endpoint.
+const MetadataKey = "X-Meta-Owner"
+
+// Rule maps a CODEOWNERS-style path pattern to its owners.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	pattern *regexp.Regexp
+}
+
+// Rules is an ordered set of ownership rules rooted at a directory. As in
+// CODEOWNERS, the last matching rule wins.
+type Rules struct {
+	Root  string
+	Rules []Rule
+}
+
+// LoadRules reads a CODEOWNERS-like file. Patterns are resolved relative to
+// the directory containing the file.
+func LoadRules(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	root, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rules.Root = root
+	return rules, nil
+}
+
+// ParseRules parses CODEOWNERS-like lines of the form `pattern owner...`.
+// Blank lines and lines starting with # are ignored.
+func ParseRules(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a pattern followed by at least one owner", lineNum)
+		}
+		rules.Rules = append(rules.Rules, Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			pattern: compilePattern(fields[0]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// OwnersOf returns the owners of the file at path, or nil if no rule matches.
+func (r *Rules) OwnersOf(path string) []string {
+	rel := path
+	if r.Root != "" {
+		if p, err := filepath.Rel(r.Root, path); err == nil {
+			rel = p
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	for i := len(r.Rules) - 1; i >= 0; i-- {
+		if r.Rules[i].pattern.MatchString(rel) {
+			return r.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compilePattern converts a gitignore-style pattern into a regular expression
+// over slash-separated relative paths. Patterns without an inner slash match
+// at any depth, and a pattern matching a directory matches everything below it.
+func compilePattern(pattern string) *regexp.Regexp {
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		}
+	}
+	b.WriteString("(/.*)?$")
+	return regexp.MustCompile(b.String())
+}
+
+// Entry describes who owns one mocked route.
+type Entry struct {
+	Route    string
+	FilePath string
+	Owners   []string
+}
+
+// Unowned reports whether no owner was found for the route.
+func (e Entry) Unowned() bool {
+	return len(e.Owners) == 0
+}
+
+// declaredOwners returns the MetadataKey entry of metadata, whose keys are
+// written as in the file, in any case.
+func declaredOwners(metadata map[string]string) string {
+	for key, value := range metadata {
+		if strings.EqualFold(key, MetadataKey) {
+			return value
+		}
+	}
+	return ""
+}
+
+// Report resolves the owners of each endpoint. Owners declared in the
+// endpoint's X-Meta-Owner metadata take precedence over the rules, which may be nil.
+func Report(endpoints []*endpoint.EndpointWithFile, rules *Rules) []Entry {
+	entries := make([]Entry, 0, len(endpoints))
+	for _, ep := range endpoints {
+		entry := Entry{Route: ep.Schema.Route, FilePath: ep.FilePath}
+		if declared := declaredOwners(ep.Schema.Metadata); declared != "" {
+			entry.Owners = strings.FieldsFunc(declared, func(r rune) bool {
+				return r == ',' || r == ' '
+			})
+		} else if rules != nil {
+			entry.Owners = rules.OwnersOf(ep.FilePath)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Route < entries[j].Route
+	})
+	return entries
+}
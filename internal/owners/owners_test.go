@@ -0,0 +1,152 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestParseRules(t *testing.T) {
+	input := `# Mock ownership
+*.apimock        @platform
+
+payments/        @team-payments @alice
+/users/*.apimock @team-accounts
+`
+	rules, err := ParseRules(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+
+	if len(rules.Rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules.Rules))
+	}
+	if got := rules.Rules[1].Owners; len(got) != 2 || got[0] != "@team-payments" || got[1] != "@alice" {
+		t.Errorf("unexpected owners for payments rule: %v", got)
+	}
+}
+
+func TestParseRules_MissingOwner(t *testing.T) {
+	_, err := ParseRules(strings.NewReader("payments/\n"))
+	if err == nil {
+		t.Fatal("expected error for pattern without owners")
+	}
+	if !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected line number in error, got %v", err)
+	}
+}
+
+func TestRules_OwnersOf(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+*.apimock          @platform
+payments/          @team-payments
+/users/*.apimock   @team-accounts
+docs/**/draft-*    @writers
+`))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	rules.Root = "/mocks"
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/mocks/health.apimock", "@platform"},
+		{"/mocks/payments/create.apimock", "@team-payments"},
+		{"/mocks/api/payments/refund.apimock", "@team-payments"},
+		{"/mocks/users/get.apimock", "@team-accounts"},
+		{"/mocks/legacy/users/get.apimock", "@platform"},
+		{"/mocks/docs/a/b/draft-x.apimock", "@writers"},
+		{"/mocks/notes.txt", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := strings.Join(rules.OwnersOf(tt.path), " ")
+			if got != tt.want {
+				t.Errorf("OwnersOf(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRules_RootIsFileDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CODEOWNERS")
+	if err := os.WriteFile(path, []byte("/orders/ @team-orders\n"), 0o644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+
+	owners := rules.OwnersOf(filepath.Join(dir, "orders", "list.apimock"))
+	if len(owners) != 1 || owners[0] != "@team-orders" {
+		t.Errorf("expected @team-orders, got %v", owners)
+	}
+}
+
+func TestReport(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader("payments/ @team-payments\n"))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	rules.Root = "/mocks"
+
+	endpoints := []*endpoint.EndpointWithFile{
+		{
+			Schema:   &endpoint.EndpointSchema{Route: "POST /payments"},
+			FilePath: "/mocks/payments/create.apimock",
+		},
+		{
+			Schema: &endpoint.EndpointSchema{
+				Route:    "GET /users",
+				Metadata: map[string]string{MetadataKey: "@team-accounts, @bob"},
+			},
+			FilePath: "/mocks/users/list.apimock",
+		},
+		{
+			Schema: &endpoint.EndpointSchema{
+				Route:    "GET /orders",
+				Metadata: map[string]string{"x-meta-owner": "@team-orders"},
+			},
+			FilePath: "/mocks/payments/orders.apimock",
+		},
+		{
+			Schema:   &endpoint.EndpointSchema{Route: "GET /health"},
+			FilePath: "/mocks/health.apimock",
+		},
+	}
+
+	entries := Report(endpoints, rules)
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+
+	byRoute := make(map[string]Entry)
+	for _, e := range entries {
+		byRoute[e.Route] = e
+	}
+
+	if got := byRoute["GET /users"].Owners; len(got) != 2 || got[0] != "@team-accounts" || got[1] != "@bob" {
+		t.Errorf("expected owners from metadata, got %v", got)
+	}
+	if got := byRoute["GET /orders"].Owners; len(got) != 1 || got[0] != "@team-orders" {
+		t.Errorf("expected owners from metadata written in lower case, got %v", got)
+	}
+	if got := byRoute["POST /payments"].Owners; len(got) != 1 || got[0] != "@team-payments" {
+		t.Errorf("expected owners from rules, got %v", got)
+	}
+	if !byRoute["GET /health"].Unowned() {
+		t.Errorf("expected GET /health to be unowned, got %v", byRoute["GET /health"].Owners)
+	}
+	if entries[0].Route != "GET /health" {
+		t.Errorf("expected entries sorted by route, got %s first", entries[0].Route)
+	}
+}
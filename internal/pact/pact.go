@@ -0,0 +1,266 @@
+// Package pact generates Pact consumer contracts from .apimock files, so the
+// mocks a consumer is tested against can be verified against the provider.
+package pact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// SpecificationVersion is the version of the Pact specification written.
+const SpecificationVersion = "3.0.0"
+
+// Pact is a consumer contract: the interactions a consumer expects from a
+// provider.
+type Pact struct {
+	Consumer     Pacticipant   `json:"consumer"`
+	Provider     Pacticipant   `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+	Metadata     Metadata      `json:"metadata"`
+}
+
+type Pacticipant struct {
+	Name string `json:"name"`
+}
+
+type Metadata struct {
+	PactSpecification struct {
+		Version string `json:"version"`
+	} `json:"pactSpecification"`
+}
+
+// Interaction is a request and the response the consumer expects, given the
+// provider states.
+type Interaction struct {
+	Description    string          `json:"description"`
+	ProviderStates []ProviderState `json:"providerStates,omitempty"`
+	Request        Request         `json:"request"`
+	Response       Response        `json:"response"`
+}
+
+type ProviderState struct {
+	Name string `json:"name"`
+}
+
+// Request is the request of an interaction. When the mock path has
+// parameters, Path is an example and MatchingRules holds the pattern of the
+// path.
+type Request struct {
+	Method        string              `json:"method"`
+	Path          string              `json:"path"`
+	Query         map[string][]string `json:"query,omitempty"`
+	Headers       map[string]string   `json:"headers,omitempty"`
+	MatchingRules *MatchingRules      `json:"matchingRules,omitempty"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+type MatchingRules struct {
+	Path *Matchers `json:"path,omitempty"`
+}
+
+type Matchers struct {
+	Matchers []Matcher `json:"matchers"`
+}
+
+type Matcher struct {
+	Match string `json:"match"`
+	Regex string `json:"regex,omitempty"`
+}
+
+// Generate builds the contract between consumer and provider from the
+// request and response pairs of files. The response a mock serves by default
+// is expected without provider state; the others are expected in the state
+// named after their profile, or else after their description. Mocks without a
+// request section, proxy sections and what a contract cannot hold, such as
+// templated values and conditions, are left out and described in the
+// returned warnings.
+func Generate(consumer, provider string, files []*apimock.APIMockFile) (*Pact, []string) {
+	p := &Pact{
+		Consumer:     Pacticipant{Name: consumer},
+		Provider:     Pacticipant{Name: provider},
+		Interactions: []Interaction{},
+	}
+	p.Metadata.PactSpecification.Version = SpecificationVersion
+
+	var warnings []string
+	seen := make(map[string]int)
+	for _, file := range files {
+		if file.Request == nil {
+			warnings = append(warnings, "mock without a request section left out: a contract needs a path")
+			continue
+		}
+		route := strings.TrimSpace(file.Request.Method + " " + file.Request.Path)
+		req, err := request(file.Request)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", route, err))
+			continue
+		}
+
+		contentTypes := make(map[int]int)
+		for _, resp := range file.Responses {
+			contentTypes[resp.StatusCode]++
+		}
+		defaultIndex := defaultResponse(file.Responses)
+
+		for i, resp := range file.Responses {
+			name := fmt.Sprintf("%s -- %d: %s", route, resp.StatusCode, resp.Description)
+			if resp.Upstream != "" {
+				warnings = append(warnings, fmt.Sprintf("%s -- proxy: proxy sections left out", route))
+				continue
+			}
+			if strings.HasPrefix(resp.Body, apimock.ConditionPrefix) {
+				warnings = append(warnings, fmt.Sprintf("%s: conditions left out", name))
+			}
+
+			interaction := Interaction{
+				Description: strings.TrimSpace(name),
+				Request:     req,
+				Response:    response(resp),
+			}
+			if seen[interaction.Description]++; seen[interaction.Description] > 1 {
+				interaction.Description += fmt.Sprintf(" (%d)", seen[interaction.Description])
+			}
+			if contentType := resp.Properties[endpoint.ResponseContentTypePropertyName]; contentTypes[resp.StatusCode] > 1 && contentType != "" {
+				interaction.Request.Headers = map[string]string{"Accept": contentType}
+			}
+
+			profiles := endpoint.PropertyProfiles(resp.Properties)
+			switch {
+			case len(profiles) > 0:
+				for _, profile := range profiles {
+					interaction.ProviderStates = append(interaction.ProviderStates, ProviderState{Name: profile})
+				}
+			case i != defaultIndex:
+				state := resp.Description
+				if state == "" {
+					state = http.StatusText(resp.StatusCode)
+				}
+				interaction.ProviderStates = []ProviderState{{Name: state}}
+			}
+
+			for key, value := range interaction.Response.Headers {
+				if strings.Contains(value, "{{") {
+					delete(interaction.Response.Headers, key)
+					warnings = append(warnings, fmt.Sprintf("%s: templated header %s left out", name, key))
+				}
+			}
+			p.Interactions = append(p.Interactions, interaction)
+		}
+	}
+	return p, warnings
+}
+
+// request builds the request of a mock, with an example path when the mock
+// path has parameters.
+func request(r *apimock.RequestSection) (Request, error) {
+	req := Request{Method: r.Method}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	for key, value := range r.QueryParams {
+		if req.Query == nil {
+			req.Query = make(map[string][]string)
+		}
+		req.Query[key] = []string{value}
+	}
+
+	path, err := r.ExamplePath()
+	if err != nil {
+		return Request{}, err
+	}
+	req.Path = path
+
+	var pattern strings.Builder
+	parameters := false
+	for _, seg := range r.PathSegments {
+		pattern.WriteString("/")
+		switch {
+		case seg.IsWildcard:
+			parameters = true
+			pattern.WriteString(".*")
+		case seg.IsParameter && seg.Pattern != "":
+			parameters = true
+			pattern.WriteString(seg.Pattern)
+		case seg.IsParameter:
+			parameters = true
+			pattern.WriteString("[^/]+")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(seg.Value))
+		}
+	}
+	if parameters {
+		req.MatchingRules = &MatchingRules{Path: &Matchers{Matchers: []Matcher{{Match: "regex", Regex: "^" + pattern.String() + "$"}}}}
+	}
+	return req, nil
+}
+
+func response(r apimock.ResponseSection) Response {
+	resp := Response{Status: r.StatusCode}
+	for key, value := range r.Properties {
+		switch {
+		case key == endpoint.ResponseContentTypePropertyName:
+			key = "Content-Type"
+		case endpoint.IsResponseControlProperty(key):
+			continue
+		}
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string)
+		}
+		resp.Headers[key] = value
+	}
+
+	if r.Body == "" {
+		return resp
+	}
+	var body any
+	if strings.Contains(resp.Headers["Content-Type"], "json") && json.Unmarshal([]byte(r.Body), &body) == nil {
+		resp.Body = body
+	} else {
+		resp.Body = r.Body
+	}
+	return resp
+}
+
+// defaultResponse returns the index of the response served when nothing else
+// is asked for: the first 200 response without a profile, or else the first
+// with the lowest status code. It returns -1 when every response has a
+// profile.
+func defaultResponse(responses []apimock.ResponseSection) int {
+	best := -1
+	for i, resp := range responses {
+		if resp.Upstream != "" || len(endpoint.PropertyProfiles(resp.Properties)) > 0 {
+			continue
+		}
+		if best < 0 || responses[best].StatusCode != http.StatusOK &&
+			(resp.StatusCode == http.StatusOK || resp.StatusCode < responses[best].StatusCode) {
+			best = i
+		}
+	}
+	return best
+}
+
+// WriteJSON writes the contract as an indented Pact file.
+func (p *Pact) WriteJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,93 @@
+package pact
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+const source = `GET /api/users/{id:[0-9]+}
+  ?expand=orders
+
+-- 200: Found
+ContentType: application/json
+X-Request-ID: {{headers["X-Correlation-ID"]}}
+
+{"id": 1, "name": "Ada"}
+
+-- 200: Found as text
+ContentType: text/plain
+
+Ada
+
+-- 404: User not found
+ContentType: application/json
+
+{"error": "not found"}
+
+-- 503: Down
+Profile: outage
+`
+
+func TestGenerate(t *testing.T) {
+	file, err := apimock.NewParserFromBytes("users.apimock", []byte(source)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	proxy, err := apimock.NewParserFromBytes("proxy.apimock", []byte("GET /legacy\n\n-- proxy: https://legacy.example.com\n")).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	p, warnings := Generate("web", "users-api", []*apimock.APIMockFile{file, proxy})
+
+	var buf bytes.Buffer
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var got, want any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+
+	request := func(accept string) string {
+		headers := ""
+		if accept != "" {
+			headers = `"headers": {"Accept": "` + accept + `"},`
+		}
+		return `"request": {"method": "GET", "path": "/api/users/1", "query": {"expand": ["orders"]}, ` + headers + `
+			"matchingRules": {"path": {"matchers": [{"match": "regex", "regex": "^/api/users/[0-9]+$"}]}}}`
+	}
+	wantJSON := `{
+		"consumer": {"name": "web"},
+		"provider": {"name": "users-api"},
+		"interactions": [
+			{"description": "GET /api/users/{id:[0-9]+} -- 200: Found", ` + request("application/json") + `,
+			 "response": {"status": 200, "headers": {"Content-Type": "application/json"}, "body": {"id": 1, "name": "Ada"}}},
+			{"description": "GET /api/users/{id:[0-9]+} -- 200: Found as text", "providerStates": [{"name": "Found as text"}], ` + request("text/plain") + `,
+			 "response": {"status": 200, "headers": {"Content-Type": "text/plain"}, "body": "Ada"}},
+			{"description": "GET /api/users/{id:[0-9]+} -- 404: User not found", "providerStates": [{"name": "User not found"}], ` + request("") + `,
+			 "response": {"status": 404, "headers": {"Content-Type": "application/json"}, "body": {"error": "not found"}}},
+			{"description": "GET /api/users/{id:[0-9]+} -- 503: Down", "providerStates": [{"name": "outage"}], ` + request("") + `,
+			 "response": {"status": 503}}
+		],
+		"metadata": {"pactSpecification": {"version": "3.0.0"}}
+	}`
+	if err := json.Unmarshal([]byte(wantJSON), &want); err != nil {
+		t.Fatalf("invalid expectation: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate() =\n%s", buf.String())
+	}
+
+	wantWarnings := []string{
+		"GET /api/users/{id:[0-9]+} -- 200: Found: templated header X-Request-ID left out",
+		"GET /legacy -- proxy: proxy sections left out",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Generate() warnings = %v, want %v", warnings, wantWarnings)
+	}
+}
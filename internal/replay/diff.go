@@ -0,0 +1,77 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Diff compares two decoded JSON values: it lists the fields of expected
+// missing from actual, the fields of actual expected does not have, the
+// arrays of other lengths and the values that differ.
+func Diff(expected, actual any) []string {
+	var diff []string
+	diffValues(&diff, "$", expected, actual)
+	return diff
+}
+
+func diffValues(diff *[]string, path string, expected, actual any) {
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(expected) {
+			value, ok := actual[key]
+			if !ok {
+				*diff = append(*diff, fmt.Sprintf("%s.%s is missing", path, key))
+				continue
+			}
+			diffValues(diff, path+"."+key, expected[key], value)
+		}
+		for _, key := range sortedKeys(actual) {
+			if _, ok := expected[key]; !ok {
+				*diff = append(*diff, fmt.Sprintf("%s.%s is not in the recording", path, key))
+			}
+		}
+		return
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		if len(expected) != len(actual) {
+			*diff = append(*diff, fmt.Sprintf("%s has %d items, the recording has %d", path, len(actual), len(expected)))
+		}
+		for i := range min(len(expected), len(actual)) {
+			diffValues(diff, fmt.Sprintf("%s[%d]", path, i), expected[i], actual[i])
+		}
+		return
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		*diff = append(*diff, fmt.Sprintf("%s is %s, the recording has %s", path, encode(actual), encode(expected)))
+	}
+}
+
+// encode writes a value as JSON, shortening objects and arrays.
+func encode(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+// Package replay sends the requests of a recorded request history again,
+// to the mock server or to a real API, and reports where the responses
+// differ from the recorded ones, so recorded sessions serve as regression
+// tests.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/verify"
+)
+
+// Options configure how requests are replayed.
+type Options struct {
+	// BaseURL is prepended to the recorded path of every request
+	BaseURL string
+	// Header is sent with every request, replacing recorded headers of the
+	// same name, for credentials
+	Header http.Header
+	// Shape compares JSON bodies by their structure only, ignoring values,
+	// for APIs answering with generated IDs and timestamps
+	Shape bool
+	// Timeout bounds each request (0 = 10s)
+	Timeout time.Duration
+}
+
+// Result is the outcome of replaying one recorded request.
+type Result struct {
+	ID      int64  `json:"id"`
+	Request string `json:"request"`
+	// Status is the status code answered, 0 when the server was not reached
+	Status int `json:"status,omitempty"`
+	// Diff lists the differences between the response and the recorded one
+	Diff  []string `json:"diff,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// OK reports whether the response matched the recorded one.
+func (r Result) OK() bool {
+	return r.Error == "" && len(r.Diff) == 0
+}
+
+// Load reads a history as returned by GET /_admin/history and returns its
+// exchanges oldest first, the order they were recorded in.
+func Load(r io.Reader) ([]history.Exchange, error) {
+	var exchanges []history.Exchange
+	if err := json.NewDecoder(r).Decode(&exchanges); err != nil {
+		return nil, fmt.Errorf("invalid history: %w", err)
+	}
+	slices.SortStableFunc(exchanges, func(a, b history.Exchange) int {
+		return a.Time.Compare(b.Time)
+	})
+	return exchanges, nil
+}
+
+// notReplayed are the recorded request headers left out: those the client
+// sets itself, and Accept-Encoding so that bodies are compared decoded.
+var notReplayed = []string{"Host", "Content-Length", "Connection", "Accept-Encoding", "Transfer-Encoding"}
+
+// Replay sends every exchange again, in order, and compares each response
+// with the recorded one: the status code, the content type and the body,
+// JSON bodies by value (or by shape with Options.Shape). Bodies that were
+// truncated when recorded are not compared.
+func Replay(ctx context.Context, exchanges []history.Exchange, opts Options) []Result {
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		// Redirects are part of the recorded response
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	if client.Timeout == 0 {
+		client.Timeout = 10 * time.Second
+	}
+
+	results := make([]Result, 0, len(exchanges))
+	for _, ex := range exchanges {
+		results = append(results, replay(ctx, client, ex, opts))
+	}
+	return results
+}
+
+func replay(ctx context.Context, client *http.Client, ex history.Exchange, opts Options) Result {
+	target := strings.TrimRight(opts.BaseURL, "/") + ex.Path
+	if ex.Query != "" {
+		target += "?" + ex.Query
+	}
+	result := Result{ID: ex.ID, Request: ex.Method + " " + target}
+
+	req, err := http.NewRequestWithContext(ctx, ex.Method, target, strings.NewReader(ex.RequestBody))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header = ex.RequestHeaders.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	for _, key := range notReplayed {
+		req.Header.Del(key)
+	}
+	for key, values := range opts.Header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = resp.StatusCode
+	if resp.StatusCode != ex.Status {
+		result.Diff = append(result.Diff, fmt.Sprintf("status is %d, the recording has %d", resp.StatusCode, ex.Status))
+	}
+	want, got := mediaType(ex.ResponseHeaders.Get("Content-Type")), mediaType(resp.Header.Get("Content-Type"))
+	if want != got {
+		result.Diff = append(result.Diff, fmt.Sprintf("content type is %q, the recording has %q", got, want))
+	}
+	if !ex.Truncated {
+		result.Diff = append(result.Diff, compareBodies([]byte(ex.ResponseBody), body, opts.Shape)...)
+	}
+	return result
+}
+
+// compareBodies lists the differences between a recorded body and a new
+// one, comparing them as JSON when the recorded body is JSON.
+func compareBodies(recorded, body []byte, shape bool) []string {
+	var want, got any
+	if json.Unmarshal(recorded, &want) != nil {
+		if !bytes.Equal(recorded, body) {
+			return []string{"body differs"}
+		}
+		return nil
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		return []string{"body is not JSON"}
+	}
+	if shape {
+		return verify.Shape(want, got)
+	}
+	return Diff(want, got)
+}
+
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return media
+}
@@ -0,0 +1,125 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/history"
+)
+
+func TestLoad(t *testing.T) {
+	newest := `{"id": 2, "time": "2026-01-01T10:00:02Z", "method": "GET", "path": "/b", "status": 200, "responseIndex": 0}`
+	oldest := `{"id": 1, "time": "2026-01-01T10:00:01Z", "method": "GET", "path": "/a", "status": 200, "responseIndex": 0}`
+	exchanges, err := Load(strings.NewReader("[" + newest + "," + oldest + "]"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(exchanges) != 2 || exchanges[0].Path != "/a" || exchanges[1].Path != "/b" {
+		t.Errorf("Load() = %+v, want /a then /b", exchanges)
+	}
+
+	if _, err := Load(strings.NewReader(`{"id": 1}`)); err == nil {
+		t.Error("Load() of an object succeeded, want an error")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.String() {
+		case "/users?page=2":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"users": [{"id": 1, "name": "Ada"}]}`))
+		case "/users":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"name": "Bob"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 7, "name": "Bob"}`))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}
+	}))
+	defer api.Close()
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+	exchanges := []history.Exchange{
+		{ID: 1, Method: "GET", Path: "/users", Query: "page=2", Status: 200, ResponseHeaders: jsonHeader,
+			ResponseBody: `{"users": [{"id": 1, "name": "Ada"}]}`},
+		{ID: 2, Method: "POST", Path: "/users", RequestBody: `{"name": "Bob"}`, Status: 201, ResponseHeaders: jsonHeader,
+			ResponseBody: `{"id": 3, "name": "Bob", "admin": false}`},
+		{ID: 3, Method: "GET", Path: "/orders", Status: 200, ResponseHeaders: jsonHeader, ResponseBody: `[]`},
+		{ID: 4, Method: "GET", Path: "/missing", Status: 404, ResponseHeaders: http.Header{"Content-Type": {"text/plain"}},
+			ResponseBody: "no such page"},
+		{ID: 5, Method: "GET", Path: "/missing", Status: 404, ResponseHeaders: http.Header{"Content-Type": {"text/plain"}},
+			ResponseBody: "cut", Truncated: true},
+	}
+	results := Replay(context.Background(), exchanges, Options{BaseURL: api.URL + "/", Header: http.Header{"Authorization": {"Bearer t"}}})
+
+	want := []Result{
+		{ID: 1, Request: "GET " + api.URL + "/users?page=2", Status: 200},
+		{ID: 2, Request: "POST " + api.URL + "/users", Status: 201, Diff: []string{
+			"$.admin is missing",
+			"$.id is 7, the recording has 3",
+		}},
+		{ID: 3, Request: "GET " + api.URL + "/orders", Status: 404, Diff: []string{
+			"status is 404, the recording has 200",
+			`content type is "text/plain", the recording has "application/json"`,
+			"body is not JSON",
+		}},
+		{ID: 4, Request: "GET " + api.URL + "/missing", Status: 404, Diff: []string{"body differs"}},
+		{ID: 5, Request: "GET " + api.URL + "/missing", Status: 404},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Replay() =\n%+v\nwant\n%+v", results, want)
+	}
+
+	results = Replay(context.Background(), exchanges[1:2], Options{BaseURL: api.URL, Header: http.Header{"Authorization": {"Bearer t"}}, Shape: true})
+	if want := []string{"$.admin is missing"}; len(results) != 1 || !reflect.DeepEqual(results[0].Diff, want) {
+		t.Errorf("Replay() with Shape = %+v, want only %v", results, want)
+	}
+}
+
+func TestReplay_Unreachable(t *testing.T) {
+	results := Replay(context.Background(), []history.Exchange{{Method: "GET", Path: "/a", Status: 200}},
+		Options{BaseURL: "http://127.0.0.1:1", Timeout: time.Second})
+	if len(results) != 1 || results[0].Error == "" || results[0].OK() {
+		t.Errorf("Replay() = %+v, want a connection error", results)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected any
+		actual   any
+		want     []string
+	}{
+		{name: "equal", expected: map[string]any{"a": []any{1.0}}, actual: map[string]any{"a": []any{1.0}}},
+		{name: "value", expected: map[string]any{"a": "x"}, actual: map[string]any{"a": "y"}, want: []string{`$.a is "y", the recording has "x"`}},
+		{name: "type", expected: []any{}, actual: map[string]any{}, want: []string{"$ is an object, the recording has an array"}},
+		{name: "length", expected: []any{1.0, 2.0}, actual: []any{1.0}, want: []string{"$ has 1 items, the recording has 2"}},
+		{name: "null", expected: map[string]any{"a": nil}, actual: map[string]any{"a": 1.0}, want: []string{"$.a is 1, the recording has null"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.expected, tt.actual); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package scaffold writes a starter mock project for new users.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Options configures the generated project.
+type Options struct {
+	// Dir is the directory the .apimock files are written to
+	Dir string
+	// Port is the port the Makefile target serves the mocks on
+	Port int
+	// Makefile is the file the mock target is added to; created if missing
+	Makefile string
+	// Force overwrites existing .apimock files
+	Force bool
+}
+
+// MakeTarget is the Makefile target serving the generated mocks.
+const MakeTarget = "mock"
+
+// makeTargetRegex finds an existing mock target in a Makefile.
+var makeTargetRegex = regexp.MustCompile(`(?m)^` + MakeTarget + `\s*:`)
+
+// Files are the starter .apimock files, by name.
+var Files = []struct {
+	Name    string
+	Content string
+}{
+	{"health.apimock", `GET /health
+
+-- 200: Healthy
+ContentType: application/json
+
+{"status": "up"}
+`},
+	{"get-user.apimock", `GET /users/{id:[0-9]+}
+
+-- 200: User found
+ContentType: application/json
+
+{
+  "id": 1,
+  "name": "Ada Lovelace",
+  "email": "ada@example.com"
+}
+
+-- 404: User not found
+ContentType: application/json
+
+{"error": "user not found"}
+`},
+	{"create-user.apimock", `POST /users
+Accept: application/json
+{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "email": {"type": "string"}
+  },
+  "required": ["name", "email"]
+}
+
+-- 201: User created
+ContentType: application/json
+Location: /users/2
+
+{
+  "id": 2,
+  "name": "Alan Turing",
+  "email": "alan@example.com"
+}
+
+-- 400: Invalid user
+ContentType: application/json
+
+{"error": "name and email are required"}
+`},
+}
+
+// Write creates the starter project and returns the paths it wrote. It fails
+// before writing anything when an .apimock file exists, unless opts.Force is
+// set. The Makefile is left alone when it already has a mock target.
+func Write(opts Options) ([]string, error) {
+	if !opts.Force {
+		for _, f := range Files {
+			path := filepath.Join(opts.Dir, f.Name)
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s: %w", path, fs.ErrExist)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, f := range Files {
+		path := filepath.Join(opts.Dir, f.Name)
+		if err := os.WriteFile(path, []byte(f.Content), 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	added, err := addMakeTarget(opts)
+	if err != nil {
+		return written, err
+	}
+	if added {
+		written = append(written, opts.Makefile)
+	}
+	return written, nil
+}
+
+// addMakeTarget appends the mock target to the Makefile and reports whether
+// it did.
+func addMakeTarget(opts Options) (bool, error) {
+	content, err := os.ReadFile(opts.Makefile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if makeTargetRegex.Match(content) {
+		return false, nil
+	}
+
+	var b bytes.Buffer
+	b.Write(content)
+	if len(content) > 0 {
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "# Serve the mocks in %s\n", opts.Dir)
+	fmt.Fprintf(&b, ".PHONY: %s\n%s:\n\tanansi-proxy --port %d %s\n", MakeTarget, MakeTarget, opts.Port, filepath.ToSlash(opts.Dir))
+	return true, os.WriteFile(opts.Makefile, b.Bytes(), 0o644)
+}
@@ -0,0 +1,55 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+	opts := Options{
+		Dir:      filepath.Join(root, "mocks"),
+		Port:     9000,
+		Makefile: filepath.Join(root, "Makefile"),
+	}
+	if err := os.WriteFile(opts.Makefile, []byte("build:\n\tgo build ./..."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := Write(opts)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(written) != len(Files)+1 {
+		t.Errorf("Write() wrote %v, want the %d mocks and the Makefile", written, len(Files))
+	}
+
+	for _, path := range written[:len(Files)] {
+		if _, err := endpoint.ParseAPIMock(path); err != nil {
+			t.Errorf("generated mock does not load: %v", err)
+		}
+	}
+
+	makefile, err := os.ReadFile(opts.Makefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "build:\n\tgo build ./...\n\n# Serve the mocks in " + opts.Dir + "\n.PHONY: mock\nmock:\n\tanansi-proxy --port 9000 " + filepath.ToSlash(opts.Dir) + "\n"
+	if string(makefile) != want {
+		t.Errorf("Makefile = %q, want %q", makefile, want)
+	}
+
+	// A second run refuses to overwrite the mocks, and with Force leaves the
+	// Makefile alone
+	if _, err := Write(opts); err == nil || !strings.Contains(err.Error(), "file already exists") {
+		t.Errorf("Write() over existing mocks = %v, want an file already exists error", err)
+	}
+	opts.Force = true
+	if written, err := Write(opts); err != nil || len(written) != len(Files) {
+		t.Errorf("Write() with Force = %v, %v; want only the mocks", written, err)
+	}
+}
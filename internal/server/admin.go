@@ -0,0 +1,251 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Admin routes. Mocks declaring the same route take precedence.
+const (
+	// EventsRoute serves the event stream of a server
+	EventsRoute = "GET /_admin/events"
+	// SourceRoute maps routes to the .apimock sections defining them
+	SourceRoute = "GET /_admin/source"
+	// StatsRoute serves the request statistics of a server
+	StatsRoute = "GET /_admin/stats"
+	// ErrorsRoute lists the .apimock files that failed to load
+	ErrorsRoute = "GET /_admin/errors"
+	// ProfileRoute tells the active response profile and the declared ones
+	ProfileRoute = "GET /_admin/profile"
+	// SetProfileRoute switches the active response profile
+	SetProfileRoute = "PUT /_admin/profile"
+	// HistoryRoute lists the last requests served, see KeepHistory
+	HistoryRoute = "GET /_admin/history"
+	// ClearHistoryRoute forgets the requests served so far
+	ClearHistoryRoute = "DELETE /_admin/history"
+	// DeleteNamespaceRoute forgets the state of a NamespaceHeader value
+	DeleteNamespaceRoute = "DELETE /_admin/sessions/{name}"
+)
+
+// registerAdmin adds the admin routes not taken by a mock to mux. shapes and
+// groups are the route shapes of the mocks and the endpoints sharing each.
+func (s *Server) registerAdmin(mux *http.ServeMux, shapes []string, groups map[string][]*endpoint.EndpointWithFile) {
+	if _, declared := groups[EventsRoute]; s.events != nil && !declared {
+		mux.Handle(EventsRoute, s.events)
+	}
+	if _, declared := groups[SourceRoute]; !declared {
+		mux.HandleFunc(SourceRoute, s.sourceHandler(shapes, groups))
+	}
+	if _, declared := groups[StatsRoute]; s.stats != nil && !declared {
+		mux.HandleFunc(StatsRoute, s.statsHandler)
+	}
+	if _, declared := groups[ErrorsRoute]; !declared {
+		mux.HandleFunc(ErrorsRoute, s.errorsHandler)
+	}
+	if _, declared := groups[ProfileRoute]; !declared {
+		mux.HandleFunc(ProfileRoute, s.profileHandler)
+	}
+	if _, declared := groups[SetProfileRoute]; !declared {
+		mux.HandleFunc(SetProfileRoute, s.setProfileHandler)
+	}
+	if _, declared := groups[HistoryRoute]; s.history != nil && !declared {
+		mux.HandleFunc(HistoryRoute, historyHandler(s.history))
+	}
+	if _, declared := groups[ClearHistoryRoute]; s.history != nil && !declared {
+		mux.HandleFunc(ClearHistoryRoute, clearHistoryHandler(s.history))
+	}
+	if _, declared := groups[endpoint.RouteShape(DeleteNamespaceRoute)]; !declared {
+		mux.HandleFunc(DeleteNamespaceRoute, s.deleteNamespaceHandler)
+	}
+}
+
+// historyHandler lists the exchanges of log selected by the route, method,
+// path, status, failed and limit parameters, newest first.
+func historyHandler(log *history.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := history.ParseQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		exchanges := log.Find(q)
+		if exchanges == nil {
+			exchanges = []history.Exchange{}
+		}
+		w.Header().Set(endpoint.ContentTypeHeader, "application/json")
+		json.NewEncoder(w).Encode(exchanges)
+	}
+}
+
+// clearHistoryHandler empties log.
+func clearHistoryHandler(log *history.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Clear()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// statsHandler writes the statistics collected so far, including the traffic
+// of the last 1, 5 and 15 minutes.
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(endpoint.ContentTypeHeader, "application/json")
+	s.stats.Report().WriteJSON(w)
+}
+
+// BrokenFile is an .apimock file left out of a server because it failed to
+// load.
+type BrokenFile struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// errorsHandler lists the files given to ReportBrokenFiles.
+func (s *Server) errorsHandler(w http.ResponseWriter, r *http.Request) {
+	broken := make([]BrokenFile, len(s.broken))
+	for i, err := range s.broken {
+		broken[i] = BrokenFile{File: err.FilePath, Error: err.Err.Error()}
+	}
+	w.Header().Set(endpoint.ContentTypeHeader, "application/json")
+	json.NewEncoder(w).Encode(broken)
+}
+
+// SourceLocation tells where an endpoint is defined, so editor plugins can
+// jump from an HTTP call to the mock answering it. Metadata holds the X-Meta-
+// properties of the request section.
+type SourceLocation struct {
+	Route     string            `json:"route"`
+	File      string            `json:"file"`
+	Request   *SourceLines      `json:"request,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Responses []SourceResponse  `json:"responses"`
+}
+
+// SourceResponse locates one response section.
+type SourceResponse struct {
+	Status      int               `json:"status"`
+	Title       string            `json:"title"`
+	ContentType string            `json:"contentType"`
+	Lines       SourceLines       `json:"lines"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// SourceLines is a range of 1-based lines of an .apimock file.
+type SourceLines struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// sourceHandler lists the source locations of the endpoints. The list is
+// narrowed to a declared route with ?route=GET%20/users/{id}, or to the
+// endpoints that would answer a request with ?method=GET&path=/users/42.
+// Endpoints told apart by their request body are all listed.
+func (s *Server) sourceHandler(shapes []string, groups map[string][]*endpoint.EndpointWithFile) http.HandlerFunc {
+	routes := http.NewServeMux()
+	for _, shape := range shapes {
+		routes.Handle(groups[shape][0].Schema.Route, http.NotFoundHandler())
+	}
+	routes.Handle("/", http.NotFoundHandler())
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		candidates := s.endpoints
+
+		if route := query.Get("route"); route != "" {
+			candidates = nil
+			for _, ep := range s.endpoints {
+				if ep.Schema.Route == route {
+					candidates = append(candidates, ep)
+				}
+			}
+		}
+
+		if path := query.Get("path"); path != "" {
+			method := query.Get("method")
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, path, nil)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			candidates = s.resolve(routes, groups, req, candidates)
+		}
+
+		locations := make([]SourceLocation, 0, len(candidates))
+		for _, ep := range candidates {
+			locations = append(locations, sourceLocation(ep))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(locations)
+	}
+}
+
+// resolve returns the candidates that would answer req, looking the route up
+// the way the server does.
+func (s *Server) resolve(routes *http.ServeMux, groups map[string][]*endpoint.EndpointWithFile, req *http.Request, candidates []*endpoint.EndpointWithFile) []*endpoint.EndpointWithFile {
+	var group []*endpoint.EndpointWithFile
+	switch _, pattern := routes.Handler(req); pattern {
+	case "":
+		return nil
+	case "/":
+		group = s.fallbackEndpoints
+	default:
+		group = groups[endpoint.RouteShape(pattern)]
+	}
+
+	var resolved []*endpoint.EndpointWithFile
+	for _, ep := range group {
+		if matchesPath(ep.Schema, req) && slices.Contains(candidates, ep) {
+			resolved = append(resolved, ep)
+		}
+	}
+	return resolved
+}
+
+// matchesPath checks the path constraints of schema, leaving aside the
+// matchers that need the request body.
+func matchesPath(schema *endpoint.EndpointSchema, req *http.Request) bool {
+	for _, m := range schema.Matchers {
+		if pm, ok := m.(*endpoint.PathMatcher); ok && !pm.Match(req, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func sourceLocation(ep *endpoint.EndpointWithFile) SourceLocation {
+	file := ep.FilePath
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+
+	loc := SourceLocation{
+		Route:     ep.Schema.Route,
+		File:      file,
+		Metadata:  ep.Schema.Metadata,
+		Responses: make([]SourceResponse, 0, ep.Schema.CountResponses()),
+	}
+	if ep.Schema.RequestLines != (apimock.LineRange{}) {
+		loc.Request = &SourceLines{Start: ep.Schema.RequestLines.Start, End: ep.Schema.RequestLines.End}
+	}
+	for _, resp := range ep.Schema.SliceResponses() {
+		loc.Responses = append(loc.Responses, SourceResponse{
+			Status:      resp.StatusCode,
+			Title:       resp.Title,
+			ContentType: resp.ContentType,
+			Lines:       SourceLines{Start: resp.Lines.Start, End: resp.Lines.End},
+			Metadata:    resp.Metadata,
+		})
+	}
+	return loc
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/authmock"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/stats"
+)
+
+func writeMock(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestServer_SourceRoute(t *testing.T) {
+	dir := t.TempDir()
+	users := writeMock(t, dir, "users.apimock", `GET /users/{id:[0-9]+}
+
+-- 200: Found
+ContentType: application/json
+
+{"id": 1}
+
+-- 404: Missing
+`)
+	slugs := writeMock(t, dir, "slugs.apimock", `GET /users/{slug:[a-z]+}
+
+-- 200: Found by slug
+`)
+	fallback := writeMock(t, dir, "fallback.apimock", `-- 200: Anything
+`)
+
+	endpoints, err := endpoint.ParseAPIMockFiles(users, slugs, fallback)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	handler := New(endpoints).Handler()
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{name: "all", query: nil, want: []string{users, slugs, fallback}},
+		{name: "by route", query: url.Values{"route": {"GET /users/{slug}"}}, want: []string{slugs}},
+		{name: "by request", query: url.Values{"path": {"/users/42"}}, want: []string{users}},
+		{name: "by request with constraint", query: url.Values{"method": {"GET"}, "path": {"/users/ana"}}, want: []string{slugs}},
+		{name: "by request to fallback", query: url.Values{"path": {"/orders"}}, want: []string{fallback}},
+		{name: "unknown route", query: url.Values{"route": {"GET /orders"}}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/source?"+tt.query.Encode(), nil))
+
+			var locations []SourceLocation
+			if err := json.Unmarshal(rec.Body.Bytes(), &locations); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+			}
+			if len(locations) != len(tt.want) {
+				t.Fatalf("Expected %d locations, got %+v", len(tt.want), locations)
+			}
+			for i, file := range tt.want {
+				if locations[i].File != file {
+					t.Errorf("location %d: expected file %s, got %s", i, file, locations[i].File)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_SourceRouteLines(t *testing.T) {
+	path := writeMock(t, t.TempDir(), "users.apimock", `GET /users/{id}
+X-Meta-Owner: team-accounts
+
+-- 200: Found
+ContentType: application/json
+X-Meta-Reviewed-By: alice
+
+{"id": 1}
+
+-- 404: Missing
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(path)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	New(endpoints).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/source", nil))
+
+	var locations []SourceLocation
+	if err := json.Unmarshal(rec.Body.Bytes(), &locations); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	loc := locations[0]
+	if loc.Route != "GET /users/{id}" {
+		t.Errorf("Expected route GET /users/{id}, got %q", loc.Route)
+	}
+	if loc.Request == nil || *loc.Request != (SourceLines{Start: 1, End: 2}) {
+		t.Errorf("Expected request lines 1-2, got %+v", loc.Request)
+	}
+	if loc.Metadata["X-Meta-Owner"] != "team-accounts" {
+		t.Errorf("Expected the request metadata, got %v", loc.Metadata)
+	}
+	want := []SourceResponse{
+		{Status: 200, Title: "Found", ContentType: "application/json", Lines: SourceLines{Start: 4, End: 8}, Metadata: map[string]string{"X-Meta-Reviewed-By": "alice"}},
+		{Status: 404, Title: "Missing", ContentType: endpoint.DefaultContentType, Lines: SourceLines{Start: 10, End: 10}},
+	}
+	if len(loc.Responses) != len(want) {
+		t.Fatalf("Expected %d responses, got %+v", len(want), loc.Responses)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(loc.Responses[i], want[i]) {
+			t.Errorf("response %d: expected %+v, got %+v", i, want[i], loc.Responses[i])
+		}
+	}
+}
+
+func TestServer_MockAuth(t *testing.T) {
+	provider, err := authmock.New()
+	if err != nil {
+		t.Fatalf("authmock.New() error = %v", err)
+	}
+	token := createEndpointWithFile("POST /token", 200, `{"access_token": "canned"}`)
+	srv := New([]*endpoint.EndpointWithFile{token})
+	srv.MockAuth(provider)
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"keys"`) {
+		t.Errorf("Expected the provider's JWKS, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/token", nil))
+	if rec.Body.String() != `{"access_token": "canned"}` {
+		t.Errorf("Expected the mock to take precedence over the provider, got %q", rec.Body.String())
+	}
+}
+
+func TestServer_StatsRoute(t *testing.T) {
+	endpoints := []*endpoint.EndpointWithFile{createEndpointWithFile("GET /users", 200, `[]`)}
+	srv := New(endpoints)
+	srv.CollectStats(stats.NewCollector(endpoints))
+	handler := srv.Handler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/stats", nil))
+
+	var report stats.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", rec.Body.String(), err)
+	}
+	if report.Requests != 2 || len(report.Windows) != 3 {
+		t.Fatalf("Expected 2 requests over 3 windows, got %+v", report)
+	}
+	if got := report.Windows[0]; got.Window != "1m" || got.Requests != 2 || got.Errors != 1 {
+		t.Errorf("Expected the 1m window to hold both requests and the 404, got %+v", got)
+	}
+}
+
+func TestServer_ErrorsRoute(t *testing.T) {
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("GET /users", 200, `[]`)})
+	srv.ReportBrokenFiles([]*endpoint.FileError{{FilePath: "mocks/orders.apimock", Err: errors.New("line 3: invalid status code")}})
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/errors", nil))
+
+	var broken []BrokenFile
+	if err := json.Unmarshal(rec.Body.Bytes(), &broken); err != nil {
+		t.Fatalf("Expected a JSON list, got %q: %v", rec.Body.String(), err)
+	}
+	want := []BrokenFile{{File: "mocks/orders.apimock", Error: "line 3: invalid status code"}}
+	if !reflect.DeepEqual(broken, want) {
+		t.Errorf("Expected %+v, got %+v", want, broken)
+	}
+}
+
+func TestServer_HistoryRoute(t *testing.T) {
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("POST /users", 201, `{"id": 1}`)})
+	srv.KeepHistory(10)
+	handler := srv.Handler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Ana"}`)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/history?method=POST", nil))
+	var exchanges []history.Exchange
+	if err := json.Unmarshal(rec.Body.Bytes(), &exchanges); err != nil {
+		t.Fatalf("Expected a JSON list, got %q: %v", rec.Body.String(), err)
+	}
+	if len(exchanges) != 1 {
+		t.Fatalf("Expected the POST only, got %+v", exchanges)
+	}
+	got := exchanges[0]
+	if got.Route != "POST /users" || got.Status != 201 || got.ResponseIndex != 0 ||
+		got.RequestBody != `{"name": "Ana"}` || got.ResponseBody != `{"id": 1}` {
+		t.Errorf("Unexpected exchange %+v", got)
+	}
+
+	if found := srv.History().Find(history.Query{Status: http.StatusNotFound}); len(found) != 1 || found[0].Route != "" {
+		t.Errorf("Expected the unmatched request kept with no route, got %+v", found)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/history?limit=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad query, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/_admin/history", nil))
+	if rec.Code != http.StatusNoContent || len(srv.History().Find(history.Query{})) != 0 {
+		t.Errorf("Expected DELETE to clear the history, got %d and %+v", rec.Code, srv.History().Find(history.Query{}))
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
+)
+
+// callbackClient sends the callbacks declared by responses.
+var callbackClient = &http.Client{Timeout: 10 * time.Second}
+
+// scheduleCallback sends cb once its delay has passed. Placeholders are filled
+// right away, from the request that was answered by ep.
+func (s *Server) scheduleCallback(r *http.Request, ep *endpoint.EndpointWithFile, cb *endpoint.Callback, newContext func() *endpoint.TemplateContext) {
+	ctx := newContext()
+	target, urlErr := ctx.Interpolate(cb.URL)
+	body, bodyErr := ctx.Interpolate(cb.Body)
+	if err := errors.Join(urlErr, bodyErr); err != nil {
+		s.evalFailed(r, ep, fmt.Errorf("callback: %w", err))
+	}
+	// The callback belongs to the trace of the request, which has ended by
+	// the time it is sent
+	parent := tracing.FromContext(r.Context())
+
+	time.AfterFunc(cb.Delay, func() {
+		traceCtx, span := tracing.Start(tracing.ContextWith(context.Background(), parent), "callback "+cb.Method, tracing.KindClient)
+		defer span.End()
+		err := sendCallback(traceCtx, cb.Method, target, cb.ContentType, body)
+		span.SetError(err)
+		if err != nil {
+			fmt.Println(i18n.T("Warning: %s: callback failed: %v", ep.Schema.Route, err))
+			s.publishError(r, ep, err)
+		}
+	})
+}
+
+func sendCallback(ctx context.Context, method, target, contentType, body string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid callback %s %s: %w", method, target, err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("callback %s %s: %w", method, target, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback %s %s answered %d", method, target, resp.StatusCode)
+	}
+	return nil
+}
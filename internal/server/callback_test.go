@@ -0,0 +1,54 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_FiresCallback(t *testing.T) {
+	type received struct {
+		method, path, contentType, body string
+	}
+	callbacks := make(chan received, 1)
+	client := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		callbacks <- received{r.Method, r.URL.Path, r.Header.Get("Content-Type"), string(body)}
+	}))
+	defer client.Close()
+
+	ep := createEndpointWithFile("POST /payments", 202, `{"status": "pending"}`)
+	ep.Schema.Responses[202][0].Callback = &endpoint.Callback{
+		Method:      http.MethodPost,
+		URL:         "{{body.callback_url}}/payments",
+		Delay:       10 * time.Millisecond,
+		Body:        `{"id": {{body.id}}, "status": "paid"}`,
+		ContentType: "application/json",
+	}
+	handler := New([]*endpoint.EndpointWithFile{ep}).Handler()
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"id": 7, "callback_url": "`+client.URL+`"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+
+	select {
+	case got := <-callbacks:
+		want := received{http.MethodPost, "/payments", "application/json", `{"id": 7, "status": "paid"}`}
+		if got != want {
+			t.Errorf("Expected callback %+v, got %+v", want, got)
+		}
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Errorf("Expected the callback after its delay, got it after %s", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a callback")
+	}
+}
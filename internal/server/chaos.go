@@ -0,0 +1,126 @@
+package server
+
+import (
+	"cmp"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// ChaosLatency is how long a request hit by the latency fault is held before
+// being answered.
+var ChaosLatency = 30 * time.Second
+
+// fault is a failure chaos mode injects into a response.
+type fault int
+
+const (
+	faultNone        fault = iota
+	faultReset             // drop the connection without answering
+	faultTruncate          // send half of the body, then close the connection
+	faultGarbage           // replace the body with random bytes
+	faultLatency           // answer after ChaosLatency
+	faultServerError       // answer 500, 502, 503 or 504
+	faultCount
+)
+
+var chaosStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// chaos picks the responses to break. Faults are drawn from a seeded source,
+// so a sequence of requests fails the same way from run to run.
+type chaos struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+	rate float64 // fraction of responses broken on endpoints without their own rate
+}
+
+func newChaos(rate float64, seed int64) *chaos {
+	return &chaos{rand: rand.New(rand.NewSource(seed)), rate: rate}
+}
+
+// pick decides whether to break a response of schema, and how. It also
+// returns a source for the random choices of the fault.
+func (c *chaos) pick(schema *endpoint.EndpointSchema) (fault, *rand.Rand) {
+	rate := cmp.Or(schema.Chaos, c.rate)
+	if rate <= 0 {
+		return faultNone, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rand.Float64() >= rate {
+		return faultNone, nil
+	}
+	return fault(1 + c.rand.Intn(int(faultCount)-1)), rand.New(rand.NewSource(c.rand.Int63()))
+}
+
+// injectFault answers a request with f instead of, or on top of, the response
+// produced by write. It returns the status code sent, 0 when the connection
+// was dropped before the status line.
+func injectFault(w http.ResponseWriter, r *http.Request, f fault, rng *rand.Rand, write func(http.ResponseWriter) int) int {
+	switch f {
+	case faultReset:
+		resetConnection(w)
+		return 0
+	case faultServerError:
+		status := chaosStatuses[rng.Intn(len(chaosStatuses))]
+		writeStatus(w, status)
+		return status
+	case faultLatency:
+		select {
+		case <-time.After(ChaosLatency):
+		case <-r.Context().Done():
+			return 0
+		}
+		return write(w)
+	}
+
+	rec := httptest.NewRecorder()
+	status := write(rec)
+	for key, values := range rec.Header() {
+		w.Header()[key] = values
+	}
+	body := rec.Body.Bytes()
+
+	if f == faultGarbage {
+		rng.Read(body)
+		w.WriteHeader(status)
+		w.Write(body)
+		return status
+	}
+
+	// The declared length makes clients notice the body is cut short
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body[:len(body)/2])
+	resetConnection(w)
+	return status
+}
+
+// resetConnection closes the connection of w at once, with a TCP reset when
+// possible. Writers that cannot be hijacked abort the handler instead, which
+// makes the server drop the connection.
+func resetConnection(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
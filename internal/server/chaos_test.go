@@ -0,0 +1,105 @@
+package server
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestChaos_PickIsDeterministic(t *testing.T) {
+	schema := &endpoint.EndpointSchema{}
+	sequence := func(seed int64) []fault {
+		c := newChaos(0.5, seed)
+		faults := make([]fault, 50)
+		for i := range faults {
+			faults[i], _ = c.pick(schema)
+		}
+		return faults
+	}
+
+	first := sequence(42)
+	if !slices.Equal(first, sequence(42)) {
+		t.Error("Expected the same seed to break the same responses")
+	}
+	if !slices.Contains(first, faultNone) || !slices.ContainsFunc(first, func(f fault) bool { return f != faultNone }) {
+		t.Errorf("Expected a mix of broken and intact responses at rate 0.5, got %v", first)
+	}
+
+	if f, _ := newChaos(0, 42).pick(schema); f != faultNone {
+		t.Errorf("Expected no fault without a rate, got %v", f)
+	}
+	if f, _ := newChaos(0, 42).pick(&endpoint.EndpointSchema{Chaos: 1}); f == faultNone {
+		t.Error("Expected the endpoint rate to apply without a server rate")
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	const body = `{"name": "Ana", "email": "ana@example.com"}`
+	write := func(w http.ResponseWriter) int {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, body)
+		return http.StatusOK
+	}
+	serve := func(f fault) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		injectFault(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil), f, rand.New(rand.NewSource(1)), write)
+		return rec
+	}
+
+	if rec := serve(faultServerError); rec.Code < 500 {
+		t.Errorf("Expected a 5xx status, got %d", rec.Code)
+	}
+
+	rec := serve(faultGarbage)
+	if rec.Code != http.StatusOK || rec.Body.Len() != len(body) || rec.Body.String() == body {
+		t.Errorf("Expected a garbled body of the same length, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected the headers to be kept, got Content-Type %q", got)
+	}
+
+	defer func(latency time.Duration) { ChaosLatency = latency }(ChaosLatency)
+	ChaosLatency = 20 * time.Millisecond
+	start := time.Now()
+	if rec := serve(faultLatency); rec.Body.String() != body || time.Since(start) < ChaosLatency {
+		t.Errorf("Expected the response after %s, got %q after %s", ChaosLatency, rec.Body.String(), time.Since(start))
+	}
+}
+
+func TestServer_ChaosBreaksConnections(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault fault
+	}{
+		{name: "reset", fault: faultReset},
+		{name: "truncate", fault: faultTruncate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				injectFault(w, r, tt.fault, rand.New(rand.NewSource(1)), func(w http.ResponseWriter) int {
+					io.WriteString(w, `{"users": ["ana", "bruno", "carla"]}`)
+					return http.StatusOK
+				})
+			}))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL)
+			if err == nil {
+				_, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			if err == nil {
+				t.Error("Expected the client to see a broken response")
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// Comparator evaluates every request against a baseline handler in the
+// background and reports when its response differs from the one served.
+type Comparator struct {
+	baseline http.Handler
+	mu       sync.Mutex
+	out      io.Writer
+	wg       sync.WaitGroup
+}
+
+func NewComparator(baseline http.Handler, out io.Writer) *Comparator {
+	return &Comparator{
+		baseline: baseline,
+		out:      out,
+	}
+}
+
+// Wrap returns a handler that serves requests with next and compares each
+// response with what the baseline would have answered. Admin requests are
+// served without comparison.
+func (c *Comparator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/_admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(r.Body)
+			r.Body.Close()
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		served := response{
+			status:      rec.status,
+			contentType: rec.Header().Get("Content-Type"),
+			body:        rec.body.Bytes(),
+		}
+
+		replay := r.Clone(context.Background())
+		replay.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.compare(replay, served)
+		}()
+	})
+}
+
+// Wait blocks until all pending comparisons have been reported.
+func (c *Comparator) Wait() {
+	c.wg.Wait()
+}
+
+// response is what was served for a request, taken before the handler
+// returns since the writer may not be used afterwards.
+type response struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (c *Comparator) compare(r *http.Request, served response) {
+	baseline := httptest.NewRecorder()
+	c.baseline.ServeHTTP(baseline, r)
+
+	var diffs []string
+	if baseline.Code != served.status {
+		diffs = append(diffs, i18n.T("status %d -> %d", baseline.Code, served.status))
+	}
+
+	oldType := baseline.Header().Get("Content-Type")
+	if oldType != served.contentType {
+		diffs = append(diffs, i18n.T("content type %q -> %q", oldType, served.contentType))
+	}
+
+	if !bytes.Equal(baseline.Body.Bytes(), served.body) {
+		diffs = append(diffs, i18n.T("body differs"))
+	}
+
+	if len(diffs) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(c.out, i18n.T("Compare: %s %s: %s", r.Method, r.URL.RequestURI(), strings.Join(diffs, ", ")))
+}
+
+// recordingWriter passes the response through while keeping a copy of the
+// status code and body for comparison.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush lets the event stream and chaos faults flush through the writer.
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the writer underneath.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
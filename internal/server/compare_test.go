@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestComparator_ReportsDifferences(t *testing.T) {
+	baseline := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("GET /api/users", 200, `{"users": []}`),
+		createEndpointWithFile("GET /api/posts", 200, `{"posts": []}`),
+	})
+	current := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("GET /api/users", 201, `{"users": ["alice"]}`),
+		createEndpointWithFile("GET /api/posts", 200, `{"posts": []}`),
+	})
+
+	var out bytes.Buffer
+	current.CompareWith(baseline, &out)
+	handler := current.Handler()
+
+	for _, path := range []string{"/api/users", "/api/posts"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	current.comparator.Wait()
+
+	report := out.String()
+	if !strings.Contains(report, "GET /api/users: status 200 -> 201, body differs") {
+		t.Errorf("expected users diff to be reported, got %q", report)
+	}
+	if strings.Contains(report, "/api/posts") {
+		t.Errorf("expected no diff for identical posts endpoint, got %q", report)
+	}
+}
+
+func TestComparator_ServesCurrentResponse(t *testing.T) {
+	baseline := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("GET /api/users", 200, `{"old": true}`),
+	})
+	current := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("GET /api/users", 200, `{"new": true}`),
+	})
+
+	var out bytes.Buffer
+	current.CompareWith(baseline, &out)
+
+	rec := httptest.NewRecorder()
+	current.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	current.comparator.Wait()
+
+	if rec.Body.String() != `{"new": true}` {
+		t.Errorf("expected current response to be served, got %q", rec.Body.String())
+	}
+}
+
+func TestComparator_ReplaysRequestBody(t *testing.T) {
+	schema := `{"type": "object", "required": ["name"]}`
+	validator, err := endpoint.NewJsonSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	old := createEndpointWithFile("POST /api/users", 201, `{"created": true}`)
+	old.Schema.Validator = validator
+
+	baseline := New([]*endpoint.EndpointWithFile{old})
+	current := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("POST /api/users", 201, `{"created": true}`),
+	})
+
+	var out bytes.Buffer
+	current.CompareWith(baseline, &out)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"name": "John"}`))
+	current.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	current.comparator.Wait()
+
+	if out.Len() != 0 {
+		t.Errorf("expected baseline to validate the replayed body, got %q", out.String())
+	}
+}
+
+func TestComparator_SkipsAdminAndFlushes(t *testing.T) {
+	var replayed []string
+	baseline := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replayed = append(replayed, r.URL.Path)
+	})
+	var out bytes.Buffer
+	comparator := NewComparator(baseline, &out)
+	handler := comparator.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() error = %v", err)
+		}
+	}))
+
+	for _, path := range []string{"/_admin/events", "/api/users"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if !rec.Flushed {
+			t.Errorf("expected the response to %s to be flushed", path)
+		}
+	}
+	comparator.Wait()
+
+	if len(replayed) != 1 || replayed[0] != "/api/users" {
+		t.Errorf("expected only /api/users to be replayed, got %v", replayed)
+	}
+}
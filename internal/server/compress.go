@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// supportedEncodings lists the content codings the server can produce, in
+// order of preference when the client accepts several with the same weight.
+var supportedEncodings = []string{"br", "gzip", "deflate"}
+
+// negotiateEncoding returns the content coding preferred by an
+// Accept-Encoding header, or "" when the body should be sent as is.
+func negotiateEncoding(acceptEncoding string) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range supportedEncodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// encodeBody compresses body with the given content coding.
+func encodeBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported content coding %q", encoding)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBody writes the status and body of resp. When compress is set and the
+// client accepts a supported content coding, the body is compressed and
+// Content-Encoding is set.
+func writeBody(w http.ResponseWriter, r *http.Request, resp endpoint.Response, compress bool) {
+	body := []byte(resp.Body)
+
+	if compress && len(body) > 0 {
+		if encoding := negotiateEncoding(r.Header.Get("Accept-Encoding")); encoding != "" {
+			if encoded, err := encodeBody(body, encoding); err == nil {
+				body = encoded
+				w.Header().Set("Content-Encoding", encoding)
+				// The encoded bytes differ from the ones a strong ETag stands for
+				if etag := w.Header().Get("ETag"); strings.HasPrefix(etag, `"`) {
+					w.Header().Set("ETag", "W/"+etag)
+				}
+			}
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
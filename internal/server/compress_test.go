@@ -0,0 +1,113 @@
+package server
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate, br", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"GZIP", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, gzip", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"gzip;q=0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_CompressesResponses(t *testing.T) {
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		"br":      func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+
+	ep := createEndpointWithFile("GET /api/users", 200, `{"users": []}`)
+	ep.Schema.Compress = true
+	handler := New([]*endpoint.EndpointWithFile{ep}).Handler()
+
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", encoding, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+			}
+			r, err := decode(w.Body)
+			if err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			body, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if string(body) != `{"users": []}` {
+				t.Errorf("unexpected body %q", body)
+			}
+		})
+	}
+}
+
+func TestServer_CompressionIsOptIn(t *testing.T) {
+	ep := createEndpointWithFile("GET /api/users", 200, `{"users": []}`)
+
+	tests := []struct {
+		name     string
+		enable   bool
+		encoding string
+	}{
+		{name: "disabled", enable: false, encoding: ""},
+		{name: "enabled by flag", enable: true, encoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New([]*endpoint.EndpointWithFile{ep})
+			if tt.enable {
+				srv.EnableCompression()
+			}
+
+			req := httptest.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tt.encoding, got)
+			}
+		})
+	}
+}
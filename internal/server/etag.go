@@ -0,0 +1,60 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// bodyETag returns a strong ETag derived from the content of body, so the same
+// mock body always gets the same tag across restarts.
+func bodyETag(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// writeETag sets the ETag of a successful GET or HEAD response, keeping one
+// declared by the mock, and reports whether the request's If-None-Match
+// already holds it. In that case a 304 Not Modified has been written and the
+// body must not be.
+func writeETag(w http.ResponseWriter, r *http.Request, schema *endpoint.EndpointSchema, resp endpoint.Response) bool {
+	if schema.DisableETag || r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		etag = bodyETag(resp.Body)
+		w.Header().Set("ETag", etag)
+	}
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	// A 304 carries no body, so its content headers would describe nothing
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 requires for that header.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
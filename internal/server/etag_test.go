@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{``, `"abc"`, false},
+		{`"abc"`, `"abc"`, true},
+		{`"xyz"`, `"abc"`, false},
+		{`"xyz", "abc"`, `"abc"`, true},
+		{`*`, `"abc"`, true},
+		{`W/"abc"`, `"abc"`, true},
+		{`"abc"`, `W/"abc"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_ETag(t *testing.T) {
+	ep := createEndpointWithFile("GET /users", 200, `[]`)
+	handler := New([]*endpoint.EndpointWithFile{ep}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	etag := rec.Header().Get("ETag")
+	if etag != bodyETag(`[]`) {
+		t.Fatalf("Expected ETag %s, got %q", bodyETag(`[]`), etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+		wantBody    string
+	}{
+		{name: "matching tag", ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+		{name: "any tag", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale tag", ifNoneMatch: `"stale"`, wantStatus: http.StatusOK, wantBody: `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_ETagOptOutAndDeclared(t *testing.T) {
+	disabled := createEndpointWithFile("GET /disabled", 200, `[]`)
+	disabled.Schema.DisableETag = true
+	declared := createEndpointWithFile("GET /declared", 200, `[]`)
+	declared.Schema.Responses[200][0].Headers = map[string]string{"ETag": `"v1"`}
+	created := createEndpointWithFile("POST /created", 201, `{}`)
+	handler := New([]*endpoint.EndpointWithFile{disabled, declared, created}).Handler()
+
+	tests := []struct {
+		method   string
+		path     string
+		wantETag string
+	}{
+		{method: http.MethodGet, path: "/disabled", wantETag: ""},
+		{method: http.MethodGet, path: "/declared", wantETag: `"v1"`},
+		{method: http.MethodPost, path: "/created", wantETag: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("Expected ETag %q, got %q", tt.wantETag, got)
+			}
+		})
+	}
+}
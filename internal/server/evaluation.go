@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// EvalErrorsHeader is set on responses served although conditions or
+// placeholders failed to evaluate, to the number of failures, so clients
+// and test logs show broken expressions. The failures are published as
+// errors.
+const EvalErrorsHeader = "X-Anansi-Eval-Errors"
+
+// evalErrors collects the errors evaluating the conditions and placeholders
+// of a request.
+type evalErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// evalErrorsKey is the context key of the evalErrors of a request.
+type evalErrorsKey struct{}
+
+// collectEvalErrors returns r collecting the errors evaluating its
+// conditions and placeholders, see evalFailed.
+func collectEvalErrors(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), evalErrorsKey{}, &evalErrors{}))
+}
+
+// evalErrorsOf returns the errors evaluating the conditions and placeholders
+// of r so far.
+func evalErrorsOf(r *http.Request) []error {
+	collected, _ := r.Context().Value(evalErrorsKey{}).(*evalErrors)
+	if collected == nil {
+		return nil
+	}
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	return collected.errs
+}
+
+// evalFailed records err, joining the errors evaluating conditions or
+// placeholders while serving r, and publishes it.
+func (s *Server) evalFailed(r *http.Request, ep *endpoint.EndpointWithFile, err error) {
+	if collected, _ := r.Context().Value(evalErrorsKey{}).(*evalErrors); collected != nil {
+		collected.mu.Lock()
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			collected.errs = append(collected.errs, joined.Unwrap()...)
+		} else {
+			collected.errs = append(collected.errs, err)
+		}
+		collected.mu.Unlock()
+	}
+	s.publishError(r, ep, err)
+}
+
+// EnableStrict answers 500 instead of the response to requests whose
+// conditions or response placeholders fail to evaluate, so broken
+// expressions are noticed rather than skipped or sent as written.
+func (s *Server) EnableStrict() {
+	s.strict = true
+}
+
+// failStrict answers 500 to r when the server is strict and evaluating the
+// conditions or placeholders of r failed, reporting whether it did.
+// Otherwise it sets EvalErrorsHeader when some failed.
+func (s *Server) failStrict(w http.ResponseWriter, r *http.Request) bool {
+	errs := evalErrorsOf(r)
+	if len(errs) == 0 {
+		return false
+	}
+	if !s.strict {
+		w.Header().Set(EvalErrorsHeader, strconv.Itoa(len(errs)))
+		return false
+	}
+	http.Error(w, fmt.Sprintf("Expression evaluation failed: %v", errors.Join(errs...)), http.StatusInternalServerError)
+	return true
+}
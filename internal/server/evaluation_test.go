@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/stats"
+)
+
+func TestServer_EvalErrors(t *testing.T) {
+	dir := t.TempDir()
+	mock := writeMock(t, dir, "items.apimock", `GET /items
+
+-- 200: OK
+X-Page: {{query.page}}
+
+{"size": {{query.size}}}
+
+-- 204: Empty
+
+> query.page > 10
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(mock)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		strict     bool
+		target     string
+		wantCode   int
+		wantHeader string
+		wantErrors int
+	}{
+		{name: "resolved", target: "/items?page=1&size=5", wantCode: http.StatusOK},
+		{name: "condition and placeholders failing", target: "/items", wantCode: http.StatusOK, wantHeader: "3", wantErrors: 3},
+		{name: "strict condition", strict: true, target: "/items", wantCode: http.StatusInternalServerError, wantErrors: 1},
+		{name: "strict placeholder", strict: true, target: "/items?page=1", wantCode: http.StatusInternalServerError, wantErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := stats.NewCollector(endpoints)
+			s := New(endpoints)
+			s.CollectStats(collector)
+			if tt.strict {
+				s.EnableStrict()
+			}
+
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get(EvalErrorsHeader); got != tt.wantHeader {
+				t.Errorf("Expected %s %q, got %q", EvalErrorsHeader, tt.wantHeader, got)
+			}
+			if tt.strict && !strings.Contains(rec.Body.String(), "is not set") {
+				t.Errorf("Expected the 500 to say what failed, got %q", rec.Body.String())
+			}
+			if got := collector.Report().EvalErrors; got != tt.wantErrors {
+				t.Errorf("Expected %d evaluation errors in stats, got %d", tt.wantErrors, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
+)
+
+// served identifies a response sent by an endpoint: its position among the
+// responses of the endpoint and the status code sent.
+type served struct {
+	index  int
+	status int
+}
+
+// lastResponse remembers the response an endpoint served last, for the
+// response_index and previous_status placeholders.
+type lastResponse struct {
+	mu   sync.Mutex
+	last served
+}
+
+func newLastResponse() *lastResponse {
+	return &lastResponse{last: served{index: -1}}
+}
+
+func (l *lastResponse) get() served {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+func (l *lastResponse) set(s served) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.last = s
+}
+
+// historyKey is the context key of the exchange a request adds to the
+// history of its server.
+type historyKey struct{}
+
+// pendingExchange is the exchange of a request being served, filled in as
+// the request goes through the server and added to the history once it is
+// answered. Callbacks failing later do not change it.
+type pendingExchange struct {
+	mu   sync.Mutex
+	ex   history.Exchange
+	hit  bool // an endpoint, or the 404 for no endpoint, answered
+	done bool
+}
+
+// noteExchange changes the pending exchange of r, if the request is kept in
+// a history and not added to it yet.
+func noteExchange(r *http.Request, change func(ex *history.Exchange)) {
+	p, _ := r.Context().Value(historyKey{}).(*pendingExchange)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.done {
+		change(&p.ex)
+	}
+}
+
+// noteHit records that ep, or no endpoint when ep is nil, answered r with
+// status, so that the exchange is added to the history.
+func noteHit(r *http.Request, ep *endpoint.EndpointWithFile, status int) {
+	p, _ := r.Context().Value(historyKey{}).(*pendingExchange)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return
+	}
+	p.hit = true
+	p.ex.Status = status
+	if ep != nil {
+		p.ex.Route, p.ex.File = ep.Schema.Route, ep.FilePath
+	}
+}
+
+// recordHistory returns next adding the requests answered by an endpoint,
+// or by no endpoint, to log. Requests to admin routes are not kept.
+func recordHistory(log *history.Log, next http.Handler) http.Handler {
+	if log == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		p := &pendingExchange{ex: history.Exchange{
+			Time:           start,
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			Query:          r.URL.RawQuery,
+			RequestHeaders: r.Header.Clone(),
+			ResponseIndex:  -1,
+		}}
+		requestBody := &clippedBuffer{}
+		if r.Body != nil {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, requestBody), r.Body}
+		}
+		capture := &captureWriter{ResponseWriter: w}
+
+		next.ServeHTTP(capture, r.WithContext(context.WithValue(r.Context(), historyKey{}, p)))
+
+		p.mu.Lock()
+		p.done = true
+		ex, hit := p.ex, p.hit
+		p.mu.Unlock()
+		if !hit {
+			return
+		}
+		ex.RequestBody, ex.ResponseBody = requestBody.String(), capture.body.String()
+		ex.Truncated = requestBody.clipped || capture.body.clipped
+		ex.ResponseHeaders = w.Header().Clone()
+		ex.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		log.Add(ex)
+	})
+}
+
+// clippedBuffer keeps the first history.MaxBody bytes written to it.
+type clippedBuffer struct {
+	bytes.Buffer
+	clipped bool
+}
+
+func (b *clippedBuffer) Write(p []byte) (int, error) {
+	if room := history.MaxBody - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:max(room, 0)])
+		b.clipped = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// captureWriter copies the body written to a response into a buffer.
+type captureWriter struct {
+	http.ResponseWriter
+	body clippedBuffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush lets the event stream and chaos faults flush through the writer.
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the writer underneath.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// DefaultHistorySize is the number of requests the CLI keeps per route.
+const DefaultHistorySize = 50
+
+// KeepHistory keeps the last size requests answered for each route, with the
+// response sent and the errors met, for History and HistoryRoute.
+func (s *Server) KeepHistory(size int) {
+	s.history = history.New(size)
+}
+
+// History returns the log of the requests served, or nil unless KeepHistory
+// was called.
+func (s *Server) History() *history.Log {
+	return s.history
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// RecordedRequest is a request served by a Server, as seen by response hooks
+// and kept for assertions by tests embedding the server.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	Route  string // route of the endpoint that answered, empty when none matched
+	File   string // file declaring that endpoint
+	Status int
+	Time   time.Time
+}
+
+// ResponseSelection is the response an endpoint chose for a request, as seen
+// by OnResponseSelected hooks before it is written.
+type ResponseSelection struct {
+	Route       string // route of the endpoint
+	File        string // file declaring the endpoint
+	StatusCode  int
+	Description string
+	Index       int // position of the response in the file, from 0
+}
+
+// hooks holds the callbacks registered on a Server and the requests it
+// recorded.
+type hooks struct {
+	mu         sync.Mutex
+	middleware []func(http.Handler) http.Handler
+	onRequest  []func(*http.Request)
+	onResponse []func(RecordedRequest)
+	onSelected []func(*http.Request, ResponseSelection)
+	onError    []func(*http.Request, string, error)
+	onNoMatch  []func(RecordedRequest)
+	record     bool
+	recorded   []RecordedRequest
+}
+
+// bodyKey is the context key of the request body captured for hooks.
+type bodyKey struct{}
+
+// active reports whether requests must be captured for hooks or recording.
+func (h *hooks) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.record || len(h.onRequest)+len(h.onResponse)+len(h.onNoMatch) > 0
+}
+
+// wrap returns a handler capturing the body of each request for the hooks and
+// running the request hooks before next.
+func (h *hooks) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.active() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(r.Body)
+			r.Body.Close()
+		}
+		r = r.WithContext(context.WithValue(r.Context(), bodyKey{}, body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		h.mu.Lock()
+		onRequest := h.onRequest
+		h.mu.Unlock()
+		for _, fn := range onRequest {
+			fn(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// chain returns a handler passing requests through the middleware registered
+// when they arrive, the first registered outermost, before next.
+func (h *hooks) chain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		middleware := h.middleware
+		h.mu.Unlock()
+
+		handler := next
+		for i := len(middleware) - 1; i >= 0; i-- {
+			handler = middleware[i](handler)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// selected runs the OnResponseSelected hooks for resp, chosen by ep.
+func (h *hooks) selected(r *http.Request, ep *endpoint.EndpointWithFile, resp endpoint.Response) {
+	h.mu.Lock()
+	callbacks := h.onSelected
+	h.mu.Unlock()
+	if len(callbacks) == 0 {
+		return
+	}
+
+	selection := ResponseSelection{
+		Route:       ep.Schema.Route,
+		File:        ep.FilePath,
+		StatusCode:  resp.StatusCode,
+		Description: resp.Title,
+		Index:       ep.Schema.ResponseIndex(resp),
+	}
+	for _, fn := range callbacks {
+		fn(r, selection)
+	}
+}
+
+// failed runs the OnError hooks for err, met serving a request to route.
+func (h *hooks) failed(r *http.Request, route string, err error) {
+	h.mu.Lock()
+	callbacks := h.onError
+	h.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(r, route, err)
+	}
+}
+
+// served runs the response or no-match hooks for a request answered by ep,
+// or by no endpoint when ep is nil, and records it.
+func (h *hooks) served(r *http.Request, ep *endpoint.EndpointWithFile, status int) {
+	body, captured := r.Context().Value(bodyKey{}).([]byte)
+	if !captured {
+		return
+	}
+
+	req := RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+		Status: status,
+		Time:   time.Now(),
+	}
+	if ep != nil {
+		req.Route, req.File = ep.Schema.Route, ep.FilePath
+	}
+
+	h.mu.Lock()
+	callbacks := h.onResponse
+	if ep == nil {
+		callbacks = h.onNoMatch
+	}
+	if h.record {
+		h.recorded = append(h.recorded, req)
+	}
+	h.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(req)
+	}
+}
+
+// Use adds middleware around every request the server handles, including
+// those to the admin routes, so embedders can add authentication, tracing or
+// logging, or change requests before the mocks see them. Middleware added
+// first runs first, and middleware added after Handler was called applies
+// to the requests arriving from then on.
+func (s *Server) Use(middleware func(next http.Handler) http.Handler) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.middleware = append(s.hooks.middleware, middleware)
+}
+
+// OnRequest registers fn to be called with every request before it is
+// served. fn may read the body, which is restored for the handler.
+func (s *Server) OnRequest(fn func(r *http.Request)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onRequest = append(s.hooks.onRequest, fn)
+}
+
+// OnResponse registers fn to be called after an endpoint answered a request.
+func (s *Server) OnResponse(fn func(req RecordedRequest)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onResponse = append(s.hooks.onResponse, fn)
+}
+
+// OnResponseSelected registers fn to be called when an endpoint chose the
+// declared response it answers a request with, before writing it. Requests
+// forwarded to an upstream and errors answered without a declared response
+// do not select one.
+func (s *Server) OnResponseSelected(fn func(r *http.Request, selection ResponseSelection)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onSelected = append(s.hooks.onSelected, fn)
+}
+
+// OnError registers fn to be called when a request to the endpoint of route
+// could not be served as declared: it failed validation, exceeded a quota,
+// was unauthorized, or its upstream or callback failed.
+func (s *Server) OnError(fn func(r *http.Request, route string, err error)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onError = append(s.hooks.onError, fn)
+}
+
+// OnNoMatch registers fn to be called after a request no endpoint matched was
+// answered with 404.
+func (s *Server) OnNoMatch(fn func(req RecordedRequest)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onNoMatch = append(s.hooks.onNoMatch, fn)
+}
+
+// RecordRequests keeps every request served from now on, for RequestsMatching.
+// Requests are kept in memory until the server is discarded.
+func (s *Server) RecordRequests() {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.record = true
+}
+
+// RequestsMatching returns the recorded requests accepted by match, in the
+// order they were served. A nil match returns every recorded request.
+func (s *Server) RequestsMatching(match func(req RecordedRequest) bool) []RecordedRequest {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+
+	var matching []RecordedRequest
+	for _, req := range s.hooks.recorded {
+		if match == nil || match(req) {
+			matching = append(matching, req)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_Hooks(t *testing.T) {
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("POST /users", 201, `{}`)})
+
+	var seenBodies []string
+	var responses, noMatches []RecordedRequest
+	srv.OnRequest(func(r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seenBodies = append(seenBodies, string(body))
+	})
+	srv.OnResponse(func(req RecordedRequest) { responses = append(responses, req) })
+	srv.OnNoMatch(func(req RecordedRequest) { noMatches = append(noMatches, req) })
+	handler := srv.Handler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Ana"}`)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if len(seenBodies) != 2 || seenBodies[0] != `{"name": "Ana"}` {
+		t.Errorf("Expected OnRequest to see both requests and their bodies, got %q", seenBodies)
+	}
+	if len(responses) != 1 || responses[0].Route != "POST /users" || responses[0].Status != http.StatusCreated || string(responses[0].Body) != `{"name": "Ana"}` {
+		t.Errorf("Expected one OnResponse call for POST /users, got %+v", responses)
+	}
+	if len(noMatches) != 1 || noMatches[0].Path != "/missing" || noMatches[0].Status != http.StatusNotFound {
+		t.Errorf("Expected one OnNoMatch call for /missing, got %+v", noMatches)
+	}
+}
+
+func TestServer_RequestsMatching(t *testing.T) {
+	srv := New([]*endpoint.EndpointWithFile{
+		createEndpointWithFile("GET /users", 200, `[]`),
+		createEndpointWithFile("POST /users", 201, `{}`),
+	})
+	handler := srv.Handler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	if got := srv.RequestsMatching(nil); len(got) != 0 {
+		t.Fatalf("Expected nothing recorded before RecordRequests, got %+v", got)
+	}
+
+	srv.RecordRequests()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users?page=2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Ana"}`)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Bia"}`)))
+
+	if got := srv.RequestsMatching(nil); len(got) != 3 {
+		t.Errorf("Expected 3 recorded requests, got %d", len(got))
+	}
+	creates := srv.RequestsMatching(func(req RecordedRequest) bool {
+		return req.Method == http.MethodPost && strings.Contains(string(req.Body), "Bia")
+	})
+	if len(creates) != 1 || creates[0].Route != "POST /users" {
+		t.Errorf("Expected the request creating Bia, got %+v", creates)
+	}
+	if pages := srv.RequestsMatching(func(req RecordedRequest) bool { return req.Query.Get("page") == "2" }); len(pages) != 1 {
+		t.Errorf("Expected one request for page 2, got %+v", pages)
+	}
+}
+
+func TestServer_Use(t *testing.T) {
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("GET /users", 200, `[]`)})
+	srv.RecordRequests()
+
+	var order []string
+	srv.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "outer")
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	handler := srv.Handler()
+
+	// Middleware added after the handler was built applies too
+	srv.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "inner")
+			r.Header.Set("X-Trace", "abc")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the middleware to answer 401, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+
+	if strings.Join(order, ",") != "outer,outer,inner" {
+		t.Errorf("Expected middleware to run in the order added, got %v", order)
+	}
+	recorded := srv.RequestsMatching(nil)
+	if len(recorded) != 1 || recorded[0].Header.Get("X-Trace") != "abc" {
+		t.Errorf("Expected the mocks to see the changed request, got %+v", recorded)
+	}
+}
+
+func TestServer_OnResponseSelectedAndOnError(t *testing.T) {
+	ep := createEndpointWithFile("POST /users", 201, `{}`)
+	validator, err := endpoint.NewJsonSchemaValidator(`{"type": "object", "required": ["name"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep.Schema.Validator = validator
+	ep.Schema.Responses[400] = []endpoint.Response{{Title: "Invalid", StatusCode: 400, Body: `{}`}}
+	srv := New([]*endpoint.EndpointWithFile{ep})
+
+	var selections []ResponseSelection
+	var failures []string
+	srv.OnResponseSelected(func(r *http.Request, selection ResponseSelection) { selections = append(selections, selection) })
+	srv.OnError(func(r *http.Request, route string, err error) { failures = append(failures, route) })
+	handler := srv.Handler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Ana"}`)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`)))
+
+	if len(selections) != 2 || selections[0].StatusCode != http.StatusCreated || selections[1].StatusCode != http.StatusBadRequest || selections[1].Description != "Invalid" || selections[1].Route != "POST /users" {
+		t.Errorf("Expected the 201 then the 400 response to be selected, got %+v", selections)
+	}
+	if len(failures) != 1 || failures[0] != "POST /users" {
+		t.Errorf("Expected one OnError call for the invalid body, got %v", failures)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/events"
+)
+
+// DefaultShutdownTimeout is how long in-flight requests are given to finish
+// when a server stops, unless Timeouts says otherwise.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// Timeouts configures the HTTP server running the mocks. Zero read, write and
+// idle timeouts mean no timeout.
+type Timeouts struct {
+	Read     time.Duration
+	Write    time.Duration
+	Idle     time.Duration
+	Shutdown time.Duration // time given to in-flight requests when stopping
+}
+
+// Listen opens the listener a server runs on: the unix domain socket at
+// socket when it is set, and host:port otherwise. An empty host listens on
+// every interface. A socket file left behind by a previous run is replaced.
+func Listen(host string, port int, socket string) (net.Listener, error) {
+	if socket != "" {
+		if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(socket); err != nil {
+				return nil, fmt.Errorf("failed to remove stale socket %s: %w", socket, err)
+			}
+		}
+		ln, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start server on socket %s: %w", socket, err)
+		}
+		return ln, nil
+	}
+
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port number %d: must be between 1 and 65535", port)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start server on %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// ListenAddress describes where ln accepts connections, for startup messages.
+func ListenAddress(ln net.Listener) string {
+	if ln.Addr().Network() == "unix" {
+		return "unix:" + ln.Addr().String()
+	}
+	return ln.Addr().String()
+}
+
+// serveListener serves handler on ln until ctx is cancelled, then stops
+// accepting connections and waits up to t.Shutdown for in-flight requests
+// before closing the remaining connections. Event streams, which never end
+// on their own, are closed when the shutdown starts.
+func serveListener(ctx context.Context, ln net.Listener, handler http.Handler, t Timeouts, streams *events.Bus) error {
+	srv := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  t.Read,
+		WriteTimeout: t.Write,
+		IdleTimeout:  t.Idle,
+	}
+	if streams != nil {
+		srv.RegisterOnShutdown(streams.CloseStreams)
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cmp.Or(t.Shutdown, DefaultShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("requests still running after %s were cut off", cmp.Or(t.Shutdown, DefaultShutdownTimeout))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+)
+
+func TestServeListener_DrainsInFlightRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveListener(ctx, ln, handler, Timeouts{Shutdown: time.Second}, nil) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-responses
+	if res.err != nil || res.body != "done" {
+		t.Errorf("in-flight request = %q, %v; want %q", res.body, res.err, "done")
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serveListener() = %v, want nil", err)
+	}
+	if _, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		t.Error("listener still accepting connections after shutdown")
+	}
+}
+
+func TestServeListener_CutsOffSlowRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveListener(ctx, ln, handler, Timeouts{Shutdown: 50 * time.Millisecond}, nil) }()
+	go http.Get("http://" + ln.Addr().String())
+
+	<-started
+	cancel()
+
+	err = <-served
+	if err == nil || !strings.Contains(err.Error(), "cut off") {
+		t.Errorf("serveListener() = %v, want cut off error", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	ln, err := Listen("127.0.0.1", 0, "")
+	if err == nil {
+		ln.Close()
+		t.Fatal("Listen() should reject port 0")
+	}
+
+	socket := filepath.Join(t.TempDir(), "anansi.sock")
+	for range 2 {
+		// The second round finds the socket file the first one left behind
+		ln, err := Listen("127.0.0.1", 0, socket)
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+		if got := ListenAddress(ln); got != "unix:"+socket {
+			t.Errorf("ListenAddress() = %q, want %q", got, "unix:"+socket)
+		}
+		ln.(*net.UnixListener).SetUnlinkOnClose(false)
+		ln.Close()
+	}
+}
+
+func TestServer_ServeListener_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "anansi.sock")
+	ln, err := Listen("", 0, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("GET /ping", 200, "pong")})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.ServeListener(ctx, ln) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://anansi/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("body = %q, want %q", body, "pong")
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("ServeListener() = %v, want nil", err)
+	}
+}
+
+func TestServer_ServeListener_ClosesEventStreams(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New([]*endpoint.EndpointWithFile{createEndpointWithFile("GET /ping", 200, "pong")})
+	srv.PublishEvents(events.NewBus())
+	srv.SetTimeouts(Timeouts{Shutdown: 5 * time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.ServeListener(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/_admin/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("ServeListener() = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %s, waiting for the event stream", elapsed)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/session"
+)
+
+// NamespaceHeader scopes the state requests change to its value, so
+// parallel test workers sending different values do not see each other's
+// calls. Requests without it share the default namespace. The interactive
+// server ignores it, serving every request from the same counters.
+const NamespaceHeader = "X-Anansi-Session"
+
+// NamespaceIdleTimeout is how long the state of a NamespaceHeader value is
+// kept after its last request. DeleteNamespaceRoute forgets it sooner.
+const NamespaceIdleTimeout = 30 * time.Minute
+
+// namespaceSweepInterval is how often namespaces idle for longer than
+// NamespaceIdleTimeout are looked for.
+const namespaceSweepInterval = time.Minute
+
+// namespace is the state requests change: the calls to each endpoint, which
+// call_count placeholders and Max-Calls count, the response each endpoint
+// served last, the rate limits and the sessions.
+type namespace struct {
+	calls    map[*endpoint.EndpointSchema]*atomic.Int64
+	last     map[*endpoint.EndpointSchema]*lastResponse
+	limiters map[*endpoint.EndpointSchema]*tokenBucket
+	sessions *session.Store
+	used     time.Time // time of the last request, guarded by Server.namespacesMu
+}
+
+func newNamespace(endpoints []*endpoint.EndpointWithFile, frozen bool) *namespace {
+	ns := &namespace{
+		calls:    make(map[*endpoint.EndpointSchema]*atomic.Int64, len(endpoints)),
+		last:     make(map[*endpoint.EndpointSchema]*lastResponse, len(endpoints)),
+		limiters: make(map[*endpoint.EndpointSchema]*tokenBucket),
+		sessions: session.NewStore(),
+	}
+	for _, ep := range endpoints {
+		ns.calls[ep.Schema] = new(atomic.Int64)
+		ns.last[ep.Schema] = newLastResponse()
+		if limit := ep.Schema.RateLimit; limit != nil {
+			ns.limiters[ep.Schema] = newTokenBucket(*limit, time.Now())
+		}
+	}
+	if frozen {
+		ns.sessions.UseSequentialIDs()
+	}
+	return ns
+}
+
+// namespace returns the namespace of r, named by its NamespaceHeader, and
+// creates it on first use. Namespaces other than the default one are
+// forgotten once idle for NamespaceIdleTimeout.
+func (s *Server) namespace(r *http.Request) *namespace {
+	name := r.Header.Get(NamespaceHeader)
+	now := time.Now()
+	s.namespacesMu.Lock()
+	defer s.namespacesMu.Unlock()
+	if now.Sub(s.namespacesSwept) >= namespaceSweepInterval {
+		s.namespacesSwept = now
+		for other, ns := range s.namespaces {
+			if other != "" && now.Sub(ns.used) >= NamespaceIdleTimeout {
+				delete(s.namespaces, other)
+			}
+		}
+	}
+	ns, ok := s.namespaces[name]
+	if !ok {
+		ns = newNamespace(s.endpoints, s.frozen)
+		s.namespaces[name] = ns
+	}
+	ns.used = now
+	return ns
+}
+
+// deleteNamespaceHandler forgets the namespace named by the request, so
+// the next requests sending its NamespaceHeader start over. It answers 404
+// for namespaces never used or already forgotten.
+func (s *Server) deleteNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.namespacesMu.Lock()
+	_, ok := s.namespaces[name]
+	delete(s.namespaces, name)
+	s.namespacesMu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown session %q", name), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
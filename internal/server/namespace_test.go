@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_Namespaces(t *testing.T) {
+	search := createEndpointWithFile("GET /search", 200, `[]`)
+	search.Schema.MaxCalls = 1
+	search.Schema.Responses[200][0].Headers = map[string]string{"X-Calls": "{{call_count}}", "X-Previous": "{{previous_status}}"}
+	login := createEndpointWithFile("POST /login", 200, `{}`)
+	login.Schema.Session = endpoint.SessionCreate
+	srv := New([]*endpoint.EndpointWithFile{search, login})
+	srv.FreezeRandom()
+	handler := srv.Handler()
+
+	get := func(ns string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		if ns != "" {
+			req.Header.Set(NamespaceHeader, ns)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(""); rec.Code != http.StatusOK || rec.Header().Get("X-Calls") != "1" {
+		t.Fatalf("Expected the first call served, got %d with %q calls", rec.Code, rec.Header().Get("X-Calls"))
+	}
+	if rec := get(""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the default namespace to run out of calls, got %d", rec.Code)
+	}
+	for _, ns := range []string{"worker-1", "worker-2"} {
+		rec := get(ns)
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Calls") != "1" || rec.Header().Get("X-Previous") != "0" {
+			t.Errorf("Expected %s to start over, got %d with headers %v", ns, rec.Code, rec.Header())
+		}
+	}
+	if rec := get("worker-1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected worker-1 to run out of calls, got %d", rec.Code)
+	}
+
+	// Sequential session IDs start over too, so workers get the same ones
+	for _, ns := range []string{"worker-1", "worker-2"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.Header.Set(NamespaceHeader, ns)
+		handler.ServeHTTP(rec, req)
+		if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "session-1" {
+			t.Errorf("Expected %s to get session-1, got %v", ns, cookies)
+		}
+	}
+}
+
+func TestServer_ForgetNamespaces(t *testing.T) {
+	search := createEndpointWithFile("GET /search", 200, `[]`)
+	search.Schema.Responses[200][0].Headers = map[string]string{"X-Calls": "{{call_count}}"}
+	srv := New([]*endpoint.EndpointWithFile{search})
+	handler := srv.Handler()
+
+	calls := func(ns string) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.Header.Set(NamespaceHeader, ns)
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("X-Calls")
+	}
+	forget := func(ns string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/_admin/sessions/"+ns, nil))
+		return rec.Code
+	}
+
+	calls("worker-1")
+	if got := forget("worker-1"); got != http.StatusNoContent {
+		t.Errorf("Expected worker-1 to be forgotten, got %d", got)
+	}
+	if got := calls("worker-1"); got != "1" {
+		t.Errorf("Expected worker-1 to start over, got %s calls", got)
+	}
+	if got := forget("worker-9"); got != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown namespace, got %d", got)
+	}
+
+	// Namespaces idle for too long are forgotten on the next sweep
+	calls("worker-2")
+	srv.namespacesMu.Lock()
+	srv.namespaces["worker-2"].used = time.Now().Add(-NamespaceIdleTimeout)
+	srv.namespacesSwept = time.Time{}
+	srv.namespacesMu.Unlock()
+	if got := calls("worker-1"); got != "2" {
+		t.Errorf("Expected worker-1 to be kept, got %s calls", got)
+	}
+	if got := calls("worker-2"); got != "1" {
+		t.Errorf("Expected idle worker-2 to start over, got %s calls", got)
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// DefaultResponseHeader is the request header naming the response to serve,
+// such as `X-Anansi-Response: 404`, unless SetResponseHeader changes it.
+const DefaultResponseHeader = "X-Anansi-Response"
+
+// overrideResponse returns the response of schema named by the header of r,
+// with ok set, or ok unset when r does not send header. The header names a
+// status code, as 404, or the title of a response, as Not Found; a status
+// code declared several times is negotiated as usual, under profile. It
+// fails when no response of schema is named so.
+func overrideResponse(r *http.Request, header string, schema *endpoint.EndpointSchema, profile string) (resp endpoint.Response, ok bool, err error) {
+	if header == "" {
+		return resp, false, nil
+	}
+	value := strings.TrimSpace(r.Header.Get(header))
+	if value == "" {
+		return resp, false, nil
+	}
+
+	if code, err := strconv.Atoi(value); err == nil {
+		if resp, declared := schema.NegotiateProfileResponse(profile, code, r.Header.Get("Accept")); declared {
+			return resp, true, nil
+		}
+		// Responses of another profile can still be asked for
+		if responses := schema.Responses[code]; len(responses) > 0 {
+			return responses[0], true, nil
+		}
+	} else {
+		for _, resp := range schema.SliceResponses() {
+			if strings.EqualFold(resp.Title, value) {
+				return resp, true, nil
+			}
+		}
+	}
+	return resp, false, fmt.Errorf("%s: %s declares no response %q", header, schema.Route, value)
+}
+
+// SetResponseHeader changes the request header naming the response to serve,
+// DefaultResponseHeader by default. An empty name turns the override off.
+func (s *Server) SetResponseHeader(name string) {
+	s.responseHeader = name
+}
+
+// override returns the response the request asks ep for with the response
+// header, if any. A header naming no response is reported and ignored.
+func (s *Server) override(r *http.Request, ep *endpoint.EndpointWithFile) (endpoint.Response, bool) {
+	resp, ok, err := overrideResponse(r, s.responseHeader, ep.Schema, s.Profile())
+	if err != nil {
+		s.publishError(r, ep, err)
+	}
+	return resp, ok
+}
+
+// SetResponseHeader changes the request header naming the response to serve
+// instead of the selected one. See Server.SetResponseHeader.
+func (s *InteractiveServer) SetResponseHeader(name string) {
+	s.responseHeader = name
+}
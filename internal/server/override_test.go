@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestServer_ResponseHeader(t *testing.T) {
+	mock := writeMock(t, t.TempDir(), "users.apimock", `POST /users
+Accept: application/json
+
+{"type": "object", "required": ["name"]}
+
+-- 201: Created
+ContentType: text/plain
+
+created
+
+-- 409: Conflict
+ContentType: text/plain
+
+taken
+
+-- 503: Down
+ContentType: text/plain
+Profile: outage
+
+down
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(mock)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	srv := New(endpoints)
+	srv.KeepHistory(10)
+	handler := srv.Handler()
+
+	post := func(header, value string) (int, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Ann"}`))
+		if value != "" {
+			req.Header.Set(header, value)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	tests := []struct {
+		header, value string
+		want          int
+	}{
+		{DefaultResponseHeader, "", 201},
+		{DefaultResponseHeader, "409", 409},
+		{"x-anansi-response", "conflict", 409},
+		{DefaultResponseHeader, "503", 503},
+		{DefaultResponseHeader, "418", 201},
+	}
+	for _, tt := range tests {
+		if status, _ := post(tt.header, tt.value); status != tt.want {
+			t.Errorf("POST with %s: %q = %d, want %d", tt.header, tt.value, status, tt.want)
+		}
+	}
+	failed := srv.History().Find(history.Query{Failed: true})
+	if len(failed) != 1 || !strings.Contains(failed[0].Errors[0], `no response "418"`) {
+		t.Errorf("Expected the unknown response reported, got %+v", failed)
+	}
+
+	// The checks of the endpoint are skipped
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`not json`))
+	req.Header.Set(DefaultResponseHeader, "201")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected the named response despite the invalid body, got %d", rec.Code)
+	}
+
+	srv.SetResponseHeader("")
+	if status, _ := post(DefaultResponseHeader, "409"); status != http.StatusCreated {
+		t.Errorf("Expected the header ignored once turned off, got %d", status)
+	}
+}
+
+func TestInteractiveServer_ResponseHeader(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	users.Schema.Responses[404] = []endpoint.Response{{Title: "Missing", StatusCode: 404}}
+	srv := NewInteractiveSet(state.NewSelection(users.Schema.CountResponses()), []*endpoint.EndpointWithFile{users})
+	srv.SetResponseHeader("X-Pick")
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-Pick", "404")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the response named by the header, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the selected response without the header, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// UpstreamProbe tells what happens at startup to the proxy sections whose
+// upstream cannot be reached, see ProbeUpstreams.
+type UpstreamProbe string
+
+const (
+	// UpstreamProbeOff does not probe the upstreams.
+	UpstreamProbeOff UpstreamProbe = ""
+	// UpstreamProbeWarn prints a warning and forwards the requests anyway.
+	UpstreamProbeWarn UpstreamProbe = "warn"
+	// UpstreamProbeFail refuses to start.
+	UpstreamProbeFail UpstreamProbe = "fail"
+	// UpstreamProbeMock answers the requests of the proxy sections with the
+	// mocks of their endpoints, see MockUpstreams.
+	UpstreamProbeMock UpstreamProbe = "mock"
+)
+
+// ParseUpstreamProbe parses the name of an upstream probe mode.
+func ParseUpstreamProbe(mode string) (UpstreamProbe, error) {
+	switch UpstreamProbe(mode) {
+	case UpstreamProbeOff, UpstreamProbeWarn, UpstreamProbeFail, UpstreamProbeMock:
+		return UpstreamProbe(mode), nil
+	}
+	return "", fmt.Errorf("unknown upstream probe mode %q: expected %s, %s or %s", mode, UpstreamProbeWarn, UpstreamProbeFail, UpstreamProbeMock)
+}
+
+// ProbeUpstreams sends a HEAD request to the upstream of each proxy section
+// of endpoints, once per URL, and returns the errors of the upstreams that
+// could not be reached within timeout (0 for no limit), by URL. A reply of
+// any status counts as reachable.
+func ProbeUpstreams(ctx context.Context, endpoints []*endpoint.EndpointWithFile, timeout time.Duration) map[string]error {
+	client := &http.Client{Timeout: timeout}
+	unreachable := make(map[string]error)
+	probed := make(map[string]bool)
+	for _, ep := range endpoints {
+		if ep.Schema.Upstream == nil {
+			continue
+		}
+		url := ep.Schema.Upstream.URL.String()
+		if probed[url] {
+			continue
+		}
+		probed[url] = true
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err == nil {
+			var resp *http.Response
+			if resp, err = client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		if err != nil {
+			unreachable[url] = err
+		}
+	}
+	return unreachable
+}
+
+// MockUpstreams answers the requests of the proxy sections forwarding to the
+// given upstream URLs with the responses their endpoints declare, as if they
+// had no proxy section. Endpoints without responses of their own still
+// forward.
+func (s *Server) MockUpstreams(urls ...string) {
+	if s.mockedUpstreams == nil {
+		s.mockedUpstreams = make(map[string]bool)
+	}
+	for _, url := range urls {
+		s.mockedUpstreams[url] = true
+	}
+}
+
+// forwards reports whether the requests to schema are forwarded to its
+// upstream, rather than answered by its mocks.
+func (s *Server) forwards(schema *endpoint.EndpointSchema) bool {
+	if schema.Upstream == nil {
+		return false
+	}
+	return !s.mockedUpstreams[schema.Upstream.URL.String()] || !hasMock(schema)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestParseUpstreamProbe(t *testing.T) {
+	for _, mode := range []string{"", "warn", "fail", "mock"} {
+		if got, err := ParseUpstreamProbe(mode); err != nil || string(got) != mode {
+			t.Errorf("ParseUpstreamProbe(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseUpstreamProbe("retry"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestProbeUpstreams(t *testing.T) {
+	probes := 0
+	upstreamSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer upstreamSrv.Close()
+	up, _ := url.Parse(upstreamSrv.URL)
+	downSrv := httptest.NewServer(http.NotFoundHandler())
+	down, _ := url.Parse(downSrv.URL)
+	downSrv.Close()
+
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	users.Schema.Upstream = &endpoint.Upstream{URL: up}
+	orders := createEndpointWithFile("GET /orders", 200, `[]`)
+	orders.Schema.Upstream = &endpoint.Upstream{URL: up}
+	payments := createEndpointWithFile("GET /payments", 200, `{"mock": true}`)
+	payments.Schema.Upstream = &endpoint.Upstream{URL: down}
+	mocked := createEndpointWithFile("GET /health", 200, `{}`)
+	endpoints := []*endpoint.EndpointWithFile{users, orders, payments, mocked}
+
+	unreachable := ProbeUpstreams(context.Background(), endpoints, time.Second)
+	if len(unreachable) != 1 || unreachable[down.String()] == nil {
+		t.Errorf("expected only %s unreachable, got %v", down, unreachable)
+	}
+	if probes != 1 {
+		t.Errorf("expected a probe per upstream, got %d", probes)
+	}
+
+	srv := New(endpoints)
+	srv.MockUpstreams(down.String())
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payments", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"mock": true}` {
+		t.Errorf("expected the mock of an unreachable upstream, got %d %q", rec.Code, rec.Body.String())
+	}
+}
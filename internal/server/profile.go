@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// SetProfile makes the responses of profile take the place of the responses
+// without a profile, for the endpoints and status codes the profile declares.
+// An empty profile serves the responses without a profile again. It fails
+// when no endpoint declares profile.
+func (s *Server) SetProfile(profile string) error {
+	if profile != "" && !slices.Contains(s.Profiles(), profile) {
+		return fmt.Errorf("unknown profile %q", profile)
+	}
+	s.profile.Store(&profile)
+	return nil
+}
+
+// Profile returns the active response profile, empty when none is.
+func (s *Server) Profile() string {
+	if profile := s.profile.Load(); profile != nil {
+		return *profile
+	}
+	return ""
+}
+
+// Profiles returns the response profiles declared by the endpoints, sorted.
+func (s *Server) Profiles() []string {
+	var profiles []string
+	for _, ep := range s.endpoints {
+		for _, profile := range ep.Schema.Profiles() {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// ProfileState is served at ProfileRoute.
+type ProfileState struct {
+	Profile  string   `json:"profile"`
+	Profiles []string `json:"profiles"`
+}
+
+// profileHandler writes the active profile and the declared ones.
+func (s *Server) profileHandler(w http.ResponseWriter, r *http.Request) {
+	state := ProfileState{Profile: s.Profile(), Profiles: s.Profiles()}
+	if state.Profiles == nil {
+		state.Profiles = []string{}
+	}
+	w.Header().Set(endpoint.ContentTypeHeader, "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// setProfileHandler switches to the profile of a {"profile": "outage"} body
+// and answers like profileHandler.
+func (s *Server) setProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Profile string `json:"profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid profile request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := s.SetProfile(req.Profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.profileHandler(w, r)
+}
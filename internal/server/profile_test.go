@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_Profile(t *testing.T) {
+	mock := writeMock(t, t.TempDir(), "users.apimock", `GET /users
+
+-- 200: OK
+ContentType: text/plain
+
+healthy
+
+-- 200: Slow
+ContentType: text/plain
+Profile: degraded
+
+slow
+
+-- 503: Down
+ContentType: text/plain
+Profile: outage, degraded
+
+down
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(mock)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	srv := New(endpoints)
+	handler := srv.Handler()
+
+	get := func() (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+	setProfile := func(profile string) int {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"profile": "` + profile + `"}`)
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/_admin/profile", body))
+		return rec.Code
+	}
+
+	if status, body := get(); status != http.StatusOK || body != "healthy" {
+		t.Errorf("Expected the base response, got %d %q", status, body)
+	}
+
+	if code := setProfile("outage"); code != http.StatusOK {
+		t.Fatalf("Expected switching to outage to succeed, got %d", code)
+	}
+	if status, body := get(); status != http.StatusServiceUnavailable || body != "down" {
+		t.Errorf("Expected the outage response, got %d %q", status, body)
+	}
+
+	if code := setProfile("degraded"); code != http.StatusOK {
+		t.Fatalf("Expected switching to degraded to succeed, got %d", code)
+	}
+	if status, body := get(); status != http.StatusOK || body != "slow" {
+		t.Errorf("Expected the degraded 200 response, got %d %q", status, body)
+	}
+
+	if code := setProfile("missing"); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown profile to be rejected, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_admin/profile", nil))
+	var state ProfileState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Expected a JSON state, got %q: %v", rec.Body.String(), err)
+	}
+	want := ProfileState{Profile: "degraded", Profiles: []string{"degraded", "outage"}}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("Expected %+v, got %+v", want, state)
+	}
+
+	if code := setProfile(""); code != http.StatusOK {
+		t.Fatalf("Expected resetting the profile to succeed, got %d", code)
+	}
+	if status, body := get(); status != http.StatusOK || body != "healthy" {
+		t.Errorf("Expected the base response again, got %d %q", status, body)
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/session"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
+)
+
+// forward answers a request to ep with the reply of its upstream, rewritten
+// by the rules of its proxy section, and returns the status code sent. body
+// is the request body, already read by the handler. When the upstream cannot
+// be reached the declared 502 response is served, or a plain 502. With
+// fallback to mock enabled, replies failing with 5xx and unreachable
+// upstreams are answered by the mocked response instead, see
+// EnableFallbackToMock.
+func (s *Server) forward(w http.ResponseWriter, r *http.Request, ep *endpoint.EndpointWithFile, body []byte, calls int64, sess *session.Session) int {
+	upstream := ep.Schema.Upstream
+	newContext := s.templateContext(r, ep, body, int(calls), sess, s.namespace(r).last[ep.Schema].get())
+	ctx, span := tracing.Start(r.Context(), "forward "+upstream.URL.Host, tracing.KindClient)
+	defer span.End()
+
+	status := 0
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream.URL)
+			pr.SetXForwarded()
+			tracing.Inject(ctx, pr.Out.Header)
+			if len(upstream.Rewrites) > 0 {
+				// Rules apply to the plain body
+				pr.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if s.fallback != nil && resp.StatusCode >= http.StatusInternalServerError && hasMock(ep.Schema) {
+				return fmt.Errorf("upstream %s answered %s", upstream.URL.Host, resp.Status)
+			}
+			status = resp.StatusCode
+			if err := writeResponseHeaders(resp.Header, upstream.Headers, newContext); err != nil {
+				s.evalFailed(r, ep, err)
+				resp.Header.Set(EvalErrorsHeader, strconv.Itoa(len(evalErrorsOf(r))))
+			}
+			if len(upstream.Rewrites) == 0 {
+				return nil
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			data = upstream.Rewrite(data)
+			resp.Body = io.NopCloser(bytes.NewReader(data))
+			resp.ContentLength = int64(len(data))
+			resp.Header.Set("Content-Length", strconv.Itoa(len(data)))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			span.SetError(err)
+			s.publishError(r, ep, err)
+			if s.fallback != nil && hasMock(ep.Schema) {
+				w.Header().Set(FallbackHeader, "true")
+				status = s.respond(w, r, ep, s.defaultResponse(ep.Schema, r.Header.Get("Accept")), body, calls, sess)
+				return
+			}
+			if declared, ok := s.negotiate(ep.Schema, http.StatusBadGateway, r.Header.Get("Accept")); ok {
+				status = s.respond(w, r, ep, declared, body, calls, sess)
+				return
+			}
+			status = http.StatusBadGateway
+			writeStatus(w, status)
+		},
+	}
+
+	if s.fallback != nil {
+		proxy.Transport = s.fallback
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	proxy.ServeHTTP(w, r)
+	span.SetAttribute("http.response.status_code", status)
+	return status
+}
+
+// DefaultUpstreamTimeout is the time given to upstreams to send their reply
+// headers before the mock answers, with fallback to mock enabled.
+const DefaultUpstreamTimeout = 10 * time.Second
+
+// FallbackHeader is set on the mocked responses served in place of failed
+// upstream replies, see EnableFallbackToMock.
+const FallbackHeader = "X-Anansi-Fallback"
+
+// EnableFallbackToMock answers the requests of proxy sections with the
+// response the endpoint serves without its proxy section when the upstream
+// cannot be reached, answers 5xx, or takes longer than timeout (0 for no
+// limit) to send its reply headers. This keeps frontends working while a
+// staging server is down. Endpoints without responses of their own keep
+// the usual 502.
+func (s *Server) EnableFallbackToMock(timeout time.Duration) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	s.fallback = transport
+}
+
+// hasMock reports whether schema declares responses besides its proxy
+// section.
+func hasMock(schema *endpoint.EndpointSchema) bool {
+	return len(schema.Responses) > 0
+}
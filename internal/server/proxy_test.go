@@ -0,0 +1,148 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_ForwardsToUpstream(t *testing.T) {
+	var gotPath, gotBody string
+	upstreamSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.RequestURI(), string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"env": "sandbox", "id": 7}`)
+	}))
+	defer upstreamSrv.Close()
+	base, _ := url.Parse(upstreamSrv.URL + "/v1")
+
+	payments := createEndpointWithFile("POST /payments", 200, `{}`)
+	payments.Schema.Upstream = &endpoint.Upstream{
+		URL:      base,
+		Headers:  map[string]string{"X-Mocked": "false", "X-Calls": "{{call_count}}"},
+		Rewrites: []endpoint.Rewrite{{Old: "sandbox", New: "mock"}},
+	}
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	handler := New([]*endpoint.EndpointWithFile{payments, users}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payments?dry=1", strings.NewReader(`{"amount": 10}`)))
+
+	if gotPath != "/v1/payments?dry=1" || gotBody != `{"amount": 10}` {
+		t.Errorf("Expected the request forwarded to /v1/payments?dry=1, got %q with body %q", gotPath, gotBody)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"env": "mock", "id": 7}` {
+		t.Errorf("Expected the rewritten upstream reply, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Mocked") != "false" || rec.Header().Get("X-Calls") != "1" {
+		t.Errorf("Expected the declared headers on the reply, got %v", rec.Header())
+	}
+	if got := rec.Header().Get("Content-Length"); got != "24" {
+		t.Errorf("Expected Content-Length of the rewritten body, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Body.String() != `[]` {
+		t.Errorf("Expected other endpoints to stay mocked, got %q", rec.Body.String())
+	}
+}
+
+func TestServer_UpstreamDown(t *testing.T) {
+	upstreamSrv := httptest.NewServer(http.NotFoundHandler())
+	base, _ := url.Parse(upstreamSrv.URL)
+	upstreamSrv.Close()
+
+	plain := createEndpointWithFile("GET /plain", 200, `{}`)
+	plain.Schema.Upstream = &endpoint.Upstream{URL: base}
+	declared := createEndpointWithFile("GET /declared", 200, `{}`)
+	declared.Schema.Upstream = &endpoint.Upstream{URL: base}
+	declared.Schema.Responses[502] = []endpoint.Response{{Title: "Down", Body: `{"error": "down"}`, StatusCode: 502}}
+	handler := New([]*endpoint.EndpointWithFile{plain, declared}).Handler()
+
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{path: "/plain", wantBody: "502 - Bad Gateway"},
+		{path: "/declared", wantBody: `{"error": "down"}`},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusBadGateway || rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected 502 %q, got %d %q", tt.path, tt.wantBody, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestServer_FallbackToMock(t *testing.T) {
+	release := make(chan struct{})
+	upstreamSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/failing":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			<-release
+		default:
+			io.WriteString(w, `{"upstream": true}`)
+		}
+	}))
+	defer upstreamSrv.Close()
+	defer close(release)
+	up, _ := url.Parse(upstreamSrv.URL)
+	downSrv := httptest.NewServer(http.NotFoundHandler())
+	down, _ := url.Parse(downSrv.URL)
+	downSrv.Close()
+
+	var endpoints []*endpoint.EndpointWithFile
+	for _, route := range []string{"GET /healthy", "GET /failing", "GET /slow", "GET /down"} {
+		ep := createEndpointWithFile(route, 200, `{"mock": true}`)
+		ep.Schema.Upstream = &endpoint.Upstream{URL: up}
+		if route == "GET /down" {
+			ep.Schema.Upstream.URL = down
+		}
+		endpoints = append(endpoints, ep)
+	}
+	proxyOnly := createEndpointWithFile("GET /proxy-only", 200, `{}`)
+	proxyOnly.Schema.Responses = map[int][]endpoint.Response{}
+	proxyOnly.Schema.Upstream = &endpoint.Upstream{URL: down}
+	endpoints = append(endpoints, proxyOnly)
+
+	srv := New(endpoints)
+	srv.EnableFallbackToMock(100 * time.Millisecond)
+	handler := srv.Handler()
+
+	tests := []struct {
+		path         string
+		wantStatus   int
+		wantBody     string
+		wantFallback bool
+	}{
+		{path: "/healthy", wantStatus: http.StatusOK, wantBody: `{"upstream": true}`},
+		{path: "/failing", wantStatus: http.StatusOK, wantBody: `{"mock": true}`, wantFallback: true},
+		{path: "/slow", wantStatus: http.StatusOK, wantBody: `{"mock": true}`, wantFallback: true},
+		{path: "/down", wantStatus: http.StatusOK, wantBody: `{"mock": true}`, wantFallback: true},
+		{path: "/proxy-only", wantStatus: http.StatusBadGateway, wantBody: "502 - Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus || rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.wantStatus, tt.wantBody, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get(FallbackHeader) == "true"; got != tt.wantFallback {
+			t.Errorf("%s: expected %s set = %v, got %v", tt.path, FallbackHeader, tt.wantFallback, got)
+		}
+	}
+}
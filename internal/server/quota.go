@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// readBody reads the body of r. When schema caps the body size, reading stops
+// one byte past the cap, which is enough to tell the cap was exceeded.
+func readBody(r *http.Request, schema *endpoint.EndpointSchema) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if schema.MaxBodySize > 0 {
+		reader = io.LimitReader(r.Body, schema.MaxBodySize+1)
+	}
+	return io.ReadAll(reader)
+}
+
+// quotaExceeded returns the status code answering a request that exceeds a
+// quota of schema, and why. calls is the number of calls to the endpoint,
+// including the current one.
+func quotaExceeded(schema *endpoint.EndpointSchema, body []byte, calls int64) (int, error) {
+	switch {
+	case schema.MaxBodySize > 0 && int64(len(body)) > schema.MaxBodySize:
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", schema.MaxBodySize)
+	case schema.MaxCalls > 0 && calls > int64(schema.MaxCalls):
+		return http.StatusTooManyRequests, fmt.Errorf("endpoint called more than %d times", schema.MaxCalls)
+	}
+	return 0, nil
+}
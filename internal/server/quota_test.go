@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_MaxCalls(t *testing.T) {
+	limited := createEndpointWithFile("GET /search", 200, `[]`)
+	limited.Schema.MaxCalls = 2
+	declared := createEndpointWithFile("GET /reports", 200, `[]`)
+	declared.Schema.MaxCalls = 1
+	declared.Schema.Responses[429] = []endpoint.Response{{Title: "Plan limit", Body: `{"error": "upgrade"}`, ContentType: "application/json", StatusCode: 429}}
+	handler := New([]*endpoint.EndpointWithFile{limited, declared}).Handler()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/search", wantCode: 200, wantBody: `[]`},
+		{path: "/search", wantCode: 200, wantBody: `[]`},
+		{path: "/search", wantCode: 429, wantBody: "429 - Too Many Requests"},
+		{path: "/reports", wantCode: 200, wantBody: `[]`},
+		{path: "/reports", wantCode: 429, wantBody: `{"error": "upgrade"}`},
+	}
+
+	for i, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantCode || rec.Body.String() != tt.wantBody {
+			t.Errorf("request %d to %s: expected %d %q, got %d %q", i, tt.path, tt.wantCode, tt.wantBody, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestServer_MaxBodySize(t *testing.T) {
+	upload := createEndpointWithFile("POST /upload", 201, `{}`)
+	upload.Schema.MaxBodySize = 8
+	fallback := createEndpointWithFile("/", 200, `{}`)
+	fallback.Schema.MaxBodySize = 4
+	handler := New([]*endpoint.EndpointWithFile{upload, fallback}).Handler()
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{name: "within limit", path: "/upload", body: "12345678", wantCode: http.StatusCreated},
+		{name: "over limit", path: "/upload", body: "123456789", wantCode: http.StatusRequestEntityTooLarge},
+		{name: "fallback within limit", path: "/other", body: "1234", wantCode: http.StatusOK},
+		{name: "fallback over limit", path: "/other", body: "12345", wantCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// tokenBucket enforces an endpoint.RateLimit. It starts full and refills one
+// token every Period/Requests.
+type tokenBucket struct {
+	mu       sync.Mutex
+	limit    endpoint.RateLimit
+	tokens   float64
+	refilled time.Time
+}
+
+func newTokenBucket(limit endpoint.RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: float64(limit.Requests), refilled: now}
+}
+
+// take spends a token at now. When the bucket is empty it returns false and
+// how long until the next token.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	interval := b.limit.Period / time.Duration(b.limit.Requests)
+	if elapsed := now.Sub(b.refilled); elapsed > 0 {
+		b.tokens = min(float64(b.limit.Requests), b.tokens+float64(elapsed)/float64(interval))
+		b.refilled = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) * float64(interval))
+}
+
+// rateLimited answers whether a request r to schema exceeds its rate limit,
+// counted in the namespace of r. If so, it sets the Retry-After header of w
+// and returns 429 and why.
+func (s *Server) rateLimited(w http.ResponseWriter, r *http.Request, schema *endpoint.EndpointSchema) (int, error) {
+	bucket, ok := s.namespace(r).limiters[schema]
+	if !ok {
+		return 0, nil
+	}
+	allowed, retryAfter := bucket.take(time.Now())
+	if allowed {
+		return 0, nil
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return http.StatusTooManyRequests, fmt.Errorf("endpoint rate limit of %d requests per %s exceeded", schema.RateLimit.Requests, schema.RateLimit.Period)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(endpoint.RateLimit{Requests: 2, Period: time.Minute}, start)
+
+	tests := []struct {
+		at             time.Duration
+		wantAllowed    bool
+		wantRetryAfter time.Duration
+	}{
+		{at: 0, wantAllowed: true},
+		{at: 0, wantAllowed: true},
+		{at: 0, wantAllowed: false, wantRetryAfter: 30 * time.Second},
+		{at: 20 * time.Second, wantAllowed: false, wantRetryAfter: 10 * time.Second},
+		{at: 30 * time.Second, wantAllowed: true},
+		{at: 5 * time.Minute, wantAllowed: true},
+		{at: 5 * time.Minute, wantAllowed: true},
+		{at: 5 * time.Minute, wantAllowed: false, wantRetryAfter: 30 * time.Second},
+	}
+
+	for i, tt := range tests {
+		allowed, retryAfter := bucket.take(start.Add(tt.at))
+		if allowed != tt.wantAllowed || retryAfter.Round(time.Millisecond) != tt.wantRetryAfter {
+			t.Errorf("take %d at %s: expected %v/%s, got %v/%s", i, tt.at, tt.wantAllowed, tt.wantRetryAfter, allowed, retryAfter)
+		}
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	ep := createEndpointWithFile("GET /search", 200, `[]`)
+	ep.Schema.RateLimit = &endpoint.RateLimit{Requests: 1, Period: time.Hour}
+	handler := New([]*endpoint.EndpointWithFile{ep}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to be served, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Expected Retry-After 3600, got %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// ResponseValidation tells what the server does with response bodies that do
+// not match the schema declared by their Schema property.
+type ResponseValidation string
+
+const (
+	// ResponseValidationOff sends bodies without checking them.
+	ResponseValidationOff ResponseValidation = ""
+	// ResponseValidationLog sends mismatching bodies and prints a warning.
+	ResponseValidationLog ResponseValidation = "log"
+	// ResponseValidationError answers 500 instead of a mismatching body.
+	ResponseValidationError ResponseValidation = "error"
+)
+
+// ParseResponseValidation parses the name of a response validation mode.
+func ParseResponseValidation(mode string) (ResponseValidation, error) {
+	switch ResponseValidation(mode) {
+	case ResponseValidationOff, ResponseValidationLog, ResponseValidationError:
+		return ResponseValidation(mode), nil
+	}
+	return "", fmt.Errorf("unknown response validation mode %q: expected %s or %s", mode, ResponseValidationLog, ResponseValidationError)
+}
+
+// ValidateResponses checks the bodies of the declared responses against
+// their schemas before sending them, catching broken mock payloads.
+// Mismatches are published as errors, and handled as mode says.
+func (s *Server) ValidateResponses(mode ResponseValidation) {
+	s.validateResponses = mode
+}
+
+// checkResponse validates the body of resp and reports whether it may be
+// sent. When it may not, the 500 answer has been written.
+func (s *Server) checkResponse(w http.ResponseWriter, r *http.Request, ep *endpoint.EndpointWithFile, resp endpoint.Response) bool {
+	if s.validateResponses == ResponseValidationOff {
+		return true
+	}
+	err := resp.ValidateBody()
+	if err == nil {
+		return true
+	}
+	s.publishError(r, ep, err)
+	if s.validateResponses == ResponseValidationLog {
+		fmt.Println(i18n.T("Warning: %s: %v", ep.Schema.Route, err))
+		return true
+	}
+	http.Error(w, fmt.Sprintf("Response validation failed: %v", err), http.StatusInternalServerError)
+	return false
+}
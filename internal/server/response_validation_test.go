@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func TestServer_ValidateResponses(t *testing.T) {
+	dir := t.TempDir()
+	writeMock(t, dir, "user.json", `{"type": "object", "required": ["id"]}`)
+	valid := writeMock(t, dir, "valid.apimock", `GET /users/1
+
+-- 200: OK
+ContentType: application/json
+Schema: user.json
+
+{"id": 1}
+`)
+	broken := writeMock(t, dir, "broken.apimock", `GET /users/2
+
+-- 200: OK
+ContentType: application/json
+Schema: user.json
+
+{"name": "Ana"}
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(valid, broken)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		mode ResponseValidation
+		path string
+		want int
+	}{
+		{"valid body", ResponseValidationError, "/users/1", http.StatusOK},
+		{"off", ResponseValidationOff, "/users/2", http.StatusOK},
+		{"log", ResponseValidationLog, "/users/2", http.StatusOK},
+		{"error", ResponseValidationError, "/users/2", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(endpoints)
+			srv.ValidateResponses(tt.mode)
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if tt.want == http.StatusInternalServerError && !strings.Contains(rec.Body.String(), "user.json") {
+				t.Errorf("Expected the error to name the schema, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestParseResponseValidation(t *testing.T) {
+	for _, mode := range []string{"", "log", "error"} {
+		if got, err := ParseResponseValidation(mode); err != nil || string(got) != mode {
+			t.Errorf("ParseResponseValidation(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseResponseValidation("strict"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
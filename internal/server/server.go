@@ -1,17 +1,62 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pretodev/anansi-proxy/internal/authmock"
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/session"
+	"github.com/pretodev/anansi-proxy/internal/stats"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
 )
 
+// FrozenTime is the time seen by placeholders when random values are frozen.
+var FrozenTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// FrozenSeed seeds the random functions of placeholders, such as
+// .random_int and .uuid, when random values are frozen.
+const FrozenSeed = 1
+
 type Server struct {
 	endpoints         []*endpoint.EndpointWithFile
 	specificEndpoints []*endpoint.EndpointWithFile // endpoints with specific routes (not "/")
 	fallbackEndpoints []*endpoint.EndpointWithFile // endpoints with "/" route
+	comparator        *Comparator                  // optional baseline replayed for every request
+	stats             *stats.Collector             // optional request counters
+	events            *events.Bus                  // optional event stream
+	namespaces        map[string]*namespace        // state of the requests, by NamespaceHeader
+	namespacesMu      sync.Mutex
+	namespacesSwept   time.Time          // last look for idle namespaces
+	compress          bool               // compress every response, not only those of endpoints asking for it
+	frozen            bool               // fill time placeholders with FrozenTime
+	auth              *authmock.Provider // optional OAuth2/OIDC provider
+	chaos             *chaos
+	hooks             hooks
+	timeouts          Timeouts
+	broken            []*endpoint.FileError  // files left out, listed at ErrorsRoute
+	profile           atomic.Pointer[string] // active response profile, see SetProfile
+	validateResponses ResponseValidation     // what to do with bodies not matching their schema
+	tracer            *tracing.Tracer        // optional span exporter
+	history           *history.Log           // optional log of the last requests, see KeepHistory
+	responseHeader    string                 // request header naming the response to serve, see SetResponseHeader
+	strict            bool                   // answer 500 when expressions fail to evaluate, see EnableStrict
+	fallback          *http.Transport        // transport of proxied requests answered by the mock when they fail, see EnableFallbackToMock
+	mockedUpstreams   map[string]bool        // upstream URLs whose proxy sections are answered by the mocks, see MockUpstreams
 }
 
 func New(endpoints []*endpoint.EndpointWithFile) *Server {
@@ -19,6 +64,9 @@ func New(endpoints []*endpoint.EndpointWithFile) *Server {
 		endpoints:         endpoints,
 		specificEndpoints: make([]*endpoint.EndpointWithFile, 0),
 		fallbackEndpoints: make([]*endpoint.EndpointWithFile, 0),
+		namespaces:        map[string]*namespace{"": newNamespace(endpoints, false)},
+		chaos:             newChaos(0, time.Now().UnixNano()),
+		responseHeader:    DefaultResponseHeader,
 	}
 
 	// Separate specific routes from fallback routes
@@ -35,101 +83,665 @@ func New(endpoints []*endpoint.EndpointWithFile) *Server {
 
 func (s *Server) createHandlerFromEndpoint(ep *endpoint.EndpointWithFile) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Get the first available response (prioritize 200 OK if available, otherwise use first in map)
-		resp := endpoint.EmptyResponse()
-		if okResp, ok := ep.Schema.GetResponseByStatusCode(http.StatusOK); ok {
-			resp = okResp
-		} else {
-			// If no 200 OK response, use the first available response
-			responses := ep.Schema.SliceResponses()
-			if len(responses) > 0 {
-				resp = responses[0]
+		start := time.Now()
+		r = collectEvalErrors(r)
+		status, invalid := 0, false
+		defer func() { s.recordHit(r, ep, status, invalid, start) }()
+		calls := s.namespace(r).calls[ep.Schema].Add(1)
+
+		accept := r.Header.Get("Accept")
+		resp := s.defaultResponse(ep.Schema, accept)
+		// Proxy endpoints forward the requests not diverted to an error
+		// response, and responses with conditions are served to the others
+		// they hold for
+		forward, diverted := s.forwards(ep.Schema), false
+
+		body, readErr := readBody(r, ep.Schema)
+		r.Body.Close()
+
+		// A response asked for by name skips the checks, as when forced in
+		// the interactive UI
+		if forced, ok := s.override(r, ep); ok {
+			status = s.respond(w, r, ep, forced, body, calls, nil)
+			return
+		}
+
+		quota, err := quotaExceeded(ep.Schema, body, calls)
+		if err == nil {
+			quota, err = s.rateLimited(w, r, ep.Schema)
+		}
+		if err != nil {
+			s.publishError(r, ep, err)
+			if exceeded, declared := s.negotiate(ep.Schema, quota, accept); declared {
+				status = s.respond(w, r, ep, exceeded, body, calls, nil)
+			} else {
+				status = quota
+				writeStatus(w, status)
 			}
+			return
 		}
 
-		if ep.Schema.Validator != nil {
-			bodyBytes, err := io.ReadAll(r.Body)
-			defer r.Body.Close()
+		_, validation := tracing.Start(r.Context(), "validate request", tracing.KindInternal)
+		rejected, err := http.StatusBadRequest, ep.Schema.CheckParamTypes(r)
+		if err == nil {
+			rejected, err = ep.Schema.RequiredStatus, ep.Schema.CheckRequired(r)
+		}
+		var bodyErr error
+		if err == nil && ep.Schema.Validator != nil && readErr == nil {
+			bodyErr = ep.Schema.Validator.Validate(string(body))
+		}
+		validation.SetError(errors.Join(err, bodyErr))
+		validation.End()
 
-			if err != nil {
-				badResp, hasBadResp := ep.Schema.GetResponseByStatusCode(http.StatusBadRequest)
+		if err != nil {
+			invalid = true
+			s.publishError(r, ep, err)
+			rejection, declared := s.negotiate(ep.Schema, rejected, accept)
+			if !declared {
+				status = rejected
+				http.Error(w, fmt.Sprintf("Request validation failed: %v", err), status)
+				return
+			}
+			resp, forward, diverted = rejection, false, true
+			r = r.WithContext(context.WithValue(r.Context(), validationKey{}, err))
+		} else if ep.Schema.Validator != nil {
+			if err := readErr; err != nil {
+				s.publishError(r, ep, err)
+				badResp, hasBadResp := s.negotiate(ep.Schema, http.StatusBadRequest, accept)
 				if hasBadResp {
-					resp = badResp
+					resp, forward, diverted = badResp, false, true
 				} else {
-					http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+					status = http.StatusBadRequest
+					http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), status)
 					return
 				}
-			} else if err := ep.Schema.Validator.Validate(string(bodyBytes)); err != nil {
-				badResp, hasBadResp := ep.Schema.GetResponseByStatusCode(http.StatusBadRequest)
+			} else if err := bodyErr; err != nil {
+				invalid = true
+				s.publishError(r, ep, err)
+				badResp, hasBadResp := s.negotiate(ep.Schema, http.StatusBadRequest, accept)
 				if hasBadResp {
-					resp = badResp
+					resp, forward, diverted = badResp, false, true
+					r = r.WithContext(context.WithValue(r.Context(), validationKey{}, err))
 				} else {
-					http.Error(w, fmt.Sprintf("Request validation failed: %v", err), http.StatusBadRequest)
+					status = http.StatusBadRequest
+					http.Error(w, fmt.Sprintf("Request validation failed: %v", err), status)
 					return
 				}
 			}
 		}
 
-		if resp.ContentType != "" {
-			w.Header().Set("Content-Type", resp.ContentType)
+		var sess *session.Session
+		authorized := true
+		if err := ep.Schema.CheckBearer(r, time.Now()); err != nil {
+			s.publishError(r, ep, err)
+			authorized = false
+		} else {
+			sess, authorized = s.handleSession(w, r, body, ep.Schema)
+		}
+		if !authorized {
+			unauthorized, declared := s.negotiate(ep.Schema, http.StatusUnauthorized, accept)
+			if !declared {
+				status = http.StatusUnauthorized
+				writeStatus(w, status)
+				return
+			}
+			resp, forward, diverted = unauthorized, false, true
+		}
+
+		if !diverted {
+			if chosen, variables, ok := s.conditional(r, ep, body, calls, sess); ok {
+				resp, forward = chosen, false
+				r = r.WithContext(context.WithValue(r.Context(), variablesKey{}, variables))
+			}
+			if s.failStrict(w, r) {
+				status = http.StatusInternalServerError
+				return
+			}
+		}
+		if forward {
+			status = s.forward(w, r, ep, body, calls, sess)
+			return
+		}
+		status = s.respond(w, r, ep, resp, body, calls, sess)
+	}
+}
+
+// conditional returns the first response of ep, in declaration order, whose
+// condition lines hold for r under the active profile, with the variables
+// its conditions assigned. Conditions failing to evaluate do not hold and
+// are recorded with evalFailed.
+func (s *Server) conditional(r *http.Request, ep *endpoint.EndpointWithFile, body []byte, calls int64, sess *session.Session) (endpoint.Response, map[string]any, bool) {
+	responses := ep.Schema.ConditionalResponses(s.Profile())
+	if len(responses) == 0 {
+		return endpoint.Response{}, nil, false
+	}
+	newContext := s.templateContext(r, ep, body, int(calls), sess, s.namespace(r).last[ep.Schema].get())
+	for _, resp := range responses {
+		ctx := newContext()
+		holds, err := ctx.Holds(resp.Conditions)
+		if err != nil {
+			s.evalFailed(r, ep, err)
+		}
+		if holds {
+			return resp, ctx.Variables, true
+		}
+	}
+	return endpoint.Response{}, nil, false
+}
+
+// respond writes resp as the answer of ep to a request and returns the status
+// code sent, which is 304 when the client already has the response. In chaos
+// mode the response may be broken on the way. The callback of resp is
+// scheduled once the response was sent with its declared status.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, ep *endpoint.EndpointWithFile, resp endpoint.Response, body []byte, calls int64, sess *session.Session) int {
+	s.hooks.selected(r, ep, resp)
+	noteExchange(r, func(ex *history.Exchange) {
+		ex.ResponseIndex, ex.Response = ep.Schema.ResponseIndex(resp), resp.Title
+	})
+	last := s.namespace(r).last[ep.Schema]
+	newContext := sync.OnceValue(s.templateContext(r, ep, body, int(calls), sess, last.get()))
+	write := func(w http.ResponseWriter) int {
+		return s.write(w, r, ep, resp, newContext)
+	}
+
+	var status int
+	if f, rng := s.chaos.pick(ep.Schema); f != faultNone {
+		status = injectFault(w, r, f, rng, write)
+	} else {
+		status = write(w)
+	}
+	last.set(served{index: ep.Schema.ResponseIndex(resp), status: status})
+	if resp.Callback != nil && status == resp.StatusCode {
+		s.scheduleCallback(r, ep, resp.Callback, newContext)
+	}
+	return status
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request, ep *endpoint.EndpointWithFile, resp endpoint.Response, newContext func() *endpoint.TemplateContext) int {
+	_, span := tracing.Start(r.Context(), "render response", tracing.KindInternal)
+	defer span.End()
+	span.SetAttribute("anansi.response.index", ep.Schema.ResponseIndex(resp))
+
+	// err joins the placeholders that could not be filled
+	var err error
+	if resp.Script != nil {
+		result, err := resp.Script.Run(newContext())
+		span.SetError(err)
+		if err != nil {
+			s.publishError(r, ep, fmt.Errorf("script %s failed: %w", resp.ScriptFile, err))
+			http.Error(w, fmt.Sprintf("Script %s failed: %v", resp.ScriptFile, err), http.StatusInternalServerError)
+			return http.StatusInternalServerError
+		}
+		resp = result.Apply(resp)
+	} else if endpoint.HasTemplate(resp.Body) {
+		resp.Body, err = newContext().Interpolate(resp.Body)
+	}
+	headers, headerErr := interpolateHeaders(resp.Headers, newContext)
+	if err = errors.Join(err, headerErr); err != nil {
+		s.evalFailed(r, ep, err)
+	}
+	if s.failStrict(w, r) || !s.checkResponse(w, r, ep, resp) {
+		return http.StatusInternalServerError
+	}
+	writeContentHeaders(w, ep.Schema, resp)
+	setResponseHeaders(w.Header(), headers)
+
+	if writeETag(w, r, ep.Schema, resp) {
+		return http.StatusNotModified
+	}
+	writeBody(w, r, resp, s.compress || ep.Schema.Compress)
+	return resp.StatusCode
+}
+
+// validationKey is the context key of the error of a request body failing
+// its schema, or of a request missing required parameters, for the
+// placeholders of the error response served instead.
+type validationKey struct{}
+
+// variablesKey is the context key of the variables assigned by the
+// conditions of the response served, for its placeholders.
+type variablesKey struct{}
+
+// writeStatus answers with a plain-text status line, for errors the mock
+// declares no response for.
+func writeStatus(w http.ResponseWriter, status int) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%d - %s", status, http.StatusText(status))
+}
+
+// defaultResponse returns the response served when nothing else is asked for:
+// 200 OK if declared, otherwise the response with the lowest status code. When
+// several responses share that status code, the one preferred by the Accept
+// header is chosen. While a profile declaring responses for the endpoint is
+// active, only the status codes of the profile are considered.
+func (s *Server) defaultResponse(schema *endpoint.EndpointSchema, accept string) endpoint.Response {
+	profile := s.Profile()
+	codes := schema.ProfileStatusCodes(profile)
+	if len(codes) == 0 {
+		profile = ""
+		codes = schema.ProfileStatusCodes(profile)
+	}
+	if len(codes) == 0 {
+		return endpoint.EmptyResponse()
+	}
+	status := codes[0]
+	if slices.Contains(codes, http.StatusOK) {
+		status = http.StatusOK
+	}
+	resp, _ := schema.NegotiateProfileResponse(profile, status, accept)
+	return resp
+}
+
+// negotiate returns the response of schema for statusCode under the active
+// profile.
+func (s *Server) negotiate(schema *endpoint.EndpointSchema, statusCode int, accept string) (endpoint.Response, bool) {
+	return schema.NegotiateProfileResponse(s.Profile(), statusCode, accept)
+}
+
+// DraftHeader is set on draft responses, so clients and test logs show when
+// an unfinished part of a mock answered.
+const DraftHeader = "X-Anansi-Draft"
+
+// writeContentHeaders sets the Content-Type of resp, Vary when the response
+// was picked among several content types, and DraftHeader on drafts.
+func writeContentHeaders(w http.ResponseWriter, schema *endpoint.EndpointSchema, resp endpoint.Response) {
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	if resp.Draft {
+		w.Header().Set(DraftHeader, "true")
+	}
+	if schema.HasVariants(resp.StatusCode) {
+		w.Header().Add("Vary", "Accept")
+	}
+}
+
+// writeResponseHeaders sets the declared headers on h, interpolated by
+// interpolateHeaders, and returns the error of the placeholders that could
+// not be filled, which are sent as written.
+func writeResponseHeaders(h http.Header, declared map[string]string, newContext func() *endpoint.TemplateContext) error {
+	headers, err := interpolateHeaders(declared, newContext)
+	setResponseHeaders(h, headers)
+	return err
+}
+
+// interpolateHeaders returns the declared headers with their {{...}}
+// placeholders filled with the context returned by newContext, which is only
+// called when a header has placeholders. The error joins the placeholders
+// that could not be filled, which are kept as written.
+func interpolateHeaders(declared map[string]string, newContext func() *endpoint.TemplateContext) (map[string]string, error) {
+	var ctx *endpoint.TemplateContext
+	var errs []error
+	headers := make(map[string]string, len(declared))
+	for key, value := range declared {
+		if endpoint.HasTemplate(value) {
+			if ctx == nil {
+				ctx = newContext()
+			}
+			var err error
+			if value, err = ctx.Interpolate(value); err != nil {
+				errs = append(errs, fmt.Errorf("header %s: %w", key, err))
+			}
+		}
+		headers[key] = value
+	}
+	return headers, errors.Join(errs...)
+}
+
+// setResponseHeaders sets headers on h. They take precedence over the ones
+// already set, except Set-Cookie which is added to them.
+func setResponseHeaders(h http.Header, headers map[string]string) {
+	for key, value := range headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			h.Add(key, value)
+		} else {
+			h.Set(key, value)
+		}
+	}
+}
+
+// templateContext returns a function building the placeholder context of a
+// request: its content, the number of calls to the endpoint, the response the
+// endpoint served before, the session of the request and the variables the
+// conditions of the response assigned.
+func (s *Server) templateContext(r *http.Request, ep *endpoint.EndpointWithFile, body []byte, calls int, sess *session.Session, prev served) func() *endpoint.TemplateContext {
+	return func() *endpoint.TemplateContext {
+		ctx := endpoint.NewTemplateContext(r, body)
+		ctx.Params = func(name string) string { return ep.Schema.PathParam(r.URL.Path, name) }
+		ctx.ParamTypes = ep.Schema.ParamTypes
+		ctx.CallCount = calls
+		ctx.ResponseIndex, ctx.PreviousStatus = prev.index, prev.status
+		if s.frozen {
+			ctx.Now = FrozenTime
+		}
+		ctx.Scope = r.Header.Get(NamespaceHeader)
+		if sess != nil {
+			ctx.SessionID, ctx.SessionData = sess.ID, sess.Data
+			ctx.Scope += "/" + sess.ID
+		}
+		ctx.Validation, _ = r.Context().Value(validationKey{}).(error)
+		variables, _ := r.Context().Value(variablesKey{}).(map[string]any)
+		ctx.Variables = maps.Clone(variables)
+		return ctx
+	}
+}
+
+// matchersFirst orders endpoints with matchers before those without, for
+// slices.SortStableFunc.
+func matchersFirst(a, b *endpoint.EndpointSchema) int {
+	switch {
+	case len(a.Matchers) > 0 && len(b.Matchers) == 0:
+		return -1
+	case len(a.Matchers) == 0 && len(b.Matchers) > 0:
+		return 1
+	}
+	return 0
+}
+
+// createRouteHandler dispatches a request to the first endpoint of the group
+// whose matchers accept it, falling back to the fallback handler otherwise.
+func (s *Server) createRouteHandler(group []*endpoint.EndpointWithFile) http.HandlerFunc {
+	handlers := make([]http.HandlerFunc, len(group))
+	needsBody := false
+	for i, ep := range group {
+		handlers[i] = s.createHandlerFromEndpoint(ep)
+		if len(ep.Schema.Matchers) > 0 {
+			needsBody = true
+		}
+	}
+	if !needsBody {
+		return handlers[0]
+	}
+
+	fallback := s.fallbackHandler()
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+
+		_, span := tracing.Start(r.Context(), "match route", tracing.KindInternal)
+		matched := slices.IndexFunc(group, func(ep *endpoint.EndpointWithFile) bool {
+			return ep.Schema.Match(r, body)
+		})
+		if matched < 0 {
+			// A typed parameter of the wrong type, as in /users/abc for
+			// /users/{id:int}, is a bad request to the route rather than a
+			// request for another one
+			matched = slices.IndexFunc(group, func(ep *endpoint.EndpointWithFile) bool {
+				return ep.Schema.CheckParamTypes(r) != nil
+			})
+		}
+		span.SetAttribute("anansi.candidates", len(group))
+		span.SetAttribute("anansi.matched", matched >= 0)
+		span.End()
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if matched >= 0 {
+			handlers[matched](w, r)
+			return
+		}
+		fallback(w, r)
+	}
+}
+
+// overBudget returns how long serving r since start took and, when longer
+// than the latency budget declared for schema, the budget event reporting it.
+func overBudget(r *http.Request, schema *endpoint.EndpointSchema, start time.Time) (time.Duration, *events.Budget) {
+	elapsed := time.Since(start)
+	if schema.Budget <= 0 || elapsed <= schema.Budget {
+		return elapsed, nil
+	}
+	return elapsed, &events.Budget{
+		Route:      schema.Route,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		BudgetMs:   float64(schema.Budget.Microseconds()) / 1000,
+	}
+}
+
+// recordHit counts and publishes a request answered by ep, or by no endpoint
+// when ep is nil.
+func (s *Server) recordHit(r *http.Request, ep *endpoint.EndpointWithFile, status int, validationFailed bool, start time.Time) {
+	s.hooks.served(r, ep, status)
+	traceHit(r, ep, status)
+	noteHit(r, ep, status)
+
+	if s.stats != nil {
+		if ep == nil {
+			s.stats.RecordUnmatched()
+		} else {
+			s.stats.Record(ep, status, validationFailed)
+			s.stats.RecordEvalErrors(ep, len(evalErrorsOf(r)))
+		}
+	}
+
+	if ep != nil {
+		if elapsed, budget := overBudget(r, ep.Schema, start); budget != nil {
+			fmt.Println(i18n.T("Warning: %s took %s, exceeding its %s budget", ep.Schema.Route, elapsed.Round(time.Millisecond), ep.Schema.Budget))
+			if s.stats != nil {
+				s.stats.RecordOverBudget(ep)
+			}
+			if s.events != nil {
+				s.events.Publish(events.TypeBudget, *budget)
+			}
+		}
+	}
+
+	if s.events != nil {
+		data := events.Request{
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     status,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if ep != nil {
+			data.Route, data.File = ep.Schema.Route, ep.FilePath
 		}
+		s.events.Publish(events.TypeRequest, data)
+	}
+}
 
-		w.WriteHeader(resp.StatusCode)
-		fmt.Fprint(w, resp.Body)
+func (s *Server) publishError(r *http.Request, ep *endpoint.EndpointWithFile, err error) {
+	s.hooks.failed(r, ep.Schema.Route, err)
+	noteExchange(r, func(ex *history.Exchange) { ex.Errors = append(ex.Errors, err.Error()) })
+	if s.events != nil {
+		s.events.Publish(events.TypeError, events.Error{
+			Route:   ep.Schema.Route,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Message: err.Error(),
+		})
 	}
 }
 
 func (s *Server) fallbackHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r = collectEvalErrors(r)
 		if len(s.fallbackEndpoints) > 0 {
 			ep := s.fallbackEndpoints[0]
 
-			// Get the first available response (prioritize 200 OK if available, otherwise use first in map)
-			resp := endpoint.EmptyResponse()
-			if okResp, ok := ep.Schema.GetResponseByStatusCode(http.StatusOK); ok {
-				resp = okResp
+			accept := r.Header.Get("Accept")
+			resp := s.defaultResponse(ep.Schema, accept)
+			calls := s.namespace(r).calls[ep.Schema].Add(1)
+			body, _ := readBody(r, ep.Schema)
+			if forced, ok := s.override(r, ep); ok {
+				s.recordHit(r, ep, s.respond(w, r, ep, forced, body, calls, nil), false, start)
+				return
+			}
+
+			var sess *session.Session
+			errorStatus := 0
+			if quota, err := quotaExceeded(ep.Schema, body, calls); err != nil {
+				s.publishError(r, ep, err)
+				errorStatus = quota
+			} else if limited, err := s.rateLimited(w, r, ep.Schema); err != nil {
+				s.publishError(r, ep, err)
+				errorStatus = limited
+			} else if err := ep.Schema.CheckBearer(r, time.Now()); err != nil {
+				s.publishError(r, ep, err)
+				errorStatus = http.StatusUnauthorized
+			} else if current, hasSession := s.handleSession(w, r, body, ep.Schema); !hasSession {
+				errorStatus = http.StatusUnauthorized
 			} else {
-				// If no 200 OK response, use the first available response
-				responses := ep.Schema.SliceResponses()
-				if len(responses) > 0 {
-					resp = responses[0]
-				}
+				sess = current
 			}
 
-			if resp.ContentType != "" {
-				w.Header().Set("Content-Type", resp.ContentType)
+			if errorStatus != 0 {
+				declared, ok := s.negotiate(ep.Schema, errorStatus, accept)
+				if !ok {
+					s.recordHit(r, ep, errorStatus, false, start)
+					writeStatus(w, errorStatus)
+					return
+				}
+				resp = declared
 			}
 
-			w.WriteHeader(resp.StatusCode)
-			fmt.Fprint(w, resp.Body)
+			if errorStatus == 0 {
+				chosen, variables, ok := s.conditional(r, ep, body, calls, sess)
+				if s.failStrict(w, r) {
+					s.recordHit(r, ep, http.StatusInternalServerError, false, start)
+					return
+				}
+				if ok {
+					resp = chosen
+					r = r.WithContext(context.WithValue(r.Context(), variablesKey{}, variables))
+				} else if s.forwards(ep.Schema) {
+					s.recordHit(r, ep, s.forward(w, r, ep, body, calls, sess), false, start)
+					return
+				}
+			}
+			s.recordHit(r, ep, s.respond(w, r, ep, resp, body, calls, sess), false, start)
 			return
 		}
 
+		s.recordHit(r, nil, http.StatusNotFound, false, start)
+
 		// No fallback endpoint, return 404
 		w.WriteHeader(http.StatusNotFound)
 		fmt.Fprint(w, "404 - Not Found")
 	}
 }
 
-func (s *Server) Serve(port int) error {
-	if port <= 0 || port > 65535 {
-		return fmt.Errorf("invalid port number %d: must be between 1 and 65535", port)
+// CompareWith replays every request served by s against the baseline server
+// in the background and reports responses that differ to out.
+func (s *Server) CompareWith(baseline *Server, out io.Writer) {
+	s.comparator = NewComparator(baseline.Handler(), out)
+}
+
+// ReportBrokenFiles lists the files that failed to load at ErrorsRoute, so
+// tools can tell a missing mock from a broken one.
+func (s *Server) ReportBrokenFiles(errs []*endpoint.FileError) {
+	s.broken = errs
+}
+
+// CollectStats counts every request served by s in c.
+func (s *Server) CollectStats(c *stats.Collector) {
+	s.stats = c
+}
+
+// PublishEvents publishes every request served by s, and validation errors,
+// to bus, and serves bus at EventsRoute.
+func (s *Server) PublishEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// MockAuth serves the token, authorization, discovery and JWKS endpoints of
+// provider next to the mocks. Mocks declaring the same routes take
+// precedence.
+func (s *Server) MockAuth(provider *authmock.Provider) {
+	s.auth = provider
+}
+
+// FreezeRandom makes responses byte-identical from run to run: time
+// placeholders are filled with FrozenTime, the random functions are seeded
+// with FrozenSeed and sessions are numbered instead of getting random IDs.
+func (s *Server) FreezeRandom() {
+	apimock.SeedRandom(FrozenSeed)
+	s.namespacesMu.Lock()
+	defer s.namespacesMu.Unlock()
+	s.frozen = true
+	for _, ns := range s.namespaces {
+		ns.sessions.UseSequentialIDs()
 	}
-	addr := fmt.Sprintf(":%d", port)
+}
+
+// EnableChaos breaks the given fraction of responses of every endpoint, by
+// dropping the connection, truncating or garbling the body, holding the
+// response for ChaosLatency or answering a 5xx status. Faults follow from
+// seed, so runs sending the same requests fail the same way.
+func (s *Server) EnableChaos(rate float64, seed int64) {
+	s.chaos = newChaos(rate, seed)
+}
+
+// EnableCompression compresses the responses of every endpoint for clients
+// that accept gzip, deflate or brotli.
+func (s *Server) EnableCompression() {
+	s.compress = true
+}
 
+// Handler returns the HTTP handler that routes requests to the endpoints.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
+	// Endpoints sharing a route shape are registered once, under the route of
+	// the first of them, and told apart by their matchers. Those with
+	// matchers are tried first, so an endpoint accepting every request does
+	// not shadow them whatever the load order.
+	var shapes []string
+	groups := make(map[string][]*endpoint.EndpointWithFile)
 	for _, ep := range s.specificEndpoints {
-		route := ep.Schema.Route
-		mux.HandleFunc(route, s.createHandlerFromEndpoint(ep))
+		shape := endpoint.RouteShape(ep.Schema.Route)
+		if _, exists := groups[shape]; !exists {
+			shapes = append(shapes, shape)
+		}
+		groups[shape] = append(groups[shape], ep)
+	}
+
+	for _, shape := range shapes {
+		group := groups[shape]
+		slices.SortStableFunc(group, func(a, b *endpoint.EndpointWithFile) int {
+			return matchersFirst(a.Schema, b.Schema)
+		})
+		mux.HandleFunc(group[0].Schema.Route, s.createRouteHandler(group))
+	}
+
+	s.registerAdmin(mux, shapes, groups)
+	if s.auth != nil {
+		for pattern, handler := range s.auth.Routes() {
+			if _, declared := groups[pattern]; !declared {
+				mux.HandleFunc(pattern, handler)
+			}
+		}
 	}
 
 	mux.HandleFunc("/", s.fallbackHandler())
 
-	fmt.Printf("\nStarting server on port %d...\n", port)
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		return fmt.Errorf("failed to start server on port %d: %w", port, err)
+	handler := s.hooks.wrap(recordHistory(s.history, mux))
+	if s.comparator != nil {
+		handler = s.comparator.Wrap(handler)
 	}
+	return s.traced(s.hooks.chain(handler))
+}
+
+// SetTimeouts configures the read, write, idle and shutdown timeouts of the
+// HTTP server started by Serve.
+func (s *Server) SetTimeouts(t Timeouts) {
+	s.timeouts = t
+}
+
+// Serve serves the mocks on port, on every interface, until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, port int) error {
+	ln, err := Listen("", port, "")
+	if err != nil {
+		return err
+	}
+	return s.ServeListener(ctx, ln)
+}
 
-	return nil
+// ServeListener serves the mocks on ln until ctx is cancelled, then shuts down
+// gracefully: new connections are refused and in-flight requests are given
+// the shutdown timeout to finish.
+func (s *Server) ServeListener(ctx context.Context, ln net.Listener) error {
+	return serveListener(ctx, ln, s.Handler(), s.timeouts, s.events)
 }
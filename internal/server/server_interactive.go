@@ -1,52 +1,372 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
 	"github.com/pretodev/anansi-proxy/internal/state"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
 )
 
+// InteractiveLatency is how long requests are held while the latency switch
+// of the interactive UI is on.
+var InteractiveLatency = 2 * time.Second
+
+// InteractiveChaosRate is the fraction of responses broken while the chaos
+// switch of the interactive UI is on, unless EnableChaos sets another.
+const InteractiveChaosRate = 0.3
+
 type InteractiveServer struct {
-	state    *state.StateManager
-	endpoint *endpoint.EndpointSchema
+	endpoints []*interactiveEndpoint
+	controls  *state.Controls // switches applying to every endpoint
+	chaos     *chaos          // faults injected while controls.Chaos()
+	events    *events.Bus     // optional event stream
+	frozen    bool            // fill time placeholders with FrozenTime
+	timeouts  Timeouts
+	history   *history.Log // optional log of the last requests, see KeepHistory
+	// responseHeader names the request header overriding the selected
+	// response, see SetResponseHeader
+	responseHeader string
+}
+
+// interactiveEndpoint is an endpoint serving the response selected in its
+// state. Its schema is replaced when its file is edited, see SetSchema.
+type interactiveEndpoint struct {
+	state  *state.StateManager
+	schema atomic.Pointer[endpoint.EndpointSchema]
+	file   string
+	last   *lastResponse
+}
+
+func newInteractiveEndpoint(sm *state.StateManager, schema *endpoint.EndpointSchema, file string) *interactiveEndpoint {
+	e := &interactiveEndpoint{state: sm, file: file, last: newLastResponse()}
+	e.schema.Store(schema)
+	return e
 }
 
 func NewInteractive(sm *state.StateManager, endpoint *endpoint.EndpointSchema) *InteractiveServer {
 	return &InteractiveServer{
-		state:    sm,
-		endpoint: endpoint,
+		endpoints:      []*interactiveEndpoint{newInteractiveEndpoint(sm, endpoint, "")},
+		controls:       &state.Controls{},
+		chaos:          newChaos(InteractiveChaosRate, time.Now().UnixNano()),
+		responseHeader: DefaultResponseHeader,
 	}
 }
 
-func (s *InteractiveServer) handler() http.HandlerFunc {
+// NewInteractiveSet returns a server for several endpoints, the i-th serving
+// the response selected in sel.Endpoint(i), under the switches of
+// sel.Controls().
+func NewInteractiveSet(sel *state.Selection, endpoints []*endpoint.EndpointWithFile) *InteractiveServer {
+	s := &InteractiveServer{
+		endpoints:      make([]*interactiveEndpoint, len(endpoints)),
+		controls:       sel.Controls(),
+		chaos:          newChaos(InteractiveChaosRate, time.Now().UnixNano()),
+		responseHeader: DefaultResponseHeader,
+	}
+	for i, ep := range endpoints {
+		s.endpoints[i] = newInteractiveEndpoint(sel.Endpoint(i), ep.Schema, ep.FilePath)
+	}
+	return s
+}
+
+func (s *InteractiveServer) handler(e *interactiveEndpoint) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		responseIndex := s.state.Index()
-		currentResponse := s.endpoint.SliceResponses()[responseIndex]
+		start := time.Now()
+		schema := e.schema.Load()
+
+		calls := e.state.AddCall()
+		responseIndex := e.state.Index()
+		currentResponse := schema.SliceResponses()[responseIndex]
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		ep := &endpoint.EndpointWithFile{Schema: schema, FilePath: e.file}
+
+		status := 0
+		defer func() {
+			// Slow requests are reported in the history and the events
+			// rather than printed over the UI
+			elapsed, budget := overBudget(r, schema, start)
+			if budget != nil {
+				noteExchange(r, func(ex *history.Exchange) {
+					ex.Errors = append(ex.Errors, i18n.T("%s took %s, exceeding its %s budget", schema.Route, elapsed.Round(time.Millisecond), schema.Budget))
+				})
+			}
+			noteHit(r, ep, status)
+			if s.events != nil {
+				s.events.Publish(events.TypeRequest, events.Request{
+					Method:     r.Method,
+					Path:       r.URL.Path,
+					Route:      schema.Route,
+					Status:     status,
+					DurationMs: float64(elapsed.Microseconds()) / 1000,
+				})
+				if budget != nil {
+					s.events.Publish(events.TypeBudget, *budget)
+				}
+			}
+		}()
+
+		// A response asked for by name is served as if selected and forced
+		overridden, ok, err := overrideResponse(r, s.responseHeader, schema, "")
+		if err != nil {
+			s.publishError(r, schema, err)
+		}
+		if ok {
+			currentResponse, responseIndex = overridden, schema.ResponseIndex(overridden)
+		} else if !e.state.Forced() {
+			if rejected, err := checkInteractive(r, schema, body); err != nil {
+				s.publishError(r, schema, err)
+				resp, declared := schema.NegotiateResponse(rejected, r.Header.Get("Accept"))
+				if !declared {
+					status = rejected
+					http.Error(w, fmt.Sprintf("Request validation failed: %v", err), status)
+					return
+				}
+				currentResponse, responseIndex = resp, schema.ResponseIndex(resp)
+			}
+		}
 
-		if currentResponse.ContentType != "" {
-			w.Header().Set("Content-Type", currentResponse.ContentType)
+		if s.controls.Latency() {
+			select {
+			case <-time.After(InteractiveLatency):
+			case <-r.Context().Done():
+				return
+			}
 		}
 
-		w.WriteHeader(currentResponse.StatusCode)
-		fmt.Fprint(w, currentResponse.Body)
+		prev := e.last.get()
+		newContext := sync.OnceValue(func() *endpoint.TemplateContext {
+			ctx := endpoint.NewTemplateContext(r, body)
+			ctx.Params = func(name string) string { return schema.PathParam(r.URL.Path, name) }
+			ctx.ParamTypes = schema.ParamTypes
+			ctx.CallCount = int(calls)
+			ctx.ResponseIndex, ctx.PreviousStatus = prev.index, prev.status
+			if s.frozen {
+				ctx.Now = FrozenTime
+			}
+			return ctx
+		})
+		write := func(w http.ResponseWriter) int {
+			if currentResponse.ContentType != "" {
+				w.Header().Set("Content-Type", currentResponse.ContentType)
+			}
+			if currentResponse.Draft {
+				w.Header().Set(DraftHeader, "true")
+			}
+			err := writeResponseHeaders(w.Header(), currentResponse.Headers, newContext)
+			if endpoint.HasTemplate(currentResponse.Body) {
+				var bodyErr error
+				currentResponse.Body, bodyErr = newContext().Interpolate(currentResponse.Body)
+				err = errors.Join(err, bodyErr)
+			}
+			if err != nil {
+				s.publishError(r, schema, err)
+			}
+			if writeETag(w, r, schema, currentResponse) {
+				return http.StatusNotModified
+			}
+			writeBody(w, r, currentResponse, schema.Compress)
+			return currentResponse.StatusCode
+		}
+
+		noteExchange(r, func(ex *history.Exchange) {
+			ex.ResponseIndex, ex.Response = responseIndex, currentResponse.Title
+		})
+		f, rng := faultNone, (*rand.Rand)(nil)
+		if s.controls.Chaos() {
+			f, rng = s.chaos.pick(schema)
+		}
+		if f != faultNone {
+			status = injectFault(w, r, f, rng, write)
+		} else {
+			status = write(w)
+		}
+		e.last.set(served{index: responseIndex, status: status})
 	}
 }
 
-func (s *InteractiveServer) Serve(port int) error {
-	if port <= 0 || port > 65535 {
-		return fmt.Errorf("invalid port number %d: must be between 1 and 65535", port)
+// checkInteractive checks a request against the typed and required
+// parameters and the body schema of an endpoint whose selected response is
+// not forced, returning the status code rejecting it and why.
+func checkInteractive(r *http.Request, schema *endpoint.EndpointSchema, body []byte) (int, error) {
+	if err := schema.CheckParamTypes(r); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := schema.CheckRequired(r); err != nil {
+		return schema.RequiredStatus, err
+	}
+	if schema.Validator != nil {
+		if err := schema.Validator.Validate(string(body)); err != nil {
+			return http.StatusBadRequest, err
+		}
 	}
+	return 0, nil
+}
 
-	addr := fmt.Sprintf(":%d", port)
+// publishError records why a request was not served the selected response.
+func (s *InteractiveServer) publishError(r *http.Request, schema *endpoint.EndpointSchema, err error) {
+	noteExchange(r, func(ex *history.Exchange) { ex.Errors = append(ex.Errors, err.Error()) })
+	if s.events != nil {
+		s.events.Publish(events.TypeError, events.Error{
+			Route:   schema.Route,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Message: err.Error(),
+		})
+	}
+}
 
-	http.HandleFunc(s.endpoint.Route, s.handler())
+// routeHandler dispatches a request to the first endpoint of the group whose
+// matchers accept it, as Server does for endpoints sharing a route.
+func (s *InteractiveServer) routeHandler(group []*interactiveEndpoint) http.HandlerFunc {
+	if len(group) == 1 {
+		return s.handler(group[0])
+	}
+	handlers := make([]http.HandlerFunc, len(group))
+	for i, e := range group {
+		handlers[i] = s.handler(e)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
 
-	fmt.Printf("\nStarting server on port %d...\n", port)
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		return fmt.Errorf("failed to start server on port %d: %w", port, err)
+		matched := slices.IndexFunc(group, func(e *interactiveEndpoint) bool {
+			return e.schema.Load().Match(r, body)
+		})
+		if matched < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		handlers[matched](w, r)
 	}
+}
 
+// SetSchema replaces the schema of the i-th endpoint, as parsed again after
+// its file was edited, for the requests that follow, and publishes a reload
+// event. The route cannot change, not even the names of its parameters,
+// since requests are routed by it.
+func (s *InteractiveServer) SetSchema(i int, schema *endpoint.EndpointSchema) error {
+	e := s.endpoints[i]
+	if current := e.schema.Load().Route; schema.Route != current {
+		return fmt.Errorf("route changed from %s to %s; restart to serve it", current, schema.Route)
+	}
+	e.state.SetMax(schema.CountResponses())
+	e.schema.Store(schema)
+	if s.events != nil {
+		s.events.Publish(events.TypeReload, events.Reload{Route: schema.Route, File: e.file})
+	}
 	return nil
 }
+
+// EnableChaos sets the fraction of responses broken, and the seed of the
+// faults, while the chaos switch is on. See Server.EnableChaos.
+func (s *InteractiveServer) EnableChaos(rate float64, seed int64) {
+	s.chaos = newChaos(rate, seed)
+}
+
+// FreezeRandom fills time placeholders with FrozenTime and seeds the random
+// functions with FrozenSeed, so responses are byte-identical from run to run.
+func (s *InteractiveServer) FreezeRandom() {
+	apimock.SeedRandom(FrozenSeed)
+	s.frozen = true
+}
+
+// PublishEvents publishes every request served by s, and every change of the
+// selected response of an endpoint, to bus, and serves bus at EventsRoute.
+func (s *InteractiveServer) PublishEvents(bus *events.Bus) {
+	s.events = bus
+	for _, e := range s.endpoints {
+		e.state.OnChange(func(index int) {
+			schema := e.schema.Load()
+			resp := schema.SliceResponses()[index]
+			bus.Publish(events.TypeState, events.State{
+				Route:  schema.Route,
+				Index:  index,
+				Status: resp.StatusCode,
+				Title:  resp.Title,
+			})
+		})
+	}
+}
+
+// KeepHistory keeps the last size requests served, with the response sent,
+// for History and HistoryRoute.
+func (s *InteractiveServer) KeepHistory(size int) {
+	s.history = history.New(size)
+}
+
+// History returns the log of the requests served, or nil unless KeepHistory
+// was called.
+func (s *InteractiveServer) History() *history.Log {
+	return s.history
+}
+
+// SetTimeouts configures the read, write, idle and shutdown timeouts of the
+// HTTP server started by Serve.
+func (s *InteractiveServer) SetTimeouts(t Timeouts) {
+	s.timeouts = t
+}
+
+// Serve serves the endpoints on port, on every interface, until ctx is
+// cancelled.
+func (s *InteractiveServer) Serve(ctx context.Context, port int) error {
+	ln, err := Listen("", port, "")
+	if err != nil {
+		return err
+	}
+	return s.ServeListener(ctx, ln)
+}
+
+// ServeListener serves the endpoints on ln until ctx is cancelled, then
+// shuts down gracefully.
+func (s *InteractiveServer) ServeListener(ctx context.Context, ln net.Listener) error {
+	return serveListener(ctx, ln, s.Handler(), s.timeouts, s.events)
+}
+
+// Handler returns the HTTP handler that routes requests to the endpoints.
+func (s *InteractiveServer) Handler() http.Handler {
+	// Endpoints sharing a route shape are registered once, under the route of
+	// the first of them, those with matchers first
+	var shapes []string
+	groups := make(map[string][]*interactiveEndpoint)
+	for _, e := range s.endpoints {
+		shape := endpoint.RouteShape(e.schema.Load().Route)
+		if _, exists := groups[shape]; !exists {
+			shapes = append(shapes, shape)
+		}
+		groups[shape] = append(groups[shape], e)
+	}
+
+	mux := http.NewServeMux()
+	for _, shape := range shapes {
+		group := groups[shape]
+		slices.SortStableFunc(group, func(a, b *interactiveEndpoint) int {
+			return matchersFirst(a.schema.Load(), b.schema.Load())
+		})
+		mux.Handle(group[0].schema.Load().Route, recordHistory(s.history, s.routeHandler(group)))
+	}
+	if _, declared := groups[EventsRoute]; s.events != nil && !declared {
+		mux.Handle(EventsRoute, s.events)
+	}
+	_, declared := groups[HistoryRoute]
+	if _, clearDeclared := groups[ClearHistoryRoute]; s.history != nil && !declared && !clearDeclared {
+		mux.HandleFunc(HistoryRoute, historyHandler(s.history))
+		mux.HandleFunc(ClearHistoryRoute, clearHistoryHandler(s.history))
+	}
+	return mux
+}
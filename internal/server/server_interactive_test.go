@@ -0,0 +1,152 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestInteractiveServer_Set(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	users.Schema.Responses[503] = []endpoint.Response{{Title: "Down", StatusCode: 503}}
+	orders := createEndpointWithFile("GET /orders", 200, `[]`)
+	orders.Schema.Responses[404] = []endpoint.Response{{Title: "Missing", StatusCode: 404}}
+
+	sel := state.NewSelection(users.Schema.CountResponses(), orders.Schema.CountResponses())
+	srv := NewInteractiveSet(sel, []*endpoint.EndpointWithFile{users, orders})
+	srv.KeepHistory(10)
+	handler := srv.Handler()
+
+	sel.Endpoint(1).SetIndex(1)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/users", 200},
+		{"/orders", 404},
+		{"/unknown", 404},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+
+	found := srv.History().Find(history.Query{Route: "GET /orders"})
+	if len(found) != 1 || found[0].Response != "Missing" || found[0].File != orders.FilePath {
+		t.Errorf("Expected the history to name the response and file of GET /orders, got %+v", found)
+	}
+}
+
+func TestInteractiveServer_OverBudget(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	users.Schema.Budget = time.Nanosecond
+	srv := NewInteractiveSet(state.NewSelection(users.Schema.CountResponses()), []*endpoint.EndpointWithFile{users})
+	srv.KeepHistory(10)
+	bus := events.NewBus()
+	srv.PublishEvents(bus)
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if found := srv.History().Find(history.Query{Route: "GET /users"}); len(found) != 1 || len(found[0].Errors) != 1 {
+		t.Errorf("Expected the history to report the request over budget, got %+v", found)
+	}
+	if request, budget := <-received, <-received; request.Type != events.TypeRequest || budget.Type != events.TypeBudget {
+		t.Errorf("Expected a request and a budget event, got %+v and %+v", request, budget)
+	}
+}
+
+func TestInteractiveServer_SetSchema(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	sm := state.New(1)
+	srv := NewInteractive(sm, users.Schema)
+	handler := srv.Handler()
+
+	edited := createEndpointWithFile("GET /users", 200, `[{"id": 1}]`)
+	if err := srv.SetSchema(0, edited.Schema); err != nil {
+		t.Fatalf("SetSchema() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Body.String() != `[{"id": 1}]` {
+		t.Errorf("Expected the edited body, got %q", rec.Body.String())
+	}
+
+	moved := createEndpointWithFile("GET /people", 200, `[]`)
+	if err := srv.SetSchema(0, moved.Schema); err == nil {
+		t.Error("SetSchema() changing the route succeeded, want an error")
+	}
+}
+
+func TestInteractiveServer_SetSchemaParams(t *testing.T) {
+	user := createEndpointWithFile("GET /users/{id}", 200, `{}`)
+	srv := NewInteractive(state.New(1), user.Schema)
+	bus := events.NewBus()
+	srv.PublishEvents(bus)
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	renamed := createEndpointWithFile("GET /users/{userId}", 200, `{}`)
+	if err := srv.SetSchema(0, renamed.Schema); err == nil {
+		t.Error("SetSchema() renaming a parameter succeeded, want an error")
+	}
+	edited := createEndpointWithFile("GET /users/{id}", 200, `{"id": 1}`)
+	if err := srv.SetSchema(0, edited.Schema); err != nil {
+		t.Fatalf("SetSchema() error = %v", err)
+	}
+	event := <-received
+	if data, ok := event.Data.(events.Reload); event.Type != events.TypeReload || !ok || data.Route != "GET /users/{id}" {
+		t.Errorf("Expected a reload event, got %+v", event)
+	}
+}
+
+func TestInteractiveServer_Switches(t *testing.T) {
+	path := writeMock(t, t.TempDir(), "users.apimock", "GET /users/{id:int}\n\n-- 200: Found\n\n{\"id\": 1}\n\n-- 400: Bad id\n\n{\"error\": \"bad id\"}\n")
+	schema, err := endpoint.ParseAPIMock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel := state.NewSelection(schema.CountResponses())
+	srv := NewInteractiveSet(sel, []*endpoint.EndpointWithFile{{Schema: schema, FilePath: path}})
+	srv.EnableChaos(1, 16) // the first fault drawn is a 5xx status
+	handler := srv.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/users/abc"); rec.Code != 200 {
+		t.Errorf("Expected the forced response for a bad id, got %d", rec.Code)
+	}
+	sel.Endpoint(0).SetForced(false)
+	if rec := get("/users/abc"); rec.Code != 400 || rec.Body.String() != `{"error": "bad id"}` {
+		t.Errorf("Expected the declared 400 once the response is not forced, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("/users/1"); rec.Code != 200 {
+		t.Errorf("Expected the selected response for a good id, got %d", rec.Code)
+	}
+
+	InteractiveLatency = 50 * time.Millisecond
+	defer func() { InteractiveLatency = 2 * time.Second }()
+	sel.Controls().SetLatency(true)
+	if start := time.Now(); get("/users/1").Code != 200 || time.Since(start) < InteractiveLatency {
+		t.Errorf("Expected the response delayed by %s", InteractiveLatency)
+	}
+	sel.Controls().SetLatency(false)
+
+	sel.Controls().SetChaos(true)
+	if rec := get("/users/1"); rec.Code < 500 {
+		t.Errorf("Expected a 5xx fault with chaos on, got %d", rec.Code)
+	}
+}
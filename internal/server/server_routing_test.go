@@ -1,12 +1,21 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/events"
+	"github.com/pretodev/anansi-proxy/internal/stats"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
 )
 
 // Helper function to create test endpoints
@@ -366,20 +375,275 @@ func TestServer_EmptyContentType(t *testing.T) {
 	}
 }
 
-// Helper method for testing - creates a ServeMux for testing without starting a server
-func (s *Server) createTestMux() *http.ServeMux {
-	mux := http.NewServeMux()
+func TestServer_ConstrainedAndWildcardRoutes(t *testing.T) {
+	byID := createEndpointWithFile("GET /orders/{id}", 200, "by id")
+	byID.Schema.Matchers = []endpoint.RequestMatcher{mustPathMatcher(t, "{id:[0-9]+}", "id", "[0-9]+")}
+	bySlug := createEndpointWithFile("GET /orders/{slug}", 200, "by slug")
+	bySlug.Schema.Matchers = []endpoint.RequestMatcher{mustPathMatcher(t, "{slug:[a-z-]+}", "slug", "[a-z-]+")}
+	files := createEndpointWithFile("GET /files/{wildcard...}", 200, "file")
 
-	// Register specific endpoints first
-	for _, ep := range s.specificEndpoints {
-		route := ep.Schema.Route
-		mux.HandleFunc(route, s.createHandlerFromEndpoint(ep))
+	server := New([]*endpoint.EndpointWithFile{byID, bySlug, files})
+	mux := server.createTestMux()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/orders/42", 200, "by id"},
+		{"/orders/big-order", 200, "by slug"},
+		{"/orders/BIG", 404, "404 - Not Found"},
+		{"/files/a/b/c.txt", 200, "file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func mustPathMatcher(t *testing.T, value, name, pattern string) *endpoint.PathMatcher {
+	t.Helper()
+	_, matcher, err := endpoint.RoutePath([]apimock.PathSegment{
+		{Value: "orders"},
+		{Value: value, IsParameter: true, Name: name, Pattern: pattern},
+	})
+	if err != nil {
+		t.Fatalf("RoutePath() error = %v", err)
+	}
+	return matcher
+}
+
+func TestServer_CollectStats(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, "[]")
+	server := New([]*endpoint.EndpointWithFile{users})
+	collector := stats.NewCollector(server.endpoints)
+	server.CollectStats(collector)
+	mux := server.createTestMux()
+
+	for _, path := range []string{"/users", "/users", "/missing"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	report := collector.Report()
+	if report.Requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", report.Requests)
+	}
+	if report.Endpoints[0].StatusCodes[200] != 2 {
+		t.Errorf("Expected 2 hits with status 200, got %v", report.Endpoints[0].StatusCodes)
+	}
+	if report.Unmatched != 1 {
+		t.Errorf("Expected 1 unmatched request, got %d", report.Unmatched)
+	}
+}
+
+func TestServer_PublishEvents(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, "[]")
+	server := New([]*endpoint.EndpointWithFile{users})
+	bus := events.NewBus()
+	server.PublishEvents(bus)
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	handler := server.Handler()
+
+	for _, path := range []string{"/users", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	want := []events.Request{
+		{Method: "GET", Path: "/users", Route: "GET /users", File: "/test/mock.apimock", Status: 200},
+		{Method: "GET", Path: "/missing", Status: 404},
+	}
+	for _, w := range want {
+		event := <-received
+		data, ok := event.Data.(events.Request)
+		if event.Type != events.TypeRequest || !ok {
+			t.Fatalf("Expected a request event, got %+v", event)
+		}
+		data.DurationMs = 0
+		if data != w {
+			t.Errorf("Expected %+v, got %+v", w, data)
+		}
+	}
+}
+
+func TestServer_PublishesPlaceholderErrors(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, `{"page": {{query.page * 2}}}`)
+	server := New([]*endpoint.EndpointWithFile{users})
+	bus := events.NewBus()
+	server.PublishEvents(bus)
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Body.String() != `{"page": {{query.page * 2}}}` {
+		t.Errorf("Expected the placeholder sent as written, got %q", rec.Body.String())
+	}
+
+	event := <-received
+	data, ok := event.Data.(events.Error)
+	if event.Type != events.TypeError || !ok || !strings.Contains(data.Message, "query.page * 2 (column 1): query.page is not set") {
+		t.Errorf("Expected an error event locating the placeholder, got %+v", event)
+	}
+}
+
+func TestServer_OverBudget(t *testing.T) {
+	users := createEndpointWithFile("GET /users", 200, "[]")
+	users.Schema.Budget = time.Nanosecond
+	server := New([]*endpoint.EndpointWithFile{users})
+	collector := stats.NewCollector(server.endpoints)
+	server.CollectStats(collector)
+	bus := events.NewBus()
+	server.PublishEvents(bus)
+	received, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if got := collector.Report().Endpoints[0].OverBudget; got != 1 {
+		t.Errorf("Expected 1 request over budget, got %d", got)
+	}
+	event := <-received
+	if data, ok := event.Data.(events.Budget); event.Type != events.TypeBudget || !ok || data.Route != "GET /users" || data.DurationMs <= data.BudgetMs {
+		t.Errorf("Expected a budget event for GET /users, got %+v", event)
+	}
+}
+
+func TestServer_EventsRoute(t *testing.T) {
+	server := New([]*endpoint.EndpointWithFile{createEndpointWithFile("GET /users", 200, "[]")})
+	server.PublishEvents(events.NewBus())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/_admin/events", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected event stream, got Content-Type %q", got)
+	}
+}
+
+func TestServer_NegotiatesContentType(t *testing.T) {
+	ep := createEndpointWithFile("GET /users", 200, `[]`)
+	ep.Schema.Responses[200] = []endpoint.Response{
+		{Title: "JSON", Body: `[]`, ContentType: "application/json", StatusCode: 200},
+		{Title: "XML", Body: `<users/>`, ContentType: "application/xml", StatusCode: 200},
+	}
+
+	server := New([]*endpoint.EndpointWithFile{ep})
+	mux := server.createTestMux()
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Body.String() != `<users/>` {
+		t.Errorf("Expected XML body, got %q", rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/xml" {
+		t.Errorf("Expected Content-Type application/xml, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Expected Vary: Accept, got %q", got)
+	}
+}
+
+func TestServer_DeclaredResponseHeaders(t *testing.T) {
+	ep := createEndpointWithFile("POST /users", 201, `{}`)
+	ep.Schema.Responses[201][0].Headers = map[string]string{
+		"Location":     "/users/{{body.id}}",
+		"X-Request-ID": "{{headers.X-Correlation-ID}}",
+	}
+
+	server := New([]*endpoint.EndpointWithFile{ep})
+	mux := server.createTestMux()
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"id": 42}`))
+	req.Header.Set("X-Correlation-ID", "corr-9")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Location"); got != "/users/42" {
+		t.Errorf("Expected Location /users/42, got %q", got)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "corr-9" {
+		t.Errorf("Expected X-Request-ID corr-9, got %q", got)
+	}
+}
+
+func TestServer_HeaderExpressionsUseCallCount(t *testing.T) {
+	ep := createEndpointWithFile("GET /quota", 200, `{}`)
+	ep.Schema.Responses[200][0].Headers = map[string]string{
+		"X-RateLimit-Remaining": "{{10 - call_count}}",
 	}
 
-	// Set fallback handler for all other routes
-	mux.HandleFunc("/", s.fallbackHandler())
+	server := New([]*endpoint.EndpointWithFile{ep})
+	mux := server.createTestMux()
+
+	for _, want := range []string{"9", "8", "7"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quota", nil))
+
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("Expected X-RateLimit-Remaining %s, got %q", want, got)
+		}
+	}
+}
 
-	return mux
+func TestServer_HeadersSeePreviousResponse(t *testing.T) {
+	previous := map[string]string{"X-Previous": "{{response_index}} {{previous_status}}"}
+	ep := createEndpointWithFile("GET /jobs/1", 200, `{}`)
+	ep.Schema.Responses[200][0].Headers = previous
+	ep.Schema.Responses[429] = []endpoint.Response{{Title: "Slow down", StatusCode: 429, Headers: previous}}
+	ep.Schema.MaxCalls = 1
+
+	server := New([]*endpoint.EndpointWithFile{ep})
+	mux := server.createTestMux()
+
+	for _, want := range []string{"-1 0", "0 200", "1 429"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/1", nil))
+
+		if got := rec.Header().Get("X-Previous"); got != want {
+			t.Errorf("Expected X-Previous %q, got %q", want, got)
+		}
+	}
+}
+
+func TestServer_DraftHeader(t *testing.T) {
+	draft := createEndpointWithFile("GET /reports", 501, "")
+	draft.Schema.Responses[501][0].Draft = true
+
+	server := New([]*endpoint.EndpointWithFile{draft, createEndpointWithFile("GET /users", 200, `[]`)})
+	mux := server.createTestMux()
+
+	for path, want := range map[string]string{"/reports": "true", "/users": ""} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if got := rec.Header().Get(DraftHeader); got != want {
+			t.Errorf("%s: Expected %s %q, got %q", path, DraftHeader, want, got)
+		}
+	}
+}
+
+// Helper method for testing - returns the server handler without starting a server
+func (s *Server) createTestMux() http.Handler {
+	return s.Handler()
 }
 
 func BenchmarkServer_SpecificEndpoint(b *testing.B) {
@@ -454,7 +718,7 @@ func TestServer_Serve_InvalidPort(t *testing.T) {
 			}
 			server := New(endpoints)
 
-			err := server.Serve(tt.port)
+			err := server.Serve(context.Background(), tt.port)
 			if err == nil {
 				t.Error("Serve() should return error for invalid port")
 			}
@@ -709,6 +973,213 @@ func TestServer_NoValidation_WhenNoValidator(t *testing.T) {
 	}
 }
 
+func TestServer_RequestValidation_TemplatedErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeMock(t, dir, "user.json", `{"type": "object", "properties": {"age": {"type": "integer"}}, "required": ["name"]}`)
+	mock := writeMock(t, dir, "users.apimock", `POST /api/users
+Accept: application/json
+
+{"$ref": "user.json"}
+
+-- 201: Created
+
+-- 400: Invalid user
+ContentType: application/json
+X-Error: {{validation.errors[0].keyword}}
+
+{"errors": {{validation.errors}}}
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(mock)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	New(endpoints).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"age": "ten"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Errors []endpoint.ValidationIssue `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected the issues as JSON, got %q: %v", rec.Body.String(), err)
+	}
+	keywords := make(map[string]string)
+	for _, issue := range body.Errors {
+		keywords[issue.InstancePath] = issue.Keyword
+	}
+	if keywords[""] != "required" || keywords["/age"] != "type" {
+		t.Errorf("Expected required and type issues, got %+v", body.Errors)
+	}
+	if got := rec.Header().Get("X-Error"); got == "" || strings.Contains(got, "{{") {
+		t.Errorf("Expected X-Error to name a keyword, got %q", got)
+	}
+}
+
+func TestServer_RequiredParameters(t *testing.T) {
+	dir := t.TempDir()
+	search := writeMock(t, dir, "search.apimock", `GET /api/search
+  ?q!=shoes
+  &page=1
+X-Api-Key!: secret
+Required-Status: 422
+
+-- 200: OK
+
+-- 422: Missing parameter
+ContentType: application/json
+
+{"field": "{{validation.first.path}}", "count": {{validation.count}}}
+`)
+	orders := writeMock(t, dir, "orders.apimock", `POST /api/orders
+Authorization!: Bearer token
+Accept: application/json
+
+{"type": "object", "required": ["id"]}
+
+-- 201: Created
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(search, orders)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	handler := New(endpoints).Handler()
+
+	tests := []struct {
+		name     string
+		request  *http.Request
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "all sent", request: httptest.NewRequest(http.MethodGet, "/api/search?q=", nil), header: "X-Api-Key", wantCode: http.StatusOK},
+		{name: "missing query and header", request: httptest.NewRequest(http.MethodGet, "/api/search?page=2", nil), wantCode: http.StatusUnprocessableEntity, wantBody: `{"field": "/headers/X-Api-Key", "count": 2}`},
+		{name: "missing query", request: httptest.NewRequest(http.MethodGet, "/api/search", nil), header: "X-Api-Key", wantCode: http.StatusUnprocessableEntity, wantBody: `{"field": "/query/q", "count": 1}`},
+		// Checked before the body, which is invalid too
+		{name: "default status", request: httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{}`)), wantCode: http.StatusBadRequest, wantBody: "Request validation failed: missing required header Authorization\n"},
+		{name: "body validated next", request: httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{}`)), header: "Authorization", wantCode: http.StatusBadRequest},
+		{name: "valid order", request: httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"id": 1}`)), header: "Authorization", wantCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.header != "" {
+				tt.request.Header.Set(tt.header, "x")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_TypedPathParameters(t *testing.T) {
+	dir := t.TempDir()
+	users := writeMock(t, dir, "users.apimock", `GET /users/{id:int}
+
+-- 200: OK
+
+-- 400: Bad id
+ContentType: application/json
+
+{"field": "{{validation.first.path}}"}
+`)
+	byRef := writeMock(t, dir, "refs.apimock", `GET /refs/{ref:uuid}
+
+-- 200: OK
+`)
+	orders := writeMock(t, dir, "orders.apimock", `GET /orders/{id:[0-9]+}
+
+-- 200: OK
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(users, byRef, orders)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	handler := New(endpoints).Handler()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/users/42", wantCode: http.StatusOK},
+		{path: "/users/abc", wantCode: http.StatusBadRequest, wantBody: `{"field": "/params/id"}`},
+		{path: "/refs/0b6a2f5e-7c1d-4e8a-9f3b-2d4c6e8a0b1c", wantCode: http.StatusOK},
+		{path: "/refs/42", wantCode: http.StatusBadRequest, wantBody: "Request validation failed: path parameter ref must be of type uuid, got \"42\"\n"},
+		// Regular expression constraints still select routes
+		{path: "/orders/abc", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+// quantityScript answers 409 for orders of more than the stock, from the
+// decoded request body.
+type quantityScript struct{}
+
+func (quantityScript) Run(ctx *endpoint.TemplateContext) (endpoint.ScriptResult, error) {
+	body, _ := ctx.Body.(map[string]any)
+	qty, ok := body["qty"].(float64)
+	if !ok {
+		return endpoint.ScriptResult{}, errors.New("qty is missing")
+	}
+	if qty > 3 {
+		return endpoint.ScriptResult{Status: http.StatusConflict, Body: []byte(`{"error": "out of stock"}`)}, nil
+	}
+	return endpoint.ScriptResult{Headers: map[string]string{"X-Qty": fmt.Sprint(qty)}}, nil
+}
+
+func TestServer_ResponseScript(t *testing.T) {
+	ep := createEndpointWithFile("POST /orders", 201, `{"status": "placed"}`)
+	resp := ep.Schema.Responses[201][0]
+	resp.ScriptFile, resp.Script = "hooks/orders.js", quantityScript{}
+	ep.Schema.Responses[201][0] = resp
+	handler := New([]*endpoint.EndpointWithFile{ep}).Handler()
+
+	tests := []struct {
+		body       string
+		wantCode   int
+		wantBody   string
+		wantHeader string
+	}{
+		{body: `{"qty": 2}`, wantCode: http.StatusCreated, wantBody: `{"status": "placed"}`, wantHeader: "2"},
+		{body: `{"qty": 5}`, wantCode: http.StatusConflict, wantBody: `{"error": "out of stock"}`},
+		{body: `{}`, wantCode: http.StatusInternalServerError, wantBody: "Script hooks/orders.js failed: qty is missing\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode || rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected %d %q, got %d %q", tt.wantCode, tt.wantBody, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("X-Qty"); got != tt.wantHeader {
+				t.Errorf("Expected X-Qty %q, got %q", tt.wantHeader, got)
+			}
+		})
+	}
+}
+
 // Helper type to create a ReadCloser from a string
 type bodyReader struct {
 	body string
@@ -727,3 +1198,159 @@ func (b *bodyReader) Read(p []byte) (n int, err error) {
 func (b *bodyReader) Close() error {
 	return nil
 }
+
+func TestServer_SharedRouteDispatchesByMatcher(t *testing.T) {
+	getUser := createEndpointWithFile("POST /soap", 200, `<GetUserResponse/>`)
+	getUser.Schema.Matchers = []endpoint.RequestMatcher{&endpoint.SOAPMatcher{Action: "urn:GetUser"}}
+	deleteUser := createEndpointWithFile("POST /soap", 200, `<DeleteUserResponse/>`)
+	deleteUser.Schema.Matchers = []endpoint.RequestMatcher{&endpoint.SOAPMatcher{Action: "urn:DeleteUser"}}
+
+	server := New([]*endpoint.EndpointWithFile{getUser, deleteUser})
+	mux := server.createTestMux()
+
+	tests := []struct {
+		action   string
+		wantCode int
+		wantBody string
+	}{
+		{"urn:GetUser", 200, `<GetUserResponse/>`},
+		{"urn:DeleteUser", 200, `<DeleteUserResponse/>`},
+		{"urn:Unknown", 404, "404 - Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/soap", nil)
+			req.Header.Set("SOAPAction", tt.action)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_SharedRouteParamsByPosition(t *testing.T) {
+	byID := createEndpointWithFile("GET /orders/{id}", 200, `{"id": "{{params.id}}"}`)
+	byID.Schema.Matchers = []endpoint.RequestMatcher{mustPathMatcher(t, "{id:[0-9]+}", "id", "[0-9]+")}
+	bySlug := createEndpointWithFile("GET /orders/{slug}", 200, `{"slug": "{{params.slug}}"}`)
+
+	mux := New([]*endpoint.EndpointWithFile{byID, bySlug}).createTestMux()
+
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{"/orders/42", `{"id": "42"}`},
+		{"/orders/latest", `{"slug": "latest"}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.wantBody {
+			t.Errorf("GET %s: expected body %q, got %q", tt.path, tt.wantBody, rec.Body.String())
+		}
+	}
+}
+
+func TestServer_SharedRouteDispatchesByBody(t *testing.T) {
+	premium := createEndpointWithFile("POST /orders", 201, `{"tier": "premium"}`)
+	premiumMatcher, err := endpoint.NewBodyMatcher(`$.type == "premium"`)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	premium.Schema.Matchers = []endpoint.RequestMatcher{premiumMatcher}
+	standard := createEndpointWithFile("POST /orders", 201, `{"tier": "standard"}`)
+
+	tests := []struct {
+		body     string
+		wantBody string
+	}{
+		{`{"type": "premium"}`, `{"tier": "premium"}`},
+		{`{"type": "basic"}`, `{"tier": "standard"}`},
+		{``, `{"tier": "standard"}`},
+	}
+
+	// The endpoint without matchers must not shadow the other, whichever
+	// was loaded first
+	orders := map[string][]*endpoint.EndpointWithFile{
+		"matcher first":   {premium, standard},
+		"catch-all first": {standard, premium},
+	}
+	for name, endpoints := range orders {
+		mux := New(endpoints).createTestMux()
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.body, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+				rec := httptest.NewRecorder()
+
+				mux.ServeHTTP(rec, req)
+
+				if rec.Body.String() != tt.wantBody {
+					t.Errorf("Expected body %q, got %q", tt.wantBody, rec.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestServer_ConditionalResponses(t *testing.T) {
+	dir := t.TempDir()
+	mock := writeMock(t, dir, "orders.apimock", `GET /orders/{id}
+
+-- 200: OK
+ContentType: application/json
+
+{"id": "{{params.id}}", "call": {{call_count}}}
+
+-- 404: Not found
+
+> params.id == "0"
+{"error": "order {{params.id}} not found"}
+
+-- 429: Too many calls
+
+> call_count >> calls
+> calls > 2
+> or headers["X-Limit"] == "now"
+{"calls": {{calls}}}
+`)
+	endpoints, err := endpoint.ParseAPIMockFiles(mock)
+	if err != nil {
+		t.Fatalf("failed to parse mocks: %v", err)
+	}
+	handler := New(endpoints).Handler()
+
+	tests := []struct {
+		path     string
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/orders/7", wantCode: http.StatusOK, wantBody: `{"id": "7", "call": 1}`},
+		{path: "/orders/0", wantCode: http.StatusNotFound, wantBody: `{"error": "order 0 not found"}`},
+		{path: "/orders/7", wantCode: http.StatusTooManyRequests, wantBody: `{"calls": 3}`},
+		{path: "/orders/7", header: "now", wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Limit", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.wantCode {
+			t.Fatalf("GET %s: expected status %d, got %d: %s", tt.path, tt.wantCode, rec.Code, rec.Body.String())
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("GET %s: expected body %q, got %q", tt.path, tt.wantBody, rec.Body.String())
+		}
+	}
+}
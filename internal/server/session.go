@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/session"
+)
+
+// handleSession runs the session action of schema for a request and returns
+// the session of the request, if any. ok is false when the endpoint requires
+// a session the request does not have.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request, body []byte, schema *endpoint.EndpointSchema) (sess *session.Session, ok bool) {
+	sessions := s.namespace(r).sessions
+	name := schema.SessionCookie
+	if name == "" {
+		name = endpoint.DefaultSessionCookie
+	}
+	if cookie, err := r.Cookie(name); err == nil {
+		sess, _ = sessions.Get(cookie.Value)
+	}
+
+	switch schema.Session {
+	case endpoint.SessionCreate:
+		var data any
+		if json.Unmarshal(body, &data) != nil {
+			data = nil
+		}
+		sess = sessions.Create(data)
+		http.SetCookie(w, &http.Cookie{Name: name, Value: sess.ID, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	case endpoint.SessionDestroy:
+		if sess != nil {
+			sessions.Delete(sess.ID)
+		}
+		http.SetCookie(w, &http.Cookie{Name: name, Value: "", Path: "/", HttpOnly: true, MaxAge: -1})
+	case endpoint.SessionRequire:
+		return sess, sess != nil
+	}
+	return sess, true
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
+)
+
+// Trace records the handling of every request as spans exported by t: a
+// server span continuing the trace context of the request, with spans for
+// route matching, request validation, rendering the response and
+// forwarding it to an upstream. Upstreams and callbacks receive the trace
+// context in their traceparent header.
+func (s *Server) Trace(t *tracing.Tracer) {
+	s.tracer = t
+}
+
+// traced wraps next in the server span of each request, when tracing.
+func (s *Server) traced(next http.Handler) http.Handler {
+	if s.tracer == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, span := s.tracer.StartRequest(r, r.Method)
+		defer span.End()
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// traceHit names the server span of a request answered by ep, or by no
+// endpoint when ep is nil, after its route and records the status sent.
+func traceHit(r *http.Request, ep *endpoint.EndpointWithFile, status int) {
+	span := tracing.FromContext(r.Context())
+	if span == nil {
+		return
+	}
+	if ep != nil {
+		// Routes are patterns such as "GET /users/{id}", or a bare path
+		// matching any method
+		method, path, found := strings.Cut(ep.Schema.Route, " ")
+		if !found {
+			method, path = r.Method, ep.Schema.Route
+		}
+		span.SetName(method + " " + path)
+		span.SetAttribute("http.route", path)
+		span.SetAttribute("anansi.file", ep.FilePath)
+	}
+	span.SetAttribute("http.response.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.SetError(fmt.Errorf("answered %d", status))
+	}
+}
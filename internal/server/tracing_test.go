@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/tracing"
+)
+
+func TestServer_Trace(t *testing.T) {
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+	}
+	var mu sync.Mutex
+	var spans []span
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	var forwarded string
+	upstreamSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(tracing.TraceparentHeader)
+	}))
+	defer upstreamSrv.Close()
+	base, _ := url.Parse(upstreamSrv.URL)
+
+	payments := createEndpointWithFile("POST /payments", 200, `{}`)
+	payments.Schema.Upstream = &endpoint.Upstream{URL: base}
+	users := createEndpointWithFile("GET /users", 200, `[]`)
+	srv := New([]*endpoint.EndpointWithFile{payments, users})
+	tracer := tracing.New(collector.URL, "test")
+	srv.Trace(tracer)
+	handler := srv.Handler()
+
+	const traceID = "4bf92f3577b34da6a3ce929b0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`)))
+
+	if err := tracer.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	byName := make(map[string]span)
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	served, rendered := byName["GET /users"], byName["render response"]
+	if served.TraceID != traceID || served.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Expected the server span to continue the trace of the request, got %+v among %+v", served, spans)
+	}
+	if rendered.TraceID != traceID || rendered.ParentSpanID != served.SpanID {
+		t.Errorf("Expected a render span under the server span, got %+v", rendered)
+	}
+	if _, ok := byName["validate request"]; !ok {
+		t.Errorf("Expected a validation span, got %+v", spans)
+	}
+
+	proxied := byName["forward "+base.Host]
+	if proxied.SpanID == "" || byName["POST /payments"].SpanID != proxied.ParentSpanID {
+		t.Errorf("Expected a forward span under the server span of POST /payments, got %+v", spans)
+	}
+	if want := "00-" + proxied.TraceID + "-" + proxied.SpanID + "-01"; forwarded != want {
+		t.Errorf("Expected the upstream to receive traceparent %q, got %q", want, forwarded)
+	}
+}
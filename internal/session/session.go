@@ -0,0 +1,75 @@
+// Package session keeps the server-side sessions of mocked login flows,
+// keyed by the value of a session cookie.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Session is one logged-in client.
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+	// Data is the decoded JSON body of the request that created the session,
+	// nil when it was not JSON
+	Data any
+}
+
+// Store holds the live sessions. It is safe for concurrent use.
+type Store struct {
+	mu         sync.RWMutex
+	sessions   map[string]*Session
+	sequential bool // number the sessions instead of using random IDs
+	created    int
+}
+
+func NewStore() *Store {
+	return &Store{sessions: make(map[string]*Session)}
+}
+
+// UseSequentialIDs makes the store name sessions session-1, session-2, ...
+// so runs making the same requests get the same session IDs.
+func (s *Store) UseSequentialIDs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequential = true
+}
+
+// Create starts a session with a random ID, or the next sequential one.
+func (s *Store) Create(data any) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.created++
+	var id string
+	if s.sequential {
+		id = "session-" + strconv.Itoa(s.created)
+	} else {
+		random := make([]byte, 16)
+		rand.Read(random)
+		id = hex.EncodeToString(random)
+	}
+
+	sess := &Session{ID: id, CreatedAt: time.Now(), Data: data}
+	s.sessions[sess.ID] = sess
+	return sess
+}
+
+// Get returns the session with the given ID.
+func (s *Store) Get(id string) (*Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+// Delete ends the session with the given ID, if any.
+func (s *Store) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
@@ -0,0 +1,38 @@
+package session
+
+import "testing"
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	first := store.Create(map[string]any{"user": "ana"})
+	second := store.Create(nil)
+
+	if first.ID == second.ID {
+		t.Fatalf("expected unique session IDs, got %s twice", first.ID)
+	}
+	if got, ok := store.Get(first.ID); !ok || got != first {
+		t.Errorf("Get(%s) = %v, %v; want the created session", first.ID, got, ok)
+	}
+
+	store.Delete(first.ID)
+	if _, ok := store.Get(first.ID); ok {
+		t.Error("expected deleted session to be gone")
+	}
+	if _, ok := store.Get(second.ID); !ok {
+		t.Error("expected other sessions to be kept")
+	}
+	if _, ok := store.Get("unknown"); ok {
+		t.Error("expected unknown session to be missing")
+	}
+}
+
+func TestStore_UseSequentialIDs(t *testing.T) {
+	store := NewStore()
+	store.UseSequentialIDs()
+
+	for _, want := range []string{"session-1", "session-2"} {
+		if sess := store.Create(nil); sess.ID != want {
+			t.Errorf("expected session ID %s, got %s", want, sess.ID)
+		}
+	}
+}
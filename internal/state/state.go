@@ -2,12 +2,16 @@ package state
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
 type StateManager struct {
-	mu    sync.RWMutex
-	index int
-	max   int
+	mu       sync.RWMutex
+	index    int
+	max      int
+	onChange func(index int)
+	released atomic.Bool // see SetForced
+	calls    atomic.Int64
 }
 
 func New(max int) *StateManager {
@@ -17,18 +21,46 @@ func New(max int) *StateManager {
 	}
 }
 
+// SetForced tells whether the selected response is served to every request,
+// the default, or only to requests passing the checks of the endpoint, as
+// its typed and required parameters and body schema.
+func (s *StateManager) SetForced(forced bool) {
+	s.released.Store(!forced)
+}
+
+// Forced reports whether the selected response is served to every request.
+func (s *StateManager) Forced() bool {
+	return !s.released.Load()
+}
+
 func (s *StateManager) SetIndex(index int) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	previous := s.index
 	if index < 0 {
 		s.index = 0
-		return
-	}
-	if index >= s.max {
+	} else if index >= s.max {
 		s.index = s.max - 1
-		return
+	} else {
+		s.index = index
+	}
+	current, onChange := s.index, s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil && current != previous {
+		onChange(current)
+	}
+}
+
+// SetMax changes the number of responses to choose from, as when the file
+// of the endpoint is edited, moving the index to the last response if it is
+// past it.
+func (s *StateManager) SetMax(max int) {
+	s.mu.Lock()
+	s.max = max
+	s.mu.Unlock()
+	if index := s.Index(); index >= max {
+		s.SetIndex(max - 1)
 	}
-	s.index = index
 }
 
 func (s *StateManager) Index() int {
@@ -36,3 +68,113 @@ func (s *StateManager) Index() int {
 	defer s.mu.RUnlock()
 	return s.index
 }
+
+// OnChange registers fn to be called with the new index whenever SetIndex
+// changes it.
+func (s *StateManager) OnChange(fn func(index int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// AddCall counts a request to the endpoint and returns the number of calls,
+// the current one included, as the call_count of templates.
+func (s *StateManager) AddCall() int64 {
+	return s.calls.Add(1)
+}
+
+// CallCount returns the number of requests to the endpoint since it started
+// or since ResetCallCount.
+func (s *StateManager) CallCount() int64 {
+	return s.calls.Load()
+}
+
+// ResetCallCount counts calls from zero again, so that responses depending
+// on the call count start over.
+func (s *StateManager) ResetCallCount() {
+	s.calls.Store(0)
+}
+
+// Selection holds the selected response of each of several endpoints, each
+// in its own StateManager, and the endpoint the interactive UI is showing.
+// An endpoint keeps its selected response while the UI shows another.
+type Selection struct {
+	mu       sync.RWMutex
+	managers []*StateManager
+	current  int
+	controls Controls
+}
+
+// NewSelection returns a selection of len(counts) endpoints, the i-th
+// offering counts[i] responses. Every endpoint starts on its first response,
+// and the first endpoint is shown.
+func NewSelection(counts ...int) *Selection {
+	s := &Selection{managers: make([]*StateManager, len(counts))}
+	for i, count := range counts {
+		s.managers[i] = New(count)
+	}
+	return s
+}
+
+// Controls returns the switches applying to every endpoint.
+func (s *Selection) Controls() *Controls {
+	return &s.controls
+}
+
+// Len returns the number of endpoints.
+func (s *Selection) Len() int {
+	return len(s.managers)
+}
+
+// Endpoint returns the state of the i-th endpoint.
+func (s *Selection) Endpoint(i int) *StateManager {
+	return s.managers[i]
+}
+
+// ResetCallCounts resets the call count of every endpoint.
+func (s *Selection) ResetCallCounts() {
+	for _, sm := range s.managers {
+		sm.ResetCallCount()
+	}
+}
+
+// Current returns the position of the endpoint shown.
+func (s *Selection) Current() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// SetCurrent shows the i-th endpoint, clamping i to the endpoints there are.
+func (s *Selection) SetCurrent(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = max(0, min(i, len(s.managers)-1))
+}
+
+// Controls are the switches of the interactive UI applying to every
+// endpoint, read by the server on every request. They start off.
+type Controls struct {
+	latency atomic.Bool
+	chaos   atomic.Bool
+}
+
+// SetLatency turns the artificial latency of every response on or off.
+func (c *Controls) SetLatency(on bool) {
+	c.latency.Store(on)
+}
+
+// Latency reports whether responses are delayed.
+func (c *Controls) Latency() bool {
+	return c.latency.Load()
+}
+
+// SetChaos turns the injection of faults into responses on or off.
+func (c *Controls) SetChaos(on bool) {
+	c.chaos.Store(on)
+}
+
+// Chaos reports whether faults are injected into responses.
+func (c *Controls) Chaos() bool {
+	return c.chaos.Load()
+}
@@ -323,3 +323,105 @@ func BenchmarkStateManager_ConcurrentIndex(b *testing.B) {
 		}
 	})
 }
+
+func TestStateManager_OnChange(t *testing.T) {
+	sm := New(5)
+	var changes []int
+	sm.OnChange(func(index int) { changes = append(changes, index) })
+
+	for _, index := range []int{2, 2, 10, 4, -1} {
+		sm.SetIndex(index)
+	}
+
+	want := []int{2, 4, 0}
+	if len(changes) != len(want) {
+		t.Fatalf("OnChange called with %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("OnChange called with %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestSelection(t *testing.T) {
+	sel := NewSelection(3, 2)
+	if sel.Len() != 2 || sel.Current() != 0 {
+		t.Fatalf("NewSelection() has %d endpoints showing %d, want 2 showing 0", sel.Len(), sel.Current())
+	}
+
+	sel.Endpoint(0).SetIndex(2)
+	sel.SetCurrent(1)
+	sel.Endpoint(1).SetIndex(1)
+	sel.SetCurrent(0)
+	if got := sel.Endpoint(0).Index(); got != 2 {
+		t.Errorf("Endpoint(0).Index() = %d after showing another endpoint, want 2", got)
+	}
+	if got := sel.Endpoint(1).Index(); got != 1 {
+		t.Errorf("Endpoint(1).Index() = %d, want 1", got)
+	}
+
+	for _, tt := range []struct{ set, want int }{{5, 1}, {-1, 0}} {
+		sel.SetCurrent(tt.set)
+		if got := sel.Current(); got != tt.want {
+			t.Errorf("SetCurrent(%d) shows %d, want %d", tt.set, got, tt.want)
+		}
+	}
+}
+
+func TestStateManager_SetMax(t *testing.T) {
+	sm := New(5)
+	sm.SetIndex(4)
+
+	sm.SetMax(3)
+	if got := sm.Index(); got != 2 {
+		t.Errorf("Index() after SetMax(3) = %d, want 2", got)
+	}
+	sm.SetMax(6)
+	sm.SetIndex(5)
+	if got := sm.Index(); got != 5 {
+		t.Errorf("Index() after SetMax(6) = %d, want 5", got)
+	}
+}
+
+func TestStateManager_Forced(t *testing.T) {
+	sm := New(3)
+	if !sm.Forced() {
+		t.Error("Forced() = false for a new state, want true")
+	}
+	sm.SetForced(false)
+	if sm.Forced() {
+		t.Error("Forced() = true after SetForced(false)")
+	}
+}
+
+func TestControls(t *testing.T) {
+	controls := NewSelection(1).Controls()
+	if controls.Latency() || controls.Chaos() {
+		t.Fatal("Expected the controls to start off")
+	}
+	controls.SetLatency(true)
+	controls.SetChaos(true)
+	if !controls.Latency() || !controls.Chaos() {
+		t.Error("Expected the controls on after turning them on")
+	}
+}
+
+func TestStateManager_CallCount(t *testing.T) {
+	sel := NewSelection(1, 1)
+	users, orders := sel.Endpoint(0), sel.Endpoint(1)
+	users.AddCall()
+	if got := users.AddCall(); got != 2 {
+		t.Errorf("AddCall() = %d, want 2", got)
+	}
+	orders.AddCall()
+
+	users.ResetCallCount()
+	if users.CallCount() != 0 || orders.CallCount() != 1 {
+		t.Errorf("After ResetCallCount(), CallCount() = %d and %d, want 0 and 1", users.CallCount(), orders.CallCount())
+	}
+	sel.ResetCallCounts()
+	if orders.CallCount() != 0 {
+		t.Errorf("After ResetCallCounts(), CallCount() = %d, want 0", orders.CallCount())
+	}
+}
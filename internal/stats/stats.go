@@ -0,0 +1,235 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// Collector counts the requests served by each endpoint.
+type Collector struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	endpoints []*endpoint.EndpointWithFile
+	hits      map[*endpoint.EndpointWithFile]*EndpointReport
+	unmatched int
+	recent    rolling
+	now       func() time.Time
+}
+
+func NewCollector(endpoints []*endpoint.EndpointWithFile) *Collector {
+	c := &Collector{
+		startedAt: time.Now(),
+		endpoints: endpoints,
+		hits:      make(map[*endpoint.EndpointWithFile]*EndpointReport, len(endpoints)),
+		now:       time.Now,
+	}
+	for _, ep := range endpoints {
+		c.hits[ep] = &EndpointReport{
+			Route:       ep.Schema.Route,
+			File:        ep.FilePath,
+			StatusCodes: make(map[int]int),
+		}
+	}
+	return c
+}
+
+// Record counts a request answered by ep with the given status code.
+// validationFailed tells whether the request body was rejected by the
+// endpoint's schema.
+func (c *Collector) Record(ep *endpoint.EndpointWithFile, status int, validationFailed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hits, ok := c.hits[ep]
+	if !ok {
+		return
+	}
+	c.recent.add(c.now(), status >= 400)
+	hits.Hits++
+	hits.StatusCodes[status]++
+	if validationFailed {
+		hits.ValidationFailures++
+	}
+}
+
+// RecordEvalErrors counts the conditions and placeholders that failed to
+// evaluate while ep served a request.
+func (c *Collector) RecordEvalErrors(ep *endpoint.EndpointWithFile, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hits, ok := c.hits[ep]; ok {
+		hits.EvalErrors += n
+	}
+}
+
+// RecordUnmatched counts a request that no endpoint answered.
+func (c *Collector) RecordUnmatched() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unmatched++
+	c.recent.add(c.now(), true)
+}
+
+// RecordOverBudget counts a request that ep served slower than its latency
+// budget.
+func (c *Collector) RecordOverBudget(ep *endpoint.EndpointWithFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hits, ok := c.hits[ep]; ok {
+		hits.OverBudget++
+	}
+}
+
+// Report is a summary of the requests served since the collector was created.
+type Report struct {
+	StartedAt          time.Time        `json:"startedAt"`
+	Duration           string           `json:"duration"`
+	Requests           int              `json:"requests"`
+	Unmatched          int              `json:"unmatched"`
+	ValidationFailures int              `json:"validationFailures"`
+	EvalErrors         int              `json:"evalErrors"`
+	OverBudget         int              `json:"overBudget"`
+	Windows            []WindowReport   `json:"windows"`
+	Endpoints          []EndpointReport `json:"endpoints"`
+	NeverHit           []string         `json:"neverHit"`
+}
+
+// EndpointReport holds the counters of one endpoint.
+type EndpointReport struct {
+	Route              string `json:"route"`
+	File               string `json:"file"`
+	Hits               int    `json:"hits"`
+	ValidationFailures int    `json:"validationFailures"`
+	// EvalErrors counts the conditions and placeholders that failed to
+	// evaluate
+	EvalErrors int `json:"evalErrors"`
+	// OverBudget counts the requests served slower than the latency budget
+	// of the endpoint
+	OverBudget  int         `json:"overBudget"`
+	StatusCodes map[int]int `json:"statusCodes"`
+}
+
+// Report returns a snapshot of the collected statistics. Endpoints are listed
+// in the order they were loaded.
+func (c *Collector) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &Report{
+		StartedAt: c.startedAt,
+		Duration:  time.Since(c.startedAt).Round(time.Second).String(),
+		Unmatched: c.unmatched,
+		Requests:  c.unmatched,
+		Windows:   c.recent.report(c.now()),
+		Endpoints: make([]EndpointReport, 0, len(c.endpoints)),
+		NeverHit:  make([]string, 0),
+	}
+	for _, ep := range c.endpoints {
+		hits := *c.hits[ep]
+		hits.StatusCodes = make(map[int]int, len(c.hits[ep].StatusCodes))
+		for code, n := range c.hits[ep].StatusCodes {
+			hits.StatusCodes[code] = n
+		}
+
+		report.Requests += hits.Hits
+		report.ValidationFailures += hits.ValidationFailures
+		report.EvalErrors += hits.EvalErrors
+		report.OverBudget += hits.OverBudget
+		report.Endpoints = append(report.Endpoints, hits)
+		if hits.Hits == 0 {
+			report.NeverHit = append(report.NeverHit, hits.Route)
+		}
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the report as a Markdown document.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Anansi Proxy Report\n\n")
+	fmt.Fprintf(&b, "- Started: %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Duration: %s\n", r.Duration)
+	fmt.Fprintf(&b, "- Requests: %d\n", r.Requests)
+	fmt.Fprintf(&b, "- Unmatched: %d\n", r.Unmatched)
+	fmt.Fprintf(&b, "- Validation failures: %d\n", r.ValidationFailures)
+	fmt.Fprintf(&b, "- Evaluation errors: %d\n", r.EvalErrors)
+	fmt.Fprintf(&b, "- Over budget: %d\n", r.OverBudget)
+
+	b.WriteString("\n## Recent Traffic\n\n")
+	b.WriteString("| Window | Requests | Requests/s | Errors | Error rate |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+	for _, w := range r.Windows {
+		fmt.Fprintf(&b, "| %s | %d | %.2f | %d | %.1f%% |\n", w.Window, w.Requests, w.RequestRate, w.Errors, w.ErrorRate*100)
+	}
+
+	b.WriteString("\n## Endpoints\n\n")
+	b.WriteString("| Route | Hits | Validation failures | Evaluation errors | Over budget | Status codes | File |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | --- | --- |\n")
+	for _, ep := range r.Endpoints {
+		fmt.Fprintf(&b, "| `%s` | %d | %d | %d | %d | %s | %s |\n", ep.Route, ep.Hits, ep.ValidationFailures, ep.EvalErrors, ep.OverBudget, formatStatusCodes(ep.StatusCodes), ep.File)
+	}
+
+	if len(r.NeverHit) > 0 {
+		b.WriteString("\n## Never Hit\n\n")
+		for _, route := range r.NeverHit {
+			fmt.Fprintf(&b, "- `%s`\n", route)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFile writes the report to path, as Markdown when the file has a .md
+// extension and as JSON otherwise.
+func (r *Report) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		err = r.WriteMarkdown(f)
+	default:
+		err = r.WriteJSON(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// formatStatusCodes renders status code counts as "200×3, 400×1".
+func formatStatusCodes(codes map[int]int) string {
+	if len(codes) == 0 {
+		return "-"
+	}
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Ints(keys)
+
+	parts := make([]string, len(keys))
+	for i, code := range keys {
+		parts[i] = fmt.Sprintf("%d×%d", code, codes[code])
+	}
+	return strings.Join(parts, ", ")
+}
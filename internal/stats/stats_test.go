@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+func newTestEndpoints() []*endpoint.EndpointWithFile {
+	return []*endpoint.EndpointWithFile{
+		{Schema: &endpoint.EndpointSchema{Route: "GET /users"}, FilePath: "users.apimock"},
+		{Schema: &endpoint.EndpointSchema{Route: "POST /users"}, FilePath: "create.apimock"},
+		{Schema: &endpoint.EndpointSchema{Route: "GET /health"}, FilePath: "health.apimock"},
+	}
+}
+
+func TestCollector_Report(t *testing.T) {
+	endpoints := newTestEndpoints()
+	c := NewCollector(endpoints)
+
+	c.Record(endpoints[0], 200, false)
+	c.Record(endpoints[0], 200, false)
+	c.Record(endpoints[1], 400, true)
+	c.RecordEvalErrors(endpoints[1], 2)
+	c.RecordOverBudget(endpoints[0])
+	c.Record(&endpoint.EndpointWithFile{Schema: &endpoint.EndpointSchema{Route: "GET /other"}}, 200, false)
+	c.RecordUnmatched()
+
+	r := c.Report()
+	if r.Requests != 4 {
+		t.Errorf("expected 4 requests, got %d", r.Requests)
+	}
+	if r.Unmatched != 1 {
+		t.Errorf("expected 1 unmatched request, got %d", r.Unmatched)
+	}
+	if r.ValidationFailures != 1 {
+		t.Errorf("expected 1 validation failure, got %d", r.ValidationFailures)
+	}
+	if r.EvalErrors != 2 || r.Endpoints[1].EvalErrors != 2 {
+		t.Errorf("expected 2 evaluation errors for POST /users, got %d in total and %+v", r.EvalErrors, r.Endpoints[1])
+	}
+	if r.OverBudget != 1 || r.Endpoints[0].OverBudget != 1 {
+		t.Errorf("expected 1 request over budget for GET /users, got %d in total and %+v", r.OverBudget, r.Endpoints[0])
+	}
+	if r.Endpoints[0].Hits != 2 || r.Endpoints[0].StatusCodes[200] != 2 {
+		t.Errorf("unexpected counters for GET /users: %+v", r.Endpoints[0])
+	}
+	if len(r.NeverHit) != 1 || r.NeverHit[0] != "GET /health" {
+		t.Errorf("expected GET /health never hit, got %v", r.NeverHit)
+	}
+
+	// The report is a snapshot
+	c.Record(endpoints[0], 500, false)
+	if r.Endpoints[0].StatusCodes[500] != 0 {
+		t.Error("expected report to be unaffected by later requests")
+	}
+}
+
+func TestReport_WriteFile(t *testing.T) {
+	endpoints := newTestEndpoints()
+	c := NewCollector(endpoints)
+	c.Record(endpoints[0], 200, false)
+	r := c.Report()
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := r.WriteFile(jsonPath); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected JSON report, got error %v", err)
+	}
+	if decoded.Requests != 1 {
+		t.Errorf("expected 1 request in JSON report, got %d", decoded.Requests)
+	}
+
+	mdPath := filepath.Join(dir, "report.md")
+	if err := r.WriteFile(mdPath); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	data, err = os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, want := range []string{"# Anansi Proxy Report", "| `GET /users` | 1 | 0 | 0 | 0 | 200×1 |", "## Never Hit", "- `GET /health`"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected Markdown report to contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestReport_WriteMarkdown_OmitsNeverHitWhenAllHit(t *testing.T) {
+	endpoints := newTestEndpoints()[:1]
+	c := NewCollector(endpoints)
+	c.Record(endpoints[0], 200, false)
+
+	var buf bytes.Buffer
+	if err := c.Report().WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Never Hit") {
+		t.Errorf("expected no Never Hit section:\n%s", buf.String())
+	}
+}
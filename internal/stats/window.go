@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"strings"
+	"time"
+)
+
+// Windows are the rolling windows reported by a Collector.
+var Windows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// rolling counts requests per second over the longest of Windows. Buckets are
+// reused as time goes by, so memory stays constant however long the server
+// runs.
+type rolling struct {
+	buckets [15 * 60]bucket
+}
+
+type bucket struct {
+	second   int64 // Unix second the counts belong to
+	requests int
+	errors   int
+}
+
+func (r *rolling) add(now time.Time, failed bool) {
+	second := now.Unix()
+	b := &r.buckets[second%int64(len(r.buckets))]
+	if b.second != second {
+		*b = bucket{second: second}
+	}
+	b.requests++
+	if failed {
+		b.errors++
+	}
+}
+
+// sum returns the requests and errors counted in the window ending at now.
+func (r *rolling) sum(now time.Time, window time.Duration) (requests, errors int) {
+	last := now.Unix()
+	first := last - int64(window/time.Second)
+	for _, b := range r.buckets {
+		if b.second > first && b.second <= last {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+	return requests, errors
+}
+
+// WindowReport holds the traffic of one rolling window. Errors are responses
+// with a 4xx or 5xx status, including requests no endpoint answered.
+type WindowReport struct {
+	Window      string  `json:"window"`
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"`
+	RequestRate float64 `json:"requestRate"` // requests per second
+	ErrorRate   float64 `json:"errorRate"`   // fraction of requests that failed
+}
+
+func (r *rolling) report(now time.Time) []WindowReport {
+	reports := make([]WindowReport, 0, len(Windows))
+	for _, window := range Windows {
+		requests, errors := r.sum(now, window)
+		wr := WindowReport{
+			Window:      formatWindow(window),
+			Requests:    requests,
+			Errors:      errors,
+			RequestRate: float64(requests) / window.Seconds(),
+		}
+		if requests > 0 {
+			wr.ErrorRate = float64(errors) / float64(requests)
+		}
+		reports = append(reports, wr)
+	}
+	return reports
+}
+
+// formatWindow renders a window as 1m, 5m or 15m.
+func formatWindow(d time.Duration) string {
+	return strings.TrimSuffix(d.String(), "0s")
+}
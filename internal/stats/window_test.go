@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollector_Windows(t *testing.T) {
+	endpoints := newTestEndpoints()
+	c := NewCollector(endpoints)
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	record := func(ago time.Duration, status int) {
+		c.now = func() time.Time { return now.Add(-ago) }
+		c.Record(endpoints[0], status, false)
+	}
+	record(20*time.Minute, 200) // outside every window
+	record(10*time.Minute, 500)
+	record(3*time.Minute, 200)
+	record(30*time.Second, 200)
+	record(10*time.Second, 429)
+	c.now = func() time.Time { return now }
+	c.RecordUnmatched()
+
+	tests := []struct {
+		window       string
+		wantRequests int
+		wantErrors   int
+	}{
+		{window: "1m", wantRequests: 3, wantErrors: 2},
+		{window: "5m", wantRequests: 4, wantErrors: 2},
+		{window: "15m", wantRequests: 5, wantErrors: 3},
+	}
+
+	windows := c.Report().Windows
+	if len(windows) != len(tests) {
+		t.Fatalf("expected %d windows, got %+v", len(tests), windows)
+	}
+	for i, tt := range tests {
+		got := windows[i]
+		if got.Window != tt.window || got.Requests != tt.wantRequests || got.Errors != tt.wantErrors {
+			t.Errorf("expected %s window with %d requests / %d errors, got %+v", tt.window, tt.wantRequests, tt.wantErrors, got)
+		}
+	}
+	if got := windows[0].RequestRate; got != 3.0/60 {
+		t.Errorf("expected 1m request rate 0.05, got %v", got)
+	}
+	if got := windows[0].ErrorRate; got != 2.0/3 {
+		t.Errorf("expected 1m error rate 2/3, got %v", got)
+	}
+}
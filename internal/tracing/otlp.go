@@ -0,0 +1,261 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Export batching: spans are sent every exportInterval, or as soon as
+// exportBatch of them are waiting. Up to queueSize spans wait for export;
+// further spans are dropped while the collector is not keeping up.
+const (
+	exportInterval = time.Second
+	exportBatch    = 256
+	queueSize      = 4096
+)
+
+// TracesPath is the path an OTLP/HTTP collector receives traces at.
+const TracesPath = "/v1/traces"
+
+// Tracer starts the server spans of requests and exports the finished spans
+// of their traces to an OTLP/HTTP collector, encoded as JSON.
+type Tracer struct {
+	url     string
+	service string
+	client  *http.Client
+
+	spans chan *Span
+	done  chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	err    error // last export error, returned by Close
+}
+
+// New returns a tracer exporting to the collector at endpoint, such as
+// http://localhost:4318, under the service name service. TracesPath is
+// appended to endpoint unless it names it already. Close flushes the spans
+// still waiting.
+func New(endpoint, service string) *Tracer {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, TracesPath) {
+		url += TracesPath
+	}
+	t := &Tracer{
+		url:     url,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		spans:   make(chan *Span, queueSize),
+		done:    make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// StartRequest starts the server span of r, continuing the trace of its
+// traceparent header or starting a new one, and returns r carrying it.
+func (t *Tracer) StartRequest(r *http.Request, name string) (*http.Request, *Span) {
+	span := &Span{tracer: t, kind: KindServer, name: name, start: time.Now()}
+	if traceID, parent, ok := parseTraceparent(r.Header.Get(TraceparentHeader)); ok {
+		span.traceID, span.parent = traceID, parent
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.id[:])
+	return r.WithContext(ContextWith(r.Context(), span)), span
+}
+
+func (t *Tracer) queue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.spans <- span:
+	default:
+	}
+}
+
+// run exports the queued spans in batches until the queue is closed.
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) > 0 {
+			t.export(context.Background(), batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case span, ok := <-t.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= exportBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close exports the spans still waiting, until ctx is done, and returns the
+// last export error, if any. Spans ended after Close are dropped.
+func (t *Tracer) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.spans)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *Tracer) export(ctx context.Context, spans []*Span) {
+	data, err := json.Marshal(t.request(spans))
+	if err == nil {
+		err = t.post(ctx, data)
+	}
+	if err != nil {
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+	}
+}
+
+func (t *Tracer) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting spans to %s: %w", t.url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("exporting spans to %s: collector answered %d", t.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// The OTLP/HTTP JSON encoding of an export request (see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding): IDs are
+// hex strings and 64-bit integers are decimal strings.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanJSON `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	spanJSON struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+	status struct {
+		Code    int    `json:"code,omitempty"` // 2 is error
+		Message string `json:"message,omitempty"`
+	}
+	keyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// ScopeName names the instrumentation in exported spans.
+const ScopeName = "github.com/pretodev/anansi-proxy"
+
+func (t *Tracer) request(spans []*Span) exportRequest {
+	encoded := make([]spanJSON, len(spans))
+	for i, span := range spans {
+		span.mu.Lock()
+		encoded[i] = spanJSON{
+			TraceID:           hex.EncodeToString(span.traceID[:]),
+			SpanID:            hex.EncodeToString(span.id[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		}
+		if span.parent != [8]byte{} {
+			encoded[i].ParentSpanID = hex.EncodeToString(span.parent[:])
+		}
+		for _, attr := range span.attrs {
+			encoded[i].Attributes = append(encoded[i].Attributes, keyValue{attr.key, anyValue(attr.value)})
+		}
+		if span.err != nil {
+			encoded[i].Status = status{Code: 2, Message: span.err.Error()}
+		}
+		span.mu.Unlock()
+	}
+
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{
+			{"service.name", anyValue(t.service)},
+		}},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: ScopeName}, Spans: encoded}},
+	}}}
+}
+
+// anyValue encodes v as an OTLP AnyValue.
+func anyValue(v any) map[string]any {
+	switch v := v.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,157 @@
+// Package tracing records how the mock server handles requests as
+// OpenTelemetry spans. It continues the W3C trace context of incoming
+// requests, propagates it to upstreams and callbacks, and exports the spans
+// to an OTLP/HTTP collector, so mocks show up in the distributed traces of
+// integration tests.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader carries the trace context of a request (see
+// https://www.w3.org/TR/trace-context/).
+const TraceparentHeader = "traceparent"
+
+// Span kinds, as numbered by OTLP.
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Span is one timed operation of a trace. The methods of a nil *Span do
+// nothing, so code can trace unconditionally.
+type Span struct {
+	tracer  *Tracer
+	traceID [16]byte
+	id      [8]byte
+	parent  [8]byte
+	kind    int
+	start   time.Time
+
+	mu    sync.Mutex
+	name  string
+	end   time.Time
+	attrs []attribute
+	err   error
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+// SetName renames the span, for server spans named once the route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute records key with value, a string, bool, integer or float.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attribute{key, value})
+}
+
+// SetError marks the span as failed with err. A nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End ends the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	ended := !s.end.IsZero()
+	if !ended {
+		s.end = time.Now()
+	}
+	s.mu.Unlock()
+	if !ended {
+		s.tracer.queue(s)
+	}
+}
+
+// TraceID returns the trace of the span as 32 hex digits.
+func (s *Span) TraceID() string {
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// Traceparent returns the traceparent header value making the span the
+// parent of an outgoing request.
+func (s *Span) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-01", s.traceID, s.id)
+}
+
+type spanKey struct{}
+
+// FromContext returns the span of ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWith returns ctx carrying span, so spans started from it are its
+// children.
+func ContextWith(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Start starts a span named name as a child of the span of ctx. Without a
+// span in ctx, tracing is off and it returns ctx and a nil span.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: parent.tracer, traceID: parent.traceID, parent: parent.id, kind: kind, name: name, start: time.Now()}
+	rand.Read(span.id[:])
+	return ContextWith(ctx, span), span
+}
+
+// Inject sets the traceparent header of an outgoing request to the span of
+// ctx, if any.
+func Inject(ctx context.Context, h http.Header) {
+	if span := FromContext(ctx); span != nil {
+		h.Set(TraceparentHeader, span.Traceparent())
+	}
+}
+
+// parseTraceparent returns the trace and parent span IDs of a traceparent
+// header value, or false when it is missing or malformed.
+func parseTraceparent(value string) (traceID [16]byte, parent [8]byte, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return traceID, parent, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, parent, false
+	}
+	if _, err := hex.Decode(parent[:], []byte(parts[2])); err != nil || parent == [8]byte{} {
+		return traceID, parent, false
+	}
+	return traceID, parent, true
+}
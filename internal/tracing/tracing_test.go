@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// collector is an OTLP/HTTP collector keeping the spans it receives.
+type collector struct {
+	mu    sync.Mutex
+	paths []string
+	spans []spanJSON
+	attrs []keyValue // resource attributes
+}
+
+func newCollector(t *testing.T) (*collector, string) {
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("collector: %v", err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.paths = append(c.paths, r.URL.Path)
+		for _, rs := range req.ResourceSpans {
+			c.attrs = append(c.attrs, rs.Resource.Attributes...)
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv.URL
+}
+
+func TestTracer_ExportsSpans(t *testing.T) {
+	c, url := newCollector(t)
+	tracer := New(url, "checkout-tests")
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929b0e0e4736-00f067aa0ba902b7-01")
+	r, server := tracer.StartRequest(r, "GET")
+	server.SetName("GET /users")
+	server.SetAttribute("http.response.status_code", 200)
+
+	ctx, child := Start(r.Context(), "render response", KindInternal)
+	h := http.Header{}
+	Inject(ctx, h)
+	child.SetError(errors.New("boom"))
+	child.End()
+	server.End()
+	server.End()
+
+	if err := tracer.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(c.paths) == 0 || c.paths[0] != TracesPath {
+		t.Errorf("Expected spans posted to %s, got %v", TracesPath, c.paths)
+	}
+	if len(c.attrs) == 0 || c.attrs[0].Key != "service.name" || c.attrs[0].Value["stringValue"] != "checkout-tests" {
+		t.Errorf("Expected the service name as a resource attribute, got %+v", c.attrs)
+	}
+	if len(c.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %+v", c.spans)
+	}
+	rendered, served := c.spans[0], c.spans[1]
+	if served.Name != "GET /users" || served.Kind != KindServer || served.TraceID != "4bf92f3577b34da6a3ce929b0e0e4736" || served.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("server span = %+v, want the trace of the traceparent header", served)
+	}
+	if len(served.Attributes) != 1 || served.Attributes[0].Value["intValue"] != "200" {
+		t.Errorf("server span attributes = %+v", served.Attributes)
+	}
+	if rendered.TraceID != served.TraceID || rendered.ParentSpanID != served.SpanID || rendered.Status.Code != 2 || rendered.Status.Message != "boom" {
+		t.Errorf("child span = %+v, want a failed child of the server span", rendered)
+	}
+	if want := "00-" + served.TraceID + "-" + rendered.SpanID + "-01"; h.Get(TraceparentHeader) != want {
+		t.Errorf("Inject() set %q, want %q", h.Get(TraceparentHeader), want)
+	}
+}
+
+func TestTracer_StartsNewTrace(t *testing.T) {
+	tracer := New("http://127.0.0.1:0", "test")
+	defer tracer.Close(context.Background())
+
+	for _, header := range []string{"", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929b0e0e4736-00f067aa0ba902b7-01", "garbage"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(TraceparentHeader, header)
+		_, span := tracer.StartRequest(r, "GET")
+		if span.parent != [8]byte{} || span.traceID == [16]byte{} || span.TraceID() == "4bf92f3577b34da6a3ce929b0e0e4736" {
+			t.Errorf("StartRequest() with traceparent %q = %+v, want a new trace", header, span)
+		}
+	}
+}
+
+func TestStart_WithoutTracing(t *testing.T) {
+	ctx, span := Start(context.Background(), "render response", KindInternal)
+	if span != nil || ctx != context.Background() {
+		t.Errorf("Start() = %v, want no span", span)
+	}
+	// Methods of a nil span do nothing
+	span.SetName("x")
+	span.SetAttribute("k", "v")
+	span.SetError(errors.New("boom"))
+	span.End()
+
+	h := http.Header{}
+	Inject(ctx, h)
+	if len(h) != 0 {
+		t.Errorf("Inject() set %v without a span", h)
+	}
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// copyCurl shows the curl command calling the endpoint shown and copies it
+// to the clipboard of the terminal.
+func (m *model) copyCurl() tea.Cmd {
+	ep := &endpoint.EndpointWithFile{Schema: m.endpoint}
+	if m.endpoints != nil {
+		ep = m.endpoints[m.current()]
+	}
+	command, err := curlCommand(m.baseURL, m.socket, requestOf(ep))
+	if err != nil {
+		m.status, m.failed = i18n.T("No curl command for %s: %v", m.endpoint.Route, err), true
+		return nil
+	}
+	m.status, m.failed = i18n.T("Copied to the clipboard, if the terminal allows it:")+"\n"+command, false
+	if m.clipboard == nil {
+		return nil
+	}
+	out := m.clipboard
+	return func() tea.Msg {
+		io.WriteString(out, osc52(command))
+		return nil
+	}
+}
+
+// osc52 returns the escape sequence asking the terminal to put text in the
+// clipboard. Terminals that do not support it ignore it.
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// requestOf returns the request section of the file of ep. Endpoints not
+// read from a file, as with standard input and --inline, get one built
+// from their schema, whose path parameters are left as written.
+func requestOf(ep *endpoint.EndpointWithFile) *apimock.RequestSection {
+	if ep.FilePath != "" {
+		if parser, err := apimock.NewParser(ep.FilePath); err == nil {
+			if file, err := parser.Parse(); err == nil && file.Request != nil {
+				return file.Request
+			}
+		}
+	}
+
+	method, path, ok := strings.Cut(ep.Schema.Route, " ")
+	if !ok {
+		method, path = "", ep.Schema.Route
+	}
+	req := &apimock.RequestSection{
+		Method:             method,
+		Path:               path,
+		QueryParams:        map[string]string{},
+		Properties:         map[string]string{},
+		RequiredQuery:      ep.Schema.RequiredQuery,
+		RequiredProperties: ep.Schema.RequiredHeaders,
+		BodySchema:         ep.Schema.Body,
+	}
+	if ep.Schema.Body != "" {
+		req.Properties[endpoint.RequestAcceptPropertyName] = ep.Schema.Accept
+	}
+	// The values of required headers and query parameters are not kept
+	for _, name := range ep.Schema.RequiredHeaders {
+		req.Properties[name] = requiredSample
+	}
+	for _, name := range ep.Schema.RequiredQuery {
+		req.QueryParams[name] = requiredSample
+	}
+	return req
+}
+
+// requiredSample is the value sent for required headers and query
+// parameters whose value is not declared, or is a {placeholder}.
+const requiredSample = "example"
+
+// curlCommand returns a curl command sending req to the server at baseURL,
+// through socket when set: sample values stand in for the path and query
+// parameters, the declared headers are sent, and the body is an example of
+// its schema. Required headers and query parameters are always sent.
+func curlCommand(baseURL, socket string, req *apimock.RequestSection) (string, error) {
+	path, err := req.ExamplePath()
+	if err != nil {
+		return "", err
+	}
+	target := strings.TrimRight(baseURL, "/") + path
+	if query := exampleQuery(req.QueryParams, req.RequiredQuery); query != "" {
+		target += "?" + query
+	}
+	body := req.ExampleBody()
+
+	args := []string{"curl"}
+	if socket != "" {
+		args = append(args, "--unix-socket", shellQuote(socket))
+	}
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	// curl sends GET, or POST with a body, unless told otherwise
+	if (method != http.MethodGet || body != "") && (method != http.MethodPost || body == "") {
+		args = append(args, "-X", method)
+	}
+	args = append(args, shellQuote(target))
+
+	var headers []string
+	for key, value := range req.Properties {
+		required := slices.Contains(req.RequiredProperties, key)
+		if required && (strings.TrimSpace(value) == "" || strings.HasPrefix(value, "{")) {
+			value = requiredSample
+		}
+		if required || !endpoint.IsRequestControlProperty(key) && !apimock.IsMetadataKey(key) {
+			headers = append(headers, key+": "+value)
+		}
+	}
+	if contentType := req.Properties[endpoint.RequestAcceptPropertyName]; body != "" && contentType != "" {
+		headers = append(headers, endpoint.ContentTypeHeader+": "+contentType)
+	} else if body != "" && apimock.IsJSONSchema(req.BodySchema) {
+		headers = append(headers, endpoint.ContentTypeHeader+": application/json")
+	}
+	slices.Sort(headers)
+	for _, header := range headers {
+		args = append(args, "-H", shellQuote(header))
+	}
+	if body != "" {
+		args = append(args, "--data-raw", shellQuote(body))
+	}
+	return strings.Join(args, " "), nil
+}
+
+// exampleQuery encodes the query parameters of a request, leaving out the
+// optional ones whose {placeholder} values stand for any value.
+func exampleQuery(params map[string]string, required []string) string {
+	values := url.Values{}
+	for key, value := range params {
+		switch {
+		case !strings.HasPrefix(value, "{"):
+			values.Set(key, value)
+		case slices.Contains(required, key):
+			values.Set(key, requiredSample)
+		}
+	}
+	return values.Encode()
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for POSIX shells, unless it needs no quoting.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/state"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func TestCurlCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		socket string
+		want   string
+	}{
+		{
+			name:   "get",
+			source: "GET /users/{id:int}\n  ?fields=name\n  &page={page}\nX-Meta-Owner: team\nX-Api-Version: 2\nMax-Calls: 3\n\n-- 200: OK\n",
+			want:   "curl 'http://localhost:8977/users/1?fields=name' -H 'X-Api-Version: 2'",
+		},
+		{
+			name:   "json schema body",
+			source: "POST /users\nAccept: application/json\nAuthorization!: Bearer it's me\n\n{\"type\": \"object\", \"properties\": {\"name\": {\"type\": \"string\"}}}\n\n-- 201: Created\n",
+			want:   `curl http://localhost:8977/users -H 'Authorization: Bearer it'\''s me' -H 'Content-Type: application/json' --data-raw '{"name":"string"}'`,
+		},
+		{
+			name:   "required headers and query parameters",
+			source: "GET /reports\n  ?from!={date}\n  &to={date}\nX-Api-Key!: {key}\nX-Tenant!: acme\nX-Meta-Ticket: OPS-1\n\n-- 200: OK\n",
+			want:   "curl 'http://localhost:8977/reports?from=example' -H 'X-Api-Key: example' -H 'X-Tenant: acme'",
+		},
+		{
+			name:   "delete over a socket",
+			source: "DELETE /users/{id}\n\n-- 204: No Content\n",
+			socket: "/tmp/mock.sock",
+			want:   "curl --unix-socket /tmp/mock.sock -X DELETE http://localhost:8977/users/1",
+		},
+		{
+			name:   "put with a literal body",
+			source: "PUT /notes\nAccept: text/plain\n\nhello\n\n-- 200: OK\n",
+			want:   "curl -X PUT http://localhost:8977/notes -H 'Content-Type: text/plain' --data-raw hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := apimock.ParseString("test.apimock", tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := curlCommand("http://localhost:8977/", tt.socket, file.Request)
+			if err != nil || got != tt.want {
+				t.Errorf("curlCommand() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_CopyCurl(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.apimock")
+	if err := os.WriteFile(file, []byte("GET /users/{id}\n\n-- 200: OK\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := endpoint.ParseAPIMock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inline := &endpoint.EndpointSchema{Route: "POST /notes", Accept: "text/plain", Body: "hi", RequiredHeaders: []string{"X-Api-Key"}, Responses: schema.Responses}
+
+	sel := state.NewSelection(1, 1)
+	m := newModel(sel, []*endpoint.EndpointWithFile{{Schema: schema, FilePath: file}, {Schema: inline, FilePath: "inline"}})
+	var clipboard bytes.Buffer
+	m.baseURL, m.clipboard = "http://localhost:8977", &clipboard
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil {
+		t.Fatal("Expected a command copying to the clipboard")
+	}
+	cmd()
+	if want := osc52("curl http://localhost:8977/users/1"); clipboard.String() != want {
+		t.Errorf("Expected %q written to the terminal, got %q", want, clipboard.String())
+	}
+	if view := updated.(model).View(); !strings.Contains(view, "curl http://localhost:8977/users/1") {
+		t.Errorf("Expected the command shown, got:\n%s", view)
+	}
+
+	m = updated.(model)
+	m.show(1)
+	m.copyCurl()
+	if want := "curl http://localhost:8977/notes -H 'Content-Type: text/plain' -H 'X-Api-Key: example' --data-raw hi"; !strings.HasSuffix(m.status, want) {
+		t.Errorf("Expected the command of the inline endpoint, got %q", m.status)
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+)
+
+// editedMsg reports that the editor of the file of the i-th endpoint exited.
+type editedMsg struct {
+	index int
+	err   error
+}
+
+// current returns the position of the endpoint shown.
+func (m model) current() int {
+	if m.selection == nil {
+		return 0
+	}
+	return m.selection.Current()
+}
+
+// edit opens the file of the endpoint shown in $EDITOR, on the line of the
+// response under the cursor. It returns nil when the endpoint was not read
+// from a file, as with standard input and --inline.
+func (m *model) edit() tea.Cmd {
+	if m.endpoints == nil {
+		return nil
+	}
+	i := m.current()
+	file := m.endpoints[i].FilePath
+	if _, err := os.Stat(file); err != nil {
+		m.status, m.failed = i18n.T("%s is not a file and cannot be edited", file), true
+		return nil
+	}
+
+	line := 0
+	if responses := m.endpoint.SliceResponses(); m.cursor < len(responses) {
+		line = responses[m.cursor].Lines.Start
+	}
+	return tea.ExecProcess(editorCommand(file, line), func(err error) tea.Msg {
+		return editedMsg{index: i, err: err}
+	})
+}
+
+// editorCommand returns the command opening file at line in $EDITOR, or vi.
+// The +line argument is understood by vi, nano, emacs and most terminal
+// editors.
+func editorCommand(file string, line int) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	if line > 0 {
+		args = append(args, fmt.Sprintf("+%d", line))
+	}
+	args = append(args, file)
+	return exec.Command(args[0], args[1:]...)
+}
+
+// applyEdit parses the edited file again and serves it. A file that no
+// longer parses is reported and the endpoint keeps serving the previous
+// version.
+func (m *model) applyEdit(msg editedMsg) {
+	file := m.endpoints[msg.index].FilePath
+	if msg.err != nil {
+		m.status, m.failed = i18n.T("Editor failed: %v", msg.err), true
+		return
+	}
+	schema, err := endpoint.ParseAPIMock(file)
+	if err == nil {
+		err = m.apply(msg.index, schema)
+	}
+	if err != nil {
+		m.status, m.failed = i18n.T("Changes to %s not applied: %v", file, err), true
+		return
+	}
+
+	m.endpoints[msg.index] = &endpoint.EndpointWithFile{Schema: schema, FilePath: file}
+	if msg.index == m.current() {
+		m.endpoint = schema
+		m.cursor = min(m.cursor, max(schema.CountResponses()-1, 0))
+	}
+	m.status, m.failed = i18n.T("Applied changes to %s", file), false
+}
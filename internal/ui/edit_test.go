@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "code --wait")
+	if got, want := editorCommand("a.apimock", 12).Args, []string{"code", "--wait", "+12", "a.apimock"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("editorCommand() = %v, want %v", got, want)
+	}
+
+	t.Setenv("EDITOR", "")
+	if got, want := editorCommand("a.apimock", 0).Args, []string{"vi", "a.apimock"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("editorCommand() = %v, want %v", got, want)
+	}
+}
+
+func TestModel_ApplyEdit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.apimock")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("GET /users\n\n-- 200: OK\n\n[]\n\n-- 500: Error\n")
+	schema, err := endpoint.ParseAPIMock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var applied []*endpoint.EndpointSchema
+	sel := state.NewSelection(schema.CountResponses())
+	m := newModel(sel, []*endpoint.EndpointWithFile{{Schema: schema, FilePath: file}})
+	m.apply = func(i int, schema *endpoint.EndpointSchema) error {
+		applied = append(applied, schema)
+		return nil
+	}
+	m.cursor = 1
+
+	write("GET /users\n\n-- 200: OK\n\n[{\"id\": 1}]\n")
+	m.applyEdit(editedMsg{})
+	if len(applied) != 1 || m.endpoint != applied[0] || m.failed {
+		t.Fatalf("Expected the edited file applied, got status %q", m.status)
+	}
+	if m.cursor != 0 {
+		t.Errorf("Expected the cursor on the only response left, got %d", m.cursor)
+	}
+	if body := m.endpoint.SliceResponses()[0].Body; body != `[{"id": 1}]` {
+		t.Errorf("Expected the edited body, got %q", body)
+	}
+
+	write("GET /users\n\n-- 999: Broken\n")
+	m.applyEdit(editedMsg{})
+	if len(applied) != 1 || !m.failed || !strings.Contains(m.View(), "not applied") {
+		t.Errorf("Expected a broken file to be reported and not applied, got status %q", m.status)
+	}
+
+	m.applyEdit(editedMsg{err: errors.New("exit status 1")})
+	if !m.failed || !strings.Contains(m.status, "exit status 1") {
+		t.Errorf("Expected the editor failure reported, got status %q", m.status)
+	}
+}
+
+func TestModel_EditWithoutFile(t *testing.T) {
+	sel := state.NewSelection(3)
+	m := newModel(sel, []*endpoint.EndpointWithFile{{Schema: createTestEndpoint(), FilePath: endpoint.InlineFilePath}})
+	m.apply = func(int, *endpoint.EndpointSchema) error { return nil }
+
+	if cmd := m.edit(); cmd != nil || !m.failed {
+		t.Errorf("Expected an inline endpoint not to be edited, got status %q", m.status)
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+)
+
+// fuzzyMatch reports whether the characters of query appear in s in order,
+// ignoring case and spaces, so "gus" matches "GET /users".
+func fuzzyMatch(query, s string) bool {
+	target := []rune(strings.ToLower(s))
+	for _, r := range strings.ToLower(query) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		i := 0
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i == len(target) {
+			return false
+		}
+		target = target[i+1:]
+	}
+	return true
+}
+
+// responseText is what the filter matches a response against.
+func responseText(resp endpoint.Response) string {
+	return fmt.Sprintf("%d %s", resp.StatusCode, resp.Title)
+}
+
+// responseVisible reports whether the i-th response of schema is listed
+// under the filter: all of them when the route matches, otherwise those
+// whose status code and title match.
+func (m model) responseVisible(schema *endpoint.EndpointSchema, i int) bool {
+	if m.filter == "" || fuzzyMatch(m.filter, schema.Route) {
+		return true
+	}
+	return fuzzyMatch(m.filter, responseText(schema.SliceResponses()[i]))
+}
+
+// endpointVisible reports whether the i-th endpoint is listed under the
+// filter: when its route or one of its responses matches.
+func (m model) endpointVisible(i int) bool {
+	schema := m.endpoints[i].Schema
+	if m.filter == "" || fuzzyMatch(m.filter, schema.Route) {
+		return true
+	}
+	for j := range schema.CountResponses() {
+		if m.responseVisible(schema, j) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveCursor moves the cursor to the next listed response in direction
+// step, staying put at either end.
+func (m *model) moveCursor(step int) {
+	for i := m.cursor + step; i >= 0 && i < m.endpoint.CountResponses(); i += step {
+		if m.responseVisible(m.endpoint, i) {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// moveEndpoint shows the next listed endpoint in direction step, staying
+// put at either end.
+func (m *model) moveEndpoint(step int) {
+	for i := m.selection.Current() + step; i >= 0 && i < len(m.endpoints); i += step {
+		if m.endpointVisible(i) {
+			m.show(i)
+			return
+		}
+	}
+}
+
+// updateFilter handles a key typed while filtering: characters edit the
+// filter, enter keeps it and esc clears it. Arrows still move through the
+// lists. It returns the command to run, if any.
+func (m *model) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEsc:
+		m.filtering, m.filter = false, ""
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyBackspace:
+		if runes := []rune(m.filter); len(runes) > 0 {
+			m.filter = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	case tea.KeyUp:
+		m.moveCursor(-1)
+	case tea.KeyDown:
+		m.moveCursor(1)
+	case tea.KeyLeft:
+		if m.selection != nil {
+			m.moveEndpoint(-1)
+		}
+	case tea.KeyRight:
+		if m.selection != nil {
+			m.moveEndpoint(1)
+		}
+	}
+
+	// Show a listed endpoint when the one shown no longer matches
+	if m.selection != nil && !m.endpointVisible(m.selection.Current()) {
+		for i := range m.endpoints {
+			if m.endpointVisible(i) {
+				m.show(i)
+				break
+			}
+		}
+	}
+	return nil
+}
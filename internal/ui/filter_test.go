@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, s string
+		want     bool
+	}{
+		{"", "GET /users", true},
+		{"gus", "GET /users", true},
+		{"USERS", "GET /users", true},
+		{"get ord", "GET /orders/{id}", true},
+		{"sug", "GET /users", false},
+		{"404", "404 Not Found", true},
+		{"nf", "404 Not Found", true},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.query, tt.s); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestModel_Filter(t *testing.T) {
+	users, orders := createTestEndpoint(), createTestEndpoint()
+	users.Route, orders.Route = "GET /users", "GET /orders"
+	orders.Responses[409] = []endpoint.Response{{Title: "Conflict", StatusCode: 409}}
+	sel := state.NewSelection(users.CountResponses(), orders.CountResponses())
+	var m tea.Model = newModel(sel, []*endpoint.EndpointWithFile{{Schema: users}, {Schema: orders}})
+	typeKeys := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			m, _ = m.Update(msg)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// Only GET /orders declares a conflict; it is shown with that response only
+	typeKeys(runes("/"), runes("c"), runes("o"), runes("n"), runes("f"))
+	view := m.View()
+	if strings.Contains(view, "GET /users") || !strings.Contains(view, "[409] Conflict") || strings.Contains(view, "[200] Success") {
+		t.Errorf("Expected only the conflict of GET /orders listed, got:\n%s", view)
+	}
+	if sel.Current() != 1 {
+		t.Errorf("Expected GET /orders shown once GET /users no longer matches, got %d", sel.Current())
+	}
+	if !strings.Contains(view, "/conf") {
+		t.Errorf("View should show the filter being typed, got:\n%s", view)
+	}
+
+	// Down moves to the conflict, skipping the hidden responses
+	typeKeys(tea.KeyMsg{Type: tea.KeyEnter}, runes("j"))
+	if got := sel.Endpoint(1).Index(); orders.SliceResponses()[got].StatusCode != 409 {
+		t.Errorf("Expected the conflict selected, got index %d", got)
+	}
+
+	typeKeys(tea.KeyMsg{Type: tea.KeyEsc})
+	if view := m.View(); !strings.Contains(view, "GET /users") || !strings.Contains(view, "[200] Success") {
+		t.Errorf("Expected esc to clear the filter, got:\n%s", view)
+	}
+}
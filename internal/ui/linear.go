@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+// RenderLinear runs the response selector as plain line-based output: it
+// prints numbered choices and reads the selected number from in. Unlike
+// Render it does not redraw the terminal, so it works with screen readers and
+// dumb terminals. It returns when in is exhausted or the user enters q.
+func RenderLinear(sm *state.StateManager, endpoint *endpoint.EndpointSchema, in io.Reader, out io.Writer) error {
+	responses := endpoint.SliceResponses()
+	scanner := bufio.NewScanner(in)
+
+	printChoices(out, responses, sm.Index())
+	for {
+		fmt.Fprint(out, i18n.T("Select a response (1-%d), l to list, q to quit: ", len(responses)))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(input) {
+		case "":
+			continue
+		case "q", "quit":
+			return nil
+		case "l", "list":
+			printChoices(out, responses, sm.Index())
+			continue
+		}
+
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 1 || choice > len(responses) {
+			fmt.Fprintln(out, i18n.T("Invalid choice %q.", input))
+			continue
+		}
+
+		sm.SetIndex(choice - 1)
+		res := responses[choice-1]
+		fmt.Fprintln(out, i18n.T("Now serving %d: [%d] %s", choice, res.StatusCode, res.Title))
+	}
+}
+
+func printChoices(out io.Writer, responses []endpoint.Response, selected int) {
+	fmt.Fprintln(out, i18n.T("Select a response for the server:"))
+	for i, res := range responses {
+		marker := ""
+		if i == selected {
+			marker = " " + i18n.T("(selected)")
+		}
+		fmt.Fprintf(out, "%d. [%d] %s%s\n", i+1, res.StatusCode, res.Title, marker)
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestRenderLinear_SelectsResponses(t *testing.T) {
+	ep := createTestEndpoint()
+	sm := state.New(ep.CountResponses())
+
+	var out bytes.Buffer
+	err := RenderLinear(sm, ep, strings.NewReader("3\n\nabc\n9\n2\nq\n1\n"), &out)
+	if err != nil {
+		t.Fatalf("RenderLinear() error = %v", err)
+	}
+
+	if sm.Index() != 1 {
+		t.Errorf("expected index 1 after choosing 2 and quitting, got %d", sm.Index())
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"1. [200] Success (selected)",
+		"2. [404] Not Found",
+		"3. [500] Server Error",
+		"Now serving 3: [500] Server Error",
+		`Invalid choice "abc".`,
+		`Invalid choice "9".`,
+		"Now serving 2: [404] Not Found",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Now serving 1") {
+		t.Error("expected input after q to be ignored")
+	}
+}
+
+func TestRenderLinear_ListShowsCurrentSelection(t *testing.T) {
+	ep := createTestEndpoint()
+	sm := state.New(ep.CountResponses())
+
+	var out bytes.Buffer
+	if err := RenderLinear(sm, ep, strings.NewReader("2\nl\n"), &out); err != nil {
+		t.Fatalf("RenderLinear() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "2. [404] Not Found (selected)") {
+		t.Errorf("expected listing to mark the new selection:\n%s", out.String())
+	}
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+// replCommands are the usages of the commands of RunREPL, with what they do.
+var replCommands = [][2]string{
+	{"list", "list the endpoints and the responses of the current one"},
+	{"endpoint N|ROUTE", "make the N-th endpoint, or the one of ROUTE, current"},
+	{"select N", "serve the N-th response of the current endpoint (or just N)"},
+	{"reset [all]", "reset the call count of the current endpoint, or of all"},
+	{"latency|chaos [on|off]", "toggle or set a switch, as d and c do in the UI"},
+	{"force [on|off]", "toggle or set whether the current endpoint forces its response"},
+	{"quit", "stop the server"},
+}
+
+// RunREPL controls the selections of sel, the i-th for endpoints[i], with
+// commands read line by line from in, such as `select 2`, `list` and
+// `reset`. It acts as the keys of Render do, without drawing anything, so
+// it suits output that is not a terminal, such as CI and container logs.
+// It returns nil once the user quits and io.EOF when in is exhausted.
+func RunREPL(sel *state.Selection, endpoints []*endpoint.EndpointWithFile, in io.Reader, out io.Writer) error {
+	r := &repl{sel: sel, endpoints: endpoints, out: out}
+	fmt.Fprintln(out, i18n.T("Enter help for the commands."))
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if quit := r.run(strings.Fields(scanner.Text())); quit {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+type repl struct {
+	sel       *state.Selection
+	endpoints []*endpoint.EndpointWithFile
+	out       io.Writer
+}
+
+// run runs a command and reports whether it was quit.
+func (r *repl) run(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	current := r.sel.Current()
+	sm := r.sel.Endpoint(current)
+	responses := r.endpoints[current].Schema.SliceResponses()
+	command, args := strings.ToLower(args[0]), args[1:]
+	if _, err := strconv.Atoi(command); err == nil {
+		command, args = "select", []string{command}
+	}
+
+	switch command {
+	case "q", "quit", "exit":
+		return true
+	case "help", "?":
+		for _, c := range replCommands {
+			fmt.Fprintf(r.out, "  %-24s%s\n", c[0], i18n.T(c[1]))
+		}
+	case "l", "list":
+		if len(r.endpoints) > 1 {
+			r.printEndpoints()
+		}
+		printChoices(r.out, responses, sm.Index())
+	case "endpoint":
+		i, ok := r.endpointOf(strings.Join(args, " "))
+		if !ok {
+			fmt.Fprintln(r.out, i18n.T("Invalid choice %q.", strings.Join(args, " ")))
+			break
+		}
+		r.sel.SetCurrent(i)
+		fmt.Fprintln(r.out, i18n.T("Current endpoint: %s", r.endpoints[i].Schema.Route))
+	case "select":
+		choice, err := strconv.Atoi(strings.Join(args, " "))
+		if err != nil || choice < 1 || choice > len(responses) {
+			fmt.Fprintln(r.out, i18n.T("Invalid choice %q.", strings.Join(args, " ")))
+			break
+		}
+		sm.SetIndex(choice - 1)
+		res := responses[choice-1]
+		fmt.Fprintln(r.out, i18n.T("Now serving %d: [%d] %s", choice, res.StatusCode, res.Title))
+	case "reset":
+		if len(args) > 0 && strings.ToLower(args[0]) == "all" {
+			r.sel.ResetCallCounts()
+		} else {
+			sm.ResetCallCount()
+		}
+		fmt.Fprintln(r.out, i18n.T("Calls to %s: %d", r.endpoints[current].Schema.Route, sm.CallCount()))
+	case "latency", "chaos", "force":
+		controls := r.sel.Controls()
+		get, set := controls.Latency, controls.SetLatency
+		switch command {
+		case "chaos":
+			get, set = controls.Chaos, controls.SetChaos
+		case "force":
+			get, set = sm.Forced, sm.SetForced
+		}
+		on, ok := switchValue(args, get())
+		if !ok {
+			fmt.Fprintln(r.out, i18n.T("Invalid choice %q.", strings.Join(args, " ")))
+			break
+		}
+		set(on)
+		fmt.Fprintln(r.out, i18n.T("Latency: %s  Chaos: %s  Forced response: %s",
+			onOff(controls.Latency()), onOff(controls.Chaos()), onOff(sm.Forced())))
+	default:
+		fmt.Fprintln(r.out, i18n.T("Unknown command %q. Enter help for the commands.", command))
+	}
+	return false
+}
+
+// endpointOf returns the position of the endpoint a 1-based number or a
+// route names.
+func (r *repl) endpointOf(name string) (int, bool) {
+	if n, err := strconv.Atoi(name); err == nil {
+		return n - 1, n >= 1 && n <= len(r.endpoints)
+	}
+	for i, ep := range r.endpoints {
+		if strings.EqualFold(ep.Schema.Route, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// printEndpoints lists the endpoints as the endpoint list of the UI does.
+func (r *repl) printEndpoints() {
+	fmt.Fprintln(r.out, i18n.T("Endpoints:"))
+	current := r.sel.Current()
+	for i, ep := range r.endpoints {
+		sm := r.sel.Endpoint(i)
+		line := fmt.Sprintf("%d. %s", i+1, ep.Schema.Route)
+		if responses := ep.Schema.SliceResponses(); len(responses) > 0 {
+			line += fmt.Sprintf(" (%d)", responses[sm.Index()].StatusCode)
+		}
+		line += " " + i18n.T("%d call(s)", sm.CallCount())
+		if i == current {
+			line += " " + i18n.T("(selected)")
+		}
+		fmt.Fprintln(r.out, line)
+	}
+}
+
+// switchValue returns the value a switch command sets: the one given as on
+// or off, or else the opposite of current.
+func switchValue(args []string, current bool) (bool, bool) {
+	if len(args) == 0 {
+		return !current, true
+	}
+	switch strings.ToLower(args[0]) {
+	case "on", "true", "1":
+		return true, true
+	case "off", "false", "0":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/state"
+)
+
+func TestRunREPL(t *testing.T) {
+	users := createTestEndpoint()
+	orders := createTestEndpoint()
+	orders.Route = "GET /orders"
+	endpoints := []*endpoint.EndpointWithFile{{Schema: users}, {Schema: orders}}
+	sel := state.NewSelection(users.CountResponses(), orders.CountResponses())
+	sel.Endpoint(0).AddCall()
+
+	input := strings.Join([]string{
+		"select 3",
+		"endpoint get /orders",
+		"2",
+		"select 9",
+		"reset all",
+		"chaos on",
+		"force",
+		"list",
+		"frobnicate",
+		"",
+	}, "\n")
+	var out bytes.Buffer
+	if err := RunREPL(sel, endpoints, strings.NewReader(input), &out); err != io.EOF {
+		t.Fatalf("RunREPL() error = %v, want io.EOF", err)
+	}
+
+	if sel.Endpoint(0).Index() != 2 || sel.Endpoint(1).Index() != 1 || sel.Current() != 1 {
+		t.Errorf("Expected responses 2 and 1 selected with the orders current, got %d, %d and %d",
+			sel.Endpoint(0).Index(), sel.Endpoint(1).Index(), sel.Current())
+	}
+	if sel.Endpoint(0).CallCount() != 0 || !sel.Controls().Chaos() || sel.Endpoint(1).Forced() {
+		t.Error("Expected the calls reset, chaos on and the orders response released")
+	}
+	output := out.String()
+	for _, want := range []string{
+		"Now serving 3: [500] Server Error",
+		"Current endpoint: GET /orders",
+		"Now serving 2: [404] Not Found",
+		`Invalid choice "9".`,
+		"Latency: off  Chaos: on  Forced response: off",
+		"1. /api/test (500) 0 call(s)",
+		"2. GET /orders (404) 0 call(s) (selected)",
+		"2. [404] Not Found (selected)",
+		`Unknown command "frobnicate".`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected the output to contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunREPL_Quit(t *testing.T) {
+	ep := createTestEndpoint()
+	sel := state.NewSelection(ep.CountResponses())
+
+	var out bytes.Buffer
+	if err := RunREPL(sel, []*endpoint.EndpointWithFile{{Schema: ep}}, strings.NewReader("quit\n2\n"), &out); err != nil {
+		t.Fatalf("RunREPL() error = %v", err)
+	}
+	if sel.Endpoint(0).Index() != 0 {
+		t.Error("Expected the commands after quit to be ignored")
+	}
+}
@@ -2,17 +2,43 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
+	"github.com/pretodev/anansi-proxy/internal/i18n"
 	"github.com/pretodev/anansi-proxy/internal/state"
 )
 
-func Render(sm *state.StateManager, endpoint *endpoint.EndpointSchema) error {
-	p := tea.NewProgram(initialModel(sm, endpoint))
+// Options configure the interactive UI.
+type Options struct {
+	// History lists its last requests below the responses, when set
+	History *history.Log
+	// Apply serves the schema of the i-th endpoint, parsed again after its
+	// file was edited. Without it, files cannot be edited from the UI.
+	Apply func(i int, schema *endpoint.EndpointSchema) error
+	// BaseURL is the URL of the server curl commands are copied for, such
+	// as http://localhost:8977, and UnixSocket the socket they connect to
+	// when it listens on one
+	BaseURL    string
+	UnixSocket string
+}
+
+// Render draws the response selector of endpoints, the i-th selecting in
+// sel.Endpoint(i), until the user quits. With several endpoints, the list
+// of endpoints is drawn beside their responses.
+func Render(sel *state.Selection, endpoints []*endpoint.EndpointWithFile, opts Options) error {
+	m := newModel(sel, endpoints)
+	m.history, m.apply = opts.History, opts.Apply
+	m.baseURL, m.socket, m.clipboard = opts.BaseURL, opts.UnixSocket, os.Stderr
+	p := tea.NewProgram(m)
 	if _, err := p.Run(); err != nil {
 		return err
 	}
@@ -22,19 +48,62 @@ func Render(sm *state.StateManager, endpoint *endpoint.EndpointSchema) error {
 var (
 	selectedItemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
 	helpStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	failedStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	endpointPaneStyle = lipgloss.NewStyle().PaddingRight(4)
+)
+
+// Requests listed below the responses, and how often the list is refreshed.
+const (
+	historyLines   = 5
+	historyRefresh = 500 * time.Millisecond
 )
 
+// refreshMsg redraws the list of requests and the call counts.
+type refreshMsg struct{}
+
+func refresh() tea.Cmd {
+	return tea.Tick(historyRefresh, func(time.Time) tea.Msg { return refreshMsg{} })
+}
+
+// model shows the responses of one endpoint at a time: endpoint,
+// stateManager and cursor belong to the endpoint shown.
 type model struct {
 	endpoint     *endpoint.EndpointSchema
 	cursor       int
 	keys         keyMap
 	stateManager *state.StateManager
+	history      *history.Log // optional, listed below the responses
+
+	// The endpoints listed, beside the responses when there are several,
+	// and their selections
+	endpoints []*endpoint.EndpointWithFile
+	selection *state.Selection
+
+	apply     func(i int, schema *endpoint.EndpointSchema) error // optional, see Options
+	filter    string                                             // narrows the lists, see fuzzyMatch
+	filtering bool                                               // keys edit the filter
+
+	baseURL, socket string    // where copied curl commands are sent, see Options
+	clipboard       io.Writer // terminal receiving copied commands, see osc52
+
+	status string // outcome of the last edit
+	failed bool   // the last edit was not applied
 }
 
 type keyMap struct {
-	Up   key.Binding
-	Down key.Binding
-	Quit key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	Edit     key.Binding
+	Latency  key.Binding
+	Chaos    key.Binding
+	Force    key.Binding
+	Reset    key.Binding
+	ResetAll key.Binding
+	Filter   key.Binding
+	Curl     key.Binding
+	Quit     key.Binding
 }
 
 func initialModel(sm *state.StateManager, endpoint *endpoint.EndpointSchema) model {
@@ -43,32 +112,104 @@ func initialModel(sm *state.StateManager, endpoint *endpoint.EndpointSchema) mod
 		cursor:       0,
 		stateManager: sm,
 		keys: keyMap{
-			Up:   key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "move up")),
-			Down: key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "move down")),
-			Quit: key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q/ctrl+c", "quit")),
+			Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", i18n.T("move up"))),
+			Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", i18n.T("move down"))),
+			Left:     key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", i18n.T("previous endpoint"))),
+			Right:    key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", i18n.T("next endpoint"))),
+			Edit:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", i18n.T("edit"))),
+			Latency:  key.NewBinding(key.WithKeys("d"), key.WithHelp("d", i18n.T("toggle latency"))),
+			Chaos:    key.NewBinding(key.WithKeys("c"), key.WithHelp("c", i18n.T("toggle chaos"))),
+			Force:    key.NewBinding(key.WithKeys("f"), key.WithHelp("f", i18n.T("toggle forced response"))),
+			Reset:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", i18n.T("reset calls"))),
+			ResetAll: key.NewBinding(key.WithKeys("R"), key.WithHelp("R", i18n.T("reset all calls"))),
+			Filter:   key.NewBinding(key.WithKeys("/"), key.WithHelp("/", i18n.T("filter"))),
+			Curl:     key.NewBinding(key.WithKeys("y"), key.WithHelp("y", i18n.T("copy as curl"))),
+			Quit:     key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q/ctrl+c", i18n.T("quit"))),
 		},
 	}
 }
 
+// newModel returns a model listing endpoints, showing the endpoint current
+// in sel with the response it has selected.
+func newModel(sel *state.Selection, endpoints []*endpoint.EndpointWithFile) model {
+	current := sel.Current()
+	m := initialModel(sel.Endpoint(current), endpoints[current].Schema)
+	m.cursor = m.stateManager.Index()
+	// Edits replace endpoints in the copy only
+	m.endpoints, m.selection = slices.Clone(endpoints), sel
+	return m
+}
+
+// show shows the i-th endpoint, with the cursor on the response it has
+// selected.
+func (m *model) show(i int) {
+	if m.selection == nil {
+		return
+	}
+	m.selection.SetCurrent(i)
+	current := m.selection.Current()
+	m.endpoint = m.endpoints[current].Schema
+	m.stateManager = m.selection.Endpoint(current)
+	m.cursor = m.stateManager.Index()
+}
+
 // Init is the first function to be executed.
 func (m model) Init() tea.Cmd {
+	// Requests change the history and the call counts
+	if m.history != nil || m.selection != nil {
+		return refresh()
+	}
 	return nil
 }
 
 // Update is called when "something happens", like a key press.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case refreshMsg:
+		return m, refresh()
+	case editedMsg:
+		m.applyEdit(msg)
 	case tea.KeyMsg:
+		if m.filtering {
+			if cmd := m.updateFilter(msg); cmd != nil {
+				return m, cmd
+			}
+			break
+		}
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.Filter):
+			m.filtering = true
+		case msg.Type == tea.KeyEsc:
+			m.filter = ""
 		case key.Matches(msg, m.keys.Up):
-			if m.cursor > 0 {
-				m.cursor--
-			}
+			m.moveCursor(-1)
 		case key.Matches(msg, m.keys.Down):
-			if m.cursor < m.endpoint.CountResponses()-1 {
-				m.cursor++
+			m.moveCursor(1)
+		case key.Matches(msg, m.keys.Left) && m.selection != nil:
+			m.moveEndpoint(-1)
+		case key.Matches(msg, m.keys.Right) && m.selection != nil:
+			m.moveEndpoint(1)
+		case key.Matches(msg, m.keys.Latency) && m.selection != nil:
+			controls := m.selection.Controls()
+			controls.SetLatency(!controls.Latency())
+		case key.Matches(msg, m.keys.Chaos) && m.selection != nil:
+			controls := m.selection.Controls()
+			controls.SetChaos(!controls.Chaos())
+		case key.Matches(msg, m.keys.Force):
+			m.stateManager.SetForced(!m.stateManager.Forced())
+		case key.Matches(msg, m.keys.Reset):
+			m.stateManager.ResetCallCount()
+		case key.Matches(msg, m.keys.ResetAll) && m.selection != nil:
+			m.selection.ResetCallCounts()
+		case key.Matches(msg, m.keys.Curl):
+			if cmd := m.copyCurl(); cmd != nil {
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.Edit) && m.apply != nil:
+			if cmd := m.edit(); cmd != nil {
+				return m, cmd
 			}
 		}
 	}
@@ -81,9 +222,86 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) View() string {
 	var b strings.Builder
 
-	b.WriteString("Select a response for the server:\n\n")
+	if len(m.endpoints) > 1 {
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, endpointPaneStyle.Render(m.endpointList()), m.responseList()))
+	} else {
+		b.WriteString(m.responseList())
+	}
+
+	if m.history != nil {
+		b.WriteString("\n" + i18n.T("Last requests:") + "\n")
+		exchanges := m.history.Find(history.Query{Limit: historyLines})
+		if len(exchanges) == 0 {
+			b.WriteString(helpStyle.Render("  "+i18n.T("none yet")) + "\n")
+		}
+		for _, ex := range exchanges {
+			line := fmt.Sprintf("  %s %s %s -> %d", ex.Time.Format("15:04:05"), ex.Method, ex.Path, ex.Status)
+			if ex.ResponseIndex >= 0 {
+				line += fmt.Sprintf(" [%d] %s", ex.ResponseIndex, ex.Response)
+			}
+			if len(ex.Errors) > 0 {
+				line = failedStyle.Render(line + ": " + strings.Join(ex.Errors, "; "))
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	switch {
+	case m.filtering:
+		b.WriteString("\n/" + m.filter + "█\n")
+	case m.filter != "":
+		b.WriteString("\n" + helpStyle.Render(i18n.T("Filter: %s (esc to clear)", m.filter)) + "\n")
+	}
+
+	if m.selection != nil {
+		controls := m.selection.Controls()
+		b.WriteString("\n" + i18n.T("Calls to %s: %d", m.endpoint.Route, m.stateManager.CallCount()) + "\n")
+		b.WriteString(i18n.T("Latency: %s  Chaos: %s  Forced response: %s",
+			onOff(controls.Latency()), onOff(controls.Chaos()), onOff(m.stateManager.Forced())) + "\n")
+	}
+
+	if m.status != "" {
+		style := helpStyle
+		if m.failed {
+			style = failedStyle
+		}
+		b.WriteString("\n" + style.Render(m.status) + "\n")
+	}
+
+	bindings := []key.Binding{m.keys.Up, m.keys.Down, m.keys.Filter}
+	if len(m.endpoints) > 1 {
+		bindings = append(bindings, m.keys.Left, m.keys.Right)
+	}
+	if m.selection != nil {
+		bindings = append(bindings, m.keys.Latency, m.keys.Chaos)
+	}
+	bindings = append(bindings, m.keys.Force, m.keys.Reset, m.keys.Curl)
+	if len(m.endpoints) > 1 {
+		bindings = append(bindings, m.keys.ResetAll)
+	}
+	if m.apply != nil {
+		bindings = append(bindings, m.keys.Edit)
+	}
+	bindings = append(bindings, m.keys.Quit)
+	help := make([]string, len(bindings))
+	for i, binding := range bindings {
+		help[i] = fmt.Sprint(binding.Help())
+	}
+	b.WriteString(helpStyle.Render("\n" + strings.Join(help, "  ")))
+
+	return b.String()
+}
+
+// responseList renders the responses of the endpoint shown.
+func (m model) responseList() string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T("Select a response for the server:") + "\n\n")
 
 	for i, res := range m.endpoint.SliceResponses() {
+		if !m.responseVisible(m.endpoint, i) {
+			continue
+		}
 		cursor := "  " // Not selected
 		line := fmt.Sprintf("[%d] %s", res.StatusCode, res.Title)
 
@@ -94,9 +312,43 @@ func (m model) View() string {
 
 		b.WriteString(cursor + line + "\n")
 	}
+	return b.String()
+}
+
+// endpointList renders the endpoints, each with the status code of the
+// response it serves and the number of calls it got.
+func (m model) endpointList() string {
+	var b strings.Builder
 
-	help := fmt.Sprintf("\n%s  %s  %s", m.keys.Up.Help(), m.keys.Down.Help(), m.keys.Quit.Help())
-	b.WriteString(helpStyle.Render(help))
+	b.WriteString(i18n.T("Endpoints:") + "\n\n")
 
+	current := m.selection.Current()
+	for i, ep := range m.endpoints {
+		if !m.endpointVisible(i) {
+			continue
+		}
+		cursor := "  "
+		sm := m.selection.Endpoint(i)
+		line := ep.Schema.Route
+		if responses := ep.Schema.SliceResponses(); len(responses) > 0 {
+			line += fmt.Sprintf(" (%d)", responses[sm.Index()].StatusCode)
+		}
+		line += " " + i18n.T("%d call(s)", sm.CallCount())
+
+		if i == current {
+			cursor = "> "
+			line = selectedItemStyle.Render(line)
+		}
+
+		b.WriteString(cursor + line + "\n")
+	}
 	return b.String()
 }
+
+// onOff renders the state of a switch.
+func onOff(on bool) string {
+	if on {
+		return i18n.T("on")
+	}
+	return i18n.T("off")
+}
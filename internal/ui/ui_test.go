@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/history"
 	"github.com/pretodev/anansi-proxy/internal/state"
 )
 
@@ -460,3 +461,112 @@ func TestModel_Update_NavigationBoundaries(t *testing.T) {
 // It's better suited for integration/manual testing rather than unit tests.
 // The underlying model, Init(), Update(), and View() functions are all tested above,
 // which provides comprehensive coverage of the UI logic.
+
+func TestModel_View_History(t *testing.T) {
+	m := initialModel(state.New(3), createTestEndpoint())
+	m.history = history.New(5)
+
+	if view := m.View(); !strings.Contains(view, "none yet") {
+		t.Errorf("View should say no request came yet, got:\n%s", view)
+	}
+	if m.Init() == nil {
+		t.Error("Init should schedule a refresh of the requests")
+	}
+
+	m.history.Add(history.Exchange{Method: "GET", Path: "/api/test", Status: 404, ResponseIndex: 1, Response: "Not Found"})
+	m.history.Add(history.Exchange{Method: "POST", Path: "/api/test", Status: 400, ResponseIndex: -1, Errors: []string{"invalid body"}})
+	view := m.View()
+	for _, want := range []string{"GET /api/test -> 404 [1] Not Found", "POST /api/test -> 400: invalid body"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View should contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestModel_MultipleEndpoints(t *testing.T) {
+	users := createTestEndpoint()
+	users.Route = "GET /users"
+	orders := &endpoint.EndpointSchema{
+		Route: "GET /orders",
+		Responses: map[int][]endpoint.Response{
+			200: {{Title: "Orders", StatusCode: 200}},
+			503: {{Title: "Down", StatusCode: 503}},
+		},
+	}
+	endpoints := []*endpoint.EndpointWithFile{{Schema: users}, {Schema: orders}}
+	sel := state.NewSelection(users.CountResponses(), orders.CountResponses())
+	var m tea.Model = newModel(sel, endpoints)
+
+	press := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			m, _ = m.Update(msg)
+		}
+	}
+
+	press("j", "j", "l", "j")
+	if sel.Current() != 1 || sel.Endpoint(0).Index() != 2 || sel.Endpoint(1).Index() != 1 {
+		t.Fatalf("Expected GET /users on its third response and GET /orders on its second, got %d and %d showing %d",
+			sel.Endpoint(0).Index(), sel.Endpoint(1).Index(), sel.Current())
+	}
+
+	view := m.View()
+	for _, want := range []string{"Endpoints:", "GET /users (500)", "GET /orders (503)", "[503] Down", "next endpoint"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Going back shows the selection GET /users kept
+	press("h")
+	if got := m.(model).cursor; got != 2 {
+		t.Errorf("Expected the cursor back on the third response of GET /users, got %d", got)
+	}
+	press("h")
+	if sel.Current() != 0 {
+		t.Errorf("Expected h on the first endpoint to stay there, got %d", sel.Current())
+	}
+}
+
+func TestModel_Switches(t *testing.T) {
+	sel := state.NewSelection(3)
+	var m tea.Model = newModel(sel, []*endpoint.EndpointWithFile{{Schema: createTestEndpoint()}})
+	for _, k := range []string{"d", "c", "f"} {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	if !sel.Controls().Latency() || !sel.Controls().Chaos() || sel.Endpoint(0).Forced() {
+		t.Errorf("Expected latency and chaos on and the response not forced, got %v, %v, %v",
+			sel.Controls().Latency(), sel.Controls().Chaos(), sel.Endpoint(0).Forced())
+	}
+	if view := m.View(); !strings.Contains(view, "Latency: on  Chaos: on  Forced response: off") {
+		t.Errorf("View should show the switches, got:\n%s", view)
+	}
+}
+
+func TestModel_CallCounts(t *testing.T) {
+	users, orders := createTestEndpoint(), createTestEndpoint()
+	users.Route, orders.Route = "GET /users", "GET /orders"
+	sel := state.NewSelection(3, 3)
+	var m tea.Model = newModel(sel, []*endpoint.EndpointWithFile{{Schema: users}, {Schema: orders}})
+	for range 3 {
+		sel.Endpoint(0).AddCall()
+	}
+	sel.Endpoint(1).AddCall()
+
+	view := m.View()
+	for _, want := range []string{"GET /users (200) 3 call(s)", "GET /orders (200) 1 call(s)", "Calls to GET /users: 3"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if sel.Endpoint(0).CallCount() != 0 || sel.Endpoint(1).CallCount() != 1 {
+		t.Errorf("Expected r to reset the calls of GET /users only, got %d and %d", sel.Endpoint(0).CallCount(), sel.Endpoint(1).CallCount())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if sel.Endpoint(1).CallCount() != 0 {
+		t.Errorf("Expected R to reset every count, got %d", sel.Endpoint(1).CallCount())
+	}
+}
@@ -0,0 +1,76 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Shape compares the structure of two decoded JSON values, ignoring their
+// values: it lists the fields of expected missing from actual, the fields of
+// actual the mock does not have, and the fields whose JSON types differ.
+// Arrays are compared by their first elements. Null
+// matches any type, since mocks and APIs often leave optional fields empty.
+func Shape(expected, actual any) []string {
+	var drift []string
+	shape(&drift, "$", expected, actual)
+	return drift
+}
+
+func shape(drift *[]string, path string, expected, actual any) {
+	if expected == nil || actual == nil {
+		return
+	}
+	if want, got := jsonType(expected), jsonType(actual); want != got {
+		*drift = append(*drift, fmt.Sprintf("%s is %s, the mock has %s", path, got, want))
+		return
+	}
+
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual := actual.(map[string]any)
+		for _, key := range sortedKeys(expected) {
+			value, ok := actual[key]
+			if !ok {
+				*drift = append(*drift, fmt.Sprintf("%s.%s is missing", path, key))
+				continue
+			}
+			shape(drift, path+"."+key, expected[key], value)
+		}
+		for _, key := range sortedKeys(actual) {
+			if _, ok := expected[key]; !ok {
+				*drift = append(*drift, fmt.Sprintf("%s.%s is not in the mock", path, key))
+			}
+		}
+	case []any:
+		actual := actual.([]any)
+		if len(expected) > 0 && len(actual) > 0 {
+			shape(drift, path+"[0]", expected[0], actual[0])
+		}
+	}
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,223 @@
+// Package verify replays the requests of .apimock files against a live API
+// and reports where the real responses drifted from the mocks.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Mock is a parsed .apimock file and the path it was read from.
+type Mock struct {
+	File string
+	AST  *apimock.APIMockFile
+}
+
+// Options configure how requests are replayed.
+type Options struct {
+	// BaseURL is prepended to the example path of every request
+	BaseURL string
+	// Header is sent with every request, for credentials
+	Header http.Header
+	// Unsafe replays requests with methods other than GET, HEAD and OPTIONS,
+	// which may change the state of the API
+	Unsafe bool
+	// Timeout bounds each request (0 = 10s)
+	Timeout time.Duration
+}
+
+// Result is the outcome of replaying the request of one mock.
+type Result struct {
+	File    string `json:"file"`
+	Route   string `json:"route"`
+	Request string `json:"request,omitempty"`
+	// Status is the status code the API answered, 0 when it was not reached
+	Status int `json:"status,omitempty"`
+	// Drift lists the differences between the real response and the mock
+	Drift []string `json:"drift,omitempty"`
+	// Skipped tells why the mock was not verified
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// OK reports whether the API answered as the mock does.
+func (r Result) OK() bool {
+	return r.Skipped == "" && r.Error == "" && len(r.Drift) == 0
+}
+
+// safeMethods are replayed without Options.Unsafe.
+var safeMethods = map[string]bool{http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true}
+
+// Verify replays the request of every mock against the API. The real
+// response is compared with the declared response of the same status code,
+// or with the response the mock serves by default when the status code is
+// not declared: the content type, the declared headers and, for JSON, the
+// shape of the body must match.
+func Verify(ctx context.Context, mocks []Mock, opts Options) []Result {
+	client := &http.Client{Timeout: opts.Timeout}
+	if client.Timeout == 0 {
+		client.Timeout = 10 * time.Second
+	}
+
+	results := make([]Result, 0, len(mocks))
+	for _, mock := range mocks {
+		results = append(results, verify(ctx, client, mock, opts))
+	}
+	return results
+}
+
+func verify(ctx context.Context, client *http.Client, mock Mock, opts Options) Result {
+	req := mock.AST.Request
+	result := Result{File: mock.File, Route: "*"}
+	if req == nil {
+		result.Skipped = "no request section"
+		return result
+	}
+	result.Route = strings.TrimSpace(req.Method + " " + req.Path)
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !safeMethods[method] && !opts.Unsafe {
+		result.Skipped = fmt.Sprintf("%s may change the API; run with --unsafe to replay it", method)
+		return result
+	}
+	responses := declared(mock.AST.Responses)
+	if len(responses) == 0 {
+		result.Skipped = "no response without a profile"
+		return result
+	}
+
+	path, err := req.ExamplePath()
+	if err != nil {
+		result.Skipped = err.Error()
+		return result
+	}
+	target := strings.TrimRight(opts.BaseURL, "/") + path
+	if query := exampleQuery(req.QueryParams); query != "" {
+		target += "?" + query
+	}
+	result.Request = method + " " + target
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for key, values := range opts.Header {
+		httpReq.Header[key] = values
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = resp.StatusCode
+	expected, ok := responses[resp.StatusCode]
+	if !ok {
+		expected = responses[defaultStatus(responses)]
+		result.Drift = append(result.Drift, fmt.Sprintf("status %d is not declared; the mock answers %d", resp.StatusCode, expected.StatusCode))
+	}
+	result.Drift = append(result.Drift, compare(expected, resp.Header, body)...)
+	return result
+}
+
+// declared returns the first response without a profile for each status
+// code, since profiled responses describe states of the API that cannot be
+// asked for.
+func declared(sections []apimock.ResponseSection) map[int]apimock.ResponseSection {
+	responses := make(map[int]apimock.ResponseSection)
+	for _, resp := range sections {
+		if resp.Upstream != "" || len(endpoint.PropertyProfiles(resp.Properties)) > 0 {
+			continue
+		}
+		if _, ok := responses[resp.StatusCode]; !ok {
+			responses[resp.StatusCode] = resp
+		}
+	}
+	return responses
+}
+
+// defaultStatus returns 200 if declared, otherwise the lowest status code.
+func defaultStatus(responses map[int]apimock.ResponseSection) int {
+	if _, ok := responses[http.StatusOK]; ok {
+		return http.StatusOK
+	}
+	status := 0
+	for code := range responses {
+		if status == 0 || code < status {
+			status = code
+		}
+	}
+	return status
+}
+
+// exampleQuery encodes the query parameters of a mock, leaving out the
+// {placeholder} values that stand for any value.
+func exampleQuery(params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		if !strings.HasPrefix(value, "{") {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
+}
+
+// compare lists the differences between a declared response and a real one.
+func compare(expected apimock.ResponseSection, header http.Header, body []byte) []string {
+	var drift []string
+
+	want := mediaType(expected.Properties[endpoint.ResponseContentTypePropertyName])
+	got := mediaType(header.Get("Content-Type"))
+	if want != "" && want != got {
+		drift = append(drift, fmt.Sprintf("content type is %q, the mock declares %q", got, want))
+	}
+
+	for _, key := range sortedKeys(expected.Properties) {
+		if endpoint.IsResponseControlProperty(key) || apimock.IsMetadataKey(key) {
+			continue
+		}
+		if header.Get(key) == "" {
+			drift = append(drift, fmt.Sprintf("header %s is missing", key))
+		}
+	}
+
+	var mocked, real any
+	if json.Unmarshal([]byte(expected.Body), &mocked) != nil {
+		return drift
+	}
+	if err := json.Unmarshal(body, &real); err != nil {
+		return append(drift, "body is not JSON")
+	}
+	return append(drift, Shape(mocked, real)...)
+}
+
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return media
+}
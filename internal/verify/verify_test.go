@@ -0,0 +1,110 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+func parse(t *testing.T, name, src string) Mock {
+	t.Helper()
+	ast, err := apimock.NewParserFromBytes(name, []byte(src)).Parse()
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", name, err)
+	}
+	return Mock{File: name, AST: ast}
+}
+
+func TestVerify(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.String() {
+		case "/users/1?expand=orders":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"id": 1, "name": "Ada", "email": "ada@example.com", "orders": [{"id": "o1"}]}`))
+		case "/users/example":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	mocks := []Mock{
+		parse(t, "user.apimock", "GET /users/{id:[0-9]+}\n  ?expand=orders\n  &fields={fields}\n\n"+
+			"-- 200: Found\nContentType: application/json\nCache-Control: no-store\n\n"+
+			"{\"id\": 1, \"name\": \"Ada\", \"age\": 36, \"orders\": [{\"id\": 1}]}\n\n"+
+			"-- 503: Down\nProfile: outage\n"),
+		parse(t, "slug.apimock", "GET /users/{slug:[a-z]+}\n\n-- 200: Found\nContentType: application/json\n\n{}\n\n-- 404: Missing\n"),
+		parse(t, "missing.apimock", "GET /missing\n\n-- 200: OK\n\n-- 404: Not found\n"),
+		parse(t, "create.apimock", "POST /users\n\n-- 201: Created\n"),
+		parse(t, "fallback.apimock", "-- 200: Anything\n"),
+	}
+	header := http.Header{"Authorization": {"Bearer t"}}
+	results := Verify(context.Background(), mocks, Options{BaseURL: api.URL + "/", Header: header})
+
+	want := []Result{
+		{
+			File: "user.apimock", Route: "GET /users/{id:[0-9]+}", Request: "GET " + api.URL + "/users/1?expand=orders", Status: 200,
+			Drift: []string{
+				"header Cache-Control is missing",
+				"$.age is missing",
+				"$.orders[0].id is a string, the mock has a number",
+				"$.email is not in the mock",
+			},
+		},
+		{
+			File: "slug.apimock", Route: "GET /users/{slug:[a-z]+}", Request: "GET " + api.URL + "/users/example", Status: 500,
+			Drift: []string{
+				"status 500 is not declared; the mock answers 200",
+				`content type is "text/html", the mock declares "application/json"`,
+				"body is not JSON",
+			},
+		},
+		{File: "missing.apimock", Route: "GET /missing", Request: "GET " + api.URL + "/missing", Status: 404},
+		{File: "create.apimock", Route: "POST /users", Skipped: "POST may change the API; run with --unsafe to replay it"},
+		{File: "fallback.apimock", Route: "*", Skipped: "no request section"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Verify() =\n%+v\nwant\n%+v", results, want)
+	}
+	if !results[2].OK() || results[0].OK() || results[3].OK() {
+		t.Errorf("OK() = %v, %v, %v; want true, false, false", results[2].OK(), results[0].OK(), results[3].OK())
+	}
+}
+
+func TestVerify_Unreachable(t *testing.T) {
+	results := Verify(context.Background(), []Mock{parse(t, "a.apimock", "GET /a\n\n-- 200: OK\n")}, Options{BaseURL: "http://127.0.0.1:1"})
+	if len(results) != 1 || results[0].Error == "" || results[0].OK() {
+		t.Errorf("Verify() = %+v, want a connection error", results)
+	}
+}
+
+func TestShape(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected any
+		actual   any
+		want     []string
+	}{
+		{name: "same shape, other values", expected: map[string]any{"a": 1.0}, actual: map[string]any{"a": 2.0}},
+		{name: "null matches anything", expected: map[string]any{"a": nil}, actual: map[string]any{"a": "x"}},
+		{name: "root type", expected: []any{}, actual: map[string]any{}, want: []string{"$ is an object, the mock has an array"}},
+		{name: "empty mock array", expected: []any{}, actual: []any{1.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Shape(tt.expected, tt.actual); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Shape() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
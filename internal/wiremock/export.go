@@ -0,0 +1,240 @@
+package wiremock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Mapping priorities, lowest first: the responses WireMock should prefer are
+// those of a profile while its scenario state is set, then the response the
+// mock serves by default, then the others, which WireMock only serves when
+// they are the sole match.
+const (
+	priorityProfile = 1
+	priorityDefault = 2
+	priorityOther   = 3
+)
+
+// bodyComparisons are the Match-Body operators besides ==, which
+// matchesJsonPath cannot express.
+var bodyComparisons = []string{"!=", ">=", "<=", ">", "<"}
+
+// Export converts .apimock files into WireMock mappings, one per response.
+// Profiled responses wait for the ProfileScenario state named after their
+// profile. What WireMock mappings cannot express, such as conditions, request
+// body schemas and callbacks, is left out and described in the returned
+// warnings.
+func Export(files []*apimock.APIMockFile) (*Mappings, []string) {
+	m := &Mappings{Mappings: []Mapping{}}
+	var warnings []string
+	for _, file := range files {
+		pattern, route, warns := requestPattern(file.Request)
+		warnings = append(warnings, warns...)
+		defaults := defaultResponses(file.Responses)
+
+		for i, resp := range file.Responses {
+			name := fmt.Sprintf("%s -- %d: %s", route, resp.StatusCode, resp.Description)
+			if resp.Upstream != "" {
+				name = fmt.Sprintf("%s -- proxy: %s", route, resp.Upstream)
+			}
+			mapping := Mapping{
+				Name:     strings.TrimSpace(name),
+				Request:  pattern,
+				Response: responseDefinition(resp),
+				Priority: priorityOther,
+			}
+			if defaults[i] {
+				mapping.Priority = priorityDefault
+			}
+
+			for _, key := range sortedKeys(resp.Properties) {
+				if strings.HasPrefix(key, "Callback") {
+					warnings = append(warnings, fmt.Sprintf("%s: %s not exported", mapping.Name, key))
+				}
+			}
+			if strings.HasPrefix(resp.Body, apimock.ConditionPrefix) {
+				warnings = append(warnings, fmt.Sprintf("%s: conditions not exported", mapping.Name))
+			}
+
+			profiles := endpoint.PropertyProfiles(resp.Properties)
+			if len(profiles) == 0 {
+				m.Mappings = append(m.Mappings, mapping)
+				continue
+			}
+			for _, profile := range profiles {
+				profiled := mapping
+				profiled.ScenarioName = ProfileScenario
+				profiled.RequiredScenarioState = profile
+				if defaults[i] {
+					profiled.Priority = priorityProfile
+				}
+				m.Mappings = append(m.Mappings, profiled)
+			}
+		}
+	}
+	return m, warnings
+}
+
+// defaultResponses marks the responses a server serves when nothing else is
+// asked for: per profile, the first 200 response, or else the first response
+// with the lowest status code. Proxy sections are always defaults.
+func defaultResponses(responses []apimock.ResponseSection) map[int]bool {
+	best := make(map[string]int)
+	for i, resp := range responses {
+		if resp.Upstream != "" {
+			continue
+		}
+		profiles := endpoint.PropertyProfiles(resp.Properties)
+		if len(profiles) == 0 {
+			profiles = []string{""}
+		}
+		for _, profile := range profiles {
+			current, ok := best[profile]
+			if !ok || better(resp.StatusCode, responses[current].StatusCode) {
+				best[profile] = i
+			}
+		}
+	}
+
+	defaults := make(map[int]bool)
+	for i, resp := range responses {
+		if resp.Upstream != "" {
+			defaults[i] = true
+		}
+	}
+	for _, i := range best {
+		defaults[i] = true
+	}
+	return defaults
+}
+
+// better reports whether a status code is preferred over the current default.
+func better(status, current int) bool {
+	if current == http.StatusOK {
+		return false
+	}
+	return status == http.StatusOK || status < current
+}
+
+// requestPattern converts a request section, returning the pattern, the route
+// naming its mappings and warnings for what was left out.
+func requestPattern(req *apimock.RequestSection) (RequestPattern, string, []string) {
+	if req == nil {
+		return RequestPattern{Method: "ANY"}, "*", nil
+	}
+
+	p := RequestPattern{Method: req.Method}
+	if p.Method == "" {
+		p.Method = "ANY"
+	}
+	route := strings.TrimSpace(req.Method + " " + req.Path)
+
+	plain, templated := true, true
+	for _, seg := range req.PathSegments {
+		if seg.IsParameter || seg.IsWildcard {
+			plain = false
+		}
+		if seg.IsWildcard || seg.Pattern != "" {
+			templated = false
+		}
+	}
+	switch {
+	case plain:
+		p.URLPath = req.Path
+	case templated:
+		p.URLPathTemplate = req.Path
+	default:
+		var b strings.Builder
+		for _, seg := range req.PathSegments {
+			b.WriteString("/")
+			switch {
+			case seg.IsWildcard:
+				b.WriteString(".*")
+			case seg.IsParameter && seg.Pattern != "":
+				b.WriteString(seg.Pattern)
+			case seg.IsParameter:
+				b.WriteString("[^/]+")
+			default:
+				b.WriteString(regexp.QuoteMeta(seg.Value))
+			}
+		}
+		p.URLPathPattern = b.String()
+	}
+
+	var warnings []string
+	for _, key := range sortedKeys(req.QueryParams) {
+		if p.QueryParameters == nil {
+			p.QueryParameters = make(map[string]Matcher)
+		}
+		p.QueryParameters[key] = Matcher{"equalTo": req.QueryParams[key]}
+	}
+	if accept, ok := req.Properties[endpoint.RequestAcceptPropertyName]; ok {
+		p.Headers = map[string]Matcher{"Content-Type": {"contains": accept}}
+	}
+	if predicate, ok := req.Properties[endpoint.RequestMatchBodyPropertyName]; ok {
+		if pattern, ok := jsonPathPattern(predicate); ok {
+			p.BodyPatterns = []Matcher{pattern}
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s: Match-Body %q not exported", route, predicate))
+		}
+	}
+	if req.BodySchema != "" {
+		warnings = append(warnings, fmt.Sprintf("%s: request body schema not exported", route))
+	}
+	return p, route, warnings
+}
+
+// jsonPathPattern converts a Match-Body predicate into a matchesJsonPath
+// body pattern. Only presence tests and == comparisons have one.
+func jsonPathPattern(predicate string) (Matcher, bool) {
+	expr, literal, compared := strings.Cut(predicate, "==")
+	for _, op := range bodyComparisons {
+		if strings.Contains(expr, op) {
+			return nil, false
+		}
+	}
+	if !compared {
+		return Matcher{"matchesJsonPath": strings.TrimSpace(predicate)}, true
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(literal)), &value); err != nil {
+		return nil, false
+	}
+	return Matcher{"matchesJsonPath": map[string]any{
+		"expression": strings.TrimSpace(expr),
+		"equalTo":    fmt.Sprint(value),
+	}}, true
+}
+
+func responseDefinition(resp apimock.ResponseSection) ResponseDefinition {
+	if resp.Upstream != "" {
+		return ResponseDefinition{ProxyBaseURL: resp.Upstream}
+	}
+
+	d := ResponseDefinition{
+		Status:        resp.StatusCode,
+		StatusMessage: resp.Description,
+		Body:          resp.Body,
+	}
+	for _, key := range sortedKeys(resp.Properties) {
+		value := resp.Properties[key]
+		switch {
+		case key == endpoint.ResponseContentTypePropertyName:
+			key = "Content-Type"
+		case endpoint.IsResponseControlProperty(key):
+			continue
+		}
+		if d.Headers == nil {
+			d.Headers = make(map[string]any)
+		}
+		d.Headers[key] = value
+	}
+	return d
+}
@@ -0,0 +1,270 @@
+package wiremock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/importer"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// Import builds one mock per distinct request pattern of the mappings, with
+// a response per mapping, in the order the patterns first appear. Mappings
+// waiting for a scenario state other than Started get a Profile named after
+// that state. What .apimock files cannot express, such as scenario
+// transitions, delays and most matchers, is left out and described in the
+// returned warnings.
+func Import(m *Mappings) ([]importer.Mock, []string) {
+	var mocks []importer.Mock
+	var warnings []string
+	index := make(map[string]int)
+	names := make(importer.Names)
+
+	for _, mapping := range m.Mappings {
+		c := &conversion{label: label(mapping)}
+
+		key, _ := json.Marshal(mapping.Request)
+		i, seen := index[string(key)]
+		if !seen {
+			file := apimock.NewAPIMockFile()
+			file.Request = c.request(mapping.Request)
+			i = len(mocks)
+			index[string(key)] = i
+			mocks = append(mocks, importer.Mock{Name: names.ForRequest(file.Request), File: file})
+		}
+
+		resp := c.response(mapping.Response)
+		if state := mapping.RequiredScenarioState; state != "" && state != StartedState {
+			resp.Properties[endpoint.ResponseProfilePropertyName] = state
+		}
+		if mapping.NewScenarioState != "" {
+			c.warn("scenario transition to %q not imported; switch profiles with PUT /_admin/profile", mapping.NewScenarioState)
+		}
+		mocks[i].File.Responses = append(mocks[i].File.Responses, resp)
+		warnings = append(warnings, c.warnings...)
+	}
+	return mocks, warnings
+}
+
+// conversion collects the warnings of converting one mapping.
+type conversion struct {
+	label    string
+	warnings []string
+}
+
+func (c *conversion) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, c.label+": "+fmt.Sprintf(format, args...))
+}
+
+// label names a mapping in warnings.
+func label(m Mapping) string {
+	if m.Name != "" {
+		return m.Name
+	}
+	target := m.Request.URL + m.Request.URLPath + m.Request.URLPathTemplate + m.Request.URLPathPattern + m.Request.URLPattern
+	return strings.TrimSpace(m.Request.Method + " " + target)
+}
+
+func (c *conversion) request(p RequestPattern) *apimock.RequestSection {
+	method := strings.ToUpper(p.Method)
+	if method == "ANY" {
+		method = ""
+	}
+
+	var req *apimock.RequestSection
+	var query url.Values
+	switch {
+	case p.URL != "":
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			c.warn("invalid url %q", p.URL)
+			return nil
+		}
+		req, query = importer.NewRequest(method, u.EscapedPath()), u.Query()
+	case p.URLPath != "":
+		req = importer.NewRequest(method, p.URLPath)
+	case p.URLPathTemplate != "":
+		req = importer.NewRequest(method, p.URLPathTemplate)
+	case p.URLPathPattern != "":
+		req = patternRequest(method, p.URLPathPattern)
+	case p.URLPattern != "":
+		pattern, _, _ := strings.Cut(p.URLPattern, `\?`)
+		req = patternRequest(method, pattern)
+	}
+	if req == nil {
+		if method != "" || len(p.QueryParameters)+len(p.Headers)+len(p.BodyPatterns) > 0 {
+			c.warn("only the URL path of requests to / can be matched; the mock answers every request")
+		}
+		return nil
+	}
+
+	for key, values := range query {
+		req.QueryParams[key] = values[0]
+	}
+	for _, key := range sortedKeys(p.QueryParameters) {
+		if value, ok := p.QueryParameters[key]["equalTo"].(string); ok {
+			req.QueryParams[key] = value
+		} else {
+			c.warn("query parameter matcher %s not imported", key)
+		}
+	}
+
+	for _, key := range sortedKeys(p.Headers) {
+		contentType, ok := contentTypeMatcher(key, p.Headers[key])
+		if !ok {
+			c.warn("header matcher %s not imported", key)
+			continue
+		}
+		req.Properties[endpoint.RequestAcceptPropertyName] = contentType
+	}
+
+	for _, pattern := range p.BodyPatterns {
+		predicate, ok := bodyPredicate(pattern)
+		if !ok || req.Properties[endpoint.RequestMatchBodyPropertyName] != "" {
+			c.warn("body pattern %v not imported", map[string]any(pattern))
+			continue
+		}
+		req.Properties[endpoint.RequestMatchBodyPropertyName] = predicate
+	}
+	return req
+}
+
+// patternRequest returns the request section for a regular expression over
+// the URL path, turning the segments that are not literal into parameters.
+func patternRequest(method, pattern string) *apimock.RequestSection {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	if strings.Trim(pattern, "/") == "" {
+		return nil
+	}
+	req := apimock.NewRequestSection()
+	req.Method = method
+	for i, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		segment := importer.PatternSegment(seg, fmt.Sprintf("p%d", i+1))
+		req.PathSegments = append(req.PathSegments, segment)
+		req.Path += "/" + segment.Value
+	}
+	return req
+}
+
+// contentTypeMatcher returns the content type a Content-Type header matcher
+// asks for.
+func contentTypeMatcher(header string, m Matcher) (string, bool) {
+	if !strings.EqualFold(header, "Content-Type") {
+		return "", false
+	}
+	for _, op := range []string{"equalTo", "contains"} {
+		if value, ok := m[op].(string); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// bodyPredicate converts a matchesJsonPath body pattern into a Match-Body
+// predicate. Other body patterns have no .apimock equivalent.
+func bodyPredicate(m Matcher) (string, bool) {
+	switch path := m["matchesJsonPath"].(type) {
+	case string:
+		if _, err := endpoint.NewBodyMatcher(path); err == nil {
+			return path, true
+		}
+	case map[string]any:
+		expr, _ := path["expression"].(string)
+		value, ok := path["equalTo"].(string)
+		if expr == "" || !ok {
+			return "", false
+		}
+		literal, _ := json.Marshal(value)
+		predicate := expr + " == " + string(literal)
+		if _, err := endpoint.NewBodyMatcher(predicate); err == nil {
+			return predicate, true
+		}
+	}
+	return "", false
+}
+
+func (c *conversion) response(d ResponseDefinition) apimock.ResponseSection {
+	resp := apimock.NewResponseSection()
+	if d.ProxyBaseURL != "" {
+		resp.Upstream = d.ProxyBaseURL
+		return resp
+	}
+
+	resp.StatusCode = d.Status
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	resp.Description = d.StatusMessage
+	if resp.Description == "" {
+		resp.Description = http.StatusText(resp.StatusCode)
+	}
+
+	for _, key := range sortedKeys(d.Headers) {
+		value := headerValue(d.Headers[key])
+		if strings.EqualFold(key, "Content-Type") {
+			resp.Properties[endpoint.ResponseContentTypePropertyName] = value
+		} else {
+			resp.Properties[key] = value
+		}
+	}
+
+	switch {
+	case d.JSONBody != nil:
+		data, err := json.MarshalIndent(d.JSONBody, "", "  ")
+		if err == nil {
+			resp.Body = string(data)
+		}
+		if _, ok := resp.Properties[endpoint.ResponseContentTypePropertyName]; !ok {
+			resp.Properties[endpoint.ResponseContentTypePropertyName] = "application/json"
+		}
+	case d.Base64Body != "":
+		data, err := base64.StdEncoding.DecodeString(d.Base64Body)
+		if err != nil || !utf8.Valid(data) {
+			c.warn("binary base64Body not imported")
+			break
+		}
+		resp.Body = string(data)
+	case d.BodyFileName != "":
+		c.warn("bodyFileName %s not imported; paste the file into the response body", d.BodyFileName)
+	default:
+		resp.Body = d.Body
+	}
+	resp.Body = strings.TrimRight(strings.ReplaceAll(resp.Body, "\r\n", "\n"), " \t\n")
+
+	if d.FixedDelayMilliseconds > 0 {
+		c.warn("fixedDelayMilliseconds not imported")
+	}
+	return resp
+}
+
+// headerValue joins the values of a header given as a list.
+func headerValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			values = append(values, fmt.Sprint(value))
+		}
+		return strings.Join(values, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+// Package wiremock converts between WireMock stub mappings and .apimock
+// files, so suites written for one tool can be served by the other.
+package wiremock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProfileScenario is the scenario exported profiled responses belong to. Its
+// states are the profile names: setting the state through the WireMock admin
+// API switches profiles as PUT /_admin/profile does.
+const ProfileScenario = "anansi-profiles"
+
+// StartedState is the state every WireMock scenario starts in.
+const StartedState = "Started"
+
+// Mappings is a WireMock mappings document, as served at /__admin/mappings.
+type Mappings struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping is a WireMock stub: a request pattern and the response served for
+// matching requests.
+type Mapping struct {
+	Name                  string             `json:"name,omitempty"`
+	Priority              int                `json:"priority,omitempty"`
+	Request               RequestPattern     `json:"request"`
+	Response              ResponseDefinition `json:"response"`
+	ScenarioName          string             `json:"scenarioName,omitempty"`
+	RequiredScenarioState string             `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string             `json:"newScenarioState,omitempty"`
+}
+
+// RequestPattern selects the requests a mapping answers. At most one of the
+// URL fields is set; none matches every URL.
+type RequestPattern struct {
+	Method          string             `json:"method,omitempty"`
+	URL             string             `json:"url,omitempty"`
+	URLPath         string             `json:"urlPath,omitempty"`
+	URLPathTemplate string             `json:"urlPathTemplate,omitempty"`
+	URLPathPattern  string             `json:"urlPathPattern,omitempty"`
+	URLPattern      string             `json:"urlPattern,omitempty"`
+	QueryParameters map[string]Matcher `json:"queryParameters,omitempty"`
+	Headers         map[string]Matcher `json:"headers,omitempty"`
+	BodyPatterns    []Matcher          `json:"bodyPatterns,omitempty"`
+}
+
+// Matcher is a WireMock value matcher, such as {"equalTo": "json"} or
+// {"matchesJsonPath": "$.name"}.
+type Matcher map[string]any
+
+// ResponseDefinition is the response of a mapping. Headers values are
+// strings or lists of strings.
+type ResponseDefinition struct {
+	Status                 int            `json:"status,omitempty"`
+	StatusMessage          string         `json:"statusMessage,omitempty"`
+	Headers                map[string]any `json:"headers,omitempty"`
+	Body                   string         `json:"body,omitempty"`
+	JSONBody               any            `json:"jsonBody,omitempty"`
+	Base64Body             string         `json:"base64Body,omitempty"`
+	BodyFileName           string         `json:"bodyFileName,omitempty"`
+	ProxyBaseURL           string         `json:"proxyBaseUrl,omitempty"`
+	FixedDelayMilliseconds int            `json:"fixedDelayMilliseconds,omitempty"`
+}
+
+// Read decodes a mappings document, or a file holding a single mapping as
+// WireMock keeps them in its mappings directory.
+func Read(r io.Reader) (*Mappings, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid WireMock mappings: %w", err)
+	}
+	var m Mappings
+	if _, single := probe["request"]; single {
+		var mapping Mapping
+		err = json.Unmarshal(data, &mapping)
+		m.Mappings = []Mapping{mapping}
+	} else {
+		err = json.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid WireMock mappings: %w", err)
+	}
+	return &m, nil
+}
+
+// WriteJSON writes the mappings as an indented document WireMock can load
+// from its mappings directory or receive at /__admin/mappings/import.
+func (m *Mappings) WriteJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,170 @@
+package wiremock
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+const mappings = `{
+  "mappings": [
+    {
+      "request": {"method": "GET", "url": "/api/users?page=1"},
+      "response": {"status": 200, "jsonBody": {"users": []}, "headers": {"X-Total": ["0", "1"]}}
+    },
+    {
+      "name": "users down",
+      "request": {"method": "GET", "url": "/api/users?page=1"},
+      "response": {"status": 503, "body": "down", "fixedDelayMilliseconds": 500},
+      "scenarioName": "outage", "requiredScenarioState": "broken"
+    },
+    {
+      "request": {"method": "POST", "urlPathPattern": "/api/users/[0-9]+/orders",
+        "headers": {"Content-Type": {"equalTo": "application/json"}, "Authorization": {"matches": "Bearer .*"}},
+        "bodyPatterns": [{"matchesJsonPath": {"expression": "$.type", "equalTo": "premium"}}]},
+      "response": {"status": 201, "statusMessage": "Order placed", "body": "{\"cost\": \"${price}\"}",
+        "headers": {"Content-Type": "application/json", "Location": "/orders/1"}},
+      "newScenarioState": "ordered"
+    },
+    {
+      "request": {"urlPathTemplate": "/legacy/{id}"},
+      "response": {"proxyBaseUrl": "https://legacy.example.com"}
+    }
+  ]
+}`
+
+func TestImport(t *testing.T) {
+	m, err := Read(strings.NewReader(mappings))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	mocks, warnings := Import(m)
+
+	want := map[string]string{
+		"get-api-users.apimock": "GET /api/users\n  ?page=1\n\n" +
+			"-- 200: OK\nContentType: application/json\nX-Total: 0, 1\n\n{\n  \"users\": []\n}\n\n" +
+			"-- 503: Service Unavailable\nProfile: broken\n\ndown\n",
+		"post-api-users-p3-orders.apimock": "POST /api/users/{p3:[0-9]+}/orders\nAccept: application/json\nMatch-Body: $.type == \"premium\"\n\n" +
+			"-- 201: Order placed\nContentType: application/json\nLocation: /orders/1\n\n{\"cost\": \"$${price}\"}\n",
+		"any-legacy-id.apimock": "/legacy/{id}\n\n-- proxy: https://legacy.example.com\n",
+	}
+	if len(mocks) != len(want) {
+		t.Fatalf("Import() = %d mocks, want %d", len(mocks), len(want))
+	}
+	for _, mock := range mocks {
+		data, err := mock.File.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%s) error = %v", mock.Name, err)
+		}
+		if string(data) != want[mock.Name] {
+			t.Errorf("%s =\n%s\nwant\n%s", mock.Name, data, want[mock.Name])
+		}
+		if _, err := apimock.NewParserFromBytes(mock.Name, data).Parse(); err != nil {
+			t.Errorf("%s does not parse: %v", mock.Name, err)
+		}
+	}
+
+	wantWarnings := []string{
+		"users down: fixedDelayMilliseconds not imported",
+		"POST /api/users/[0-9]+/orders: header matcher Authorization not imported",
+		`POST /api/users/[0-9]+/orders: scenario transition to "ordered" not imported; switch profiles with PUT /_admin/profile`,
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Import() warnings =\n%v\nwant\n%v", warnings, wantWarnings)
+	}
+}
+
+func TestRead_SingleMapping(t *testing.T) {
+	m, err := Read(strings.NewReader(`{"request": {"method": "GET", "urlPath": "/ping"}, "response": {"body": "pong"}}`))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(m.Mappings) != 1 || m.Mappings[0].Request.URLPath != "/ping" {
+		t.Errorf("Read() = %+v, want the single mapping", m)
+	}
+}
+
+const source = `POST /api/users/{id:[0-9]+}/orders
+Accept: application/json
+Match-Body: $.type == "premium"
+
+-- 201: Order placed
+ContentType: application/json
+Callback: https://hooks.example.com
+
+{"id": 1}
+
+-- 503: Down
+Profile: outage
+
+-- 400: Invalid
+`
+
+func TestExport(t *testing.T) {
+	file, err := apimock.NewParserFromBytes("orders.apimock", []byte(source)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	m, warnings := Export([]*apimock.APIMockFile{file})
+
+	var buf bytes.Buffer
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var got any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+
+	request := `"request": {
+		"method": "POST",
+		"urlPathPattern": "/api/users/[0-9]+/orders",
+		"headers": {"Content-Type": {"contains": "application/json"}},
+		"bodyPatterns": [{"matchesJsonPath": {"expression": "$.type", "equalTo": "premium"}}]
+	}`
+	var want any
+	wantJSON := `{"mappings": [
+		{"name": "POST /api/users/{id:[0-9]+}/orders -- 201: Order placed", "priority": 2, ` + request + `,
+		 "response": {"status": 201, "statusMessage": "Order placed", "body": "{\"id\": 1}", "headers": {"Content-Type": "application/json"}}},
+		{"name": "POST /api/users/{id:[0-9]+}/orders -- 503: Down", "priority": 1, ` + request + `,
+		 "response": {"status": 503, "statusMessage": "Down"},
+		 "scenarioName": "anansi-profiles", "requiredScenarioState": "outage"},
+		{"name": "POST /api/users/{id:[0-9]+}/orders -- 400: Invalid", "priority": 3, ` + request + `,
+		 "response": {"status": 400, "statusMessage": "Invalid"}}
+	]}`
+	if err := json.Unmarshal([]byte(wantJSON), &want); err != nil {
+		t.Fatalf("invalid expectation: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Export() =\n%s", buf.String())
+	}
+
+	wantWarnings := []string{"POST /api/users/{id:[0-9]+}/orders -- 201: Order placed: Callback not exported"}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Export() warnings = %v, want %v", warnings, wantWarnings)
+	}
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	src := "GET /api/items/{id}\n\n-- 200: Found\nContentType: application/json\n\n{\"id\": 1}\n\n-- 404: Missing\nProfile: empty\n"
+	file, err := apimock.NewParserFromBytes("items.apimock", []byte(src)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	m, _ := Export([]*apimock.APIMockFile{file})
+	mocks, warnings := Import(m)
+	if len(mocks) != 1 || len(warnings) != 0 {
+		t.Fatalf("Import(Export()) = %d mocks, warnings %v", len(mocks), warnings)
+	}
+	data, err := mocks[0].File.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != src {
+		t.Errorf("Import(Export()) =\n%s\nwant\n%s", data, src)
+	}
+}
@@ -0,0 +1,295 @@
+// Package anansi embeds the anansi-proxy mock server in Go programs, so tests
+// can serve .apimock files with net/http/httptest instead of running the
+// anansi-proxy binary:
+//
+//	//go:embed mocks
+//	var mocks embed.FS
+//
+//	func TestClient(t *testing.T) {
+//		srv := anansi.NewServer()
+//		if err := srv.LoadDir(mocks, "mocks"); err != nil {
+//			t.Fatal(err)
+//		}
+//		ts := httptest.NewServer(srv.Handler())
+//		defer ts.Close()
+//		// point the client under test at ts.URL
+//	}
+package anansi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+	"github.com/pretodev/anansi-proxy/internal/endpoint"
+	"github.com/pretodev/anansi-proxy/internal/server"
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+// builtFilePath stands in for the file path of mocks added with Add, in
+// listings and error messages.
+const builtFilePath = "(built)"
+
+// DefaultAddr is the address Start listens on unless WithAddr sets another:
+// a free port of the loopback interface.
+const DefaultAddr = "127.0.0.1:0"
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAddr makes Start listen on addr, such as ":8977".
+func WithAddr(addr string) Option {
+	return func(s *Server) { s.addr = addr }
+}
+
+// WithCompression compresses the responses of every endpoint for clients
+// that accept gzip, deflate or brotli.
+func WithCompression() Option {
+	return func(s *Server) { s.configure = append(s.configure, (*server.Server).EnableCompression) }
+}
+
+// WithFrozenRandom fills time placeholders and session IDs with fixed values,
+// and seeds the random functions, so responses are identical from run to run.
+func WithFrozenRandom() Option {
+	return func(s *Server) { s.configure = append(s.configure, (*server.Server).FreezeRandom) }
+}
+
+// WithChaos breaks the given fraction of responses, reproducibly for a seed.
+func WithChaos(rate float64, seed int64) Option {
+	return func(s *Server) {
+		s.configure = append(s.configure, func(srv *server.Server) { srv.EnableChaos(rate, seed) })
+	}
+}
+
+// WithStrictResponses answers 500 instead of response bodies that do not
+// match the schema of their Schema property.
+func WithStrictResponses() Option {
+	return func(s *Server) {
+		s.configure = append(s.configure, func(srv *server.Server) { srv.ValidateResponses(server.ResponseValidationError) })
+	}
+}
+
+// WithRecording keeps every request the server answers, for Requests.
+func WithRecording() Option {
+	return func(s *Server) { s.configure = append(s.configure, (*server.Server).RecordRequests) }
+}
+
+// Request is a request answered by a Server. Route is the route of the mock
+// that answered it, as in "GET /users/{id}", and is empty when no mock
+// matched.
+type Request = server.RecordedRequest
+
+// ResponseSelection is the declared response a mock chose for a request.
+type ResponseSelection = server.ResponseSelection
+
+// Script computes a response declared with a Script property from the
+// request, described by a ScriptContext holding the values {{...}}
+// placeholders see. Its ScriptResult changes the declared response.
+type (
+	Script        = endpoint.Script
+	ScriptContext = endpoint.TemplateContext
+	ScriptResult  = endpoint.ScriptResult
+	ScriptEngine  = endpoint.ScriptEngine
+)
+
+// RegisterScriptEngine makes engine run the scripts whose file name ends with
+// ext, such as .js or .lua. No engine is built in, so mocks declaring scripts
+// fail to load until one is registered for their extension.
+func RegisterScriptEngine(ext string, engine ScriptEngine) {
+	endpoint.RegisterScriptEngine(ext, engine)
+}
+
+// Server serves the mocks loaded into it. Its handler is built on first use
+// and shared by Handler and Start, so call counts, sessions and the other
+// state of the mocks are the same through both; loading more mocks resets
+// that state.
+type Server struct {
+	addr      string
+	configure []func(*server.Server)
+
+	mu        sync.Mutex
+	endpoints []*endpoint.EndpointWithFile
+	srv       *server.Server
+	handler   http.Handler
+	url       string
+	done      chan struct{} // closed when the server started by Start stops
+	err       error         // why it stopped, see Wait
+}
+
+// NewServer returns a Server without mocks, configured by opts.
+func NewServer(opts ...Option) *Server {
+	s := &Server{addr: DefaultAddr}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// LoadDir loads the .apimock files of fsys under dir, searched recursively,
+// or the file dir names. Includes and response schema files are read from
+// fsys too. Nothing is loaded if any of the files fails to parse.
+func (s *Server) LoadDir(fsys fs.FS, dir string) error {
+	names, err := discovery.FindAPIMockFilesFS(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	endpoints, broken := endpoint.LoadAPIMockFS(fsys, names...)
+	if len(broken) > 0 {
+		errs := make([]error, len(broken))
+		for i, err := range broken {
+			errs[i] = err
+		}
+		return fmt.Errorf("failed to load mocks from '%s': %w", dir, errors.Join(errs...))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = append(s.endpoints, endpoints...)
+	s.srv, s.handler = nil, nil
+	return nil
+}
+
+// Add loads mocks built in code, such as with apimock.NewEndpoint. Nothing is
+// loaded if any of them is invalid.
+func (s *Server) Add(files ...*apimock.APIMockFile) error {
+	endpoints := make([]*endpoint.EndpointWithFile, len(files))
+	for i, file := range files {
+		schema, err := endpoint.FromAPIMockFile(file)
+		if err != nil {
+			return fmt.Errorf("invalid mock %d: %w", i+1, err)
+		}
+		endpoints[i] = &endpoint.EndpointWithFile{Schema: schema, FilePath: builtFilePath}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = append(s.endpoints, endpoints...)
+	s.srv, s.handler = nil, nil
+	return nil
+}
+
+// Handler returns the HTTP handler serving the loaded mocks.
+func (s *Server) Handler() http.Handler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.build()
+}
+
+// build creates the server of the loaded mocks if it does not exist yet.
+// s.mu must be held.
+func (s *Server) build() http.Handler {
+	if s.handler == nil {
+		s.srv = server.New(s.endpoints)
+		for _, configure := range s.configure {
+			configure(s.srv)
+		}
+		s.handler = s.srv.Handler()
+	}
+	return s.handler
+}
+
+// Use adds middleware around every request the server handles, so embedders
+// can add authentication, tracing or logging, or change requests before the
+// mocks see them. Middleware added first runs first.
+func (s *Server) Use(middleware func(next http.Handler) http.Handler) {
+	s.hook(func(srv *server.Server) { srv.Use(middleware) })
+}
+
+// OnRequest registers fn to be called with every request before it is
+// served. fn may read the body, which is restored for the mocks.
+func (s *Server) OnRequest(fn func(r *http.Request)) {
+	s.hook(func(srv *server.Server) { srv.OnRequest(fn) })
+}
+
+// OnResponseSelected registers fn to be called when a mock chose the
+// declared response it answers a request with, before writing it.
+func (s *Server) OnResponseSelected(fn func(r *http.Request, selection ResponseSelection)) {
+	s.hook(func(srv *server.Server) { srv.OnResponseSelected(fn) })
+}
+
+// OnError registers fn to be called when a request to the mock of route could
+// not be served as declared, such as a body failing its schema.
+func (s *Server) OnError(fn func(r *http.Request, route string, err error)) {
+	s.hook(func(srv *server.Server) { srv.OnError(fn) })
+}
+
+// hook registers a hook on the server built from now on and on the one
+// built already, if any, so hooks apply to handlers returned before.
+func (s *Server) hook(register func(*server.Server)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configure = append(s.configure, register)
+	if s.srv != nil {
+		register(s.srv)
+	}
+}
+
+// Requests returns the requests answered since the handler was built, in the
+// order they were answered, if the server was created WithRecording.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.RequestsMatching(nil)
+}
+
+// Start listens on the address of the server and serves the loaded mocks in
+// the background until ctx is cancelled, then shuts down gracefully. URL
+// returns the address the server listens on once Start returns, and Wait
+// tells when and why serving stopped.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	s.mu.Lock()
+	s.build()
+	srv := s.srv
+	s.url = "http://" + ln.Addr().String()
+	done := make(chan struct{})
+	s.done = done
+	s.mu.Unlock()
+
+	go func() {
+		err := srv.ServeListener(ctx, ln)
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		close(done)
+	}()
+	return nil
+}
+
+// Wait blocks until the server started by Start stops serving and returns
+// why: nil once ctx is cancelled and in-flight requests finish, or the error
+// that stopped it. It returns nil at once before Start.
+func (s *Server) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// URL returns the base URL of the server started by Start, such as
+// http://127.0.0.1:53412, or "" before Start.
+func (s *Server) URL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.url
+}
@@ -0,0 +1,182 @@
+package anansi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pretodev/anansi-proxy/pkg/apimock"
+)
+
+var mocks = fstest.MapFS{
+	"mocks/users.apimock": {Data: []byte(`GET /users/{id}
+
+-- 200: OK
+ContentType: application/json
+Schema: schemas/user.json
+
+{"id": 7}
+
+@include ../common/errors.apimock
+`)},
+	"mocks/names/name.apimock": {Data: []byte(`GET /users/{id}/name
+
+-- 200: OK
+ContentType: application/json
+Schema: ../schemas/user.json
+
+{"name": "Ada"}
+`)},
+	"mocks/schemas/user.json": {Data: []byte(`{"type": "object", "required": ["id"]}`)},
+	"common/errors.apimock": {Data: []byte(`-- 404: Not found
+
+{"error": "not found"}
+`)},
+}
+
+func TestServer_Handler(t *testing.T) {
+	srv := NewServer(WithStrictResponses())
+	if err := srv.LoadDir(mocks, "mocks"); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/users/7", wantCode: http.StatusOK, wantBody: `{"id": 7}`},
+		// The body lacks the id the schema requires
+		{path: "/users/7/name", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.path, err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, resp.StatusCode, body)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestServer_LoadDirErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		dir  string
+		want string
+	}{
+		{name: "missing directory", fsys: mocks, dir: "missing", want: "missing"},
+		{name: "no mocks", fsys: mocks, dir: "mocks/schemas", want: "no .apimock files"},
+		{name: "missing include", fsys: fstest.MapFS{"a.apimock": {Data: []byte("GET /a\n@include b.apimock\n\n-- 200: OK\n")}}, dir: ".", want: "cannot include b.apimock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer()
+			err := srv.LoadDir(tt.fsys, tt.dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadDir() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Start(t *testing.T) {
+	srv := NewServer()
+	if err := srv.LoadDir(mocks, "mocks/users.apimock"); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if srv.URL() != "" {
+		t.Errorf("URL() before Start = %q", srv.URL())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	resp, err := http.Get(srv.URL() + "/users/1")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	if err := srv.Wait(); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if _, err := http.Get(srv.URL() + "/users/1"); err == nil {
+		t.Error("expected the server to stop once ctx is cancelled")
+	}
+}
+
+func TestServer_Add(t *testing.T) {
+	file, err := apimock.NewEndpoint("POST /orders").
+		Response(201).JSON(map[string]any{"id": 1}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	srv := NewServer()
+	if err := srv.Add(file); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "{\n  \"id\": 1\n}" {
+		t.Errorf("Expected the built response, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := srv.Add(apimock.NewAPIMockFile()); err == nil {
+		t.Error("expected an error for a mock without responses")
+	}
+}
+
+func TestServer_Hooks(t *testing.T) {
+	srv := NewServer()
+	if err := srv.LoadDir(mocks, "mocks/users.apimock"); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	handler := srv.Handler()
+
+	var selected []int
+	srv.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Served-By", "anansi")
+			next.ServeHTTP(w, r)
+		})
+	})
+	srv.OnResponseSelected(func(r *http.Request, selection ResponseSelection) {
+		selected = append(selected, selection.StatusCode)
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if rec.Header().Get("X-Served-By") != "anansi" {
+		t.Errorf("Expected the middleware to run, got headers %v", rec.Header())
+	}
+	if len(selected) != 1 || selected[0] != http.StatusOK {
+		t.Errorf("Expected the 200 response to be selected, got %v", selected)
+	}
+}
@@ -0,0 +1,115 @@
+// Package mocktest starts anansi mock servers for Go tests and verifies the
+// requests they received:
+//
+//	func TestSignup(t *testing.T) {
+//		m := mocktest.New(t, "testdata/mocks")
+//		client := NewClient(m.URL)
+//		// exercise the client
+//		m.AssertCalled(t, "POST /users", 1)
+//		if body := m.Requests("POST /users")[0].Body; !bytes.Contains(body, []byte("Ana")) {
+//			t.Errorf("unexpected body %s", body)
+//		}
+//	}
+package mocktest
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pretodev/anansi-proxy/pkg/anansi"
+)
+
+// Mock is a mock server serving the .apimock files of a directory for the
+// duration of a test, recording the requests it answers.
+type Mock struct {
+	// URL is the base URL of the server, such as http://127.0.0.1:53412.
+	URL string
+
+	srv *anansi.Server
+}
+
+// New serves the .apimock files under dir, searched recursively, until t
+// and its subtests complete. It fails t if the files do not load.
+func New(t testing.TB, dir string, opts ...anansi.Option) *Mock {
+	t.Helper()
+
+	srv := anansi.NewServer(append(opts, anansi.WithRecording())...)
+	if err := srv.LoadDir(os.DirFS(dir), "."); err != nil {
+		t.Fatalf("mocktest: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return &Mock{URL: ts.URL, srv: srv}
+}
+
+// Requests returns the requests matching pattern, in the order they were
+// answered. pattern is either the request line of a mock, as in
+// "GET /users/{id}", matching the requests that mock answered, or a method
+// and path, as in "GET /users/7". A pattern without a method, as "/users",
+// matches requests of any method.
+func (m *Mock) Requests(pattern string) []anansi.Request {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+
+	var matching []anansi.Request
+	for _, req := range m.srv.Requests() {
+		if method != "" && req.Method != method {
+			continue
+		}
+		route := req.Route
+		if _, routePath, ok := strings.Cut(route, " "); ok {
+			route = routePath
+		}
+		if req.Path == path || route == path {
+			matching = append(matching, req)
+		}
+	}
+	return matching
+}
+
+// AllRequests returns every request the mock answered, including those no
+// mock matched, in the order they were answered.
+func (m *Mock) AllRequests() []anansi.Request {
+	return m.srv.Requests()
+}
+
+// AssertCalled fails t unless the mock answered exactly times requests
+// matching pattern, as Requests matches them. It reports whether it passed.
+func (m *Mock) AssertCalled(t testing.TB, pattern string, times int) bool {
+	t.Helper()
+	if got := len(m.Requests(pattern)); got != times {
+		t.Errorf("mocktest: %s was called %d time(s), want %d%s", pattern, got, times, m.received())
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails t if the mock answered a request matching pattern.
+// It reports whether it passed.
+func (m *Mock) AssertNotCalled(t testing.TB, pattern string) bool {
+	t.Helper()
+	return m.AssertCalled(t, pattern, 0)
+}
+
+// received lists the requests the mock answered, to explain failed
+// assertions.
+func (m *Mock) received() string {
+	requests := m.srv.Requests()
+	if len(requests) == 0 {
+		return "; no requests were received"
+	}
+	var b strings.Builder
+	b.WriteString("; received:")
+	for _, req := range requests {
+		b.WriteString("\n  " + req.Method + " " + req.Path)
+		if req.Route == "" {
+			b.WriteString(" (no mock matched)")
+		}
+	}
+	return b.String()
+}
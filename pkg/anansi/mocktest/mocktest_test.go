@@ -0,0 +1,104 @@
+package mocktest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder stands in for the testing.TB of a test whose assertions fail.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func writeMocks(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"users/create.apimock": "POST /users\n\n-- 201: Created\n\n{}\n",
+		"users/get.apimock":    "GET /users/{id}\n\n-- 200: OK\n\n{}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMock_Requests(t *testing.T) {
+	m := New(t, writeMocks(t))
+
+	send := func(method, path, body string) {
+		req, err := http.NewRequest(method, m.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	send(http.MethodPost, "/users", `{"name": "Ana"}`)
+	send(http.MethodPost, "/users", `{"name": "Bia"}`)
+	send(http.MethodGet, "/users/7", "")
+	send(http.MethodGet, "/users/8", "")
+	send(http.MethodGet, "/missing", "")
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{pattern: "POST /users", want: 2},
+		{pattern: "GET /users", want: 0},
+		{pattern: "/users", want: 2},
+		{pattern: "GET /users/{id}", want: 2},
+		{pattern: "GET /users/7", want: 1},
+		{pattern: "GET /missing", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			m.AssertCalled(t, tt.pattern, tt.want)
+		})
+	}
+
+	if body := string(m.Requests("POST /users")[1].Body); body != `{"name": "Bia"}` {
+		t.Errorf("second POST body = %q", body)
+	}
+	if got := len(m.AllRequests()); got != 5 {
+		t.Errorf("AllRequests() returned %d requests, want 5", got)
+	}
+	m.AssertNotCalled(t, "DELETE /users/{id}")
+}
+
+func TestMock_AssertCalledFailure(t *testing.T) {
+	m := New(t, writeMocks(t))
+	resp, err := http.Get(m.URL + "/nowhere")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	r := &recorder{TB: t}
+	if m.AssertCalled(r, "POST /users", 1) {
+		t.Error("AssertCalled() passed without a matching request")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "POST /users was called 0 time(s), want 1") || !strings.Contains(r.errors[0], "GET /nowhere (no mock matched)") {
+		t.Errorf("unexpected failure message: %q", r.errors)
+	}
+}
@@ -2,7 +2,11 @@
 // APIMock files define HTTP API mock specifications including requests and responses.
 package apimock
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
 
 // HTTP method constants
 const (
@@ -17,6 +21,16 @@ const (
 	MethodConnect = "CONNECT"
 )
 
+// MetadataPrefix marks properties that carry tool-specific metadata
+// (owners, ticket links, ...) rather than mock behavior. Other X- properties
+// are headers like any other.
+const MetadataPrefix = "X-Meta-"
+
+// ConditionPrefix starts the condition lines of a response (see
+// CONDITIONS.md). The parser checks their expressions and keeps them at the
+// start of the response body; see ResponseSection.Conditions and Content.
+const ConditionPrefix = ">"
+
 // HTTP status code ranges
 const (
 	MinHTTPStatusCode = 100
@@ -41,6 +55,8 @@ var validHTTPMethods = map[string]bool{
 type APIMockFile struct {
 	Request   *RequestSection   // Optional request section
 	Responses []ResponseSection // At least one response section
+	Includes  []string          // Files expanded by @include directives, in the order they were first included
+	Env       []string          // Environment variables referenced with ${NAME}, in the order they first appear
 }
 
 // RequestSection represents the HTTP request definition.
@@ -53,15 +69,41 @@ type RequestSection struct {
 	QueryParams  map[string]string // Query parameters
 	Properties   map[string]string // Request Properties
 	BodySchema   string            // Request body schema (JSON, XML, etc.)
+	Lines        LineRange         // Lines of the source file spanned by the section
+
+	// RequiredQuery and RequiredProperties list the query parameters and
+	// properties written with a ! after the key (?limit!=10,
+	// Authorization!: Bearer token), which requests must send
+	RequiredQuery      []string
+	RequiredProperties []string
 }
 
 // PathSegment represents a segment in the URL path.
-// A segment can be either a static value (e.g., "users") or a parameter
-// placeholder (e.g., "{id}").
+// A segment can be a static value (e.g., "users"), a parameter placeholder
+// (e.g., "{id}" or "{id:[0-9]+}") or a wildcard ("*").
 type PathSegment struct {
 	Value       string // The actual value
 	IsParameter bool   // true if it's a placeholder like {id}
 	Name        string // Parameter name (only if IsParameter is true)
+	Pattern     string // Regular expression the parameter must match, if any
+	Type        string // ParamTypeInt, ParamTypeString or ParamTypeUUID for typed parameters
+	IsWildcard  bool   // true if it's a * wildcard
+}
+
+// Types of path parameters, written in place of a pattern as in {id:int}.
+// The Pattern of a typed parameter is the regular expression of its type.
+const (
+	ParamTypeInt    = "int"    // decimal integer, such as 42 or -1
+	ParamTypeString = "string" // any segment
+	ParamTypeUUID   = "uuid"   // hyphenated UUID, such as 0b6a2f5e-7c1d-4e8a-9f3b-2d4c6e8a0b1c
+)
+
+// paramTypePatterns maps the types of path parameters to the regular
+// expressions their values must match.
+var paramTypePatterns = map[string]string{
+	ParamTypeInt:    `-?[0-9]+`,
+	ParamTypeString: `[^/]+`,
+	ParamTypeUUID:   `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
 }
 
 // String returns the string representation of the path segment.
@@ -73,10 +115,20 @@ func (ps PathSegment) String() string {
 // Each response includes a status code, optional description,
 // properties, and response body content.
 type ResponseSection struct {
-	StatusCode  int               // HTTP status code (200, 404, etc.)
+	StatusCode  int               // HTTP status code (200, 404, etc.); 0 for proxy sections
 	Description string            // Optional description
 	Properties  map[string]string // Response Properties
 	Body        string            // Response body content
+	Lines       LineRange         // Lines of the source file spanned by the section
+	Upstream    string            // URL requests are forwarded to, for proxy sections (-- proxy: URL)
+}
+
+// LineRange is a range of 1-based source lines, from the first line of a
+// section to its last non-blank line. It is zero for sections that were not
+// parsed from a file.
+type LineRange struct {
+	Start int
+	End   int
 }
 
 // NewAPIMockFile creates a new empty APIMock file structure.
@@ -119,6 +171,32 @@ func (r *RequestSection) HasPathParameters() bool {
 	return false
 }
 
+// Metadata returns the request properties whose keys start with MetadataPrefix.
+func (r *RequestSection) Metadata() map[string]string {
+	return metadataOf(r.Properties)
+}
+
+// Metadata returns the response properties whose keys start with MetadataPrefix.
+func (r *ResponseSection) Metadata() map[string]string {
+	return metadataOf(r.Properties)
+}
+
+// metadataOf collects the metadata entries of a properties map.
+func metadataOf(properties map[string]string) map[string]string {
+	metadata := make(map[string]string)
+	for key, value := range properties {
+		if IsMetadataKey(key) {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// IsMetadataKey checks if a property key is a metadata key (X-Meta- prefixed).
+func IsMetadataKey(key string) bool {
+	return len(key) > len(MetadataPrefix) && strings.EqualFold(key[:len(MetadataPrefix)], MetadataPrefix)
+}
+
 // NewResponseSection creates a new empty response section.
 // The Headers map is initialized to an empty map.
 func NewResponseSection() ResponseSection {
@@ -132,7 +210,9 @@ func NewResponseSection() ResponseSection {
 // values are within acceptable ranges.
 func (f *APIMockFile) Validate() error {
 	if len(f.Responses) == 0 {
-		return NewValidationError("Responses", "at least one response section is required")
+		err := NewValidationError("Responses", "at least one response section is required")
+		err.Code = CodeMissingResponse
+		return err
 	}
 
 	// Validate request section if present
@@ -155,12 +235,12 @@ func (f *APIMockFile) Validate() error {
 // Validate checks if the RequestSection is semantically valid.
 func (r *RequestSection) Validate() error {
 	if r.Path == "" {
-		return NewValidationError("Path", "path is required")
+		return r.validationError(CodeMissingPath, "Path", "path is required")
 	}
 
 	// Validate HTTP method if present
 	if r.Method != "" && !IsValidHTTPMethod(r.Method) {
-		return NewValidationError("Method", fmt.Sprintf("invalid HTTP method: %s", r.Method))
+		return r.validationError(CodeInvalidMethod, "Method", fmt.Sprintf("invalid HTTP method: %s", r.Method))
 	}
 
 	return nil
@@ -168,12 +248,33 @@ func (r *RequestSection) Validate() error {
 
 // Validate checks if the ResponseSection is semantically valid.
 func (r *ResponseSection) Validate() error {
+	if r.Upstream != "" {
+		if !IsValidUpstream(r.Upstream) {
+			return r.validationError(CodeInvalidProxyURL, "Upstream", fmt.Sprintf("invalid proxy URL %q (must be an absolute http or https URL)", r.Upstream))
+		}
+		return nil
+	}
 	if !IsValidHTTPStatusCode(r.StatusCode) {
-		return NewValidationError("StatusCode", fmt.Sprintf("invalid HTTP status code: %d (must be between %d-%d)", r.StatusCode, MinHTTPStatusCode, MaxHTTPStatusCode))
+		return r.validationError(CodeInvalidStatusCode, "StatusCode", fmt.Sprintf("invalid HTTP status code: %d (must be between %d-%d)", r.StatusCode, MinHTTPStatusCode, MaxHTTPStatusCode))
 	}
 	return nil
 }
 
+func (r *RequestSection) validationError(code ErrorCode, field, message string) *ValidationError {
+	return &ValidationError{Code: code, Field: field, Line: r.Lines.Start, Message: message}
+}
+
+func (r *ResponseSection) validationError(code ErrorCode, field, message string) *ValidationError {
+	return &ValidationError{Code: code, Field: field, Line: r.Lines.Start, Message: message}
+}
+
+// IsValidUpstream checks if the URL of a proxy section is an absolute http or
+// https URL.
+func IsValidUpstream(upstream string) bool {
+	u, err := url.Parse(upstream)
+	return err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https")
+}
+
 // IsValidHTTPMethod checks if the given method is a valid HTTP method.
 func IsValidHTTPMethod(method string) bool {
 	return validHTTPMethods[method]
@@ -316,3 +316,118 @@ func TestIsValidHTTPStatusCode(t *testing.T) {
 		}
 	}
 }
+
+func TestIsMetadataKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"X-Meta-Owner", true},
+		{"x-meta-ticket", true},
+		{"X-Meta-", false},
+		{"X-Request-ID", false},
+		{"Owner", false},
+		{"ContentType", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsMetadataKey(tt.key); got != tt.want {
+			t.Errorf("IsMetadataKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSection_Metadata(t *testing.T) {
+	content := `GET /api/users
+Accept: application/json
+X-Meta-Owner: team-accounts
+X-Meta-Ticket: https://tracker.example.com/API-42
+X-Api-Version: 2
+
+-- 200: OK
+ContentType: application/json
+X-Meta-Reviewed-By: alice
+X-Request-ID: 42
+
+{"users": []}`
+
+	tmpFile := createTempFile(t, content)
+	parser, err := NewParser(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create parser: %v", err)
+	}
+	ast, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	reqMeta := ast.Request.Metadata()
+	if len(reqMeta) != 2 {
+		t.Fatalf("expected 2 request metadata entries, got %v", reqMeta)
+	}
+	if reqMeta["X-Meta-Owner"] != "team-accounts" {
+		t.Errorf("expected X-Meta-Owner 'team-accounts', got %q", reqMeta["X-Meta-Owner"])
+	}
+	if reqMeta["X-Meta-Ticket"] != "https://tracker.example.com/API-42" {
+		t.Errorf("expected X-Meta-Ticket link, got %q", reqMeta["X-Meta-Ticket"])
+	}
+	if _, ok := ast.Request.Properties["X-Meta-Owner"]; !ok {
+		t.Error("expected metadata to stay in request properties")
+	}
+
+	respMeta := ast.Responses[0].Metadata()
+	if len(respMeta) != 1 || respMeta["X-Meta-Reviewed-By"] != "alice" {
+		t.Errorf("expected response metadata X-Meta-Reviewed-By, got %v", respMeta)
+	}
+}
+
+func TestRequestSection_ExamplePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/users", want: "/users"},
+		{path: "/users/{id}/files/*", want: "/users/1/files/example"},
+		{path: "/users/{slug:[a-z]+}", want: "/users/example"},
+		{path: "/codes/{code:[A-Z]{2,3}}", want: "/codes/ABC"},
+		{path: "/days/{day:\\d{4}-\\d{2}-\\d{2}}", want: "/days/2024-01-01"},
+		{path: "/odd/{x:x{9}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		ast, err := NewParserFromBytes("test.apimock", []byte("GET "+tt.path+"\n\n-- 200: OK\n")).Parse()
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.path, err)
+		}
+		got, err := ast.Request.ExamplePath()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ExamplePath(%s) = %q, %v, want %q (error %v)", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRequestSection_ExampleBody(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: "", want: ""},
+		{body: `{"name": "Ann"}`, want: `{"name": "Ann"}`},
+		{body: "name=Ann", want: "name=Ann"},
+		{
+			body: `{"type": "object", "properties": {"name": {"type": "string"}, "age": {"type": "integer", "minimum": 18}, "email": {"type": "string", "format": "email"}, "tags": {"type": "array", "items": {"enum": ["a", "b"]}}, "active": {"type": "boolean", "default": false}}}`,
+			want: `{"active":false,"age":18,"email":"user@example.com","name":"string","tags":["a"]}`,
+		},
+		{body: `{"type": "array", "items": {"type": "number", "example": 2.5}}`, want: `[2.5]`},
+		{
+			body: `{"type": "object", "allOf": [{"type": "object", "properties": {"id": {"type": "integer"}}}, {"properties": {"kind": {"const": "user"}}}]}`,
+			want: `{"id":1,"kind":"user"}`,
+		},
+	}
+	for _, tt := range tests {
+		r := &RequestSection{BodySchema: tt.body}
+		if got := r.ExampleBody(); got != tt.want {
+			t.Errorf("ExampleBody(%s) = %s, want %s", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package apimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EndpointBuilder builds an APIMockFile in code, for programs generating
+// mocks:
+//
+//	file, err := apimock.NewEndpoint("GET /users/{id}").
+//		Response(200).JSON(user).
+//		When("call_count > 3").Response(429).
+//		Build()
+//
+// Methods record the first error they meet and Build returns it.
+type EndpointBuilder struct {
+	file       *APIMockFile
+	conditions []string // conditions of the next response
+	err        error
+}
+
+// NewEndpoint starts an endpoint answering requestLine, a method and path as
+// written on the first line of an .apimock file, such as "GET /users/{id}".
+func NewEndpoint(requestLine string) *EndpointBuilder {
+	b := &EndpointBuilder{file: NewAPIMockFile()}
+
+	tokens, err := NewLexer([]string{requestLine}).Lex()
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if len(tokens) != 1 || tokens[0].Type != TokenRequestLine || strings.TrimSpace(requestLine) != strings.TrimSpace(tokens[0].Method+" "+tokens[0].Path) {
+		b.err = fmt.Errorf("invalid request line %q (expected a method and path, such as 'GET /api/users')", requestLine)
+		return b
+	}
+
+	b.file.Request = NewRequestSection()
+	b.file.Request.Method = tokens[0].Method
+	b.file.Request.Path = tokens[0].Path
+	b.file.Request.PathSegments = tokens[0].PathSegments
+	return b
+}
+
+// Query adds a query parameter with an example value to the request.
+func (b *EndpointBuilder) Query(name, value string) *EndpointBuilder {
+	if b.err == nil && b.file.Request != nil {
+		b.file.Request.QueryParams[name] = value
+	}
+	return b
+}
+
+// Property sets a property of the last response added, or of the request
+// before the first response, as the property lines of an .apimock file do.
+func (b *EndpointBuilder) Property(key, value string) *EndpointBuilder {
+	if b.err != nil {
+		return b
+	}
+	if key == "" || strings.ContainsAny(key, ": \t\n") {
+		b.err = fmt.Errorf("invalid property name %q", key)
+		return b
+	}
+	if resp := b.last(); resp != nil {
+		resp.Properties[key] = value
+	} else {
+		b.file.Request.Properties[key] = value
+	}
+	return b
+}
+
+// RequestBody sets the schema request bodies are validated against.
+func (b *EndpointBuilder) RequestBody(schema string) *EndpointBuilder {
+	if b.err == nil {
+		b.file.Request.BodySchema = schema
+	}
+	return b
+}
+
+// When adds a condition line (see CONDITIONS.md) to the next response.
+func (b *EndpointBuilder) When(condition string) *EndpointBuilder {
+	if b.err == nil {
+		b.conditions = append(b.conditions, condition)
+	}
+	return b
+}
+
+// Response adds a response with statusCode, preceded by the conditions given
+// to When since the previous response.
+func (b *EndpointBuilder) Response(statusCode int) *EndpointBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	resp := NewResponseSection()
+	resp.StatusCode = statusCode
+	if len(b.conditions) > 0 {
+		lines := make([]string, len(b.conditions))
+		for i, condition := range b.conditions {
+			lines[i] = ConditionPrefix + " " + condition
+		}
+		resp.Body = strings.Join(lines, "\n")
+		b.conditions = nil
+	}
+	b.file.Responses = append(b.file.Responses, resp)
+	return b
+}
+
+// Description sets the description of the last response added.
+func (b *EndpointBuilder) Description(description string) *EndpointBuilder {
+	if resp := b.response("Description"); resp != nil {
+		resp.Description = description
+	}
+	return b
+}
+
+// Body sets the body of the last response added.
+func (b *EndpointBuilder) Body(body string) *EndpointBuilder {
+	if resp := b.response("Body"); resp != nil {
+		if condition, _, _ := strings.Cut(resp.Body, "\n\n"); strings.HasPrefix(condition, ConditionPrefix) {
+			resp.Body = condition + "\n\n" + body
+		} else {
+			resp.Body = body
+		}
+	}
+	return b
+}
+
+// JSON sets the body of the last response added to v encoded as indented
+// JSON, and its ContentType to application/json unless it is set already.
+func (b *EndpointBuilder) JSON(v any) *EndpointBuilder {
+	resp := b.response("JSON")
+	if resp == nil {
+		return b
+	}
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.err = fmt.Errorf("failed to encode the body of response %d: %w", resp.StatusCode, err)
+		return b
+	}
+	if _, ok := resp.Properties["ContentType"]; !ok {
+		resp.Properties["ContentType"] = "application/json"
+	}
+	return b.Body(string(body))
+}
+
+// Build returns the endpoint built, or the first error met building it.
+func (b *EndpointBuilder) Build() (*APIMockFile, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.conditions) > 0 {
+		return nil, fmt.Errorf("condition %q is not followed by a response", b.conditions[0])
+	}
+	if err := b.file.Validate(); err != nil {
+		return nil, err
+	}
+	return b.file, nil
+}
+
+// last returns the last response added, or nil before the first.
+func (b *EndpointBuilder) last() *ResponseSection {
+	if len(b.file.Responses) == 0 {
+		return nil
+	}
+	return &b.file.Responses[len(b.file.Responses)-1]
+}
+
+// response returns the last response added for method, recording an error
+// if there is none.
+func (b *EndpointBuilder) response(method string) *ResponseSection {
+	if b.err != nil {
+		return nil
+	}
+	resp := b.last()
+	if resp == nil {
+		b.err = fmt.Errorf("%s called before Response", method)
+	}
+	return resp
+}
@@ -0,0 +1,80 @@
+package apimock
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEndpointBuilder(t *testing.T) {
+	file, err := NewEndpoint("GET /users/{id}").
+		Query("fields", "name").
+		Property("Accept", "application/json").
+		Response(200).Description("User found").JSON(map[string]any{"id": 1}).
+		When("call_count > 3").When("call_count < 10").Response(429).Property("Retry-After", "60").Body(`{"error": "slow down"}`).
+		Response(404).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	got, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `GET /users/{id}
+  ?fields=name
+Accept: application/json
+
+-- 200: User found
+ContentType: application/json
+
+{
+  "id": 1
+}
+
+-- 429:
+Retry-After: 60
+> call_count > 3
+> call_count < 10
+
+{"error": "slow down"}
+
+-- 404:
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant:\n%s", got, want)
+	}
+
+	parsed, err := NewParserFromBytes("users.apimock", got).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.Responses[1].Body != file.Responses[1].Body || len(parsed.Request.PathSegments) != 2 {
+		t.Errorf("parsed file differs from the built one: %+v", parsed)
+	}
+}
+
+func TestEndpointBuilder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *EndpointBuilder
+		want    string
+	}{
+		{name: "invalid request line", builder: NewEndpoint("users").Response(200), want: "invalid request line"},
+		{name: "body before response", builder: NewEndpoint("GET /users").Body("[]").Response(200), want: "Body called before Response"},
+		{name: "no responses", builder: NewEndpoint("GET /users"), want: "at least one response"},
+		{name: "invalid status", builder: NewEndpoint("GET /users").Response(42), want: "42"},
+		{name: "dangling condition", builder: NewEndpoint("GET /users").Response(200).When("call_count > 1"), want: "not followed by a response"},
+		{name: "invalid property", builder: NewEndpoint("GET /users").Property("Bad Name", "x").Response(200), want: "invalid property name"},
+		{name: "unencodable JSON", builder: NewEndpoint("GET /users").Response(200).JSON(func() {}), want: "failed to encode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
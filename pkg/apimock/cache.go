@@ -14,6 +14,11 @@ type CacheEntry struct {
 	ModTime  time.Time
 	FileHash uint64
 	CachedAt time.Time
+	// Dependencies holds the modification time of each file the entry
+	// includes, so editing a shared fragment invalidates its includers
+	Dependencies map[string]time.Time
+	// Env holds the values of the environment variables the file references
+	Env map[string]string
 }
 
 // ParserCache provides thread-safe caching of parsed APIMock files.
@@ -100,13 +105,45 @@ func (c *ParserCache) Set(filename string, file *APIMockFile, modTime time.Time,
 	}
 
 	c.entries[filename] = &CacheEntry{
-		File:     file,
-		ModTime:  modTime,
-		FileHash: hash,
-		CachedAt: time.Now(),
+		File:         file,
+		ModTime:      modTime,
+		FileHash:     hash,
+		CachedAt:     time.Now(),
+		Dependencies: includeModTimes(file),
+		Env:          envValues(file),
 	}
 }
 
+// envValues returns the current values of the environment variables
+// referenced by file.
+func envValues(file *APIMockFile) map[string]string {
+	if file == nil || len(file.Env) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(file.Env))
+	for _, name := range file.Env {
+		values[name] = os.Getenv(name)
+	}
+	return values
+}
+
+// includeModTimes returns the modification time of the files included by
+// file. Files that cannot be read get the zero time, which never matches.
+func includeModTimes(file *APIMockFile) map[string]time.Time {
+	if file == nil || len(file.Includes) == 0 {
+		return nil
+	}
+	modTimes := make(map[string]time.Time, len(file.Includes))
+	for _, path := range file.Includes {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		} else {
+			modTimes[path] = time.Time{}
+		}
+	}
+	return modTimes
+}
+
 // Invalidate removes a specific file from the cache.
 func (c *ParserCache) Invalidate(filename string) {
 	c.mu.Lock()
@@ -184,6 +221,12 @@ func (c *ParserCache) IsValid(filename string) bool {
 		return false
 	}
 
+	for name, value := range entry.Env {
+		if os.Getenv(name) != value {
+			return false
+		}
+	}
+
 	// Check file modification time
 	if c.config.CheckFileModTime {
 		fileInfo, err := os.Stat(filename)
@@ -194,6 +237,13 @@ func (c *ParserCache) IsValid(filename string) bool {
 		if !fileInfo.ModTime().Equal(entry.ModTime) {
 			return false
 		}
+
+		for path, modTime := range entry.Dependencies {
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().Equal(modTime) {
+				return false
+			}
+		}
 	}
 
 	// Optionally check file hash for content changes
@@ -485,3 +485,63 @@ Content-Type: application/json
 		}
 	}
 }
+
+func TestCachedParser_IncludeModification(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.apimock")
+	shared := filepath.Join(tmpDir, "errors.apimock")
+
+	if err := os.WriteFile(testFile, []byte("GET /api/users\n\n-- 200: OK\n\n@include errors.apimock\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(shared, []byte("-- 404: Not Found\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create included file: %v", err)
+	}
+
+	config := DefaultCacheConfig()
+	config.CheckFileModTime = true
+	parser := NewCachedParser(config)
+
+	if _, err := parser.ParseFile(testFile); err != nil {
+		t.Fatalf("Failed to parse file: %v", err)
+	}
+
+	// Change only the included file; its new mod time must invalidate the entry
+	if err := os.WriteFile(shared, []byte("-- 500: Internal Server Error\n"), 0o644); err != nil {
+		t.Fatalf("Failed to modify included file: %v", err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(shared, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to parse file: %v", err)
+	}
+	if got := file.Responses[len(file.Responses)-1].StatusCode; got != 500 {
+		t.Errorf("Expected the included response to be re-read as 500, got %d", got)
+	}
+}
+
+func TestCachedParser_EnvChange(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.apimock")
+	if err := os.WriteFile(testFile, []byte("GET /api/${ANANSI_TEST_VERSION}/users\n\n-- 200: OK\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	parser := NewCachedParser(DefaultCacheConfig())
+	t.Setenv("ANANSI_TEST_VERSION", "v1")
+	if _, err := parser.ParseFile(testFile); err != nil {
+		t.Fatalf("Failed to parse file: %v", err)
+	}
+
+	t.Setenv("ANANSI_TEST_VERSION", "v2")
+	file, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to parse file: %v", err)
+	}
+	if file.Request.Path != "/api/v2/users" {
+		t.Errorf("Expected the new value of the variable, got %s", file.Request.Path)
+	}
+}
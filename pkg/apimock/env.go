@@ -0,0 +1,79 @@
+package apimock
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envRegex matches the ${NAME} and ${NAME:-default} environment variable
+// references of a line, and the $${ escape that writes a literal ${.
+var envRegex = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expandEnv replaces the environment variable references of line with their
+// values. ${NAME:-default} falls back to default when NAME is unset or empty;
+// ${NAME} must be set. It returns the names referenced and, for the first
+// unset variable without a default, its byte offset in the line.
+func expandEnv(line string) (string, []string, string, int) {
+	if !strings.Contains(line, "${") {
+		return line, nil, "", -1
+	}
+
+	var names []string
+	missing, offset := "", -1
+	var b strings.Builder
+	last := 0
+	for _, m := range envRegex.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(line[last:m[0]])
+		last = m[1]
+		if m[2] < 0 {
+			b.WriteString("${")
+			continue
+		}
+
+		name := line[m[2]:m[3]]
+		names = append(names, name)
+		value, ok := os.LookupEnv(name)
+		switch {
+		case m[4] >= 0 && value == "":
+			value = line[m[4]+len(":-") : m[5]]
+		case !ok && offset < 0:
+			missing, offset = name, m[0]
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(line[last:])
+	return b.String(), names, missing, offset
+}
+
+// interpolate expands the environment variable references of lines, in place,
+// returning the names referenced and an error for each unset variable.
+func (p *Parser) interpolate(lines []string) ([]string, ParseErrors) {
+	var names []string
+	var errs ParseErrors
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		expanded, refs, missing, offset := expandEnv(line)
+		lines[i] = expanded
+		for _, name := range refs {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		if offset >= 0 {
+			origin := p.origins[i]
+			errs = append(errs, &ParseError{
+				Code:       CodeUndefinedEnv,
+				Filename:   origin.file,
+				Line:       origin.line,
+				Column:     offset + 1,
+				Message:    fmt.Sprintf("environment variable %s is not set", missing),
+				Snippet:    line,
+				Suggestion: fmt.Sprintf("set it or give a default, as in ${%s:-value}", missing),
+			})
+		}
+	}
+	return names, errs
+}
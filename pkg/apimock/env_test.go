@@ -0,0 +1,48 @@
+package apimock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_EnvInterpolation(t *testing.T) {
+	t.Setenv("ANANSI_TEST_VERSION", "v2")
+	t.Setenv("ANANSI_TEST_EMPTY", "")
+
+	content := `GET /api/${ANANSI_TEST_VERSION}/users
+X-Owner: ${ANANSI_TEST_OWNER:-platform}
+
+-- 200: OK
+ContentType: application/json
+
+{"region": "${ANANSI_TEST_EMPTY:-local}", "template": "$${literal}"}
+`
+	ast, err := NewParserFromBytes("env.apimock", []byte(content)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if ast.Request.Path != "/api/v2/users" {
+		t.Errorf("expected the path to use the variable, got %q", ast.Request.Path)
+	}
+	if got := ast.Request.Properties["X-Owner"]; got != "platform" {
+		t.Errorf("expected the default for an unset variable, got %q", got)
+	}
+	if want := `{"region": "local", "template": "${literal}"}`; ast.Responses[0].Body != want {
+		t.Errorf("got body %q, want %q", ast.Responses[0].Body, want)
+	}
+	if want := []string{"ANANSI_TEST_VERSION", "ANANSI_TEST_OWNER", "ANANSI_TEST_EMPTY"}; !reflect.DeepEqual(ast.Env, want) {
+		t.Errorf("got env %v, want %v", ast.Env, want)
+	}
+}
+
+func TestParser_EnvUndefined(t *testing.T) {
+	_, err := NewParserFromBytes("env.apimock", []byte("GET /users\nX-Key: ${ANANSI_TEST_UNSET}\n\n-- 200: OK\n")).Parse()
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if parseErr.Code != CodeUndefinedEnv || parseErr.Line != 2 || parseErr.Column != 8 {
+		t.Errorf("got %s at %d:%d, want %s at 2:8", parseErr.Code, parseErr.Line, parseErr.Column, CodeUndefinedEnv)
+	}
+}
@@ -1,29 +1,169 @@
 package apimock
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode identifies the kind of problem a ParseError, ValidationError or
+// EvalError reports, so tools can act on it without matching messages. Codes
+// are errors themselves, so errors.Is(err, CodeIncludeCycle) reports whether
+// err wraps an error of that kind.
+type ErrorCode string
+
+// Error implements the error interface, returning the code itself.
+func (c ErrorCode) Error() string {
+	return string(c)
+}
+
+const (
+	// CodeMissingResponse: the file declares no response section
+	CodeMissingResponse ErrorCode = "missing-response"
+	// CodeMissingRequestLine: the request section does not start with a method and/or path
+	CodeMissingRequestLine ErrorCode = "missing-request-line"
+	// CodeInvalidResponseLine: a response section does not start with -- CODE: Description
+	CodeInvalidResponseLine ErrorCode = "invalid-response-line"
+	// CodeInvalidStatusCode: a response declares a status code outside 100-599
+	CodeInvalidStatusCode ErrorCode = "invalid-status-code"
+	// CodeInvalidProxyURL: a proxy section points to something other than an http or https URL
+	CodeInvalidProxyURL ErrorCode = "invalid-proxy-url"
+	// CodeMissingPath: the request section declares no path
+	CodeMissingPath ErrorCode = "missing-path"
+	// CodeInvalidMethod: the request section declares an unknown HTTP method
+	CodeInvalidMethod ErrorCode = "invalid-method"
+	// CodeIncludeNotFound: an @include directive names a file that cannot be read
+	CodeIncludeNotFound ErrorCode = "include-not-found"
+	// CodeIncludeCycle: a file includes itself, directly or through other files
+	CodeIncludeCycle ErrorCode = "include-cycle"
+	// CodeUndefinedEnv: a ${NAME} reference names an unset environment variable and gives no default
+	CodeUndefinedEnv ErrorCode = "undefined-env"
+	// CodeInvalidExpression: a condition line or a {{...}} placeholder is not a valid expression
+	CodeInvalidExpression ErrorCode = "invalid-expression"
+	// CodeUnknownFunction: a condition line calls a function that is neither built in nor registered
+	CodeUnknownFunction ErrorCode = "unknown-function"
+	// CodeInvalidArgument: a function is called with the wrong number or types of arguments
+	CodeInvalidArgument ErrorCode = "invalid-argument"
+	// CodeFunctionFailed: the implementation of a function returned an error
+	CodeFunctionFailed ErrorCode = "function-failed"
+	// CodeUndefinedVariable: an expression refers to a variable the request does not set
+	CodeUndefinedVariable ErrorCode = "undefined-variable"
+	// CodeInvalidOperand: an operator is applied to values it does not take, as in dividing by zero
+	CodeInvalidOperand ErrorCode = "invalid-operand"
+)
 
 // ParseError represents an error that occurred during parsing.
 // It includes the filename, line number, and error message for better debugging.
+// Line and Column start at 1 and are 0 when unknown. Snippet holds the source
+// line the error points to and Suggestion a hint on how to fix it.
 type ParseError struct {
-	Filename string
-	Line     int
-	Message  string
+	Code       ErrorCode
+	Filename   string
+	Line       int
+	Column     int
+	Message    string
+	Snippet    string
+	Suggestion string
 }
 
 // Error implements the error interface for ParseError.
 func (e *ParseError) Error() string {
-	if e.Filename != "" && e.Line > 0 {
-		return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Message)
+	position := ""
+	if e.Line > 0 {
+		position = fmt.Sprint(e.Line)
+		if e.Column > 0 {
+			position += fmt.Sprintf(":%d", e.Column)
+		}
+	}
+
+	if e.Filename != "" && position != "" {
+		return fmt.Sprintf("%s:%s: %s", e.Filename, position, e.Message)
 	}
 	if e.Filename != "" {
 		return fmt.Sprintf("%s: %s", e.Filename, e.Message)
 	}
-	if e.Line > 0 {
-		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	if position != "" {
+		return fmt.Sprintf("line %s: %s", position, e.Message)
+	}
+	if e.Column > 0 {
+		return fmt.Sprintf("column %d: %s", e.Column, e.Message)
 	}
 	return e.Message
 }
 
+// Is reports whether target is the code of the error.
+func (e *ParseError) Is(target error) bool {
+	return e.Code != "" && target == e.Code
+}
+
+// Excerpt renders the source line of the error with a caret under its column,
+// followed by the suggestion, as compilers do. It is empty when the error has
+// neither a snippet nor a suggestion.
+func (e *ParseError) Excerpt() string {
+	var b strings.Builder
+	if e.Snippet != "" && e.Line > 0 {
+		gutter := fmt.Sprint(e.Line)
+		fmt.Fprintf(&b, "  %s | %s\n", gutter, e.Snippet)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "  %s | %s^\n", strings.Repeat(" ", len(gutter)), strings.Repeat(" ", e.Column-1))
+		}
+	}
+	if e.Suggestion != "" {
+		fmt.Fprintf(&b, "  hint: %s\n", e.Suggestion)
+	}
+	return b.String()
+}
+
+// ParseErrors lists the problems of a file when the parser found more than
+// one. errors.As finds each of them.
+type ParseErrors []*ParseError
+
+// Error implements the error interface, with one error per line.
+func (e ParseErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the errors of the list, for errors.Is and errors.As.
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ParseErrorsOf returns the parse errors wrapped by err: every error of a
+// ParseErrors, or the single ParseError, or none.
+func ParseErrorsOf(err error) []*ParseError {
+	var list ParseErrors
+	if errors.As(err, &list) {
+		return list
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return []*ParseError{parseErr}
+	}
+	return nil
+}
+
+// Explain describes err for people: each parse error it wraps followed by its
+// excerpt, or err itself when it wraps none.
+func Explain(err error) string {
+	parseErrs := ParseErrorsOf(err)
+	if len(parseErrs) == 0 {
+		return err.Error()
+	}
+	var b strings.Builder
+	for _, parseErr := range parseErrs {
+		b.WriteString(parseErr.Error() + "\n" + parseErr.Excerpt())
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // NewParseError creates a new ParseError with the given details.
 func NewParseError(filename string, line int, message string) *ParseError {
 	return &ParseError{
@@ -34,8 +174,12 @@ func NewParseError(filename string, line int, message string) *ParseError {
 }
 
 // ValidationError represents an error found during AST validation.
+// Line is the first line of the section at fault, or 0 for sections built in
+// code rather than parsed.
 type ValidationError struct {
+	Code    ErrorCode
 	Field   string
+	Line    int
 	Message string
 }
 
@@ -47,6 +191,11 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error: %s", e.Message)
 }
 
+// Is reports whether target is the code of the error.
+func (e *ValidationError) Is(target error) bool {
+	return e.Code != "" && target == e.Code
+}
+
 // NewValidationError creates a new ValidationError.
 func NewValidationError(field, message string) *ValidationError {
 	return &ValidationError{
@@ -54,3 +203,61 @@ func NewValidationError(field, message string) *ValidationError {
 		Message: message,
 	}
 }
+
+// EvalError represents an error evaluating a condition or a placeholder:
+// calling an unknown function, calling one with bad arguments, a function
+// failing, a missing variable or an operator applied to values it does not
+// take. Err is the error the function returned, if any. Expression is the
+// source evaluated, when known, and Column, from 1, the position in it of
+// the part that failed.
+type EvalError struct {
+	Code       ErrorCode
+	Function   string // Name of the function called, without the leading dot
+	Message    string
+	Err        error
+	Expression string
+	Column     int
+}
+
+// Error implements the error interface for EvalError.
+func (e *EvalError) Error() string {
+	message := e.Message
+	if e.Err != nil {
+		message = fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	if e.Expression != "" && e.Column > 0 {
+		return fmt.Sprintf("%s (column %d): %s", e.Expression, e.Column, message)
+	}
+	if e.Expression != "" {
+		return fmt.Sprintf("%s: %s", e.Expression, message)
+	}
+	return message
+}
+
+// Unwrap returns the error the function returned, if any.
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the code of the error.
+func (e *EvalError) Is(target error) bool {
+	return e.Code != "" && target == e.Code
+}
+
+// ErrorCodeOf returns the code of the ParseError, ValidationError or
+// EvalError wrapped by err, or "" if there is none.
+func ErrorCodeOf(err error) ErrorCode {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Code
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Code
+	}
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Code
+	}
+	return ""
+}
@@ -1,6 +1,8 @@
 package apimock
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -250,3 +252,69 @@ func TestResponseSection_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationError_CodeAndLine(t *testing.T) {
+	file := &APIMockFile{
+		Responses: []ResponseSection{
+			{StatusCode: 200, Lines: LineRange{Start: 3, End: 4}},
+			{StatusCode: 999, Lines: LineRange{Start: 6, End: 6}},
+		},
+	}
+	err := file.Validate()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if validationErr.Line != 6 {
+		t.Errorf("expected line 6, got %d", validationErr.Line)
+	}
+	if code := ErrorCodeOf(err); code != CodeInvalidStatusCode {
+		t.Errorf("expected %s, got %q", CodeInvalidStatusCode, code)
+	}
+}
+
+func TestErrorCode_Is(t *testing.T) {
+	_, parseErr := NewParserFromBytes("users.apimock", []byte("GET /users\n\n-- 200: OK\n@include users.apimock\n")).Parse()
+	validationErr := (&APIMockFile{Responses: []ResponseSection{{StatusCode: 999}}}).Validate()
+	boom := errors.New("boom")
+	RegisterFunction("fail_for_test", "() -> any", func(args ...any) (any, error) { return nil, boom })
+	t.Cleanup(func() {
+		functionsMu.Lock()
+		delete(functions, "fail_for_test")
+		functionsMu.Unlock()
+	})
+	_, evalErr := CallFunction("fail_for_test")
+
+	tests := []struct {
+		name string
+		err  error
+		code ErrorCode
+	}{
+		{name: "parse", err: parseErr, code: CodeIncludeCycle},
+		{name: "validation", err: validationErr, code: CodeInvalidStatusCode},
+		{name: "eval", err: evalErr, code: CodeFunctionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("loading mocks: %w", tt.err)
+			if !errors.Is(wrapped, tt.code) {
+				t.Errorf("errors.Is(%v, %s) = false", wrapped, tt.code)
+			}
+			if errors.Is(wrapped, CodeMissingPath) {
+				t.Errorf("errors.Is(%v, %s) = true", wrapped, CodeMissingPath)
+			}
+			if code := ErrorCodeOf(wrapped); code != tt.code {
+				t.Errorf("ErrorCodeOf() = %q, want %q", code, tt.code)
+			}
+		})
+	}
+
+	var e *EvalError
+	if !errors.As(evalErr, &e) || e.Function != "fail_for_test" || !errors.Is(evalErr, boom) {
+		t.Errorf("CallFunction() error = %#v, want an EvalError wrapping the function's error", evalErr)
+	}
+	if _, err := CallFunction("trim", 1.0); !errors.Is(err, CodeInvalidArgument) {
+		t.Errorf("CallFunction() error = %v, want %s", err, CodeInvalidArgument)
+	}
+}
@@ -0,0 +1,188 @@
+package apimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// examplePathValues are tried, in order, as the value of path parameters
+// until one matches the pattern of the parameter. Wildcards take the second.
+var examplePathValues = []string{"1", "example", "abc", "ABC", "v1", "a-1", "2024-01-01", "00000000-0000-0000-0000-000000000001"}
+
+// ExamplePath returns a concrete path the request path matches, with sample
+// values in place of its parameters and wildcards, such as /users/1 for
+// /users/{id:[0-9]+}. It fails when no sample value matches the pattern of a
+// parameter.
+func (r *RequestSection) ExamplePath() (string, error) {
+	if len(r.PathSegments) == 0 {
+		return r.Path, nil
+	}
+
+	var b strings.Builder
+	for _, seg := range r.PathSegments {
+		b.WriteString("/")
+		switch {
+		case seg.IsWildcard:
+			b.WriteString(examplePathValues[1])
+		case seg.IsParameter:
+			value, ok := exampleValue(seg.Pattern)
+			if !ok {
+				return "", fmt.Errorf("no sample value matches %s", seg.Value)
+			}
+			b.WriteString(value)
+		default:
+			b.WriteString(seg.Value)
+		}
+	}
+	return b.String(), nil
+}
+
+// exampleValue returns a sample value the parameter pattern accepts; any
+// value matches an empty pattern.
+func exampleValue(pattern string) (string, bool) {
+	if pattern == "" {
+		return examplePathValues[0], true
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return "", false
+	}
+	for _, value := range examplePathValues {
+		if re.MatchString(value) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// ExampleBody returns a request body the request accepts: an example
+// document built from the body schema when it is a JSON Schema, or else the
+// body as written.
+func (r *RequestSection) ExampleBody() string {
+	if !IsJSONSchema(r.BodySchema) {
+		return r.BodySchema
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(r.BodySchema), &schema); err != nil {
+		return r.BodySchema
+	}
+	data, err := json.Marshal(exampleOf(schema, 0))
+	if err != nil {
+		return r.BodySchema
+	}
+	return string(data)
+}
+
+// exampleFormats are the sample strings of the string formats.
+var exampleFormats = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "00:00:00Z",
+	"email":     "user@example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000001",
+	"uri":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+}
+
+// maxExampleDepth stops recursive schemas from building endless examples.
+const maxExampleDepth = 8
+
+// exampleOf returns a value valid against schema, preferring the example,
+// default, const or first enum value it declares. $refs are not resolved
+// and give null.
+func exampleOf(schema map[string]any, depth int) any {
+	if depth > maxExampleDepth {
+		return nil
+	}
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf", "allOf"} {
+		if options, ok := schema[key].([]any); ok && len(options) > 0 {
+			if key == "allOf" {
+				return exampleOfAll(options, depth)
+			}
+			if option, ok := options[0].(map[string]any); ok {
+				return exampleOf(option, depth+1)
+			}
+		}
+	}
+
+	typ, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]any); ok && len(types) > 0 {
+		typ, _ = types[0].(string)
+	}
+	if typ == "" {
+		if _, ok := schema["properties"]; ok {
+			typ = "object"
+		} else if _, ok := schema["items"]; ok {
+			typ = "array"
+		}
+	}
+
+	switch typ {
+	case "object":
+		object := map[string]any{}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range properties {
+			if property, ok := property.(map[string]any); ok {
+				object[name] = exampleOf(property, depth+1)
+			}
+		}
+		return object
+	case "array":
+		if items, ok := schema["items"].(map[string]any); ok {
+			return []any{exampleOf(items, depth+1)}
+		}
+		return []any{}
+	case "string":
+		if format, ok := schema["format"].(string); ok && exampleFormats[format] != "" {
+			return exampleFormats[format]
+		}
+		return "string"
+	case "integer", "number":
+		if minimum, ok := schema["minimum"].(float64); ok {
+			return minimum
+		}
+		return 1
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// exampleOfAll merges the examples of the allOf schemas, when they are
+// objects, or else returns the example of the first.
+func exampleOfAll(schemas []any, depth int) any {
+	merged := map[string]any{}
+	for i, schema := range schemas {
+		schema, ok := schema.(map[string]any)
+		if !ok {
+			continue
+		}
+		example := exampleOf(schema, depth+1)
+		object, ok := example.(map[string]any)
+		if !ok {
+			if i == 0 {
+				return example
+			}
+			continue
+		}
+		for name, value := range object {
+			merged[name] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,851 @@
+package apimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of the syntax tree of an expression of the conditions
+// language (see CONDITIONS.md), as ParseExpression returns it. Pos is the
+// offset of the node in the text of the expression, from 0: the start of
+// values and the operator of operations.
+type Expr interface {
+	Pos() int
+}
+
+type (
+	// Literal is a number, a string, a boolean or nil written in an
+	// expression. Numbers are float64s.
+	Literal struct {
+		Value  any
+		Offset int
+	}
+
+	// Variable is a context variable reference, such as `query.page`,
+	// `headers["X-Plan"]` or `body.user?.name`, or a variable assigned by a
+	// condition, with its .key and ["key"] steps.
+	Variable struct {
+		Name   string
+		Offset int
+	}
+
+	// Table is a table written in an expression: an array, as in
+	// `{"active", "pending"}`, or a dictionary, as in `{name = "Ana"}`, when
+	// Keys holds the key of each element.
+	Table struct {
+		Elements []Expr
+		Keys     []string
+		Offset   int
+	}
+
+	// Index is `x[key]`, or `x.key` after a parenthesized expression.
+	Index struct {
+		X, Key Expr
+		Offset int
+	}
+
+	// Unary is `-x` or `not x`.
+	Unary struct {
+		Op     string
+		X      Expr
+		Offset int
+	}
+
+	// Binary is an operation on two values, with Op as written: arithmetic
+	// (+ - * / // %), comparison (== != < <= > >=), membership (in, not in),
+	// logic (and, or) or concatenation and ranges (..).
+	Binary struct {
+		Op     string
+		X, Y   Expr
+		Offset int
+	}
+
+	// Conditional is `if cond then x else y`, elif included, or
+	// `cond ? x : y`.
+	Conditional struct {
+		Cond, Then, Else Expr
+		Offset           int
+	}
+
+	// Exists is `exists(x)`.
+	Exists struct {
+		X      Expr
+		Offset int
+	}
+
+	// Call is a call to a function, such as `.random_int 1 10`. The value
+	// piped into it with >> is its first argument.
+	Call struct {
+		Name   string
+		Args   []Expr
+		Offset int
+	}
+
+	// Assign is an attribution, `x >> name`, or the destructuring of a
+	// table into several variables, `x >> first, last`.
+	Assign struct {
+		X      Expr
+		Names  []string
+		Offset int
+	}
+)
+
+func (x *Literal) Pos() int     { return x.Offset }
+func (x *Variable) Pos() int    { return x.Offset }
+func (x *Table) Pos() int       { return x.Offset }
+func (x *Index) Pos() int       { return x.Offset }
+func (x *Unary) Pos() int       { return x.Offset }
+func (x *Binary) Pos() int      { return x.Offset }
+func (x *Conditional) Pos() int { return x.Offset }
+func (x *Exists) Pos() int      { return x.Offset }
+func (x *Call) Pos() int        { return x.Offset }
+func (x *Assign) Pos() int      { return x.Offset }
+
+// ParseExpression parses an expression of the conditions language:
+//
+//	expression  = "if" expression "then" expression { "elif" expression "then" expression } "else" expression
+//	            | pipeline [ "?" expression ":" expression ]
+//	pipeline    = disjunction { ">>" call } [ ">>" name { "," name } ]
+//	disjunction = conjunction { "or" conjunction }
+//	conjunction = inversion { "and" inversion }
+//	inversion   = "not" inversion | comparison
+//	comparison  = concat [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" | "not" "in" ) concat ]
+//	concat      = sum { ".." sum }
+//	sum         = product { ( "+" | "-" ) product }
+//	product     = unary { ( "*" | "/" | "//" | "%" ) unary }
+//	unary       = "-" unary | "(" expression ")" { step } | table | call | "exists" "(" expression ")"
+//	            | number | string | "true" | "false" | "nil" | variable
+//	table       = "{" [ element { "," element } ] "}"
+//	element     = [ name "=" ] expression
+//	call        = "." name { unary }
+//	step        = "[" expression "]" | "." name
+//
+// Strings are written in double quotes, with Go escapes. Calls take the
+// arguments following them up to an operator, so negative arguments and
+// other expressions are parenthesized: `.random_int (-5) 5`. Variables
+// with a ?. step, as `body.user?.name`, are nil instead of failing when
+// what follows the ?. is missing. Errors are *ParseErrors whose Column
+// counts from 1 in input.
+func ParseExpression(input string) (Expr, error) {
+	return (&exprParser{input: input}).parse()
+}
+
+// ParseCondition parses the text of a condition line after its > prefix: an
+// expression, led by `or` when the line is an alternative to the lines
+// before it, and followed by an optional # comment. x is nil for an empty
+// condition, which never holds. Errors are those of ParseExpression, with
+// Column counting from 1 in text.
+func ParseCondition(text string) (x Expr, or bool, err error) {
+	p := &exprParser{input: withoutComment(text)}
+	or = p.accept("or")
+	if p.skipSpaces(); p.pos == len(p.input) {
+		if or {
+			return nil, false, p.errorf("expected a condition after or")
+		}
+		return nil, false, nil
+	}
+	x, err = p.parse()
+	return x, or, err
+}
+
+// withoutComment cuts the # comment off a condition line, if any.
+func withoutComment(line string) string {
+	inString := false
+	for pos := 0; pos < len(line); pos++ {
+		switch c := line[pos]; {
+		case inString && c == '\\':
+			pos++
+		case c == '"':
+			inString = !inString
+		case c == '#' && !inString:
+			return line[:pos]
+		}
+	}
+	return line
+}
+
+// parse parses the expression from the current position to the end of the
+// input.
+func (p *exprParser) parse() (Expr, error) {
+	x, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos != len(p.input) {
+		return nil, p.unexpected()
+	}
+	return x, nil
+}
+
+// exprParser is a recursive descent parser over an expression string.
+type exprParser struct {
+	input string
+	pos   int
+}
+
+// keywords cannot be used as variables.
+var keywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true,
+	"and": true, "or": true, "not": true, "in": true, "nil": true,
+}
+
+// errorf returns the error of the expression at the current position.
+func (p *exprParser) errorf(format string, args ...any) *ParseError {
+	return &ParseError{
+		Code:    CodeInvalidExpression,
+		Column:  p.pos + 1,
+		Message: fmt.Sprintf(format, args...),
+		Snippet: p.input,
+	}
+}
+
+// unexpected returns the error of the token at the current position, which
+// does not fit there.
+func (p *exprParser) unexpected() *ParseError {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return p.errorf("unexpected end of expression")
+	}
+	end := p.pos + 1
+	if isWordChar(p.input[p.pos]) {
+		for end < len(p.input) && isWordChar(p.input[end]) {
+			end++
+		}
+	}
+	return p.errorf("unexpected %q", p.input[p.pos:end])
+}
+
+// expect consumes token, failing when something else comes next.
+func (p *exprParser) expect(token string) error {
+	if !p.accept(token) {
+		if p.skipSpaces(); p.pos >= len(p.input) {
+			return p.errorf("expected %s, found end of expression", token)
+		}
+		return p.errorf("expected %s", token)
+	}
+	return nil
+}
+
+func (p *exprParser) expression() (Expr, error) {
+	start := p.offset()
+	if p.accept("if") {
+		return p.branches(start)
+	}
+	x, err := p.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	offset := p.offset()
+	if !p.accept("?") {
+		return x, nil
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &Conditional{Cond: x, Then: then, Else: otherwise, Offset: offset}, nil
+}
+
+// branches parses the rest of an if expression, after its if or elif.
+func (p *exprParser) branches(offset int) (Expr, error) {
+	cond, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	var otherwise Expr
+	switch elif := p.offset(); {
+	case p.accept("elif"):
+		otherwise, err = p.branches(elif)
+	case p.accept("else"):
+		otherwise, err = p.expression()
+	default:
+		err = p.errorf("expected elif or else")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Conditional{Cond: cond, Then: then, Else: otherwise, Offset: offset}, nil
+}
+
+func (p *exprParser) pipeline() (Expr, error) {
+	x, err := p.disjunction()
+	for err == nil {
+		offset := p.offset()
+		if !p.accept(">>") {
+			return x, nil
+		}
+		if p.peek() != '.' {
+			return p.assignment(x, offset)
+		}
+		var f *Call
+		if f, err = p.call(); err == nil {
+			f.Args = append([]Expr{x}, f.Args...)
+			x = f
+		}
+	}
+	return nil, err
+}
+
+// assignment parses the names x is assigned to, after the >>.
+func (p *exprParser) assignment(x Expr, offset int) (Expr, error) {
+	a := &Assign{X: x, Offset: offset}
+	for {
+		p.skipSpaces()
+		name := p.word()
+		if name == "" || keywords[name] || !isIdentStart(name[0]) {
+			return nil, p.errorf("expected a variable name or a function call after >>")
+		}
+		a.Names = append(a.Names, name)
+		if !p.accept(",") {
+			return a, nil
+		}
+	}
+}
+
+// call parses a function call, from its dot.
+func (p *exprParser) call() (*Call, error) {
+	if p.peek() != '.' {
+		return nil, p.errorf("expected a function call")
+	}
+	f := &Call{Offset: p.pos}
+	p.pos++
+	if f.Name = p.word(); f.Name == "" {
+		return nil, p.errorf("expected a function name after the dot")
+	}
+	for p.argumentNext() {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		f.Args = append(f.Args, x)
+	}
+	return f, nil
+}
+
+// steps parses the [key] and .key steps following a parenthesized
+// expression, as in `(path >> .regex_capture "/users/(\d+)")[1]`.
+func (p *exprParser) steps(x Expr) (Expr, error) {
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; {
+		case c == '[':
+			offset := p.pos
+			p.pos++
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &Index{X: x, Key: key, Offset: offset}
+		case c == '.' && p.pos+1 < len(p.input) && isWordChar(p.input[p.pos+1]):
+			offset := p.pos
+			p.pos++
+			x = &Index{X: x, Key: &Literal{Value: p.word(), Offset: offset + 1}, Offset: offset}
+		default:
+			return x, nil
+		}
+	}
+	return x, nil
+}
+
+// argumentNext reports whether an argument of a call comes next, rather
+// than an operator, a keyword or the end of the expression.
+func (p *exprParser) argumentNext() bool {
+	switch c := p.peek(); {
+	case c == '"' || c == '{' || c == '(' || isDigit(c):
+		return true
+	case isIdentStart(c):
+		end := identEnd(p.input, p.pos)
+		word := p.input[p.pos:end]
+		return !keywords[word] || word == "nil"
+	}
+	return false
+}
+
+func (p *exprParser) disjunction() (Expr, error) {
+	x, err := p.conjunction()
+	for err == nil {
+		offset := p.offset()
+		if !p.accept("or") {
+			return x, nil
+		}
+		var y Expr
+		if y, err = p.conjunction(); err == nil {
+			x = &Binary{Op: "or", X: x, Y: y, Offset: offset}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) conjunction() (Expr, error) {
+	x, err := p.inversion()
+	for err == nil {
+		offset := p.offset()
+		if !p.accept("and") {
+			return x, nil
+		}
+		var y Expr
+		if y, err = p.inversion(); err == nil {
+			x = &Binary{Op: "and", X: x, Y: y, Offset: offset}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) inversion() (Expr, error) {
+	offset := p.offset()
+	if p.accept("not") {
+		x, err := p.inversion()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: "not", X: x, Offset: offset}, nil
+	}
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (Expr, error) {
+	x, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+	offset := p.offset()
+	op := ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if candidate == ">" && strings.HasPrefix(p.input[p.pos:], ">>") {
+			break
+		}
+		if p.accept(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" && p.accept("not") {
+		if err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		op = "not in"
+	}
+	if op == "" {
+		return x, nil
+	}
+	y, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+	return &Binary{Op: op, X: x, Y: y, Offset: offset}, nil
+}
+
+func (p *exprParser) concat() (Expr, error) {
+	x, err := p.sum()
+	for err == nil {
+		offset := p.offset()
+		if !p.accept("..") {
+			return x, nil
+		}
+		var y Expr
+		if y, err = p.sum(); err == nil {
+			x = &Binary{Op: "..", X: x, Y: y, Offset: offset}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) sum() (Expr, error) {
+	x, err := p.product()
+	for err == nil {
+		offset := p.offset()
+		op := "+"
+		if !p.accept(op) {
+			if op = "-"; !p.accept(op) {
+				return x, nil
+			}
+		}
+		var y Expr
+		if y, err = p.product(); err == nil {
+			x = &Binary{Op: op, X: x, Y: y, Offset: offset}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) product() (Expr, error) {
+	x, err := p.unary()
+	for err == nil {
+		offset := p.offset()
+		op := ""
+		for _, candidate := range []string{"*", "//", "/", "%"} {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return x, nil
+		}
+		var y Expr
+		if y, err = p.unary(); err == nil {
+			x = &Binary{Op: op, X: x, Y: y, Offset: offset}
+		}
+	}
+	return nil, err
+}
+
+func (p *exprParser) unary() (Expr, error) {
+	switch c, offset := p.peek(), p.pos; {
+	case c == '-':
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: "-", X: x, Offset: offset}, nil
+	case c == '(':
+		p.pos++
+		x, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return p.steps(x)
+	case c == '{':
+		p.pos++
+		return p.table(offset)
+	case c == '.' && p.pos+1 < len(p.input) && !isDigit(p.input[p.pos+1]):
+		return p.call()
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.' && !strings.HasPrefix(p.input[p.pos:], "..")) {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[offset:p.pos], 64)
+		if err != nil {
+			p.pos = offset
+			return nil, p.errorf("invalid number %q", p.input[offset:identEnd(p.input, offset)])
+		}
+		return &Literal{Value: value, Offset: offset}, nil
+	case c == '"':
+		for p.pos++; p.pos < len(p.input) && p.input[p.pos] != '"'; p.pos++ {
+			if p.input[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		if p.pos >= len(p.input) {
+			p.pos = offset
+			return nil, p.errorf("unterminated string")
+		}
+		p.pos++
+		value, err := strconv.Unquote(p.input[offset:p.pos])
+		if err != nil {
+			p.pos = offset
+			return nil, p.errorf("invalid string %s", p.input[offset:p.pos])
+		}
+		return &Literal{Value: value, Offset: offset}, nil
+	default:
+		ref := p.reference()
+		switch {
+		case ref == "true" || ref == "True":
+			return &Literal{Value: true, Offset: offset}, nil
+		case ref == "false" || ref == "False":
+			return &Literal{Value: false, Offset: offset}, nil
+		case ref == "nil":
+			return &Literal{Value: nil, Offset: offset}, nil
+		case ref == "exists" && p.peek() == '(':
+			p.pos++
+			x, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return &Exists{X: x, Offset: offset}, nil
+		case ref == "" || keywords[ref]:
+			p.pos = offset
+			return nil, p.unexpected()
+		}
+		return &Variable{Name: ref, Offset: offset}, nil
+	}
+}
+
+// table parses a table, after its opening brace.
+func (p *exprParser) table(offset int) (Expr, error) {
+	t := &Table{Offset: offset}
+	if p.accept("}") {
+		return t, nil
+	}
+	for {
+		key := p.key()
+		if (key != "") != (len(t.Keys) > 0) && len(t.Elements) > 0 {
+			return nil, p.errorf("a table is either an array or a dictionary; write every element with a key or none")
+		}
+		x, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		t.Elements = append(t.Elements, x)
+		if key != "" {
+			t.Keys = append(t.Keys, key)
+		}
+		if p.accept("}") {
+			return t, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// key consumes the `name =` of a dictionary element, if one comes next.
+func (p *exprParser) key() string {
+	start := p.pos
+	p.skipSpaces()
+	name := p.word()
+	if name != "" && isIdentStart(name[0]) && p.accept("=") && !strings.HasPrefix(p.input[p.pos:], "=") {
+		return name
+	}
+	p.pos = start
+	return ""
+}
+
+// reference consumes a variable reference, including its .key and ["key"]
+// steps. Names containing dashes must use the ["key"] form, since a dash is
+// read as subtraction.
+func (p *exprParser) reference() string {
+	start := p.pos
+	inQuotes := false
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '.' && strings.HasPrefix(p.input[p.pos:], ".."):
+			return p.input[start:p.pos]
+		case c == '.' || c == '[' || c == ']' || isWordChar(c):
+		case c == '?' && strings.HasPrefix(p.input[p.pos:], "?."):
+		default:
+			return p.input[start:p.pos]
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// word consumes the letters, digits and underscores that come next.
+func (p *exprParser) word() string {
+	start := p.pos
+	for p.pos < len(p.input) && isWordChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// accept consumes token if it comes next. Words such as `and` must not be
+// followed by another letter.
+func (p *exprParser) accept(token string) bool {
+	p.skipSpaces()
+	if !strings.HasPrefix(p.input[p.pos:], token) {
+		return false
+	}
+	end := p.pos + len(token)
+	if isWordChar(token[0]) && end < len(p.input) && isWordChar(p.input[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+// offset returns the position of what comes next.
+func (p *exprParser) offset() int {
+	p.skipSpaces()
+	return p.pos
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func isWordChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// exprJSON is the JSON form of an Expr: Type names the node, as "Binary",
+// and the other fields are those of the node, when it has them.
+type exprJSON struct {
+	Type     string
+	Value    json.RawMessage `json:",omitempty"`
+	Name     string          `json:",omitempty"`
+	Names    []string        `json:",omitempty"`
+	Op       string          `json:",omitempty"`
+	X        *exprJSON       `json:",omitempty"`
+	Y        *exprJSON       `json:",omitempty"`
+	Key      *exprJSON       `json:",omitempty"`
+	Cond     *exprJSON       `json:",omitempty"`
+	Then     *exprJSON       `json:",omitempty"`
+	Else     *exprJSON       `json:",omitempty"`
+	Elements []*exprJSON     `json:",omitempty"`
+	Keys     []string        `json:",omitempty"`
+	Args     []*exprJSON     `json:",omitempty"`
+	Offset   int
+}
+
+// MarshalExpr encodes x as JSON, each node an object whose Type names it,
+// as in {"Type": "Variable", "Name": "query.page", "Offset": 0}, so tools
+// can read the syntax tree of conditions. UnmarshalExpr decodes it.
+func MarshalExpr(x Expr) ([]byte, error) {
+	node, err := toExprJSON(x)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+// UnmarshalExpr decodes an expression encoded by MarshalExpr. null decodes
+// to a nil Expr.
+func UnmarshalExpr(data []byte) (Expr, error) {
+	var node *exprJSON
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return node.expr()
+}
+
+func toExprJSON(x Expr) (*exprJSON, error) {
+	if x == nil {
+		return nil, nil
+	}
+	var (
+		node = &exprJSON{Offset: x.Pos()}
+		err  error
+	)
+	// each converts the children of node, keeping the first error
+	each := func(xs []Expr) []*exprJSON {
+		var nodes []*exprJSON
+		for _, x := range xs {
+			child, childErr := toExprJSON(x)
+			if err == nil {
+				err = childErr
+			}
+			nodes = append(nodes, child)
+		}
+		return nodes
+	}
+	one := func(x Expr) *exprJSON {
+		if nodes := each([]Expr{x}); len(nodes) == 1 {
+			return nodes[0]
+		}
+		return nil
+	}
+
+	switch x := x.(type) {
+	case *Literal:
+		node.Type = "Literal"
+		node.Value, err = json.Marshal(x.Value)
+	case *Variable:
+		node.Type, node.Name = "Variable", x.Name
+	case *Table:
+		node.Type, node.Elements, node.Keys = "Table", each(x.Elements), x.Keys
+	case *Index:
+		node.Type, node.X, node.Key = "Index", one(x.X), one(x.Key)
+	case *Unary:
+		node.Type, node.Op, node.X = "Unary", x.Op, one(x.X)
+	case *Binary:
+		node.Type, node.Op, node.X, node.Y = "Binary", x.Op, one(x.X), one(x.Y)
+	case *Conditional:
+		node.Type, node.Cond, node.Then, node.Else = "Conditional", one(x.Cond), one(x.Then), one(x.Else)
+	case *Exists:
+		node.Type, node.X = "Exists", one(x.X)
+	case *Call:
+		node.Type, node.Name, node.Args = "Call", x.Name, each(x.Args)
+	case *Assign:
+		node.Type, node.X, node.Names = "Assign", one(x.X), x.Names
+	default:
+		return nil, fmt.Errorf("cannot encode expression node %T", x)
+	}
+	return node, err
+}
+
+func (n *exprJSON) expr() (Expr, error) {
+	if n == nil {
+		return nil, nil
+	}
+	var err error
+	// each converts the children of n, keeping the first error
+	each := func(nodes []*exprJSON) []Expr {
+		var xs []Expr
+		for _, node := range nodes {
+			x, childErr := node.expr()
+			if err == nil {
+				err = childErr
+			}
+			xs = append(xs, x)
+		}
+		return xs
+	}
+	one := func(node *exprJSON) Expr {
+		if xs := each([]*exprJSON{node}); len(xs) == 1 {
+			return xs[0]
+		}
+		return nil
+	}
+
+	var x Expr
+	switch n.Type {
+	case "Literal":
+		literal := &Literal{Offset: n.Offset}
+		if len(n.Value) > 0 {
+			err = json.Unmarshal(n.Value, &literal.Value)
+		}
+		x = literal
+	case "Variable":
+		x = &Variable{Name: n.Name, Offset: n.Offset}
+	case "Table":
+		x = &Table{Elements: each(n.Elements), Keys: n.Keys, Offset: n.Offset}
+	case "Index":
+		x = &Index{X: one(n.X), Key: one(n.Key), Offset: n.Offset}
+	case "Unary":
+		x = &Unary{Op: n.Op, X: one(n.X), Offset: n.Offset}
+	case "Binary":
+		x = &Binary{Op: n.Op, X: one(n.X), Y: one(n.Y), Offset: n.Offset}
+	case "Conditional":
+		x = &Conditional{Cond: one(n.Cond), Then: one(n.Then), Else: one(n.Else), Offset: n.Offset}
+	case "Exists":
+		x = &Exists{X: one(n.X), Offset: n.Offset}
+	case "Call":
+		x = &Call{Name: n.Name, Args: each(n.Args), Offset: n.Offset}
+	case "Assign":
+		x = &Assign{X: one(n.X), Names: n.Names, Offset: n.Offset}
+	default:
+		return nil, fmt.Errorf("unknown expression node type %q", n.Type)
+	}
+	return x, err
+}
@@ -0,0 +1,52 @@
+package apimock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalExpr(t *testing.T) {
+	for _, source := range []string{
+		`call_count > 3 and query.page == 1`,
+		`-(body.total // 2) % 7 != 0`,
+		`not exists(body.user?.name) or headers["X-Plan"] in {"free", "trial"}`,
+		`{name = "Ana", active = true, parent = nil}`,
+		`{}`,
+		`if call_count > 5 then "busy" elif call_count == 0 then "idle" else 1.5`,
+		`(body.items)[0].price .. " EUR"`,
+		`body.email >> .split "@" >> user, domain`,
+		`.random_int (-5) 5`,
+		`body.items >> .filter "price > 10" >> .map "name"`,
+		`body.tags ? 1 : 0`,
+		`1..10`,
+	} {
+		t.Run(source, func(t *testing.T) {
+			x, err := ParseExpression(source)
+			if err != nil {
+				t.Fatalf("ParseExpression() error = %v", err)
+			}
+			data, err := MarshalExpr(x)
+			if err != nil {
+				t.Fatalf("MarshalExpr() error = %v", err)
+			}
+			decoded, err := UnmarshalExpr(data)
+			if err != nil {
+				t.Fatalf("UnmarshalExpr(%s) error = %v", data, err)
+			}
+			if !reflect.DeepEqual(decoded, x) {
+				t.Errorf("UnmarshalExpr(%s) = %#v, want %#v", data, decoded, x)
+			}
+		})
+	}
+
+	if data, err := MarshalExpr(&Variable{Name: "query.page", Offset: 2}); err != nil || string(data) != `{"Type":"Variable","Name":"query.page","Offset":2}` {
+		t.Errorf("MarshalExpr() = %s, %v", data, err)
+	}
+	if x, err := UnmarshalExpr([]byte("null")); x != nil || err != nil {
+		t.Errorf("UnmarshalExpr(null) = %v, %v, want nil", x, err)
+	}
+	if _, err := UnmarshalExpr([]byte(`{"Type": "Lambda"}`)); err == nil || !strings.Contains(err.Error(), `unknown expression node type "Lambda"`) {
+		t.Errorf("UnmarshalExpr() error = %v, want an unknown node type", err)
+	}
+}
@@ -0,0 +1,830 @@
+package apimock
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FunctionImpl implements a function of conditions (see CONDITIONS.md). args
+// holds the value piped into the call, if the signature takes one, followed
+// by the arguments written after the function name, already checked against
+// the signature. Values are float64 numbers, bools, strings, nil, and
+// tables: []any arrays and map[string]any dictionaries.
+type FunctionImpl func(args ...any) (any, error)
+
+// Expression is an expression evaluated on each element of a table, such as
+// `price > 10` in `items >> .filter "price > 10"`. Functions declare them
+// with the expression type; conditions write them as strings, which the
+// program evaluating the conditions compiles into an Expression before
+// calling the function.
+type Expression func(element any) (any, error)
+
+// Function is a function conditions call with a dot prefix, as in
+// `email >> .contains "@"`.
+type Function struct {
+	Name string
+	// Signature lists the parameter types and the result type, as in
+	// "(string, string) -> table". Types are string, number, boolean, table,
+	// expression and any, or alternatives such as string|table; the first
+	// parameter receives the piped value.
+	Signature string
+	Impl      FunctionImpl
+
+	params []string
+	// at implements built-ins depending on the current time, such as
+	// .uuid_v7, with the time given to CallFunctionAt
+	at func(now time.Time, args ...any) (any, error)
+}
+
+var (
+	functionNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	signatureRegex    = regexp.MustCompile(`^\(([^()]*)\)\s*->\s*([a-z|]+)$`)
+	valueTypes        = map[string]bool{"string": true, "number": true, "boolean": true, "table": true, "expression": true, "any": true}
+)
+
+var (
+	functionsMu sync.RWMutex
+	// functions maps names, without the dot, to the functions of conditions
+	functions = make(map[string]Function)
+)
+
+// RegisterFunction adds a function conditions can call as .name, such as a
+// domain check like .valid_cpf, replacing the function registered before
+// with that name, if any. It panics if name is not an identifier, signature
+// is malformed or impl is nil.
+func RegisterFunction(name, signature string, impl FunctionImpl) {
+	if !functionNameRegex.MatchString(name) {
+		panic(fmt.Sprintf("apimock: invalid function name %q", name))
+	}
+	if impl == nil {
+		panic("apimock: RegisterFunction impl is nil")
+	}
+	params, err := parseSignature(signature)
+	if err != nil {
+		panic(fmt.Sprintf("apimock: function %s: %v", name, err))
+	}
+
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+	functions[name] = Function{Name: name, Signature: signature, Impl: impl, params: params}
+}
+
+// registerClockFunction registers a built-in depending on the current time,
+// which CallFunctionAt gives it.
+func registerClockFunction(name, signature string, at func(now time.Time, args ...any) (any, error)) {
+	RegisterFunction(name, signature, func(args ...any) (any, error) {
+		return at(time.Now(), args...)
+	})
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+	fn := functions[name]
+	fn.at = at
+	functions[name] = fn
+}
+
+// Params returns the types of the parameters of f, as its signature lists
+// them.
+func (f Function) Params() []string {
+	return slices.Clone(f.params)
+}
+
+// parseSignature returns the parameter types of signature.
+func parseSignature(signature string) ([]string, error) {
+	m := signatureRegex.FindStringSubmatch(strings.TrimSpace(signature))
+	if m == nil {
+		return nil, fmt.Errorf("invalid signature %q (expected a form such as \"(string, string) -> table\")", signature)
+	}
+
+	var params []string
+	if strings.TrimSpace(m[1]) != "" {
+		for _, param := range strings.Split(m[1], ",") {
+			params = append(params, strings.TrimSpace(param))
+		}
+	}
+	for _, typ := range append(slices.Clone(params), m[2]) {
+		for _, alt := range strings.Split(typ, "|") {
+			if !valueTypes[alt] {
+				return nil, fmt.Errorf("unknown type %q in signature %q", alt, signature)
+			}
+		}
+	}
+	return params, nil
+}
+
+// LookupFunction returns the function registered as name, without the dot.
+func LookupFunction(name string) (Function, bool) {
+	functionsMu.RLock()
+	defer functionsMu.RUnlock()
+	fn, ok := functions[name]
+	return fn, ok
+}
+
+// Functions returns the registered functions, built-in ones included, sorted
+// by name.
+func Functions() []Function {
+	functionsMu.RLock()
+	defer functionsMu.RUnlock()
+	list := make([]Function, 0, len(functions))
+	for _, fn := range functions {
+		list = append(list, fn)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// CallFunction calls the function registered as name with args, after
+// checking them against its signature. Its errors are *EvalErrors.
+func CallFunction(name string, args ...any) (any, error) {
+	return CallFunctionAt(time.Now(), name, args...)
+}
+
+// CallFunctionAt calls the function registered as name as CallFunction
+// does, with now as the current time of the built-ins depending on it, such
+// as .uuid_v7 and .ulid, so programs evaluating conditions can freeze it.
+func CallFunctionAt(now time.Time, name string, args ...any) (any, error) {
+	fn, ok := LookupFunction(name)
+	if !ok {
+		return nil, &EvalError{Code: CodeUnknownFunction, Function: name, Message: fmt.Sprintf("unknown function .%s", name)}
+	}
+	if len(args) != len(fn.params) {
+		return nil, &EvalError{Code: CodeInvalidArgument, Function: name, Message: fmt.Sprintf("function .%s takes %d argument(s), got %d", name, len(fn.params), len(args))}
+	}
+	for i, arg := range args {
+		if !hasType(arg, fn.params[i]) {
+			return nil, &EvalError{Code: CodeInvalidArgument, Function: name, Message: fmt.Sprintf("argument %d of .%s must be %s, got %s", i+1, name, withArticle(fn.params[i]), typeName(arg))}
+		}
+	}
+	impl := fn.Impl
+	if fn.at != nil {
+		impl = func(args ...any) (any, error) { return fn.at(now, args...) }
+	}
+	result, err := impl(args...)
+	if err != nil {
+		return nil, &EvalError{Code: CodeFunctionFailed, Function: name, Message: fmt.Sprintf("function .%s failed", name), Err: err}
+	}
+	return result, nil
+}
+
+// withArticle prefixes a type with its indefinite article.
+func withArticle(typ string) string {
+	if strings.IndexByte("aeiou", typ[0]) >= 0 {
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+// hasType reports whether v is of typ or one of its alternatives.
+func hasType(v any, typ string) bool {
+	for _, alt := range strings.Split(typ, "|") {
+		if alt == "any" || alt == typeName(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any, map[string]any:
+		return "table"
+	case nil:
+		return "nil"
+	case Expression:
+		return "expression"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// Truthy reports whether a value counts as true in conditions: false, 0,
+// "", nil and empty tables do not.
+func Truthy(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return false
+}
+
+// Equal reports whether two values are equal in conditions. nil equals only
+// nil; numbers, and strings reading as numbers, compare by value, so
+// 2 == "2.0"; a number never equals anything else; other values compare as
+// rendered, so strings exactly, true == "true" and tables as JSON.
+func Equal(x, y any) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	_, xNumber := x.(float64)
+	_, yNumber := y.(float64)
+	if xNumber || yNumber {
+		left, xOK := asNumber(x)
+		right, yOK := asNumber(y)
+		return xOK && yOK && left == right
+	}
+	return rendered(x) == rendered(y)
+}
+
+// asNumber returns value as a number if it is one or a string reading as
+// one.
+func asNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// rendered returns a value other than a number as Equal compares it:
+// strings as they are and anything else as JSON.
+func rendered(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// array returns the elements of a table that must be an array.
+func array(table any) ([]any, error) {
+	elements, ok := table.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got a dictionary")
+	}
+	return elements, nil
+}
+
+// compareValues orders the sort keys of .sort: nil, then booleans, numbers,
+// strings and tables, numbers by value and strings byte by byte.
+func compareValues(a, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case bool:
+		return cmp.Compare(text(a), text(b))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+// text renders a value for string functions: numbers without exponent or
+// trailing zeros, nil as an empty string and anything else as fmt does.
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// intRange returns the bounds of .random_int and .random_sticky as integers,
+// failing when they leave the range of an int64 or hi is below lo.
+func intRange(lo, hi float64) (int64, int64, error) {
+	for _, bound := range []float64{lo, hi} {
+		if !(bound >= math.MinInt64 && bound < math.MaxInt64) {
+			return 0, 0, fmt.Errorf("range bound %g is out of range", bound)
+		}
+	}
+	if int64(hi) < int64(lo) {
+		return 0, 0, fmt.Errorf("empty range %d..%d", int64(lo), int64(hi))
+	}
+	return int64(lo), int64(hi), nil
+}
+
+// maxPadWidth is the widest text .pad_left and .pad_right produce, since the
+// width may come from the request.
+const maxPadWidth = 10000
+
+// pad returns the implementation of .pad_left, or of .pad_right: the value
+// as text, filled with its third argument up to the width of its second.
+func pad(left bool) FunctionImpl {
+	return func(args ...any) (any, error) {
+		s, width, fill := text(args[0]), args[1].(float64), args[2].(string)
+		if fill == "" {
+			return nil, fmt.Errorf("cannot pad with an empty string")
+		}
+		if !(width >= 0 && width <= maxPadWidth) {
+			return nil, fmt.Errorf("cannot pad to a width of %g: expected 0 to %d", width, maxPadWidth)
+		}
+		missing := int(width) - utf8.RuneCountInString(s)
+		if missing <= 0 {
+			return s, nil
+		}
+		fillRunes := []rune(fill)
+		padding := make([]rune, missing)
+		for i := range padding {
+			padding[i] = fillRunes[i%len(fillRunes)]
+		}
+		if left {
+			return string(padding) + s, nil
+		}
+		return s + string(padding), nil
+	}
+}
+
+// numbers returns the elements of a table of numbers.
+func numbers(table any) ([]float64, error) {
+	array, ok := table.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of numbers, got a dictionary")
+	}
+	ns := make([]float64, len(array))
+	for i, v := range array {
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("element %d is a %s, not a number", i+1, typeName(v))
+		}
+		ns[i] = n
+	}
+	return ns, nil
+}
+
+// aggregate returns the implementation of a function reducing a table of
+// numbers, which must not be empty.
+func aggregate(reduce func([]float64) float64) FunctionImpl {
+	return func(args ...any) (any, error) {
+		ns, err := numbers(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(ns) == 0 {
+			return nil, fmt.Errorf("empty table")
+		}
+		return reduce(ns), nil
+	}
+}
+
+// patterns caches the compiled patterns of .matches and .regex_capture,
+// which run on every request.
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// captures returns the first match of re in s: a table of the match and
+// its groups, by number from 0 for the whole match, or, when re names
+// groups, a dictionary holding them by name and by number. Groups that did
+// not participate are nil, and so is the result when re does not match.
+func captures(re *regexp.Regexp, s string) any {
+	m := re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil
+	}
+	groups := make([]any, len(m)/2)
+	for i := range groups {
+		if m[2*i] >= 0 {
+			groups[i] = s[m[2*i]:m[2*i+1]]
+		}
+	}
+	if !slices.ContainsFunc(re.SubexpNames(), func(name string) bool { return name != "" }) {
+		return groups
+	}
+	named := make(map[string]any, len(groups))
+	for i, name := range re.SubexpNames() {
+		named[strconv.Itoa(i)] = groups[i]
+		if name != "" {
+			named[name] = groups[i]
+		}
+	}
+	return named
+}
+
+// formatNumber is a number given to .format, printed as an integer by the
+// integer verbs, such as %d and %x, and as a float by the others.
+type formatNumber float64
+
+func (n formatNumber) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'd', 'b', 'o', 'O', 'x', 'X', 'c', 'U':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), int64(n))
+	case 'v', 's':
+		fmt.Fprintf(f, fmt.FormatString(f, 's'), text(float64(n)))
+	default:
+		fmt.Fprintf(f, fmt.FormatString(f, verb), float64(n))
+	}
+}
+
+// unknownFunction returns the first function a condition line calls that is
+// not registered, and its column, from 1.
+func unknownFunction(line string) (string, int) {
+	inString := false
+	for pos := 0; pos < len(line); pos++ {
+		c := line[pos]
+		switch {
+		case inString:
+			if c == '\\' {
+				pos++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '#':
+			return "", 0
+		case c == '.' && pos+1 < len(line) && isIdentStart(line[pos+1]):
+			end := identEnd(line, pos+1)
+			// A dot right after a value accesses a field, as in body.email or
+			// body.user?.name
+			if pos > 0 && (isIdentStart(line[pos-1]) || isDigit(line[pos-1]) || strings.IndexByte(`]})"?`, line[pos-1]) >= 0) {
+				pos = end - 1
+				continue
+			}
+			if _, ok := LookupFunction(line[pos+1 : end]); !ok {
+				return line[pos+1 : end], pos + 1
+			}
+			pos = end - 1
+		}
+	}
+	return "", 0
+}
+
+// functionSuggestion suggests the registered function closest to name.
+func functionSuggestion(name string) string {
+	best, bestDistance := "", 3
+	for _, fn := range Functions() {
+		if d := editDistance(name, fn.Name); d < bestDistance {
+			best, bestDistance = fn.Name, d
+		}
+	}
+	if best == "" {
+		return "use a built-in function or register it with apimock.RegisterFunction"
+	}
+	return fmt.Sprintf("did you mean .%s?", best)
+}
+
+// editDistance returns the number of single-character edits turning a into b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func init() {
+	contains := func(args ...any) (any, error) {
+		if s, ok := args[0].(string); ok {
+			sub, ok := args[1].(string)
+			return ok && strings.Contains(s, sub), nil
+		}
+		if object, ok := args[0].(map[string]any); ok {
+			key, ok := args[1].(string)
+			_, found := object[key]
+			return ok && found, nil
+		}
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return slices.ContainsFunc(elements, func(element any) bool { return Equal(element, args[1]) }), nil
+	}
+	number := func(op func(float64) float64) FunctionImpl {
+		return func(args ...any) (any, error) { return op(args[0].(float64)), nil }
+	}
+	is := func(typ string) FunctionImpl {
+		return func(args ...any) (any, error) { return typeName(args[0]) == typ, nil }
+	}
+
+	RegisterFunction("split", "(string, string) -> table", func(args ...any) (any, error) {
+		parts := strings.Split(args[0].(string), args[1].(string))
+		table := make([]any, len(parts))
+		for i, part := range parts {
+			table[i] = part
+		}
+		return table, nil
+	})
+	RegisterFunction("contains", "(string|table, any) -> boolean", contains)
+	RegisterFunction("not_contains", "(string|table, any) -> boolean", func(args ...any) (any, error) {
+		found, err := contains(args...)
+		return !found.(bool), err
+	})
+	RegisterFunction("trim", "(string) -> string", func(args ...any) (any, error) {
+		return strings.TrimSpace(args[0].(string)), nil
+	})
+	RegisterFunction("replace", "(string, string, string) -> string", func(args ...any) (any, error) {
+		return strings.ReplaceAll(args[0].(string), args[1].(string), args[2].(string)), nil
+	})
+	RegisterFunction("starts_with", "(string, string) -> boolean", func(args ...any) (any, error) {
+		return strings.HasPrefix(args[0].(string), args[1].(string)), nil
+	})
+	RegisterFunction("ends_with", "(string, string) -> boolean", func(args ...any) (any, error) {
+		return strings.HasSuffix(args[0].(string), args[1].(string)), nil
+	})
+	RegisterFunction("matches", "(string, string) -> boolean", func(args ...any) (any, error) {
+		re, err := compilePattern(args[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(args[0].(string)), nil
+	})
+	RegisterFunction("regex_capture", "(string, string) -> table", func(args ...any) (any, error) {
+		re, err := compilePattern(args[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		return captures(re, args[0].(string)), nil
+	})
+	RegisterFunction("base64_encode", "(string) -> string", func(args ...any) (any, error) {
+		return base64.StdEncoding.EncodeToString([]byte(args[0].(string))), nil
+	})
+	RegisterFunction("base64_decode", "(string) -> string", func(args ...any) (any, error) {
+		encoded := strings.TrimRight(args[0].(string), "=")
+		for _, encoding := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+			if data, err := encoding.DecodeString(encoded); err == nil {
+				return string(data), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid base64")
+	})
+	RegisterFunction("url_encode", "(string) -> string", func(args ...any) (any, error) {
+		return url.QueryEscape(args[0].(string)), nil
+	})
+	RegisterFunction("url_decode", "(string) -> string", func(args ...any) (any, error) {
+		return url.QueryUnescape(args[0].(string))
+	})
+	RegisterFunction("md5", "(string) -> string", func(args ...any) (any, error) {
+		sum := md5.Sum([]byte(args[0].(string)))
+		return hex.EncodeToString(sum[:]), nil
+	})
+	RegisterFunction("sha256", "(string) -> string", func(args ...any) (any, error) {
+		sum := sha256.Sum256([]byte(args[0].(string)))
+		return hex.EncodeToString(sum[:]), nil
+	})
+	RegisterFunction("hmac_sha256", "(string, string) -> string", func(args ...any) (any, error) {
+		mac := hmac.New(sha256.New, []byte(args[1].(string)))
+		mac.Write([]byte(args[0].(string)))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	})
+	RegisterFunction("jwt_decode", "(string) -> table", func(args ...any) (any, error) {
+		_, claims, err := jwtClaims(args[0].(string))
+		return claims, err
+	})
+	RegisterFunction("jwt_valid", "(string, string) -> boolean", func(args ...any) (any, error) {
+		token, _, err := jwtClaims(args[0].(string))
+		return err == nil && VerifyJWT(token, args[1].(string)) == nil, nil
+	})
+	registerClockFunction("jwt_expired", "(string) -> boolean", func(now time.Time, args ...any) (any, error) {
+		_, claims, err := jwtClaims(args[0].(string))
+		if err != nil {
+			return nil, err
+		}
+		return JWTExpired(claims, now), nil
+	})
+	RegisterFunction("join", "(table, string) -> string", func(args ...any) (any, error) {
+		array, ok := args[0].([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot join a dictionary")
+		}
+		parts := make([]string, len(array))
+		for i, v := range array {
+			parts[i] = text(v)
+		}
+		return strings.Join(parts, args[1].(string)), nil
+	})
+	RegisterFunction("pad_left", "(string|number, number, string) -> string", pad(true))
+	RegisterFunction("pad_right", "(string|number, number, string) -> string", pad(false))
+	RegisterFunction("format", "(any, string) -> string", func(args ...any) (any, error) {
+		values := []any{args[0]}
+		if array, ok := args[0].([]any); ok {
+			values = array
+		}
+		operands := make([]any, len(values))
+		for i, v := range values {
+			if n, ok := v.(float64); ok {
+				v = formatNumber(n)
+			}
+			operands[i] = v
+		}
+		return fmt.Sprintf(args[1].(string), operands...), nil
+	})
+	RegisterFunction("is_string", "(any) -> boolean", is("string"))
+	RegisterFunction("is_number", "(any) -> boolean", is("number"))
+	RegisterFunction("is_boolean", "(any) -> boolean", is("boolean"))
+	RegisterFunction("is_table", "(any) -> boolean", is("table"))
+	RegisterFunction("is_nil", "(any) -> boolean", is("nil"))
+	RegisterFunction("round", "(number) -> number", number(math.Round))
+	RegisterFunction("floor", "(number) -> number", number(math.Floor))
+	RegisterFunction("ceil", "(number) -> number", number(math.Ceil))
+	RegisterFunction("abs", "(number) -> number", number(math.Abs))
+	RegisterFunction("min", "(table) -> number", aggregate(func(ns []float64) float64 { return slices.Min(ns) }))
+	RegisterFunction("max", "(table) -> number", aggregate(func(ns []float64) float64 { return slices.Max(ns) }))
+	RegisterFunction("sum", "(table) -> number", func(args ...any) (any, error) {
+		ns, err := numbers(args[0])
+		if err != nil {
+			return nil, err
+		}
+		total := 0.0
+		for _, n := range ns {
+			total += n
+		}
+		return total, nil
+	})
+	RegisterFunction("avg", "(table) -> number", aggregate(func(ns []float64) float64 {
+		total := 0.0
+		for _, n := range ns {
+			total += n
+		}
+		return total / float64(len(ns))
+	}))
+	RegisterFunction("random_bool", "() -> boolean", func(args ...any) (any, error) {
+		return random.IntN(2) == 1, nil
+	})
+	RegisterFunction("random_int", "(number, number) -> number", func(args ...any) (any, error) {
+		lo, hi, err := intRange(args[0].(float64), args[1].(float64))
+		if err != nil {
+			return nil, err
+		}
+		return float64(random.int64Between(lo, hi)), nil
+	})
+	RegisterFunction("random_float", "(number, number) -> number", func(args ...any) (any, error) {
+		lo, hi := args[0].(float64), args[1].(float64)
+		return lo + random.Float64()*(hi-lo), nil
+	})
+	RegisterFunction("uuid", "() -> string", func(args ...any) (any, error) {
+		return newUUID(), nil
+	})
+	registerClockFunction("uuid_v7", "() -> string", func(now time.Time, args ...any) (any, error) {
+		return newUUIDv7(now), nil
+	})
+	registerClockFunction("ulid", "() -> string", func(now time.Time, args ...any) (any, error) {
+		return newULID(now), nil
+	})
+	RegisterFunction("random_sticky", "(any, number, number) -> number", func(args ...any) (any, error) {
+		lo, hi, err := intRange(args[1].(float64), args[2].(float64))
+		if err != nil {
+			return nil, err
+		}
+		return float64(random.sticky(text(args[0]), lo, hi)), nil
+	})
+	RegisterFunction("map", "(table, expression) -> table", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		mapped := make([]any, len(elements))
+		for i, element := range elements {
+			if mapped[i], err = args[1].(Expression)(element); err != nil {
+				return nil, err
+			}
+		}
+		return mapped, nil
+	})
+	RegisterFunction("filter", "(table, expression) -> table", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		kept := []any{}
+		for _, element := range elements {
+			keep, err := args[1].(Expression)(element)
+			if err != nil {
+				return nil, err
+			}
+			if Truthy(keep) {
+				kept = append(kept, element)
+			}
+		}
+		return kept, nil
+	})
+	RegisterFunction("sort", "(table, expression) -> table", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]any, len(elements))
+		for i, element := range elements {
+			if keys[i], err = args[1].(Expression)(element); err != nil {
+				return nil, err
+			}
+		}
+		order := make([]int, len(elements))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(i, j int) int { return compareValues(keys[i], keys[j]) })
+		sorted := make([]any, len(elements))
+		for i, j := range order {
+			sorted[i] = elements[j]
+		}
+		return sorted, nil
+	})
+	RegisterFunction("reverse", "(table) -> table", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		reversed := slices.Clone(elements)
+		slices.Reverse(reversed)
+		return reversed, nil
+	})
+	RegisterFunction("slice", "(table, number, number) -> table", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		clamp := func(n float64) int { return int(max(0, min(n, float64(len(elements))))) }
+		start, end := clamp(args[1].(float64)), clamp(args[2].(float64))
+		return slices.Clone(elements[start:max(start, end)]), nil
+	})
+	RegisterFunction("first", "(table) -> any", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil || len(elements) == 0 {
+			return nil, err
+		}
+		return elements[0], nil
+	})
+	RegisterFunction("last", "(table) -> any", func(args ...any) (any, error) {
+		elements, err := array(args[0])
+		if err != nil || len(elements) == 0 {
+			return nil, err
+		}
+		return elements[len(elements)-1], nil
+	})
+	RegisterFunction("random", "(table) -> any", func(args ...any) (any, error) {
+		table, err := array(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(table) == 0 {
+			return nil, fmt.Errorf("cannot pick from an empty table")
+		}
+		return table[random.IntN(len(table))], nil
+	})
+}
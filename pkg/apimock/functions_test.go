@@ -0,0 +1,265 @@
+package apimock
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCallFunction_Builtins(t *testing.T) {
+	tests := []struct {
+		name string
+		args []any
+		want any
+	}{
+		{name: "split", args: []any{"2025-10-06", "-"}, want: []any{"2025", "10", "06"}},
+		{name: "contains", args: []any{"ana@example.com", "@"}, want: true},
+		{name: "contains", args: []any{[]any{1.0, 2.0}, 3.0}, want: false},
+		{name: "not_contains", args: []any{[]any{"a", "b"}, "b"}, want: false},
+		{name: "trim", args: []any{"  hello  "}, want: "hello"},
+		{name: "is_number", args: []any{8.7}, want: true},
+		{name: "is_table", args: []any{"x"}, want: false},
+		{name: "is_table", args: []any{map[string]any{}}, want: true},
+		{name: "is_nil", args: []any{nil}, want: true},
+		{name: "is_nil", args: []any{""}, want: false},
+		{name: "contains", args: []any{map[string]any{"id": 1.0}, "id"}, want: true},
+		{name: "contains", args: []any{[]any{[]any{1.0}, map[string]any{"id": 1.0}}, map[string]any{"id": 1.0}}, want: true},
+		{name: "contains", args: []any{[]any{2.0, nil}, "2.0"}, want: true},
+		{name: "contains", args: []any{[]any{"2", true}, "2.0"}, want: false},
+		{name: "round", args: []any{8.7}, want: 9.0},
+		{name: "floor", args: []any{8.7}, want: 8.0},
+		{name: "ceil", args: []any{8.2}, want: 9.0},
+		{name: "abs", args: []any{-5.0}, want: 5.0},
+		{name: "random_int", args: []any{3.0, 3.0}, want: 3.0},
+		{name: "replace", args: []any{"a-b-c", "-", "/"}, want: "a/b/c"},
+		{name: "starts_with", args: []any{"Bearer abc", "Bearer "}, want: true},
+		{name: "ends_with", args: []any{"photo.png", ".jpg"}, want: false},
+		{name: "join", args: []any{[]any{"a", 2.0, 3.5}, ","}, want: "a,2,3.5"},
+		{name: "pad_left", args: []any{42.0, 5.0, "0"}, want: "00042"},
+		{name: "pad_left", args: []any{"abcdef", 3.0, "0"}, want: "abcdef"},
+		{name: "pad_right", args: []any{"ab", 5.0, "-="}, want: "ab-=-"},
+		{name: "format", args: []any{3.14159, "%.2f"}, want: "3.14"},
+		{name: "format", args: []any{[]any{"Ana", 3.0, 2.5}, "%s has %d items worth %v"}, want: "Ana has 3 items worth 2.5"},
+		{name: "format", args: []any{255.0, "%04X"}, want: "00FF"},
+		{name: "min", args: []any{[]any{3.0, -1.5, 2.0}}, want: -1.5},
+		{name: "max", args: []any{[]any{3.0, -1.5, 2.0}}, want: 3.0},
+		{name: "sum", args: []any{[]any{3.0, -1.5, 2.0}}, want: 3.5},
+		{name: "sum", args: []any{[]any{}}, want: 0.0},
+		{name: "avg", args: []any{[]any{1.0, 2.0, 6.0}}, want: 3.0},
+		{name: "matches", args: []any{"/users/42", `^/users/\d+$`}, want: true},
+		{name: "regex_capture", args: []any{"/users/42/orders/7", `/users/(\d+)/orders/(\d+)`}, want: []any{"/users/42/orders/7", "42", "7"}},
+		{name: "regex_capture", args: []any{"v2", `v(\d+)(-beta)?`}, want: []any{"v2", "2", nil}},
+		{name: "regex_capture", args: []any{"Bearer abc", `^Bearer (?P<token>.+)$`}, want: map[string]any{"0": "Bearer abc", "1": "abc", "token": "abc"}},
+		{name: "regex_capture", args: []any{"Basic abc", `^Bearer (.+)$`}, want: nil},
+		{name: "base64_encode", args: []any{"user:pass"}, want: "dXNlcjpwYXNz"},
+		{name: "base64_decode", args: []any{"dXNlcjpwYXNz"}, want: "user:pass"},
+		{name: "base64_decode", args: []any{"PDw_Pz8-Pg"}, want: "<<???>>"},
+		{name: "url_encode", args: []any{"a b&c=d"}, want: "a+b%26c%3Dd"},
+		{name: "url_decode", args: []any{"a+b%26c%3Dd"}, want: "a b&c=d"},
+		{name: "md5", args: []any{"hello"}, want: "5d41402abc4b2a76b9719d911017c592"},
+		{name: "sha256", args: []any{"hello"}, want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "hmac_sha256", args: []any{"The quick brown fox jumps over the lazy dog", "key"}, want: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CallFunction(tt.name, tt.args...)
+			if err != nil {
+				t.Fatalf("CallFunction() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CallFunction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallFunction_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []any
+		want string
+	}{
+		{name: "missing", want: "unknown function .missing"},
+		{name: "trim", want: "takes 1 argument(s), got 0"},
+		{name: "split", args: []any{"a-b", 1.0}, want: "argument 2 of .split must be a string, got number"},
+		{name: "contains", args: []any{true, "x"}, want: "must be a string|table, got boolean"},
+		{name: "pad_left", args: []any{"7", 3.0, ""}, want: "cannot pad with an empty string"},
+		{name: "pad_left", args: []any{"7", 100000000000.0, "x"}, want: "cannot pad to a width of 1e+11"},
+		{name: "pad_right", args: []any{"7", -1e20, "x"}, want: "cannot pad to a width of -1e+20"},
+		{name: "join", args: []any{map[string]any{}, ","}, want: "cannot join a dictionary"},
+		{name: "starts_with", args: []any{"abc"}, want: "takes 2 argument(s), got 1"},
+		{name: "max", args: []any{[]any{}}, want: "empty table"},
+		{name: "regex_capture", args: []any{"abc", "("}, want: "missing closing )"},
+		{name: "base64_decode", args: []any{"not base64!"}, want: "invalid base64"},
+		{name: "filter", args: []any{[]any{1.0}, "item > 0"}, want: "argument 2 of .filter must be an expression, got string"},
+		{name: "url_decode", args: []any{"%zz"}, want: "invalid URL escape"},
+		{name: "avg", args: []any{[]any{1.0, "2"}}, want: "element 2 is a string, not a number"},
+		{name: "sum", args: []any{map[string]any{"a": 1.0}}, want: "got a dictionary"},
+		{name: "random", args: []any{map[string]any{"a": 1.0}}, want: "got a dictionary"},
+		{name: "random_int", args: []any{5.0, 1.0}, want: "empty range 5..1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CallFunction(tt.name, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CallFunction() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallFunction_Tables(t *testing.T) {
+	items := []any{
+		map[string]any{"name": "pear", "price": 12.0},
+		map[string]any{"name": "apple", "price": 5.0},
+		map[string]any{"name": "fig", "price": 30.0},
+	}
+	field := func(name string) Expression {
+		return func(element any) (any, error) { return element.(map[string]any)[name], nil }
+	}
+	expensive := Expression(func(element any) (any, error) { return element.(map[string]any)["price"].(float64) > 10, nil })
+
+	tests := []struct {
+		name string
+		args []any
+		want any
+	}{
+		{name: "map", args: []any{items, field("name")}, want: []any{"pear", "apple", "fig"}},
+		{name: "filter", args: []any{items, expensive}, want: []any{items[0], items[2]}},
+		{name: "filter", args: []any{[]any{}, expensive}, want: []any{}},
+		{name: "sort", args: []any{items, field("name")}, want: []any{items[1], items[2], items[0]}},
+		{name: "sort", args: []any{items, field("price")}, want: []any{items[1], items[0], items[2]}},
+		{name: "reverse", args: []any{[]any{1.0, 2.0, 3.0}}, want: []any{3.0, 2.0, 1.0}},
+		{name: "slice", args: []any{[]any{1.0, 2.0, 3.0, 4.0}, 1.0, 3.0}, want: []any{2.0, 3.0}},
+		{name: "slice", args: []any{[]any{1.0, 2.0}, 1.0, 10.0}, want: []any{2.0}},
+		{name: "slice", args: []any{[]any{1.0, 2.0}, 5.0, 10.0}, want: []any{}},
+		{name: "slice", args: []any{[]any{1.0, 2.0}, 1.0, 0.0}, want: []any{}},
+		{name: "first", args: []any{[]any{1.0, 2.0}}, want: 1.0},
+		{name: "last", args: []any{[]any{1.0, 2.0}}, want: 2.0},
+		{name: "first", args: []any{[]any{}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CallFunction(tt.name, tt.args...)
+			if err != nil {
+				t.Fatalf("CallFunction() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CallFunction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	failing := Expression(func(any) (any, error) { return nil, errors.New("no price") })
+	if _, err := CallFunction("filter", items, failing); err == nil || !strings.Contains(err.Error(), "no price") {
+		t.Errorf("CallFunction(filter) error = %v, want the error of the expression", err)
+	}
+}
+
+// TestFunctions_Implemented checks that every function the documentation
+// describes is registered, and that every registered function runs on
+// arguments of its signature.
+func TestFunctions_Implemented(t *testing.T) {
+	doc, err := os.ReadFile("../../docs/apimock/CONDITIONS.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	documented := regexp.MustCompile("(?m)^#### (.+)$")
+	for _, m := range documented.FindAllStringSubmatch(string(doc), -1) {
+		for _, name := range regexp.MustCompile("`\\.([a-z_]+)`").FindAllStringSubmatch(m[1], -1) {
+			if _, ok := LookupFunction(name[1]); !ok {
+				t.Errorf("CONDITIONS.md documents .%s, which is not registered", name[1])
+			}
+		}
+	}
+
+	identity := Expression(func(element any) (any, error) { return element, nil })
+	samples := map[string]any{"string": "a", "number": 1.0, "boolean": true, "table": []any{1.0}, "expression": identity, "any": 1.0}
+	for _, fn := range Functions() {
+		t.Run(fn.Name, func(t *testing.T) {
+			if fn.Impl == nil {
+				t.Fatal("no implementation")
+			}
+			args := make([]any, len(fn.params))
+			for i, param := range fn.params {
+				args[i] = samples[strings.Split(param, "|")[0]]
+			}
+			var evalErr *EvalError
+			if _, err := CallFunction(fn.Name, args...); errors.As(err, &evalErr) && evalErr.Code != CodeFunctionFailed {
+				t.Errorf("CallFunction(%v) error = %v", args, err)
+			}
+		})
+	}
+}
+
+func TestRegisterFunction(t *testing.T) {
+	saved := maps.Clone(functions)
+	t.Cleanup(func() { functions = saved })
+
+	RegisterFunction("valid_cpf", "(string) -> boolean", func(args ...any) (any, error) {
+		return len(args[0].(string)) == 11, nil
+	})
+	if got, err := CallFunction("valid_cpf", "12345678901"); err != nil || got != true {
+		t.Errorf("CallFunction() = %v, %v", got, err)
+	}
+
+	source := "GET /customers\n\n-- 200: OK\n> body.cpf >> .valid_cpf\n\n{}\n"
+	if _, err := NewParserFromBytes("customers.apimock", []byte(source)).Parse(); err != nil {
+		t.Errorf("Parse() error = %v, want the registered function to be recognized", err)
+	}
+
+	for _, tt := range []struct{ name, signature string }{
+		{name: "1bad", signature: "() -> boolean"},
+		{name: "ok", signature: "string -> boolean"},
+		{name: "ok", signature: "(date) -> boolean"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected RegisterFunction(%q, %q) to panic", tt.name, tt.signature)
+				}
+			}()
+			RegisterFunction(tt.name, tt.signature, func(args ...any) (any, error) { return nil, nil })
+		}()
+	}
+}
+
+func TestParser_UnknownFunction(t *testing.T) {
+	tests := []struct {
+		name       string
+		condition  string
+		column     int
+		suggestion string
+	}{
+		{name: "typo", condition: `> body.email >> .contians "@"`, column: 17, suggestion: "did you mean .contains?"},
+		{name: "unknown", condition: `> .lookup_customer >> customer`, column: 3, suggestion: "apimock.RegisterFunction"},
+		{name: "new built-in", condition: `> path >> .start_with "/v2"`, column: 11, suggestion: "did you mean .starts_with?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "GET /users\n\n-- 200: OK\n" + tt.condition + "\n\n{}\n"
+			_, err := NewParserFromBytes("users.apimock", []byte(source)).Parse()
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse() error = %v, want a *ParseError", err)
+			}
+			if perr.Code != CodeUnknownFunction || perr.Line != 4 || perr.Column != tt.column || !strings.Contains(perr.Suggestion, tt.suggestion) {
+				t.Errorf("Parse() error = %+v", perr)
+			}
+		})
+	}
+
+	// Field access, safe access, strings, numbers and comments are not calls
+	source := "GET /users\n\n-- 200: OK\n> body.email == \"a.b@x.com\" # see .docs\n> exists(body.user?.name)\n> 1.5 >> .round >> r\n\n{\"a\": \".nope\"}\n"
+	if _, err := NewParserFromBytes("users.apimock", []byte(source)).Parse(); err != nil {
+		t.Errorf("Parse() error = %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package apimock
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	random.Read(b[:])
+	return formatUUID(b, 4)
+}
+
+// newUUIDv7 returns a version 7 UUID: the Unix time of now in milliseconds
+// followed by random bits, so identifiers sort by creation time.
+func newUUIDv7(now time.Time) string {
+	var b [16]byte
+	random.Read(b[6:])
+	putMillis(b[:6], now)
+	return formatUUID(b, 7)
+}
+
+// formatUUID sets the version and the RFC 9562 variant of b and formats it.
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = b[6]&0x0f | version<<4
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// crockford is the alphabet of ULIDs, Crockford's base 32.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID: the Unix time of now in milliseconds followed by
+// 80 random bits, as 26 characters of Crockford's base 32.
+func newULID(now time.Time) string {
+	var b [16]byte
+	random.Read(b[6:])
+	putMillis(b[:6], now)
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// putMillis writes the Unix time of t in milliseconds as 48 big-endian bits.
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
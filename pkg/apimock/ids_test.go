@@ -0,0 +1,74 @@
+package apimock
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewUUID(t *testing.T) {
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	now := time.Date(2025, 10, 6, 14, 30, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		id      string
+		version string
+	}{
+		{newUUID(), "4"},
+		{newUUIDv7(now), "7"},
+	} {
+		m := uuidRegex.FindStringSubmatch(tt.id)
+		if m == nil || m[1] != tt.version {
+			t.Errorf("got %q, want a version %s UUID", tt.id, tt.version)
+		}
+	}
+	if newUUID() == newUUID() {
+		t.Error("expected different UUIDs")
+	}
+
+	// The first 48 bits of a version 7 UUID are its time in milliseconds
+	id := strings.ReplaceAll(newUUIDv7(now), "-", "")
+	if ms, err := strconv.ParseInt(id[:12], 16, 64); err != nil || ms != now.UnixMilli() {
+		t.Errorf("newUUIDv7() = %s, want the time %d", id, now.UnixMilli())
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	now := time.Date(2025, 10, 6, 14, 30, 0, 0, time.UTC)
+	id := newULID(now)
+	if !regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`).MatchString(id) {
+		t.Fatalf("newULID() = %q, want a ULID", id)
+	}
+
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms != now.UnixMilli() {
+		t.Errorf("newULID() = %s encodes %d, want %d", id, ms, now.UnixMilli())
+	}
+	if later := newULID(now.Add(time.Millisecond)); later <= id {
+		t.Errorf("newULID() = %s after %s, want ULIDs to sort by time", later, id)
+	}
+}
+
+func TestCallFunctionAt_Time(t *testing.T) {
+	now := time.Date(2025, 10, 6, 14, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"uuid_v7", newUUIDv7(now)[:13]},
+		{"ulid", newULID(now)[:10]},
+	} {
+		got, err := CallFunctionAt(now, tt.name)
+		if err != nil {
+			t.Fatalf("CallFunctionAt(%s) error = %v", tt.name, err)
+		}
+		if !strings.HasPrefix(got.(string), tt.want) {
+			t.Errorf("CallFunctionAt(%s) = %s, want the time of now, %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package apimock
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeDirective starts a line that is replaced by the content of another
+// file, as in `@include ./common/errors.apimock`. Relative paths are resolved
+// from the directory of the including file, and included files may include
+// others.
+const IncludeDirective = "@include"
+
+// lineOrigin tells where a line of the expanded source comes from: the file
+// and line it was read from, and the line of the parsed file it stands for,
+// which is the @include directive for included lines.
+type lineOrigin struct {
+	file string
+	line int
+	top  int
+}
+
+// includePath returns the path of an @include line, if line is one.
+func includePath(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimRight(line, " \t\r"), IncludeDirective)
+	if !ok || rest == "" || rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// expand replaces the @include lines of the parser source with the files they
+// name, recording where every line comes from and which files were included.
+func (p *Parser) expand() ([]string, []lineOrigin, []string, error) {
+	e := &expansion{seen: make(map[string]bool), fsys: p.fsys}
+	var errs ParseErrors
+	for i, line := range p.lines {
+		path, ok := includePath(line)
+		if !ok {
+			e.lines = append(e.lines, line)
+			e.origins = append(e.origins, lineOrigin{file: p.filename, line: i + 1, top: i + 1})
+			continue
+		}
+		if err := e.include(p.filename, i+1, line, path, i+1, []string{p.filename}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 1 {
+		return nil, nil, nil, errs[0]
+	}
+	if len(errs) > 1 {
+		return nil, nil, nil, errs
+	}
+	return e.lines, e.origins, e.includes, nil
+}
+
+type expansion struct {
+	lines    []string
+	origins  []lineOrigin
+	includes []string
+	seen     map[string]bool
+	fsys     fs.FS // nil to read the operating system's files
+}
+
+// include appends the lines of the file named by the @include directive at
+// line of from. stack lists the files being included, to detect cycles.
+func (e *expansion) include(from string, line int, raw, name string, top int, stack []string) *ParseError {
+	path := e.resolve(from, name)
+
+	for _, file := range stack {
+		if samePath(file, path) {
+			chain := append(append([]string{}, stack...), path)
+			return e.errorAt(from, line, raw, CodeIncludeCycle,
+				fmt.Sprintf("include cycle: %s", strings.Join(chain, " -> ")),
+				"move the shared sections to a file that does not include its includers")
+		}
+	}
+
+	content, err := e.readFile(path)
+	if err != nil {
+		return e.errorAt(from, line, raw, CodeIncludeNotFound,
+			fmt.Sprintf("cannot include %s: %v", path, err),
+			"paths are relative to the directory of the including file")
+	}
+	if !e.seen[path] {
+		e.seen[path] = true
+		e.includes = append(e.includes, path)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i, l := range lines {
+		if nested, ok := includePath(l); ok {
+			if err := e.include(path, i+1, l, nested, top, append(stack, path)); err != nil {
+				return err
+			}
+			continue
+		}
+		e.lines = append(e.lines, l)
+		e.origins = append(e.origins, lineOrigin{file: path, line: i + 1, top: top})
+	}
+	return nil
+}
+
+// resolve returns the path of the file named by an @include of from.
+func (e *expansion) resolve(from, name string) string {
+	if e.fsys != nil {
+		// fs.FS paths are slash-separated and rooted at fsys
+		if strings.HasPrefix(name, "/") {
+			return path.Clean(name[1:])
+		}
+		return path.Join(path.Dir(from), name)
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(from), name)
+	}
+	return filepath.Clean(name)
+}
+
+func (e *expansion) readFile(name string) ([]byte, error) {
+	if e.fsys != nil {
+		return fs.ReadFile(e.fsys, name)
+	}
+	return os.ReadFile(name)
+}
+
+// errorAt builds the error of the @include directive raw, pointing at its
+// path.
+func (e *expansion) errorAt(file string, line int, raw string, code ErrorCode, message, suggestion string) *ParseError {
+	path, _ := includePath(raw)
+	return &ParseError{
+		Code:       code,
+		Filename:   file,
+		Line:       line,
+		Column:     columnOf(raw, path),
+		Message:    message,
+		Snippet:    raw,
+		Suggestion: suggestion,
+	}
+}
+
+// samePath reports whether a and b name the same file.
+func samePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
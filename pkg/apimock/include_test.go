@@ -0,0 +1,179 @@
+package apimock
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// writeFiles writes files, keyed by path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParser_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"users.apimock": `GET /users/{id}
+@include ./common/headers.apimock
+
+-- 200: OK
+ContentType: application/json
+
+{"id": 1}
+
+@include ./common/errors.apimock
+`,
+		"common/headers.apimock": "Accept: application/json\n",
+		"common/errors.apimock": `-- 404: Not Found
+ContentType: application/json
+
+{"error": "not found"}
+
+@include fatal.apimock
+`,
+		"common/fatal.apimock": "-- 500: Internal Server Error\n",
+	})
+
+	parser, err := NewParser(filepath.Join(dir, "users.apimock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if ast.Request.Properties["Accept"] != "application/json" {
+		t.Errorf("expected the included Accept property, got %v", ast.Request.Properties)
+	}
+	var codes []int
+	for _, resp := range ast.Responses {
+		codes = append(codes, resp.StatusCode)
+	}
+	if len(codes) != 3 || codes[0] != 200 || codes[1] != 404 || codes[2] != 500 {
+		t.Fatalf("expected responses 200, 404 and 500, got %v", codes)
+	}
+	if body := ast.Responses[1].Body; body != `{"error": "not found"}` {
+		t.Errorf("unexpected included body %q", body)
+	}
+
+	// Included sections point at the directive that brought them in
+	if lines := ast.Responses[1].Lines; lines.Start != 9 || lines.End != 9 {
+		t.Errorf("expected the 404 to span the @include line 9, got %+v", lines)
+	}
+	if lines := ast.Responses[0].Lines; lines.Start != 4 || lines.End != 7 {
+		t.Errorf("expected the 200 to span lines 4-7, got %+v", lines)
+	}
+
+	want := []string{filepath.Join(dir, "common", "headers.apimock"), filepath.Join(dir, "common", "errors.apimock"), filepath.Join(dir, "common", "fatal.apimock")}
+	if len(ast.Includes) != len(want) {
+		t.Fatalf("expected includes %v, got %v", want, ast.Includes)
+	}
+	for i := range want {
+		if ast.Includes[i] != want[i] {
+			t.Errorf("include %d: expected %s, got %s", i, want[i], ast.Includes[i])
+		}
+	}
+}
+
+func TestParser_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantCode   ErrorCode
+		wantFile   string
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:       "missing file",
+			files:      map[string]string{"main.apimock": "-- 200: OK\n\n@include  nope.apimock\n"},
+			wantCode:   CodeIncludeNotFound,
+			wantFile:   "main.apimock",
+			wantLine:   3,
+			wantColumn: 11,
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.apimock": "-- 200: OK\n\n@include a.apimock\n",
+				"a.apimock":    "@include main.apimock\n",
+			},
+			wantCode:   CodeIncludeCycle,
+			wantFile:   "a.apimock",
+			wantLine:   1,
+			wantColumn: 10,
+		},
+		{
+			name: "error inside the included file",
+			files: map[string]string{
+				"main.apimock":   "-- 200: OK\n\n@include errors.apimock\n",
+				"errors.apimock": "-- 404: Not Found\n\n-- 999: Weird\n",
+			},
+			wantCode:   CodeInvalidStatusCode,
+			wantFile:   "errors.apimock",
+			wantLine:   3,
+			wantColumn: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			parser, err := NewParser(filepath.Join(dir, "main.apimock"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parser.Parse()
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected a *ParseError, got %v", err)
+			}
+			if parseErr.Code != tt.wantCode || parseErr.Filename != filepath.Join(dir, tt.wantFile) || parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn {
+				t.Errorf("got %s at %s:%d:%d, want %s at %s:%d:%d", parseErr.Code, parseErr.Filename, parseErr.Line, parseErr.Column, tt.wantCode, tt.wantFile, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestParserFS_Include(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/users.apimock":      {Data: []byte("GET /users\n@include ../common/headers.apimock\n\n-- 200: OK\n\n@include /common/errors.apimock\n")},
+		"common/headers.apimock": {Data: []byte("Accept: application/json\n")},
+		"common/errors.apimock":  {Data: []byte("-- 404: Not Found\n")},
+	}
+
+	parser, err := NewParserFS(fsys, "api/users.apimock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if ast.Request.Properties["Accept"] != "application/json" || len(ast.Responses) != 2 {
+		t.Errorf("included sections were not parsed: %+v", ast)
+	}
+	want := []string{"common/headers.apimock", "common/errors.apimock"}
+	if !slices.Equal(ast.Includes, want) {
+		t.Errorf("Includes = %v, want %v", ast.Includes, want)
+	}
+
+	if _, err := NewParserFS(fsys, "api/missing.apimock"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
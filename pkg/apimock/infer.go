@@ -0,0 +1,216 @@
+package apimock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema version of inferred schemas.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema inferred from example documents. Type is a type
+// name, or a list of names when the examples disagree.
+type Schema struct {
+	Dialect    string             `json:"$schema,omitempty"`
+	Type       any                `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+
+	types []string
+}
+
+// ErrNoExamples is returned when there is no JSON example to infer a schema
+// from.
+var ErrNoExamples = errors.New("no JSON example to infer a schema from")
+
+// ErrHasSchema is returned when the request section already holds a JSON
+// Schema.
+var ErrHasSchema = errors.New("the request section already holds a JSON Schema")
+
+// InferSchema derives the JSON Schema the example documents satisfy. Object
+// fields present in every example are required, numbers without a fraction
+// are integers, and strings that are all RFC 3339 timestamps get the
+// date-time format.
+func InferSchema(examples ...[]byte) (*Schema, error) {
+	var schema *Schema
+	for i, example := range examples {
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(example))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("example %d is not JSON: %w", i+1, err)
+		}
+		schema = merge(schema, infer(doc))
+	}
+	if schema == nil {
+		return nil, ErrNoExamples
+	}
+	schema.Dialect = SchemaDialect
+	return schema, nil
+}
+
+// JSON renders the schema as indented JSON.
+func (s *Schema) JSON() string {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return string(data)
+}
+
+func infer(doc any) *Schema {
+	switch v := doc.(type) {
+	case map[string]any:
+		s := &Schema{types: []string{"object"}, Properties: make(map[string]*Schema)}
+		for key, value := range v {
+			s.Properties[key] = infer(value)
+			s.Required = append(s.Required, key)
+		}
+		sort.Strings(s.Required)
+		return s.finish()
+	case []any:
+		s := &Schema{types: []string{"array"}}
+		for _, item := range v {
+			s.Items = merge(s.Items, infer(item))
+		}
+		return s.finish()
+	case string:
+		s := &Schema{types: []string{"string"}}
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			s.Format = "date-time"
+		}
+		return s.finish()
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !strings.ContainsAny(v.String(), ".eE") {
+			return (&Schema{types: []string{"integer"}}).finish()
+		}
+		return (&Schema{types: []string{"number"}}).finish()
+	case bool:
+		return (&Schema{types: []string{"boolean"}}).finish()
+	default:
+		return (&Schema{types: []string{"null"}}).finish()
+	}
+}
+
+// merge returns the schema both a and b satisfy.
+func merge(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	s := &Schema{types: append(append([]string{}, a.types...), b.types...)}
+	if a.Format == b.Format || !b.has("string") {
+		s.Format = a.Format
+	} else if !a.has("string") {
+		s.Format = b.Format
+	}
+
+	if a.Properties != nil || b.Properties != nil {
+		s.Properties = make(map[string]*Schema)
+		for key, prop := range a.Properties {
+			s.Properties[key] = prop
+		}
+		for key, prop := range b.Properties {
+			s.Properties[key] = merge(s.Properties[key], prop)
+		}
+		// Fields are only required when every object example has them
+		switch {
+		case a.Properties == nil:
+			s.Required = b.Required
+		case b.Properties == nil:
+			s.Required = a.Required
+		default:
+			for _, key := range a.Required {
+				if slices.Contains(b.Required, key) {
+					s.Required = append(s.Required, key)
+				}
+			}
+		}
+	}
+	s.Items = merge(a.Items, b.Items)
+	return s.finish()
+}
+
+// finish sorts and deduplicates the types of the schema and sets Type.
+func (s *Schema) finish() *Schema {
+	sort.Strings(s.types)
+	types := slices.Compact(s.types)
+	// An integer example does not narrow a number field
+	if slices.Contains(types, "number") {
+		types = slices.DeleteFunc(types, func(t string) bool { return t == "integer" })
+	}
+	s.types = types
+	if len(types) == 1 {
+		s.Type = types[0]
+	} else {
+		s.Type = types
+	}
+	return s
+}
+
+func (s *Schema) has(t string) bool {
+	return slices.Contains(s.types, t)
+}
+
+// schemaTypes are the type names of JSON Schema, which tell a schema from an
+// example document whose own "type" field holds some other value.
+var schemaTypes = map[string]bool{"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true}
+
+// IsJSONSchema reports whether body is a JSON Schema rather than an example
+// document: an object with a $schema keyword, or a type keyword naming a
+// JSON Schema type.
+func IsJSONSchema(body string) bool {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return false
+	}
+	if _, ok := doc["$schema"]; ok {
+		return true
+	}
+	switch t := doc["type"].(type) {
+	case string:
+		return schemaTypes[t]
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); !ok || !schemaTypes[s] {
+				return false
+			}
+		}
+		return len(t) > 0
+	}
+	return false
+}
+
+// InferRequestSchema derives the schema of the request body of the file
+// from its examples: the request body, when it holds an example document
+// rather than a schema, or else the JSON bodies of the 2xx responses. It
+// does not change the file.
+func (f *APIMockFile) InferRequestSchema() (*Schema, error) {
+	if f.Request != nil && strings.TrimSpace(f.Request.BodySchema) != "" {
+		if IsJSONSchema(f.Request.BodySchema) {
+			return nil, ErrHasSchema
+		}
+		return InferSchema([]byte(f.Request.BodySchema))
+	}
+
+	var examples [][]byte
+	for _, resp := range f.Responses {
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			continue
+		}
+		if json.Valid([]byte(resp.Body)) {
+			examples = append(examples, []byte(resp.Body))
+		}
+	}
+	return InferSchema(examples...)
+}
@@ -0,0 +1,141 @@
+package apimock
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		examples []string
+		want     string
+	}{
+		{
+			name:     "object",
+			examples: []string{`{"id": 1, "name": "Ana", "score": 1.5, "active": true, "at": "2024-01-01T00:00:00Z"}`},
+			want:     `{"type":"object","properties":{"active":{"type":"boolean"},"at":{"type":"string","format":"date-time"},"id":{"type":"integer"},"name":{"type":"string"},"score":{"type":"number"}},"required":["active","at","id","name","score"]}`,
+		},
+		{
+			name:     "fields missing from an example are optional",
+			examples: []string{`{"id": 1, "nick": "a"}`, `{"id": 2}`},
+			want:     `{"type":"object","properties":{"id":{"type":"integer"},"nick":{"type":"string"}},"required":["id"]}`,
+		},
+		{
+			name:     "conflicting types",
+			examples: []string{`{"v": 1}`, `{"v": 1.5}`, `{"v": null}`},
+			want:     `{"type":"object","properties":{"v":{"type":["null","number"]}},"required":["v"]}`,
+		},
+		{
+			name:     "array items are merged",
+			examples: []string{`[{"id": 1, "tag": "a"}, {"id": 2}]`},
+			want:     `{"type":"array","items":{"type":"object","properties":{"id":{"type":"integer"},"tag":{"type":"string"}},"required":["id"]}}`,
+		},
+		{
+			name:     "format is dropped when a string does not have it",
+			examples: []string{`"2024-01-01T00:00:00Z"`, `"soon"`},
+			want:     `{"type":"string"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var examples [][]byte
+			for _, e := range tt.examples {
+				examples = append(examples, []byte(e))
+			}
+			schema, err := InferSchema(examples...)
+			if err != nil {
+				t.Fatalf("InferSchema() error = %v", err)
+			}
+			if schema.Dialect != SchemaDialect {
+				t.Errorf("Dialect = %q, want %q", schema.Dialect, SchemaDialect)
+			}
+			schema.Dialect = ""
+			got, _ := json.Marshal(schema)
+			if string(got) != tt.want {
+				t.Errorf("InferSchema() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferSchema_Errors(t *testing.T) {
+	if _, err := InferSchema(); !errors.Is(err, ErrNoExamples) {
+		t.Errorf("InferSchema() error = %v, want ErrNoExamples", err)
+	}
+	if _, err := InferSchema([]byte("{not json")); err == nil {
+		t.Error("InferSchema() error = nil for invalid JSON")
+	}
+}
+
+func TestIsJSONSchema(t *testing.T) {
+	tests := map[string]bool{
+		`{"$schema": "https://json-schema.org/draft/2020-12/schema"}`: true,
+		`{"type": "object", "properties": {}}`:                        true,
+		`{"type": ["string", "null"]}`:                                true,
+		`{"type": "premium", "id": 1}`:                                false,
+		`{"name": "Ana"}`:                                             false,
+		`[1, 2]`:                                                      false,
+		`<user/>`:                                                     false,
+	}
+	for body, want := range tests {
+		if got := IsJSONSchema(body); got != want {
+			t.Errorf("IsJSONSchema(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+func TestAPIMockFile_InferRequestSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "request example",
+			src:  "POST /users\n\n{\"name\": \"Ana\"}\n\n-- 201: Created\n\n{\"id\": 1}\n",
+			want: []string{"name"},
+		},
+		{
+			name: "2xx responses",
+			src:  "GET /users/{id}\n\n-- 200: OK\n\n{\"id\": 1, \"name\": \"Ana\"}\n\n-- 200: Short\n\n{\"id\": 2}\n\n-- 404: Not Found\n\n{\"error\": \"missing\"}\n",
+			want: []string{"id"},
+		},
+		{
+			name:    "request schema",
+			src:     "POST /users\n\n{\"type\": \"object\"}\n\n-- 201: Created\n",
+			wantErr: ErrHasSchema,
+		},
+		{
+			name:    "no JSON examples",
+			src:     "GET /health\n\n-- 200: OK\n\nup\n",
+			wantErr: ErrNoExamples,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewParserFromBytes("test.apimock", []byte(tt.src)).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			schema, err := f.InferRequestSchema()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("InferRequestSchema() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InferRequestSchema() error = %v", err)
+			}
+			if !reflect.DeepEqual(schema.Required, tt.want) {
+				t.Errorf("Required = %v, want %v", schema.Required, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package apimock
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors reported for JSON Web Tokens.
+var (
+	ErrJWTMalformed = errors.New("malformed JWT")
+	ErrJWTSignature = errors.New("invalid JWT signature")
+)
+
+// DecodeJWT returns the claims of a compact serialized JWT without verifying
+// its signature.
+func DecodeJWT(token string) (map[string]any, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// VerifyJWT checks that a compact serialized JWT is signed with HS256 and
+// secret.
+func VerifyJWT(token, secret string) error {
+	header, _, _ := strings.Cut(token, ".")
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return ErrJWTMalformed
+	}
+	if h.Alg != "HS256" {
+		return fmt.Errorf("%w: expected HS256, got %q", ErrJWTSignature, h.Alg)
+	}
+
+	i := strings.LastIndex(token, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return ErrJWTSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token[:i]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return ErrJWTSignature
+	}
+	return nil
+}
+
+// JWTExpired reports whether the exp claim of claims is past at now. Tokens
+// without one never expire.
+func JWTExpired(claims map[string]any, now time.Time) bool {
+	exp, ok := claims["exp"].(float64)
+	return ok && now.Unix() >= int64(exp)
+}
+
+// jwtClaims decodes the JWT of an Authorization header value, such as
+// "Bearer eyJ...", or of the token alone, returning it along with its claims.
+func jwtClaims(value string) (string, map[string]any, error) {
+	token := strings.TrimSpace(value)
+	if scheme, rest, ok := strings.Cut(token, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(rest)
+	}
+	claims, ok := DecodeJWT(token)
+	if !ok {
+		return "", nil, ErrJWTMalformed
+	}
+	return token, claims, nil
+}
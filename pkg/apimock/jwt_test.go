@@ -0,0 +1,58 @@
+package apimock
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signHS256(claims, secret string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestCallFunction_JWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid := signHS256(`{"sub": "ana", "exp": 1700003600}`, "s3cr3t")
+	expired := signHS256(`{"sub": "ana", "exp": 1699999999}`, "s3cr3t")
+
+	tests := []struct {
+		name string
+		args []any
+		want any
+	}{
+		{name: "jwt_decode", args: []any{"Bearer " + valid}, want: map[string]any{"sub": "ana", "exp": 1700003600.0}},
+		{name: "jwt_decode", args: []any{valid}, want: map[string]any{"sub": "ana", "exp": 1700003600.0}},
+		{name: "jwt_valid", args: []any{"Bearer " + valid, "s3cr3t"}, want: true},
+		{name: "jwt_valid", args: []any{"Bearer " + valid, "other"}, want: false},
+		{name: "jwt_valid", args: []any{"Bearer not-a-jwt", "s3cr3t"}, want: false},
+		{name: "jwt_expired", args: []any{"Bearer " + valid}, want: false},
+		{name: "jwt_expired", args: []any{"bearer " + expired}, want: true},
+		{name: "jwt_expired", args: []any{signHS256(`{"sub": "ana"}`, "s3cr3t")}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CallFunctionAt(now, tt.name, tt.args...)
+			if err != nil {
+				t.Fatalf("CallFunctionAt() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CallFunctionAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, name := range []string{"jwt_decode", "jwt_expired"} {
+		if _, err := CallFunction(name, "Bearer not-a-jwt"); err == nil || !strings.Contains(err.Error(), "malformed JWT") {
+			t.Errorf("CallFunction(%s) error = %v, want a malformed JWT", name, err)
+		}
+	}
+}
@@ -21,7 +21,8 @@ const (
 	TokenQueryParam
 	// TokenHeader represents an HTTP header (key: value)
 	TokenHeader
-	// TokenResponseStart represents the start of a response section (-- code: description)
+	// TokenResponseStart represents the start of a response section (-- code: description
+	// or -- proxy: URL)
 	TokenResponseStart
 	// TokenBodyLine represents a line of body content (request or response)
 	TokenBodyLine
@@ -45,28 +46,40 @@ type Token struct {
 	// Query param
 	Key   string
 	Value string
+	// Required marks query params and headers written with a ! after the
+	// key, as in ?limit!=10 or Authorization!: Bearer token
+	Required bool
 
 	// Response start
 	StatusCode  int
 	Description string
+	Upstream    string // URL of a proxy section, whose StatusCode is 0
 }
 
+// pathParamPattern matches a path parameter such as {id}, optionally
+// constrained by a regular expression as in {id:[0-9]+}. The constraint may
+// not contain slashes or whitespace, and braces only as {n,m} quantifiers.
+const pathParamPattern = `\{[a-zA-Z0-9_.\-]+(?::(?:[^{}/\s]|\{[0-9,]+\})+)?\}`
+
 // Regular expressions used by the lexer for pattern matching
 var (
 	// httpMethodRegex matches HTTP method verbs at the start of a line
 	httpMethodRegex = regexp.MustCompile(`^(GET|POST|PUT|DELETE|PATCH|HEAD|OPTIONS|TRACE|CONNECT)\s`)
 	// pathStartRegex matches URL paths starting with /
-	pathStartRegex = regexp.MustCompile(`^(/[a-zA-Z0-9_.\-{}]+)+`)
+	pathStartRegex = regexp.MustCompile(`^(/(` + pathParamPattern + `|\*|[a-zA-Z0-9_.\-{}]+))+`)
 	// pathSegmentRegex matches individual path segments including parameters
-	pathSegmentRegex = regexp.MustCompile(`/([a-zA-Z0-9_.\-]+|\{[a-zA-Z0-9_.\-]+\})`)
+	pathSegmentRegex = regexp.MustCompile(`/(` + pathParamPattern + `|\*|[a-zA-Z0-9_.\-]+)`)
 	// pathContRegex matches path continuations on indented lines
-	pathContRegex = regexp.MustCompile(`^\s+(/[a-zA-Z0-9_.\-{}]+|\?[a-zA-Z0-9_.\-]+=\S+|&[a-zA-Z0-9_.\-]+=\S+)`)
+	pathContRegex = regexp.MustCompile(`^\s+(/(?:` + pathParamPattern + `|\*|[a-zA-Z0-9_.\-{}]+)|\?[a-zA-Z0-9_.\-]+!?=\S+|&[a-zA-Z0-9_.\-]+!?=\S+)`)
 	// queryParamRegex extracts key-value pairs from query parameters
-	queryParamRegex = regexp.MustCompile(`([a-zA-Z0-9_.\-]+)=(\S+)`)
+	queryParamRegex = regexp.MustCompile(`([a-zA-Z0-9_.\-]+)(!?)=(\S+)`)
 	// responseLineCaptureRegex matches response start lines (-- 200: Description)
 	responseLineCaptureRegex = regexp.MustCompile(`^--\s*(\d{3}):\s*(.*)`)
-	// propertyCaptureRegex matches header-like properties (Key: Value)
-	propertyCaptureRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9_.\-]*):\s*(.+)`)
+	// proxyLineCaptureRegex matches proxy section start lines (-- proxy: https://api.example.com)
+	proxyLineCaptureRegex = regexp.MustCompile(`^--\s*proxy:\s*(\S+)\s*$`)
+	// propertyCaptureRegex matches header-like properties (Key: Value or
+	// Key!: Value)
+	propertyCaptureRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9_.\-]*)(!?):\s*(.+)`)
 )
 
 // Lexer reads .apimock file lines and produces a stream of tokens.
@@ -112,9 +125,15 @@ func (l *Lexer) Lex() ([]Token, error) {
 			continue
 		}
 
+		// Proxy line
+		if m := proxyLineCaptureRegex.FindStringSubmatch(line); m != nil {
+			tokens = append(tokens, Token{Type: TokenResponseStart, Line: i + 1, Raw: line, Upstream: m[1]})
+			continue
+		}
+
 		// Header property
 		if m := propertyCaptureRegex.FindStringSubmatch(line); m != nil {
-			tokens = append(tokens, Token{Type: TokenHeader, Line: i + 1, Raw: line, Key: m[1], Value: strings.TrimSpace(m[2])})
+			tokens = append(tokens, Token{Type: TokenHeader, Line: i + 1, Raw: line, Key: m[1], Value: strings.TrimSpace(m[3]), Required: m[2] != ""})
 			continue
 		}
 
@@ -129,8 +148,8 @@ func (l *Lexer) Lex() ([]Token, error) {
 			if strings.HasPrefix(cont, "?") || strings.HasPrefix(cont, "&") {
 				pairs := queryParamRegex.FindAllStringSubmatch(cont[1:], -1)
 				for _, pair := range pairs {
-					if len(pair) >= 3 {
-						tokens = append(tokens, Token{Type: TokenQueryParam, Line: i + 1, Raw: line, Key: pair[1], Value: pair[2]})
+					if len(pair) >= 4 {
+						tokens = append(tokens, Token{Type: TokenQueryParam, Line: i + 1, Raw: line, Key: pair[1], Value: pair[3], Required: pair[2] != ""})
 					}
 				}
 				continue
@@ -163,15 +182,22 @@ func (l *Lexer) Lex() ([]Token, error) {
 }
 
 // parsePathSegments parses a path string into PathSegment tokens.
-// It identifies both static segments and parameter placeholders (e.g., {id}).
+// It identifies static segments, parameter placeholders (e.g., {id} or
+// {id:[0-9]+}) and wildcards (*).
 func parsePathSegments(path string) []PathSegment {
 	segments := make([]PathSegment, 0)
 	matches := pathSegmentRegex.FindAllStringSubmatch(path, -1)
 	for _, match := range matches {
 		seg := match[1]
-		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
-			name := seg[1 : len(seg)-1]
-			segments = append(segments, PathSegment{Value: seg, IsParameter: true, Name: name})
+		if seg == "*" {
+			segments = append(segments, PathSegment{Value: seg, IsWildcard: true})
+		} else if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			name, pattern, _ := strings.Cut(seg[1:len(seg)-1], ":")
+			typ := ""
+			if typePattern, ok := paramTypePatterns[pattern]; ok {
+				typ, pattern = pattern, typePattern
+			}
+			segments = append(segments, PathSegment{Value: seg, IsParameter: true, Name: name, Pattern: pattern, Type: typ})
 		} else {
 			segments = append(segments, PathSegment{Value: seg, IsParameter: false})
 		}
@@ -104,6 +104,56 @@ func TestLexer_PathWithParameters(t *testing.T) {
 	}
 }
 
+func TestLexer_PathPatternsAndWildcards(t *testing.T) {
+	lines := []string{"GET /orders/{id:[0-9]{2,4}}/files/*"}
+	lexer := NewLexer(lines)
+	tokens, err := lexer.Lex()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok := tokens[0]
+
+	if tok.Path != "/orders/{id:[0-9]{2,4}}/files/*" {
+		t.Fatalf("expected full path, got %s", tok.Path)
+	}
+	if len(tok.PathSegments) != 4 {
+		t.Fatalf("expected 4 path segments, got %d", len(tok.PathSegments))
+	}
+
+	id := tok.PathSegments[1]
+	if !id.IsParameter || id.Name != "id" || id.Pattern != "[0-9]{2,4}" {
+		t.Errorf("expected id parameter with pattern, got %+v", id)
+	}
+	if wildcard := tok.PathSegments[3]; !wildcard.IsWildcard || wildcard.IsParameter {
+		t.Errorf("expected wildcard segment, got %+v", wildcard)
+	}
+}
+
+func TestLexer_TypedPathParameters(t *testing.T) {
+	segments := parsePathSegments("/users/{id:int}/posts/{slug:string}/{ref:uuid}")
+	tests := []struct {
+		index   int
+		name    string
+		typ     string
+		pattern string
+	}{
+		{index: 1, name: "id", typ: ParamTypeInt, pattern: paramTypePatterns[ParamTypeInt]},
+		{index: 3, name: "slug", typ: ParamTypeString, pattern: paramTypePatterns[ParamTypeString]},
+		{index: 4, name: "ref", typ: ParamTypeUUID, pattern: paramTypePatterns[ParamTypeUUID]},
+	}
+	for _, tt := range tests {
+		seg := segments[tt.index]
+		if !seg.IsParameter || seg.Name != tt.name || seg.Type != tt.typ || seg.Pattern != tt.pattern {
+			t.Errorf("segment %d = %+v, want %s of type %s", tt.index, seg, tt.name, tt.typ)
+		}
+	}
+
+	// A type name inside a longer pattern stays a regular expression
+	if seg := parsePathSegments("/{id:int|str}")[0]; seg.Type != "" || seg.Pattern != "int|str" {
+		t.Errorf("expected an untyped pattern, got %+v", seg)
+	}
+}
+
 func TestLexer_QueryParams(t *testing.T) {
 	lines := []string{
 		"GET /api/search",
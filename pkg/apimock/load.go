@@ -0,0 +1,78 @@
+package apimock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/pretodev/anansi-proxy/internal/discovery"
+)
+
+// ParseBytes parses .apimock content that is already in memory. The filename
+// names the content in error messages, and relative @include paths are
+// resolved from its directory.
+func ParseBytes(filename string, content []byte) (*APIMockFile, error) {
+	return NewParserFromBytes(filename, content).Parse()
+}
+
+// ParseString parses .apimock source, as ParseBytes does.
+func ParseString(filename, source string) (*APIMockFile, error) {
+	return ParseBytes(filename, []byte(source))
+}
+
+// ParseReader parses the .apimock content read from r, as ParseBytes does.
+func ParseReader(filename string, r io.Reader) (*APIMockFile, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", filename, err)
+	}
+	return ParseBytes(filename, content)
+}
+
+// ParseFS parses the .apimock file name of fsys, such as an embed.FS, reading
+// the files it includes from fsys too.
+func ParseFS(fsys fs.FS, name string) (*APIMockFile, error) {
+	parser, err := NewParserFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse()
+}
+
+// ParseDir parses the .apimock files of fsys under dir, searched recursively
+// and skipping directories such as .git and node_modules, keyed by their
+// slash-separated path in fsys. Files included by another file are
+// fragments and are left out. Files that fail to parse are left out too, and
+// their errors are returned joined, along with the files that parsed.
+func ParseDir(fsys fs.FS, dir string) (map[string]*APIMockFile, error) {
+	names, err := discovery.FindAPIMockFilesFS(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*APIMockFile, len(names))
+	failed := make(map[string]error)
+	included := make(map[string]bool)
+	for _, name := range names {
+		file, err := ParseFS(fsys, name)
+		if err != nil {
+			failed[name] = err
+			continue
+		}
+		files[name] = file
+		for _, path := range file.Includes {
+			included[path] = true
+		}
+	}
+
+	var errs []error
+	for _, name := range names {
+		if included[name] {
+			delete(files, name)
+		} else if err, ok := failed[name]; ok {
+			errs = append(errs, err)
+		}
+	}
+	return files, errors.Join(errs...)
+}
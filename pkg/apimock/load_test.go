@@ -0,0 +1,70 @@
+package apimock
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+func TestParseBytesStringReader(t *testing.T) {
+	const source = "GET /ping\n\n-- 200: OK\n\npong\n"
+
+	parsers := map[string]func() (*APIMockFile, error){
+		"ParseBytes":  func() (*APIMockFile, error) { return ParseBytes("ping.apimock", []byte(source)) },
+		"ParseString": func() (*APIMockFile, error) { return ParseString("ping.apimock", source) },
+		"ParseReader": func() (*APIMockFile, error) { return ParseReader("ping.apimock", strings.NewReader(source)) },
+	}
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			file, err := parse()
+			if err != nil {
+				t.Fatalf("%s() error = %v", name, err)
+			}
+			if file.Request.Path != "/ping" || len(file.Responses) != 1 || file.Responses[0].Body != "pong" {
+				t.Errorf("%s() = %+v", name, file)
+			}
+		})
+	}
+
+	if _, err := ParseString("broken.apimock", "GET /ping\n"); err == nil || !strings.Contains(err.Error(), "broken.apimock") {
+		t.Errorf("ParseString() error = %v, want one naming the file", err)
+	}
+	if _, err := ParseReader("ping.apimock", iotest.ErrReader(errors.New("closed"))); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("ParseReader() error = %v, want the read error", err)
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mocks/users.apimock":          {Data: []byte("GET /users\n\n-- 200: OK\n\n@include common/errors.apimock\n")},
+		"mocks/orders/list.apimock":    {Data: []byte("GET /orders\n\n-- 200: OK\n")},
+		"mocks/common/errors.apimock":  {Data: []byte("-- 404: Not Found\n")},
+		"mocks/broken.apimock":         {Data: []byte("GET /broken\n\n-- 999: Nope\n")},
+		"mocks/node_modules/x.apimock": {Data: []byte("GET /ignored\n\n-- 200: OK\n")},
+	}
+
+	files, err := ParseDir(fsys, "mocks")
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Filename != "mocks/broken.apimock" {
+		t.Errorf("ParseDir() error = %v, want the error of mocks/broken.apimock", err)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"mocks/orders/list.apimock", "mocks/users.apimock"}; !slices.Equal(names, want) {
+		t.Errorf("ParseDir() parsed %v, want %v", names, want)
+	}
+	if users := files["mocks/users.apimock"]; users == nil || len(users.Responses) != 2 {
+		t.Errorf("Expected the included responses in users.apimock, got %+v", users)
+	}
+
+	if _, err := ParseDir(fsys, "missing"); err == nil {
+		t.Error("ParseDir() of a missing directory succeeded")
+	}
+}
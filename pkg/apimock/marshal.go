@@ -0,0 +1,159 @@
+package apimock
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Marshal renders the file as .apimock source. Query parameters and
+// properties are written in alphabetical order, so parsing the output yields
+// an equal APIMockFile. Sections pulled in with @include and ${NAME}
+// references are written as they were expanded, and a literal ${ is escaped
+// as $${. It returns an error for values the syntax cannot hold, such as a
+// property with a line break or a body line that would start a new section.
+func (f *APIMockFile) Marshal() ([]byte, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if err := f.checkMarshalable(); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if f.Request != nil {
+		writeRequest(&b, f.Request)
+		b.WriteString("\n")
+	}
+	for i, resp := range f.Responses {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeResponse(&b, resp)
+	}
+	return []byte(b.String()), nil
+}
+
+func writeRequest(b *strings.Builder, r *RequestSection) {
+	if r.Method != "" {
+		b.WriteString(r.Method + " ")
+	}
+	b.WriteString(escapeEnv(r.Path) + "\n")
+
+	for i, key := range sortedKeys(r.QueryParams) {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		fmt.Fprintf(b, "  %s%s%s=%s\n", sep, key, requiredMarker(r.RequiredQuery, key), escapeEnv(r.QueryParams[key]))
+	}
+	writeProperties(b, r.Properties, r.RequiredProperties)
+
+	if r.BodySchema != "" {
+		b.WriteString("\n" + escapeEnv(r.BodySchema) + "\n")
+	}
+}
+
+func writeResponse(b *strings.Builder, r ResponseSection) {
+	if r.Upstream != "" {
+		fmt.Fprintf(b, "-- proxy: %s", escapeEnv(r.Upstream))
+	} else {
+		fmt.Fprintf(b, "-- %d:", r.StatusCode)
+		if r.Description != "" {
+			b.WriteString(" " + escapeEnv(r.Description))
+		}
+	}
+	b.WriteString("\n")
+	writeProperties(b, r.Properties, nil)
+
+	switch {
+	case strings.HasPrefix(r.Body, ConditionPrefix):
+		// Condition lines stay right below the properties, as they are written
+		b.WriteString(escapeEnv(r.Body) + "\n")
+	case r.Body != "":
+		b.WriteString("\n" + escapeEnv(r.Body) + "\n")
+	}
+}
+
+func writeProperties(b *strings.Builder, properties map[string]string, required []string) {
+	for _, key := range sortedKeys(properties) {
+		fmt.Fprintf(b, "%s%s: %s\n", key, requiredMarker(required, key), escapeEnv(properties[key]))
+	}
+}
+
+// escapeEnv escapes the ${ of s as $${, so that parsing does not read it as
+// an environment variable reference.
+func escapeEnv(s string) string {
+	return strings.ReplaceAll(s, "${", "$${")
+}
+
+// checkMarshalable reports the values of the file that Marshal cannot write
+// as source that parses back to them.
+func (f *APIMockFile) checkMarshalable() error {
+	if r := f.Request; r != nil {
+		for _, key := range sortedKeys(r.QueryParams) {
+			if strings.ContainsAny(r.QueryParams[key], "\r\n") {
+				return fmt.Errorf("cannot marshal query parameter %s: its value has a line break", key)
+			}
+		}
+		if err := checkProperties(r.Properties); err != nil {
+			return fmt.Errorf("cannot marshal request: %w", err)
+		}
+		if err := checkBody(r.BodySchema); err != nil {
+			return fmt.Errorf("cannot marshal request body: %w", err)
+		}
+	}
+	for i, resp := range f.Responses {
+		if strings.ContainsAny(resp.Description, "\r\n") {
+			return fmt.Errorf("cannot marshal response %d: its description has a line break", i+1)
+		}
+		if err := checkProperties(resp.Properties); err != nil {
+			return fmt.Errorf("cannot marshal response %d: %w", i+1, err)
+		}
+		if err := checkBody(resp.Body); err != nil {
+			return fmt.Errorf("cannot marshal response %d body: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func checkProperties(properties map[string]string) error {
+	for _, key := range sortedKeys(properties) {
+		if strings.ContainsAny(properties[key], "\r\n") {
+			return fmt.Errorf("property %s has a line break", key)
+		}
+	}
+	return nil
+}
+
+// checkBody reports the first line of body that parsing would read as the
+// start of a section or as an @include directive.
+func checkBody(body string) error {
+	for i, line := range strings.Split(body, "\n") {
+		if responseLineCaptureRegex.MatchString(line) || proxyLineCaptureRegex.MatchString(line) {
+			return fmt.Errorf("line %d would start a new section: %q", i+1, line)
+		}
+		if _, ok := includePath(line); ok {
+			return fmt.Errorf("line %d would be read as an %s directive: %q", i+1, IncludeDirective, line)
+		}
+	}
+	return nil
+}
+
+// requiredMarker returns the ! written after a required key.
+func requiredMarker(required []string, key string) string {
+	if slices.Contains(required, key) {
+		return "!"
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,181 @@
+package apimock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAPIMockFile_Marshal(t *testing.T) {
+	f := NewAPIMockFile()
+	f.Request = NewRequestSection()
+	f.Request.Method = "GET"
+	f.Request.Path = "/users/{id}"
+	f.Request.PathSegments = parsePathSegments(f.Request.Path)
+	f.Request.QueryParams["sort"] = "name"
+	f.Request.QueryParams["page"] = "1"
+	f.Request.Properties["Accept"] = "application/json"
+	f.Request.Properties["Authorization"] = "Bearer token"
+	f.Request.RequiredQuery = []string{"page"}
+	f.Request.RequiredProperties = []string{"Authorization"}
+
+	ok := NewResponseSection()
+	ok.StatusCode = 200
+	ok.Description = "User found"
+	ok.Properties["ContentType"] = "application/json"
+	ok.Body = "{\n  \"id\": 1\n}"
+	missing := NewResponseSection()
+	missing.StatusCode = 404
+	f.Responses = append(f.Responses, ok, missing)
+
+	got, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `GET /users/{id}
+  ?page!=1
+  &sort=name
+Accept: application/json
+Authorization!: Bearer token
+
+-- 200: User found
+ContentType: application/json
+
+{
+  "id": 1
+}
+
+-- 404:
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant:\n%s", got, want)
+	}
+
+	parsed, err := NewParserFromBytes("users.apimock", got).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	f.Request.Lines = LineRange{Start: 1, End: 5}
+	f.Responses[0].Lines = LineRange{Start: 7, End: 12}
+	f.Responses[1].Lines = LineRange{Start: 14, End: 14}
+	if !reflect.DeepEqual(parsed, f) {
+		t.Errorf("expected marshaled file to parse back unchanged\ngot:  %+v\nwant: %+v", parsed, f)
+	}
+}
+
+func TestAPIMockFile_Marshal_Proxy(t *testing.T) {
+	f := NewAPIMockFile()
+	proxy := NewResponseSection()
+	proxy.Upstream = "https://api.example.com"
+	proxy.Body = "sandbox => mock"
+	f.Responses = append(f.Responses, proxy)
+
+	got, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "-- proxy: https://api.example.com\n\nsandbox => mock\n"; string(got) != want {
+		t.Errorf("Marshal() = %q, want %q", got, want)
+	}
+}
+
+func TestAPIMockFile_Marshal_Invalid(t *testing.T) {
+	if _, err := NewAPIMockFile().Marshal(); err == nil {
+		t.Error("expected error for file without responses")
+	}
+}
+
+func TestAPIMockFile_Marshal_KeepsConditionsBelowProperties(t *testing.T) {
+	source := `-- 429: Too Many Requests
+ContentType: application/json
+> call_count > 5
+
+{"error": "slow down"}
+`
+	f, err := NewParserFromBytes("limits.apimock", []byte(source)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	got, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(got) != source {
+		t.Errorf("Marshal() =\n%s\nwant:\n%s", got, source)
+	}
+}
+
+func TestAPIMockFile_Marshal_RoundTrip(t *testing.T) {
+	t.Setenv("API_TOKEN", "secret")
+
+	source := `POST /orders/{id:int}
+  ?dry_run=false
+Authorization!: Bearer ${API_TOKEN}
+ContentType: application/json
+
+{"item": "string"}
+
+-- 201: Created
+> body.item == "book"
+
+{"id": 1, "price": "$${amount}"}
+
+-- 400: Bad Request
+
+-- proxy: https://api.example.com
+`
+	f, err := NewParserFromBytes("orders.apimock", []byte(source)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	got, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	parsed, err := NewParserFromBytes("orders.apimock", got).Parse()
+	if err != nil {
+		t.Fatalf("Parse() of the marshaled file error = %v\n%s", err, got)
+	}
+	parsed.Request.Lines, f.Request.Lines = LineRange{}, LineRange{}
+	for i := range f.Responses {
+		parsed.Responses[i].Lines, f.Responses[i].Lines = LineRange{}, LineRange{}
+	}
+	parsed.Env, f.Env = nil, nil
+	if !reflect.DeepEqual(parsed, f) {
+		t.Errorf("expected marshaled file to parse back unchanged\ngot:  %+v\nwant: %+v\n%s", parsed, f, got)
+	}
+	if f.Responses[0].Body != "> body.item == \"book\"\n\n{\"id\": 1, \"price\": \"${amount}\"}" {
+		t.Errorf("Body = %q", f.Responses[0].Body)
+	}
+}
+
+func TestAPIMockFile_Marshal_Unrepresentable(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(r *ResponseSection)
+		want string
+	}{
+		{name: "section line in body", edit: func(r *ResponseSection) { r.Body = "a\n-- 404: Not Found" }, want: "line 2 would start a new section"},
+		{name: "include in body", edit: func(r *ResponseSection) { r.Body = "@include errors.apimock" }, want: "@include directive"},
+		{name: "line break in property", edit: func(r *ResponseSection) { r.Properties["X-Note"] = "a\nb" }, want: "property X-Note has a line break"},
+		{name: "line break in description", edit: func(r *ResponseSection) { r.Description = "a\nb" }, want: "description has a line break"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewAPIMockFile()
+			resp := NewResponseSection()
+			resp.StatusCode = 200
+			tt.edit(&resp)
+			f.Responses = append(f.Responses, resp)
+
+			_, err := f.Marshal()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Marshal() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package apimock
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +16,11 @@ type Parser struct {
 	lines    []string
 	lineNum  int
 	errors   []string
+	// origins maps the lines of the source, once includes are expanded, to
+	// the files and lines they come from
+	origins []lineOrigin
+	// fsys holds the included files, nil for the operating system's
+	fsys fs.FS
 }
 
 // NewParser creates a new parser for a .apimock file.
@@ -24,13 +32,33 @@ func NewParser(filename string) (*Parser, error) {
 		return nil, err
 	}
 
+	return NewParserFromBytes(filename, content), nil
+}
+
+// NewParserFS creates a parser for the .apimock file name of fsys, such as an
+// embed.FS. Files pulled in with @include are read from fsys too, with
+// slash-separated paths relative to the including file.
+func NewParserFS(fsys fs.FS, name string) (*Parser, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewParserFromBytes(name, content)
+	p.fsys = fsys
+	return p, nil
+}
+
+// NewParserFromBytes creates a parser for .apimock content that is already in
+// memory. The filename is only used in error messages.
+func NewParserFromBytes(filename string, content []byte) *Parser {
 	lines := strings.Split(string(content), "\n")
 	return &Parser{
 		filename: filename,
 		lines:    lines,
 		lineNum:  0,
 		errors:   make([]string, 0),
-	}, nil
+	}
 }
 
 // Parse parses the file and returns the AST.
@@ -40,7 +68,24 @@ func NewParser(filename string) (*Parser, error) {
 func (p *Parser) Parse() (*APIMockFile, error) {
 	ast := NewAPIMockFile()
 
-	lexer := NewLexer(p.lines)
+	lines, origins, includes, err := p.expand()
+	if err != nil {
+		return nil, err
+	}
+	p.origins = origins
+	ast.Includes = includes
+
+	env, envErrs := p.interpolate(lines)
+	switch len(envErrs) {
+	case 0:
+	case 1:
+		return nil, envErrs[0]
+	default:
+		return nil, envErrs
+	}
+	ast.Env = env
+
+	lexer := NewLexer(lines)
 	tokens, err := lexer.Lex()
 	if err != nil {
 		return nil, err
@@ -67,10 +112,17 @@ func (p *Parser) Parse() (*APIMockFile, error) {
 
 	// At least one response section required
 	if i >= len(tokens) || tokens[i].Type != TokenResponseStart {
-		return nil, NewParseError(p.filename, 0, "expected at least one response section (format: -- CODE: Description)")
+		err := NewParseError(p.filename, 0, "expected at least one response section (format: -- CODE: Description)")
+		err.Code, err.Suggestion = CodeMissingResponse, "add a response such as -- 200: OK"
+		if i < len(tokens) {
+			err = p.errorAt(tokens[i], CodeMissingResponse, 1, "expected a response section (format: -- CODE: Description)", "start the response with a line such as -- 200: OK")
+		}
+		return nil, err
 	}
 
-	// Parse all response sections
+	// Parse all response sections. A broken section is skipped up to the next
+	// one, so every problem of the file is reported at once.
+	var errs ParseErrors
 	for i < len(tokens) {
 		// Skip blanks
 		for i < len(tokens) && tokens[i].Type == TokenBlankLine {
@@ -84,13 +136,36 @@ func (p *Parser) Parse() (*APIMockFile, error) {
 		}
 		resp, err := p.parseResponseSection(tokens, &i)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			i++
+			for i < len(tokens) && tokens[i].Type != TokenResponseStart {
+				i++
+			}
+			continue
 		}
 		ast.Responses = append(ast.Responses, resp)
 	}
 
+	switch len(errs) {
+	case 0:
+	case 1:
+		return nil, errs[0]
+	default:
+		return nil, errs
+	}
 	if len(ast.Responses) == 0 {
-		return nil, NewParseError(p.filename, 0, "expected at least one response section")
+		err := NewParseError(p.filename, 0, "expected at least one response section")
+		err.Code, err.Suggestion = CodeMissingResponse, "add a response such as -- 200: OK"
+		return nil, err
+	}
+
+	// Sections report the lines of the parsed file, so included sections
+	// point at their @include directive
+	if ast.Request != nil {
+		ast.Request.Lines = p.topLines(ast.Request.Lines)
+	}
+	for i := range ast.Responses {
+		ast.Responses[i].Lines = p.topLines(ast.Responses[i].Lines)
 	}
 
 	return ast, nil
@@ -103,10 +178,11 @@ func (p *Parser) parseRequestSection(tokens []Token, i *int) (*RequestSection, e
 	req := NewRequestSection()
 
 	if *i >= len(tokens) || tokens[*i].Type != TokenRequestLine {
-		return nil, NewParseError(p.filename, tokens[*i].Line, "expected HTTP method and/or path (e.g., 'GET /api/users')")
+		return nil, p.errorAt(tokens[*i], CodeMissingRequestLine, 1, "expected HTTP method and/or path (e.g., 'GET /api/users')", "")
 	}
 
 	// Request line
+	req.Lines = LineRange{Start: tokens[*i].Line, End: tokens[*i].Line}
 	req.Method = tokens[*i].Method
 	req.Path = tokens[*i].Path
 	req.PathSegments = append(req.PathSegments, tokens[*i].PathSegments...)
@@ -130,6 +206,9 @@ func (p *Parser) parseRequestSection(tokens []Token, i *int) (*RequestSection, e
 				goto DONE
 			}
 			req.QueryParams[tok.Key] = tok.Value
+			if tok.Required && !slices.Contains(req.RequiredQuery, tok.Key) {
+				req.RequiredQuery = append(req.RequiredQuery, tok.Key)
+			}
 			*i++
 		case TokenHeader:
 			if inBody {
@@ -139,6 +218,9 @@ func (p *Parser) parseRequestSection(tokens []Token, i *int) (*RequestSection, e
 				continue
 			}
 			req.Properties[tok.Key] = tok.Value
+			if tok.Required && !slices.Contains(req.RequiredProperties, tok.Key) {
+				req.RequiredProperties = append(req.RequiredProperties, tok.Key)
+			}
 			*i++
 		case TokenBlankLine:
 			// Blank line indicates start of body (if any)
@@ -158,6 +240,7 @@ func (p *Parser) parseRequestSection(tokens []Token, i *int) (*RequestSection, e
 	}
 
 DONE:
+	req.Lines.End = lastContentLine(tokens[:*i], req.Lines.End)
 	if len(bodyLines) > 0 {
 		req.BodySchema = strings.Join(bodyLines, "\n")
 	}
@@ -167,18 +250,24 @@ DONE:
 // parseResponseSection parses a response section using tokens.
 // It extracts the status code, description, headers, and body content.
 // Trailing blank lines are removed from the response body.
-func (p *Parser) parseResponseSection(tokens []Token, i *int) (ResponseSection, error) {
+func (p *Parser) parseResponseSection(tokens []Token, i *int) (ResponseSection, *ParseError) {
 	resp := NewResponseSection()
 
 	if *i >= len(tokens) || tokens[*i].Type != TokenResponseStart {
-		return resp, NewParseError(p.filename, tokens[*i].Line, "invalid response line format (expected: -- CODE: Description)")
+		return resp, p.errorAt(tokens[*i], CodeInvalidResponseLine, 1, "invalid response line format (expected: -- CODE: Description)", "write the line as -- 200: OK")
 	}
 	resp.StatusCode = tokens[*i].StatusCode
 	resp.Description = tokens[*i].Description
+	resp.Upstream = tokens[*i].Upstream
+	resp.Lines = LineRange{Start: tokens[*i].Line, End: tokens[*i].Line}
 
-	// Validate status code
-	if !IsValidHTTPStatusCode(resp.StatusCode) {
-		return resp, NewParseError(p.filename, tokens[*i].Line, fmt.Sprintf("invalid HTTP status code: %d (must be between %d-%d)", resp.StatusCode, MinHTTPStatusCode, MaxHTTPStatusCode))
+	// Validate status code, or the URL of a proxy section
+	if resp.Upstream != "" {
+		if !IsValidUpstream(resp.Upstream) {
+			return resp, p.errorAt(tokens[*i], CodeInvalidProxyURL, columnOf(tokens[*i].Raw, resp.Upstream), fmt.Sprintf("invalid proxy URL %q (must be an absolute http or https URL)", resp.Upstream), "use a URL such as https://api.example.com")
+		}
+	} else if !IsValidHTTPStatusCode(resp.StatusCode) {
+		return resp, p.errorAt(tokens[*i], CodeInvalidStatusCode, columnOf(tokens[*i].Raw, strconv.Itoa(resp.StatusCode)), fmt.Sprintf("invalid HTTP status code: %d (must be between %d-%d)", resp.StatusCode, MinHTTPStatusCode, MaxHTTPStatusCode), fmt.Sprintf("use a status code between %d and %d", MinHTTPStatusCode, MaxHTTPStatusCode))
 	}
 
 	*i++
@@ -201,12 +290,24 @@ func (p *Parser) parseResponseSection(tokens []Token, i *int) (ResponseSection,
 
 	// Parse body lines until next response or EOF
 	bodyLines := make([]string, 0)
+	inConditions := true // condition lines lead the body
 	for *i < len(tokens) {
 		tok := tokens[*i]
 		if tok.Type == TokenResponseStart {
 			break
 		}
 		if tok.Type == TokenBodyLine || tok.Type == TokenBlankLine || tok.Type == TokenHeader {
+			if inConditions && tok.Type != TokenBlankLine {
+				inConditions = strings.HasPrefix(tok.Raw, ConditionPrefix)
+			}
+			if inConditions && tok.Type != TokenBlankLine {
+				if _, err := parseConditionLine(tok.Raw); err != nil {
+					return resp, p.errorAt(tok, err.Code, err.Column, err.Message, "")
+				}
+				if name, column := unknownFunction(tok.Raw); name != "" {
+					return resp, p.errorAt(tok, CodeUnknownFunction, column, fmt.Sprintf("unknown function .%s", name), functionSuggestion(name))
+				}
+			}
 			// Treat any content here as body (including header-like lines)
 			bodyLines = append(bodyLines, tok.Raw)
 			*i++
@@ -215,6 +316,8 @@ func (p *Parser) parseResponseSection(tokens []Token, i *int) (ResponseSection,
 		break
 	}
 
+	resp.Lines.End = lastContentLine(tokens[:*i], resp.Lines.End)
+
 	// Remove trailing blank lines from body
 	for len(bodyLines) > 0 && strings.TrimSpace(bodyLines[len(bodyLines)-1]) == "" {
 		bodyLines = bodyLines[:len(bodyLines)-1]
@@ -225,3 +328,47 @@ func (p *Parser) parseResponseSection(tokens []Token, i *int) (ResponseSection,
 
 	return resp, nil
 }
+
+// errorAt builds the error of the line of tok, pointing at column. Lines
+// that come from an included file are reported in that file.
+func (p *Parser) errorAt(tok Token, code ErrorCode, column int, message, suggestion string) *ParseError {
+	origin := p.origins[tok.Line-1]
+	return &ParseError{
+		Code:       code,
+		Filename:   origin.file,
+		Line:       origin.line,
+		Column:     column,
+		Message:    message,
+		Snippet:    tok.Raw,
+		Suggestion: suggestion,
+	}
+}
+
+// topLines maps a range of expanded source lines to the lines of the parsed
+// file.
+func (p *Parser) topLines(r LineRange) LineRange {
+	if r.Start > 0 {
+		r.Start = p.origins[r.Start-1].top
+	}
+	if r.End > 0 {
+		r.End = p.origins[r.End-1].top
+	}
+	return r
+}
+
+// columnOf returns the 1-based column where s starts in line, or 1 if it does
+// not appear.
+func columnOf(line, s string) int {
+	return max(strings.Index(line, s), 0) + 1
+}
+
+// lastContentLine returns the line of the last non-blank token, or start if
+// every token after the one at line start is blank.
+func lastContentLine(tokens []Token, start int) int {
+	for j := len(tokens) - 1; j >= 0 && tokens[j].Line > start; j-- {
+		if tokens[j].Type != TokenBlankLine {
+			return tokens[j].Line
+		}
+	}
+	return start
+}
@@ -1,8 +1,10 @@
 package apimock
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -225,6 +227,38 @@ func TestParser_QueryParameters(t *testing.T) {
 	}
 }
 
+func TestParser_RequiredMarkers(t *testing.T) {
+	content := `GET /api/search
+  ?query!=test
+  &limit=10
+Authorization!: Bearer token
+Accept: application/json
+
+-- 200: OK
+ContentType: application/json
+
+[]`
+
+	ast, err := NewParserFromBytes("search.apimock", []byte(content)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	req := ast.Request
+	if req.QueryParams["query"] != "test" || req.QueryParams["limit"] != "10" {
+		t.Errorf("expected the query params without markers, got %v", req.QueryParams)
+	}
+	if req.Properties["Authorization"] != "Bearer token" {
+		t.Errorf("expected the Authorization property without marker, got %v", req.Properties)
+	}
+	if !reflect.DeepEqual(req.RequiredQuery, []string{"query"}) {
+		t.Errorf("expected query to be required, got %v", req.RequiredQuery)
+	}
+	if !reflect.DeepEqual(req.RequiredProperties, []string{"Authorization"}) {
+		t.Errorf("expected Authorization to be required, got %v", req.RequiredProperties)
+	}
+}
+
 func TestParser_NoResponseError(t *testing.T) {
 	content := `POST /api/users
 
@@ -377,3 +411,182 @@ func createTempFile(t *testing.T, content string) string {
 	}
 	return tmpFile
 }
+
+func TestParser_SectionLines(t *testing.T) {
+	content := `
+POST /users
+Accept: application/json
+
+{"name": "string"}
+
+
+-- 201: Created
+ContentType: application/json
+
+{"id": 1}
+
+-- 400: Bad Request
+
+`
+
+	ast, err := NewParserFromBytes("users.apimock", []byte(content)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if want := (LineRange{Start: 2, End: 5}); ast.Request.Lines != want {
+		t.Errorf("expected request lines %+v, got %+v", want, ast.Request.Lines)
+	}
+	want := []LineRange{{Start: 8, End: 11}, {Start: 13, End: 13}}
+	for i, lines := range want {
+		if ast.Responses[i].Lines != lines {
+			t.Errorf("response %d: expected lines %+v, got %+v", i, lines, ast.Responses[i].Lines)
+		}
+	}
+}
+
+func TestParser_ProxySection(t *testing.T) {
+	content := `GET /payments/{id}
+
+-- proxy: https://api.example.com/v1
+X-Mocked: false
+
+sandbox => mock
+
+-- 502: Upstream down
+`
+
+	ast, err := NewParserFromBytes("payments.apimock", []byte(content)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(ast.Responses) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(ast.Responses))
+	}
+
+	proxy := ast.Responses[0]
+	if proxy.Upstream != "https://api.example.com/v1" || proxy.StatusCode != 0 {
+		t.Errorf("expected proxy section to https://api.example.com/v1, got %+v", proxy)
+	}
+	if proxy.Properties["X-Mocked"] != "false" || proxy.Body != "sandbox => mock" {
+		t.Errorf("expected proxy properties and rules, got %+v", proxy)
+	}
+	if err := ast.Validate(); err != nil {
+		t.Errorf("expected valid file, got %v", err)
+	}
+
+	for _, invalid := range []string{"-- proxy: api.example.com", "-- proxy: ftp://api.example.com"} {
+		if _, err := NewParserFromBytes("invalid.apimock", []byte(invalid)).Parse(); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestParser_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantCode   ErrorCode
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:       "invalid status code",
+			content:    "GET /users\n\n--  999: Weird\n",
+			wantCode:   CodeInvalidStatusCode,
+			wantLine:   3,
+			wantColumn: 5,
+		},
+		{
+			name:       "invalid proxy URL",
+			content:    "-- proxy: ftp://api.example.com",
+			wantCode:   CodeInvalidProxyURL,
+			wantLine:   1,
+			wantColumn: 11,
+		},
+		{
+			name:       "body without response",
+			content:    "GET /users\n\n{\"id\": 1}\n\nnot a response\n",
+			wantCode:   CodeMissingResponse,
+			wantLine:   0,
+			wantColumn: 0,
+		},
+		{
+			name:       "text before the first response",
+			content:    "\nhello\n-- 200: OK\n",
+			wantCode:   CodeMissingResponse,
+			wantLine:   2,
+			wantColumn: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParserFromBytes("test.apimock", []byte(tt.content)).Parse()
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected a *ParseError, got %v", err)
+			}
+			if parseErr.Code != tt.wantCode || parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn {
+				t.Errorf("got %s at %d:%d, want %s at %d:%d", parseErr.Code, parseErr.Line, parseErr.Column, tt.wantCode, tt.wantLine, tt.wantColumn)
+			}
+			if parseErr.Suggestion == "" {
+				t.Error("expected a suggestion")
+			}
+		})
+	}
+}
+
+func TestParseError_Excerpt(t *testing.T) {
+	_, err := NewParserFromBytes("test.apimock", []byte("-- 999: Weird")).Parse()
+	parseErr := err.(*ParseError)
+
+	want := "  1 | -- 999: Weird\n" +
+		"    |    ^\n" +
+		"  hint: use a status code between 100 and 599\n"
+	if got := parseErr.Excerpt(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := parseErr.Error(); got != "test.apimock:1:4: invalid HTTP status code: 999 (must be between 100-599)" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestParser_ReportsEveryError(t *testing.T) {
+	content := `GET /users
+
+-- 999: Weird
+{"a": 1}
+
+-- 200: OK
+{"ok": true}
+
+-- proxy: ftp://example.com
+`
+	_, err := NewParserFromBytes("test.apimock", []byte(content)).Parse()
+	errs, ok := err.(ParseErrors)
+	if !ok {
+		t.Fatalf("expected ParseErrors, got %T: %v", err, err)
+	}
+
+	got := make([]ErrorCode, len(errs))
+	lines := make([]int, len(errs))
+	for i, e := range errs {
+		got[i], lines[i] = e.Code, e.Line
+	}
+	want := []ErrorCode{CodeInvalidStatusCode, CodeInvalidProxyURL}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got codes %v, want %v", got, want)
+	}
+	if lines[0] != 3 || lines[1] != 9 {
+		t.Errorf("got lines %v, want [3 9]", lines)
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 {
+		t.Errorf("expected errors.As to find the first error, got %v", parseErr)
+	}
+	if n := len(ParseErrorsOf(err)); n != 2 {
+		t.Errorf("expected ParseErrorsOf to return 2 errors, got %d", n)
+	}
+}
@@ -0,0 +1,88 @@
+package apimock
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// random is the source of the random functions, such as .random_int and
+// .uuid, seeded at random unless SeedRandom seeds it.
+var random = newLockedRand(rand.Uint64())
+
+// SeedRandom seeds the source of the random functions, so runs making the
+// same calls in the same order get the same values, and .random_sticky
+// returns the same values from run to run.
+func SeedRandom(seed int64) {
+	random.reseed(uint64(seed))
+}
+
+// lockedRand is a random source safe for concurrent use.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+	seed uint64
+}
+
+func newLockedRand(seed uint64) *lockedRand {
+	r := &lockedRand{}
+	r.reseed(seed)
+	return r
+}
+
+func (r *lockedRand) reseed(seed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rand, r.seed = rand.New(rand.NewPCG(seed, seed)), seed
+}
+
+func (r *lockedRand) IntN(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.IntN(n)
+}
+
+// int64Between returns a number between lo and hi, inclusive, which may
+// span every int64.
+func (r *lockedRand) int64Between(lo, hi int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	span := uint64(hi - lo)
+	if span == math.MaxUint64 {
+		return int64(r.rand.Uint64())
+	}
+	return lo + int64(r.rand.Uint64N(span+1))
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64()
+}
+
+func (r *lockedRand) Read(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range b {
+		b[i] = byte(r.rand.Uint32())
+	}
+}
+
+// sticky returns a number between lo and hi, inclusive, that depends only
+// on key and the seed: the same key always gets the same number. The range
+// may span every int64.
+func (r *lockedRand) sticky(key string, lo, hi int64) int64 {
+	r.mu.Lock()
+	seed := r.seed
+	r.mu.Unlock()
+	h := fnv.New64a()
+	binary.Write(h, binary.BigEndian, seed)
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	if span := uint64(hi - lo); span != math.MaxUint64 {
+		sum %= span + 1
+	}
+	return lo + int64(sum)
+}
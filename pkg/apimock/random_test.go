@@ -0,0 +1,89 @@
+package apimock
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSeedRandom(t *testing.T) {
+	t.Cleanup(func() { SeedRandom(0) })
+
+	draw := func() []any {
+		var values []any
+		for _, name := range []string{"random_int", "random_float"} {
+			v, _ := CallFunction(name, 1.0, 1000.0)
+			values = append(values, v)
+		}
+		v, _ := CallFunction("uuid")
+		return append(values, v)
+	}
+	SeedRandom(42)
+	first := draw()
+	SeedRandom(42)
+	if second := draw(); !slices.Equal(first, second) {
+		t.Errorf("draws after the same seed differ: %v and %v", first, second)
+	}
+}
+
+func TestRandomInt_WideRange(t *testing.T) {
+	lo, hi := float64(math.MinInt64), math.Nextafter(float64(math.MaxInt64), 0)
+	for range 100 {
+		v, err := CallFunction("random_int", lo, hi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := v.(float64); n < lo || n > hi {
+			t.Fatalf("random_int(%g, %g) = %g", lo, hi, n)
+		}
+	}
+	random.int64Between(math.MinInt64, math.MaxInt64) // every int64 is in range
+	if _, err := CallFunction("random_int", 0.0, 1e19); err == nil {
+		t.Error("expected an error for a bound beyond an int64")
+	}
+}
+
+func TestRandomSticky(t *testing.T) {
+	t.Cleanup(func() { SeedRandom(0) })
+	SeedRandom(7)
+
+	sticky := func(key any) float64 {
+		v, err := CallFunction("random_sticky", key, 1.0, 1000000.0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.(float64)
+	}
+	first := sticky("total")
+	for range 5 {
+		CallFunction("random_int", 1.0, 10.0) // other draws do not move sticky values
+		if got := sticky("total"); got != first {
+			t.Fatalf("random_sticky(total) = %v, then %v", first, got)
+		}
+	}
+	if first < 1 || first > 1000000 {
+		t.Errorf("random_sticky(total) = %v, out of 1..1000000", first)
+	}
+	if sticky("count") == first && sticky(3.0) == first {
+		t.Error("expected different keys to get different values")
+	}
+
+	SeedRandom(8)
+	if sticky("total") == first {
+		t.Error("expected another seed to give another value")
+	}
+	if _, err := CallFunction("random_sticky", "total", 5.0, 1.0); err == nil {
+		t.Error("expected an error for an empty range")
+	}
+	if _, err := CallFunction("random_sticky", "total", 0.0, 1e19); err == nil {
+		t.Error("expected an error for a bound beyond an int64")
+	}
+
+	lo, hi := float64(math.MinInt64), math.Nextafter(float64(math.MaxInt64), 0)
+	if v, err := CallFunction("random_sticky", "total", lo, hi); err != nil || v.(float64) < lo || v.(float64) > hi {
+		t.Errorf("random_sticky(total, %g, %g) = %v, %v", lo, hi, v, err)
+	}
+	if got := random.sticky("total", math.MinInt64, math.MaxInt64); got != random.sticky("total", math.MinInt64, math.MaxInt64) {
+		t.Errorf("random_sticky over every int64 = %d, then another value", got)
+	}
+}
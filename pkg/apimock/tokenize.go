@@ -0,0 +1,320 @@
+package apimock
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SemanticKind classifies the text a SemanticToken covers, for syntax
+// highlighting.
+type SemanticKind string
+
+const (
+	KindMethod      SemanticKind = "method"      // HTTP method of the request line
+	KindPath        SemanticKind = "path"        // static path segments
+	KindParameter   SemanticKind = "parameter"   // {name}, {name:pattern} and * path segments
+	KindQuery       SemanticKind = "query"       // query parameter names
+	KindProperty    SemanticKind = "property"    // property names, such as ContentType
+	KindValue       SemanticKind = "value"       // property values, query values, proxy URLs and included paths
+	KindStatus      SemanticKind = "status"      // status code of a response line
+	KindDescription SemanticKind = "description" // description of a response line
+	KindKeyword     SemanticKind = "keyword"     // proxy, @include, and the and/or/not/True/False of conditions
+	KindOperator    SemanticKind = "operator"    // --, ?, &, =, >, the ! of required keys and the operators of conditions
+	KindString      SemanticKind = "string"      // string literals of conditions
+	KindNumber      SemanticKind = "number"      // number literals of conditions
+	KindFunction    SemanticKind = "function"    // built-in functions of conditions, such as .contains
+	KindVariable    SemanticKind = "variable"    // variables of conditions
+	KindComment     SemanticKind = "comment"     // # comments of conditions
+	KindBody        SemanticKind = "body"        // request and response body lines
+)
+
+// SemanticToken is a span of source text with its kind. Line and Column start
+// at 1; Column and Length count bytes.
+type SemanticToken struct {
+	Kind   SemanticKind `json:"kind"`
+	Line   int          `json:"line"`
+	Column int          `json:"column"`
+	Length int          `json:"length"`
+	Text   string       `json:"text"`
+}
+
+// conditionKeywords are the words of the conditions language that are not
+// variables.
+var conditionKeywords = map[string]bool{"and": true, "or": true, "not": true, "True": true, "False": true}
+
+// conditionOperators are the operators of the conditions language, longest
+// first so >> is not read as two >.
+var conditionOperators = []string{">>", "==", "!=", ">=", "<=", "//", "..", ">", "<", "+", "-", "*", "/", "%", "=", "(", ")", "[", "]", "{", "}", ","}
+
+// tokenizer collects the semantic tokens of one line at a time.
+type tokenizer struct {
+	tokens []SemanticToken
+	line   int
+	raw    string
+}
+
+// Tokenize splits .apimock source into positioned semantic tokens, following
+// the sections the parser sees, so editors can highlight files without
+// reimplementing the grammar. It accepts broken files and never fails: text
+// it cannot classify is left out.
+func Tokenize(src []byte) []SemanticToken {
+	lexed, _ := NewLexer(strings.Split(string(src), "\n")).Lex()
+	t := &tokenizer{}
+
+	const (
+		preamble = iota
+		request
+		requestBody
+		responseHead
+		responseBody
+	)
+	state, lastLine := preamble, 0
+	for _, tok := range lexed {
+		// The lexer emits one token per query parameter of a line
+		if tok.Line == lastLine {
+			continue
+		}
+		lastLine = tok.Line
+		t.line, t.raw = tok.Line, tok.Raw
+
+		if path, ok := includePath(tok.Raw); ok {
+			t.emit(KindKeyword, 0, len(IncludeDirective))
+			t.emit(KindValue, strings.LastIndex(tok.Raw, path), len(path))
+			continue
+		}
+
+		if tok.Type == TokenResponseStart {
+			t.responseLine(tok)
+			state = responseHead
+			continue
+		}
+
+		switch state {
+		case preamble, request:
+			switch tok.Type {
+			case TokenRequestLine:
+				if state == preamble {
+					t.requestLine(tok)
+					state = request
+				} else {
+					t.body()
+					state = requestBody
+				}
+			case TokenPathContinuation:
+				t.path(strings.Index(tok.Raw, tok.PathContinuation), tok.PathContinuation)
+			case TokenQueryParam:
+				t.query(strings.IndexAny(tok.Raw, "?&"))
+			case TokenHeader:
+				t.property(tok)
+			case TokenBlankLine:
+				if state == request {
+					state = requestBody
+				}
+			default:
+				t.body()
+				if state == request {
+					state = requestBody
+				}
+			}
+		case requestBody, responseBody:
+			t.body()
+		case responseHead:
+			switch {
+			case tok.Type == TokenHeader:
+				t.property(tok)
+			case strings.HasPrefix(tok.Raw, ConditionPrefix):
+				t.condition()
+			case tok.Type == TokenBlankLine:
+				state = responseBody
+			default:
+				t.body()
+				state = responseBody
+			}
+		}
+	}
+	return t.tokens
+}
+
+// emit adds the token covering length bytes of the line from offset.
+func (t *tokenizer) emit(kind SemanticKind, offset, length int) {
+	if offset < 0 || length <= 0 || offset+length > len(t.raw) {
+		return
+	}
+	t.tokens = append(t.tokens, SemanticToken{
+		Kind:   kind,
+		Line:   t.line,
+		Column: offset + 1,
+		Length: length,
+		Text:   t.raw[offset : offset+length],
+	})
+}
+
+func (t *tokenizer) requestLine(tok Token) {
+	offset := 0
+	if tok.Method != "" {
+		offset = strings.Index(t.raw, tok.Method)
+		t.emit(KindMethod, offset, len(tok.Method))
+		offset += len(tok.Method)
+	}
+	start := strings.Index(t.raw[offset:], tok.Path)
+	if start < 0 {
+		return
+	}
+	end := offset + start + len(tok.Path)
+	t.path(offset+start, tok.Path)
+	if strings.HasPrefix(t.raw[end:], "?") {
+		t.query(end)
+	}
+}
+
+// path emits the segments of the path found at offset.
+func (t *tokenizer) path(offset int, path string) {
+	if offset < 0 {
+		return
+	}
+	for _, m := range pathSegmentRegex.FindAllStringSubmatchIndex(path, -1) {
+		seg := path[m[2]:m[3]]
+		if seg == "*" || strings.HasPrefix(seg, "{") {
+			t.emit(KindPath, offset+m[0], m[2]-m[0])
+			t.emit(KindParameter, offset+m[2], m[3]-m[2])
+		} else {
+			t.emit(KindPath, offset+m[0], m[1]-m[0])
+		}
+	}
+}
+
+// query emits the ?key=value&key=value pairs starting at offset, up to the
+// first whitespace.
+func (t *tokenizer) query(offset int) {
+	if offset < 0 {
+		return
+	}
+	end := strings.IndexFunc(t.raw[offset:], unicode.IsSpace)
+	if end < 0 {
+		end = len(t.raw) - offset
+	}
+	for pos := offset; pos < offset+end; {
+		t.emit(KindOperator, pos, 1)
+		pos++
+		pair := t.raw[pos : offset+end]
+		if next := strings.IndexByte(pair, '&'); next >= 0 {
+			pair = pair[:next]
+		}
+		key, value, found := strings.Cut(pair, "=")
+		if name, ok := strings.CutSuffix(key, "!"); ok {
+			t.emit(KindQuery, pos, len(name))
+			t.emit(KindOperator, pos+len(name), 1)
+		} else {
+			t.emit(KindQuery, pos, len(key))
+		}
+		if found {
+			t.emit(KindOperator, pos+len(key), 1)
+			t.emit(KindValue, pos+len(key)+1, len(value))
+		}
+		pos += len(pair)
+	}
+}
+
+func (t *tokenizer) property(tok Token) {
+	t.emit(KindProperty, 0, len(tok.Key))
+	end := len(tok.Key)
+	if tok.Required {
+		t.emit(KindOperator, end, 1)
+		end++
+	}
+	if start := strings.Index(t.raw[end+1:], tok.Value); start >= 0 {
+		t.emit(KindValue, end+1+start, len(tok.Value))
+	}
+}
+
+func (t *tokenizer) responseLine(tok Token) {
+	t.emit(KindOperator, 0, 2)
+	if tok.Upstream != "" {
+		m := proxyLineCaptureRegex.FindStringSubmatchIndex(t.raw)
+		t.emit(KindKeyword, strings.Index(t.raw, "proxy"), len("proxy"))
+		t.emit(KindValue, m[2], m[3]-m[2])
+		return
+	}
+	m := responseLineCaptureRegex.FindStringSubmatchIndex(t.raw)
+	t.emit(KindStatus, m[2], m[3]-m[2])
+	t.emit(KindDescription, m[4], len(strings.TrimRightFunc(t.raw[m[4]:m[5]], unicode.IsSpace)))
+}
+
+func (t *tokenizer) body() {
+	start := strings.IndexFunc(t.raw, func(r rune) bool { return !unicode.IsSpace(r) })
+	if start < 0 {
+		return
+	}
+	t.emit(KindBody, start, len(strings.TrimRightFunc(t.raw, unicode.IsSpace))-start)
+}
+
+// condition emits the tokens of a condition line (see CONDITIONS.md).
+func (t *tokenizer) condition() {
+	t.emit(KindOperator, 0, len(ConditionPrefix))
+	s := t.raw
+	for pos := len(ConditionPrefix); pos < len(s); {
+		c := s[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			pos++
+		case c == '#':
+			t.emit(KindComment, pos, len(strings.TrimRightFunc(s[pos:], unicode.IsSpace)))
+			return
+		case c == '"':
+			end := pos + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(s))
+			t.emit(KindString, pos, end-pos)
+			pos = end
+		case isDigit(c):
+			end := pos
+			for end < len(s) && (isDigit(s[end]) || s[end] == '.' && !strings.HasPrefix(s[end:], "..")) {
+				end++
+			}
+			t.emit(KindNumber, pos, end-pos)
+			pos = end
+		case c == '.' && pos+1 < len(s) && isIdentStart(s[pos+1]):
+			end := identEnd(s, pos+1)
+			t.emit(KindFunction, pos, end-pos)
+			pos = end
+		case isIdentStart(c):
+			end := identEnd(s, pos)
+			kind := KindVariable
+			if conditionKeywords[s[pos:end]] {
+				kind = KindKeyword
+			}
+			t.emit(kind, pos, end-pos)
+			pos = end
+		default:
+			length := 0
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(s[pos:], op) {
+					length = len(op)
+					break
+				}
+			}
+			t.emit(KindOperator, pos, length)
+			pos += max(length, 1)
+		}
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func identEnd(s string, pos int) int {
+	for pos < len(s) && (isIdentStart(s[pos]) || isDigit(s[pos])) {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,156 @@
+package apimock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe renders tokens as "line:column kind text" for readable diffs.
+func describe(tokens []SemanticToken) []string {
+	lines := make([]string, len(tokens))
+	for i, tok := range tokens {
+		lines[i] = fmt.Sprintf("%d:%d %s %s", tok.Line, tok.Column, tok.Kind, tok.Text)
+	}
+	return lines
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "request section",
+			src:  "GET /users/{id:[0-9]+}/*\n  /orders\n  ?page=1&sort=desc\nAccept: application/json\n\n{\"id\": 1}\n\n-- 200: OK\n",
+			want: []string{
+				"1:1 method GET",
+				"1:5 path /users",
+				"1:11 path /",
+				"1:12 parameter {id:[0-9]+}",
+				"1:23 path /",
+				"1:24 parameter *",
+				"2:3 path /orders",
+				"3:3 operator ?",
+				"3:4 query page",
+				"3:8 operator =",
+				"3:9 value 1",
+				"3:10 operator &",
+				"3:11 query sort",
+				"3:15 operator =",
+				"3:16 value desc",
+				"4:1 property Accept",
+				"4:9 value application/json",
+				`6:1 body {"id": 1}`,
+				"8:1 operator --",
+				"8:4 status 200",
+				"8:9 description OK",
+			},
+		},
+		{
+			name: "conditions and body",
+			src:  "-- 401: Unauthorized\nContentType: text/plain\n> headers[\"Authorization\"] >> token  # bearer\n> or not call_count >= 2.5\n\nStatus: denied\n",
+			want: []string{
+				"1:1 operator --",
+				"1:4 status 401",
+				"1:9 description Unauthorized",
+				"2:1 property ContentType",
+				"2:14 value text/plain",
+				"3:1 operator >",
+				"3:3 variable headers",
+				"3:10 operator [",
+				`3:11 string "Authorization"`,
+				"3:26 operator ]",
+				"3:28 operator >>",
+				"3:31 variable token",
+				"3:38 comment # bearer",
+				"4:1 operator >",
+				"4:3 keyword or",
+				"4:6 keyword not",
+				"4:10 variable call_count",
+				"4:21 operator >=",
+				"4:24 number 2.5",
+				"6:1 body Status: denied",
+			},
+		},
+		{
+			name: "required markers",
+			src:  "GET /search\n  ?q!=shoes\nX-Api-Key!: secret\n\n-- 200: OK\n",
+			want: []string{
+				"1:1 method GET",
+				"1:5 path /search",
+				"2:3 operator ?",
+				"2:4 query q",
+				"2:5 operator !",
+				"2:6 operator =",
+				"2:7 value shoes",
+				"3:1 property X-Api-Key",
+				"3:10 operator !",
+				"3:13 value secret",
+				"5:1 operator --",
+				"5:4 status 200",
+				"5:9 description OK",
+			},
+		},
+		{
+			name: "proxy section",
+			src:  "-- proxy: https://api.example.com\n.contains x\n",
+			want: []string{
+				"1:1 operator --",
+				"1:4 keyword proxy",
+				"1:11 value https://api.example.com",
+				"2:1 body .contains x",
+			},
+		},
+		{
+			name: "include",
+			src:  "GET /users\n@include ./headers.apimock\n\n-- 200: OK\n\n@include errors.apimock\n",
+			want: []string{
+				"1:1 method GET",
+				"1:5 path /users",
+				"2:1 keyword @include",
+				"2:10 value ./headers.apimock",
+				"4:1 operator --",
+				"4:4 status 200",
+				"4:9 description OK",
+				"6:1 keyword @include",
+				"6:10 value errors.apimock",
+			},
+		},
+		{
+			name: "broken file",
+			src:  "-- 999: Weird\n> 1..10\n",
+			want: []string{
+				"1:1 operator --",
+				"1:4 status 999",
+				"1:9 description Weird",
+				"2:1 operator >",
+				"2:3 number 1",
+				"2:4 operator ..",
+				"2:6 number 10",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := describe(Tokenize([]byte(tt.src)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenize_TextMatchesSource(t *testing.T) {
+	src := "POST /api/users?debug=true\nX-Owner: @team\n\n-- 201: Created\n> body.name .. \"!\" == \"x\"\n\n{\n  \"id\": 1\n}\n"
+	lines := strings.Split(src, "\n")
+	for _, tok := range Tokenize([]byte(src)) {
+		line := lines[tok.Line-1]
+		if got := line[tok.Column-1 : tok.Column-1+tok.Length]; got != tok.Text {
+			t.Errorf("token %+v covers %q", tok, got)
+		}
+	}
+}
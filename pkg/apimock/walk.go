@@ -0,0 +1,256 @@
+package apimock
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ConditionLine is a condition line of a response, written with a leading
+// > as in `> body.age >= 18` (see CONDITIONS.md). Responses are served when
+// the conditions of one of their alternatives all hold: the lines before
+// the first starting with `or`, or those from one such line to the next.
+type ConditionLine struct {
+	Expression string // Condition text without the > prefix, the leading or, the comment and surrounding spaces
+	Raw        string // Line as written, prefix included
+	Line       int    // Source line, counted as Lines is; 0 for sections that were not parsed from a file
+	Or         bool   // The line starts with or, beginning a new alternative
+	Expr       Expr   // Parsed Expression; nil for an empty condition, which never holds, or one that does not parse
+}
+
+// MarshalJSON encodes the line with its Expr in the form of MarshalExpr.
+func (c ConditionLine) MarshalJSON() ([]byte, error) {
+	type line ConditionLine
+	expr, err := toExprJSON(c.Expr)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		line
+		Expr *exprJSON `json:",omitempty"`
+	}{line(c), expr})
+}
+
+// UnmarshalJSON decodes a line encoded by MarshalJSON.
+func (c *ConditionLine) UnmarshalJSON(data []byte) error {
+	type line ConditionLine
+	var decoded struct {
+		line
+		Expr *exprJSON
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	expr, err := decoded.Expr.expr()
+	if err != nil {
+		return err
+	}
+	*c = ConditionLine(decoded.line)
+	c.Expr = expr
+	return nil
+}
+
+// Conditions returns the condition lines that lead the body of the
+// response, in order. Lines that do not parse, which the parser reports,
+// have a nil Expr.
+func (r *ResponseSection) Conditions() []ConditionLine {
+	if !strings.HasPrefix(r.Body, ConditionPrefix) {
+		return nil
+	}
+
+	lines := strings.Split(r.Body, "\n")
+	var conditions []ConditionLine
+	for i, raw := range lines {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if !strings.HasPrefix(raw, ConditionPrefix) {
+			break
+		}
+		condition, _ := parseConditionLine(raw)
+		if r.Lines.End != 0 {
+			condition.Line = r.Lines.End - (len(lines) - 1 - i)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// parseConditionLine parses a line starting with ConditionPrefix. The
+// Column of the error, if any, counts from 1 in raw.
+func parseConditionLine(raw string) (ConditionLine, *ParseError) {
+	text := strings.TrimPrefix(raw, ConditionPrefix)
+	x, or, err := ParseCondition(text)
+	condition := ConditionLine{Raw: raw, Or: or, Expr: x}
+
+	expression := strings.TrimSpace(withoutComment(text))
+	if or {
+		expression = strings.TrimSpace(strings.TrimPrefix(expression, "or"))
+	}
+	condition.Expression = expression
+	if err != nil {
+		parseErr := err.(*ParseError)
+		parseErr.Column += len(ConditionPrefix)
+		parseErr.Snippet = raw
+		condition.Expr = nil
+		return condition, parseErr
+	}
+	return condition, nil
+}
+
+// Content returns the body of the response without the condition lines
+// leading it, and the blank lines following them: what is sent.
+func (r *ResponseSection) Content() string {
+	if !strings.HasPrefix(r.Body, ConditionPrefix) {
+		return r.Body
+	}
+	lines := strings.Split(r.Body, "\n")
+	i := 0
+	for i < len(lines) && (strings.HasPrefix(lines[i], ConditionPrefix) || strings.TrimSpace(lines[i]) == "") {
+		i++
+	}
+	return strings.Join(lines[i:], "\n")
+}
+
+// MarshalJSON encodes the response with its Conditions, their syntax trees
+// included, so that tools reading the syntax tree as JSON need not split
+// them out of the body.
+func (r ResponseSection) MarshalJSON() ([]byte, error) {
+	type response ResponseSection
+	return json.Marshal(struct {
+		response
+		Conditions []ConditionLine `json:",omitempty"`
+	}{response(r), r.Conditions()})
+}
+
+// UnmarshalJSON decodes a response encoded by MarshalJSON. The body holds
+// the condition lines too; when it does not, as for JSON written by tools,
+// the lines of Conditions are put back at its start.
+func (r *ResponseSection) UnmarshalJSON(data []byte) error {
+	type response ResponseSection
+	var decoded struct {
+		response
+		Conditions []ConditionLine
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = ResponseSection(decoded.response)
+	if len(decoded.Conditions) == 0 || strings.HasPrefix(r.Body, ConditionPrefix) {
+		return nil
+	}
+	lines := make([]string, 0, len(decoded.Conditions)+1)
+	for _, c := range decoded.Conditions {
+		raw := c.Raw
+		if raw == "" {
+			raw = ConditionPrefix + " " + c.Expression
+			if c.Or {
+				raw = ConditionPrefix + " or " + c.Expression
+			}
+		}
+		lines = append(lines, raw)
+	}
+	if r.Body != "" {
+		lines = append(lines, "", r.Body)
+	}
+	r.Body = strings.Join(lines, "\n")
+	return nil
+}
+
+// Visitors are called by Walk for the nodes of the kinds they implement. A
+// visitor passed to Walk may implement any number of them.
+type (
+	// FileVisitor visits the file itself, before any of its sections.
+	FileVisitor interface {
+		VisitFile(f *APIMockFile) error
+	}
+	// RequestVisitor visits the request section, before its path segments.
+	RequestVisitor interface {
+		VisitRequest(r *RequestSection) error
+	}
+	// PathSegmentVisitor visits the segments of the request path, in order.
+	PathSegmentVisitor interface {
+		VisitPathSegment(s *PathSegment) error
+	}
+	// ResponseVisitor visits the responses, in order, before their
+	// conditions. index is the position of r in the Responses of the file.
+	ResponseVisitor interface {
+		VisitResponse(index int, r *ResponseSection) error
+	}
+	// ConditionVisitor visits the condition lines of a response, in order.
+	ConditionVisitor interface {
+		VisitCondition(r *ResponseSection, c ConditionLine) error
+	}
+)
+
+// SkipChildren is returned by a visitor to skip the nodes inside the one it
+// visits: the sections of the file, the segments of the request or the
+// conditions of a response. Returned for a segment or a condition, it skips
+// the ones that follow. Walk does not return it.
+var SkipChildren = errors.New("skip children")
+
+// Walk traverses the file depth-first in source order — the file, the
+// request and its path segments, then each response and its conditions —
+// calling the methods visitor implements among FileVisitor,
+// RequestVisitor, PathSegmentVisitor, ResponseVisitor and ConditionVisitor.
+// Visitors may change the nodes they are given. Walk stops at the first
+// error a visitor returns, other than SkipChildren, and returns it.
+func Walk(f *APIMockFile, visitor any) error {
+	if v, ok := visitor.(FileVisitor); ok {
+		if err := v.VisitFile(f); err != nil {
+			return skipped(err)
+		}
+	}
+
+	if f.Request != nil {
+		if err := walkRequest(f.Request, visitor); err != nil {
+			return err
+		}
+	}
+	for i := range f.Responses {
+		if err := walkResponse(i, &f.Responses[i], visitor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkRequest(r *RequestSection, visitor any) error {
+	if v, ok := visitor.(RequestVisitor); ok {
+		if err := v.VisitRequest(r); err != nil {
+			return skipped(err)
+		}
+	}
+	if v, ok := visitor.(PathSegmentVisitor); ok {
+		for i := range r.PathSegments {
+			if err := v.VisitPathSegment(&r.PathSegments[i]); err != nil {
+				return skipped(err)
+			}
+		}
+	}
+	return nil
+}
+
+func walkResponse(index int, r *ResponseSection, visitor any) error {
+	if v, ok := visitor.(ResponseVisitor); ok {
+		if err := v.VisitResponse(index, r); err != nil {
+			return skipped(err)
+		}
+	}
+	if v, ok := visitor.(ConditionVisitor); ok {
+		for _, c := range r.Conditions() {
+			if err := v.VisitCondition(r, c); err != nil {
+				return skipped(err)
+			}
+		}
+	}
+	return nil
+}
+
+// skipped returns nil for SkipChildren, which only ends the node at hand.
+func skipped(err error) error {
+	if errors.Is(err, SkipChildren) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package apimock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recorder lists the nodes Walk visits, skipping the children of the
+// response with the status skip.
+type recorder struct {
+	visited []string
+	skip    int
+}
+
+func (r *recorder) VisitFile(f *APIMockFile) error {
+	r.visited = append(r.visited, "file")
+	return nil
+}
+
+func (r *recorder) VisitRequest(req *RequestSection) error {
+	r.visited = append(r.visited, "request "+req.Path)
+	return nil
+}
+
+func (r *recorder) VisitPathSegment(s *PathSegment) error {
+	r.visited = append(r.visited, "segment "+s.Value)
+	return nil
+}
+
+func (r *recorder) VisitResponse(index int, resp *ResponseSection) error {
+	r.visited = append(r.visited, fmt.Sprintf("response %d: %d", index, resp.StatusCode))
+	if resp.StatusCode == r.skip {
+		return SkipChildren
+	}
+	return nil
+}
+
+func (r *recorder) VisitCondition(resp *ResponseSection, c ConditionLine) error {
+	r.visited = append(r.visited, fmt.Sprintf("condition %d: %s", c.Line, c.Expression))
+	return nil
+}
+
+const walkSource = `GET /users/{id}
+
+-- 200: OK
+> path.id == "1"
+
+> query.full == "true"
+
+{"id": 1}
+
+-- 403: Forbidden
+> headers.Authorization == ""
+
+-- 404: Not Found
+`
+
+func TestWalk(t *testing.T) {
+	f, err := NewParserFromBytes("users.apimock", []byte(walkSource)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	r := &recorder{skip: 403}
+	if err := Walk(f, r); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	want := []string{
+		"file",
+		"request /users/{id}",
+		"segment users",
+		"segment {id}",
+		"response 0: 200",
+		`condition 4: path.id == "1"`,
+		`condition 6: query.full == "true"`,
+		"response 1: 403",
+		"response 2: 404",
+	}
+	if !reflect.DeepEqual(r.visited, want) {
+		t.Errorf("Walk() visited %q, want %q", r.visited, want)
+	}
+}
+
+type statusRewriter struct{}
+
+func (statusRewriter) VisitResponse(index int, r *ResponseSection) error {
+	if r.StatusCode == 404 {
+		return errors.New("404 is not allowed")
+	}
+	r.Description = "checked"
+	return nil
+}
+
+func TestWalk_StopsAtError(t *testing.T) {
+	f, err := NewParserFromBytes("users.apimock", []byte(walkSource)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if err := Walk(f, statusRewriter{}); err == nil || err.Error() != "404 is not allowed" {
+		t.Errorf("Walk() error = %v, want the visitor's", err)
+	}
+	if f.Responses[0].Description != "checked" || f.Responses[1].Description != "checked" {
+		t.Errorf("expected visitors to change the responses, got %+v", f.Responses)
+	}
+}
+
+func TestResponseSection_Conditions(t *testing.T) {
+	built := ResponseSection{Body: "> a == 1\n\n>b\n{}\n> not a condition"}
+	want := []ConditionLine{
+		{Expression: "a == 1", Raw: "> a == 1", Expr: &Binary{Op: "==", X: &Variable{Name: "a", Offset: 1}, Y: &Literal{Value: 1.0, Offset: 6}, Offset: 3}},
+		{Expression: "b", Raw: ">b", Expr: &Variable{Name: "b"}},
+	}
+	if got := built.Conditions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conditions() = %+v, want %+v", got, want)
+	}
+	if got := (&ResponseSection{Body: "{}"}).Conditions(); got != nil {
+		t.Errorf("Conditions() = %+v, want none", got)
+	}
+}
+
+func TestAPIMockFile_JSON(t *testing.T) {
+	f, err := NewParserFromBytes("users.apimock", []byte(walkSource)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var encoded struct {
+		Responses []struct {
+			StatusCode int
+			Conditions []ConditionLine
+		}
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got := encoded.Responses[1].Conditions; len(got) != 1 || got[0].Expression != `headers.Authorization == ""` || got[0].Line != 11 {
+		t.Errorf("Conditions of the 403 response = %+v", got)
+	}
+	if got, want := encoded.Responses[1].Conditions[0].Expr, f.Responses[1].Conditions()[0].Expr; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expr of the 403 condition = %#v, want %#v", got, want)
+	}
+	if got := encoded.Responses[2].Conditions; got != nil {
+		t.Errorf("Conditions of the 404 response = %+v, want none", got)
+	}
+
+	var decoded APIMockFile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, f) {
+		t.Errorf("expected the file to decode unchanged\ngot:  %+v\nwant: %+v", &decoded, f)
+	}
+
+	// Conditions missing from the body, as tools may write them, lead it
+	var written ResponseSection
+	if err := json.Unmarshal([]byte(`{"StatusCode": 200, "Body": "{}", "Conditions": [{"Expression": "query.page == 1"}, {"Expression": "query.all", "Or": true}]}`), &written); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if want := "> query.page == 1\n> or query.all\n\n{}"; written.Body != want || written.Content() != "{}" {
+		t.Errorf("Body = %q, want %q", written.Body, want)
+	}
+}